@@ -4,33 +4,35 @@ import . "github.com/deadsy/sdfx/sdf"
 
 func main() {
 
-	module := (5.0 / 8.0) / 20.0
-	pa := DtoR(20.0)
-	h := 0.15
-	number_teeth := 20
+	p := NewParams("gears")
+	number_teeth := p.Int("teeth", 20, "number of gear teeth")
+	module := p.Float("module", (5.0/8.0)/20.0, "gear module")
+	pa := p.Float("pa", 20.0, "pressure angle (degrees)")
+	h := p.Float("height", 0.15, "gear height")
+	p.MustParse()
 
 	gear_2d := InvoluteGear(
-		number_teeth, // number_teeth
-		module,       // gear_module
-		pa,           // pressure_angle
-		0.0,          // backlash
-		0.0,          // clearance
-		0.05,         // ring_width
-		7,            // facets
+		*number_teeth, // number_teeth
+		*module,       // gear_module
+		DtoR(*pa),     // pressure_angle
+		0.0,           // backlash
+		0.0,           // clearance
+		0.05,          // ring_width
+		7,             // facets
 	)
-	gear_3d := Extrude3D(gear_2d, h)
-	m := Rotate3d(V3{0, 0, 1}, DtoR(180.0/float64(number_teeth)))
+	gear_3d := Extrude3D(gear_2d, *h)
+	m := Rotate3d(V3{0, 0, 1}, DtoR(180.0/float64(*number_teeth)))
 	m = Translate3d(V3{0, 0.39, 0}).Mul(m)
 	gear_3d = Transform3D(gear_3d, m)
 
 	rack_2d := GearRack2D(
-		11,     // number_teeth
-		module, // gear_module
-		pa,     // pressure_angle
-		0.00,   // backlash
-		0.025,  // base_height
+		11,        // number_teeth
+		*module,   // gear_module
+		DtoR(*pa), // pressure_angle
+		0.00,      // backlash
+		0.025,     // base_height
 	)
-	rack_3d := Extrude3D(rack_2d, h)
+	rack_3d := Extrude3D(rack_2d, *h)
 
 	s := Union3D(rack_3d, gear_3d)
 	RenderSTL(s, 200, "gear.stl")
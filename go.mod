@@ -7,5 +7,6 @@ require (
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/llgcode/draw2d v0.0.0-20190810100245-79e59b6b8fbc
 	github.com/yofu/dxf v0.0.0-20190710012328-5a6d1e83f16c
+	go.starlark.net v0.0.0-20200306205701-8dd3e2ee1dd5
 	golang.org/x/image v0.0.0-20191009234506-e7c1f5e7dbb8
 )
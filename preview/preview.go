@@ -0,0 +1,418 @@
+//-----------------------------------------------------------------------------
+/*
+
+Preview Web Server
+
+Serve an SDF3 over HTTP with a WebGL viewer for a quick edit/preview loop.
+
+The viewer polls the server for its status. When the program is restarted
+(E.g. after an edit) the server instance id changes and the viewer reloads.
+Parametric models have a form in the viewer, changing the parameters
+rebuilds the model and the viewer loads the new mesh.
+
+*/
+//-----------------------------------------------------------------------------
+
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+// serveMeshCells is the number of cells on the longest axis of the preview mesh.
+const serveMeshCells = 100
+
+// previewParam is a named model parameter.
+type previewParam struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// previewStatus is the server status polled by the viewer.
+type previewStatus struct {
+	ID      string `json:"id"`      // server instance id
+	Version int    `json:"version"` // mesh version
+	Error   string `json:"error"`   // build error
+}
+
+// previewServer serves the preview of an SDF3.
+type previewServer struct {
+	lock      sync.Mutex // protects params, status and mesh
+	buildLock sync.Mutex // serializes rebuilds
+	build     func(map[string]float64) (sdf.SDF3, error)
+	changed   func() bool
+	params    []previewParam
+	status    previewStatus
+	mesh      []byte
+	meshLen   int
+}
+
+// newPreviewServer returns a preview server for a parametric model.
+func newPreviewServer(params map[string]float64, build func(map[string]float64) (sdf.SDF3, error)) *previewServer {
+	p := &previewServer{build: build, params: []previewParam{}}
+	for k, v := range params {
+		p.params = append(p.params, previewParam{k, v})
+	}
+	sort.Slice(p.params, func(i, j int) bool { return p.params[i].Name < p.params[j].Name })
+	p.status.ID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	p.rebuild()
+	return p
+}
+
+// render builds the model and meshes it as little endian float32 triangle vertices.
+func (p *previewServer) render(params map[string]float64) ([]byte, int, error) {
+	s, err := p.build(params)
+	if err == nil && s == nil {
+		err = errors.New("nil sdf")
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	mesh := sdf.RenderMesh(s, serveMeshCells)
+	v, _ := sdf.MeshArrays(mesh)
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, v)
+	return buf.Bytes(), len(mesh), nil
+}
+
+// rebuild renders the model with the current parameters and swaps in the new mesh.
+// The model is rendered without holding the lock, so the viewer isn't blocked.
+func (p *previewServer) rebuild() {
+	p.buildLock.Lock()
+	defer p.buildLock.Unlock()
+	p.lock.Lock()
+	params := make(map[string]float64)
+	for _, x := range p.params {
+		params[x.Name] = x.Value
+	}
+	p.lock.Unlock()
+	mesh, n, err := p.render(params)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.status.Version++
+	p.status.Error = ""
+	if err != nil {
+		p.status.Error = err.Error()
+		return
+	}
+	p.mesh = mesh
+	p.meshLen = n
+}
+
+// handler returns the HTTP handler for the preview server.
+func (p *previewServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, previewHTML)
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if p.changed != nil && p.changed() {
+			p.rebuild()
+		}
+		p.lock.Lock()
+		status := p.status
+		p.lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	})
+	mux.HandleFunc("/mesh", func(w http.ResponseWriter, r *http.Request) {
+		p.lock.Lock()
+		mesh := p.mesh
+		p.lock.Unlock()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(mesh)
+	})
+	mux.HandleFunc("/params", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if err := r.ParseForm(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			p.lock.Lock()
+			params := append([]previewParam(nil), p.params...)
+			p.lock.Unlock()
+			for i := range params {
+				s := r.Form.Get(params[i].Name)
+				if s == "" {
+					continue
+				}
+				v, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				params[i].Value = v
+			}
+			p.lock.Lock()
+			p.params = params
+			p.lock.Unlock()
+			p.rebuild()
+		}
+		p.lock.Lock()
+		params := append([]previewParam{}, p.params...)
+		p.lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(params)
+	})
+	return mux
+}
+
+//-----------------------------------------------------------------------------
+
+// Serve runs a local HTTP server with a WebGL preview of an SDF3. E.g. addr = "localhost:8000".
+// Serve doesn't return unless there is an error.
+func Serve(s sdf.SDF3, addr string) error {
+	return ServeParametric(addr, nil, func(map[string]float64) (sdf.SDF3, error) {
+		return s, nil
+	})
+}
+
+// ServeParametric runs a local HTTP server with a WebGL preview of a parametric model.
+// The viewer has a form to change the parameters, and the model is rebuilt when they change.
+func ServeParametric(
+	addr string, // server address, E.g. "localhost:8000"
+	params map[string]float64, // initial parameter values
+	build func(map[string]float64) (sdf.SDF3, error), // build the model from the parameters
+) error {
+	return ServeReload(addr, params, build, nil)
+}
+
+// ServeReload runs a local HTTP server with a WebGL preview of a parametric model.
+// The model is also rebuilt when changed() returns true, E.g. when a script file is edited.
+// changed is polled each time the viewer checks the server status.
+func ServeReload(
+	addr string, // server address, E.g. "localhost:8000"
+	params map[string]float64, // initial parameter values
+	build func(map[string]float64) (sdf.SDF3, error), // build the model from the parameters
+	changed func() bool, // has the model source changed?
+) error {
+	p := newPreviewServer(params, build)
+	p.changed = changed
+	fmt.Printf("serving preview at http://%s (%d triangles)\n", addr, p.meshLen)
+	return http.ListenAndServe(addr, p.handler())
+}
+
+//-----------------------------------------------------------------------------
+
+// previewHTML is the WebGL viewer.
+const previewHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>sdfx preview</title>
+<style>
+body { margin: 0; font-family: sans-serif; overflow: hidden; }
+canvas { display: block; width: 100vw; height: 100vh; }
+#panel { position: absolute; top: 8px; left: 8px; background: rgba(255,255,255,0.8); padding: 8px; }
+#panel input { width: 6em; }
+#error { color: red; }
+</style>
+</head>
+<body>
+<canvas id="view"></canvas>
+<div id="panel">
+<form id="params"></form>
+<div id="error"></div>
+</div>
+<script>
+"use strict";
+const canvas = document.getElementById("view");
+const gl = canvas.getContext("webgl");
+
+function mul(a, b) {
+  const r = new Float32Array(16);
+  for (let i = 0; i < 4; i++) {
+    for (let j = 0; j < 4; j++) {
+      let s = 0;
+      for (let k = 0; k < 4; k++) s += a[k * 4 + j] * b[i * 4 + k];
+      r[i * 4 + j] = s;
+    }
+  }
+  return r;
+}
+function perspective(fov, aspect, n, f) {
+  const t = 1 / Math.tan(fov / 2);
+  return new Float32Array([t / aspect, 0, 0, 0, 0, t, 0, 0, 0, 0, (f + n) / (n - f), -1, 0, 0, 2 * f * n / (n - f), 0]);
+}
+function translate(x, y, z) {
+  return new Float32Array([1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, x, y, z, 1]);
+}
+function scale(k) {
+  return new Float32Array([k, 0, 0, 0, 0, k, 0, 0, 0, 0, k, 0, 0, 0, 0, 1]);
+}
+function rotateX(a) {
+  const c = Math.cos(a), s = Math.sin(a);
+  return new Float32Array([1, 0, 0, 0, 0, c, s, 0, 0, -s, c, 0, 0, 0, 0, 1]);
+}
+function rotateZ(a) {
+  const c = Math.cos(a), s = Math.sin(a);
+  return new Float32Array([c, s, 0, 0, -s, c, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1]);
+}
+
+function shader(type, src) {
+  const s = gl.createShader(type);
+  gl.shaderSource(s, src);
+  gl.compileShader(s);
+  return s;
+}
+const prog = gl.createProgram();
+gl.attachShader(prog, shader(gl.VERTEX_SHADER,
+  "attribute vec3 p; attribute vec3 n; uniform mat4 mvp; uniform mat4 rot; varying float l;" +
+  "void main() { gl_Position = mvp * vec4(p, 1.0);" +
+  " vec3 m = normalize((rot * vec4(n, 0.0)).xyz);" +
+  " l = 0.3 + 0.7 * abs(dot(m, normalize(vec3(0.3, 0.5, 1.0)))); }"));
+gl.attachShader(prog, shader(gl.FRAGMENT_SHADER,
+  "precision mediump float; varying float l;" +
+  "void main() { gl_FragColor = vec4(vec3(0.4, 0.6, 0.9) * l, 1.0); }"));
+gl.linkProgram(prog);
+gl.useProgram(prog);
+const locP = gl.getAttribLocation(prog, "p");
+const locN = gl.getAttribLocation(prog, "n");
+const locMVP = gl.getUniformLocation(prog, "mvp");
+const locRot = gl.getUniformLocation(prog, "rot");
+const posBuf = gl.createBuffer();
+const nrmBuf = gl.createBuffer();
+
+let count = 0, center = [0, 0, 0], size = 1;
+let yaw = 0.5, pitch = -1.0, dist = 2.5;
+
+function loadMesh() {
+  fetch("/mesh").then(r => r.arrayBuffer()).then(buf => {
+    const v = new Float32Array(buf);
+    const n = new Float32Array(v.length);
+    const lo = [Infinity, Infinity, Infinity], hi = [-Infinity, -Infinity, -Infinity];
+    for (let i = 0; i < v.length; i += 9) {
+      const ax = v[i + 3] - v[i], ay = v[i + 4] - v[i + 1], az = v[i + 5] - v[i + 2];
+      const bx = v[i + 6] - v[i], by = v[i + 7] - v[i + 1], bz = v[i + 8] - v[i + 2];
+      const nx = ay * bz - az * by, ny = az * bx - ax * bz, nz = ax * by - ay * bx;
+      for (let j = 0; j < 9; j += 3) {
+        n[i + j] = nx; n[i + j + 1] = ny; n[i + j + 2] = nz;
+        for (let k = 0; k < 3; k++) {
+          lo[k] = Math.min(lo[k], v[i + j + k]);
+          hi[k] = Math.max(hi[k], v[i + j + k]);
+        }
+      }
+    }
+    count = v.length / 3;
+    if (count > 0) {
+      center = [0, 1, 2].map(k => 0.5 * (lo[k] + hi[k]));
+      size = Math.max(hi[0] - lo[0], hi[1] - lo[1], hi[2] - lo[2]);
+    }
+    gl.bindBuffer(gl.ARRAY_BUFFER, posBuf);
+    gl.bufferData(gl.ARRAY_BUFFER, v, gl.STATIC_DRAW);
+    gl.bindBuffer(gl.ARRAY_BUFFER, nrmBuf);
+    gl.bufferData(gl.ARRAY_BUFFER, n, gl.STATIC_DRAW);
+    draw();
+  });
+}
+
+function draw() {
+  canvas.width = canvas.clientWidth;
+  canvas.height = canvas.clientHeight;
+  gl.viewport(0, 0, canvas.width, canvas.height);
+  gl.clearColor(0.95, 0.95, 0.95, 1);
+  gl.enable(gl.DEPTH_TEST);
+  gl.clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT);
+  if (count == 0) return;
+  const rot = mul(rotateX(pitch), rotateZ(yaw));
+  let m = mul(scale(1 / size), translate(-center[0], -center[1], -center[2]));
+  m = mul(translate(0, 0, -dist), mul(rot, m));
+  const mvp = mul(perspective(0.8, canvas.width / canvas.height, 0.01, 100), m);
+  gl.uniformMatrix4fv(locMVP, false, mvp);
+  gl.uniformMatrix4fv(locRot, false, rot);
+  gl.bindBuffer(gl.ARRAY_BUFFER, posBuf);
+  gl.enableVertexAttribArray(locP);
+  gl.vertexAttribPointer(locP, 3, gl.FLOAT, false, 0, 0);
+  gl.bindBuffer(gl.ARRAY_BUFFER, nrmBuf);
+  gl.enableVertexAttribArray(locN);
+  gl.vertexAttribPointer(locN, 3, gl.FLOAT, false, 0, 0);
+  gl.drawArrays(gl.TRIANGLES, 0, count);
+}
+
+// mouse drag to rotate, wheel to zoom
+let drag = null;
+canvas.onmousedown = e => { drag = [e.clientX, e.clientY]; };
+window.onmouseup = () => { drag = null; };
+window.onmousemove = e => {
+  if (!drag) return;
+  yaw += 0.01 * (e.clientX - drag[0]);
+  pitch += 0.01 * (e.clientY - drag[1]);
+  drag = [e.clientX, e.clientY];
+  draw();
+};
+canvas.onwheel = e => {
+  e.preventDefault();
+  dist *= Math.exp(0.001 * e.deltaY);
+  draw();
+};
+window.onresize = draw;
+
+// parameter form
+const form = document.getElementById("params");
+function showParams(params) {
+  form.innerHTML = "";
+  if (params.length == 0) return;
+  for (const x of params) {
+    const label = document.createElement("label");
+    label.textContent = x.name + " ";
+    const input = document.createElement("input");
+    input.name = x.name;
+    input.value = x.value;
+    label.appendChild(input);
+    form.appendChild(label);
+    form.appendChild(document.createElement("br"));
+  }
+  const button = document.createElement("input");
+  button.type = "submit";
+  button.value = "update";
+  form.appendChild(button);
+}
+form.onsubmit = e => {
+  e.preventDefault();
+  fetch("/params", { method: "POST", body: new URLSearchParams(new FormData(form)) })
+    .then(r => r.json()).then(showParams);
+};
+fetch("/params").then(r => r.json()).then(showParams);
+
+// poll the server status
+let id = null, version = null;
+function poll() {
+  fetch("/status").then(r => r.json()).then(s => {
+    if (id != null && s.id != id) {
+      // the program was restarted
+      location.reload();
+      return;
+    }
+    id = s.id;
+    document.getElementById("error").textContent = s.error;
+    if (s.version != version) {
+      version = s.version;
+      loadMesh();
+    }
+  }).catch(() => {}).finally(() => setTimeout(poll, 1000));
+}
+poll();
+</script>
+</body>
+</html>
+`
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,111 @@
+//-----------------------------------------------------------------------------
+/*
+
+Preview Server Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package preview
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Serve(t *testing.T) {
+	build := func(p map[string]float64) (sdf.SDF3, error) {
+		if p["radius"] <= 0 {
+			return nil, fmt.Errorf("bad radius")
+		}
+		return sdf.Sphere3D(p["radius"]), nil
+	}
+	h := newPreviewServer(map[string]float64{"radius": 1}, build).handler()
+	get := func(method, url string, body string) string {
+		r := httptest.NewRequest(method, url, strings.NewReader(body))
+		if body != "" {
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	if !strings.Contains(get("GET", "/", ""), "webgl") {
+		t.Error("FAIL")
+	}
+	// the mesh is a whole number of float32 triangles
+	mesh := get("GET", "/mesh", "")
+	if len(mesh) == 0 || len(mesh)%36 != 0 {
+		t.Error("FAIL")
+	}
+	// changing a parameter rebuilds the model
+	if !strings.Contains(get("POST", "/params", "radius=2"), `"value":2`) {
+		t.Error("FAIL")
+	}
+	if get("GET", "/mesh", "") == mesh {
+		t.Error("FAIL")
+	}
+	if !strings.Contains(get("GET", "/status", ""), `"version":2`) {
+		t.Error("FAIL")
+	}
+	// build errors are reported
+	get("POST", "/params", "radius=-1")
+	if !strings.Contains(get("GET", "/status", ""), "bad radius") {
+		t.Error("FAIL")
+	}
+}
+
+func Test_ServeReload(t *testing.T) {
+	// the reloaded build blocks until released
+	entered := make(chan bool)
+	release := make(chan bool)
+	reload := false
+	build := func(p map[string]float64) (sdf.SDF3, error) {
+		if reload {
+			entered <- true
+			<-release
+		}
+		return sdf.Sphere3D(1), nil
+	}
+	p := newPreviewServer(nil, build)
+	p.changed = func() bool {
+		if reload {
+			return false
+		}
+		reload = true
+		return true
+	}
+	h := p.handler()
+	get := func(url string) string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		return w.Body.String()
+	}
+	done := make(chan string)
+	go func() { done <- get("/status") }()
+	<-entered
+	// the viewer isn't blocked while the model is rebuilt
+	mesh := make(chan string)
+	go func() { mesh <- get("/mesh") }()
+	select {
+	case m := <-mesh:
+		if len(m) == 0 {
+			t.Error("FAIL")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("FAIL")
+	}
+	close(release)
+	if !strings.Contains(<-done, `"version":2`) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,539 @@
+//-----------------------------------------------------------------------------
+/*
+
+Starlark Scripting
+
+Models can be written as Starlark (a Python dialect) scripts and rebuilt at
+runtime without recompiling. The script assigns the final shape to a global
+variable named "model". Model parameters are declared with param() and can be
+overridden by the caller.
+
+Example:
+
+r = param("radius", 10)
+body = difference(box(2 * r, 2 * r, 2 * r, 1), sphere(1.2 * r))
+model = union(body, translate(cylinder(4 * r, 0.3 * r), 0, 0, r))
+
+Builtins:
+
+3d shapes: sphere(r), box(x, y, z, round=0), cylinder(h, r, round=0), cone(h, r0, r1, round=0)
+2d shapes: circle(r), rect(x, y, round=0), polygon([(x, y), ...])
+2d to 3d: extrude(s, h), revolve(s)
+booleans: union(s, ...), difference(a, b), intersect(a, b)
+transforms: translate(s, x, y, z=0), rotate(s, angle, x=0, y=0, z=1), scale(s, k)
+exports: save_stl(s, path, cells=200), save_dxf(s, path, cells=200), save_svg(s, path, cells=200)
+parameters: param(name, default)
+
+Angles are in degrees. The 2d rotate ignores the axis.
+
+*/
+//-----------------------------------------------------------------------------
+
+package script
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/deadsy/sdfx/sdf"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+)
+
+//-----------------------------------------------------------------------------
+
+func init() {
+	// models need floats, and loops at the top level are handy for patterns
+	resolve.AllowFloat = true
+	resolve.AllowLambda = true
+	resolve.AllowNestedDef = true
+	resolve.AllowGlobalReassign = true
+}
+
+//-----------------------------------------------------------------------------
+// Shape Values
+
+// shape is a Starlark value holding an SDF2 or an SDF3.
+type shape struct {
+	s2 sdf.SDF2
+	s3 sdf.SDF3
+}
+
+func (s *shape) String() string {
+	return "<" + s.Type() + ">"
+}
+
+func (s *shape) Type() string {
+	if s.s3 != nil {
+		return "sdf3"
+	}
+	return "sdf2"
+}
+
+func (s *shape) Freeze()               {}
+func (s *shape) Truth() starlark.Bool  { return starlark.True }
+func (s *shape) Hash() (uint32, error) { return 0, errors.New("unhashable type: " + s.Type()) }
+
+// toFloat converts a Starlark int or float to a float64.
+func toFloat(fn, name string, v starlark.Value) (float64, error) {
+	if v == nil {
+		return 0, nil
+	}
+	if x, ok := starlark.AsFloat(v); ok {
+		return x, nil
+	}
+	return 0, fmt.Errorf("%s: %s: got %s, want number", fn, name, v.Type())
+}
+
+// floatArgs unpacks numeric arguments. Optional names have a "?" suffix and default to 0.
+func floatArgs(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, names ...string) ([]float64, error) {
+	v := make([]starlark.Value, len(names))
+	pairs := make([]interface{}, 2*len(names))
+	for i := range names {
+		pairs[2*i] = names[i]
+		pairs[2*i+1] = &v[i]
+	}
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, pairs...)
+	if err != nil {
+		return nil, err
+	}
+	x := make([]float64, len(names))
+	for i := range v {
+		x[i], err = toFloat(b.Name(), names[i], v[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// shapeArg returns the shape for an argument.
+func shapeArg(fn string, v starlark.Value) (*shape, error) {
+	if s, ok := v.(*shape); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("%s: got %s, want sdf2 or sdf3", fn, v.Type())
+}
+
+// shapeFloatArgs unpacks a shape followed by numeric arguments.
+func shapeFloatArgs(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, names ...string) (*shape, []float64, error) {
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("%s: missing shape argument", b.Name())
+	}
+	s, err := shapeArg(b.Name(), args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	x, err := floatArgs(b, args[1:], kwargs, names...)
+	return s, x, err
+}
+
+//-----------------------------------------------------------------------------
+// Builtins
+
+func sphere(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := floatArgs(b, args, kwargs, "r")
+	if err != nil {
+		return nil, err
+	}
+	return &shape{s3: sdf.Sphere3D(x[0])}, nil
+}
+
+func box(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := floatArgs(b, args, kwargs, "x", "y", "z", "round?")
+	if err != nil {
+		return nil, err
+	}
+	return &shape{s3: sdf.Box3D(sdf.V3{X: x[0], Y: x[1], Z: x[2]}, x[3])}, nil
+}
+
+func cylinder(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := floatArgs(b, args, kwargs, "h", "r", "round?")
+	if err != nil {
+		return nil, err
+	}
+	return &shape{s3: sdf.Cylinder3D(x[0], x[1], x[2])}, nil
+}
+
+func cone(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := floatArgs(b, args, kwargs, "h", "r0", "r1", "round?")
+	if err != nil {
+		return nil, err
+	}
+	return &shape{s3: sdf.Cone3D(x[0], x[1], x[2], x[3])}, nil
+}
+
+func circle(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := floatArgs(b, args, kwargs, "r")
+	if err != nil {
+		return nil, err
+	}
+	return &shape{s2: sdf.Circle2D(x[0])}, nil
+}
+
+func rect(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := floatArgs(b, args, kwargs, "x", "y", "round?")
+	if err != nil {
+		return nil, err
+	}
+	return &shape{s2: sdf.Box2D(sdf.V2{X: x[0], Y: x[1]}, x[2])}, nil
+}
+
+func polygon(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var points starlark.Iterable
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "points", &points)
+	if err != nil {
+		return nil, err
+	}
+	var v []sdf.V2
+	it := points.Iterate()
+	defer it.Done()
+	var p starlark.Value
+	for it.Next(&p) {
+		t, ok := p.(starlark.Indexable)
+		if !ok || t.Len() != 2 {
+			return nil, fmt.Errorf("%s: points must be (x, y) pairs", b.Name())
+		}
+		x, err := toFloat(b.Name(), "x", t.Index(0))
+		if err != nil {
+			return nil, err
+		}
+		y, err := toFloat(b.Name(), "y", t.Index(1))
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, sdf.V2{X: x, Y: y})
+	}
+	if len(v) < 3 {
+		return nil, fmt.Errorf("%s: need at least 3 points", b.Name())
+	}
+	return &shape{s2: sdf.Polygon2D(v)}, nil
+}
+
+func extrude(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s, x, err := shapeFloatArgs(b, args, kwargs, "h")
+	if err != nil {
+		return nil, err
+	}
+	if s.s2 == nil {
+		return nil, fmt.Errorf("%s: want sdf2", b.Name())
+	}
+	return &shape{s3: sdf.Extrude3D(s.s2, x[0])}, nil
+}
+
+func revolve(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s, _, err := shapeFloatArgs(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if s.s2 == nil {
+		return nil, fmt.Errorf("%s: want sdf2", b.Name())
+	}
+	return &shape{s3: sdf.Revolve3D(s.s2)}, nil
+}
+
+func union(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(kwargs) != 0 || len(args) == 0 {
+		return nil, fmt.Errorf("%s: want one or more shapes", b.Name())
+	}
+	var s2 []sdf.SDF2
+	var s3 []sdf.SDF3
+	for _, a := range args {
+		s, err := shapeArg(b.Name(), a)
+		if err != nil {
+			return nil, err
+		}
+		if s.s3 != nil {
+			s3 = append(s3, s.s3)
+		} else {
+			s2 = append(s2, s.s2)
+		}
+	}
+	if len(s2) != 0 && len(s3) != 0 {
+		return nil, fmt.Errorf("%s: can't mix sdf2 and sdf3", b.Name())
+	}
+	if len(s3) != 0 {
+		return &shape{s3: sdf.Union3D(s3...)}, nil
+	}
+	return &shape{s2: sdf.Union2D(s2...)}, nil
+}
+
+// shapePair unpacks 2 shapes of the same type.
+func shapePair(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (*shape, *shape, error) {
+	var v0, v1 starlark.Value
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "a", &v0, "b", &v1)
+	if err != nil {
+		return nil, nil, err
+	}
+	s0, err := shapeArg(b.Name(), v0)
+	if err != nil {
+		return nil, nil, err
+	}
+	s1, err := shapeArg(b.Name(), v1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s0.Type() != s1.Type() {
+		return nil, nil, fmt.Errorf("%s: can't mix sdf2 and sdf3", b.Name())
+	}
+	return s0, s1, nil
+}
+
+func difference(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s0, s1, err := shapePair(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if s0.s3 != nil {
+		return &shape{s3: sdf.Difference3D(s0.s3, s1.s3)}, nil
+	}
+	return &shape{s2: sdf.Difference2D(s0.s2, s1.s2)}, nil
+}
+
+func intersect(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s0, s1, err := shapePair(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if s0.s3 == nil {
+		return nil, fmt.Errorf("%s: want sdf3", b.Name())
+	}
+	return &shape{s3: sdf.Intersect3D(s0.s3, s1.s3)}, nil
+}
+
+func translate(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s, x, err := shapeFloatArgs(b, args, kwargs, "x", "y", "z?")
+	if err != nil {
+		return nil, err
+	}
+	if s.s3 != nil {
+		return &shape{s3: sdf.Transform3D(s.s3, sdf.Translate3d(sdf.V3{X: x[0], Y: x[1], Z: x[2]}))}, nil
+	}
+	return &shape{s2: sdf.Transform2D(s.s2, sdf.Translate2d(sdf.V2{X: x[0], Y: x[1]}))}, nil
+}
+
+func rotate(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s, x, err := shapeFloatArgs(b, args, kwargs, "angle", "x?", "y?", "z?")
+	if err != nil {
+		return nil, err
+	}
+	a := sdf.DtoR(x[0])
+	if s.s2 != nil {
+		return &shape{s2: sdf.Transform2D(s.s2, sdf.Rotate2d(a))}, nil
+	}
+	axis := sdf.V3{X: x[1], Y: x[2], Z: x[3]}
+	if axis.Length() == 0 {
+		axis = sdf.V3{X: 0, Y: 0, Z: 1}
+	}
+	return &shape{s3: sdf.Transform3D(s.s3, sdf.Rotate3d(axis, a))}, nil
+}
+
+func scale(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s, x, err := shapeFloatArgs(b, args, kwargs, "k")
+	if err != nil {
+		return nil, err
+	}
+	if x[0] <= 0 {
+		return nil, fmt.Errorf("%s: k <= 0", b.Name())
+	}
+	if s.s3 != nil {
+		return &shape{s3: sdf.ScaleUniform3D(s.s3, x[0])}, nil
+	}
+	return &shape{s2: sdf.ScaleUniform2D(s.s2, x[0])}, nil
+}
+
+// exportArgs unpacks the arguments for an export.
+func exportArgs(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (*shape, string, int, error) {
+	var v starlark.Value
+	var path string
+	cells := 200
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "s", &v, "path", &path, "cells?", &cells)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	s, err := shapeArg(b.Name(), v)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if cells <= 0 {
+		return nil, "", 0, fmt.Errorf("%s: cells <= 0", b.Name())
+	}
+	return s, path, cells, nil
+}
+
+func saveSTL(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s, path, cells, err := exportArgs(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if s.s3 == nil {
+		return nil, fmt.Errorf("%s: want sdf3", b.Name())
+	}
+	return starlark.None, sdf.SaveSTL(path, sdf.RenderMesh(s.s3, cells))
+}
+
+func saveDXF(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s, path, cells, err := exportArgs(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if s.s2 == nil {
+		return nil, fmt.Errorf("%s: want sdf2", b.Name())
+	}
+	sdf.RenderDXF(s.s2, cells, path)
+	return starlark.None, nil
+}
+
+func saveSVG(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s, path, cells, err := exportArgs(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if s.s2 == nil {
+		return nil, fmt.Errorf("%s: want sdf2", b.Name())
+	}
+	return starlark.None, sdf.RenderSVG(s.s2, cells, path, "fill:none;stroke:black;stroke-width:0.1")
+}
+
+// builtins returns the predeclared names for a script run with a set of parameters.
+// The values used by param() are recorded in used.
+func builtins(params, used map[string]float64) starlark.StringDict {
+	param := func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name string
+		var def starlark.Value
+		err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "default", &def)
+		if err != nil {
+			return nil, err
+		}
+		x, err := toFloat(b.Name(), name, def)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := params[name]; ok {
+			x = v
+		}
+		used[name] = x
+		return starlark.Float(x), nil
+	}
+	fns := map[string]func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error){
+		"sphere":     sphere,
+		"box":        box,
+		"cylinder":   cylinder,
+		"cone":       cone,
+		"circle":     circle,
+		"rect":       rect,
+		"polygon":    polygon,
+		"extrude":    extrude,
+		"revolve":    revolve,
+		"union":      union,
+		"difference": difference,
+		"intersect":  intersect,
+		"translate":  translate,
+		"rotate":     rotate,
+		"scale":      scale,
+		"save_stl":   saveSTL,
+		"save_dxf":   saveDXF,
+		"save_svg":   saveSVG,
+		"param":      param,
+	}
+	d := make(starlark.StringDict)
+	for k, fn := range fns {
+		d[k] = starlark.NewBuiltin(k, fn)
+	}
+	return d
+}
+
+//-----------------------------------------------------------------------------
+
+// Exec runs a script and returns the model and the parameter values it used.
+// src may be a string, []byte or nil (read from the filename).
+func Exec(filename string, src interface{}, params map[string]float64) (sdf.SDF3, map[string]float64, error) {
+	used := make(map[string]float64)
+	thread := &starlark.Thread{
+		Name:  filename,
+		Print: func(_ *starlark.Thread, msg string) { fmt.Println(msg) },
+	}
+	globals, err := starlark.ExecFile(thread, filename, src, builtins(params, used))
+	if err != nil {
+		if e, ok := err.(*starlark.EvalError); ok {
+			return nil, nil, errors.New(e.Backtrace())
+		}
+		return nil, nil, err
+	}
+	v, ok := globals["model"]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: no model defined", filename)
+	}
+	s, ok := v.(*shape)
+	if !ok || s.s3 == nil {
+		return nil, nil, fmt.Errorf("%s: model is %s, want sdf3", filename, v.Type())
+	}
+	return s.s3, used, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// Script is a model script file that is reloaded when it changes.
+type Script struct {
+	path    string
+	lock    sync.Mutex
+	modTime time.Time
+	src     []byte
+}
+
+// Load reads a script file.
+func Load(path string) (*Script, error) {
+	s := &Script{path: path}
+	_, err := s.reload()
+	return s, err
+}
+
+// reload reads the script file if it has changed, and reports if it was read.
+func (s *Script) reload() (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+	if s.src != nil && info.ModTime().Equal(s.modTime) {
+		return false, nil
+	}
+	src, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	s.src = src
+	s.modTime = info.ModTime()
+	return true, nil
+}
+
+// Changed reads the script file if it has changed, and reports if it was read.
+func (s *Script) Changed() bool {
+	changed, _ := s.reload()
+	return changed
+}
+
+// Build runs the current version of the script with a set of parameters.
+func (s *Script) Build(params map[string]float64) (sdf.SDF3, error) {
+	s.lock.Lock()
+	src := s.src
+	s.lock.Unlock()
+	model, _, err := Exec(s.path, src, params)
+	return model, err
+}
+
+// Params runs the script and returns the parameters it declares with their default values.
+func (s *Script) Params() (map[string]float64, error) {
+	s.lock.Lock()
+	src := s.src
+	s.lock.Unlock()
+	_, used, err := Exec(s.path, src, nil)
+	return used, err
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,60 @@
+//-----------------------------------------------------------------------------
+/*
+
+Script Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package script
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Exec(t *testing.T) {
+	src := `
+r = param("radius", 10)
+body = difference(box(2 * r, 2 * r, 2 * r), sphere(1.2 * r))
+model = union(body, translate(extrude(circle(1), 4), 0, 0, r))
+`
+	s, used, err := Exec("test.star", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used["radius"] != 10 {
+		t.Error("FAIL")
+	}
+	// corners are solid, the center is hollow
+	if s.Evaluate(sdf.V3{X: 9.5, Y: 9.5, Z: 9.5}) >= 0 || s.Evaluate(sdf.V3{}) <= 0 {
+		t.Error("FAIL")
+	}
+	// parameter override
+	s, used, err = Exec("test.star", src, map[string]float64{"radius": 5})
+	if err != nil || used["radius"] != 5 {
+		t.Error("FAIL")
+	}
+	if !sdf.EqualFloat64(s.BoundingBox().Max.X, 5, 1e-9) {
+		t.Error("FAIL")
+	}
+	// errors
+	_, _, err = Exec("test.star", "x = 1", nil)
+	if err == nil || !strings.Contains(err.Error(), "no model") {
+		t.Error("FAIL")
+	}
+	_, _, err = Exec("test.star", "model = union(sphere(1), circle(1))", nil)
+	if err == nil || !strings.Contains(err.Error(), "can't mix") {
+		t.Error("FAIL")
+	}
+	_, _, err = Exec("test.star", "model = circle(1)", nil)
+	if err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
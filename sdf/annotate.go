@@ -0,0 +1,226 @@
+//-----------------------------------------------------------------------------
+/*
+
+Dimension Annotations
+
+Draw measured dimensions over a rendered image, so generated documentation
+shows the numbers the code computed.
+
+Anchors (points) and circles are named, dimensions refer to them by name:
+
+a := NewAnnotations()
+a.Anchor("left", V2{-20, 0})
+a.Anchor("right", V2{20, 0})
+a.Circle("bore", V2{0, 0}, 4)
+a.Distance("left", "right", -8)
+a.Diameter("bore")
+png.Annotate(a)
+
+Positions and offsets are in model units, arrow and text sizes are in pixels.
+The labels use a built-in bitmap font, so no font files are needed.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/llgcode/draw2d/draw2dimg"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+//-----------------------------------------------------------------------------
+
+// dimensionKind is the type of a dimension annotation.
+type dimensionKind int
+
+const (
+	linearDimension dimensionKind = iota
+	diameterDimension
+)
+
+// dimension is a dimension annotation.
+type dimension struct {
+	kind   dimensionKind
+	p0, p1 V2      // end points (linear), p0 is the center (diameter)
+	r      float64 // circle radius
+	offset float64 // offset of the dimension line from the anchors
+	label  string
+}
+
+// annotationCircle is a named circle.
+type annotationCircle struct {
+	c V2      // center
+	r float64 // radius
+}
+
+// Annotations is a set of dimension annotations.
+type Annotations struct {
+	Format string      // number format for the labels (default "%.2f")
+	Color  color.Color // color of the annotations (default dark blue)
+	anchor map[string]V2
+	circle map[string]annotationCircle
+	dims   []dimension
+}
+
+// NewAnnotations returns an empty set of dimension annotations.
+func NewAnnotations() *Annotations {
+	return &Annotations{
+		Format: "%.2f",
+		Color:  color.RGBA{0, 0, 0x80, 0xff},
+		anchor: make(map[string]V2),
+		circle: make(map[string]annotationCircle),
+	}
+}
+
+// Anchor adds a named anchor point.
+func (a *Annotations) Anchor(name string, p V2) {
+	a.anchor[name] = p
+}
+
+// Circle adds a named circle.
+func (a *Annotations) Circle(name string, c V2, r float64) {
+	a.circle[name] = annotationCircle{c, r}
+}
+
+// Distance adds a dimension for the distance between two named anchors. The dimension line is
+// offset from the anchors (positive to the left looking from the first anchor to the second).
+func (a *Annotations) Distance(name0, name1 string, offset float64) error {
+	p0, ok := a.anchor[name0]
+	if !ok {
+		return fmt.Errorf("anchor \"%s\" not found", name0)
+	}
+	p1, ok := a.anchor[name1]
+	if !ok {
+		return fmt.Errorf("anchor \"%s\" not found", name1)
+	}
+	if p0.Equals(p1, tolerance) {
+		return fmt.Errorf("anchors \"%s\" and \"%s\" are coincident", name0, name1)
+	}
+	a.dims = append(a.dims, dimension{
+		kind:   linearDimension,
+		p0:     p0,
+		p1:     p1,
+		offset: offset,
+		label:  fmt.Sprintf(a.Format, p1.Sub(p0).Length()),
+	})
+	return nil
+}
+
+// Diameter adds a dimension for the diameter of a named circle.
+func (a *Annotations) Diameter(name string) error {
+	c, ok := a.circle[name]
+	if !ok {
+		return fmt.Errorf("circle \"%s\" not found", name)
+	}
+	a.dims = append(a.dims, dimension{
+		kind:  diameterDimension,
+		p0:    c.c,
+		r:     c.r,
+		label: "Ø" + fmt.Sprintf(a.Format, 2*c.r),
+	})
+	return nil
+}
+
+// Labels returns the labels of the dimensions.
+func (a *Annotations) Labels() []string {
+	s := make([]string, len(a.dims))
+	for i, d := range a.dims {
+		s[i] = d.label
+	}
+	return s
+}
+
+//-----------------------------------------------------------------------------
+
+const arrowSize = 8 // arrow head length in pixels
+
+// Draw draws the annotations on an image, using a map from model to pixel coordinates.
+func (a *Annotations) Draw(img *image.RGBA, m *Map2) {
+	gc := draw2dimg.NewGraphicContext(img)
+	gc.SetFillColor(a.Color)
+	gc.SetStrokeColor(a.Color)
+	gc.SetLineWidth(1)
+
+	pixel := func(p V2) V2 {
+		return m.ToV2i(p).ToV2()
+	}
+	line := func(p0, p1 V2) {
+		q0, q1 := pixel(p0), pixel(p1)
+		gc.MoveTo(q0.X, q0.Y)
+		gc.LineTo(q1.X, q1.Y)
+		gc.Stroke()
+	}
+	// arrow head at p pointing along u (in pixel coordinates)
+	arrow := func(p, u V2) {
+		u = u.Normalize()
+		n := V2{-u.Y, u.X}
+		b := p.Sub(u.MulScalar(arrowSize))
+		q0 := b.Add(n.MulScalar(0.3 * arrowSize))
+		q1 := b.Sub(n.MulScalar(0.3 * arrowSize))
+		gc.MoveTo(p.X, p.Y)
+		gc.LineTo(q0.X, q0.Y)
+		gc.LineTo(q1.X, q1.Y)
+		gc.Close()
+		gc.Fill()
+	}
+
+	for _, d := range a.dims {
+		switch d.kind {
+		case linearDimension:
+			u := d.p1.Sub(d.p0).Normalize()
+			n := V2{-u.Y, u.X}.MulScalar(d.offset)
+			q0, q1 := d.p0.Add(n), d.p1.Add(n)
+			// extension lines
+			if d.offset != 0 {
+				line(d.p0, q0)
+				line(d.p1, q1)
+			}
+			// dimension line
+			line(q0, q1)
+			v0, v1 := pixel(q0), pixel(q1)
+			arrow(v0, v0.Sub(v1))
+			arrow(v1, v1.Sub(v0))
+			a.label(img, v0.Add(v1).MulScalar(0.5), d.label)
+		case diameterDimension:
+			// across the circle at 45 degrees
+			u := V2{1, 1}.Normalize().MulScalar(d.r)
+			q0, q1 := d.p0.Sub(u), d.p0.Add(u)
+			line(q0, q1)
+			v0, v1 := pixel(q0), pixel(q1)
+			arrow(v0, v0.Sub(v1))
+			arrow(v1, v1.Sub(v0))
+			a.label(img, v1.Add(V2{arrowSize, -arrowSize}), d.label)
+		}
+	}
+}
+
+// label draws a text label centered on a pixel position, over a white background.
+func (a *Annotations) label(img *image.RGBA, p V2, s string) {
+	fd := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(a.Color),
+		Face: basicfont.Face7x13,
+	}
+	w := fd.MeasureString(s).Round()
+	h := basicfont.Face7x13.Height
+	x := int(p.X) - w/2
+	y := int(p.Y) - h/2
+	draw.Draw(img, image.Rect(x-1, y, x+w+1, y+h), image.White, image.Point{}, draw.Src)
+	fd.Dot = fixed.P(x, y+basicfont.Face7x13.Ascent)
+	fd.DrawString(s)
+}
+
+// Annotate draws a set of dimension annotations on a png object.
+func (d *PNG) Annotate(a *Annotations) {
+	a.Draw(d.img, d.m)
+}
+
+//-----------------------------------------------------------------------------
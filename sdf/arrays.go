@@ -0,0 +1,42 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mesh Arrays
+
+Render an SDF3 to flat float32 arrays of vertices and normals. These map
+directly onto typed arrays and GPU vertex buffers, e.g. for WebGL viewers and
+the WebAssembly bindings.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+//-----------------------------------------------------------------------------
+
+// RenderMesh renders an SDF3 to a triangle mesh in memory.
+func RenderMesh(
+	s SDF3, // sdf3 to render
+	meshCells int, // number of cells on the longest axis. e.g 200
+) []*Triangle3 {
+	bb := s.BoundingBox()
+	step := bb.Size().MaxComponent() / float64(meshCells)
+	return marchingCubes(s, bb.ScaleAboutCenter(1.05), step)
+}
+
+// MeshArrays returns the vertices and normals of a triangle mesh as flat float32 arrays.
+// Each triangle has 3 vertices of x,y,z and the triangle normal is repeated for each vertex.
+func MeshArrays(mesh []*Triangle3) (vertices, normals []float32) {
+	vertices = make([]float32, 0, 9*len(mesh))
+	normals = make([]float32, 0, 9*len(mesh))
+	for _, t := range mesh {
+		n := t.Normal()
+		for _, v := range t.V {
+			vertices = append(vertices, float32(v.X), float32(v.Y), float32(v.Z))
+			normals = append(normals, float32(n.X), float32(n.Y), float32(n.Z))
+		}
+	}
+	return vertices, normals
+}
+
+//-----------------------------------------------------------------------------
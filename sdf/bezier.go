@@ -10,7 +10,7 @@ package sdf
 
 import (
 	"fmt"
-	"math/rand"
+	"math"
 )
 
 //-----------------------------------------------------------------------------
@@ -157,6 +157,9 @@ func (s *BezierSpline) f0(t float64) V2 {
 	return V2{s.px.f0(t), s.py.f0(t)}
 }
 
+// goldenRatio is used to generate a low discrepancy sequence.
+const goldenRatio = 1.618033988749895
+
 // Sample generates polygon samples for a bezier spline.
 func (s *BezierSpline) Sample(p *Polygon, t0, t1 float64, p0, p1 V2, n int) {
 
@@ -166,7 +169,9 @@ func (s *BezierSpline) Sample(p *Polygon, t0, t1 float64, p0, p1 V2, n int) {
 	if colinearSlow(pmid, p0, p1, s.tolerance) {
 		// the curve could be periodic so perturb the midpoint
 		// pick a t value in [0.45,0.55]
-		k := 0.45 + 0.1*rand.Float64()
+		// Use a low discrepancy sequence rather than a random number
+		// so the sampling is repeatable.
+		k := 0.45 + 0.1*math.Mod(float64(n+1)*goldenRatio, 1)
 		t2 := t0 + k*(t1-t0)
 		p2 := s.f0(t2)
 		if colinearSlow(p2, p0, p1, s.tolerance) {
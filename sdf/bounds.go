@@ -0,0 +1,107 @@
+//-----------------------------------------------------------------------------
+/*
+
+Transformed Bounding Boxes
+
+Transforming the axis-aligned bounding box of an SDF3 and taking the
+axis-aligned bounding box of the result adds slack. With nested transforms
+the slack compounds, so the render region can be mostly empty space.
+
+Nodes that know their own shape can compute a tight box for a given
+transform. Transforms are composed down the tree so the boxes of the
+leaf nodes are only transformed once.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// boxTransformer is implemented by SDF3s that compute a tight bounding box after a transform.
+type boxTransformer interface {
+	transformBox(m M44) Box3
+}
+
+// transformedBox returns the bounding box of an SDF3 after a transform.
+func transformedBox(s SDF3, m M44) Box3 {
+	if t, ok := s.(boxTransformer); ok {
+		return t.transformBox(m)
+	}
+	return m.MulBox(s.BoundingBox())
+}
+
+// rowLengths returns the lengths of the rows of the upper 3x3 matrix.
+func (a M44) rowLengths() V3 {
+	return V3{
+		V3{a.x00, a.x01, a.x02}.Length(),
+		V3{a.x10, a.x11, a.x12}.Length(),
+		V3{a.x20, a.x21, a.x22}.Length(),
+	}
+}
+
+// rowLengthsXY returns the lengths of the rows of the upper 3x2 matrix.
+func (a M44) rowLengthsXY() V3 {
+	return V3{
+		V2{a.x00, a.x01}.Length(),
+		V2{a.x10, a.x11}.Length(),
+		V2{a.x20, a.x21}.Length(),
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// transformBox returns the bounding box of a transformed sphere (an ellipsoid).
+func (s *SphereSDF3) transformBox(m M44) Box3 {
+	c := m.MulPosition(V3{})
+	d := m.rowLengths().MulScalar(s.radius)
+	return Box3{c.Sub(d), c.Add(d)}
+}
+
+// transformBox returns the bounding box of a transformed cylinder.
+func (s *CylinderSDF3) transformBox(m M44) Box3 {
+	c := m.MulPosition(V3{})
+	// the cylinder is the sum of the end disks, the axis and the rounding sphere
+	axis := V3{math.Abs(m.x02), math.Abs(m.x12), math.Abs(m.x22)}.MulScalar(s.height)
+	disk := m.rowLengthsXY().MulScalar(s.radius)
+	round := m.rowLengths().MulScalar(s.round)
+	d := axis.Add(disk).Add(round)
+	return Box3{c.Sub(d), c.Add(d)}
+}
+
+// transformBox returns the bounding box of a transformed SDF3.
+func (s *TransformSDF3) transformBox(m M44) Box3 {
+	return transformedBox(s.sdf, m.Mul(s.matrix))
+}
+
+// transformBox returns the bounding box of a transformed SDF3 union.
+func (s *UnionSDF3) transformBox(m M44) Box3 {
+	bb := transformedBox(s.sdf[0], m)
+	for _, x := range s.sdf[1:] {
+		bb = bb.Extend(transformedBox(x, m))
+	}
+	return bb
+}
+
+// transformBox returns the bounding box of a transformed BVH union.
+func (s *BVHUnionSDF3) transformBox(m M44) Box3 {
+	bb := transformedBox(s.sdf[0], m)
+	for _, x := range s.sdf[1:] {
+		bb = bb.Extend(transformedBox(x, m))
+	}
+	return bb
+}
+
+// transformBox returns the bounding box of a transformed SDF3 difference.
+func (s *DifferenceSDF3) transformBox(m M44) Box3 {
+	return transformedBox(s.s0, m)
+}
+
+// transformBox returns the bounding box of a transformed SDF3 intersection.
+func (s *IntersectionSDF3) transformBox(m M44) Box3 {
+	return transformedBox(s.s0, m)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,270 @@
+//-----------------------------------------------------------------------------
+/*
+
+Render Caching
+
+Structural hashing of SDF trees, and a disk cache of rendered subtrees keyed
+by the hash and the render settings.
+
+The hash covers the types and parameter values of every node in the tree, so
+identical trees built by different runs of a program have the same hash. Wrap
+the expensive subtrees of a model with the cache and only the subtrees that
+have changed are re-rendered on the next run.
+
+Function values are hashed by name. Closures (E.g. the blending function from
+PolyMin) have no stable identity, so trees containing them can't be hashed.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//-----------------------------------------------------------------------------
+// Structural Hashing
+
+// renderCacheVersion is changed when the rendering code changes the cached results.
+const renderCacheVersion = 1
+
+type digest [sha256.Size]byte
+
+// treeHasher hashes SDF trees. Shared subtrees are hashed once.
+type treeHasher struct {
+	seen map[uintptr]map[reflect.Type]digest
+}
+
+// write writes the encoding of a value to a hash.
+func (k *treeHasher) write(h hash.Hash, v reflect.Value) error {
+	var buf [8]byte
+	putUint := func(x uint64) {
+		binary.LittleEndian.PutUint64(buf[:], x)
+		h.Write(buf[:])
+	}
+	if !v.IsValid() {
+		h.Write([]byte("nil"))
+		return nil
+	}
+	h.Write([]byte(v.Type().String()))
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			putUint(1)
+		} else {
+			putUint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		putUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		putUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		putUint(math.Float64bits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		putUint(math.Float64bits(real(v.Complex())))
+		putUint(math.Float64bits(imag(v.Complex())))
+	case reflect.String:
+		putUint(uint64(v.Len()))
+		h.Write([]byte(v.String()))
+	case reflect.Array, reflect.Slice:
+		putUint(uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := k.write(h, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := k.write(h, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			h.Write([]byte("nil"))
+			return nil
+		}
+		return k.write(h, v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() {
+			h.Write([]byte("nil"))
+			return nil
+		}
+		d, err := k.pointer(v)
+		if err != nil {
+			return err
+		}
+		h.Write(d[:])
+	case reflect.Map:
+		// hash each entry and sort the entry hashes
+		var entries []string
+		for _, key := range v.MapKeys() {
+			e := sha256.New()
+			if err := k.write(e, key); err != nil {
+				return err
+			}
+			if err := k.write(e, v.MapIndex(key)); err != nil {
+				return err
+			}
+			entries = append(entries, string(e.Sum(nil)))
+		}
+		sort.Strings(entries)
+		putUint(uint64(len(entries)))
+		for _, e := range entries {
+			h.Write([]byte(e))
+		}
+	case reflect.Func:
+		if v.IsNil() {
+			h.Write([]byte("nil"))
+			return nil
+		}
+		name := runtime.FuncForPC(v.Pointer()).Name()
+		if strings.Contains(name, ".func") || strings.HasSuffix(name, "-fm") {
+			return fmt.Errorf("can't hash closure %s", name)
+		}
+		h.Write([]byte(name))
+	default:
+		return fmt.Errorf("can't hash %s", v.Type())
+	}
+	return nil
+}
+
+// pointer returns the hash of the value a pointer points to.
+func (k *treeHasher) pointer(v reflect.Value) (digest, error) {
+	p := v.Pointer()
+	if d, ok := k.seen[p][v.Type()]; ok {
+		return d, nil
+	}
+	h := sha256.New()
+	if err := k.write(h, v.Elem()); err != nil {
+		return digest{}, err
+	}
+	var d digest
+	copy(d[:], h.Sum(nil))
+	if k.seen[p] == nil {
+		k.seen[p] = make(map[reflect.Type]digest)
+	}
+	k.seen[p][v.Type()] = d
+	return d, nil
+}
+
+// hashTree returns the structural hash of a value as a hex string.
+func hashTree(x interface{}) (string, error) {
+	k := treeHasher{seen: make(map[uintptr]map[reflect.Type]digest)}
+	h := sha256.New()
+	if err := k.write(h, reflect.ValueOf(x)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashSDF3 returns a structural hash of an SDF3 tree.
+func HashSDF3(s SDF3) (string, error) {
+	return hashTree(s)
+}
+
+// HashSDF2 returns a structural hash of an SDF2 tree.
+func HashSDF2(s SDF2) (string, error) {
+	return hashTree(s)
+}
+
+//-----------------------------------------------------------------------------
+// Render Cache
+
+// RenderCache is a disk cache of rendered SDF3s.
+type RenderCache struct {
+	dir string
+	mu  sync.Mutex
+	err error // the last error writing a cache file
+}
+
+// NewRenderCache returns a render cache stored in a directory.
+func NewRenderCache(dir string) (*RenderCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &RenderCache{dir: dir}, nil
+}
+
+// path returns the cache file path for an SDF3 and render settings.
+func (c *RenderCache) path(s SDF3, kind string, meshCells int, ext string) (string, error) {
+	h, err := HashSDF3(s)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s-%d-v%d.%s", h, kind, meshCells, renderCacheVersion, ext)
+	return filepath.Join(c.dir, name), nil
+}
+
+// Voxel3D returns a voxel sampled SDF3 (see Voxel3D), loaded from the cache if possible.
+// The error is for an SDF3 that can't be hashed. A failure to write the cache file
+// doesn't fail the render, it's reported by Err.
+func (c *RenderCache) Voxel3D(s SDF3, meshCells int) (SDF3, error) {
+	path, err := c.path(s, "voxel", meshCells, "vox")
+	if err != nil {
+		return nil, err
+	}
+	if v, err := LoadVoxel(path); err == nil {
+		return v, nil
+	}
+	v := Voxel3D(s, meshCells)
+	// match the float32 precision of the file, so the hash of a tree using v is stable
+	for i, x := range v.(*VoxelSDF3).value {
+		v.(*VoxelSDF3).value[i] = float64(float32(x))
+	}
+	c.save(path, func(tmp string) error { return SaveVoxel(tmp, v) })
+	return v, nil
+}
+
+// Mesh returns the rendered mesh of an SDF3 (see RenderMesh), loaded from the cache if possible.
+// The error is for an SDF3 that can't be hashed. A failure to write the cache file
+// doesn't fail the render, it's reported by Err.
+func (c *RenderCache) Mesh(s SDF3, meshCells int) ([]*Triangle3, error) {
+	path, err := c.path(s, "mesh", meshCells, "stl")
+	if err != nil {
+		return nil, err
+	}
+	if m, err := LoadSTL(path); err == nil {
+		return m, nil
+	}
+	m := RenderMesh(s, meshCells)
+	c.save(path, func(tmp string) error { return SaveSTL(tmp, m) })
+	return m, nil
+}
+
+// save writes a cache file via a temporary file, so partial files are never used.
+func (c *RenderCache) save(path string, write func(string) error) {
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	err := write(tmp)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+	}
+}
+
+// Err returns the last error writing a cache file (nil if there were none).
+func (c *RenderCache) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+//-----------------------------------------------------------------------------
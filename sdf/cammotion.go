@@ -0,0 +1,339 @@
+//-----------------------------------------------------------------------------
+/*
+
+Cam Motion Programs
+
+A cam motion program is a sequence of segments (rise, dwell, return, dwell)
+covering one revolution of the cam. Each segment has a duration (cam angle),
+a lift (the change in follower displacement) and a motion law giving the
+shape of the displacement curve over the segment.
+
+Motion laws (normalized displacement y(x) for x = 0..1):
+
+dwell: no motion
+linear: constant velocity, y = x
+parabolic: constant acceleration, then constant deceleration
+harmonic: simple harmonic motion, y = (1 - cos(pi x)) / 2
+cycloidal: y = x - sin(2 pi x) / (2 pi)
+poly345: y = 10x^3 - 15x^4 + 6x^5
+poly4567: y = 35x^4 - 84x^5 + 70x^6 - 20x^7
+
+The velocity and acceleration of the follower must be continuous across the
+segment boundaries for smooth running (C2 continuity). Between dwells the
+cycloidal and polynomial laws are C2, the harmonic law is only C1 and the
+linear law isn't even C1. CheckC2 reports the boundaries where the velocity
+or acceleration jumps.
+
+Disk cams can be made for knife edge, roller and flat faced followers. The
+roller profile is the inside offset of the pitch curve traced by the roller
+center, the flat faced profile is the envelope of the follower face.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+// Motion Laws
+
+// motionLaw returns the normalized displacement, velocity and acceleration at x (0..1).
+type motionLaw func(x float64) (y, v, a float64)
+
+var motionLaws = map[string]motionLaw{
+	"dwell": func(x float64) (float64, float64, float64) {
+		return 0, 0, 0
+	},
+	"linear": func(x float64) (float64, float64, float64) {
+		return x, 1, 0
+	},
+	"parabolic": func(x float64) (float64, float64, float64) {
+		if x < 0.5 {
+			return 2 * x * x, 4 * x, 4
+		}
+		return 1 - 2*(1-x)*(1-x), 4 * (1 - x), -4
+	},
+	"harmonic": func(x float64) (float64, float64, float64) {
+		s, c := math.Sincos(Pi * x)
+		return 0.5 * (1 - c), 0.5 * Pi * s, 0.5 * Pi * Pi * c
+	},
+	"cycloidal": func(x float64) (float64, float64, float64) {
+		s, c := math.Sincos(Tau * x)
+		return x - s/Tau, 1 - c, Tau * s
+	},
+	"poly345": func(x float64) (float64, float64, float64) {
+		x2 := x * x
+		x3 := x2 * x
+		return x3 * (10 - 15*x + 6*x2), 30 * x2 * (1 - 2*x + x2), 60 * x * (1 - 3*x + 2*x2)
+	},
+	"poly4567": func(x float64) (float64, float64, float64) {
+		x2 := x * x
+		x3 := x2 * x
+		x4 := x3 * x
+		y := x4 * (35 - 84*x + 70*x2 - 20*x3)
+		v := 140 * x3 * (1 - 3*x + 3*x2 - x3)
+		a := 420 * x2 * (1 - 4*x + 5*x2 - 2*x3)
+		return y, v, a
+	},
+}
+
+//-----------------------------------------------------------------------------
+
+// CamSegment is a segment of a cam motion program.
+type CamSegment struct {
+	Law      string  // motion law: dwell, linear, parabolic, harmonic, cycloidal, poly345, poly4567
+	Duration float64 // cam angle for the segment (radians)
+	Lift     float64 // follower displacement change (> 0 rise, < 0 return, 0 dwell)
+}
+
+// CamProgram is a cam motion program, the segments cover one revolution of the cam.
+type CamProgram []CamSegment
+
+// Check returns an error if the program is invalid. The segment durations must add up to a
+// full revolution and the follower must return to its starting position.
+func (p CamProgram) Check() error {
+	if len(p) == 0 {
+		return fmt.Errorf("empty cam program")
+	}
+	total := 0.0
+	lift := 0.0
+	for i, x := range p {
+		if _, ok := motionLaws[x.Law]; !ok {
+			return fmt.Errorf("segment %d: unknown motion law \"%s\"", i, x.Law)
+		}
+		if x.Duration <= 0 {
+			return fmt.Errorf("segment %d: duration <= 0", i)
+		}
+		if x.Law == "dwell" && x.Lift != 0 {
+			return fmt.Errorf("segment %d: dwell with lift", i)
+		}
+		total += x.Duration
+		lift += x.Lift
+	}
+	if !EqualFloat64(total, Tau, 1e-6) {
+		return fmt.Errorf("segment durations total %g degrees, not 360", RtoD(total))
+	}
+	if Abs(lift) > 1e-9*Max(1, p.MaxLift()) {
+		return fmt.Errorf("follower doesn't return to the start, net lift %g", lift)
+	}
+	return nil
+}
+
+// MaxLift returns the maximum follower displacement (from the start of the program).
+func (p CamProgram) MaxLift() float64 {
+	s, smax := 0.0, 0.0
+	for _, x := range p {
+		s += x.Lift
+		smax = Max(smax, s)
+	}
+	return smax
+}
+
+// segment returns the segment index, start displacement and normalized position for a cam angle.
+func (p CamProgram) segment(theta float64) (int, float64, float64) {
+	theta = math.Mod(theta, Tau)
+	if theta < 0 {
+		theta += Tau
+	}
+	s := 0.0
+	for i, x := range p {
+		if theta < x.Duration || i == len(p)-1 {
+			return i, s, Clamp(theta/x.Duration, 0, 1)
+		}
+		theta -= x.Duration
+		s += x.Lift
+	}
+	return 0, 0, 0
+}
+
+// Displacement returns the follower displacement and its first and second derivatives
+// (with respect to the cam angle) at a cam angle.
+func (p CamProgram) Displacement(theta float64) (s, v, a float64) {
+	i, s0, x := p.segment(theta)
+	k := p[i]
+	y, dy, ddy := motionLaws[k.Law](x)
+	return s0 + k.Lift*y, k.Lift * dy / k.Duration, k.Lift * ddy / (k.Duration * k.Duration)
+}
+
+// ends returns the velocity and acceleration at the start and end of a segment.
+func (k *CamSegment) ends() (v0, a0, v1, a1 float64) {
+	law := motionLaws[k.Law]
+	_, dy0, ddy0 := law(0)
+	_, dy1, ddy1 := law(1)
+	d := k.Duration
+	return k.Lift * dy0 / d, k.Lift * ddy0 / (d * d), k.Lift * dy1 / d, k.Lift * ddy1 / (d * d)
+}
+
+// CheckC2 returns an error listing the segment boundaries where the follower velocity or
+// acceleration is discontinuous.
+func (p CamProgram) CheckC2(
+	tolerance float64, // allowed jump, relative to the peak value over the program
+) error {
+	if err := p.Check(); err != nil {
+		return err
+	}
+	// peak values for scaling
+	vmax, amax := 0.0, 0.0
+	const n = 720
+	for i := 0; i < n; i++ {
+		_, v, a := p.Displacement(Tau * float64(i) / n)
+		vmax = Max(vmax, Abs(v))
+		amax = Max(amax, Abs(a))
+	}
+	var errs []string
+	angle := 0.0
+	for i := range p {
+		j := (i + 1) % len(p)
+		angle += p[i].Duration
+		_, _, v0, a0 := p[i].ends()
+		v1, a1, _, _ := p[j].ends()
+		if Abs(v1-v0) > tolerance*Max(vmax, 1e-12) {
+			errs = append(errs, fmt.Sprintf("velocity jump %g at %g degrees (segment %d/%d)", v1-v0, RtoD(math.Mod(angle, Tau)), i, j))
+		}
+		if Abs(a1-a0) > tolerance*Max(amax, 1e-12) {
+			errs = append(errs, fmt.Sprintf("acceleration jump %g at %g degrees (segment %d/%d)", a1-a0, RtoD(math.Mod(angle, Tau)), i, j))
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+
+// ProgramCam2D returns the profile of a disk cam for a radial knife edge follower driven by a
+// cam motion program. The cam is centered on the origin and turns counter-clockwise, the follower
+// is on the +y axis and touches the base circle at the start of the program.
+func ProgramCam2D(
+	program CamProgram, // cam motion program
+	baseRadius float64, // radius of the base circle
+	points int, // number of points on the profile, E.g. 720
+) (SDF2, error) {
+	if err := program.Check(); err != nil {
+		return nil, err
+	}
+	if baseRadius <= 0 {
+		return nil, fmt.Errorf("baseRadius <= 0")
+	}
+	if points < 3 {
+		return nil, fmt.Errorf("points < 3")
+	}
+	v := make([]V2, points)
+	for i := range v {
+		theta := Tau * float64(i) / float64(points)
+		s, _, _ := program.Displacement(theta)
+		r := baseRadius + s
+		if r <= 0 {
+			return nil, fmt.Errorf("profile radius <= 0 at %g degrees", RtoD(theta))
+		}
+		// the follower meets the cam at polar angle 90 - theta
+		sin, cos := math.Sincos(0.5*Pi - theta)
+		v[i] = V2{cos, sin}.MulScalar(r)
+	}
+	return Polygon2D(v), nil
+}
+
+//-----------------------------------------------------------------------------
+// Cam Followers
+
+// CamFollower is the type of follower for a disk cam.
+type CamFollower int
+
+const (
+	// KnifeFollower is a radial knife edge follower.
+	KnifeFollower CamFollower = iota
+	// RollerFollower is a radial roller follower.
+	RollerFollower
+	// FlatFollower is a flat faced follower, with the face perpendicular to its motion.
+	FlatFollower
+)
+
+// FollowerCamParms defines the parameters for a disk cam driven by a cam motion program.
+type FollowerCamParms struct {
+	Program      CamProgram  // cam motion program
+	Follower     CamFollower // follower type
+	BaseRadius   float64     // radius of the base circle of the cam profile
+	RollerRadius float64     // radius of the roller (roller follower)
+	Points       int         // number of points on the profile, E.g. 720
+}
+
+// PressureAngle returns the maximum pressure angle (radians) for a radial follower with the
+// given pitch circle radius (the base radius for a knife edge, the base radius plus the roller
+// radius for a roller). The pressure angle of a flat faced follower is always 0.
+func (p CamProgram) PressureAngle(pitchRadius float64) float64 {
+	pa := 0.0
+	const n = 720
+	for i := 0; i < n; i++ {
+		s, v, _ := p.Displacement(Tau * float64(i) / n)
+		pa = Max(pa, Abs(math.Atan2(v, pitchRadius+s)))
+	}
+	return pa
+}
+
+// FollowerCam2D returns the profile of a disk cam driven by a cam motion program.
+// The cam is centered on the origin and turns counter-clockwise, the follower is on the +y axis
+// and touches the base circle at the start of the program. The profile is checked for
+// undercutting (roller follower) and cusps (flat faced follower). The contact point on a flat
+// faced follower moves off its axis by ds/dtheta, so the face must be wider than twice the
+// maximum follower velocity (per radian of cam rotation).
+func FollowerCam2D(k *FollowerCamParms) (SDF2, error) {
+	switch k.Follower {
+	case KnifeFollower:
+		return ProgramCam2D(k.Program, k.BaseRadius, k.Points)
+	case RollerFollower:
+		if k.RollerRadius <= 0 {
+			return nil, fmt.Errorf("rollerRadius <= 0")
+		}
+	case FlatFollower:
+	default:
+		return nil, fmt.Errorf("unknown follower type")
+	}
+	if err := k.Program.Check(); err != nil {
+		return nil, err
+	}
+	if k.BaseRadius <= 0 {
+		return nil, fmt.Errorf("baseRadius <= 0")
+	}
+	if k.Points < 3 {
+		return nil, fmt.Errorf("points < 3")
+	}
+	v := make([]V2, k.Points)
+	for i := range v {
+		theta := Tau * float64(i) / float64(k.Points)
+		s, ds, dds := k.Program.Displacement(theta)
+		// follower direction and its derivative in the cam frame
+		sin, cos := math.Sincos(theta)
+		n := V2{sin, cos}
+		dn := V2{cos, -sin}
+		if k.Follower == FlatFollower {
+			// the profile is the envelope of the follower face
+			r := k.BaseRadius + s
+			if r+dds <= 0 {
+				return nil, fmt.Errorf("the profile has a cusp at %g degrees, increase the base radius", RtoD(theta))
+			}
+			v[i] = n.MulScalar(r).Add(dn.MulScalar(ds))
+			continue
+		}
+		// roller: the pitch curve is traced by the roller center
+		r := k.BaseRadius + k.RollerRadius + s
+		// radius of curvature of the pitch curve (> 0 convex)
+		rho := math.Pow(r*r+ds*ds, 1.5) / (r*r + 2*ds*ds - r*dds)
+		if rho > 0 && rho < k.RollerRadius {
+			return nil, fmt.Errorf("the profile is undercut at %g degrees, reduce the roller radius", RtoD(theta))
+		}
+		v[i] = n.MulScalar(r)
+	}
+	if k.Follower == FlatFollower {
+		return Polygon2D(v), nil
+	}
+	// the profile is the inside offset of the pitch curve
+	return Offset2D(Polygon2D(v), -k.RollerRadius), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -57,6 +57,38 @@ func FlatFlankCam2D(
 	return &s
 }
 
+// checkCamCircles checks the base and nose circles of a cam profile.
+func checkCamCircles(distance, baseRadius, noseRadius float64) error {
+	if distance <= 0 {
+		return fmt.Errorf("distance <= 0")
+	}
+	if baseRadius <= 0 {
+		return fmt.Errorf("baseRadius <= 0")
+	}
+	if noseRadius <= 0 {
+		return fmt.Errorf("noseRadius <= 0")
+	}
+	if noseRadius > baseRadius {
+		return fmt.Errorf("noseRadius > baseRadius")
+	}
+	if baseRadius-noseRadius >= distance {
+		return fmt.Errorf("the nose circle is inside the base circle")
+	}
+	return nil
+}
+
+// CheckedFlatFlankCam2D creates a 2D flat flank cam profile, with an error for invalid parameters.
+func CheckedFlatFlankCam2D(
+	distance float64, // circle to circle center distance
+	baseRadius float64, // radius of base circle
+	noseRadius float64, // radius of nose circle
+) (SDF2, error) {
+	if err := checkCamCircles(distance, baseRadius, noseRadius); err != nil {
+		return nil, err
+	}
+	return FlatFlankCam2D(distance, baseRadius, noseRadius), nil
+}
+
 // Evaluate returns the minimum distance to the cam.
 func (s *FlatFlankCamSDF2) Evaluate(p V2) float64 {
 	// we have symmetry about the y-axis
@@ -113,7 +145,7 @@ func MakeFlatFlankCam(
 		return nil, fmt.Errorf("noseRadius <= 0")
 	}
 	distance := baseRadius + lift - noseRadius
-	return FlatFlankCam2D(distance, baseRadius, noseRadius), nil
+	return CheckedFlatFlankCam2D(distance, baseRadius, noseRadius)
 }
 
 //-----------------------------------------------------------------------------
@@ -172,6 +204,22 @@ func ThreeArcCam2D(
 	return &s
 }
 
+// CheckedThreeArcCam2D creates a 2D three arc cam profile, with an error for invalid parameters.
+func CheckedThreeArcCam2D(
+	distance float64, // circle to circle center distance
+	baseRadius float64, // radius of base circle
+	noseRadius float64, // radius of nose circle
+	flankRadius float64, // radius of flank arc
+) (SDF2, error) {
+	if err := checkCamCircles(distance, baseRadius, noseRadius); err != nil {
+		return nil, err
+	}
+	if flankRadius < (baseRadius+distance+noseRadius)/2.0 {
+		return nil, fmt.Errorf("flankRadius is too small")
+	}
+	return ThreeArcCam2D(distance, baseRadius, noseRadius, flankRadius), nil
+}
+
 // Evaluate returns the minimum distance to the cam.
 func (s *ThreeArcCamSDF2) Evaluate(p V2) float64 {
 	// we have symmetry about the y-axis
@@ -255,7 +303,7 @@ func MakeThreeArcCam(
 
 	// distance between base and nose circles
 	distance := baseRadius + lift - noseRadius
-	return ThreeArcCam2D(distance, baseRadius, noseRadius, flankRadius), nil
+	return CheckedThreeArcCam2D(distance, baseRadius, noseRadius, flankRadius)
 }
 
 //-----------------------------------------------------------------------------
@@ -293,7 +341,10 @@ func MakeGenevaCam(
 	pinOffset := math.Sqrt((d * d) + (r * r) - (2 * d * r * math.Cos(theta)))
 
 	// driven wheel
-	sDriven := Circle2D(drivenRadius - clearance)
+	sDriven, err := CheckedCircle2D(drivenRadius - clearance)
+	if err != nil {
+		return nil, nil, fmt.Errorf("clearance is too large for the driven wheel")
+	}
 	// cutouts for the driver wheel
 	s := Circle2D(driverRadius + clearance)
 	s = Transform2D(s, Translate2d(V2{centerDistance, 0}))
@@ -301,14 +352,20 @@ func MakeGenevaCam(
 	sDriven = Difference2D(sDriven, s)
 	// cutouts for the pin slots
 	slotLength := pinOffset + drivenRadius - centerDistance
-	s = Line2D(2*slotLength, pinRadius+clearance)
+	s, err = CheckedLine2D(2*slotLength, pinRadius+clearance)
+	if err != nil {
+		return nil, nil, fmt.Errorf("the pin doesn't reach the driven wheel")
+	}
 	s = Transform2D(s, Translate2d(V2{drivenRadius, 0}))
 	s = RotateCopy2D(s, numSectors)
 	s = Transform2D(s, Rotate2d(theta))
 	sDriven = Difference2D(sDriven, s)
 
 	// driver wheel
-	sDriver := Circle2D(driverRadius - clearance)
+	sDriver, err := CheckedCircle2D(driverRadius - clearance)
+	if err != nil {
+		return nil, nil, fmt.Errorf("clearance is too large for the driver wheel")
+	}
 	// cutout for the driven wheel
 	s = Circle2D(drivenRadius + clearance)
 	s = Transform2D(s, Translate2d(V2{centerDistance, 0}))
@@ -322,3 +379,107 @@ func MakeGenevaCam(
 }
 
 //-----------------------------------------------------------------------------
+// Barrel Cams
+
+// BarrelCamParms defines the parameters for a barrel (cylindrical) cam.
+type BarrelCamParms struct {
+	Radius       float64                     // radius of the cam cylinder
+	Length       float64                     // length of the cam cylinder (along the z-axis)
+	GrooveWidth  float64                     // groove width (the roller diameter plus clearance)
+	GrooveDepth  float64                     // groove depth
+	Displacement func(theta float64) float64 // z position of the groove center at a polar angle (radians)
+	Points       int                         // number of points on the groove center line, E.g. 720
+}
+
+// BarrelGrooveSDF3 is the groove of a barrel cam.
+type BarrelGrooveSDF3 struct {
+	z      []float64 // groove center z at equally spaced polar angles
+	r0     float64   // groove bottom radius
+	w      float64   // half groove width
+	search float64   // search distance for the groove center line
+	bb     Box3      // bounding box
+}
+
+// BarrelCam3D returns a barrel cam centered on the origin with its axis along the z-axis.
+// The groove for a radial roller follower runs around the cylinder, with its center at
+// z = Displacement(theta) at polar angle theta. A follower on the +x axis sees
+// Displacement(theta) when the cam has turned clockwise by theta. For a cam motion program
+// use the displacement from CamProgram.Displacement (offset to suit the cylinder).
+func BarrelCam3D(k *BarrelCamParms) (SDF3, error) {
+	if k.Radius <= 0 {
+		return nil, fmt.Errorf("radius <= 0")
+	}
+	if k.Length <= 0 {
+		return nil, fmt.Errorf("length <= 0")
+	}
+	if k.GrooveWidth <= 0 {
+		return nil, fmt.Errorf("grooveWidth <= 0")
+	}
+	if k.GrooveDepth <= 0 || k.GrooveDepth >= k.Radius {
+		return nil, fmt.Errorf("grooveDepth must be > 0 and < radius")
+	}
+	if k.Displacement == nil {
+		return nil, fmt.Errorf("nil displacement function")
+	}
+	if k.Points < 3 {
+		return nil, fmt.Errorf("points < 3")
+	}
+	s := BarrelGrooveSDF3{
+		z:      make([]float64, k.Points),
+		r0:     k.Radius - k.GrooveDepth,
+		w:      0.5 * k.GrooveWidth,
+		search: 2 * k.GrooveWidth,
+	}
+	zmin, zmax := math.MaxFloat64, -math.MaxFloat64
+	for i := range s.z {
+		z := k.Displacement(Tau * float64(i) / float64(k.Points))
+		s.z[i] = z
+		zmin = Min(zmin, z)
+		zmax = Max(zmax, z)
+	}
+	if Abs(k.Displacement(Tau)-s.z[0]) > 1e-6*Max(1, zmax-zmin) {
+		return nil, fmt.Errorf("the displacement isn't periodic")
+	}
+	if zmin-s.w <= -0.5*k.Length || zmax+s.w >= 0.5*k.Length {
+		return nil, fmt.Errorf("the groove runs off the end of the cylinder")
+	}
+	r := k.Radius + k.GrooveWidth
+	s.bb = Box3{V3{-r, -r, zmin - s.w}, V3{r, r, zmax + s.w}}
+	return Difference3D(Cylinder3D(k.Length, k.Radius, 0), &s), nil
+}
+
+// Evaluate returns the minimum distance to a barrel cam groove.
+func (s *BarrelGrooveSDF3) Evaluate(p V3) float64 {
+	n := len(s.z)
+	da := Tau / float64(n)
+	r := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	theta := math.Atan2(p.Y, p.X)
+	if theta < 0 {
+		theta += Tau
+	}
+	// the distance to the center line at this polar angle limits the search
+	i := int(theta / da)
+	t := theta/da - float64(i)
+	dz := Abs(p.Z - Mix(s.z[i%n], s.z[(i+1)%n], t))
+	limit := Min(dz, s.search)
+	// the center line unrolled at this radius, relative to the point
+	m := n / 2
+	if r > 0 {
+		m = int(Min(float64(m), math.Ceil(limit/(r*da))+1))
+	}
+	q := func(j int) V2 {
+		return V2{r * (float64(j)*da - theta), s.z[((j%n)+n)%n] - p.Z}
+	}
+	d := limit
+	for j := i - m; j <= i+m; j++ {
+		d = Min(d, segmentDistance(V2{}, q(j), q(j+1)))
+	}
+	return Max(d-s.w, s.r0-r)
+}
+
+// BoundingBox returns the bounding box of a barrel cam groove.
+func (s *BarrelGrooveSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
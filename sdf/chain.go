@@ -0,0 +1,213 @@
+//-----------------------------------------------------------------------------
+/*
+
+Chains
+
+Chain links and a helper to place interlocked links along a path.
+
+Oval and square links are a circular wire swept around a 2D outline. Ball
+chain links are a hollow ball with a rod and knob. The knob of one link is
+captured in the ball of the next link.
+
+All links are centered on the origin with the chain running along the
+x-axis. The joints between links are at x = +/- pitch/2.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+// Wire Links
+
+// LinkSDF3 is a circular wire swept around a 2D outline in the xy plane.
+type LinkSDF3 struct {
+	outline SDF2    // the wire follows the zero contour of the outline
+	r       float64 // wire radius
+	bb      Box3    // bounding box
+}
+
+// Link3D returns a circular wire of radius r swept around the outline of an SDF2.
+func Link3D(outline SDF2, r float64) SDF3 {
+	s := LinkSDF3{
+		outline: outline,
+		r:       r,
+	}
+	bb := outline.BoundingBox()
+	s.bb = Box3{V3{bb.Min.X - r, bb.Min.Y - r, -r}, V3{bb.Max.X + r, bb.Max.Y + r, r}}
+	return &s
+}
+
+// Evaluate returns the minimum distance to a link.
+func (s *LinkSDF3) Evaluate(p V3) float64 {
+	d := Abs(s.outline.Evaluate(V2{p.X, p.Y}))
+	return V2{d, p.Z}.Length() - s.r
+}
+
+// BoundingBox returns the bounding box of a link.
+func (s *LinkSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a link.
+func (s *LinkSDF3) Children() []interface{} {
+	return []interface{}{s.outline}
+}
+
+//-----------------------------------------------------------------------------
+
+// ChainLinkParms defines the parameters for a chain link.
+type ChainLinkParms struct {
+	Style     string  // "oval", "square" or "ball"
+	Length    float64 // outside length of the link (ball to ball distance for a ball chain)
+	Width     float64 // outside width of the link (ball diameter for a ball chain)
+	Wire      float64 // wire diameter (rod diameter for a ball chain)
+	Clearance float64 // clearance between links
+}
+
+// ChainLink3D returns a chain link and the pitch of the chain.
+func ChainLink3D(k *ChainLinkParms) (SDF3, float64, error) {
+	if k.Wire <= 0 {
+		return nil, 0, errors.New("wire <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, 0, errors.New("clearance < 0")
+	}
+	switch k.Style {
+	case "oval", "square":
+		return wireLink(k)
+	case "ball":
+		return ballLink(k)
+	}
+	return nil, 0, fmt.Errorf("unknown style \"%s\"", k.Style)
+}
+
+// wireLink returns an oval or square link.
+func wireLink(k *ChainLinkParms) (SDF3, float64, error) {
+	d := k.Wire
+	// the wire of the next link passes through the inside of this link
+	if k.Width-2*d < d+2*k.Clearance {
+		return nil, 0, errors.New("link width is too small")
+	}
+	pitch := k.Length - 2*d - k.Clearance
+	if pitch <= 0 {
+		return nil, 0, errors.New("link length is too small")
+	}
+	// outline of the wire center
+	size := V2{k.Length - d, k.Width - d}
+	round := 0.5 * size.Y
+	if k.Style == "square" {
+		round = d
+	}
+	return Link3D(Box2D(size, round), 0.5*d), pitch, nil
+}
+
+// ballLink returns a ball chain link.
+func ballLink(k *ChainLinkParms) (SDF3, float64, error) {
+	pitch := k.Length
+	rb := 0.5 * k.Width // ball radius
+	rr := 0.5 * k.Wire  // rod radius
+	rc := rb - rr       // cavity radius
+	rh := rr + k.Clearance
+	rk := rc - k.Clearance // knob radius
+	if rk <= rh+k.Clearance {
+		return nil, 0, errors.New("ball diameter is too small")
+	}
+	if pitch <= 2*rb+k.Clearance {
+		return nil, 0, errors.New("ball spacing is too small")
+	}
+	// hollow ball with a hole for the rod of the previous link
+	ball := Difference3D(Sphere3D(rb), Union3D(Sphere3D(rc), alongX(Cylinder3D(2*rb, rh, 0), -rb)))
+	// the rod starts at the cavity wall and ends with a knob inside the next ball
+	rod := alongX(Cylinder3D(pitch-rc, rr, 0), 0.5*(rc+pitch))
+	knob := Transform3D(Sphere3D(rk), Translate3d(V3{pitch, 0, 0}))
+	s := Union3D(ball, rod, knob)
+	return Transform3D(s, Translate3d(V3{-0.5 * pitch, 0, 0})), pitch, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// ChainParms defines the placement of links along a path.
+type ChainParms struct {
+	Pitch float64 // link to link distance
+	Roll  float64 // rotation of the first link about the path (radians)
+	Twist float64 // rotation of each link relative to the previous link (radians)
+}
+
+// rotateXTo returns the rotation taking the +x axis to a direction.
+func rotateXTo(v V3) M44 {
+	v = v.Normalize()
+	x := V3{1, 0, 0}
+	axis := x.Cross(v)
+	if axis.Length() < epsilon {
+		if v.X > 0 {
+			return Identity3d()
+		}
+		return RotateZ(Pi)
+	}
+	return Rotate3d(axis, math.Acos(Clamp(x.Dot(v), -1, 1)))
+}
+
+// nextJoint returns the arc length of the first point along a path after s that is
+// a straight line distance d from the point at s.
+func nextJoint(path *Path3, s, d float64) (float64, bool) {
+	j := path.Point(s)
+	i, u := pathSegment(path.s, s)
+	for ; i < len(path.t); i, u = i+1, 0 {
+		// solve |p + t*x - j| = d for the exit from the sphere around j
+		w := path.p[i].Sub(j)
+		b := w.Dot(path.t[i])
+		c := w.Length2() - d*d
+		x := -b + math.Sqrt(Max(b*b-c, 0))
+		l := path.s[i+1] - path.s[i]
+		if i == len(path.t)-1 {
+			l += tolerance
+		}
+		if x >= u && x <= l {
+			return path.s[i] + Min(x, path.s[i+1]-path.s[i]), true
+		}
+	}
+	return 0, false
+}
+
+// Chain3D returns links placed along a polyline path with their joints on the path.
+// The joints are a pitch apart in a straight line, so the links don't overlap at
+// the bends of the path. A straight chain of n links is the path {0,0,0} to {n*pitch,0,0}.
+// Use a twist of 90 degrees for interlocked wire links, and a roll of 45 degrees to
+// print them in place.
+func Chain3D(link SDF3, path []V3, k *ChainParms) (SDF3, error) {
+	if k.Pitch <= 0 {
+		return nil, errors.New("pitch <= 0")
+	}
+	p, err := NewPath3(path)
+	if err != nil {
+		return nil, err
+	}
+	var links []SDF3
+	s := 0.0
+	j0 := p.Point(s)
+	for i := 0; ; i++ {
+		var ok bool
+		s, ok = nextJoint(p, s, k.Pitch)
+		if !ok {
+			break
+		}
+		j1 := p.Point(s)
+		m := Translate3d(j0.Add(j1).MulScalar(0.5))
+		m = m.Mul(rotateXTo(j1.Sub(j0))).Mul(RotateX(k.Roll + float64(i)*k.Twist))
+		links = append(links, Transform3D(link, m))
+		j0 = j1
+	}
+	if len(links) == 0 {
+		return nil, errors.New("path is shorter than the pitch")
+	}
+	return Union3D(links...), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,217 @@
+//-----------------------------------------------------------------------------
+/*
+
+Model Checks
+
+Walk an SDF tree and report common problems before an expensive render:
+
+box: a node with an inverted or empty (zero size) bounding box.
+nan: a node that evaluates to NaN (E.g. from bad parameters), or has NaN in
+its bounding box.
+lipschitz: a node where the distance changes by more than 1 per unit
+distance, so the distance overestimates the true distance (E.g. after non
+uniform scaling). The deepest node with the problem is reported, unless a
+Normalize3D/Normalize2D ancestor fixes it.
+sparse: a union of shapes that fill a small part of the union bounding box.
+The render resolution is set by the bounding box, so far apart shapes get a
+coarse mesh. Render them separately.
+
+The distance field checks sample the nodes at points in (and around) their
+bounding boxes, so they can miss problems in small regions.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+//-----------------------------------------------------------------------------
+
+const (
+	checkSamples   = 64   // number of distance field samples per node
+	checkLipschitz = 1.05 // maximum allowed rate of change of the distance
+	checkSparse    = 0.01 // minimum fill of a union bounding box
+)
+
+// CheckWarning is a problem found in an SDF tree.
+type CheckWarning struct {
+	Kind    string      // "box", "nan", "lipschitz" or "sparse"
+	Path    string      // path to the node from the root, E.g. "UnionSDF3/TransformSDF3[1]"
+	Node    interface{} // node with the problem
+	Message string      // description of the problem
+}
+
+// String returns a description of a check warning.
+func (w *CheckWarning) String() string {
+	return fmt.Sprintf("%s: %s: %s", w.Kind, w.Path, w.Message)
+}
+
+// checkNode is an SDF2 or SDF3 node as a distance function in 3D.
+type checkNode struct {
+	dim      int              // 2 or 3
+	eval     func(V3) float64 // distance function
+	min, max V3               // bounding box
+}
+
+// newCheckNode returns the check function for an SDF2/SDF3 node (nil for other nodes).
+func newCheckNode(node interface{}) *checkNode {
+	switch s := node.(type) {
+	case SDF3:
+		bb := s.BoundingBox()
+		return &checkNode{3, s.Evaluate, bb.Min, bb.Max}
+	case SDF2:
+		bb := s.BoundingBox()
+		eval := func(p V3) float64 { return s.Evaluate(V2{p.X, p.Y}) }
+		return &checkNode{2, eval, V3{bb.Min.X, bb.Min.Y, 0}, V3{bb.Max.X, bb.Max.Y, 0}}
+	}
+	return nil
+}
+
+// size returns the volume (or area) of the bounding box.
+func (n *checkNode) size() float64 {
+	d := n.max.Sub(n.min)
+	if n.dim == 2 {
+		return d.X * d.Y
+	}
+	return d.X * d.Y * d.Z
+}
+
+// checker walks an SDF tree and collects warnings.
+type checker struct {
+	rnd      *rand.Rand
+	warnings []CheckWarning
+}
+
+func (c *checker) warn(kind, path string, node interface{}, format string, args ...interface{}) {
+	c.warnings = append(c.warnings, CheckWarning{kind, path, node, fmt.Sprintf(format, args...)})
+}
+
+// randomPoint returns a random point in (or around) the bounding box of a node.
+func (c *checker) randomPoint(n *checkNode, margin float64) V3 {
+	p := V3{
+		Mix(n.min.X-margin, n.max.X+margin, c.rnd.Float64()),
+		Mix(n.min.Y-margin, n.max.Y+margin, c.rnd.Float64()),
+		Mix(n.min.Z-margin, n.max.Z+margin, c.rnd.Float64()),
+	}
+	if n.dim == 2 {
+		p.Z = 0
+	}
+	return p
+}
+
+// randomDirection returns a random unit vector.
+func (c *checker) randomDirection(dim int) V3 {
+	for {
+		v := V3{2*c.rnd.Float64() - 1, 2*c.rnd.Float64() - 1, 2*c.rnd.Float64() - 1}
+		if dim == 2 {
+			v.Z = 0
+		}
+		if l := v.Length(); l > 0.1 && l <= 1 {
+			return v.DivScalar(l)
+		}
+	}
+}
+
+// check checks a node and its children. It returns the lipschitz warnings for the deepest
+// nodes with distance fields that overestimate the distance, unless the node fixes them.
+func (c *checker) check(node interface{}, path string) []CheckWarning {
+	// check the children first
+	var pending []CheckWarning
+	children := Children(node)
+	for i, child := range children {
+		name := NodeName(child)
+		if len(children) > 1 {
+			name = fmt.Sprintf("%s[%d]", name, i)
+		}
+		pending = append(pending, c.check(child, path+"/"+name)...)
+	}
+	n := newCheckNode(node)
+	if n == nil {
+		return pending
+	}
+
+	// bounding box
+	for _, v := range []float64{n.min.X, n.min.Y, n.min.Z, n.max.X, n.max.Y, n.max.Z} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			c.warn("nan", path, node, "bad bounding box %v %v", n.min, n.max)
+			return pending
+		}
+	}
+	d := n.max.Sub(n.min)
+	if d.X < 0 || d.Y < 0 || (n.dim == 3 && d.Z < 0) {
+		c.warn("box", path, node, "inverted bounding box %v %v", n.min, n.max)
+		return pending
+	}
+	if d.X == 0 || d.Y == 0 || (n.dim == 3 && d.Z == 0) {
+		c.warn("box", path, node, "empty bounding box %v %v", n.min, n.max)
+		return pending
+	}
+
+	// sample the distance field
+	l := d.Length()
+	h := 1e-3 * l
+	kmax := 0.0
+	for i := 0; i < checkSamples; i++ {
+		p := c.randomPoint(n, 0.1*l)
+		d0 := n.eval(p)
+		d1 := n.eval(p.Add(c.randomDirection(n.dim).MulScalar(h)))
+		if math.IsNaN(d0) || math.IsNaN(d1) {
+			c.warn("nan", path, node, "evaluates to NaN at %v", p)
+			return nil
+		}
+		if !math.IsInf(d0, 0) && !math.IsInf(d1, 0) {
+			kmax = Max(kmax, Abs(d1-d0)/h)
+		}
+	}
+	switch node.(type) {
+	case *NormalizeSDF3, *NormalizeSDF2:
+		if kmax <= checkLipschitz {
+			// normalization fixes the problems of the children
+			pending = nil
+		}
+	}
+	if kmax > checkLipschitz && pending == nil {
+		pending = []CheckWarning{{
+			Kind:    "lipschitz",
+			Path:    path,
+			Node:    node,
+			Message: fmt.Sprintf("distance changes by %.2f per unit distance, normalize by this factor", kmax),
+		}}
+	}
+
+	// sparse unions
+	switch node.(type) {
+	case *UnionSDF3, *UnionSDF2:
+		fill := 0.0
+		for _, child := range children {
+			if cn := newCheckNode(child); cn != nil {
+				fill += cn.size()
+			}
+		}
+		if size := n.size(); fill < checkSparse*size {
+			c.warn("sparse", path, node, "children fill %.3g%% of the bounding box", 100*fill/size)
+		}
+	}
+	return pending
+}
+
+// Check walks an SDF3 tree and returns warnings for common problems.
+func Check(s SDF3) []CheckWarning {
+	c := checker{rnd: rand.New(rand.NewSource(1))}
+	pending := c.check(s, NodeName(s))
+	return append(c.warnings, pending...)
+}
+
+// Check2D walks an SDF2 tree and returns warnings for common problems.
+func Check2D(s SDF2) []CheckWarning {
+	c := checker{rnd: rand.New(rand.NewSource(1))}
+	pending := c.check(s, NodeName(s))
+	return append(c.warnings, pending...)
+}
+
+//-----------------------------------------------------------------------------
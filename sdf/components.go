@@ -0,0 +1,287 @@
+//-----------------------------------------------------------------------------
+/*
+
+Connected Components
+
+Find the disjoint solid regions of an SDF3 or a triangle mesh.
+
+A model that accidentally has floating islands can be detected, and a
+multi-part model can be split so each part is exported separately.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+// Mesh Components
+
+// MeshComponents splits a triangle mesh into connected components (largest first).
+// Triangles are connected if they share a vertex.
+func MeshComponents(mesh []*Triangle3) [][]*Triangle3 {
+	if len(mesh) == 0 {
+		return nil
+	}
+	// weld the vertices
+	bb := mesh[0].BoundingBox()
+	for _, t := range mesh {
+		bb = bb.Extend(t.BoundingBox())
+	}
+	tol := 1e-6 * Max(bb.Size().MaxComponent(), 1)
+	index := make(map[V3i]int)
+	weld := func(p V3) int {
+		k := p.DivScalar(tol).Ceil().ToV3i()
+		if i, ok := index[k]; ok {
+			return i
+		}
+		index[k] = len(index)
+		return len(index) - 1
+	}
+	tv := make([][3]int, len(mesh))
+	for i, t := range mesh {
+		tv[i] = [3]int{weld(t.V[0]), weld(t.V[1]), weld(t.V[2])}
+	}
+	// union-find on the vertices
+	parent := make([]int, len(index))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, v := range tv {
+		a := find(v[0])
+		for _, x := range v[1:] {
+			if b := find(x); a != b {
+				parent[b] = a
+			}
+		}
+	}
+	// group the triangles
+	group := make(map[int]int)
+	var components [][]*Triangle3
+	for i, v := range tv {
+		r := find(v[0])
+		j, ok := group[r]
+		if !ok {
+			j = len(components)
+			group[r] = j
+			components = append(components, nil)
+		}
+		components[j] = append(components[j], mesh[i])
+	}
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i]) > len(components[j])
+	})
+	return components
+}
+
+// SaveSTLComponents writes each connected component of a mesh to a separate STL file.
+// The files are named path_0.stl, path_1.stl, ... (largest first).
+func SaveSTLComponents(path string, mesh []*Triangle3) ([]string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	var names []string
+	for i, m := range MeshComponents(mesh) {
+		name := fmt.Sprintf("%s_%d%s", base, i, ext)
+		err := SaveSTL(name, m)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+//-----------------------------------------------------------------------------
+// SDF3 Components
+
+// componentGrid labels the interior grid points of an SDF3 with a component number.
+type componentGrid struct {
+	origin V3      // position of the grid point at index 0,0,0
+	step   float64 // grid point spacing
+	n      V3i     // number of grid points on each axis
+	label  []int32 // component label (0 = outside)
+}
+
+func (g *componentGrid) index(x, y, z int) int {
+	return (x*g.n[1]+y)*g.n[2] + z
+}
+
+// labelAt returns the label of the closest labelled grid point to p, searching the corners
+// of the grid cell containing p and then the next ring of grid points (0 = none).
+// A point inside a component near its surface can have unlabelled grid points closer to
+// it than any labelled point, so the nearest grid point alone isn't enough.
+func (g *componentGrid) labelAt(p V3) int32 {
+	q := p.Sub(g.origin).DivScalar(g.step)
+	i := V3i{int(math.Floor(q.X)), int(math.Floor(q.Y)), int(math.Floor(q.Z))}
+	for r := 0; r <= 1; r++ {
+		label := int32(0)
+		dmin := 0.0
+		for x := i[0] - r; x <= i[0]+1+r; x++ {
+			for y := i[1] - r; y <= i[1]+1+r; y++ {
+				for z := i[2] - r; z <= i[2]+1+r; z++ {
+					if x < 0 || y < 0 || z < 0 || x >= g.n[0] || y >= g.n[1] || z >= g.n[2] {
+						continue
+					}
+					l := g.label[g.index(x, y, z)]
+					if l == 0 {
+						continue
+					}
+					d := V3i{x, y, z}.ToV3().Sub(q).Length2()
+					if label == 0 || d < dmin {
+						label, dmin = l, d
+					}
+				}
+			}
+		}
+		if label != 0 {
+			return label
+		}
+	}
+	return 0
+}
+
+// ComponentSDF3 is a single connected component of an SDF3.
+type ComponentSDF3 struct {
+	sdf   SDF3
+	grid  *componentGrid
+	label int32
+	bb    Box3
+}
+
+// Components3D splits an SDF3 into its connected solid regions (largest first).
+// The regions are found on a sampling grid, so parts closer than a grid cell are joined.
+func Components3D(
+	s SDF3, // sdf3 to split
+	meshCells int, // number of cells on the longest axis. e.g 200
+) []SDF3 {
+	bb := s.BoundingBox()
+	size := bb.Size()
+	g := componentGrid{
+		origin: bb.Min,
+		step:   size.MaxComponent() / float64(meshCells),
+	}
+	g.n = size.DivScalar(g.step).Ceil().ToV3i().AddScalar(1)
+	g.label = make([]int32, g.n[0]*g.n[1]*g.n[2])
+
+	// sample the sdf
+	inside := make([]bool, len(g.label))
+	sampleGrid(s, g.origin, V3{g.step, g.step, g.step}, g.n, func(i int, p V3, d float64) {
+		inside[i] = d < 0
+	})
+
+	// flood fill the interior grid points (6-connected)
+	type region struct {
+		label    int32
+		count    int
+		min, max V3i
+	}
+	var regions []region
+	steps := []V3i{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+	var queue []V3i
+	for x := 0; x < g.n[0]; x++ {
+		for y := 0; y < g.n[1]; y++ {
+			for z := 0; z < g.n[2]; z++ {
+				i := g.index(x, y, z)
+				if !inside[i] || g.label[i] != 0 {
+					continue
+				}
+				r := region{label: int32(len(regions) + 1), min: V3i{x, y, z}, max: V3i{x, y, z}}
+				g.label[i] = r.label
+				queue = append(queue[:0], V3i{x, y, z})
+				for len(queue) > 0 {
+					c := queue[len(queue)-1]
+					queue = queue[:len(queue)-1]
+					r.count++
+					r.min = V3i{minInt(r.min[0], c[0]), minInt(r.min[1], c[1]), minInt(r.min[2], c[2])}
+					r.max = V3i{maxInt(r.max[0], c[0]), maxInt(r.max[1], c[1]), maxInt(r.max[2], c[2])}
+					for _, d := range steps {
+						k := c.Add(d)
+						if k[0] < 0 || k[1] < 0 || k[2] < 0 || k[0] >= g.n[0] || k[1] >= g.n[1] || k[2] >= g.n[2] {
+							continue
+						}
+						j := g.index(k[0], k[1], k[2])
+						if inside[j] && g.label[j] == 0 {
+							g.label[j] = r.label
+							queue = append(queue, k)
+						}
+					}
+				}
+				regions = append(regions, r)
+			}
+		}
+	}
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].count > regions[j].count
+	})
+
+	// create an SDF3 for each component
+	components := make([]SDF3, len(regions))
+	for i, r := range regions {
+		// the region bounds, extended by a cell to cover the surface
+		rbb := Box3{
+			g.origin.Add(r.min.SubScalar(1).ToV3().MulScalar(g.step)),
+			g.origin.Add(r.max.AddScalar(1).ToV3().MulScalar(g.step)),
+		}
+		components[i] = &ComponentSDF3{
+			sdf:   s,
+			grid:  &g,
+			label: r.label,
+			bb:    Box3{rbb.Min.Max(bb.Min), rbb.Max.Min(bb.Max)},
+		}
+	}
+	return components
+}
+
+// Evaluate returns the minimum distance to an SDF3 component.
+func (s *ComponentSDF3) Evaluate(p V3) float64 {
+	d := s.sdf.Evaluate(p)
+	if d < 0 {
+		// the interior of other components is outside
+		if l := s.grid.labelAt(p); l != 0 && l != s.label {
+			return -d
+		}
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of an SDF3 component.
+func (s *ComponentSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of an SDF3 component.
+func (s *ComponentSDF3) Children() []interface{} {
+	return []interface{}{s.sdf}
+}
+
+//-----------------------------------------------------------------------------
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+//-----------------------------------------------------------------------------
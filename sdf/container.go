@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+/*
+
+Screw Top Container
+
+A cylindrical container body with an externally threaded neck and a matching
+lid with an internal thread. The threads are plastic buttress threads, which
+print well and can have multiple starts for a fast closing lid.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// ContainerParms defines the parameters for a screw top container.
+type ContainerParms struct {
+	InnerRadius  float64 // radius of the internal volume
+	InnerHeight  float64 // height of the internal volume
+	Wall         float64 // wall thickness
+	Pitch        float64 // thread to thread distance
+	Starts       int     // number of thread starts
+	ThreadLength float64 // length of the threaded neck
+	Clearance    float64 // radial clearance between the body and lid threads
+	Knurl        bool    // knurled grip on the lid
+	Hand         Hand    // thread hand
+}
+
+// buttressDepth returns the depth of a plastic buttress thread.
+func buttressDepth(pitch float64) float64 {
+	h0 := pitch / (1 + math.Tan(DtoR(7.0)))
+	return 0.3*pitch + 0.5*h0
+}
+
+// Container3D returns the body and lid of a screw top container.
+// The body has its base at z = 0. The lid is upside down (for printing) with its top at z = 0.
+func Container3D(k *ContainerParms) ([]SDF3, error) {
+	if k.InnerRadius <= 0 {
+		return nil, errors.New("inner radius <= 0")
+	}
+	if k.Wall <= 0 {
+		return nil, errors.New("wall <= 0")
+	}
+	if k.Pitch <= 0 {
+		return nil, errors.New("pitch <= 0")
+	}
+	if k.Starts < 1 {
+		return nil, errors.New("starts < 1")
+	}
+	if k.ThreadLength <= 0 {
+		return nil, errors.New("thread length <= 0")
+	}
+	if k.InnerHeight <= k.ThreadLength {
+		return nil, errors.New("inner height <= thread length")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+
+	// the thread root is a wall thickness outside the internal volume
+	rt := k.InnerRadius + k.Wall + buttressDepth(k.Pitch)
+	// the body and lid have the same outside radius
+	r := rt + k.Clearance + k.Wall
+
+	// body
+	hb := k.Wall + k.InnerHeight - k.ThreadLength
+	body := Cylinder3D(hb, r, 0)
+	body = Transform3D(body, Translate3d(V3{0, 0, 0.5 * hb}))
+	neck := Screw3D(PlasticButtressThread(rt, k.Pitch), k.ThreadLength, k.Pitch, k.Starts*k.Hand.Sign())
+	neck = Transform3D(neck, Translate3d(V3{0, 0, hb + 0.5*k.ThreadLength}))
+	cavity := Cylinder3D(k.InnerHeight+1, k.InnerRadius, 0)
+	cavity = Transform3D(cavity, Translate3d(V3{0, 0, k.Wall + 0.5*(k.InnerHeight+1)}))
+	body = Difference3D(Union3D(body, neck), cavity)
+
+	// lid
+	hl := k.ThreadLength + k.Wall
+	var lid SDF3
+	if k.Knurl {
+		lid = KnurledHead3D(r, hl, r*0.25)
+	} else {
+		lid = Cylinder3D(hl, r, 0.5*k.Wall)
+	}
+	thread := Screw3D(PlasticButtressThread(rt+k.Clearance, k.Pitch), k.ThreadLength+1, k.Pitch, k.Starts*k.Hand.Sign())
+	thread = Transform3D(thread, Translate3d(V3{0, 0, 0.5 * (k.Wall + 1)}))
+	lid = Difference3D(lid, thread)
+	lid = Transform3D(lid, Translate3d(V3{0, 0, 0.5 * hl}))
+
+	return []SDF3{body, lid}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,248 @@
+//-----------------------------------------------------------------------------
+/*
+
+Calibration Coupons
+
+Small test prints used to dial in the clearance parameters for a printer.
+Each coupon has a series of features with a clearance (or angle, or span)
+that increases by a fixed step. The first feature is next to the chamfered
+corner of the coupon (the most negative x position).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// couponX returns the x position of the i-th of n features with a given spacing.
+func couponX(i, n int, spacing float64) float64 {
+	return (float64(i) - 0.5*float64(n-1)) * spacing
+}
+
+// couponPlate returns a plate with a chamfered corner marking the first feature.
+func couponPlate(size V3) SDF3 {
+	s := Box3D(size, 0)
+	c := 0.25 * size.Y
+	notch := Box3D(V3{c, c, size.Z}, 0)
+	m := Translate3d(V3{-0.5 * size.X, -0.5 * size.Y, 0}).Mul(RotateZ(DtoR(45)))
+	return Difference3D(s, Transform3D(notch, m))
+}
+
+//-----------------------------------------------------------------------------
+// Hole/Peg Fit Ladders
+
+// FitCouponParms defines the parameters for a hole/peg fit ladder.
+type FitCouponParms struct {
+	Diameter  float64 // nominal hole/peg diameter
+	Clearance float64 // clearance (diametral) of the first hole/peg
+	Step      float64 // clearance increment
+	Steps     int     // number of holes/pegs
+	Thickness float64 // plate thickness
+	Height    float64 // peg height
+	Spacing   float64 // center to center spacing of the holes/pegs
+}
+
+// FitCoupon3D returns a plate of holes and a plate of pegs with increasing clearance.
+// Hole i has diameter Diameter + c(i) and peg i has diameter Diameter - c(i),
+// where c(i) = Clearance + i * Step. Test the holes with a nominal sized pin and the
+// pegs with a nominal sized hole.
+func FitCoupon3D(k *FitCouponParms) ([]SDF3, error) {
+	if k.Diameter <= 0 {
+		return nil, errors.New("diameter <= 0")
+	}
+	if k.Steps <= 0 {
+		return nil, errors.New("steps <= 0")
+	}
+	if k.Thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	if k.Height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	cmax := k.Clearance + float64(k.Steps-1)*k.Step
+	if k.Spacing <= k.Diameter+Max(cmax, k.Clearance) {
+		return nil, errors.New("spacing too small for the holes")
+	}
+	if k.Diameter-Max(cmax, k.Clearance) <= 0 {
+		return nil, errors.New("clearance too large for the pegs")
+	}
+
+	plate := couponPlate(V3{float64(k.Steps) * k.Spacing, k.Spacing, k.Thickness})
+	holes := make([]SDF3, k.Steps)
+	pegs := make([]SDF3, k.Steps)
+	for i := 0; i < k.Steps; i++ {
+		c := k.Clearance + float64(i)*k.Step
+		x := couponX(i, k.Steps, k.Spacing)
+		hole := Cylinder3D(2*k.Thickness, 0.5*(k.Diameter+c), 0)
+		holes[i] = Transform3D(hole, Translate3d(V3{x, 0, 0}))
+		peg := Cylinder3D(k.Height, 0.5*(k.Diameter-c), 0)
+		pegs[i] = Transform3D(peg, Translate3d(V3{x, 0, 0.5 * (k.Thickness + k.Height)}))
+	}
+	return []SDF3{
+		Difference3D(plate, Union3D(holes...)),
+		Union3D(plate, Union3D(pegs...)),
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
+// Thread Samples
+
+// ThreadCouponParms defines the parameters for a set of thread samples.
+type ThreadCouponParms struct {
+	Thread    string  // name of thread
+	Tolerance float64 // thread tolerance of the first sample
+	Step      float64 // tolerance increment
+	Steps     int     // number of samples
+	Length    float64 // threaded length of the bolts
+}
+
+// ThreadCoupon3D returns a row of bolts and a row of nuts with increasing thread tolerance.
+// Bolt i and nut i both have tolerance Tolerance + i * Step.
+func ThreadCoupon3D(k *ThreadCouponParms) ([]SDF3, error) {
+	t, err := ThreadLookup(k.Thread)
+	if err != nil {
+		return nil, err
+	}
+	if k.Steps <= 0 {
+		return nil, errors.New("steps <= 0")
+	}
+	if k.Length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	if k.Tolerance < 0 || k.Tolerance+float64(k.Steps-1)*k.Step < 0 {
+		return nil, errors.New("tolerance < 0")
+	}
+
+	spacing := 2.5 * t.HexRadius()
+	bolts := make([]SDF3, k.Steps)
+	nuts := make([]SDF3, k.Steps)
+	for i := 0; i < k.Steps; i++ {
+		tolerance := k.Tolerance + float64(i)*k.Step
+		m := Translate3d(V3{couponX(i, k.Steps, spacing), 0, 0})
+		bolt, err := Bolt(&BoltParms{
+			Thread:      k.Thread,
+			Style:       "hex",
+			Tolerance:   tolerance,
+			TotalLength: k.Length,
+		})
+		if err != nil {
+			return nil, err
+		}
+		bolts[i] = Transform3D(bolt, m)
+		nut, err := Nut(&NutParms{
+			Thread:    k.Thread,
+			Style:     "hex",
+			Tolerance: tolerance,
+		})
+		if err != nil {
+			return nil, err
+		}
+		nuts[i] = Transform3D(nut, m)
+	}
+	return []SDF3{Union3D(bolts...), Union3D(nuts...)}, nil
+}
+
+//-----------------------------------------------------------------------------
+// Overhang Test
+
+// OverhangCouponParms defines the parameters for an overhang test.
+type OverhangCouponParms struct {
+	Angle     float64 // overhang angle (from the vertical) of the first fin (radians)
+	Step      float64 // angle increment (radians)
+	Steps     int     // number of fins
+	Length    float64 // length of the fin
+	Thickness float64 // thickness of the fin
+	Width     float64 // width of the fin
+	Base      float64 // base plate thickness
+}
+
+// OverhangCoupon3D returns a base plate with a row of fins leaning at increasing angles.
+func OverhangCoupon3D(k *OverhangCouponParms) (SDF3, error) {
+	if k.Steps <= 0 {
+		return nil, errors.New("steps <= 0")
+	}
+	if k.Length <= 0 || k.Thickness <= 0 || k.Width <= 0 || k.Base <= 0 {
+		return nil, errors.New("invalid fin size")
+	}
+	amax := k.Angle + float64(k.Steps-1)*k.Step
+	if k.Angle < 0 || amax < 0 || k.Angle >= 0.5*Pi || amax >= 0.5*Pi {
+		return nil, errors.New("angle must be [0..90) degrees")
+	}
+
+	// the fins lean towards +y
+	reach := k.Length * math.Sin(Max(k.Angle, amax))
+	spacing := 1.5 * k.Width
+	fins := make([]SDF3, k.Steps)
+	for i := 0; i < k.Steps; i++ {
+		a := k.Angle + float64(i)*k.Step
+		dy := k.Length * math.Sin(a)
+		dz := k.Length * math.Cos(a)
+		p := NewPolygon()
+		p.Add(0, 0)
+		p.Add(k.Thickness, 0)
+		p.Add(k.Thickness+dy, dz)
+		p.Add(dy, dz)
+		fin := Extrude3D(Polygon2D(p.Vertices()), k.Width)
+		// extrude along x, lean along y
+		m := Translate3d(V3{couponX(i, k.Steps, spacing), -0.5 * (reach + k.Thickness), 0.5 * k.Base})
+		m = m.Mul(RotateY(DtoR(90))).Mul(RotateZ(DtoR(90)))
+		fins[i] = Transform3D(fin, m)
+	}
+	base := couponPlate(V3{float64(k.Steps) * spacing, reach + k.Thickness, k.Base})
+	return Union3D(base, Union3D(fins...)), nil
+}
+
+//-----------------------------------------------------------------------------
+// Bridging Test
+
+// BridgeCouponParms defines the parameters for a bridging test.
+type BridgeCouponParms struct {
+	Span      float64 // span of the first bridge
+	Step      float64 // span increment
+	Steps     int     // number of bridges
+	Height    float64 // height of the bridge above the base plate
+	Thickness float64 // thickness of the bridge deck
+	Width     float64 // width of the bridge
+	Pillar    float64 // width of the pillars supporting the bridge
+	Base      float64 // base plate thickness
+}
+
+// BridgeCoupon3D returns a base plate with a row of bridges with increasing spans.
+func BridgeCoupon3D(k *BridgeCouponParms) (SDF3, error) {
+	if k.Steps <= 0 {
+		return nil, errors.New("steps <= 0")
+	}
+	if k.Height <= 0 || k.Thickness <= 0 || k.Width <= 0 || k.Pillar <= 0 || k.Base <= 0 {
+		return nil, errors.New("invalid bridge size")
+	}
+	smax := k.Span + float64(k.Steps-1)*k.Step
+	if k.Span <= 0 || smax <= 0 {
+		return nil, errors.New("span <= 0")
+	}
+
+	// the bridges span the y axis
+	length := Max(k.Span, smax) + 2*k.Pillar
+	spacing := 1.5 * k.Width
+	h := k.Height + k.Thickness
+	bridges := make([]SDF3, k.Steps)
+	for i := 0; i < k.Steps; i++ {
+		span := k.Span + float64(i)*k.Step
+		l := span + 2*k.Pillar
+		bridge := Box3D(V3{k.Width, l, h}, 0)
+		gap := Box3D(V3{k.Width, span, k.Height}, 0)
+		gap = Transform3D(gap, Translate3d(V3{0, 0, -0.5 * k.Thickness}))
+		bridge = Difference3D(bridge, gap)
+		m := Translate3d(V3{couponX(i, k.Steps, spacing), 0, 0.5 * (k.Base + h)})
+		bridges[i] = Transform3D(bridge, m)
+	}
+	base := couponPlate(V3{float64(k.Steps) * spacing, length, k.Base})
+	return Union3D(base, Union3D(bridges...)), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,220 @@
+//-----------------------------------------------------------------------------
+/*
+
+Cycloidal Gears
+
+The tooth addendum is an epicycloid and the dedendum is a hypocycloid, each
+traced by a generating circle rolling on the pitch circle. A pair of gears is
+conjugate when the generating circle for the addendum of one gear is the
+generating circle for the dedendum of the other. The usual choice (clock
+trains) is a generating circle with half the pitch radius of the gear it rolls
+inside, which makes the dedendum flanks radial.
+
+Cycloidal Drives
+
+The disk of a cycloidal speed reducer has one lobe less than the number of
+ring pins. The disk profile is the curve offset by the pin radius from the
+epitrochoid traced by the pin circle as the disk rolls around it with an
+eccentricity.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// epicycloidXY returns the epicycloid point for a generating circle rolling on the outside of a
+// pitch circle, starting at (pitchRadius, 0).
+func epicycloidXY(pitchRadius, r, t float64) V2 {
+	k := (pitchRadius + r) / r
+	return V2{
+		(pitchRadius+r)*math.Cos(t) - r*math.Cos(k*t),
+		(pitchRadius+r)*math.Sin(t) - r*math.Sin(k*t),
+	}
+}
+
+// hypocycloidXY returns the hypocycloid point for a generating circle rolling on the inside of a
+// pitch circle, starting at (pitchRadius, 0).
+func hypocycloidXY(pitchRadius, r, t float64) V2 {
+	k := (pitchRadius - r) / r
+	return V2{
+		(pitchRadius-r)*math.Cos(t) + r*math.Cos(k*t),
+		(pitchRadius-r)*math.Sin(t) - r*math.Sin(k*t),
+	}
+}
+
+// epicycloidT returns the epicycloid parameter for a radial distance.
+func epicycloidT(pitchRadius, r, d float64) float64 {
+	R := pitchRadius
+	c := ((R+r)*(R+r) + r*r - d*d) / (2 * r * (R + r))
+	return (r / R) * math.Acos(Clamp(c, -1, 1))
+}
+
+// hypocycloidT returns the hypocycloid parameter for a radial distance.
+func hypocycloidT(pitchRadius, r, d float64) float64 {
+	R := pitchRadius
+	c := (d*d - (R-r)*(R-r) - r*r) / (2 * r * (R - r))
+	return (r / R) * math.Acos(Clamp(c, -1, 1))
+}
+
+//-----------------------------------------------------------------------------
+
+// CycloidalGearTooth returns a 2D profile for a single cycloidal tooth.
+// The addendum is limited to where the tooth flanks meet.
+func CycloidalGearTooth(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	addendumCircle float64, // radius of the generating circle for the addendum (epicycloid)
+	dedendumCircle float64, // radius of the generating circle for the dedendum (hypocycloid)
+	rootRadius float64, // radius at tooth root
+	outerRadius float64, // radius at the outside of the tooth
+	backlash float64, // backlash expressed as units of pitch circumference
+	facets int, // number of facets for each part of the flank
+) SDF2 {
+
+	pitchRadius := float64(numberTeeth) * gearModule / 2.0
+
+	// half angle of the tooth at the pitch circle
+	backlashAngle := backlash / (2.0 * pitchRadius)
+	halfAngle := Pi/(2.0*float64(numberTeeth)) - backlashAngle
+
+	// the epicycloid is mirrored so the addendum flank turns toward the tooth center,
+	// limit it to where it meets the center line.
+	tPoint := 0.0
+	t1 := Pi * addendumCircle / pitchRadius
+	for i := 0; i < 50; i++ {
+		t := 0.5 * (tPoint + t1)
+		p := epicycloidXY(pitchRadius, addendumCircle, t)
+		if math.Atan2(p.Y, p.X) < halfAngle {
+			tPoint = t
+		} else {
+			t1 = t
+		}
+	}
+	tTip := Min(epicycloidT(pitchRadius, addendumCircle, outerRadius), tPoint)
+
+	// the hypocycloid can't get inside pitchRadius - 2 * dedendumCircle
+	tRoot := hypocycloidT(pitchRadius, dedendumCircle, Max(rootRadius, pitchRadius-2*dedendumCircle))
+
+	v := make([]V2, 0, 4*facets+3)
+
+	// upper tooth face, root to tip
+	m := Rotate(halfAngle)
+	for i := 0; i < facets; i++ {
+		t := tRoot * float64(facets-i) / float64(facets)
+		v = append(v, m.MulPosition(hypocycloidXY(pitchRadius, dedendumCircle, t)))
+	}
+	for i := 0; i <= facets; i++ {
+		t := tTip * float64(i) / float64(facets)
+		p := epicycloidXY(pitchRadius, addendumCircle, t)
+		v = append(v, m.MulPosition(V2{p.X, -p.Y}))
+	}
+
+	// lower tooth face (mirror the upper points)
+	n := len(v)
+	for i := 0; i < n; i++ {
+		p := v[n-1-i]
+		v = append(v, V2{p.X, -p.Y})
+	}
+
+	// add the origin to make the polygon a tooth wedge
+	v = append(v, V2{0, 0})
+
+	return Polygon2D(v)
+}
+
+// CycloidalGear returns a 2D polygon for a cycloidal gear.
+// The generating circles are half the pitch radius of the gear they roll inside,
+// so the gear meshes with any other cycloidal gear of the same module made this way.
+func CycloidalGear(
+	numberTeeth int, // number of gear teeth
+	matingTeeth int, // number of teeth on the mating gear
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	backlash float64, // backlash expressed as per-tooth distance at pitch circumference
+	clearance float64, // additional root clearance
+	ringWidth float64, // width of ring wall (from root circle)
+	facets int, // number of facets for each part of the flank
+) SDF2 {
+
+	// pitch radius
+	pitchRadius := float64(numberTeeth) * gearModule / 2.0
+	matingRadius := float64(matingTeeth) * gearModule / 2.0
+
+	// addendum: radial distance from pitch circle to outside circle
+	addendum := gearModule * 1.0
+	// dedendum: radial distance from pitch circle to root circle
+	dedendum := addendum + clearance
+
+	outerRadius := pitchRadius + addendum
+	rootRadius := pitchRadius - dedendum
+	ringRadius := rootRadius - ringWidth
+
+	tooth := CycloidalGearTooth(
+		numberTeeth,
+		gearModule,
+		0.5*matingRadius,
+		0.5*pitchRadius,
+		rootRadius,
+		outerRadius,
+		backlash,
+		facets,
+	)
+
+	gear := RotateCopy2D(tooth, numberTeeth)
+	root := Circle2D(rootRadius)
+	ring := Circle2D(ringRadius)
+
+	return Difference2D(Union2D(gear, root), ring)
+}
+
+//-----------------------------------------------------------------------------
+// Cycloidal Drives
+
+// CycloidalDisk2D returns the disk profile for a cycloidal drive. The disk is centered on the
+// origin and meshes with ring pins at angles 2*pi*i/pins on the pin circle when it is offset by
+// the eccentricity along the +x axis.
+func CycloidalDisk2D(
+	pins int, // number of ring pins (the disk has one lobe less)
+	pinCircleRadius float64, // radius of the ring pin circle
+	pinRadius float64, // radius of the ring pins
+	eccentricity float64, // offset of the disk center from the ring center
+	points int, // number of points on the profile, E.g. 720
+) (SDF2, error) {
+	if pins < 3 {
+		return nil, errors.New("pins < 3")
+	}
+	if pinRadius <= 0 || eccentricity <= 0 {
+		return nil, errors.New("pinRadius and eccentricity must be > 0")
+	}
+	n := float64(pins)
+	if eccentricity*n >= pinCircleRadius {
+		return nil, errors.New("eccentricity * pins >= pinCircleRadius, the profile has cusps")
+	}
+	if pinRadius >= pinCircleRadius*math.Sin(Pi/n) {
+		return nil, errors.New("the ring pins overlap")
+	}
+	if points < 3*pins {
+		return nil, errors.New("points < 3 * pins")
+	}
+	R := pinCircleRadius
+	v := make([]V2, points)
+	for i := range v {
+		t := Tau * float64(i) / float64(points)
+		// the offset direction is normal to the epitrochoid
+		s1, c1 := math.Sincos((1 - n) * t)
+		psi := math.Atan2(s1, R/(eccentricity*n)-c1)
+		v[i] = V2{
+			R*math.Cos(t) - pinRadius*math.Cos(t+psi) - eccentricity*math.Cos(n*t),
+			-R*math.Sin(t) + pinRadius*math.Sin(t+psi) + eccentricity*math.Sin(n*t),
+		}
+	}
+	return Polygon2D(v), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,493 @@
+//-----------------------------------------------------------------------------
+/*
+
+Density Grids
+
+Sample an SDF3 as a voxel grid of material density for voxel FEA and
+topology optimization tools. The density of a voxel is the fraction of its
+volume inside the SDF (0 = empty, 1 = solid). Voxels cut by the surface are
+supersampled to estimate the fraction.
+
+The grid is saved as a NumPy .npy file (format version 1.0): a little endian
+float32 array with shape (nx, ny, nz) in C order, so density[i][j][k] is the
+voxel with its minimum corner at origin + (i, j, k) * voxel size. Load it with
+numpy.load(). The .npy file doesn't have the origin and voxel size, keep them
+with the file (E.g. in the file name or a separate note).
+
+Density fields (E.g. topology optimization results) can be read from .npy
+files (float32 or float64, C order) and turned into an SDF3 at an iso-level,
+so they can be cleaned up, shelled and combined with other parts. The
+density is smoothed and converted to a distance field: an exact Euclidean
+distance transform of the inside/outside voxels away from the surface, and
+the density scaled by its gradient (for a smooth surface) close to it.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// DensityGrid is a voxel grid of material density (the volume fraction of each voxel).
+type DensityGrid struct {
+	Origin  V3        // minimum corner of voxel 0,0,0
+	Voxel   float64   // voxel size
+	N       V3i       // number of voxels on each axis
+	Density []float32 // voxel densities, index (i*ny + j)*nz + k
+}
+
+// Density3D samples an SDF3 as a density grid. The grid covers the bounding box of the SDF3 and
+// is centered on it.
+func Density3D(
+	sdf SDF3, // sdf3 to sample
+	voxel float64, // voxel size
+	samples int, // samples per axis for voxels cut by the surface (E.g. 4, 1 = no supersampling)
+) (*DensityGrid, error) {
+	if voxel <= 0 {
+		return nil, errors.New("voxel size <= 0")
+	}
+	if samples < 1 {
+		return nil, errors.New("samples < 1")
+	}
+	bb := sdf.BoundingBox()
+	size := bb.Size()
+	n := size.DivScalar(voxel).Ceil().ToV3i()
+	for i := range n {
+		if n[i] < 1 {
+			n[i] = 1
+		}
+	}
+	g := DensityGrid{
+		Origin:  bb.Center().Sub(n.ToV3().MulScalar(0.5 * voxel)),
+		Voxel:   voxel,
+		N:       n,
+		Density: make([]float32, n[0]*n[1]*n[2]),
+	}
+	// a voxel is fully inside or outside if its center is further than this from the surface
+	r := 0.5 * math.Sqrt(3) * voxel
+	sub := voxel / float64(samples)
+	total := float64(samples * samples * samples)
+	// sample the sdf at the voxel centers
+	center := g.Origin.AddScalar(0.5 * voxel)
+	sampleGrid(sdf, center, V3{voxel, voxel, voxel}, n, func(i int, p V3, d float64) {
+		var density float64
+		switch {
+		case d <= -r:
+			density = 1
+		case d >= r:
+			density = 0
+		default:
+			// supersample the boundary voxel
+			p0 := p.SubScalar(0.5 * voxel)
+			inside := 0
+			for x := 0; x < samples; x++ {
+				for y := 0; y < samples; y++ {
+					for z := 0; z < samples; z++ {
+						q := p0.Add(V3{float64(x) + 0.5, float64(y) + 0.5, float64(z) + 0.5}.MulScalar(sub))
+						if sdf.Evaluate(q) < 0 {
+							inside++
+						}
+					}
+				}
+			}
+			density = float64(inside) / total
+		}
+		g.Density[i] = float32(density)
+	})
+	return &g, nil
+}
+
+// index returns the density index for voxel i,j,k.
+func (g *DensityGrid) index(i, j, k int) int {
+	return (i*g.N[1]+j)*g.N[2] + k
+}
+
+// Volume returns the material volume of a density grid.
+func (g *DensityGrid) Volume() float64 {
+	sum := 0.0
+	for _, x := range g.Density {
+		sum += float64(x)
+	}
+	return sum * g.Voxel * g.Voxel * g.Voxel
+}
+
+//-----------------------------------------------------------------------------
+// NumPy File
+
+// npyMagic identifies a NumPy .npy file.
+const npyMagic = "\x93NUMPY"
+
+// WriteNPY writes a density grid as a NumPy .npy file.
+func (g *DensityGrid) WriteNPY(w io.Writer) error {
+	hdr := fmt.Sprintf("{'descr': '<f4', 'fortran_order': False, 'shape': (%d, %d, %d), }", g.N[0], g.N[1], g.N[2])
+	// pad the header so the data is 64 byte aligned, the header ends with a newline
+	prefix := len(npyMagic) + 4
+	pad := 64 - (prefix+len(hdr)+1)%64
+	if pad == 64 {
+		pad = 0
+	}
+	hdr += strings.Repeat(" ", pad) + "\n"
+	if _, err := io.WriteString(w, npyMagic); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{1, 0}); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint16(len(hdr))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, hdr); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, g.Density)
+}
+
+// SaveNPY saves a density grid to a NumPy .npy file.
+func (g *DensityGrid) SaveNPY(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := g.WriteNPY(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr':\s*'([<|>]?)(f4|f8)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape':\s*\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*,?\s*\)`)
+)
+
+// ReadNPY reads a density grid from a NumPy .npy file.
+func ReadNPY(
+	r io.Reader, // npy file data
+	origin V3, // minimum corner of voxel 0,0,0
+	voxel float64, // voxel size
+) (*DensityGrid, error) {
+	if voxel <= 0 {
+		return nil, errors.New("voxel size <= 0")
+	}
+	var pre [8]byte
+	if _, err := io.ReadFull(r, pre[:]); err != nil {
+		return nil, err
+	}
+	if string(pre[:6]) != npyMagic {
+		return nil, errors.New("not a npy file")
+	}
+	var hlen uint32
+	switch pre[6] {
+	case 1:
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, err
+		}
+		hlen = uint32(n)
+	case 2, 3:
+		if err := binary.Read(r, binary.LittleEndian, &hlen); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("npy version %d not supported", pre[6])
+	}
+	buf := make([]byte, hlen)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	hdr := string(buf)
+	descr := npyDescr.FindStringSubmatch(hdr)
+	if descr == nil || descr[1] == ">" {
+		return nil, errors.New("npy data must be little endian float32 or float64")
+	}
+	if f := npyFortran.FindStringSubmatch(hdr); f == nil || f[1] != "False" {
+		return nil, errors.New("npy data must be in C order")
+	}
+	shape := npyShape.FindStringSubmatch(hdr)
+	if shape == nil {
+		return nil, errors.New("npy data must have 3 dimensions")
+	}
+	var n V3i
+	for i := range n {
+		n[i], _ = strconv.Atoi(shape[i+1])
+		if n[i] < 1 {
+			return nil, errors.New("bad npy shape")
+		}
+	}
+	g := DensityGrid{
+		Origin:  origin,
+		Voxel:   voxel,
+		N:       n,
+		Density: make([]float32, n[0]*n[1]*n[2]),
+	}
+	if descr[2] == "f4" {
+		if err := binary.Read(r, binary.LittleEndian, g.Density); err != nil {
+			return nil, err
+		}
+	} else {
+		x := make([]float64, len(g.Density))
+		if err := binary.Read(r, binary.LittleEndian, x); err != nil {
+			return nil, err
+		}
+		for i := range x {
+			g.Density[i] = float32(x[i])
+		}
+	}
+	return &g, nil
+}
+
+// LoadNPY loads a density grid from a NumPy .npy file.
+func LoadNPY(
+	path string, // npy file path
+	origin V3, // minimum corner of voxel 0,0,0
+	voxel float64, // voxel size
+) (*DensityGrid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadNPY(bufio.NewReader(f), origin, voxel)
+}
+
+//-----------------------------------------------------------------------------
+// Density to Distance
+
+// edt1d is the 1D squared Euclidean distance transform of a sampled function
+// (Felzenszwalb and Huttenlocher). The distances are written to d, and the source of the
+// minimum for each sample (from src) to srcOut.
+func edt1d(f, d []float64, src, srcOut []int, v []int, z []float64) {
+	n := len(f)
+	// lower envelope of the parabolas
+	k := 0
+	v[0] = 0
+	z[0] = math.Inf(-1)
+	z[1] = math.Inf(1)
+	intersect := func(q, p int) float64 {
+		return ((f[q] + float64(q*q)) - (f[p] + float64(p*p))) / float64(2*q-2*p)
+	}
+	for q := 1; q < n; q++ {
+		s := intersect(q, v[k])
+		for s <= z[k] {
+			k--
+			s = intersect(q, v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+	// sample the envelope
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		x := float64(q - v[k])
+		d[q] = x*x + f[v[k]]
+		srcOut[q] = src[v[k]]
+	}
+}
+
+// edt3d returns the Euclidean distance (in voxels) from each voxel to the closest marked voxel,
+// and the index of the closest marked voxel.
+func edt3d(mark []bool, n V3i) ([]float64, []int) {
+	const far = 1e20
+	g := make([]float64, len(mark))
+	src := make([]int, len(mark))
+	for i, m := range mark {
+		if !m {
+			g[i] = far
+		}
+		src[i] = i
+	}
+	index := func(i, j, k int) int { return (i*n[1]+j)*n[2] + k }
+	l := n[0]
+	if n[1] > l {
+		l = n[1]
+	}
+	if n[2] > l {
+		l = n[2]
+	}
+	f := make([]float64, l)
+	d := make([]float64, l)
+	fs := make([]int, l)
+	ds := make([]int, l)
+	v := make([]int, l)
+	z := make([]float64, l+1)
+	// transform along each axis
+	for axis := 0; axis < 3; axis++ {
+		a, b := (axis+1)%3, (axis+2)%3
+		m := n[axis]
+		for x := 0; x < n[a]; x++ {
+			for y := 0; y < n[b]; y++ {
+				var p [3]int
+				p[a], p[b] = x, y
+				for i := 0; i < m; i++ {
+					p[axis] = i
+					j := index(p[0], p[1], p[2])
+					f[i] = g[j]
+					fs[i] = src[j]
+				}
+				edt1d(f[:m], d[:m], fs[:m], ds[:m], v[:m], z[:m+1])
+				for i := 0; i < m; i++ {
+					p[axis] = i
+					j := index(p[0], p[1], p[2])
+					g[j] = d[i]
+					src[j] = ds[i]
+				}
+			}
+		}
+	}
+	for i := range g {
+		g[i] = math.Sqrt(g[i])
+	}
+	return g, src
+}
+
+// IsoSurface returns an SDF3 for the surface of a density grid at an iso-level. The density is
+// smoothed by a number of [1 2 1] filter passes on each axis before the surface is found.
+func (g *DensityGrid) IsoSurface(
+	iso float64, // density at the surface (E.g. 0.5)
+	smooth int, // number of smoothing passes (0 = none)
+) (SDF3, error) {
+	if iso <= 0 || iso >= 1 {
+		return nil, errors.New("iso level must be between 0 and 1")
+	}
+	if smooth < 0 {
+		return nil, errors.New("smooth < 0")
+	}
+	if len(g.Density) != g.N[0]*g.N[1]*g.N[2] {
+		return nil, errors.New("bad density grid size")
+	}
+	// pad with empty voxels so the surface is closed
+	const pad = 2
+	n := g.N.AddScalar(2 * pad)
+	index := func(i, j, k int) int { return (i*n[1]+j)*n[2] + k }
+	rho := make([]float64, n[0]*n[1]*n[2])
+	for i := 0; i < g.N[0]; i++ {
+		for j := 0; j < g.N[1]; j++ {
+			for k := 0; k < g.N[2]; k++ {
+				rho[index(i+pad, j+pad, k+pad)] = float64(g.Density[g.index(i, j, k)])
+			}
+		}
+	}
+	// smoothing
+	tmp := make([]float64, len(rho))
+	for s := 0; s < smooth; s++ {
+		for axis := 0; axis < 3; axis++ {
+			var step int
+			switch axis {
+			case 0:
+				step = n[1] * n[2]
+			case 1:
+				step = n[2]
+			default:
+				step = 1
+			}
+			for i := 0; i < n[0]; i++ {
+				for j := 0; j < n[1]; j++ {
+					for k := 0; k < n[2]; k++ {
+						p := [3]int{i, j, k}
+						x := index(i, j, k)
+						sum := 2 * rho[x]
+						if p[axis] > 0 {
+							sum += rho[x-step]
+						}
+						if p[axis] < n[axis]-1 {
+							sum += rho[x+step]
+						}
+						tmp[x] = 0.25 * sum
+					}
+				}
+			}
+			rho, tmp = tmp, rho
+		}
+	}
+	// distance transforms of the inside and outside voxels
+	inside := make([]bool, len(rho))
+	outside := make([]bool, len(rho))
+	solid := false
+	for i, x := range rho {
+		inside[i] = x > iso
+		outside[i] = !inside[i]
+		solid = solid || inside[i]
+	}
+	if !solid {
+		return nil, errors.New("no density above the iso level")
+	}
+	// close to the surface the distance is the density scaled by its gradient
+	near := make([]float64, len(rho))
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			for k := 0; k < n[2]; k++ {
+				x := index(i, j, k)
+				near[x] = math.NaN()
+				if i > 0 && j > 0 && k > 0 && i < n[0]-1 && j < n[1]-1 && k < n[2]-1 {
+					grad := V3{
+						rho[index(i+1, j, k)] - rho[index(i-1, j, k)],
+						rho[index(i, j+1, k)] - rho[index(i, j-1, k)],
+						rho[index(i, j, k+1)] - rho[index(i, j, k-1)],
+					}.MulScalar(0.5).Length()
+					if grad > epsilon {
+						near[x] = (iso - rho[x]) / grad
+					}
+				}
+			}
+		}
+	}
+	// away from the surface it's the distance to the closest voxel on the other side of the
+	// surface, less the distance from that voxel to the surface
+	dIn, srcIn := edt3d(inside, n)
+	dOut, srcOut := edt3d(outside, n)
+	surface := func(x int) float64 {
+		if math.IsNaN(near[x]) {
+			return 0.5
+		}
+		return Clamp(Abs(near[x]), 0, 1)
+	}
+	value := make([]float64, len(rho))
+	for x := range value {
+		var d float64
+		if inside[x] {
+			d = surface(srcOut[x]) - dOut[x]
+		} else {
+			d = dIn[x] - surface(srcIn[x])
+		}
+		if Abs(d) < 1.5 && !math.IsNaN(near[x]) {
+			d = Clamp(near[x], -1.5, 1.5)
+		}
+		value[x] = d * g.Voxel
+	}
+	// the values are at the voxel centers
+	origin := g.Origin.AddScalar((0.5 - pad) * g.Voxel)
+	step := V3{g.Voxel, g.Voxel, g.Voxel}
+	return &VoxelSDF3{
+		origin: origin,
+		step:   step,
+		n:      n,
+		value:  value,
+		bb:     Box3{origin, origin.Add(n.SubScalar(1).ToV3().Mul(step))},
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,487 @@
+//-----------------------------------------------------------------------------
+/*
+
+Drawings
+
+Basic shop drawings of a part straight from the model code. The first page
+has the top, front and right side silhouettes (projections) of the part in
+third angle projection, with the overall dimensions. Each section (a plane
+slice through the part) is on a following page, hatched and dimensioned.
+
+The drawing is saved as a multi-page PDF, or as an SVG with the pages one
+above the other. The drawing is at 1:1 scale, with model units of mm.
+
+The drawing items are on layers (SVG groups): outline (view outlines),
+hatch (section hatching), dimension (dimension lines and arrows) and label
+(text and the page border).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	svg "github.com/ajstarks/svgo/float"
+)
+
+//-----------------------------------------------------------------------------
+
+// drawingLayer is a layer of drawing items.
+type drawingLayer int
+
+const (
+	layerOutline   drawingLayer = iota // view outlines
+	layerHatch                         // section hatching
+	layerDimension                     // dimension lines and arrows
+	layerLabel                         // text and page border
+	numLayers
+)
+
+var layerNames = [numLayers]string{"outline", "hatch", "dimension", "label"}
+
+// layerWidths are the line widths of the layers (mm).
+var layerWidths = [numLayers]float64{0.5, 0.18, 0.25, 0.35}
+
+// drawingPath is a polyline (or a closed and optionally filled polygon) on a drawing page.
+type drawingPath struct {
+	layer  drawingLayer
+	p      []V2
+	closed bool
+	fill   bool
+}
+
+// drawingText is centered text on a drawing page.
+type drawingText struct {
+	p     V2      // baseline center
+	size  float64 // text height
+	angle float64 // rotation (radians)
+	s     string
+}
+
+// width returns the estimated width of text (Helvetica digits are 0.556 em).
+func (t *drawingText) width() float64 {
+	return 0.556 * t.size * float64(len(t.s))
+}
+
+// drawingPage is a page of a drawing.
+type drawingPage struct {
+	size  V2 // page size, the origin is the bottom left corner
+	paths []drawingPath
+	texts []drawingText
+}
+
+// Drawing is a multi-page drawing of a part.
+type Drawing struct {
+	pages []*drawingPage
+}
+
+// DrawingParms defines the parameters for a drawing.
+type DrawingParms struct {
+	Title     string           // drawing title
+	MeshCells int              // number of cells on the longest axis of the part (0 = 200)
+	Tolerance float64          // chord tolerance of the view outlines (0 = 0.1% of the part size)
+	Sections  []DrawingSection // section views
+}
+
+// DrawingSection is a section view of a part.
+type DrawingSection struct {
+	Name   string // section name, E.g. "A"
+	Point  V3     // point on the section plane
+	Normal V3     // normal to the section plane (see Slice2D for the view axes)
+}
+
+//-----------------------------------------------------------------------------
+
+// line adds a polyline to a page.
+func (pg *drawingPage) line(layer drawingLayer, p ...V2) {
+	pg.paths = append(pg.paths, drawingPath{layer: layer, p: p})
+}
+
+// text adds text to a page.
+func (pg *drawingPage) text(p V2, size, angle float64, s string) {
+	pg.texts = append(pg.texts, drawingText{p, size, angle, s})
+}
+
+// arrow adds a filled arrow head with the tip at p, pointing in direction d.
+func (pg *drawingPage) arrow(p, d V2, ts float64) {
+	d = d.Normalize()
+	n := V2{-d.Y, d.X}
+	b := p.Sub(d.MulScalar(0.8 * ts))
+	pg.paths = append(pg.paths, drawingPath{
+		layer:  layerDimension,
+		p:      []V2{p, b.Add(n.MulScalar(0.2 * ts)), b.Sub(n.MulScalar(0.2 * ts))},
+		closed: true,
+		fill:   true,
+	})
+}
+
+// dimension adds a linear dimension from a to b, with the dimension line offset by d
+// (to the left of a to b for d > 0).
+func (pg *drawingPage) dimension(a, b V2, d, ts float64) {
+	ab := b.Sub(a)
+	dir := ab.Normalize()
+	n := V2{-dir.Y, dir.X}
+	if d < 0 {
+		n, d = n.Neg(), -d
+	}
+	a1, b1 := a.Add(n.MulScalar(d)), b.Add(n.MulScalar(d))
+	// extension lines, with a gap at the part
+	pg.line(layerDimension, a.Add(n.MulScalar(0.3*ts)), a1.Add(n.MulScalar(0.5*ts)))
+	pg.line(layerDimension, b.Add(n.MulScalar(0.3*ts)), b1.Add(n.MulScalar(0.5*ts)))
+	// dimension line and arrows
+	pg.line(layerDimension, a1, b1)
+	pg.arrow(a1, dir.Neg(), ts)
+	pg.arrow(b1, dir, ts)
+	// the text reads from the bottom or the right, above the dimension line
+	angle := math.Atan2(dir.Y, dir.X)
+	if angle > 0.5*Pi+epsilon || angle <= -0.5*Pi+epsilon {
+		angle = math.Atan2(-dir.Y, -dir.X)
+	}
+	up := V2{-math.Sin(angle), math.Cos(angle)}
+	mid := a1.Add(b1).MulScalar(0.5)
+	pg.text(mid.Add(up.MulScalar(0.3*ts)), ts, angle, fmt.Sprintf("%.2f", ab.Length()))
+}
+
+// hatch adds 45 degree hatching to the inside of a set of polygons (even-odd rule).
+func (pg *drawingPage) hatch(polygons [][]V2, spacing float64) {
+	// work in coordinates rotated by -45 degrees, so the hatch lines are horizontal
+	m := Rotate2d(-0.25 * Pi)
+	mi := Rotate2d(0.25 * Pi)
+	var edges [][2]V2
+	ymin, ymax := math.Inf(1), math.Inf(-1)
+	for _, v := range polygons {
+		for i := range v {
+			a, b := m.MulPosition(v[i]), m.MulPosition(v[(i+1)%len(v)])
+			edges = append(edges, [2]V2{a, b})
+			ymin, ymax = Min(ymin, a.Y), Max(ymax, a.Y)
+		}
+	}
+	for y := (math.Floor(ymin/spacing) + 0.5) * spacing; y < ymax; y += spacing {
+		var x []float64
+		for _, e := range edges {
+			a, b := e[0], e[1]
+			if (a.Y <= y) != (b.Y <= y) {
+				x = append(x, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+		sort.Float64s(x)
+		for i := 0; i+1 < len(x); i += 2 {
+			pg.line(layerHatch, mi.MulPosition(V2{x[i], y}), mi.MulPosition(V2{x[i+1], y}))
+		}
+	}
+}
+
+// outline adds closed polygons to a page, translated by an offset.
+func (pg *drawingPage) outline(polygons [][]V2, ofs V2) {
+	for _, v := range polygons {
+		p := make([]V2, len(v))
+		for i := range v {
+			p[i] = v[i].Add(ofs)
+		}
+		pg.paths = append(pg.paths, drawingPath{layer: layerOutline, p: p, closed: true})
+	}
+}
+
+// polygonsBox returns the bounding box of a set of polygons.
+func polygonsBox(polygons [][]V2) Box2 {
+	bb := Box2{polygons[0][0], polygons[0][0]}
+	for _, v := range polygons {
+		for _, p := range v {
+			bb = bb.Extend(Box2{p, p})
+		}
+	}
+	return bb
+}
+
+// frame moves the contents of a page inside a border, with a title below them, and sets
+// the page size.
+func (pg *drawingPage) frame(margin, ts float64, title string) {
+	// bounding box of the contents
+	bb := Box2{V2{math.Inf(1), math.Inf(1)}, V2{math.Inf(-1), math.Inf(-1)}}
+	for _, p := range pg.paths {
+		for _, v := range p.p {
+			bb = bb.Extend(Box2{v, v})
+		}
+	}
+	for _, t := range pg.texts {
+		r := 0.5*t.width() + t.size
+		bb = bb.Extend(Box2{t.p.SubScalar(r), t.p.AddScalar(r)})
+	}
+	ofs := V2{margin, margin + 2*ts}.Sub(bb.Min)
+	for i := range pg.paths {
+		for j := range pg.paths[i].p {
+			pg.paths[i].p[j] = pg.paths[i].p[j].Add(ofs)
+		}
+	}
+	for i := range pg.texts {
+		pg.texts[i].p = pg.texts[i].p.Add(ofs)
+	}
+	pg.size = bb.Size().Add(V2{2 * margin, 2*margin + 2*ts})
+	w, h := pg.size.X, pg.size.Y
+	pg.line(layerLabel, V2{ts, ts}, V2{w - ts, ts}, V2{w - ts, h - ts}, V2{ts, h - ts}, V2{ts, ts})
+	pg.text(V2{0.5 * w, margin}, ts, 0, title)
+}
+
+//-----------------------------------------------------------------------------
+
+// NewDrawing returns a drawing of a part.
+func NewDrawing(s SDF3, k *DrawingParms) (*Drawing, error) {
+	if s == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if k.MeshCells < 0 {
+		return nil, errors.New("mesh cells < 0")
+	}
+	if k.Tolerance < 0 {
+		return nil, errors.New("tolerance < 0")
+	}
+	size := s.BoundingBox().Size()
+	l := size.MaxComponent()
+	cells := k.MeshCells
+	if cells == 0 {
+		cells = 200
+	}
+	tol := k.Tolerance
+	if tol == 0 {
+		tol = 1e-3 * l
+	}
+	// text size, spacing between views and page margin
+	ts := Max(0.03*l, 2.5)
+	gap := 6 * ts
+	margin := 4 * ts
+
+	// sections
+	sections := make([][][]V2, len(k.Sections))
+	for i, sec := range k.Sections {
+		if sec.Normal.Length() == 0 {
+			return nil, errors.New("section normal is zero")
+		}
+		v, err := Polygonize2D(Slice2D(s, sec.Point, sec.Normal), cells, tol)
+		if err != nil {
+			return nil, err
+		}
+		if len(v) == 0 {
+			return nil, fmt.Errorf("section %s doesn't cut the part", sec.Name)
+		}
+		sections[i] = v
+	}
+
+	// silhouettes: the front view is x right and z up, the top view is x right and y up,
+	// the right side view is y right and z up
+	views := make([][][]V2, 3)
+	axes := [3][2]V3{{{1, 0, 0}, {0, 0, 1}}, {{1, 0, 0}, {0, 1, 0}}, {{0, 1, 0}, {0, 0, 1}}}
+	for i := range views {
+		v, err := Polygonize2D(Project2D(s, axes[i][0], axes[i][1]), cells, tol)
+		if err != nil {
+			return nil, err
+		}
+		if len(v) == 0 {
+			return nil, errors.New("empty view")
+		}
+		views[i] = v
+	}
+	front, top, side := polygonsBox(views[0]), polygonsBox(views[1]), polygonsBox(views[2])
+	fs := front.Size()
+
+	var d Drawing
+	pg := &drawingPage{}
+	// layout: the front view at the bottom left, the top view above it, the side view to the right
+	fo := front.Min.Neg()
+	to := V2{0, fs.Y + gap}.Sub(V2{front.Min.X, top.Min.Y})
+	so := V2{fs.X + gap, 0}.Sub(V2{side.Min.X, front.Min.Y})
+	pg.outline(views[0], fo)
+	pg.outline(views[1], to)
+	pg.outline(views[2], so)
+	f := Box2{front.Min.Add(fo), front.Max.Add(fo)}
+	t := Box2{top.Min.Add(to), top.Max.Add(to)}
+	r := Box2{side.Min.Add(so), side.Max.Add(so)}
+	// overall dimensions: width and height on the front view, depth on the top view
+	pg.dimension(V2{f.Max.X, f.Min.Y}, f.Min, 0.5*gap, ts)
+	pg.dimension(f.Min, V2{f.Min.X, f.Max.Y}, 0.5*gap, ts)
+	pg.dimension(t.Min, V2{t.Min.X, t.Max.Y}, 0.5*gap, ts)
+	pg.text(V2{t.Center().X, t.Max.Y + ts}, ts, 0, "TOP")
+	pg.text(V2{f.Center().X, f.Max.Y + ts}, ts, 0, "FRONT")
+	pg.text(V2{r.Center().X, r.Max.Y + ts}, ts, 0, "RIGHT")
+	d.pages = append(d.pages, pg)
+
+	// section views
+	for i, v := range sections {
+		bb := polygonsBox(v)
+		pg := &drawingPage{}
+		pg.hatch(v, ts)
+		pg.outline(v, V2{})
+		pg.dimension(V2{bb.Max.X, bb.Min.Y}, bb.Min, 0.5*gap, ts)
+		pg.dimension(bb.Min, V2{bb.Min.X, bb.Max.Y}, 0.5*gap, ts)
+		name := k.Sections[i].Name
+		pg.text(V2{bb.Center().X, bb.Max.Y + ts}, ts, 0, fmt.Sprintf("SECTION %s-%s", name, name))
+		d.pages = append(d.pages, pg)
+	}
+
+	// page borders and titles
+	for i, pg := range d.pages {
+		title := fmt.Sprintf("%s  %.2f x %.2f x %.2f  page %d/%d", k.Title, size.X, size.Y, size.Z, i+1, len(d.pages))
+		pg.frame(margin, ts, strings.TrimSpace(title))
+	}
+	return &d, nil
+}
+
+// Pages returns the number of pages in a drawing.
+func (d *Drawing) Pages() int {
+	return len(d.pages)
+}
+
+//-----------------------------------------------------------------------------
+
+// SaveSVG writes a drawing to an SVG file, with the pages one above the other.
+func (d *Drawing) SaveSVG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	width, height := 0.0, 0.0
+	for _, pg := range d.pages {
+		width = Max(width, pg.size.X)
+		height += pg.size.Y
+	}
+	canvas := svg.New(f)
+	canvas.Start(width, height)
+	y0 := 0.0
+	for i, pg := range d.pages {
+		// svg y is down
+		top := y0 + pg.size.Y
+		canvas.Gid(fmt.Sprintf("page%d", i+1))
+		for layer := drawingLayer(0); layer < numLayers; layer++ {
+			style := fmt.Sprintf("fill:none;stroke:black;stroke-width:%g", layerWidths[layer])
+			canvas.Group(fmt.Sprintf(`id="%s%d"`, layerNames[layer], i+1), style)
+			for _, p := range pg.paths {
+				if p.layer != layer {
+					continue
+				}
+				x := make([]float64, len(p.p))
+				y := make([]float64, len(p.p))
+				for j, v := range p.p {
+					x[j], y[j] = v.X, top-v.Y
+				}
+				switch {
+				case p.fill:
+					canvas.Polygon(x, y, "fill:black;stroke:none")
+				case p.closed:
+					canvas.Polygon(x, y)
+				default:
+					canvas.Polyline(x, y)
+				}
+			}
+			if layer == layerLabel {
+				for _, t := range pg.texts {
+					x, y := t.p.X, top-t.p.Y
+					canvas.Text(x, y, t.s,
+						`text-anchor="middle"`,
+						fmt.Sprintf(`font-size="%g"`, t.size),
+						fmt.Sprintf(`transform="rotate(%g %g %g)"`, -RtoD(t.angle), x, y),
+						"font-family:sans-serif;fill:black;stroke:none")
+				}
+			}
+			canvas.Gend()
+		}
+		canvas.Gend()
+		y0 = top
+	}
+	canvas.End()
+	return f.Close()
+}
+
+//-----------------------------------------------------------------------------
+
+// pdfPointsPerMM converts mm to PDF points.
+const pdfPointsPerMM = 72 / 25.4
+
+// pdfString returns a PDF string literal.
+func pdfString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return "(" + r.Replace(s) + ")"
+}
+
+// pdfContent returns the content stream for a drawing page.
+func (pg *drawingPage) pdfContent() []byte {
+	var b bytes.Buffer
+	// draw in mm
+	fmt.Fprintf(&b, "%.6f 0 0 %.6f 0 0 cm\n", pdfPointsPerMM, pdfPointsPerMM)
+	fmt.Fprintf(&b, "1 J 1 j\n")
+	for layer := drawingLayer(0); layer < numLayers; layer++ {
+		fmt.Fprintf(&b, "%g w\n", layerWidths[layer])
+		for _, p := range pg.paths {
+			if p.layer != layer {
+				continue
+			}
+			for i, v := range p.p {
+				op := "l"
+				if i == 0 {
+					op = "m"
+				}
+				fmt.Fprintf(&b, "%.3f %.3f %s\n", v.X, v.Y, op)
+			}
+			switch {
+			case p.fill:
+				fmt.Fprintf(&b, "f\n")
+			case p.closed:
+				fmt.Fprintf(&b, "s\n")
+			default:
+				fmt.Fprintf(&b, "S\n")
+			}
+		}
+	}
+	for _, t := range pg.texts {
+		// center the text with an estimated width
+		sin, cos := math.Sincos(t.angle)
+		w := t.width()
+		x, y := t.p.X-0.5*w*cos, t.p.Y-0.5*w*sin
+		fmt.Fprintf(&b, "BT /F1 %.3f Tf %.6f %.6f %.6f %.6f %.3f %.3f Tm %s Tj ET\n",
+			t.size, cos, sin, -sin, cos, x, y, pdfString(t.s))
+	}
+	return b.Bytes()
+}
+
+// SavePDF writes a drawing to a multi-page PDF file.
+func (d *Drawing) SavePDF(path string) error {
+	var b bytes.Buffer
+	var offsets []int
+	obj := func(format string, args ...interface{}) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&b, format, args...)
+		fmt.Fprintf(&b, "\nendobj\n")
+	}
+	b.WriteString("%PDF-1.4\n")
+	// objects: 1 catalog, 2 pages, 3 font, then a page and its contents for each page
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*i))
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	for i, pg := range d.pages {
+		obj("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pg.size.X*pdfPointsPerMM, pg.size.Y*pdfPointsPerMM, 5+2*i)
+		content := pg.pdfContent()
+		obj("<< /Length %d >>\nstream\n%s\nendstream", len(content)+1, content)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, ofs := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", ofs)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
+
+//-----------------------------------------------------------------------------
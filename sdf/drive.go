@@ -0,0 +1,179 @@
+//-----------------------------------------------------------------------------
+/*
+
+Drive Recesses and Bits
+
+Profiles for the common drives: hex socket, hexalobular (Torx-like 6-lobe)
+and square drive. Recesses (to subtract from a knob or screw head) are
+enlarged by the clearance, bits are reduced by it, so a printed bit fits a
+printed recess with twice the clearance.
+
+Sizes:
+
+hex: across flats
+square: across flats
+hexalobular: point to point (the A dimension of ISO 10664)
+
+The hexalobular profile has convex lobes and concave flutes made from
+tangent circular arcs, with the proportions of ISO 10664 (B = 0.7165 A,
+lobe radius = 0.1 A).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// DriveStyle is the type of a drive recess.
+type DriveStyle int
+
+const (
+	// HexDrive is a hex socket.
+	HexDrive DriveStyle = iota
+	// HexalobularDrive is a 6-lobe (Torx-like) drive.
+	HexalobularDrive
+	// SquareDrive is a square drive.
+	SquareDrive
+)
+
+// hexalobularSizes are the point to point sizes of the common hexalobular drives.
+var hexalobularSizes = map[int]float64{
+	6:  1.75,
+	8:  2.40,
+	9:  2.50,
+	10: 2.80,
+	15: 3.35,
+	20: 3.95,
+	25: 4.50,
+	27: 5.05,
+	30: 5.60,
+	40: 6.75,
+	45: 7.93,
+	50: 8.95,
+	55: 11.35,
+}
+
+// HexalobularSize returns the point to point size of a numbered hexalobular drive, E.g. 20 for a T20.
+func HexalobularSize(n int) (float64, error) {
+	size, ok := hexalobularSizes[n]
+	if !ok {
+		return 0, fmt.Errorf("hexalobular size %d not found", n)
+	}
+	return size, nil
+}
+
+// hexalobular returns the vertices of a hexalobular profile.
+func hexalobular(size float64, facets int) []V2 {
+	re := 0.1 * size // lobe radius
+	a := 0.5*size - re
+	b := 0.5 * 0.7165 * size
+	c := math.Cos(Pi / 6)
+	// the flute is tangent to the lobes and to the inner circle
+	ri := (re*re - a*a - b*b + 2*a*b*c) / (2 * (b - a*c - re))
+	c1 := V2{a, 0}
+	c2 := PolarToXY(b+ri, Pi/6)
+	d := c2.Sub(c1)
+	lobe := math.Atan2(d.Y, d.X)
+	flute := math.Atan2(-d.Y, -d.X)
+
+	var v []V2
+	for k := 0; k < 6; k++ {
+		m := Rotate(Tau * float64(k) / 6)
+		// convex lobe
+		for i := 0; i < facets; i++ {
+			theta := -lobe + 2*lobe*float64(i)/float64(facets)
+			v = append(v, m.MulPosition(c1.Add(PolarToXY(re, theta))))
+		}
+		// concave flute, the end angle is the start angle mirrored about the flute axis
+		t0 := flute
+		t1 := Pi/3 - flute
+		for t1 > t0 {
+			t1 -= Tau
+		}
+		for i := 0; i < facets; i++ {
+			theta := t0 + (t1-t0)*float64(i)/float64(facets)
+			v = append(v, m.MulPosition(c2.Add(PolarToXY(ri, theta))))
+		}
+	}
+	return v
+}
+
+// DriveProfile2D returns the nominal 2D profile of a drive centered on the origin.
+func DriveProfile2D(
+	style DriveStyle, // drive style
+	size float64, // drive size (see notes above)
+) (SDF2, error) {
+	if size <= 0 {
+		return nil, errors.New("size <= 0")
+	}
+	switch style {
+	case HexDrive:
+		return Polygon2D(Nagon(6, 0.5*size/math.Cos(Pi/6))), nil
+	case HexalobularDrive:
+		return Polygon2D(hexalobular(size, 8)), nil
+	case SquareDrive:
+		return Box2D(V2{size, size}, 0), nil
+	}
+	return nil, errors.New("bad drive style")
+}
+
+//-----------------------------------------------------------------------------
+
+// DriveParms defines the parameters for a drive recess or bit.
+type DriveParms struct {
+	Style     DriveStyle // drive style
+	Size      float64    // drive size (see notes above)
+	Depth     float64    // depth of the recess (length of the bit)
+	Clearance float64    // radial clearance (added to a recess, removed from a bit)
+}
+
+// driveProfile returns the profile of a drive offset by the clearance.
+func (k *DriveParms) driveProfile(offset float64) (SDF2, error) {
+	if k.Depth <= 0 {
+		return nil, errors.New("depth <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+	s, err := DriveProfile2D(k.Style, k.Size)
+	if err != nil {
+		return nil, err
+	}
+	if offset != 0 {
+		s = Offset2D(s, offset)
+	}
+	return s, nil
+}
+
+// DriveRecess3D returns a drive recess, to be subtracted from a part with its surface on the
+// xy-plane. The recess goes down from z = 0 to z = -Depth, it runs through the surface by 10%
+// of the depth so the difference is clean.
+func DriveRecess3D(k *DriveParms) (SDF3, error) {
+	s, err := k.driveProfile(k.Clearance)
+	if err != nil {
+		return nil, err
+	}
+	h := 1.1 * k.Depth
+	return Transform3D(Extrude3D(s, h), Translate3d(V3{0, 0, 0.5*h - k.Depth})), nil
+}
+
+// DriveBit3D returns a drive bit, going up from z = 0 to z = Depth.
+func DriveBit3D(k *DriveParms) (SDF3, error) {
+	if k.Clearance >= 0.25*k.Size {
+		return nil, errors.New("clearance is too large")
+	}
+	s, err := k.driveProfile(-k.Clearance)
+	if err != nil {
+		return nil, err
+	}
+	return Transform3D(Extrude3D(s, k.Depth), Translate3d(V3{0, 0, 0.5 * k.Depth})), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,205 @@
+//-----------------------------------------------------------------------------
+/*
+
+Profile Edge Fillets and Chamfers
+
+Revolved and extruded SDF3s keep the 2D profile they were made from. When the
+profile is a polygon each profile vertex generates an edge of the solid (a
+circular edge for a revolution, a straight edge along z for an extrusion).
+These functions rebuild the solid with a fillet or chamfer at a profile vertex.
+
+Vertices can be selected by position, E.g. "top outer" is the highest vertex
+of the profile, and the outermost of those if there are several. For revolved
+profiles x is the radius, so inner/outer are the same as left/right.
+
+Filleting a vertex adds vertices to the profile, so select the vertex again
+(or work from the highest vertex index down) when doing several edges.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// profilePolygon returns the polygon profile that generates an SDF3.
+func profilePolygon(s SDF3) (*PolySDF2, error) {
+	var p SDF2
+	switch x := s.(type) {
+	case *SorSDF3:
+		p = x.sdf
+	case *ExtrudeSDF3:
+		p = x.sdf
+	case *TransformSDF3:
+		return profilePolygon(x.sdf)
+	default:
+		return nil, fmt.Errorf("%T is not a revolved or extruded SDF3", s)
+	}
+	poly, ok := p.(*PolySDF2)
+	if !ok {
+		return nil, fmt.Errorf("the profile (%T) is not a polygon", p)
+	}
+	return poly, nil
+}
+
+// withProfile returns an SDF3 rebuilt with a new profile.
+func withProfile(s SDF3, p SDF2) SDF3 {
+	switch x := s.(type) {
+	case *SorSDF3:
+		return RevolveTheta3D(p, x.theta)
+	case *ExtrudeSDF3:
+		e := *x
+		e.sdf = p
+		return &e
+	case *TransformSDF3:
+		return Transform3D(withProfile(x.sdf, p), x.matrix)
+	}
+	return nil
+}
+
+// ProfileVertices returns the vertices of the polygon profile of a revolved or extruded SDF3.
+func ProfileVertices(s SDF3) ([]V2, error) {
+	p, err := profilePolygon(s)
+	if err != nil {
+		return nil, err
+	}
+	// drop the closing vertex
+	v := p.vertex[:len(p.vertex)-1]
+	return append([]V2(nil), v...), nil
+}
+
+// ProfileVertex returns the index of a profile vertex selected by position.
+// The position is a list of words from top/bottom, left/right and inner/outer.
+// Earlier words take priority, E.g. "top outer" is the outermost of the highest vertices.
+func ProfileVertex(s SDF3, position string) (int, error) {
+	v, err := ProfileVertices(s)
+	if err != nil {
+		return 0, err
+	}
+	words := strings.Fields(strings.ToLower(position))
+	if len(words) == 0 {
+		return 0, errors.New("no position")
+	}
+	// vertex scores for each word
+	score := make([]func(p V2) float64, len(words))
+	for i, w := range words {
+		switch w {
+		case "top":
+			score[i] = func(p V2) float64 { return p.Y }
+		case "bottom":
+			score[i] = func(p V2) float64 { return -p.Y }
+		case "right", "outer":
+			score[i] = func(p V2) float64 { return p.X }
+		case "left", "inner":
+			score[i] = func(p V2) float64 { return -p.X }
+		default:
+			return 0, fmt.Errorf("bad position \"%s\"", w)
+		}
+	}
+	tol := 1e-9 * Box2{V2Set(v).Min(), V2Set(v).Max()}.Size().MaxComponent()
+	candidates := make([]int, len(v))
+	for i := range candidates {
+		candidates[i] = i
+	}
+	for _, f := range score {
+		best := math.Inf(-1)
+		for _, i := range candidates {
+			best = Max(best, f(v[i]))
+		}
+		var next []int
+		for _, i := range candidates {
+			if f(v[i]) >= best-tol {
+				next = append(next, i)
+			}
+		}
+		candidates = next
+	}
+	if len(candidates) != 1 {
+		return 0, fmt.Errorf("position \"%s\" matches %d vertices", position, len(candidates))
+	}
+	return candidates[0], nil
+}
+
+// profileCorner returns the profile vertices and the interior angle at a vertex.
+func profileCorner(s SDF3, vertex int) ([]V2, float64, error) {
+	v, err := ProfileVertices(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	n := len(v)
+	if vertex < 0 || vertex >= n {
+		return nil, 0, errors.New("bad vertex index")
+	}
+	v0 := v[(vertex+n-1)%n].Sub(v[vertex]).Normalize()
+	v1 := v[(vertex+1)%n].Sub(v[vertex]).Normalize()
+	theta := math.Acos(Clamp(v0.Dot(v1), -1, 1))
+	if theta < 1e-6 || theta > Pi-1e-6 {
+		return nil, 0, errors.New("the vertex is not a corner")
+	}
+	return v, theta, nil
+}
+
+// smoothProfile returns an SDF3 with a profile vertex replaced by a circular arc.
+func smoothProfile(s SDF3, v []V2, vertex int, radius float64, facets int) (SDF3, error) {
+	p := NewPolygon()
+	for i, x := range v {
+		pv := p.AddV2(x)
+		if i == vertex {
+			pv.Smooth(radius, facets)
+		}
+	}
+	p.Close()
+	vs := p.Vertices()
+	if len(vs) == len(v) {
+		return nil, errors.New("the fillet/chamfer is too large for the adjacent edges")
+	}
+	return withProfile(s, Polygon2D(vs)), nil
+}
+
+// FilletEdge3D returns a revolved or extruded SDF3 with a fillet on the edge generated by a
+// profile vertex.
+func FilletEdge3D(
+	s SDF3, // revolved or extruded SDF3 with a polygon profile
+	vertex int, // profile vertex index
+	radius float64, // fillet radius
+	facets int, // number of facets on the fillet
+) (SDF3, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if facets <= 0 {
+		return nil, errors.New("facets <= 0")
+	}
+	v, _, err := profileCorner(s, vertex)
+	if err != nil {
+		return nil, err
+	}
+	return smoothProfile(s, v, vertex, radius, facets)
+}
+
+// ChamferEdge3D returns a revolved or extruded SDF3 with a chamfer on the edge generated by a
+// profile vertex. The chamfer cuts back both adjacent profile edges by the size.
+func ChamferEdge3D(
+	s SDF3, // revolved or extruded SDF3 with a polygon profile
+	vertex int, // profile vertex index
+	size float64, // chamfer size along each edge
+) (SDF3, error) {
+	if size <= 0 {
+		return nil, errors.New("size <= 0")
+	}
+	v, theta, err := profileCorner(s, vertex)
+	if err != nil {
+		return nil, err
+	}
+	// a single facet smoothing with the tangent points at the chamfer size
+	return smoothProfile(s, v, vertex, size*math.Tan(0.5*theta), 1)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,275 @@
+//-----------------------------------------------------------------------------
+/*
+
+Fastener Dimensions
+
+Standard dimensions for bolts, nuts, washers and clearance holes.
+
+ISO (mm):
+Hex head bolts: ISO 4017, Socket head cap screws: ISO 4762, Hex nuts: ISO 4032,
+Plain washers: ISO 7089, Clearance holes: ISO 273 (fine/medium/coarse).
+
+ANSI (inch):
+Hex head bolts: ASME B18.2.1, Socket head cap screws: ASME B18.3,
+Hex nuts: ASME B18.2.2, SAE flat washers, Clearance holes (close/normal/loose fit).
+
+The values are the nominal (or maximum) dimensions.
+
+Fastener Builders
+
+Hex bolts, hex nuts and flat washers built from the ISO tables. The parts
+stand on the z = 0 plane (the print orientation), the bolt has its head
+down. The threads can have an ISO tolerance class and a printer clearance
+(see ISOThread3D).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// FastenerParameters stores the standard dimensions for a fastener size.
+type FastenerParameters struct {
+	Name            string  // name of fastener size, E.g. "M5" or "1/4"
+	Thread          string  // name of the coarse thread in the thread database
+	Units           string  // "inch" or "mm"
+	Diameter        float64 // nominal major diameter
+	Pitch           float64 // coarse thread pitch
+	HexFlat2Flat    float64 // hex head flat to flat distance
+	HexHeight       float64 // hex head height
+	SocketDiameter  float64 // socket head diameter
+	SocketHeight    float64 // socket head height
+	SocketKey       float64 // socket head hex key size
+	NutFlat2Flat    float64 // hex nut flat to flat distance
+	NutHeight       float64 // hex nut height
+	WasherInner     float64 // washer inner diameter
+	WasherOuter     float64 // washer outer diameter
+	WasherThickness float64 // washer thickness
+	ClearanceClose  float64 // close fit clearance hole diameter
+	ClearanceNormal float64 // normal fit clearance hole diameter
+	ClearanceLoose  float64 // loose fit clearance hole diameter
+}
+
+type fastenerDatabase map[string]*FastenerParameters
+
+var isoFastenerDB = initISOFasteners()
+var ansiFastenerDB = initANSIFasteners()
+
+// fastenerAdd adds a row of dimensions to a fastener database.
+// The row is: diameter, pitch, hex f2f, hex height, socket diameter, socket height, socket key,
+// nut f2f, nut height, washer id, washer od, washer thickness, clearance close, normal, loose.
+func (m fastenerDatabase) fastenerAdd(name, thread, units string, x [15]float64) {
+	m[name] = &FastenerParameters{
+		Name:            name,
+		Thread:          thread,
+		Units:           units,
+		Diameter:        x[0],
+		Pitch:           x[1],
+		HexFlat2Flat:    x[2],
+		HexHeight:       x[3],
+		SocketDiameter:  x[4],
+		SocketHeight:    x[5],
+		SocketKey:       x[6],
+		NutFlat2Flat:    x[7],
+		NutHeight:       x[8],
+		WasherInner:     x[9],
+		WasherOuter:     x[10],
+		WasherThickness: x[11],
+		ClearanceClose:  x[12],
+		ClearanceNormal: x[13],
+		ClearanceLoose:  x[14],
+	}
+}
+
+// initISOFasteners returns the ISO fastener database.
+func initISOFasteners() fastenerDatabase {
+	m := make(fastenerDatabase)
+	// d, p, s, k, dk, k, key, s, m, d1, d2, h, fine, medium, coarse
+	m.fastenerAdd("M1.6", "M1.6x0.35", "mm", [15]float64{1.6, 0.35, 3.2, 1.1, 3, 1.6, 1.5, 3.2, 1.3, 1.7, 4, 0.3, 1.7, 1.8, 2})
+	m.fastenerAdd("M2", "M2x0.4", "mm", [15]float64{2, 0.4, 4, 1.4, 3.8, 2, 1.5, 4, 1.6, 2.2, 5, 0.3, 2.2, 2.4, 2.6})
+	m.fastenerAdd("M2.5", "M2.5x0.45", "mm", [15]float64{2.5, 0.45, 5, 1.7, 4.5, 2.5, 2, 5, 2, 2.7, 6, 0.5, 2.7, 2.9, 3.1})
+	m.fastenerAdd("M3", "M3x0.5", "mm", [15]float64{3, 0.5, 5.5, 2, 5.5, 3, 2.5, 5.5, 2.4, 3.2, 7, 0.5, 3.2, 3.4, 3.6})
+	m.fastenerAdd("M4", "M4x0.7", "mm", [15]float64{4, 0.7, 7, 2.8, 7, 4, 3, 7, 3.2, 4.3, 9, 0.8, 4.3, 4.5, 4.8})
+	m.fastenerAdd("M5", "M5x0.8", "mm", [15]float64{5, 0.8, 8, 3.5, 8.5, 5, 4, 8, 4.7, 5.3, 10, 1, 5.3, 5.5, 5.8})
+	m.fastenerAdd("M6", "M6x1", "mm", [15]float64{6, 1, 10, 4, 10, 6, 5, 10, 5.2, 6.4, 12, 1.6, 6.4, 6.6, 7})
+	m.fastenerAdd("M8", "M8x1.25", "mm", [15]float64{8, 1.25, 13, 5.3, 13, 8, 6, 13, 6.8, 8.4, 16, 1.6, 8.4, 9, 10})
+	m.fastenerAdd("M10", "M10x1.5", "mm", [15]float64{10, 1.5, 16, 6.4, 16, 10, 8, 16, 8.4, 10.5, 20, 2, 10.5, 11, 12})
+	m.fastenerAdd("M12", "M12x1.75", "mm", [15]float64{12, 1.75, 18, 7.5, 18, 12, 10, 18, 10.8, 13, 24, 2.5, 13, 13.5, 14.5})
+	m.fastenerAdd("M16", "M16x2", "mm", [15]float64{16, 2, 24, 10, 24, 16, 14, 24, 14.8, 17, 30, 3, 17, 17.5, 18.5})
+	m.fastenerAdd("M20", "M20x2.5", "mm", [15]float64{20, 2.5, 30, 12.5, 30, 20, 17, 30, 18, 21, 37, 3, 21, 22, 24})
+	m.fastenerAdd("M24", "M24x3", "mm", [15]float64{24, 3, 36, 15, 36, 24, 19, 36, 21.5, 25, 44, 4, 25, 26, 28})
+	m.fastenerAdd("M30", "M30x3.5", "mm", [15]float64{30, 3.5, 46, 18.7, 45, 30, 22, 46, 25.6, 31, 56, 4, 31, 33, 35})
+	m.fastenerAdd("M36", "M36x4", "mm", [15]float64{36, 4, 55, 22.5, 54, 36, 27, 55, 31, 37, 66, 5, 37, 39, 42})
+	return m
+}
+
+// initANSIFasteners returns the ANSI fastener database.
+func initANSIFasteners() fastenerDatabase {
+	m := make(fastenerDatabase)
+	// d, p, F, H, A, H, key, F, H, id, od, t, close, normal, loose
+	m.fastenerAdd("1/4", "unc_1/4", "inch", [15]float64{1.0 / 4.0, 1.0 / 20.0, 7.0 / 16.0, 11.0 / 64.0, 0.375, 1.0 / 4.0, 3.0 / 16.0, 7.0 / 16.0, 7.0 / 32.0, 0.281, 0.625, 0.065, 0.257, 0.266, 0.281})
+	m.fastenerAdd("5/16", "unc_5/16", "inch", [15]float64{5.0 / 16.0, 1.0 / 18.0, 1.0 / 2.0, 7.0 / 32.0, 0.469, 5.0 / 16.0, 1.0 / 4.0, 1.0 / 2.0, 17.0 / 64.0, 0.344, 0.688, 0.065, 0.323, 0.332, 0.344})
+	m.fastenerAdd("3/8", "unc_3/8", "inch", [15]float64{3.0 / 8.0, 1.0 / 16.0, 9.0 / 16.0, 1.0 / 4.0, 0.562, 3.0 / 8.0, 5.0 / 16.0, 9.0 / 16.0, 21.0 / 64.0, 0.406, 0.812, 0.065, 0.386, 0.397, 0.406})
+	m.fastenerAdd("7/16", "unc_7/16", "inch", [15]float64{7.0 / 16.0, 1.0 / 14.0, 5.0 / 8.0, 19.0 / 64.0, 0.656, 7.0 / 16.0, 3.0 / 8.0, 11.0 / 16.0, 3.0 / 8.0, 0.469, 0.922, 0.065, 0.453, 0.469, 0.5})
+	m.fastenerAdd("1/2", "unc_1/2", "inch", [15]float64{1.0 / 2.0, 1.0 / 13.0, 3.0 / 4.0, 11.0 / 32.0, 0.75, 1.0 / 2.0, 3.0 / 8.0, 3.0 / 4.0, 7.0 / 16.0, 0.531, 1.062, 0.095, 0.516, 0.531, 0.562})
+	m.fastenerAdd("5/8", "unc_5/8", "inch", [15]float64{5.0 / 8.0, 1.0 / 11.0, 15.0 / 16.0, 27.0 / 64.0, 0.938, 5.0 / 8.0, 1.0 / 2.0, 15.0 / 16.0, 35.0 / 64.0, 0.656, 1.312, 0.095, 0.641, 0.656, 0.688})
+	m.fastenerAdd("3/4", "unc_3/4", "inch", [15]float64{3.0 / 4.0, 1.0 / 10.0, 9.0 / 8.0, 1.0 / 2.0, 1.125, 3.0 / 4.0, 5.0 / 8.0, 9.0 / 8.0, 41.0 / 64.0, 0.812, 1.469, 0.134, 0.766, 0.781, 0.812})
+	m.fastenerAdd("7/8", "unc_7/8", "inch", [15]float64{7.0 / 8.0, 1.0 / 9.0, 21.0 / 16.0, 37.0 / 64.0, 1.312, 7.0 / 8.0, 3.0 / 4.0, 21.0 / 16.0, 3.0 / 4.0, 0.938, 1.75, 0.134, 0.891, 0.906, 0.938})
+	m.fastenerAdd("1", "unc_1", "inch", [15]float64{1, 1.0 / 8.0, 3.0 / 2.0, 43.0 / 64.0, 1.5, 1, 3.0 / 4.0, 3.0 / 2.0, 55.0 / 64.0, 1.062, 2, 0.134, 1.016, 1.031, 1.062})
+	return m
+}
+
+// ISOBolt returns the dimensions of an ISO metric fastener, E.g. "M5".
+func ISOBolt(name string) (*FastenerParameters, error) {
+	if f, ok := isoFastenerDB[name]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("ISO fastener \"%s\" not found", name)
+}
+
+// ANSIBolt returns the dimensions of an ANSI inch fastener, E.g. "1/4".
+func ANSIBolt(name string) (*FastenerParameters, error) {
+	if f, ok := ansiFastenerDB[name]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("ANSI fastener \"%s\" not found", name)
+}
+
+// HexRadius returns the corner radius of the hex head.
+func (f *FastenerParameters) HexRadius() float64 {
+	return f.HexFlat2Flat / (2.0 * math.Cos(DtoR(30)))
+}
+
+// NutRadius returns the corner radius of the hex nut.
+func (f *FastenerParameters) NutRadius() float64 {
+	return f.NutFlat2Flat / (2.0 * math.Cos(DtoR(30)))
+}
+
+//-----------------------------------------------------------------------------
+// Fastener Builders
+
+// HexBoltParms defines the parameters for a hex head bolt.
+type HexBoltParms struct {
+	Size        string  // ISO fastener size, E.g. "M5"
+	Length      float64 // length under the head
+	ShankLength float64 // unthreaded length under the head
+	Class       string  // ISO 965 thread tolerance class (E.g. "6g", "" = basic size)
+	Clearance   float64 // radial clearance between mating threads
+	Hand        Hand    // thread hand
+}
+
+// HexBolt3D returns a hex head bolt (ISO 4017) with the head on the z = 0 plane.
+func HexBolt3D(k *HexBoltParms) (SDF3, error) {
+	f, err := ISOBolt(k.Size)
+	if err != nil {
+		return nil, err
+	}
+	if k.Length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	if k.ShankLength < 0 || k.ShankLength >= k.Length {
+		return nil, errors.New("bad shank length")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+	h := f.HexHeight
+
+	head := HexHead3D(f.HexRadius(), h, "t")
+	head = Transform3D(head, Translate3d(V3{0, 0, 0.5 * h}))
+
+	// extend the thread into the shank (or head) to hide its bottom chamfer
+	depth := ISOThreadDepth(f.Pitch)
+	l := k.Length - k.ShankLength + depth
+	thread, err := ISOThread3D(&ISOThreadParms{
+		Diameter:  f.Diameter,
+		Pitch:     f.Pitch,
+		Length:    l,
+		LeadIn:    true,
+		Hand:      k.Hand,
+		Class:     k.Class,
+		Clearance: k.Clearance,
+	})
+	if err != nil {
+		return nil, err
+	}
+	thread = Transform3D(thread, Translate3d(V3{0, 0, h + k.Length - 0.5*l}))
+
+	var shank SDF3
+	if k.ShankLength > 0 {
+		ext, _ := threadClearance(k.Clearance)
+		shank = Cylinder3D(k.ShankLength+depth, 0.5*f.Diameter-ext, 0)
+		shank = Transform3D(shank, Translate3d(V3{0, 0, h + 0.5*(k.ShankLength-depth)}))
+	}
+	return Union3D(head, shank, thread), nil
+}
+
+// HexNutParms defines the parameters for a hex nut.
+type HexNutParms struct {
+	Size      string  // ISO fastener size, E.g. "M5"
+	Class     string  // ISO 965 thread tolerance class (E.g. "6H", "" = basic size)
+	Clearance float64 // radial clearance between mating threads
+	Hand      Hand    // thread hand
+}
+
+// HexNut3D returns a hex nut (ISO 4032) on the z = 0 plane.
+func HexNut3D(k *HexNutParms) (SDF3, error) {
+	f, err := ISOBolt(k.Size)
+	if err != nil {
+		return nil, err
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+	h := f.NutHeight
+	nut := HexHead3D(f.NutRadius(), h, "tb")
+	thread, err := ISOThread3D(&ISOThreadParms{
+		Diameter:  f.Diameter,
+		Pitch:     f.Pitch,
+		Internal:  true,
+		Length:    h,
+		LeadIn:    true,
+		Hand:      k.Hand,
+		Class:     k.Class,
+		Clearance: k.Clearance,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Transform3D(Difference3D(nut, thread), Translate3d(V3{0, 0, 0.5 * h})), nil
+}
+
+// FlatWasherParms defines the parameters for a flat washer.
+type FlatWasherParms struct {
+	Size      string  // ISO fastener size, E.g. "M5"
+	Clearance float64 // radial clearance added to the hole
+}
+
+// FlatWasher3D returns a plain washer (ISO 7089) on the z = 0 plane.
+func FlatWasher3D(k *FlatWasherParms) (SDF3, error) {
+	f, err := ISOBolt(k.Size)
+	if err != nil {
+		return nil, err
+	}
+	if k.Clearance < 0 || k.Clearance >= 0.5*(f.WasherOuter-f.WasherInner) {
+		return nil, errors.New("bad clearance")
+	}
+	w, err := CheckedWasher3D(&WasherParms{
+		Thickness:   f.WasherThickness,
+		InnerRadius: 0.5*f.WasherInner + k.Clearance,
+		OuterRadius: 0.5 * f.WasherOuter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Transform3D(w, Translate3d(V3{0, 0, 0.5 * f.WasherThickness})), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,286 @@
+//-----------------------------------------------------------------------------
+/*
+
+Fits
+
+Work out the hole and shaft diameters for a mating pair from the nominal
+size and a fit, so bores and pins stay consistent when the nominal size
+changes:
+
+f, _ := ISOFit(8, "H7/g6")
+bore := Cylinder3D(h, 0.5*f.Hole(), 0)
+pin := Cylinder3D(h, 0.5*f.Shaft(), 0)
+
+ISO 286 fits (mm, nominal sizes up to 500 mm):
+
+Tolerance grades IT5 to IT12. Hole positions D, E, F, G, H, JS and shaft
+positions d, e, f, g, h, js, k, m, n, p. The hole deviations for D to G are
+the shaft deviations mirrored about the nominal size (the general rule).
+
+Common fits:
+H11/c11 loose running (not supported, c), H9/d9 free running,
+H8/f7 close running, H7/g6 sliding, H7/h6 locational clearance,
+H7/k6 locational transition, H7/n6 locational transition (tighter),
+H7/p6 locational interference.
+
+Printed fits:
+
+The tolerance band of a 3D printer is wider than most ISO grades, so the
+printed fit classes are a diametral clearance added to the hole and
+taken from the shaft (half each). The values are a starting point for FDM
+printers, calibrate them with FitCoupon3D.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+//-----------------------------------------------------------------------------
+
+// Fit stores the limits of the hole and shaft diameters for a fit.
+type Fit struct {
+	Nominal  float64 // nominal size
+	HoleMin  float64 // minimum hole diameter
+	HoleMax  float64 // maximum hole diameter
+	ShaftMin float64 // minimum shaft diameter
+	ShaftMax float64 // maximum shaft diameter
+}
+
+// Hole returns the hole diameter (the middle of the tolerance band) to model.
+func (f *Fit) Hole() float64 {
+	return 0.5 * (f.HoleMin + f.HoleMax)
+}
+
+// Shaft returns the shaft diameter (the middle of the tolerance band) to model.
+func (f *Fit) Shaft() float64 {
+	return 0.5 * (f.ShaftMin + f.ShaftMax)
+}
+
+// MinClearance returns the minimum clearance of the fit (< 0 is an interference).
+func (f *Fit) MinClearance() float64 {
+	return f.HoleMin - f.ShaftMax
+}
+
+// MaxClearance returns the maximum clearance of the fit (< 0 is an interference).
+func (f *Fit) MaxClearance() float64 {
+	return f.HoleMax - f.ShaftMin
+}
+
+// FitType is the type of a fit.
+type FitType int
+
+const (
+	// ClearanceFit always has clearance.
+	ClearanceFit FitType = iota
+	// TransitionFit may have clearance or interference.
+	TransitionFit
+	// InterferenceFit always has interference.
+	InterferenceFit
+)
+
+func (t FitType) String() string {
+	switch t {
+	case ClearanceFit:
+		return "clearance"
+	case TransitionFit:
+		return "transition"
+	}
+	return "interference"
+}
+
+// Type returns the type of the fit.
+func (f *Fit) Type() FitType {
+	if f.MinClearance() >= 0 {
+		return ClearanceFit
+	}
+	if f.MaxClearance() <= 0 {
+		return InterferenceFit
+	}
+	return TransitionFit
+}
+
+func (f *Fit) String() string {
+	return fmt.Sprintf("%g: hole %.4f..%.4f shaft %.4f..%.4f (%s)",
+		f.Nominal, f.HoleMin, f.HoleMax, f.ShaftMin, f.ShaftMax, f.Type())
+}
+
+//-----------------------------------------------------------------------------
+// ISO 286
+
+// isoSizes are the upper limits of the ISO 286 nominal size ranges (mm).
+var isoSizes = []float64{3, 6, 10, 18, 30, 50, 80, 120, 180, 250, 315, 400, 500}
+
+// isoGrades are the standard tolerances (um) for IT5 to IT12.
+var isoGrades = map[int][]float64{
+	5:  {4, 5, 6, 8, 9, 11, 13, 15, 18, 20, 23, 25, 27},
+	6:  {6, 8, 9, 11, 13, 16, 19, 22, 25, 29, 32, 36, 40},
+	7:  {10, 12, 15, 18, 21, 25, 30, 35, 40, 46, 52, 57, 63},
+	8:  {14, 18, 22, 27, 33, 39, 46, 54, 63, 72, 81, 89, 97},
+	9:  {25, 30, 36, 43, 52, 62, 74, 87, 100, 115, 130, 140, 155},
+	10: {40, 48, 58, 70, 84, 100, 120, 140, 160, 185, 210, 230, 250},
+	11: {60, 75, 90, 110, 130, 160, 190, 220, 250, 290, 320, 360, 400},
+	12: {100, 120, 150, 180, 210, 250, 300, 350, 400, 460, 520, 570, 630},
+}
+
+// isoUpper are the shaft fundamental deviations (um) which are upper deviations (es).
+var isoUpper = map[string][]float64{
+	"d": {-20, -30, -40, -50, -65, -80, -100, -120, -145, -170, -190, -210, -230},
+	"e": {-14, -20, -25, -32, -40, -50, -60, -72, -85, -100, -110, -125, -135},
+	"f": {-6, -10, -13, -16, -20, -25, -30, -36, -43, -50, -56, -62, -68},
+	"g": {-2, -4, -5, -6, -7, -9, -10, -12, -14, -15, -17, -18, -20},
+	"h": {0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+}
+
+// isoLower are the shaft fundamental deviations (um) which are lower deviations (ei).
+var isoLower = map[string][]float64{
+	"k": {0, 1, 1, 1, 2, 2, 2, 3, 3, 4, 4, 4, 5}, // IT4 to IT7, 0 otherwise
+	"m": {2, 4, 6, 7, 8, 9, 11, 13, 15, 17, 20, 21, 23},
+	"n": {4, 8, 10, 12, 15, 17, 20, 23, 27, 31, 34, 37, 40},
+	"p": {6, 12, 15, 18, 22, 26, 32, 37, 43, 50, 56, 62, 68},
+}
+
+// isoSizeIndex returns the index of the nominal size range.
+func isoSizeIndex(size float64) (int, error) {
+	if size <= 0 {
+		return 0, errors.New("size <= 0")
+	}
+	for i, x := range isoSizes {
+		if size <= x {
+			return i, nil
+		}
+	}
+	return 0, errors.New("size > 500")
+}
+
+var (
+	isoToleranceRegexp = regexp.MustCompile(`^([A-Za-z]{1,2})(\d+)$`)
+	isoFitRegexp       = regexp.MustCompile(`^([A-Z]{1,2}\d+)/([a-z]{1,2}\d+)$`)
+)
+
+// ISOTolerance returns the lower and upper deviations (mm) for an ISO 286 tolerance class,
+// E.g. "H7" (hole, upper case) or "g6" (shaft, lower case).
+func ISOTolerance(size float64, class string) (float64, float64, error) {
+	i, err := isoSizeIndex(size)
+	if err != nil {
+		return 0, 0, err
+	}
+	m := isoToleranceRegexp.FindStringSubmatch(class)
+	if m == nil {
+		return 0, 0, fmt.Errorf("bad tolerance class \"%s\"", class)
+	}
+	grade, _ := strconv.Atoi(m[2])
+	it, ok := isoGrades[grade]
+	if !ok {
+		return 0, 0, fmt.Errorf("tolerance grade IT%d not supported", grade)
+	}
+	t := it[i]
+	pos := m[1]
+	hole := pos == "H" || pos == "JS" || pos == "D" || pos == "E" || pos == "F" || pos == "G"
+	if hole {
+		pos = map[string]string{"JS": "js", "D": "d", "E": "e", "F": "f", "G": "g", "H": "h"}[pos]
+	}
+	// shaft deviations (um)
+	var lo, hi float64
+	if pos == "js" {
+		lo, hi = -0.5*t, 0.5*t
+	} else if es, ok := isoUpper[pos]; ok {
+		lo, hi = es[i]-t, es[i]
+	} else if ei, ok := isoLower[pos]; ok {
+		e := ei[i]
+		if pos == "k" && grade > 7 {
+			e = 0
+		}
+		lo, hi = e, e+t
+	} else {
+		return 0, 0, fmt.Errorf("tolerance position \"%s\" not supported", m[1])
+	}
+	if hole {
+		// the hole deviations mirror the shaft deviations
+		lo, hi = -hi, -lo
+	}
+	return 1e-3 * lo, 1e-3 * hi, nil
+}
+
+// ISOFit returns the hole and shaft limits for an ISO 286 fit, E.g. "H7/g6".
+func ISOFit(
+	size float64, // nominal size (mm)
+	fit string, // hole and shaft tolerance classes
+) (*Fit, error) {
+	m := isoFitRegexp.FindStringSubmatch(fit)
+	if m == nil {
+		return nil, fmt.Errorf("bad fit \"%s\"", fit)
+	}
+	h0, h1, err := ISOTolerance(size, m[1])
+	if err != nil {
+		return nil, err
+	}
+	s0, s1, err := ISOTolerance(size, m[2])
+	if err != nil {
+		return nil, err
+	}
+	return &Fit{
+		Nominal:  size,
+		HoleMin:  size + h0,
+		HoleMax:  size + h1,
+		ShaftMin: size + s0,
+		ShaftMax: size + s1,
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
+// Printed Fits
+
+// PrintedFitClass is a fit class for 3D printed parts.
+type PrintedFitClass int
+
+const (
+	// PressFit is a press fit (pressed together, no movement).
+	PressFit PrintedFitClass = iota
+	// SnugFit is a snug fit (assembled by hand, no play).
+	SnugFit
+	// SlidingFit is a sliding fit (slides and turns, little play).
+	SlidingFit
+	// RunningFit is a free running fit.
+	RunningFit
+)
+
+// PrintedClearance is the diametral clearance (mm) for each printed fit class.
+var PrintedClearance = map[PrintedFitClass]float64{
+	PressFit:   0.05,
+	SnugFit:    0.15,
+	SlidingFit: 0.3,
+	RunningFit: 0.5,
+}
+
+// PrintedFit returns the hole and shaft diameters for a printed fit class.
+// There's no tolerance band, the limits are the diameters to model.
+func PrintedFit(size float64, class PrintedFitClass) (*Fit, error) {
+	if size <= 0 {
+		return nil, errors.New("size <= 0")
+	}
+	c, ok := PrintedClearance[class]
+	if !ok {
+		return nil, errors.New("bad printed fit class")
+	}
+	if size-0.5*c <= 0 {
+		return nil, errors.New("clearance too large for the size")
+	}
+	hole := size + 0.5*c
+	shaft := size - 0.5*c
+	return &Fit{
+		Nominal:  size,
+		HoleMin:  hole,
+		HoleMax:  hole,
+		ShaftMin: shaft,
+		ShaftMax: shaft,
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,210 @@
+//-----------------------------------------------------------------------------
+/*
+
+Flexures
+
+Compliant mechanism elements for printed precision mechanisms. The flexures
+are 2D profiles in the xy plane, extrude them by the depth to make a part.
+
+Stiffness estimates use small deflection beam theory, with E the Young's
+modulus of the material (MPa = N/mm^2, E.g. about 3500 for PLA, 2000 for
+PETG). Lengths are in mm, so stiffnesses are in N/mm and N.mm/radian.
+Printed parts are anisotropic and the effective modulus depends on the
+infill and layer orientation, so treat these as estimates.
+
+Leaf flexure: a thin beam between two blocks.
+Cantilever (one end guided by nothing) k = 3EI/L^3, bending k = EI/L.
+
+Notch hinge: a block with elliptical (or circular) cutouts on either side,
+leaving a thin neck. The rotational stiffness is found by integrating the
+compliance over the notch, 1/k = integral(12/(E*d*h(x)^3) dx), which is
+close to the Paros-Weisbord formula for circular notches.
+
+Parallelogram stage: two parallel leaves between a fixed base and a
+moving stage. The stage translates without rotating, k = 24EI/L^3.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// leafInertia returns the second moment of area of a leaf (bending in its thin direction).
+func leafInertia(thickness, depth float64) float64 {
+	return depth * thickness * thickness * thickness / 12
+}
+
+//-----------------------------------------------------------------------------
+// Leaf Flexures
+
+// LeafFlexureParms defines the parameters for a leaf flexure.
+type LeafFlexureParms struct {
+	Length    float64 // length of the leaf (between the blocks)
+	Thickness float64 // thickness of the leaf
+	Depth     float64 // depth of the leaf (extrusion length)
+	Block     V2      // size of the blocks at each end of the leaf (zero = no blocks)
+}
+
+// LeafFlexure2D returns the profile of a leaf flexure along the x-axis, centered on the origin.
+func LeafFlexure2D(k *LeafFlexureParms) (SDF2, error) {
+	if k.Length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	if k.Thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	if k.Block.X < 0 || k.Block.Y < 0 {
+		return nil, errors.New("bad block size")
+	}
+	if k.Block.X > 0 && k.Block.Y < k.Thickness {
+		return nil, errors.New("block is thinner than the leaf")
+	}
+	leaf := Box2D(V2{k.Length + k.Block.X, k.Thickness}, 0)
+	if k.Block.X == 0 {
+		return leaf, nil
+	}
+	x := 0.5 * (k.Length + k.Block.X)
+	b := Box2D(k.Block, 0)
+	b0 := Transform2D(b, Translate2d(V2{-x, 0}))
+	b1 := Transform2D(b, Translate2d(V2{x, 0}))
+	return Union2D(leaf, b0, b1), nil
+}
+
+// Stiffness returns the translational (end deflection, the other end fixed) and rotational
+// (pure bending) stiffness of a leaf flexure.
+func (k *LeafFlexureParms) Stiffness(
+	e float64, // Young's modulus (MPa)
+) (float64, float64) {
+	ei := e * leafInertia(k.Thickness, k.Depth)
+	return 3 * ei / (k.Length * k.Length * k.Length), ei / k.Length
+}
+
+//-----------------------------------------------------------------------------
+// Notch Hinges
+
+// NotchHingeParms defines the parameters for a notch hinge.
+type NotchHingeParms struct {
+	Size      V2      // size of the block (the hinge axis is at the center)
+	Thickness float64 // thickness of the neck
+	Notch     V2      // semi-axes of the notch cutouts (x along the block, y across), equal for circular notches
+	Depth     float64 // depth of the hinge (extrusion length)
+}
+
+// notchHeight returns the thickness of a notch hinge at x from the neck.
+func (k *NotchHingeParms) notchHeight(x float64) float64 {
+	u := Clamp(x/k.Notch.X, -1, 1)
+	h := k.Thickness + 2*k.Notch.Y*(1-math.Sqrt(1-u*u))
+	return Min(h, k.Size.Y)
+}
+
+// NotchHinge2D returns the profile of a notch hinge. The block is along the x-axis with the
+// neck at the origin.
+func NotchHinge2D(k *NotchHingeParms) (SDF2, error) {
+	if k.Size.X <= 0 || k.Size.Y <= 0 {
+		return nil, errors.New("bad block size")
+	}
+	if k.Thickness <= 0 || k.Thickness >= k.Size.Y {
+		return nil, errors.New("bad neck thickness")
+	}
+	if k.Notch.X <= 0 || k.Notch.Y <= 0 {
+		return nil, errors.New("bad notch size")
+	}
+	if 2*k.Notch.X >= k.Size.X {
+		return nil, errors.New("notch is too long for the block")
+	}
+	var notch SDF2
+	if k.Notch.X == k.Notch.Y {
+		notch = Circle2D(k.Notch.X)
+	} else {
+		const n = 64
+		v := make([]V2, n)
+		for i := range v {
+			s, c := math.Sincos(Tau * float64(i) / n)
+			v[i] = V2{k.Notch.X * c, k.Notch.Y * s}
+		}
+		notch = Polygon2D(v)
+	}
+	y := 0.5*k.Thickness + k.Notch.Y
+	n0 := Transform2D(notch, Translate2d(V2{0, y}))
+	n1 := Transform2D(notch, Translate2d(V2{0, -y}))
+	return Difference2D(Box2D(k.Size, 0), Union2D(n0, n1)), nil
+}
+
+// Stiffness returns the rotational stiffness of a notch hinge.
+func (k *NotchHingeParms) Stiffness(
+	e float64, // Young's modulus (MPa)
+) float64 {
+	// integrate the compliance over the notch (simpson's rule)
+	const n = 512
+	a := k.Notch.X
+	dx := 2 * a / n
+	c := 0.0
+	for i := 0; i <= n; i++ {
+		w := 2.0
+		if i == 0 || i == n {
+			w = 1
+		} else if i&1 == 1 {
+			w = 4
+		}
+		h := k.notchHeight(-a + float64(i)*dx)
+		c += w / (e * leafInertia(h, k.Depth))
+	}
+	return 1 / (c * dx / 3)
+}
+
+//-----------------------------------------------------------------------------
+// Parallelogram Stages
+
+// ParallelFlexureParms defines the parameters for a parallelogram flexure stage.
+type ParallelFlexureParms struct {
+	Length     float64 // length of the leaves
+	Thickness  float64 // thickness of the leaves
+	Separation float64 // center to center distance between the leaves
+	Block      float64 // thickness of the base and stage blocks
+	Depth      float64 // depth of the stage (extrusion length)
+}
+
+// ParallelFlexure2D returns the profile of a parallelogram flexure stage, centered on the
+// origin. The leaves are parallel to the y-axis, the base is at -y and the stage at +y.
+// The stage moves in x.
+func ParallelFlexure2D(k *ParallelFlexureParms) (SDF2, error) {
+	if k.Length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	if k.Thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	if k.Separation <= k.Thickness {
+		return nil, errors.New("separation <= thickness")
+	}
+	if k.Block <= 0 {
+		return nil, errors.New("block <= 0")
+	}
+	x := 0.5 * k.Separation
+	y := 0.5 * (k.Length + k.Block)
+	leaf := Box2D(V2{k.Thickness, k.Length + k.Block}, 0)
+	block := Box2D(V2{k.Separation + k.Thickness, k.Block}, 0)
+	return Union2D(
+		Transform2D(leaf, Translate2d(V2{-x, 0})),
+		Transform2D(leaf, Translate2d(V2{x, 0})),
+		Transform2D(block, Translate2d(V2{0, -y})),
+		Transform2D(block, Translate2d(V2{0, y})),
+	), nil
+}
+
+// Stiffness returns the translational stiffness of a parallelogram flexure stage.
+func (k *ParallelFlexureParms) Stiffness(
+	e float64, // Young's modulus (MPa)
+) float64 {
+	// two fixed-guided leaves
+	ei := e * leafInertia(k.Thickness, k.Depth)
+	return 24 * ei / (k.Length * k.Length * k.Length)
+}
+
+//-----------------------------------------------------------------------------
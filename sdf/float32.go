@@ -0,0 +1,440 @@
+//-----------------------------------------------------------------------------
+/*
+
+Float32 Evaluation and Meshing
+
+Float32 wraps an SDF3 so it's evaluated with float32 precision. The wrapped
+tree is converted to float32 nodes, with float32 parameters and arithmetic,
+for spheres, boxes, cylinders, transforms, uniform scaling, and unions,
+differences and intersections with the default (hard) min/max. Blended
+booleans and any other nodes are evaluated in float64 and the result is
+converted to float32, so there's no gain for those nodes.
+
+The uniform grid renderers (RenderSTLSlow, RenderMesh) store the sampled
+marching cubes layers of a Float32 SDF3 as float32. The grid is sampled two
+layers at a time, so this doesn't change the memory use much. Other
+renderers use the float32 evaluation with float64 storage.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+//-----------------------------------------------------------------------------
+
+// V3f is a 3d float32 cartesian vector.
+type V3f struct {
+	X, Y, Z float32
+}
+
+// ToV3f converts a V3 to a V3f.
+func (a V3) ToV3f() V3f {
+	return V3f{float32(a.X), float32(a.Y), float32(a.Z)}
+}
+
+// ToV3 converts a V3f to a V3.
+func (a V3f) ToV3() V3 {
+	return V3{float64(a.X), float64(a.Y), float64(a.Z)}
+}
+
+// Add adds two vectors. Return v = a + b.
+func (a V3f) Add(b V3f) V3f {
+	return V3f{a.X + b.X, a.Y + b.Y, a.Z + b.Z}
+}
+
+// Sub subtracts two vectors. Return v = a - b.
+func (a V3f) Sub(b V3f) V3f {
+	return V3f{a.X - b.X, a.Y - b.Y, a.Z - b.Z}
+}
+
+// MulScalar multiplies each component of a vector by a scalar.
+func (a V3f) MulScalar(k float32) V3f {
+	return V3f{a.X * k, a.Y * k, a.Z * k}
+}
+
+// Abs takes the absolute value of each vector component.
+func (a V3f) Abs() V3f {
+	return V3f{abs32(a.X), abs32(a.Y), abs32(a.Z)}
+}
+
+// Dot returns the dot product of two vectors.
+func (a V3f) Dot(b V3f) float32 {
+	return float32(a.X*b.X) + float32(a.Y*b.Y) + float32(a.Z*b.Z)
+}
+
+// Length returns the vector length.
+func (a V3f) Length() float32 {
+	return float32(math.Sqrt(float64(a.Dot(a))))
+}
+
+// MaxComponent returns the maximum component of the vector.
+func (a V3f) MaxComponent() float32 {
+	return max32(max32(a.X, a.Y), a.Z)
+}
+
+func abs32(a float32) float32 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+//-----------------------------------------------------------------------------
+
+// m44f is a float32 rotate/translate matrix (the last row is 0, 0, 0, 1).
+type m44f struct {
+	x00, x01, x02, x03 float32
+	x10, x11, x12, x13 float32
+	x20, x21, x22, x23 float32
+}
+
+// toM44f converts a M44 to a float32 rotate/translate matrix.
+func (a M44) toM44f() m44f {
+	return m44f{
+		float32(a.x00), float32(a.x01), float32(a.x02), float32(a.x03),
+		float32(a.x10), float32(a.x11), float32(a.x12), float32(a.x13),
+		float32(a.x20), float32(a.x21), float32(a.x22), float32(a.x23),
+	}
+}
+
+// MulPosition multiplies a V3f position with a rotate/translate matrix.
+func (a m44f) MulPosition(b V3f) V3f {
+	return V3f{float32(a.x00*b.X) + float32(a.x01*b.Y) + float32(a.x02*b.Z) + a.x03,
+		float32(a.x10*b.X) + float32(a.x11*b.Y) + float32(a.x12*b.Z) + a.x13,
+		float32(a.x20*b.X) + float32(a.x21*b.Y) + float32(a.x22*b.Z) + a.x23}
+}
+
+//-----------------------------------------------------------------------------
+
+// SDF3f is an SDF3 with a float32 evaluation.
+type SDF3f interface {
+	Evaluate32(p V3f) float32
+	BoundingBox() Box3
+}
+
+// toSDF3f converts an SDF3 tree to float32 nodes where it can.
+func toSDF3f(s SDF3) SDF3f {
+	switch n := s.(type) {
+	case SDF3f:
+		return n
+	case *SphereSDF3:
+		return &sphere32{float32(n.radius), n.bb}
+	case *BoxSDF3:
+		return &box32{n.size.ToV3f(), float32(n.round), n.bb}
+	case *CylinderSDF3:
+		return &cylinder32{float32(n.height), float32(n.radius), float32(n.round), n.bb}
+	case *TransformSDF3:
+		return &transform32{toSDF3f(n.sdf), n.inverse.toM44f(), n.bb}
+	case *ScaleUniformSDF3:
+		return &scaleUniform32{toSDF3f(n.sdf), float32(n.k), float32(n.invK), n.bb}
+	case *UnionSDF3:
+		u := union32{sdf: make([]SDF3f, len(n.sdf)), bb: n.bb}
+		for i, x := range n.sdf {
+			u.sdf[i] = toSDF3f(x)
+		}
+		if !sameFunc(n.min, Min) {
+			u.min = n.min
+		}
+		return &u
+	case *DifferenceSDF3:
+		d := difference32{s0: toSDF3f(n.s0), s1: toSDF3f(n.s1), bb: n.bb}
+		if !sameFunc(n.max, Max) {
+			d.max = n.max
+		}
+		return &d
+	case *IntersectionSDF3:
+		d := intersection32{s0: toSDF3f(n.s0), s1: toSDF3f(n.s1), bb: n.bb}
+		if !sameFunc(n.max, Max) {
+			d.max = n.max
+		}
+		return &d
+	}
+	return &float64SDF3{s}
+}
+
+// float64SDF3 evaluates an SDF3 in float64 and returns a float32.
+type float64SDF3 struct {
+	sdf SDF3
+}
+
+// Evaluate32 returns the minimum distance to the SDF3 as a float32.
+func (s *float64SDF3) Evaluate32(p V3f) float32 {
+	return float32(s.sdf.Evaluate(p.ToV3()))
+}
+
+// BoundingBox returns the bounding box of the SDF3.
+func (s *float64SDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+//-----------------------------------------------------------------------------
+// float32 primitives
+
+type sphere32 struct {
+	radius float32
+	bb     Box3
+}
+
+// Evaluate32 returns the minimum distance to a sphere.
+func (s *sphere32) Evaluate32(p V3f) float32 {
+	return p.Length() - s.radius
+}
+
+// BoundingBox returns the bounding box for a sphere.
+func (s *sphere32) BoundingBox() Box3 {
+	return s.bb
+}
+
+type box32 struct {
+	size  V3f
+	round float32
+	bb    Box3
+}
+
+// Evaluate32 returns the minimum distance to a 3d box.
+func (s *box32) Evaluate32(p V3f) float32 {
+	d := p.Abs().Sub(s.size)
+	e := V3f{max32(d.X, 0), max32(d.Y, 0), max32(d.Z, 0)}
+	return e.Length() + min32(d.MaxComponent(), 0) - s.round
+}
+
+// BoundingBox returns the bounding box for a 3d box.
+func (s *box32) BoundingBox() Box3 {
+	return s.bb
+}
+
+type cylinder32 struct {
+	height float32
+	radius float32
+	round  float32
+	bb     Box3
+}
+
+// Evaluate32 returns the minimum distance to a cylinder.
+func (s *cylinder32) Evaluate32(p V3f) float32 {
+	// sdfBox2d for the (radial, z) plane
+	x := float32(math.Sqrt(float64(float32(p.X*p.X) + float32(p.Y*p.Y))))
+	z := abs32(p.Z)
+	r, h := s.radius, s.height
+	dx, dz := x-r, z-h
+	var d float32
+	if dx > 0 && dz > 0 {
+		d = float32(math.Sqrt(float64(float32(dx*dx) + float32(dz*dz))))
+	} else if z-x > h-r {
+		d = dz
+	} else {
+		d = dx
+	}
+	return d - s.round
+}
+
+// BoundingBox returns the bounding box for a cylinder.
+func (s *cylinder32) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// float32 operations
+
+type transform32 struct {
+	sdf     SDF3f
+	inverse m44f
+	bb      Box3
+}
+
+// Evaluate32 returns the minimum distance to a transformed SDF3.
+func (s *transform32) Evaluate32(p V3f) float32 {
+	return s.sdf.Evaluate32(s.inverse.MulPosition(p))
+}
+
+// BoundingBox returns the bounding box of a transformed SDF3.
+func (s *transform32) BoundingBox() Box3 {
+	return s.bb
+}
+
+type scaleUniform32 struct {
+	sdf     SDF3f
+	k, invK float32
+	bb      Box3
+}
+
+// Evaluate32 returns the minimum distance to a uniformly scaled SDF3.
+func (s *scaleUniform32) Evaluate32(p V3f) float32 {
+	return s.sdf.Evaluate32(p.MulScalar(s.invK)) * s.k
+}
+
+// BoundingBox returns the bounding box of a uniformly scaled SDF3.
+func (s *scaleUniform32) BoundingBox() Box3 {
+	return s.bb
+}
+
+type union32 struct {
+	sdf []SDF3f
+	min MinFunc // blending function (nil for the minimum)
+	bb  Box3
+}
+
+// Evaluate32 returns the minimum distance to an SDF3 union.
+func (s *union32) Evaluate32(p V3f) float32 {
+	d := s.sdf[0].Evaluate32(p)
+	for _, x := range s.sdf[1:] {
+		if s.min == nil {
+			d = min32(d, x.Evaluate32(p))
+		} else {
+			d = float32(s.min(float64(d), float64(x.Evaluate32(p))))
+		}
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of an SDF3 union.
+func (s *union32) BoundingBox() Box3 {
+	return s.bb
+}
+
+type difference32 struct {
+	s0, s1 SDF3f
+	max    MaxFunc // blending function (nil for the maximum)
+	bb     Box3
+}
+
+// Evaluate32 returns the minimum distance to the SDF3 difference.
+func (s *difference32) Evaluate32(p V3f) float32 {
+	d0, d1 := s.s0.Evaluate32(p), s.s1.Evaluate32(p)
+	if s.max == nil {
+		return max32(d0, -d1)
+	}
+	return float32(s.max(float64(d0), -float64(d1)))
+}
+
+// BoundingBox returns the bounding box of the SDF3 difference.
+func (s *difference32) BoundingBox() Box3 {
+	return s.bb
+}
+
+type intersection32 struct {
+	s0, s1 SDF3f
+	max    MaxFunc // blending function (nil for the maximum)
+	bb     Box3
+}
+
+// Evaluate32 returns the minimum distance to the SDF3 intersection.
+func (s *intersection32) Evaluate32(p V3f) float32 {
+	d0, d1 := s.s0.Evaluate32(p), s.s1.Evaluate32(p)
+	if s.max == nil {
+		return max32(d0, d1)
+	}
+	return float32(s.max(float64(d0), float64(d1)))
+}
+
+// BoundingBox returns the bounding box of the SDF3 intersection.
+func (s *intersection32) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// Float32SDF3 is an SDF3 evaluated with float32 precision.
+type Float32SDF3 struct {
+	sdf SDF3  // the float64 sdf
+	sf  SDF3f // the float32 sdf
+}
+
+// Float32 returns an SDF3 evaluated with float32 precision. Render it to render
+// with float32 evaluation (and float32 layers for the uniform grid renderers).
+func Float32(s SDF3) SDF3 {
+	if f, ok := s.(*Float32SDF3); ok {
+		return f
+	}
+	return &Float32SDF3{s, toSDF3f(s)}
+}
+
+// Evaluate returns the minimum distance to the SDF3, evaluated with float32 precision.
+func (s *Float32SDF3) Evaluate(p V3) float64 {
+	return float64(s.sf.Evaluate32(p.ToV3f()))
+}
+
+// Evaluate32 returns the minimum distance to the SDF3 as a float32.
+func (s *Float32SDF3) Evaluate32(p V3f) float32 {
+	return s.sf.Evaluate32(p)
+}
+
+// BoundingBox returns the bounding box of the SDF3.
+func (s *Float32SDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+//-----------------------------------------------------------------------------
+
+// layerYZ32 is a float32 version of layerYZ.
+type layerYZ32 struct {
+	sdf   SDF3f     // sdf being sampled
+	base  V3        // base coordinate of layer
+	inc   V3        // dx, dy, dz for each step
+	steps V3i       // number of x,y,z steps
+	val0  []float32 // SDF values for x layer
+	val1  []float32 // SDF values for x + dx layer
+}
+
+// Evaluate the SDF for a given YZ layer.
+func (l *layerYZ32) Evaluate(x int) {
+	// swap the layers
+	l.val0, l.val1 = l.val1, l.val0
+	ny, nz := l.steps[1]+1, l.steps[2]+1
+	if l.val1 == nil {
+		l.val1 = make([]float32, ny*nz)
+	}
+	// evaluate the layer, one y-row per work item
+	px := l.base.X + float64(float64(x)*l.inc.X)
+	var wg sync.WaitGroup
+	rows := make(chan int)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				p := V3{px, l.base.Y + float64(float64(y)*l.inc.Y), 0}
+				for z := 0; z < nz; z++ {
+					p.Z = l.base.Z + float64(float64(z)*l.inc.Z)
+					l.val1[y*nz+z] = l.sdf.Evaluate32(p.ToV3f())
+				}
+			}
+		}()
+	}
+	for y := 0; y < ny; y++ {
+		rows <- y
+	}
+	close(rows)
+	wg.Wait()
+}
+
+// Get returns the float64 value of a layer grid point.
+func (l *layerYZ32) Get(x, y, z int) float64 {
+	idx := y*(l.steps[2]+1) + z
+	if x == 0 {
+		return float64(l.val0[idx])
+	}
+	return float64(l.val1[idx])
+}
+
+//-----------------------------------------------------------------------------
@@ -8,7 +8,11 @@ Involute Gears
 
 package sdf
 
-import "math"
+import (
+	"errors"
+	"fmt"
+	"math"
+)
 
 //-----------------------------------------------------------------------------
 
@@ -44,7 +48,7 @@ func InvoluteGearTooth(
 	baseRadius float64, // radius at the base of the involute
 	outerRadius float64, // radius at the outside of the tooth
 	backlash float64, // backlash expressed as units of pitch circumference
-	facets int, // number of facets for involute flank
+	facets int, // number of facets for involute flank (0 = adaptive, chord error < module/1000)
 ) SDF2 {
 
 	pitchRadius := float64(numberTeeth) * gearModule / 2.0
@@ -58,16 +62,39 @@ func InvoluteGearTooth(
 	// work out the angles over which the involute will be used
 	startAngle := involuteTheta(baseRadius, Max(baseRadius, rootRadius))
 	stopAngle := involuteTheta(baseRadius, outerRadius)
-	dtheta := (stopAngle - startAngle) / float64(facets)
-
-	v := make([]V2, 2*(facets+1)+1)
+	// stop where the tooth faces meet (a pointed tooth) so the polygon doesn't self-intersect
+	if stopAngle-math.Atan(stopAngle) > centerAngle {
+		lo, hi := 0.0, stopAngle
+		for i := 0; i < 50; i++ {
+			t := 0.5 * (lo + hi)
+			if t-math.Atan(t) > centerAngle {
+				hi = t
+			} else {
+				lo = t
+			}
+		}
+		stopAngle = Max(lo, startAngle)
+	}
 
 	// lower tooth face
 	m := Rotate(-centerAngle)
-	angle := startAngle
-	for i := 0; i <= facets; i++ {
-		v[i] = m.MulPosition(involuteXY(baseRadius, angle))
-		angle += dtheta
+	var flank []V2
+	if facets == 0 {
+		// dense points on the tightly curved base of the involute, sparse at the tip
+		f := func(t float64) V2 { return involuteXY(baseRadius, t) }
+		flank = AdaptiveCurve(f, startAngle, stopAngle, 1e-3*gearModule)
+		facets = len(flank) - 1
+	} else {
+		dtheta := (stopAngle - startAngle) / float64(facets)
+		angle := startAngle
+		for i := 0; i <= facets; i++ {
+			flank = append(flank, involuteXY(baseRadius, angle))
+			angle += dtheta
+		}
+	}
+	v := make([]V2, 2*(facets+1)+1)
+	for i, p := range flank {
+		v[i] = m.MulPosition(p)
 	}
 
 	// upper tooth face (mirror the lower point)
@@ -82,9 +109,160 @@ func InvoluteGearTooth(
 	return Polygon2D(v)
 }
 
+//-----------------------------------------------------------------------------
+// Rack Generated Teeth
+
+// rackSpaceAngles returns the half angular thickness of a gear tooth at a set of radii, for a
+// tooth cut by a generating rack rolling on the pitch circle. The tooth is centered on the +y axis
+// and the angles are measured from it. The rack flanks generate the involute, the rounded rack
+// tips generate the trochoidal root fillet and any undercut.
+func rackSpaceAngles(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as units of pitch circumference
+	dedendum float64, // radial distance from pitch circle to root circle
+	tipRadius float64, // radius of the rounded rack tips
+	top float64, // height of the rack teeth above the pitch line
+	radius []float64, // radii to work out the tooth thickness at
+) []float64 {
+	rp := float64(numberTeeth) * gearModule / 2.0
+	pitch := Pi * gearModule
+	tanPA := math.Tan(pressureAngle)
+
+	// The rack tooth (pointing at the gear center) cutting the space to the +x side of the gear
+	// tooth. Its pitch line is on the x-axis, y is away from the gear center.
+	w := 0.25*pitch + 0.5*backlash // half thickness at the pitch line
+	wTip := w - dedendum*tanPA
+	// limit the tip radius so the roundings fit on the rack tip
+	beta := 0.5 * (0.5*Pi + pressureAngle)
+	tipRadius = Min(tipRadius, 0.999*wTip*math.Tan(beta))
+	rack := NewPolygon()
+	rack.Add(-w-top*tanPA, top)
+	rack.Add(-wTip, -dedendum).Smooth(tipRadius, 4)
+	rack.Add(wTip, -dedendum).Smooth(tipRadius, 4)
+	rack.Add(w+top*tanPA, top)
+	rack.Close()
+	tooth := rack.Vertices()
+	for i := range tooth {
+		tooth[i] = tooth[i].Add(V2{0.5 * pitch, rp})
+	}
+
+	alpha := make([]float64, len(radius))
+	for i := range alpha {
+		alpha[i] = Pi / float64(numberTeeth)
+	}
+
+	// roll the rack through the mesh
+	thetaMax := Min(Pi, (2*(top+dedendum)/tanPA+pitch)/rp)
+	n := int(math.Ceil(2 * thetaMax * rp / (0.01 * gearModule)))
+	q := make([]V2, len(tooth))
+	for k := 0; k <= n; k++ {
+		theta := thetaMax * (2*float64(k)/float64(n) - 1)
+		// the rack moves by rp * theta as the gear turns by theta, in gear coordinates
+		m := Rotate(-theta)
+		for i, v := range tooth {
+			q[i] = m.MulPosition(v.Sub(V2{rp * theta, 0}))
+		}
+		for i := range q {
+			a := q[i]
+			d := q[(i+1)%len(q)].Sub(a)
+			// intersect the edge with the circles
+			qa := d.Dot(d)
+			qb := 2 * a.Dot(d)
+			qc0 := a.Dot(a)
+			for j, r := range radius {
+				disc := qb*qb - 4*qa*(qc0-r*r)
+				if disc < 0 {
+					continue
+				}
+				sq := math.Sqrt(disc)
+				for _, t := range []float64{(-qb - sq) / (2 * qa), (-qb + sq) / (2 * qa)} {
+					if t >= 0 && t <= 1 {
+						p := a.Add(d.MulScalar(t))
+						alpha[j] = Min(alpha[j], math.Atan2(p.X, p.Y))
+					}
+				}
+			}
+		}
+	}
+	return alpha
+}
+
+// RackGearTooth returns a 2D profile for a single involute tooth as cut by a generating rack.
+// The root has the trochoidal fillet generated by the rounded rack tips, and teeth with
+// small numbers of teeth are undercut.
+func RackGearTooth(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	rootRadius float64, // radius at tooth root
+	outerRadius float64, // radius at the outside of the tooth
+	backlash float64, // backlash expressed as units of pitch circumference
+	tipRadius float64, // radius of the rounded rack tips (limited to fit the rack tip)
+	facets int, // number of facets for the tooth flank
+) SDF2 {
+	return rackGearTooth(numberTeeth, gearModule, pressureAngle, rootRadius, outerRadius, backlash, tipRadius, nil, facets)
+}
+
+// rackGearTooth returns a 2D profile for a single rack generated involute tooth with optional
+// tip and root relief.
+func rackGearTooth(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	rootRadius float64, // radius at tooth root
+	outerRadius float64, // radius at the outside of the tooth
+	backlash float64, // backlash expressed as units of pitch circumference
+	tipRadius float64, // radius of the rounded rack tips (limited to fit the rack tip)
+	relief *GearRelief, // tip and root relief (nil = none)
+	facets int, // number of facets for the tooth flank
+) SDF2 {
+	pitchRadius := float64(numberTeeth) * gearModule / 2.0
+
+	n := 4 * facets
+	radius := make([]float64, n+1)
+	for i := range radius {
+		// avoid the tangency at the root circle
+		r0 := rootRadius + 1e-3*gearModule
+		radius[i] = r0 + (outerRadius-r0)*float64(i)/float64(n)
+	}
+	top := outerRadius - pitchRadius + gearModule
+	alpha := rackSpaceAngles(numberTeeth, gearModule, pressureAngle, backlash, pitchRadius-rootRadius, tipRadius, top, radius)
+	if relief != nil {
+		relief.apply(pitchRadius*math.Cos(pressureAngle), rootRadius, outerRadius, radius, alpha)
+	}
+
+	// lower tooth face, stop if the tooth comes to a point
+	var v []V2
+	for i, r := range radius {
+		if alpha[i] <= 0 {
+			break
+		}
+		s, c := math.Sincos(alpha[i])
+		v = append(v, V2{r * c, -r * s})
+	}
+
+	// upper tooth face (mirror the lower point)
+	k := len(v)
+	for i := k - 1; i >= 0; i-- {
+		v = append(v, V2{v[i].X, -v[i].Y})
+	}
+
+	// add the origin to make the polygon a tooth wedge
+	v = append(v, V2{0, 0})
+
+	return Polygon2D(v)
+}
+
 //-----------------------------------------------------------------------------
 
 // InvoluteGear returns an 2D polygon for an involute gear.
+// GearInspection gives the span and over pins dimensions for checking the gear,
+// InvoluteGearReport gives the derived quantities for a gear pair.
+// The teeth are cut by a generating rack, so the root has a trochoidal fillet and small
+// numbers of teeth are undercut. With facets = 0 the tooth flanks are exact involutes
+// (see InvoluteGearSDF2) rather than polygons.
 func InvoluteGear(
 	numberTeeth int, // number of gear teeth
 	gearModule float64, // pitch circle diameter / number of gear teeth
@@ -92,17 +270,66 @@ func InvoluteGear(
 	backlash float64, // backlash expressed as per-tooth distance at pitch circumference
 	clearance float64, // additional root clearance
 	ringWidth float64, // width of ring wall (from root circle)
+	facets int, // number of facets for involute flank (0 = exact involute flanks)
+) SDF2 {
+	// addendum: radial distance from pitch circle to outside circle
+	addendum := gearModule * 1.0
+	return involuteGear(numberTeeth, gearModule, pressureAngle, backlash, addendum, clearance, ringWidth, nil, facets)
+}
+
+// CheckedInvoluteGear returns a 2D involute gear, with an error for invalid parameters.
+func CheckedInvoluteGear(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as per-tooth distance at pitch circumference
+	clearance float64, // additional root clearance
+	ringWidth float64, // width of ring wall (from root circle)
+	facets int, // number of facets for involute flank (0 = exact involute flanks)
+) (SDF2, error) {
+	if numberTeeth < 4 {
+		return nil, errors.New("numberTeeth < 4")
+	}
+	if gearModule <= 0 {
+		return nil, errors.New("gearModule <= 0")
+	}
+	if pressureAngle <= 0 || pressureAngle >= DtoR(45) {
+		return nil, errors.New("bad pressure angle")
+	}
+	if backlash < 0 || clearance < 0 {
+		return nil, errors.New("backlash and clearance must be >= 0")
+	}
+	if ringWidth < 0 {
+		return nil, errors.New("ringWidth < 0")
+	}
+	if facets < 0 {
+		return nil, errors.New("facets < 0")
+	}
+	if backlash >= 0.5*Pi*gearModule {
+		return nil, errors.New("the backlash is larger than the tooth thickness")
+	}
+	if 0.5*float64(numberTeeth)*gearModule-gearModule-clearance <= 0 {
+		return nil, errors.New("root radius <= 0")
+	}
+	return InvoluteGear(numberTeeth, gearModule, pressureAngle, backlash, clearance, ringWidth, facets), nil
+}
+
+// involuteGear returns an 2D polygon for an involute gear with a given addendum.
+func involuteGear(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as per-tooth distance at pitch circumference
+	addendum float64, // radial distance from pitch circle to outside circle
+	clearance float64, // additional root clearance
+	ringWidth float64, // width of ring wall (from root circle)
+	relief *GearRelief, // tip and root relief (nil = none)
 	facets int, // number of facets for involute flank
 ) SDF2 {
 
 	// pitch radius
 	pitchRadius := float64(numberTeeth) * gearModule / 2.0
 
-	// base circle radius
-	baseRadius := pitchRadius * math.Cos(pressureAngle)
-
-	// addendum: radial distance from pitch circle to outside circle
-	addendum := gearModule * 1.0
 	// dedendum: radial distance from pitch circle to root circle
 	dedendum := addendum + clearance
 
@@ -110,13 +337,23 @@ func InvoluteGear(
 	rootRadius := pitchRadius - dedendum
 	ringRadius := rootRadius - ringWidth
 
-	tooth := InvoluteGearTooth(
+	// the generating rack tip radius fills the clearance
+	tipRadius := clearance / (1 - math.Sin(pressureAngle))
+
+	if facets == 0 && relief == nil {
+		gear := newInvoluteGearSDF2(numberTeeth, gearModule, pressureAngle, backlash, rootRadius, outerRadius, tipRadius)
+		return Difference2D(gear, Circle2D(ringRadius))
+	}
+
+	tooth := rackGearTooth(
 		numberTeeth,
 		gearModule,
+		pressureAngle,
 		rootRadius,
-		baseRadius,
 		outerRadius,
 		backlash,
+		tipRadius,
+		relief,
 		facets,
 	)
 
@@ -127,6 +364,751 @@ func InvoluteGear(
 	return Difference2D(Union2D(gear, root), ring)
 }
 
+//-----------------------------------------------------------------------------
+// Analytic Involute Gears
+
+// InvoluteGearSDF2 is an involute gear with exact (not faceted) tooth flanks.
+// The distance to the involute is solved for each evaluation, so high resolution renders don't
+// show flank facets. The root has the trochoidal fillet (and any undercut) generated by the
+// rounded rack tips, as for rackGearTooth. The fillet is sampled to 1e-5 of the module.
+type InvoluteGearSDF2 struct {
+	n          int     // number of teeth
+	rb         float64 // base radius
+	rr         float64 // root radius
+	ro         float64 // outer radius (limited if the teeth are pointed)
+	rj         float64 // radius where the root fillet joins the involute
+	psiBase    float64 // half angle of the tooth at the base circle
+	psiOuter   float64 // half angle of the tooth at the outer radius
+	psiRoot    float64 // half angle of the tooth at the root radius
+	tStart     float64 // involute roll angle at the start of the flank
+	tStop      float64 // involute roll angle at the tip
+	fillet     []V2    // root fillet from the root circle to the involute
+	halfSector float64 // pi / number of teeth
+	bb         Box2    // bounding box
+}
+
+// newInvoluteGearSDF2 returns an involute gear with exact tooth flanks.
+func newInvoluteGearSDF2(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as per-tooth distance at pitch circumference
+	rootRadius float64, // radius at tooth root
+	outerRadius float64, // radius at the outside of the tooth
+	tipRadius float64, // radius of the rounded rack tips (limited to fit the rack tip)
+) SDF2 {
+	rp := float64(numberTeeth) * gearModule / 2.0
+	s := InvoluteGearSDF2{
+		n:          numberTeeth,
+		rb:         rp * math.Cos(pressureAngle),
+		rr:         rootRadius,
+		halfSector: Pi / float64(numberTeeth),
+	}
+	// the tooth is centered on the +x axis
+	s.psiBase = (0.5*Pi*gearModule-backlash)/(2*rp) + involute(pressureAngle)
+
+	// The generating rack as for rackSpaceAngles. A point on the rounded rack tip cuts the
+	// gear when its normal passes through the pitch point, so each normal direction gives a
+	// point on the fillet. The normals run from the rack tip (the root circle) to the rack
+	// flank (the involute).
+	pitch := Pi * gearModule
+	dedendum := rp - rootRadius
+	sinPA, cosPA := math.Sincos(pressureAngle)
+	w := 0.25*pitch + 0.5*backlash
+	wTip := w - dedendum*sinPA/cosPA
+	beta := 0.5 * (0.5*Pi + pressureAngle)
+	tipRadius = Clamp(tipRadius, 0, 0.999*wTip*math.Tan(beta))
+	center := V2{0.5*pitch - wTip + tipRadius*(1-sinPA)/cosPA, rp - dedendum + tipRadius}
+	cut := func(phi float64) V2 {
+		sn, cn := math.Sincos(phi)
+		v := center.Add(V2{cn, sn}.MulScalar(tipRadius))
+		theta := (v.X - (v.Y-rp)*cn/sn) / rp
+		q := Rotate(-theta).MulPosition(v.Sub(V2{rp * theta, 0}))
+		// the rack frame has the tooth on the +y axis
+		return PolarToXY(q.Length(), math.Atan2(q.X, q.Y))
+	}
+	fillet := AdaptiveCurve(cut, -0.5*Pi, pressureAngle-Pi, 1e-5*gearModule)
+
+	// An undercut tooth: the rack flank doesn't reach the fillet, and the fillet stops where
+	// it cuts the involute.
+	dpsi := func(p V2) float64 {
+		t := involuteTheta(s.rb, p.Length())
+		return math.Atan2(p.Y, p.X) - (s.psiBase - (t - math.Atan(t)))
+	}
+	if end := fillet[len(fillet)-1]; end.Length() <= s.rb || Abs(dpsi(end)) > 1e-9 {
+		for i := 1; i < len(fillet); i++ {
+			a, b := fillet[i-1], fillet[i]
+			if a.Length() <= s.rb || b.Length() <= s.rb {
+				continue
+			}
+			if d0, d1 := dpsi(a), dpsi(b); d0*d1 <= 0 {
+				fillet[i] = a.Add(b.Sub(a).MulScalar(d0 / (d0 - d1)))
+				fillet = fillet[:i+1]
+				break
+			}
+		}
+	}
+	s.rj = Max(s.rb, fillet[len(fillet)-1].Length())
+	s.tStart = involuteTheta(s.rb, s.rj)
+	// close any gap to the involute
+	if p := s.flank(s.tStart); !p.Equals(fillet[len(fillet)-1], 1e-9*gearModule) {
+		fillet = append(fillet, p)
+	}
+	s.fillet = fillet
+	s.psiRoot = math.Atan2(fillet[0].Y, fillet[0].X)
+
+	s.tStop = involuteTheta(s.rb, Max(s.rb, outerRadius))
+	// stop where the tooth faces meet (a pointed tooth)
+	if s.tStop-math.Atan(s.tStop) > s.psiBase {
+		lo, hi := 0.0, s.tStop
+		for i := 0; i < 50; i++ {
+			t := 0.5 * (lo + hi)
+			if t-math.Atan(t) > s.psiBase {
+				hi = t
+			} else {
+				lo = t
+			}
+		}
+		s.tStop = Max(lo, s.tStart)
+		outerRadius = s.rb * math.Sqrt(1+s.tStop*s.tStop)
+	}
+	s.ro = outerRadius
+	s.psiOuter = s.psiBase - (s.tStop - math.Atan(s.tStop))
+	s.bb = Box2{V2{-s.ro, -s.ro}, V2{s.ro, s.ro}}
+	return &s
+}
+
+// flank returns the point on the tooth flank at involute roll angle t.
+func (s *InvoluteGearSDF2) flank(t float64) V2 {
+	return PolarToXY(s.rb*math.Sqrt(1+t*t), s.psiBase-(t-math.Atan(t)))
+}
+
+// filletAngle returns the half angle of the tooth at a radius on the root fillet.
+func (s *InvoluteGearSDF2) filletAngle(r float64) float64 {
+	psi := s.halfSector
+	for i := 1; i < len(s.fillet); i++ {
+		a, b := s.fillet[i-1], s.fillet[i]
+		ra, rb := a.Length(), b.Length()
+		if ra == rb || (ra-r)*(rb-r) > 0 {
+			continue
+		}
+		p := a.Add(b.Sub(a).MulScalar((r - ra) / (rb - ra)))
+		psi = Min(psi, math.Atan2(p.Y, p.X))
+	}
+	return psi
+}
+
+// Evaluate returns the minimum distance to an involute gear.
+func (s *InvoluteGearSDF2) Evaluate(p V2) float64 {
+	// fold the point into the upper half of the tooth sector on the +x axis
+	r := p.Length()
+	theta := math.Atan2(p.Y, p.X)
+	theta = math.Mod(theta+s.halfSector, 2*s.halfSector)
+	if theta < 0 {
+		theta += 2 * s.halfSector
+	}
+	theta = Abs(theta - s.halfSector)
+	q := PolarToXY(r, theta)
+
+	// tip arc
+	var d float64
+	if theta <= s.psiOuter {
+		d = Abs(r - s.ro)
+	} else {
+		d = q.Sub(PolarToXY(s.ro, s.psiOuter)).Length()
+	}
+	// root arc
+	if theta >= s.psiRoot {
+		d = Min(d, Abs(r-s.rr))
+	} else {
+		d = Min(d, q.Sub(s.fillet[0]).Length())
+	}
+	// root fillet (between the root radius and the involute)
+	if r-s.rj < d && s.rr-r < d {
+		for i := 1; i < len(s.fillet); i++ {
+			d = Min(d, segmentDistance(q, s.fillet[i-1], s.fillet[i]))
+		}
+	}
+	// involute flank, the normal to the involute is tangent to the base circle
+	if r > s.rb {
+		l := math.Sqrt(r*r - s.rb*s.rb)
+		t := s.psiBase - theta + math.Atan(l/s.rb)
+		if t >= s.tStart && t <= s.tStop {
+			d = Min(d, Abs(l-s.rb*t))
+		}
+	}
+	d = Min(d, q.Sub(s.flank(s.tStart)).Length())
+	d = Min(d, q.Sub(s.flank(s.tStop)).Length())
+
+	// inside the root circle or the tooth
+	inside := r < s.rr
+	if !inside && r <= s.ro {
+		var psi float64
+		if r < s.rj {
+			psi = s.filletAngle(r)
+		} else {
+			t := involuteTheta(s.rb, r)
+			psi = s.psiBase - (t - math.Atan(t))
+		}
+		inside = theta < psi
+	}
+	if inside {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of an involute gear.
+func (s *InvoluteGearSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Tip and Root Relief
+
+// ReliefShape is the shape of a tooth flank relief.
+type ReliefShape int
+
+const (
+	// LinearRelief increases linearly with the roll angle.
+	LinearRelief ReliefShape = iota
+	// ParabolicRelief increases with the square of the roll angle, blending smoothly into the involute.
+	ParabolicRelief
+)
+
+// FlankRelief is a relief (material removed normal to the involute) at the tip or root of a
+// tooth flank. The relief starts at a roll angle (the involute angle, tan of the pressure angle
+// at the radius) and increases to the amount at the tip (or the start of the involute at the root).
+type FlankRelief struct {
+	Shape  ReliefShape // relief shape
+	Amount float64     // relief at the tip (or root) of the flank (0 = none)
+	Start  float64     // roll angle where the relief starts (radians)
+}
+
+// GearRelief defines the tip and root relief for involute gear teeth.
+type GearRelief struct {
+	Tip  FlankRelief // tip relief
+	Root FlankRelief // root relief
+}
+
+// rollAngle returns the involute roll angle at a radius.
+func rollAngle(baseRadius, r float64) float64 {
+	return involuteTheta(baseRadius, Max(baseRadius, r))
+}
+
+// check returns an error if the relief doesn't fit on the flank.
+func (g *GearRelief) check(baseRadius, rootRadius, outerRadius float64) error {
+	t0 := rollAngle(baseRadius, rootRadius)
+	t1 := rollAngle(baseRadius, outerRadius)
+	if g.Tip.Amount < 0 || g.Root.Amount < 0 {
+		return errors.New("relief amount < 0")
+	}
+	for _, r := range []FlankRelief{g.Tip, g.Root} {
+		if r.Shape != LinearRelief && r.Shape != ParabolicRelief {
+			return errors.New("bad relief shape")
+		}
+	}
+	if g.Tip.Amount > 0 && (g.Tip.Start <= t0 || g.Tip.Start >= t1) {
+		return errors.New("the tip relief doesn't start on the involute")
+	}
+	if g.Root.Amount > 0 && (g.Root.Start <= t0 || g.Root.Start >= t1) {
+		return errors.New("the root relief doesn't start on the involute")
+	}
+	if g.Tip.Amount > 0 && g.Root.Amount > 0 && g.Root.Start > g.Tip.Start {
+		return errors.New("the tip and root reliefs overlap")
+	}
+	return nil
+}
+
+// amount returns the relief for a normalized position (0 at the start, 1 at the end).
+func (r *FlankRelief) amount(x float64) float64 {
+	if r.Amount == 0 || x <= 0 {
+		return 0
+	}
+	x = Min(x, 1)
+	if r.Shape == ParabolicRelief {
+		x *= x
+	}
+	return r.Amount * x
+}
+
+// apply reduces the half angular thickness of a tooth at a set of radii by the relief.
+// Relief normal to the involute is a rotation of the flank about the gear center.
+func (g *GearRelief) apply(baseRadius, rootRadius, outerRadius float64, radius, alpha []float64) {
+	t0 := rollAngle(baseRadius, rootRadius)
+	t1 := rollAngle(baseRadius, outerRadius)
+	for i, r := range radius {
+		if r < baseRadius {
+			// not on the involute
+			continue
+		}
+		t := rollAngle(baseRadius, r)
+		d := g.Tip.amount((t - g.Tip.Start) / (t1 - g.Tip.Start))
+		d += g.Root.amount((g.Root.Start - t) / (g.Root.Start - t0))
+		alpha[i] -= d / baseRadius
+	}
+}
+
+// RelievedInvoluteGear returns a 2D involute gear with tip and root relief on the tooth flanks.
+func RelievedInvoluteGear(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as per-tooth distance at pitch circumference
+	clearance float64, // additional root clearance
+	ringWidth float64, // width of ring wall (from root circle)
+	relief *GearRelief, // tip and root relief
+	facets int, // number of facets for involute flank
+) (SDF2, error) {
+	if relief == nil {
+		return nil, errors.New("nil relief")
+	}
+	if facets <= 0 {
+		return nil, errors.New("facets <= 0")
+	}
+	rp := float64(numberTeeth) * gearModule / 2.0
+	rb := rp * math.Cos(pressureAngle)
+	if err := relief.check(rb, rp-gearModule-clearance, rp+gearModule); err != nil {
+		return nil, err
+	}
+	return involuteGear(numberTeeth, gearModule, pressureAngle, backlash, gearModule, clearance, ringWidth, relief, facets), nil
+}
+
+//-----------------------------------------------------------------------------
+// Inspection Dimensions
+
+// GearInspection gives the inspection dimensions for an external involute spur gear made with
+// InvoluteGear (no profile shift), so a printed or machined gear can be checked with calipers
+// or a micrometer. The dimensions include the tooth thinning for the backlash.
+type GearInspection struct {
+	NumberTeeth   int     // number of gear teeth
+	Module        float64 // pitch circle diameter / number of gear teeth
+	PressureAngle float64 // gear pressure angle (radians)
+	Backlash      float64 // backlash expressed as per-tooth distance at pitch circumference
+}
+
+// involute returns the involute function, tan(a) - a.
+func involute(a float64) float64 {
+	return math.Tan(a) - a
+}
+
+// PitchRadius returns the pitch radius.
+func (g *GearInspection) PitchRadius() float64 {
+	return 0.5 * g.Module * float64(g.NumberTeeth)
+}
+
+// BaseRadius returns the radius of the base circle.
+func (g *GearInspection) BaseRadius() float64 {
+	return g.PitchRadius() * math.Cos(g.PressureAngle)
+}
+
+// ToothThickness returns the circular tooth thickness at the pitch circle.
+func (g *GearInspection) ToothThickness() float64 {
+	return 0.5*Pi*g.Module - g.Backlash
+}
+
+// SpanTeeth returns the usual number of teeth to measure the span over, the caliper jaws then
+// touch the flanks near the pitch circle.
+func (g *GearInspection) SpanTeeth() int {
+	k := int(math.Round(float64(g.NumberTeeth)*g.PressureAngle/Pi + 0.5))
+	return int(Clamp(float64(k), 1, float64(g.NumberTeeth-1)))
+}
+
+// Span returns the base tangent length, the distance over k teeth measured between parallel
+// caliper jaws.
+func (g *GearInspection) Span(k int) float64 {
+	rb := g.BaseRadius()
+	// base pitch and tooth thickness on the base circle
+	pb := Tau * rb / float64(g.NumberTeeth)
+	sb := rb * (g.ToothThickness()/g.PitchRadius() + 2*involute(g.PressureAngle))
+	return float64(k-1)*pb + sb
+}
+
+// PinDiameter returns the diameter of the measuring pins (or balls) which touch the tooth flanks
+// at the pitch circle.
+func (g *GearInspection) PinDiameter() float64 {
+	a := g.PressureAngle
+	phi := a + Pi/float64(g.NumberTeeth) - g.ToothThickness()/(2*g.PitchRadius())
+	return 2 * g.BaseRadius() * (math.Tan(phi) - math.Tan(a))
+}
+
+// pinAngle returns the pressure angle at the center of a measuring pin.
+func (g *GearInspection) pinAngle(pinDiameter float64) float64 {
+	rb := g.BaseRadius()
+	x := involute(g.PressureAngle) + 0.5*pinDiameter/rb - Pi/float64(g.NumberTeeth) + g.ToothThickness()/(2*g.PitchRadius())
+	// solve inv(phi) = x
+	lo, hi := 0.0, 0.5*Pi-epsilon
+	for i := 0; i < 100; i++ {
+		phi := 0.5 * (lo + hi)
+		if involute(phi) > x {
+			hi = phi
+		} else {
+			lo = phi
+		}
+	}
+	return 0.5 * (lo + hi)
+}
+
+// PinRadius returns the distance from the gear center to the center of a measuring pin placed
+// in a tooth space.
+func (g *GearInspection) PinRadius(pinDiameter float64) float64 {
+	return g.BaseRadius() / math.Cos(g.pinAngle(pinDiameter))
+}
+
+// OverPins returns the measurement over two pins (or balls) placed in opposite tooth spaces.
+// With an odd number of teeth the spaces aren't directly opposite and the measurement is
+// correspondingly less.
+func (g *GearInspection) OverPins(pinDiameter float64) float64 {
+	r := g.PinRadius(pinDiameter)
+	if g.NumberTeeth%2 != 0 {
+		r *= math.Cos(0.5 * Pi / float64(g.NumberTeeth))
+	}
+	return 2*r + pinDiameter
+}
+
+//-----------------------------------------------------------------------------
+// Design Report
+
+// GearReport gives the derived quantities for an InvoluteGear and a mating gear, so a gear pair
+// can be checked before it's rendered. The mating gear is an InvoluteGear with the same module,
+// pressure angle, backlash and clearance.
+type GearReport struct {
+	PitchDiameter  float64 // pitch circle diameter
+	BaseDiameter   float64 // base circle diameter
+	RootDiameter   float64 // root circle diameter
+	OuterDiameter  float64 // outside (tip) circle diameter
+	MinTeeth       int     // minimum number of teeth without undercut for the pressure angle
+	Undercut       bool    // the gear has fewer teeth than MinTeeth
+	MateUndercut   bool    // the mating gear has fewer teeth than MinTeeth
+	CenterDistance float64 // center distance with the mating gear
+	ContactRatio   float64 // transverse contact ratio with the mating gear
+	Backlash       float64 // backlash of the pair at the pitch circle
+}
+
+// InvoluteGearReport returns the derived quantities for an InvoluteGear meshing with a mating gear.
+func InvoluteGearReport(
+	numberTeeth int, // number of gear teeth
+	mateTeeth int, // number of teeth on the mating gear
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as per-tooth distance at pitch circumference
+	clearance float64, // additional root clearance
+) (*GearReport, error) {
+	if numberTeeth < 4 {
+		return nil, errors.New("numberTeeth < 4")
+	}
+	if mateTeeth < 4 {
+		return nil, errors.New("mateTeeth < 4")
+	}
+	if gearModule <= 0 {
+		return nil, errors.New("gearModule <= 0")
+	}
+	if pressureAngle <= 0 || pressureAngle >= DtoR(45) {
+		return nil, errors.New("bad pressure angle")
+	}
+	if backlash < 0 || clearance < 0 {
+		return nil, errors.New("backlash and clearance must be >= 0")
+	}
+	m := gearModule
+	r1 := 0.5 * m * float64(numberTeeth)
+	r2 := 0.5 * m * float64(mateTeeth)
+	cos := math.Cos(pressureAngle)
+	sin := math.Sin(pressureAngle)
+	// the rack generated teeth aren't undercut if z >= 2/sin^2(a)
+	minTeeth := int(math.Ceil(2/(sin*sin) - epsilon))
+	a := r1 + r2
+	// outer and base radii
+	ra1, rb1 := r1+m, r1*cos
+	ra2, rb2 := r2+m, r2*cos
+	// length of the path of contact
+	path := math.Sqrt(ra1*ra1-rb1*rb1) + math.Sqrt(ra2*ra2-rb2*rb2) - a*sin
+	return &GearReport{
+		PitchDiameter:  2 * r1,
+		BaseDiameter:   2 * r1 * cos,
+		RootDiameter:   2 * (r1 - m - clearance),
+		OuterDiameter:  2 * (r1 + m),
+		MinTeeth:       minTeeth,
+		Undercut:       numberTeeth < minTeeth,
+		MateUndercut:   mateTeeth < minTeeth,
+		CenterDistance: a,
+		ContactRatio:   path / (Pi * m * cos), // path length / base pitch
+		Backlash:       2 * backlash,
+	}, nil
+}
+
+// Check returns an error if the gear pair won't run smoothly.
+func (r *GearReport) Check() error {
+	if r.ContactRatio < 1 {
+		return fmt.Errorf("contact ratio %.3f < 1, the teeth lose contact", r.ContactRatio)
+	}
+	if r.RootDiameter <= 0 {
+		return errors.New("root diameter <= 0")
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// Internal Gears
+
+// InternalInvoluteGear returns a 2D profile for an internal (ring) gear.
+// The tooth spaces are cut with the tooth form of an external gear with the same number of teeth.
+// The root clearance is on the outside (the tips of the mating pinion), and the tip circle is kept
+// on or outside the base circle since the involute doesn't extend inside it.
+// The tooth spaces are centered on the angles 2*pi*i/numberTeeth, so a pinion with a tooth on
+// the +x axis meshes when it is offset along the x-axis by the difference of the pitch radii.
+func InternalInvoluteGear(
+	numberTeeth int, // number of gear teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as per-tooth distance at pitch circumference
+	clearance float64, // additional root clearance
+	ringWidth float64, // width of ring wall (from root circle)
+	facets int, // number of facets for involute flank
+) SDF2 {
+
+	// pitch radius
+	pitchRadius := float64(numberTeeth) * gearModule / 2.0
+
+	// base circle radius
+	baseRadius := pitchRadius * math.Cos(pressureAngle)
+
+	// addendum: radial distance from pitch circle to inside (tip) circle
+	addendum := gearModule * 1.0
+	// dedendum: radial distance from pitch circle to root circle
+	dedendum := addendum + clearance
+
+	tipRadius := Max(pitchRadius-addendum, baseRadius)
+	rootRadius := pitchRadius + dedendum
+	ringRadius := rootRadius + ringWidth
+
+	// the tooth space is an external tooth, widened by the backlash
+	space := InvoluteGearTooth(
+		numberTeeth,
+		gearModule,
+		0,
+		baseRadius,
+		rootRadius,
+		-backlash,
+		facets,
+	)
+
+	spaces := RotateCopy2D(space, numberTeeth)
+	tip := Circle2D(tipRadius)
+	ring := Circle2D(ringRadius)
+
+	return Difference2D(ring, Union2D(spaces, tip))
+}
+
+//-----------------------------------------------------------------------------
+// Involute Splines
+
+// SplineFit is the fit class for an involute spline shaft and hub.
+// The fit sets the side clearance (circular, at the pitch circle) between the shaft teeth and the
+// hub spaces, half is taken from the shaft teeth and half is added to the hub spaces.
+type SplineFit int
+
+const (
+	// SplineFitTight has no side clearance (press fit).
+	SplineFitTight SplineFit = iota
+	// SplineFitClose has a side clearance of 0.01 module.
+	SplineFitClose
+	// SplineFitSliding has a side clearance of 0.03 module.
+	SplineFitSliding
+	// SplineFitLoose has a side clearance of 0.06 module.
+	SplineFitLoose
+)
+
+var splineFits = map[SplineFit]float64{
+	SplineFitTight:   0,
+	SplineFitClose:   0.01,
+	SplineFitSliding: 0.03,
+	SplineFitLoose:   0.06,
+}
+
+// SplineParms defines the parameters for an involute spline (ANSI B92.1, DIN 5480 style).
+// The splines have flat roots with the ANSI proportions: the shaft major diameter is
+// (teeth + 1) * module, the hub minor diameter is (teeth - 1) * module, and there is a radial
+// clearance of 0.175 * module at the shaft and hub roots.
+type SplineParms struct {
+	Teeth         int       // number of teeth
+	Module        float64   // pitch diameter / number of teeth
+	PressureAngle float64   // pressure angle (radians), usually 30 degrees
+	Fit           SplineFit // fit class
+	Clearance     float64   // additional side clearance (E.g. for printed parts)
+	Facets        int       // number of facets for the involute flanks (0 = adaptive)
+}
+
+// PitchDiameter returns the pitch diameter of the spline.
+func (k *SplineParms) PitchDiameter() float64 {
+	return k.Module * float64(k.Teeth)
+}
+
+// sideClearance returns the total side clearance for the spline.
+func (k *SplineParms) sideClearance() (float64, error) {
+	if k.Teeth < 6 {
+		return 0, errors.New("teeth < 6")
+	}
+	if k.Module <= 0 {
+		return 0, errors.New("module <= 0")
+	}
+	if k.PressureAngle <= 0 || k.PressureAngle > DtoR(45) {
+		return 0, errors.New("bad pressure angle")
+	}
+	if k.Clearance < 0 {
+		return 0, errors.New("clearance < 0")
+	}
+	if k.Facets < 0 {
+		return 0, errors.New("facets < 0")
+	}
+	fit, ok := splineFits[k.Fit]
+	if !ok {
+		return 0, errors.New("bad spline fit")
+	}
+	return fit*k.Module + k.Clearance, nil
+}
+
+// SplineShaft2D returns the 2D profile of an external involute spline (shaft).
+// The teeth are centered on the angles 2*pi*i/Teeth.
+func SplineShaft2D(k *SplineParms) (SDF2, error) {
+	c, err := k.sideClearance()
+	if err != nil {
+		return nil, err
+	}
+	m := k.Module
+	n := float64(k.Teeth)
+	rb := 0.5 * k.PitchDiameter() * math.Cos(k.PressureAngle)
+	rootRadius := 0.5 * m * (n - 1.35)
+	outerRadius := 0.5 * m * (n + 1)
+	tooth := InvoluteGearTooth(k.Teeth, m, rootRadius, rb, outerRadius, 0.5*c, k.Facets)
+	return Union2D(RotateCopy2D(tooth, k.Teeth), Circle2D(rootRadius)), nil
+}
+
+// SplineHub2D returns the 2D profile of the hole for an internal involute spline (hub).
+// Subtract it from the hub body. The tooth spaces are centered on the angles 2*pi*i/Teeth,
+// so the hub mates with the shaft from SplineShaft2D.
+func SplineHub2D(k *SplineParms) (SDF2, error) {
+	c, err := k.sideClearance()
+	if err != nil {
+		return nil, err
+	}
+	m := k.Module
+	n := float64(k.Teeth)
+	rb := 0.5 * k.PitchDiameter() * math.Cos(k.PressureAngle)
+	minorRadius := 0.5 * m * (n - 1)
+	rootRadius := 0.5 * m * (n + 1.35)
+	// the tooth space is an external tooth, widened by the clearance
+	space := InvoluteGearTooth(k.Teeth, m, 0, rb, rootRadius, -0.5*c, k.Facets)
+	return Union2D(RotateCopy2D(space, k.Teeth), Circle2D(minorRadius)), nil
+}
+
+//-----------------------------------------------------------------------------
+// Helical Gears
+
+// HelicalGearParms defines the parameters for a helical gear.
+// The module, pressure angle and backlash are in the normal plane (the plane of the cutter).
+type HelicalGearParms struct {
+	NumberTeeth   int         // number of gear teeth
+	Module        float64     // normal module
+	PressureAngle float64     // normal pressure angle (radians)
+	HelixAngle    float64     // helix angle at the pitch circle (radians, < 0 reverses the hand)
+	Hand          Hand        // helix hand
+	FaceWidth     float64     // width of the gear face (along the z-axis)
+	Backlash      float64     // normal backlash expressed as per-tooth distance at pitch circumference
+	Clearance     float64     // additional root clearance
+	RingWidth     float64     // width of ring wall (from root circle)
+	Relief        *GearRelief // tip and root relief in the transverse plane (nil = none)
+	Facets        int         // number of facets for involute flank
+}
+
+// helixAngle returns the helix angle, > 0 for right hand and < 0 for left hand.
+func (k *HelicalGearParms) helixAngle() float64 {
+	return k.HelixAngle * float64(k.Hand.Sign())
+}
+
+// TransverseModule returns the module in the plane of rotation.
+func (k *HelicalGearParms) TransverseModule() float64 {
+	return k.Module / math.Cos(k.HelixAngle)
+}
+
+// PitchRadius returns the pitch radius of the helical gear.
+// The center distance for a meshing pair (with opposite hand helix angles) is the sum of the
+// pitch radii.
+func (k *HelicalGearParms) PitchRadius() float64 {
+	return float64(k.NumberTeeth) * k.TransverseModule() / 2.0
+}
+
+// helicalGear returns the transverse profile for a helical gear.
+func helicalGear(k *HelicalGearParms) (SDF2, error) {
+	if k.NumberTeeth <= 0 {
+		return nil, errors.New("number of teeth <= 0")
+	}
+	if k.Module <= 0 {
+		return nil, errors.New("module <= 0")
+	}
+	if k.PressureAngle <= 0 || k.PressureAngle >= DtoR(45) {
+		return nil, errors.New("bad pressure angle")
+	}
+	if Abs(k.HelixAngle) >= DtoR(60) {
+		return nil, errors.New("helix angle >= 60 degrees")
+	}
+	if k.FaceWidth <= 0 {
+		return nil, errors.New("face width <= 0")
+	}
+	if k.Facets <= 0 {
+		return nil, errors.New("facets <= 0")
+	}
+	c := math.Cos(k.HelixAngle)
+	mt := k.Module / c
+	// transverse pressure angle
+	pa := math.Atan(math.Tan(k.PressureAngle) / c)
+	if k.Relief != nil {
+		rp := k.PitchRadius()
+		if err := k.Relief.check(rp*math.Cos(pa), rp-k.Module-k.Clearance, rp+k.Module); err != nil {
+			return nil, err
+		}
+	}
+	// the addendum is set by the normal module
+	return involuteGear(k.NumberTeeth, mt, pa, k.Backlash/c, k.Module, k.Clearance, k.RingWidth, k.Relief, k.Facets), nil
+}
+
+// HelicalGear3D returns a helical gear centered on the origin with its axis along the z-axis.
+// The transverse tooth profile is derived from the normal module and pressure angle.
+func HelicalGear3D(k *HelicalGearParms) (SDF3, error) {
+	gear, err := helicalGear(k)
+	if err != nil {
+		return nil, err
+	}
+	// the teeth advance around the pitch circle by tan(helix angle) per unit of height
+	twist := -k.FaceWidth * math.Tan(k.helixAngle()) / k.PitchRadius()
+	return TwistExtrude3D(gear, k.FaceWidth, twist), nil
+}
+
+// HerringboneGear3D returns a herringbone (double helical) gear centered on the origin with its
+// axis along the z-axis. Each half of the face width is a helical gear with the helix angle of
+// the lower half, the upper half is its mirror image. An optional relief groove (cut down to the
+// root circle) separates the halves.
+func HerringboneGear3D(
+	k *HelicalGearParms, // gear parameters (for the lower half)
+	groove float64, // width of the central relief groove (0 = none)
+) (SDF3, error) {
+	if groove < 0 || groove >= k.FaceWidth {
+		return nil, errors.New("bad groove width")
+	}
+	gear, err := helicalGear(k)
+	if err != nil {
+		return nil, err
+	}
+	twist := 0.5 * k.FaceWidth * math.Tan(k.helixAngle()) / k.PitchRadius()
+	s := HerringboneExtrude3D(gear, k.FaceWidth, twist)
+	if groove > 0 {
+		rootRadius := k.PitchRadius() - k.Module - k.Clearance
+		r := k.PitchRadius() + 2*k.Module
+		relief := Difference3D(Cylinder3D(groove, r, 0), Cylinder3D(groove, rootRadius, 0))
+		s = Difference3D(s, relief)
+	}
+	return s, nil
+}
+
 //-----------------------------------------------------------------------------
 // 2D Gear Rack
 
@@ -145,6 +1127,95 @@ func GearRack2D(
 	pressureAngle float64, // gear pressure angle (radians)
 	backlash float64, // backlash expressed as units of pitch circumference
 	baseHeight float64, // height of rack base
+) SDF2 {
+	return gearRack(numberTeeth, gearModule, pressureAngle, backlash, baseHeight, 0, 0, 0)
+}
+
+// CheckedGearRack2D returns the 2D profile for a gear rack, with an error for invalid parameters.
+func CheckedGearRack2D(
+	numberTeeth float64, // number of rack teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as units of pitch circumference
+	baseHeight float64, // height of rack base
+) (SDF2, error) {
+	if numberTeeth <= 0 {
+		return nil, errors.New("number of teeth <= 0")
+	}
+	if gearModule <= 0 {
+		return nil, errors.New("module <= 0")
+	}
+	if pressureAngle <= 0 || pressureAngle >= DtoR(45) {
+		return nil, errors.New("bad pressure angle")
+	}
+	if backlash < 0 {
+		return nil, errors.New("backlash < 0")
+	}
+	if baseHeight < 0 {
+		return nil, errors.New("base height < 0")
+	}
+	return GearRack2D(numberTeeth, gearModule, pressureAngle, backlash, baseHeight), nil
+}
+
+// GearRackParms defines the parameters for a gear rack with rounded tooth tips and roots.
+type GearRackParms struct {
+	NumberTeeth   float64 // number of rack teeth
+	Module        float64 // pitch circle diameter / number of gear teeth
+	PressureAngle float64 // gear pressure angle (radians)
+	Backlash      float64 // backlash expressed as units of pitch circumference
+	BaseHeight    float64 // height of rack base
+	TipRadius     float64 // radius of the tooth tip corners (0 = sharp)
+	RootRadius    float64 // radius of the tooth root corners (0 = sharp)
+	Facets        int     // number of facets for the rounded corners
+}
+
+// GearRackRounded2D returns the 2D profile for a gear rack with rounded tooth tips and roots.
+func GearRackRounded2D(k *GearRackParms) (SDF2, error) {
+	if k.NumberTeeth <= 0 {
+		return nil, errors.New("number of teeth <= 0")
+	}
+	if k.Module <= 0 {
+		return nil, errors.New("module <= 0")
+	}
+	if k.PressureAngle <= 0 || k.PressureAngle >= DtoR(45) {
+		return nil, errors.New("bad pressure angle")
+	}
+	if k.TipRadius < 0 || k.RootRadius < 0 {
+		return nil, errors.New("tip/root radius < 0")
+	}
+	if (k.TipRadius > 0 || k.RootRadius > 0) && k.Facets <= 0 {
+		return nil, errors.New("facets <= 0")
+	}
+	// the flank meets the tip and root flats at 90 + pressure angle
+	t := math.Tan(0.5 * (0.5*Pi + k.PressureAngle))
+	dTip := k.TipRadius / t
+	dRoot := k.RootRadius / t
+	pitch := k.Module * Pi
+	dx := 2.25 * k.Module * math.Tan(k.PressureAngle)
+	dxt := 0.5 * (0.5*pitch - dx)
+	bl := 0.5 * k.Backlash
+	if dTip > dxt-bl {
+		return nil, errors.New("tip radius is too large for the tooth tip")
+	}
+	if dRoot > dxt+bl {
+		return nil, errors.New("root radius is too large for the tooth space")
+	}
+	if dTip+dRoot > 2.25*k.Module/math.Cos(k.PressureAngle) {
+		return nil, errors.New("tip and root radii are too large for the tooth flank")
+	}
+	return gearRack(k.NumberTeeth, k.Module, k.PressureAngle, k.Backlash, k.BaseHeight, k.TipRadius, k.RootRadius, k.Facets), nil
+}
+
+// gearRack returns the 2D profile for a gear rack with optional rounded tooth tips and roots.
+func gearRack(
+	numberTeeth float64, // number of rack teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as units of pitch circumference
+	baseHeight float64, // height of rack base
+	tipRadius float64, // radius of the tooth tip corners
+	rootRadius float64, // radius of the tooth root corners
+	facets int, // number of facets for the rounded corners
 ) SDF2 {
 	s := GearRackSDF2{}
 
@@ -165,16 +1236,15 @@ func GearRack2D(
 	bl := backlash / 2.0
 
 	// create a half tooth profile centered on the y-axis
-	tooth := []V2{
-		{pitch, 0},
-		{pitch, baseHeight},
-		{dx + dxt - bl, baseHeight},
-		{dxt - bl, toothHeight},
-		{-pitch, toothHeight},
-		{-pitch, 0},
-	}
+	tooth := NewPolygon()
+	tooth.Add(pitch, 0)
+	tooth.Add(pitch, baseHeight)
+	tooth.Add(dx+dxt-bl, baseHeight).Smooth(rootRadius, facets)
+	tooth.Add(dxt-bl, toothHeight).Smooth(tipRadius, facets)
+	tooth.Add(-pitch, toothHeight)
+	tooth.Add(-pitch, 0)
 
-	s.tooth = Polygon2D(tooth)
+	s.tooth = Polygon2D(tooth.Vertices())
 	s.pitch = pitch
 	s.length = pitch * numberTeeth / 2.0
 	s.bb = Box2{V2{-s.length, 0}, V2{s.length, toothHeight}}
@@ -199,3 +1269,167 @@ func (s *GearRackSDF2) BoundingBox() Box2 {
 }
 
 //-----------------------------------------------------------------------------
+// Arc and Face Gear Racks
+
+// ArcGearRackSDF2 is a gear rack bent around a circular arc.
+type ArcGearRackSDF2 struct {
+	rack   SDF2    // straight gear rack
+	radius float64 // pitch line radius (< 0 for teeth on the inside of the arc)
+	pitchY float64 // y position of the pitch line (at the middle of the rack)
+	center V2      // center of the arc
+	bb     Box2    // bounding box
+}
+
+// ArcGearRack2D returns the 2D profile for a gear rack with the teeth along a circular arc.
+// The pitch line is bent to the radius, with the teeth on the outside of the arc (radius > 0)
+// or the inside of the arc (radius < 0). The middle of the rack is on the y-axis, as with
+// GearRack2D. Use a large radius, the tooth flanks are straight (bent) rack flanks.
+func ArcGearRack2D(
+	numberTeeth float64, // number of rack teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as units of pitch circumference
+	baseHeight float64, // height of rack base
+	radius float64, // radius of the pitch line (< 0 for teeth on the inside of the arc)
+) (SDF2, error) {
+	rack := GearRack2D(numberTeeth, gearModule, pressureAngle, backlash, baseHeight)
+	rbb := rack.BoundingBox()
+	s := ArcGearRackSDF2{
+		rack:   rack,
+		radius: radius,
+		pitchY: baseHeight + 1.25*gearModule,
+	}
+	r := Abs(radius)
+	if r <= s.pitchY || r <= rbb.Max.Y-s.pitchY {
+		return nil, errors.New("radius is too small for the rack")
+	}
+	if rbb.Max.X/r >= Pi {
+		return nil, errors.New("rack is too long for the radius")
+	}
+	if radius > 0 {
+		s.center = V2{0, s.pitchY - r}
+	} else {
+		s.center = V2{0, s.pitchY + r}
+	}
+	// sample the arc for the bounding box
+	const n = 64
+	var v V2Set
+	for i := 0; i <= n; i++ {
+		x := Mix(rbb.Min.X, rbb.Max.X, float64(i)/n)
+		v = append(v, s.toXY(V2{x, rbb.Min.Y}), s.toXY(V2{x, rbb.Max.Y}))
+	}
+	// allow for the sagitta between the samples
+	dtheta := (rbb.Max.X - rbb.Min.X) / (r * n)
+	k := V2{1, 1}.MulScalar((r + rbb.Max.Y) * (1 - math.Cos(0.5*dtheta)))
+	s.bb = Box2{v.Min().Sub(k), v.Max().Add(k)}
+	return &s, nil
+}
+
+// toXY maps a point from the straight rack to the arc rack.
+func (s *ArcGearRackSDF2) toXY(q V2) V2 {
+	r := Abs(s.radius)
+	sin, cos := math.Sincos(q.X / r)
+	if s.radius > 0 {
+		return s.center.Add(V2{sin, cos}.MulScalar(r + q.Y - s.pitchY))
+	}
+	return s.center.Add(V2{sin, -cos}.MulScalar(r - q.Y + s.pitchY))
+}
+
+// Evaluate returns the minimum distance to the arc gear rack.
+func (s *ArcGearRackSDF2) Evaluate(p V2) float64 {
+	r := Abs(s.radius)
+	v := p.Sub(s.center)
+	l := v.Length()
+	var q V2
+	if s.radius > 0 {
+		q = V2{r * math.Atan2(v.X, v.Y), s.pitchY + l - r}
+	} else {
+		q = V2{r * math.Atan2(v.X, -v.Y), s.pitchY - l + r}
+	}
+	// the x-axis is stretched by l/r
+	return s.rack.Evaluate(q) * Min(1, l/r)
+}
+
+// BoundingBox returns the bounding box for the arc gear rack.
+func (s *ArcGearRackSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+// Children returns the child nodes of the arc gear rack.
+func (s *ArcGearRackSDF2) Children() []interface{} { return []interface{}{s.rack} }
+
+//-----------------------------------------------------------------------------
+
+// FaceRackSDF3 is a face rack (crown gear), with the rack teeth running radially on the face of a disk.
+type FaceRackSDF3 struct {
+	rack        SDF2    // straight gear rack for the full circumference
+	radius      float64 // reference (pitch) radius
+	pitchZ      float64 // z position of the pitch plane
+	baseHeight  float64 // height of the base disk
+	innerRadius float64 // inner radius of the teeth
+	outerRadius float64 // outer radius of the teeth
+	bb          Box3    // bounding box
+}
+
+// FaceRack3D returns a face rack (crown gear) on the xy-plane with the teeth facing +z.
+// The teeth have the rack profile (GearRack2D) at the reference radius, the tooth size scales
+// with the radius so the teeth converge on the center, as needed for a pinion with its axis on
+// the xy-plane through the center (a 90 degree bevel drive). The reference radius is
+// numberTeeth * gearModule / 2.
+func FaceRack3D(
+	numberTeeth int, // number of teeth
+	gearModule float64, // pitch circle diameter / number of gear teeth (at the reference radius)
+	pressureAngle float64, // gear pressure angle (radians)
+	backlash float64, // backlash expressed as units of pitch circumference
+	baseHeight float64, // height of the base disk
+	innerRadius float64, // inner radius of the teeth
+	outerRadius float64, // outer radius of the teeth
+) (SDF3, error) {
+	if numberTeeth <= 0 {
+		return nil, errors.New("number of teeth <= 0")
+	}
+	if gearModule <= 0 {
+		return nil, errors.New("module <= 0")
+	}
+	if baseHeight < 0 {
+		return nil, errors.New("base height < 0")
+	}
+	if innerRadius <= 0 || outerRadius <= innerRadius {
+		return nil, errors.New("bad inner/outer radius")
+	}
+	s := FaceRackSDF3{
+		rack:        GearRack2D(float64(numberTeeth), gearModule, pressureAngle, backlash, baseHeight),
+		radius:      float64(numberTeeth) * gearModule / 2,
+		pitchZ:      baseHeight + 1.25*gearModule,
+		baseHeight:  baseHeight,
+		innerRadius: innerRadius,
+		outerRadius: outerRadius,
+	}
+	// the tooth tips rise with the radius
+	h := s.pitchZ + (s.rack.BoundingBox().Max.Y-s.pitchZ)*outerRadius/s.radius
+	s.bb = Box3{V3{-outerRadius, -outerRadius, 0}, V3{outerRadius, outerRadius, Max(h, baseHeight)}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to the face rack.
+func (s *FaceRackSDF3) Evaluate(p V3) float64 {
+	r := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	k := s.radius / Max(r, s.innerRadius)
+	// map to the rack at the reference radius
+	q := V2{s.radius * math.Atan2(p.Y, p.X), s.pitchZ + (p.Z-s.pitchZ)*k}
+	d := s.rack.Evaluate(q) / k
+	// flat base disk
+	d = Min(d, p.Z-s.baseHeight)
+	d = Max(d, -p.Z)
+	return Max(d, Max(r-s.outerRadius, s.innerRadius-r))
+}
+
+// BoundingBox returns the bounding box for the face rack.
+func (s *FaceRackSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of the face rack.
+func (s *FaceRackSDF3) Children() []interface{} { return []interface{}{s.rack} }
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,223 @@
+//-----------------------------------------------------------------------------
+/*
+
+Gridfinity Bins and Baseplates
+
+Gridfinity is a modular storage system. Bins sit on a baseplate with a 42mm
+grid and bin heights are multiples of 7mm. The feet of a bin and the pockets
+of a baseplate have matching 45 degree profiles.
+
+The cross section of every part of the profile is a rounded rectangle with
+the same straight section, so the profiles are built as stacks of elongated
+cones (as for TruncRectPyramid3D).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+const gfPitch = 42.0           // grid pitch
+const gfHeight = 7.0           // height unit
+const gfClearance = 0.5        // bin to grid clearance
+const gfRadius = 3.75          // outside corner radius of a bin
+const gfLip = 4.4              // height of the bin stacking lip
+const gfMagnetRadius = 3.25    // magnet hole radius
+const gfMagnetDepth = 2.4      // magnet hole depth
+const gfScrewRadius = 1.5      // screw hole radius
+const gfScrewDepth = 6.0       // screw hole depth
+const gfHoleOffset = 13.0      // magnet/screw hole offset from the cell center
+const gfLabelDepth = 12.0      // depth of the label shelf
+const gfBaseplateRadius = 4.0  // outside corner radius of a baseplate
+const gfBaseplateFloor = 3.0   // baseplate thickness below the pockets (with magnets)
+const gfBaseplatePocket = 4.65 // depth of a baseplate pocket
+
+// gfCore is the straight section of a single cell.
+var gfCore = V2{gfPitch - gfClearance - 2*gfRadius, gfPitch - gfClearance - 2*gfRadius}
+
+// gfFoot is the (radius, z) profile of a bin foot.
+var gfFoot = []V2{{0.8, 0}, {1.6, 0.8}, {1.6, 2.6}, {gfRadius, 4.75}}
+
+// gfStack returns a stack of tapered sections with a rounded rectangle cross section.
+// The profile is a set of (corner radius, z) points with increasing z.
+func gfStack(core V2, profile []V2) SDF3 {
+	var s []SDF3
+	for i := 0; i < len(profile)-1; i++ {
+		p0, p1 := profile[i], profile[i+1]
+		h := p1.Y - p0.Y
+		if h <= 0 {
+			continue
+		}
+		c := Elongate3D(Cone3D(h, p0.X, p1.X, 0), V3{core.X, core.Y, 0})
+		s = append(s, Transform3D(c, Translate3d(V3{0, 0, 0.5 * (p0.Y + p1.Y)})))
+	}
+	return Union3D(s...)
+}
+
+// gfCells returns copies of an SDF3 at the centers of an x by y grid of cells.
+func gfCells(s SDF3, n V2i) SDF3 {
+	var cells []SDF3
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			x := (float64(i) - 0.5*float64(n[0]-1)) * gfPitch
+			y := (float64(j) - 0.5*float64(n[1]-1)) * gfPitch
+			cells = append(cells, Transform3D(s, Translate3d(V3{x, y, 0})))
+		}
+	}
+	return Union3D(cells...)
+}
+
+// gfHoles returns the 4 magnet/screw holes of a cell (from z = 0 upwards).
+func gfHoles(r, depth float64) SDF3 {
+	h := Cylinder3D(2*depth, r, 0)
+	d := gfHoleOffset
+	return Union3D(
+		Transform3D(h, Translate3d(V3{d, d, 0})),
+		Transform3D(h, Translate3d(V3{-d, d, 0})),
+		Transform3D(h, Translate3d(V3{d, -d, 0})),
+		Transform3D(h, Translate3d(V3{-d, -d, 0})),
+	)
+}
+
+//-----------------------------------------------------------------------------
+
+// GridfinityBinParms defines the parameters for a gridfinity bin.
+type GridfinityBinParms struct {
+	Size     V3i     // size in grid units (x, y, height), height >= 2
+	Wall     float64 // wall thickness (typically 1.2)
+	Dividers V2i     // number of dividers across the x and y axes
+	Label    bool    // add a label shelf on the back (+y) wall
+	Lip      bool    // add a stacking lip
+	Magnets  bool    // add magnet holes to the base
+	Screws   bool    // add screw holes to the base
+}
+
+// GridfinityBin3D returns a gridfinity bin. The bottom of the bin is at z = 0.
+func GridfinityBin3D(k *GridfinityBinParms) (SDF3, error) {
+	if k.Size[0] < 1 || k.Size[1] < 1 {
+		return nil, errors.New("bin size < 1 unit")
+	}
+	if k.Size[2] < 2 {
+		return nil, errors.New("bin height < 2 units")
+	}
+	if k.Wall <= 0 || k.Wall >= gfRadius-1 {
+		return nil, errors.New("invalid wall thickness")
+	}
+	if k.Dividers[0] < 0 || k.Dividers[1] < 0 {
+		return nil, errors.New("dividers < 0")
+	}
+
+	size := V2{float64(k.Size[0]), float64(k.Size[1])}.MulScalar(gfPitch).SubScalar(gfClearance)
+	core := size.SubScalar(2 * gfRadius)
+	h := float64(k.Size[2]) * gfHeight
+	floor := gfHeight
+	top := h
+	if k.Lip {
+		top += gfLip
+	}
+	base := gfFoot[len(gfFoot)-1].Y
+
+	// outside of the bin
+	outer := Union3D(
+		gfCells(gfStack(gfCore, gfFoot), V2i{k.Size[0], k.Size[1]}),
+		gfStack(core, []V2{{gfRadius, base}, {gfRadius, top}}),
+	)
+
+	// inside of the bin
+	rc := gfRadius - k.Wall
+	var inner SDF3
+	ceiling := h
+	if k.Lip {
+		// the lip matches the baseplate profile, with a 45 degree slope below it
+		lip := []V2{{0.65, h}, {2.55, h + 1.9}, {2.55, h + 3.7}, {gfRadius - 0.5, h + gfLip}, {gfRadius - 0.5, h + gfLip + 1}}
+		ceiling = h - (rc - 0.65)
+		inner = gfStack(core, append([]V2{{rc, floor}, {rc, ceiling}}, lip...))
+	} else {
+		inner = gfStack(core, []V2{{rc, floor}, {rc, h + 1}})
+	}
+
+	// dividers and label shelf
+	var parts []SDF3
+	inside := size.SubScalar(2 * k.Wall)
+	dh := ceiling - floor
+	for i := 1; i <= k.Dividers[0]; i++ {
+		x := (float64(i)/float64(k.Dividers[0]+1) - 0.5) * inside.X
+		d := Box3D(V3{k.Wall, inside.Y, dh}, 0)
+		parts = append(parts, Transform3D(d, Translate3d(V3{x, 0, floor + 0.5*dh})))
+	}
+	for i := 1; i <= k.Dividers[1]; i++ {
+		y := (float64(i)/float64(k.Dividers[1]+1) - 0.5) * inside.Y
+		d := Box3D(V3{inside.X, k.Wall, dh}, 0)
+		parts = append(parts, Transform3D(d, Translate3d(V3{0, y, floor + 0.5*dh})))
+	}
+	if k.Label {
+		// a 45 degree shelf below the top of the wall
+		p := NewPolygon()
+		p.Add(0, 0)
+		p.Add(0, -gfLabelDepth)
+		p.Add(-gfLabelDepth, 0)
+		shelf := Extrude3D(Polygon2D(p.Vertices()), inside.X)
+		m := Translate3d(V3{0, 0.5 * inside.Y, ceiling}).Mul(RotateY(DtoR(90))).Mul(RotateZ(DtoR(90)))
+		parts = append(parts, Transform3D(shelf, m))
+	}
+
+	s := Difference3D(outer, inner)
+	if len(parts) != 0 {
+		s = Union3D(s, Intersect3D(Union3D(parts...), outer))
+	}
+
+	// holes in the base
+	var holes []SDF3
+	if k.Magnets {
+		holes = append(holes, gfHoles(gfMagnetRadius, gfMagnetDepth))
+	}
+	if k.Screws {
+		holes = append(holes, gfHoles(gfScrewRadius, gfScrewDepth))
+	}
+	if len(holes) != 0 {
+		s = Difference3D(s, gfCells(Union3D(holes...), V2i{k.Size[0], k.Size[1]}))
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// GridfinityBaseplateParms defines the parameters for a gridfinity baseplate.
+type GridfinityBaseplateParms struct {
+	Size    V2i  // size in grid units
+	Magnets bool // add magnet holes (otherwise the pockets are open at the bottom)
+}
+
+// GridfinityBaseplate3D returns a gridfinity baseplate. The bottom of the baseplate is at z = 0.
+func GridfinityBaseplate3D(k *GridfinityBaseplateParms) (SDF3, error) {
+	if k.Size[0] < 1 || k.Size[1] < 1 {
+		return nil, errors.New("baseplate size < 1 unit")
+	}
+
+	size := V2{float64(k.Size[0]), float64(k.Size[1])}.MulScalar(gfPitch)
+	core := size.SubScalar(2 * gfBaseplateRadius)
+	z0 := 0.0
+	if k.Magnets {
+		z0 = gfBaseplateFloor
+	}
+	h := z0 + gfBaseplatePocket
+	plate := gfStack(core, []V2{{gfBaseplateRadius, 0}, {gfBaseplateRadius, h}})
+
+	// the pocket profile, extended beyond the top and bottom for a clean cut
+	cell := V2{gfPitch, gfPitch}.SubScalar(2 * gfBaseplateRadius)
+	r0 := gfBaseplateRadius - 2.85
+	pocket := []V2{{r0, z0}, {r0 + 0.7, z0 + 0.7}, {r0 + 0.7, z0 + 2.5}, {gfBaseplateRadius, h}, {gfBaseplateRadius, h + 1}}
+	if !k.Magnets {
+		pocket = append([]V2{{r0, -1}}, pocket...)
+	}
+	cut := gfStack(cell, pocket)
+	if k.Magnets {
+		cut = Union3D(cut, Transform3D(gfHoles(gfMagnetRadius, gfMagnetDepth), Translate3d(V3{0, 0, z0})))
+	}
+	return Difference3D(plate, gfCells(cut, k.Size)), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,84 @@
+//-----------------------------------------------------------------------------
+/*
+
+Helical Parts: Springs and Augers
+
+These are swept along a helix with Screw3D, so they have the same handedness
+conventions as threads. Set the hand directly rather than mirroring a part,
+mirroring moves any asymmetric features (E.g. end chamfers) as well.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// SpringParms defines the parameters for a helical coil spring.
+type SpringParms struct {
+	WireDiameter float64 // diameter of the spring wire
+	CoilDiameter float64 // mean diameter of the coils
+	Pitch        float64 // coil to coil distance
+	Length       float64 // length of the spring (the ends are ground flat)
+	Hand         Hand    // coil hand
+}
+
+// Spring3D returns a helical coil spring centered on the origin with its axis along the z-axis.
+func Spring3D(k *SpringParms) (SDF3, error) {
+	if k.WireDiameter <= 0 {
+		return nil, errors.New("wire diameter <= 0")
+	}
+	if k.CoilDiameter <= k.WireDiameter {
+		return nil, errors.New("coil diameter <= wire diameter")
+	}
+	if k.Pitch < k.WireDiameter {
+		return nil, errors.New("pitch < wire diameter")
+	}
+	if k.Length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	wire := Transform2D(Circle2D(0.5*k.WireDiameter), Translate2d(V2{0, 0.5 * k.CoilDiameter}))
+	return Screw3D(wire, k.Length, k.Pitch, k.Hand.Sign()), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// AugerParms defines the parameters for an auger (screw conveyor).
+type AugerParms struct {
+	ShaftDiameter   float64 // diameter of the central shaft
+	FlightDiameter  float64 // outside diameter of the flights
+	FlightThickness float64 // thickness of the flights (along the axis)
+	Pitch           float64 // flight to flight distance
+	Starts          int     // number of flights
+	Length          float64 // length of the auger
+	Hand            Hand    // flight hand
+}
+
+// Auger3D returns an auger centered on the origin with its axis along the z-axis.
+func Auger3D(k *AugerParms) (SDF3, error) {
+	if k.ShaftDiameter <= 0 {
+		return nil, errors.New("shaft diameter <= 0")
+	}
+	if k.FlightDiameter <= k.ShaftDiameter {
+		return nil, errors.New("flight diameter <= shaft diameter")
+	}
+	if k.FlightThickness <= 0 || k.FlightThickness >= k.Pitch {
+		return nil, errors.New("flight thickness must be > 0 and < pitch")
+	}
+	if k.Starts < 1 {
+		return nil, errors.New("starts < 1")
+	}
+	if k.Length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	t := 0.5 * k.FlightThickness
+	r := 0.5 * k.FlightDiameter
+	flight := Polygon2D([]V2{{t, 0}, {t, r}, {-t, r}, {-t, 0}})
+	flights := Screw3D(flight, k.Length, k.Pitch, k.Starts*k.Hand.Sign())
+	shaft := Cylinder3D(k.Length, 0.5*k.ShaftDiameter, 0)
+	return Union3D(shaft, flights), nil
+}
+
+//-----------------------------------------------------------------------------
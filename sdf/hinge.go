@@ -0,0 +1,120 @@
+//-----------------------------------------------------------------------------
+/*
+
+Barrel Hinge
+
+Two flat leaves joined by alternating knuckles on a common pin axis.
+
+The hinge lies flat (leaves in the xy plane, pin on the x-axis) which is
+the print orientation. For print in place hinges the pin is part of the
+first leaf and passes through teardrop shaped holes in the knuckles of the
+second leaf. The teardrop keeps the overhang of the hole at 45 degrees, so
+the pin clearance gap prints without support.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// HingeParms defines the parameters for a barrel hinge.
+type HingeParms struct {
+	Length       float64 // length of the hinge (along the pin axis)
+	Width        float64 // width of each leaf (from the pin axis)
+	Thickness    float64 // leaf thickness
+	Knuckles     int     // number of knuckles (>= 2)
+	Radius       float64 // knuckle radius
+	PinRadius    float64 // pin radius
+	Clearance    float64 // clearance between the moving parts
+	PrintInPlace bool    // the pin is part of the first leaf (otherwise there is a hole for a separate pin)
+	Assembled    bool    // return the assembled hinge (otherwise the two leaves)
+}
+
+// alongX returns an SDF3 with its z-axis rotated onto the x-axis and centered at x.
+func alongX(s SDF3, x float64) SDF3 {
+	return Transform3D(s, Translate3d(V3{x, 0, 0}).Mul(RotateY(DtoR(90))))
+}
+
+// teardrop3D returns a teardrop shaped hole along the x-axis with the point at +z.
+func teardrop3D(length, r float64) SDF3 {
+	k := r * math.Sqrt(0.5)
+	tip := Polygon2D([]V2{{-k, k}, {k, k}, {0, r * math.Sqrt2}})
+	s := Extrude3D(Union2D(Circle2D(r), tip), length)
+	// map the 2d profile onto the yz plane
+	return Transform3D(s, RotateY(DtoR(90)).Mul(RotateZ(DtoR(90))))
+}
+
+// Hinge3D returns a barrel hinge. The first leaf is on the -y side of the pin axis.
+// The hinge is returned as a single assembled part, or as two leaves in their assembled positions.
+func Hinge3D(k *HingeParms) ([]SDF3, error) {
+	if k.Knuckles < 2 {
+		return nil, errors.New("knuckles < 2")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+	if k.Thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	if k.PinRadius <= 0 {
+		return nil, errors.New("pin radius <= 0")
+	}
+	if k.Radius < 0.5*k.Thickness || k.Radius <= k.PinRadius+k.Clearance {
+		return nil, errors.New("knuckle radius is too small")
+	}
+	if k.Width <= k.Radius+k.Clearance {
+		return nil, errors.New("leaf width is too small")
+	}
+	n := k.Knuckles
+	kl := (k.Length - float64(n-1)*k.Clearance) / float64(n)
+	if kl <= 0 {
+		return nil, errors.New("hinge length is too small")
+	}
+
+	// knuckles alternate between the leaves
+	var knuckles, keepout [2][]SDF3
+	for i := 0; i < n; i++ {
+		x := -0.5*k.Length + float64(i)*(kl+k.Clearance) + 0.5*kl
+		j := i % 2
+		knuckles[j] = append(knuckles[j], alongX(Cylinder3D(kl, k.Radius, 0), x))
+		c := Cylinder3D(kl+2*k.Clearance, k.Radius+k.Clearance, 0)
+		keepout[1-j] = append(keepout[1-j], alongX(c, x))
+	}
+
+	// leaves
+	leaf := make([]SDF3, 2)
+	for j := range leaf {
+		plate := Box3D(V3{k.Length, k.Width, k.Thickness}, 0)
+		y := 0.5 * k.Width
+		if j == 0 {
+			y = -y
+		}
+		plate = Transform3D(plate, Translate3d(V3{0, y, 0}))
+		plate = Difference3D(plate, Union3D(keepout[j]...))
+		leaf[j] = Union3D(plate, Union3D(knuckles[j]...))
+	}
+
+	// pin
+	if k.PrintInPlace {
+		pin := alongX(Cylinder3D(k.Length, k.PinRadius, 0), 0)
+		leaf[0] = Union3D(leaf[0], pin)
+		leaf[1] = Difference3D(leaf[1], teardrop3D(k.Length+1, k.PinRadius+k.Clearance))
+	} else {
+		hole := alongX(Cylinder3D(k.Length+1, k.PinRadius, 0), 0)
+		leaf[0] = Difference3D(leaf[0], hole)
+		leaf[1] = Difference3D(leaf[1], hole)
+	}
+
+	if k.Assembled {
+		return []SDF3{Union3D(leaf...)}, nil
+	}
+	return leaf, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,66 @@
+//-----------------------------------------------------------------------------
+/*
+
+Inlays
+
+Set a 2D shape (text, a logo) into the top surface of a part for two color
+prints. The part gets a pocket of the shape and there's a matching insert to
+fill it. Save both as objects of a 3MF file and assign them to different
+extruders (or print the insert separately and press it in, with some
+clearance).
+
+The insert can be raised above the surface of the part for a tactile label.
+
+Render the part and the insert with the same mesh cell size, so the pocket
+walls and the insert walls match.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// InlayParms defines the parameters for an inlay.
+type InlayParms struct {
+	Surface   float64 // height (z) of the part surface the inlay is set into
+	Depth     float64 // depth of the pocket
+	Raise     float64 // height of the insert above the surface (0 = flush)
+	Clearance float64 // clearance between the pocket and the insert (0 for multi-material prints)
+}
+
+// Inlay3D returns a part with a pocket for a 2D shape (in the xy plane), and the matching insert.
+func Inlay3D(
+	part SDF3, // part to inlay
+	shape SDF2, // shape of the inlay
+	k *InlayParms,
+) (SDF3, SDF3, error) {
+	if part == nil || shape == nil {
+		return nil, nil, errors.New("nil sdf")
+	}
+	if k.Depth <= 0 {
+		return nil, nil, errors.New("depth <= 0")
+	}
+	if k.Raise < 0 {
+		return nil, nil, errors.New("raise < 0")
+	}
+	if k.Clearance < 0 {
+		return nil, nil, errors.New("clearance < 0")
+	}
+	// the pocket is open above the surface
+	pocketShape := shape
+	if k.Clearance > 0 {
+		pocketShape = Offset2D(shape, k.Clearance)
+	}
+	pocket := Extrude3D(pocketShape, 2*k.Depth)
+	pocket = Transform3D(pocket, Translate3d(V3{0, 0, k.Surface}))
+	// the insert fills the pocket
+	h := k.Depth + k.Raise
+	insert := Extrude3D(shape, h)
+	insert = Transform3D(insert, Translate3d(V3{0, 0, k.Surface - k.Depth + 0.5*h}))
+	return Difference3D(part, pocket), insert, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,155 @@
+//-----------------------------------------------------------------------------
+/*
+
+Maximum Inscribed Circle/Sphere
+
+The largest circle (sphere) within a shape is centered on the minimum of the
+interior distance field and the radius is the distance to the surface.
+
+The distance field is sampled on a grid and the best few samples are refined
+with a pattern search. The distance maximum is often on a ridge of the field
+(it isn't smooth) so a gradient based search isn't used.
+
+Useful for locating bosses, checking tool access and validating the minimum
+feature size of a part.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// inscribedCandidates is the number of grid samples refined with the pattern search.
+const inscribedCandidates = 8
+
+// inscribedSample is a distance sample at a grid point.
+type inscribedSample struct {
+	i int     // grid index
+	d float64 // distance
+}
+
+// bestSamples returns the samples with the lowest distance values.
+func bestSamples(d []float64) []inscribedSample {
+	var best []inscribedSample
+	for i, x := range d {
+		if x >= 0 {
+			continue
+		}
+		if len(best) == inscribedCandidates && x >= best[len(best)-1].d {
+			continue
+		}
+		best = append(best, inscribedSample{i, x})
+		sort.SliceStable(best, func(a, b int) bool { return best[a].d < best[b].d })
+		if len(best) > inscribedCandidates {
+			best = best[:inscribedCandidates]
+		}
+	}
+	return best
+}
+
+//-----------------------------------------------------------------------------
+
+// InscribedCircle2D returns the center and radius of the largest circle within an SDF2.
+func InscribedCircle2D(
+	s SDF2, // sdf2 to search
+	meshCells int, // number of cells on the longest axis. e.g 200
+) (V2, float64, error) {
+	bb := s.BoundingBox()
+	size := bb.Size()
+	step := size.MaxComponent() / float64(meshCells)
+	n := size.DivScalar(step).Ceil().ToV2i().AddScalar(1)
+	// sample the sdf
+	d := make([]float64, n[0]*n[1])
+	for x := 0; x < n[0]; x++ {
+		for y := 0; y < n[1]; y++ {
+			d[x*n[1]+y] = s.Evaluate(bb.Min.Add(V2{float64(x), float64(y)}.MulScalar(step)))
+		}
+	}
+	best := bestSamples(d)
+	if len(best) == 0 {
+		return V2{}, 0, errors.New("no interior found")
+	}
+	// refine the best samples
+	var center V2
+	dmin := 0.0
+	for _, b := range best {
+		p := bb.Min.Add(V2{float64(b.i / n[1]), float64(b.i % n[1])}.MulScalar(step))
+		dp := b.d
+		for h := step; h > tolerance*step; {
+			moved := false
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					q := p.Add(V2{float64(dx), float64(dy)}.MulScalar(h))
+					if dq := s.Evaluate(q); dq < dp {
+						p, dp, moved = q, dq, true
+					}
+				}
+			}
+			if !moved {
+				h *= 0.5
+			}
+		}
+		if dp < dmin {
+			center, dmin = p, dp
+		}
+	}
+	return center, -dmin, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// InscribedSphere3D returns the center and radius of the largest sphere within an SDF3.
+func InscribedSphere3D(
+	s SDF3, // sdf3 to search
+	meshCells int, // number of cells on the longest axis. e.g 200
+) (V3, float64, error) {
+	bb := s.BoundingBox()
+	size := bb.Size()
+	step := size.MaxComponent() / float64(meshCells)
+	n := size.DivScalar(step).Ceil().ToV3i().AddScalar(1)
+	point := func(x, y, z int) V3 { return bb.Min.Add(V3i{x, y, z}.ToV3().MulScalar(step)) }
+	// sample the sdf
+	d := make([]float64, n[0]*n[1]*n[2])
+	sampleGrid(s, bb.Min, V3{step, step, step}, n, func(i int, p V3, dp float64) {
+		d[i] = dp
+	})
+	best := bestSamples(d)
+	if len(best) == 0 {
+		return V3{}, 0, errors.New("no interior found")
+	}
+	// refine the best samples
+	var center V3
+	dmin := 0.0
+	for _, b := range best {
+		p := point(b.i/(n[1]*n[2]), (b.i/n[2])%n[1], b.i%n[2])
+		dp := b.d
+		for h := step; h > tolerance*step; {
+			moved := false
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					for dz := -1; dz <= 1; dz++ {
+						q := p.Add(V3i{dx, dy, dz}.ToV3().MulScalar(h))
+						if dq := s.Evaluate(q); dq < dp {
+							p, dp, moved = q, dq, true
+						}
+					}
+				}
+			}
+			if !moved {
+				h *= 0.5
+			}
+		}
+		if dp < dmin {
+			center, dmin = p, dp
+		}
+	}
+	return center, -dmin, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,215 @@
+//-----------------------------------------------------------------------------
+/*
+
+Lathe Toolpaths
+
+Roughing and finishing toolpaths for turning a solid of revolution on a
+2-axis (X/Z) hobby CNC lathe. The toolpaths come from the 2D profile that
+is revolved to make the part (x = radius, y = z-axis).
+
+This is external turning only. The profile should be a radius as a function
+of z (no bores or undercuts), and the tool is a right hand turning tool with
+a sharp point, moving from +z towards the chuck at -z.
+
+Roughing: passes parallel to the z-axis, stepping down from the stock radius
+by the depth of cut. Each pass cuts where the tool is clear of the part plus
+the finishing allowance.
+
+Finishing: a single pass following the profile contour from the end face
+down to the bottom of the profile.
+
+The G-code uses diameter mode (G7), so X values are diameters. The Z values
+are the profile y values, so set the work offset to match the profile origin.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+//-----------------------------------------------------------------------------
+
+// LatheParms defines the parameters for lathe toolpaths.
+type LatheParms struct {
+	StockRadius float64 // radius of the stock
+	DepthOfCut  float64 // radial depth of each roughing pass
+	Allowance   float64 // radial material left by the roughing passes for the finishing pass
+	Step        float64 // sampling step along the z-axis
+	Clearance   float64 // clearance from the stock for rapid moves
+	RoughFeed   float64 // roughing feed rate (mm/min)
+	FinishFeed  float64 // finishing feed rate (mm/min)
+	Speed       float64 // spindle speed (rpm)
+}
+
+// LatheMove is a move of the lathe tool.
+type LatheMove struct {
+	P    V2      // end point of the move (x = radius, y = z)
+	Feed float64 // feed rate (0 = rapid move)
+}
+
+//-----------------------------------------------------------------------------
+
+// LatheProfile returns the 2D profile of an SDF3 that is a full solid of revolution.
+func LatheProfile(s SDF3) (SDF2, error) {
+	sor, ok := s.(*SorSDF3)
+	if !ok || sor.theta != 0 {
+		return nil, errors.New("not a solid of revolution")
+	}
+	return sor.sdf, nil
+}
+
+// latheRadius returns the outer radius of a profile at z (0 if there is no part).
+func latheRadius(profile SDF2, z, rmax float64) float64 {
+	if profile.Evaluate(V2{rmax, z}) <= 0 {
+		return rmax
+	}
+	// the outermost part point is below the first sampled point inside the part
+	const n = 64
+	lo, hi := -1.0, rmax
+	for i := n - 1; i >= 0; i-- {
+		x := rmax * float64(i) / n
+		if profile.Evaluate(V2{x, z}) <= 0 {
+			lo = x
+			break
+		}
+		hi = x
+	}
+	if lo < 0 {
+		return 0
+	}
+	// bisect for the surface
+	for i := 0; i < 32; i++ {
+		mid := 0.5 * (lo + hi)
+		if profile.Evaluate(V2{mid, z}) <= 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// LatheToolpath returns the roughing and finishing toolpath for turning a profile.
+func LatheToolpath(profile SDF2, k *LatheParms) ([]LatheMove, error) {
+	if profile == nil {
+		return nil, errors.New("nil profile")
+	}
+	if k.StockRadius <= 0 {
+		return nil, errors.New("stock radius <= 0")
+	}
+	if k.DepthOfCut <= 0 {
+		return nil, errors.New("depth of cut <= 0")
+	}
+	if k.Allowance < 0 {
+		return nil, errors.New("allowance < 0")
+	}
+	if k.Step <= 0 {
+		return nil, errors.New("step <= 0")
+	}
+	if k.Clearance <= 0 {
+		return nil, errors.New("clearance <= 0")
+	}
+	if k.RoughFeed <= 0 || k.FinishFeed <= 0 {
+		return nil, errors.New("feed rate <= 0")
+	}
+	bb := profile.BoundingBox()
+	if bb.Max.X > k.StockRadius {
+		return nil, errors.New("profile is larger than the stock")
+	}
+	safe := k.StockRadius + k.Clearance
+	// z samples from beyond the end face down to the bottom of the profile
+	z0 := bb.Max.Y + k.Clearance
+	n := int(math.Ceil((z0 - bb.Min.Y) / k.Step))
+	z := make([]float64, n+1)
+	r := make([]float64, n+1)
+	for i := range z {
+		z[i] = z0 - (z0-bb.Min.Y)*float64(i)/float64(n)
+		r[i] = latheRadius(profile, z[i], k.StockRadius)
+	}
+
+	var moves []LatheMove
+	rapid := func(x, z float64) { moves = append(moves, LatheMove{P: V2{x, z}}) }
+	feed := func(x, z, f float64) { moves = append(moves, LatheMove{P: V2{x, z}, Feed: f}) }
+	rapid(safe, z0)
+
+	// roughing passes
+	for x := k.StockRadius - k.DepthOfCut; x > 0; x -= k.DepthOfCut {
+		i := 0
+		for i <= n {
+			// find a run of samples clear of the part plus the allowance
+			if r[i]+k.Allowance >= x {
+				i++
+				continue
+			}
+			j := i
+			for j < n && r[j+1]+k.Allowance < x {
+				j++
+			}
+			rapid(safe, z[i])
+			feed(x, z[i], k.RoughFeed)
+			feed(x, z[j], k.RoughFeed)
+			feed(safe, z[j], k.RoughFeed)
+			i = j + 1
+		}
+	}
+	rapid(safe, z0)
+
+	// finishing pass: face the end, then follow the contour
+	contour := []V2{{0, z0}, {0, bb.Max.Y}}
+	for i := range z {
+		if z[i] <= bb.Max.Y {
+			contour = append(contour, V2{r[i], z[i]})
+		}
+	}
+	contour = Simplify(contour, 0.1*k.Step, false)
+	rapid(contour[0].X, contour[0].Y)
+	for _, p := range contour[1:] {
+		feed(p.X, p.Y, k.FinishFeed)
+	}
+	last := contour[len(contour)-1]
+	feed(safe, last.Y, k.FinishFeed)
+	rapid(safe, z0)
+	return moves, nil
+}
+
+// SaveLatheGCode writes a lathe toolpath as G-code.
+func SaveLatheGCode(path string, toolpath []LatheMove, k *LatheParms) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf := bufio.NewWriter(f)
+	fmt.Fprintf(buf, "; lathe toolpath, stock diameter %.3f\n", 2*k.StockRadius)
+	fmt.Fprintf(buf, "G21 ; millimeters\n")
+	fmt.Fprintf(buf, "G18 ; xz plane\n")
+	fmt.Fprintf(buf, "G7 ; diameter mode\n")
+	fmt.Fprintf(buf, "G90 ; absolute positioning\n")
+	fmt.Fprintf(buf, "G94 ; feed per minute\n")
+	fmt.Fprintf(buf, "M3 S%.0f\n", k.Speed)
+	feed := 0.0
+	for _, m := range toolpath {
+		if m.Feed == 0 {
+			fmt.Fprintf(buf, "G0 X%.3f Z%.3f\n", 2*m.P.X, m.P.Y)
+			continue
+		}
+		if m.Feed != feed {
+			feed = m.Feed
+			fmt.Fprintf(buf, "G1 X%.3f Z%.3f F%.0f\n", 2*m.P.X, m.P.Y, feed)
+		} else {
+			fmt.Fprintf(buf, "G1 X%.3f Z%.3f\n", 2*m.P.X, m.P.Y)
+		}
+	}
+	fmt.Fprintf(buf, "M5\n")
+	fmt.Fprintf(buf, "M2\n")
+	return buf.Flush()
+}
+
+//-----------------------------------------------------------------------------
@@ -43,7 +43,7 @@ func (l *lineCache) evaluate(sdf SDF2, x int) {
 	// setup the loop variables
 	idx := 0
 	var p V2
-	p.X = l.base.X + float64(x)*dx
+	p.X = l.base.X + float64(float64(x)*dx)
 
 	// evaluate the line
 	p.Y = l.base.Y
@@ -162,9 +162,10 @@ func msInterpolate(p1, p2 V2, v1, v2, x float64) V2 {
 		return p1
 	}
 	t := (x - v1) / (v2 - v1)
+	// float64() stops FMA fusion (see vecf.go)
 	return V2{
-		p1.X + t*(p2.X-p1.X),
-		p1.Y + t*(p2.Y-p1.Y),
+		p1.X + float64(t*(p2.X-p1.X)),
+		p1.Y + float64(t*(p2.Y-p1.Y)),
 	}
 }
 
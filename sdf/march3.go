@@ -17,7 +17,14 @@ import (
 
 //-----------------------------------------------------------------------------
 
+// mcLayer caches the SDF values for a pair of adjacent YZ layers of the sampling grid.
+type mcLayer interface {
+	Evaluate(x int)          // evaluate layer x as the x + dx layer (the old one becomes the x layer)
+	Get(x, y, z int) float64 // value at a grid point of the x (x = 0) or x + dx (x = 1) layer
+}
+
 type layerYZ struct {
+	sdf   SDF3      // sdf being sampled
 	base  V3        // base coordinate of layer
 	inc   V3        // dx, dy, dz for each step
 	steps V3i       // number of x,y,z steps
@@ -25,8 +32,8 @@ type layerYZ struct {
 	val1  []float64 // SDF values for x + dx layer
 }
 
-func newLayerYZ(base, inc V3, steps V3i) *layerYZ {
-	return &layerYZ{base, inc, steps, nil, nil}
+func newLayerYZ(sdf SDF3, base, inc V3, steps V3i) *layerYZ {
+	return &layerYZ{sdf, base, inc, steps, nil, nil}
 }
 
 // evalReq is used for processing evaluations in parallel.
@@ -58,7 +65,7 @@ func init() {
 }
 
 // Evaluate the SDF for a given XY layer
-func (l *layerYZ) Evaluate(sdf SDF3, x int) {
+func (l *layerYZ) Evaluate(x int) {
 
 	// Swap the layers
 	l.val0, l.val1 = l.val1, l.val0
@@ -74,12 +81,12 @@ func (l *layerYZ) Evaluate(sdf SDF3, x int) {
 	// setup the loop variables
 	idx := 0
 	var p V3
-	p.X = l.base.X + float64(x)*dx
+	p.X = l.base.X + float64(float64(x)*dx)
 
 	// define the base struct for requesting evaluation
 	eReq := evalReq{
 		wg:  new(sync.WaitGroup),
-		fn:  sdf.Evaluate,
+		fn:  l.sdf.Evaluate,
 		out: l.val1,
 	}
 
@@ -127,17 +134,28 @@ func (l *layerYZ) Get(x, y, z int) float64 {
 //-----------------------------------------------------------------------------
 
 func marchingCubes(sdf SDF3, box Box3, step float64) []*Triangle3 {
+	base, inc, steps := mcGrid(box, step)
+	if s, ok := sdf.(*Float32SDF3); ok {
+		// float32 layers
+		return mcLayers(&layerYZ32{sdf: s.sf, base: base, inc: inc, steps: steps}, base, inc, steps)
+	}
+	return mcLayers(newLayerYZ(sdf, base, inc, steps), base, inc, steps)
+}
 
-	var triangles []*Triangle3
+// mcGrid returns the base, increment and number of steps of the sampling grid for a box.
+func mcGrid(box Box3, step float64) (V3, V3, V3i) {
 	size := box.Size()
-	base := box.Min
 	steps := size.DivScalar(step).Ceil().ToV3i()
-	inc := size.Div(steps.ToV3())
+	return box.Min, size.Div(steps.ToV3()), steps
+}
+
+// mcLayers runs marching cubes over the layers of a sampling grid.
+func mcLayers(l mcLayer, base, inc V3, steps V3i) []*Triangle3 {
+
+	var triangles []*Triangle3
 
-	// create the SDF layer cache
-	l := newLayerYZ(base, inc, steps)
 	// evaluate the SDF for x = 0
-	l.Evaluate(sdf, 0)
+	l.Evaluate(0)
 
 	nx, ny, nz := steps[0], steps[1], steps[2]
 	dx, dy, dz := inc.X, inc.Y, inc.Z
@@ -146,7 +164,7 @@ func marchingCubes(sdf SDF3, box Box3, step float64) []*Triangle3 {
 	p.X = base.X
 	for x := 0; x < nx; x++ {
 		// read the x + 1 layer
-		l.Evaluate(sdf, x+1)
+		l.Evaluate(x + 1)
 		// process all cubes in the x and x + 1 layers
 		p.Y = base.Y
 		for y := 0; y < ny; y++ {
@@ -234,10 +252,11 @@ func mcInterpolate(p1, p2 V3, v1, v2, x float64) V3 {
 		return p1
 	}
 	t := (x - v1) / (v2 - v1)
+	// float64() stops FMA fusion (see vecf.go)
 	return V3{
-		p1.X + t*(p2.X-p1.X),
-		p1.Y + t*(p2.Y-p1.Y),
-		p1.Z + t*(p2.Z-p1.Z),
+		p1.X + float64(t*(p2.X-p1.X)),
+		p1.Y + float64(t*(p2.Y-p1.Y)),
+		p1.Z + float64(t*(p2.Z-p1.Z)),
 	}
 }
 
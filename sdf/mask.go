@@ -0,0 +1,205 @@
+//-----------------------------------------------------------------------------
+/*
+
+Masked Operators
+
+Apply an operator (offset, displacement, texture, smoothing) to part of an
+SDF. The operator is applied where the mask SDF is negative and the result
+blends back to the original SDF across the mask boundary. E.g. knurl only the
+grip area of a handle:
+
+s, _ := Mask3D(handle, knurl, grip, 2)
+
+The blend is a smoothstep of the mask distance over a band of the blend width
+centered on the mask boundary. The distance in the band is a mix of two
+distances, so it's an approximation.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// maskBlend returns the weight of the operator (0..1) at a mask distance.
+func maskBlend(m, blend float64) float64 {
+	if blend <= 0 {
+		if m < 0 {
+			return 1
+		}
+		return 0
+	}
+	x := Clamp(0.5-m/blend, 0, 1)
+	return x * x * (3 - 2*x)
+}
+
+//-----------------------------------------------------------------------------
+
+// MaskSDF3 is an SDF3 with an operator applied within a mask.
+type MaskSDF3 struct {
+	sdf   SDF3    // original SDF
+	op    SDF3    // SDF with the operator applied
+	mask  SDF3    // the operator is applied where the mask is negative
+	blend float64 // width of the blend across the mask boundary
+	bb    Box3    // bounding box
+}
+
+// Mask3D returns an SDF3 with an operator applied only where the mask is negative.
+func Mask3D(
+	sdf SDF3, // original SDF
+	op func(SDF3) SDF3, // operator
+	mask SDF3, // mask SDF
+	blend float64, // width of the blend across the mask boundary (0 = no blend)
+) (SDF3, error) {
+	if sdf == nil || mask == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if op == nil {
+		return nil, errors.New("nil operator")
+	}
+	if blend < 0 {
+		return nil, errors.New("blend < 0")
+	}
+	s := MaskSDF3{
+		sdf:   sdf,
+		op:    op(sdf),
+		mask:  mask,
+		blend: blend,
+	}
+	if s.op == nil {
+		return nil, errors.New("the operator returned a nil sdf")
+	}
+	s.bb = sdf.BoundingBox().Extend(s.op.BoundingBox())
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a masked SDF3.
+func (s *MaskSDF3) Evaluate(p V3) float64 {
+	f := maskBlend(s.mask.Evaluate(p), s.blend)
+	if f == 0 {
+		return s.sdf.Evaluate(p)
+	}
+	if f == 1 {
+		return s.op.Evaluate(p)
+	}
+	return Mix(s.sdf.Evaluate(p), s.op.Evaluate(p), f)
+}
+
+// BoundingBox returns the bounding box of a masked SDF3.
+func (s *MaskSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a masked SDF3.
+func (s *MaskSDF3) Children() []interface{} { return []interface{}{s.sdf, s.op, s.mask} }
+
+//-----------------------------------------------------------------------------
+
+// MaskSDF2 is an SDF2 with an operator applied within a mask.
+type MaskSDF2 struct {
+	sdf   SDF2    // original SDF
+	op    SDF2    // SDF with the operator applied
+	mask  SDF2    // the operator is applied where the mask is negative
+	blend float64 // width of the blend across the mask boundary
+	bb    Box2    // bounding box
+}
+
+// Mask2D returns an SDF2 with an operator applied only where the mask is negative.
+func Mask2D(
+	sdf SDF2, // original SDF
+	op func(SDF2) SDF2, // operator
+	mask SDF2, // mask SDF
+	blend float64, // width of the blend across the mask boundary (0 = no blend)
+) (SDF2, error) {
+	if sdf == nil || mask == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if op == nil {
+		return nil, errors.New("nil operator")
+	}
+	if blend < 0 {
+		return nil, errors.New("blend < 0")
+	}
+	s := MaskSDF2{
+		sdf:   sdf,
+		op:    op(sdf),
+		mask:  mask,
+		blend: blend,
+	}
+	if s.op == nil {
+		return nil, errors.New("the operator returned a nil sdf")
+	}
+	s.bb = sdf.BoundingBox().Extend(s.op.BoundingBox())
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a masked SDF2.
+func (s *MaskSDF2) Evaluate(p V2) float64 {
+	f := maskBlend(s.mask.Evaluate(p), s.blend)
+	if f == 0 {
+		return s.sdf.Evaluate(p)
+	}
+	if f == 1 {
+		return s.op.Evaluate(p)
+	}
+	return Mix(s.sdf.Evaluate(p), s.op.Evaluate(p), f)
+}
+
+// BoundingBox returns the bounding box of a masked SDF2.
+func (s *MaskSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+// Children returns the child nodes of a masked SDF2.
+func (s *MaskSDF2) Children() []interface{} { return []interface{}{s.sdf, s.op, s.mask} }
+
+//-----------------------------------------------------------------------------
+
+// DisplaceSDF3 is an SDF3 with its surface displaced by a function.
+type DisplaceSDF3 struct {
+	sdf SDF3               // original SDF
+	f   func(p V3) float64 // displacement
+	bb  Box3               // bounding box
+}
+
+// Displace3D returns an SDF3 with its surface displaced outwards by f(p) (E.g. a knurl or texture).
+// The displacement must be within +/- max and should change slowly relative to the distance (the
+// gradient of f less than 1) for a good approximation of the distance.
+func Displace3D(
+	sdf SDF3, // original SDF
+	f func(p V3) float64, // displacement
+	max float64, // maximum magnitude of the displacement
+) (SDF3, error) {
+	if sdf == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if f == nil {
+		return nil, errors.New("nil displacement function")
+	}
+	if max < 0 {
+		return nil, errors.New("max < 0")
+	}
+	bb := sdf.BoundingBox()
+	return &DisplaceSDF3{
+		sdf: sdf,
+		f:   f,
+		bb:  Box3{bb.Min.SubScalar(max), bb.Max.AddScalar(max)},
+	}, nil
+}
+
+// Evaluate returns the minimum distance to a displaced SDF3.
+func (s *DisplaceSDF3) Evaluate(p V3) float64 {
+	return s.sdf.Evaluate(p) - s.f(p)
+}
+
+// BoundingBox returns the bounding box of a displaced SDF3.
+func (s *DisplaceSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a displaced SDF3.
+func (s *DisplaceSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,162 @@
+//-----------------------------------------------------------------------------
+/*
+
+Materials and Mass Reporting
+
+A small library of material densities and costs. Combined with the volume of
+a part it gives the part mass, the filament length for 3d printing and a cost
+estimate.
+
+Units are mm for lengths, g/cm^3 for density and cost per kg.
+The volume is for a solid part, so the estimates are an upper bound for
+prints with infill.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+// Volume
+
+// MeshVolume returns the volume enclosed by a closed triangle mesh.
+func MeshVolume(mesh []*Triangle3) float64 {
+	v := 0.0
+	for _, t := range mesh {
+		// signed volume of the tetrahedron with the origin
+		v += t.V[0].Dot(t.V[1].Cross(t.V[2]))
+	}
+	return Abs(v) / 6.0
+}
+
+// Volume3D returns the volume of an SDF3.
+func Volume3D(
+	s SDF3, // sdf3 to measure
+	meshCells int, // number of cells on the longest axis. e.g 200
+) float64 {
+	return MeshVolume(RenderMesh(s, meshCells))
+}
+
+//-----------------------------------------------------------------------------
+// Material Database
+
+// Material stores the properties of a material.
+type Material struct {
+	Name     string  // name of material
+	Density  float64 // density (g/cm^3)
+	Cost     float64 // typical cost per kg
+	Filament float64 // filament diameter (mm), 0 for materials that aren't filament
+}
+
+type materialDatabase map[string]*Material
+
+var materialDB = initMaterials()
+
+// add adds a material to the material database.
+func (m materialDatabase) add(name string, density, cost, filament float64) {
+	m[name] = &Material{
+		Name:     name,
+		Density:  density,
+		Cost:     cost,
+		Filament: filament,
+	}
+}
+
+// initMaterials adds a collection of common materials to the material database.
+func initMaterials() materialDatabase {
+	m := make(materialDatabase)
+	// filaments
+	m.add("PLA", 1.24, 20, 1.75)
+	m.add("PETG", 1.27, 22, 1.75)
+	m.add("ABS", 1.04, 20, 1.75)
+	m.add("ASA", 1.07, 25, 1.75)
+	m.add("TPU", 1.21, 30, 1.75)
+	m.add("Nylon", 1.14, 40, 1.75)
+	// resin
+	m.add("Resin", 1.15, 35, 0)
+	// metals
+	m.add("Aluminum", 2.70, 4, 0)
+	m.add("Steel", 7.85, 1.5, 0)
+	m.add("Stainless", 8.00, 5, 0)
+	m.add("Brass", 8.50, 8, 0)
+	return m
+}
+
+// MaterialLookup returns a copy of the properties for a material by name.
+func MaterialLookup(name string) (*Material, error) {
+	if m, ok := materialDB[name]; ok {
+		x := *m
+		return &x, nil
+	}
+	return nil, fmt.Errorf("material \"%s\" not found", name)
+}
+
+// Materials returns the names of the materials in the material database.
+func Materials() []string {
+	var names []string
+	for k := range materialDB {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//-----------------------------------------------------------------------------
+// Mass Report
+
+// MassReport stores the mass and cost estimates for a part.
+type MassReport struct {
+	Material       *Material // part material
+	Volume         float64   // part volume (mm^3)
+	Mass           float64   // part mass (g)
+	FilamentLength float64   // filament length (m), 0 for materials that aren't filament
+	Cost           float64   // material cost
+}
+
+// NewMassReport returns the mass and cost estimates for a volume (mm^3) of a material.
+func NewMassReport(volume float64, m *Material) *MassReport {
+	r := MassReport{
+		Material: m,
+		Volume:   volume,
+		Mass:     volume * 1e-3 * m.Density,
+	}
+	if m.Filament > 0 {
+		a := Pi * 0.25 * m.Filament * m.Filament
+		r.FilamentLength = volume / a * 1e-3
+	}
+	r.Cost = r.Mass * 1e-3 * m.Cost
+	return &r
+}
+
+// MassReport3D returns the mass and cost estimates for an SDF3 made from a material.
+func MassReport3D(s SDF3, meshCells int, m *Material) *MassReport {
+	return NewMassReport(Volume3D(s, meshCells), m)
+}
+
+// MassReportSTL returns the mass and cost estimates for a rendered STL file made from a material.
+func MassReportSTL(path string, m *Material) (*MassReport, error) {
+	mesh, err := LoadSTL(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMassReport(MeshVolume(mesh), m), nil
+}
+
+// Report writes the mass and cost estimates.
+func (r *MassReport) Report(w io.Writer) {
+	fmt.Fprintf(w, "material %s (%.2f g/cm^3)\n", r.Material.Name, r.Material.Density)
+	fmt.Fprintf(w, "volume %.2f cm^3\n", r.Volume*1e-3)
+	fmt.Fprintf(w, "mass %.2f g\n", r.Mass)
+	if r.FilamentLength > 0 {
+		fmt.Fprintf(w, "filament %.2f m (%.2f mm)\n", r.FilamentLength, r.Material.Filament)
+	}
+	fmt.Fprintf(w, "cost %.2f\n", r.Cost)
+}
+
+//-----------------------------------------------------------------------------
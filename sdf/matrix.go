@@ -151,9 +151,9 @@ func Rotate3d(v V3, a float64) M44 {
 	c := math.Cos(a)
 	m := 1 - c
 	return M44{
-		m*v.X*v.X + c, m*v.X*v.Y - v.Z*s, m*v.Z*v.X + v.Y*s, 0,
-		m*v.X*v.Y + v.Z*s, m*v.Y*v.Y + c, m*v.Y*v.Z - v.X*s, 0,
-		m*v.Z*v.X - v.Y*s, m*v.Y*v.Z + v.X*s, m*v.Z*v.Z + c, 0,
+		float64(m*v.X*v.X) + c, float64(m*v.X*v.Y) - float64(v.Z*s), float64(m*v.Z*v.X) + float64(v.Y*s), 0,
+		float64(m*v.X*v.Y) + float64(v.Z*s), float64(m*v.Y*v.Y) + c, float64(m*v.Y*v.Z) - float64(v.X*s), 0,
+		float64(m*v.Z*v.X) - float64(v.Y*s), float64(m*v.Y*v.Z) + float64(v.X*s), float64(m*v.Z*v.Z) + c, 0,
 		0, 0, 0, 1}
 }
 
@@ -199,6 +199,17 @@ func MirrorYZ() M44 {
 		0, 0, 0, 1}
 }
 
+// MirrorPlane returns a 4x4 matrix with mirroring across a plane through a point.
+func MirrorPlane(p, n V3) M44 {
+	n = n.Normalize()
+	m := M44{
+		1 - float64(2*n.X*n.X), -2 * n.X * n.Y, -2 * n.X * n.Z, 0,
+		-2 * n.Y * n.X, 1 - float64(2*n.Y*n.Y), -2 * n.Y * n.Z, 0,
+		-2 * n.Z * n.X, -2 * n.Z * n.Y, 1 - float64(2*n.Z*n.Z), 0,
+		0, 0, 0, 1}
+	return Translate3d(p).Mul(m).Mul(Translate3d(p.Neg()))
+}
+
 // MirrorX returns a 3x3 matrix with mirroring across the X axis.
 func MirrorX() M33 {
 	return M33{
@@ -280,23 +291,25 @@ func (a M22) Equals(b M22, tolerance float64) bool {
 
 //-----------------------------------------------------------------------------
 
+// The float64() conversions stop FMA fusion (see vecf.go).
+
 // MulPosition multiplies a V3 position with a rotate/translate matrix.
 func (a M44) MulPosition(b V3) V3 {
-	return V3{a.x00*b.X + a.x01*b.Y + a.x02*b.Z + a.x03,
-		a.x10*b.X + a.x11*b.Y + a.x12*b.Z + a.x13,
-		a.x20*b.X + a.x21*b.Y + a.x22*b.Z + a.x23}
+	return V3{float64(a.x00*b.X) + float64(a.x01*b.Y) + float64(a.x02*b.Z) + a.x03,
+		float64(a.x10*b.X) + float64(a.x11*b.Y) + float64(a.x12*b.Z) + a.x13,
+		float64(a.x20*b.X) + float64(a.x21*b.Y) + float64(a.x22*b.Z) + a.x23}
 }
 
 // MulPosition multiplies a V2 position with a rotate/translate matrix.
 func (a M33) MulPosition(b V2) V2 {
-	return V2{a.x00*b.X + a.x01*b.Y + a.x02,
-		a.x10*b.X + a.x11*b.Y + a.x12}
+	return V2{float64(a.x00*b.X) + float64(a.x01*b.Y) + a.x02,
+		float64(a.x10*b.X) + float64(a.x11*b.Y) + a.x12}
 }
 
 // MulPosition multiplies a V2 position with a rotate matrix.
 func (a M22) MulPosition(b V2) V2 {
-	return V2{a.x00*b.X + a.x01*b.Y,
-		a.x10*b.X + a.x11*b.Y}
+	return V2{float64(a.x00*b.X) + float64(a.x01*b.Y),
+		float64(a.x10*b.X) + float64(a.x11*b.Y)}
 }
 
 //-----------------------------------------------------------------------------
@@ -320,47 +333,47 @@ func (v V3Set) MulVertices(a M44) {
 // Mul multiplies 4x4 matrices.
 func (a M44) Mul(b M44) M44 {
 	m := M44{}
-	m.x00 = a.x00*b.x00 + a.x01*b.x10 + a.x02*b.x20 + a.x03*b.x30
-	m.x10 = a.x10*b.x00 + a.x11*b.x10 + a.x12*b.x20 + a.x13*b.x30
-	m.x20 = a.x20*b.x00 + a.x21*b.x10 + a.x22*b.x20 + a.x23*b.x30
-	m.x30 = a.x30*b.x00 + a.x31*b.x10 + a.x32*b.x20 + a.x33*b.x30
-	m.x01 = a.x00*b.x01 + a.x01*b.x11 + a.x02*b.x21 + a.x03*b.x31
-	m.x11 = a.x10*b.x01 + a.x11*b.x11 + a.x12*b.x21 + a.x13*b.x31
-	m.x21 = a.x20*b.x01 + a.x21*b.x11 + a.x22*b.x21 + a.x23*b.x31
-	m.x31 = a.x30*b.x01 + a.x31*b.x11 + a.x32*b.x21 + a.x33*b.x31
-	m.x02 = a.x00*b.x02 + a.x01*b.x12 + a.x02*b.x22 + a.x03*b.x32
-	m.x12 = a.x10*b.x02 + a.x11*b.x12 + a.x12*b.x22 + a.x13*b.x32
-	m.x22 = a.x20*b.x02 + a.x21*b.x12 + a.x22*b.x22 + a.x23*b.x32
-	m.x32 = a.x30*b.x02 + a.x31*b.x12 + a.x32*b.x22 + a.x33*b.x32
-	m.x03 = a.x00*b.x03 + a.x01*b.x13 + a.x02*b.x23 + a.x03*b.x33
-	m.x13 = a.x10*b.x03 + a.x11*b.x13 + a.x12*b.x23 + a.x13*b.x33
-	m.x23 = a.x20*b.x03 + a.x21*b.x13 + a.x22*b.x23 + a.x23*b.x33
-	m.x33 = a.x30*b.x03 + a.x31*b.x13 + a.x32*b.x23 + a.x33*b.x33
+	m.x00 = float64(a.x00*b.x00) + float64(a.x01*b.x10) + float64(a.x02*b.x20) + float64(a.x03*b.x30)
+	m.x10 = float64(a.x10*b.x00) + float64(a.x11*b.x10) + float64(a.x12*b.x20) + float64(a.x13*b.x30)
+	m.x20 = float64(a.x20*b.x00) + float64(a.x21*b.x10) + float64(a.x22*b.x20) + float64(a.x23*b.x30)
+	m.x30 = float64(a.x30*b.x00) + float64(a.x31*b.x10) + float64(a.x32*b.x20) + float64(a.x33*b.x30)
+	m.x01 = float64(a.x00*b.x01) + float64(a.x01*b.x11) + float64(a.x02*b.x21) + float64(a.x03*b.x31)
+	m.x11 = float64(a.x10*b.x01) + float64(a.x11*b.x11) + float64(a.x12*b.x21) + float64(a.x13*b.x31)
+	m.x21 = float64(a.x20*b.x01) + float64(a.x21*b.x11) + float64(a.x22*b.x21) + float64(a.x23*b.x31)
+	m.x31 = float64(a.x30*b.x01) + float64(a.x31*b.x11) + float64(a.x32*b.x21) + float64(a.x33*b.x31)
+	m.x02 = float64(a.x00*b.x02) + float64(a.x01*b.x12) + float64(a.x02*b.x22) + float64(a.x03*b.x32)
+	m.x12 = float64(a.x10*b.x02) + float64(a.x11*b.x12) + float64(a.x12*b.x22) + float64(a.x13*b.x32)
+	m.x22 = float64(a.x20*b.x02) + float64(a.x21*b.x12) + float64(a.x22*b.x22) + float64(a.x23*b.x32)
+	m.x32 = float64(a.x30*b.x02) + float64(a.x31*b.x12) + float64(a.x32*b.x22) + float64(a.x33*b.x32)
+	m.x03 = float64(a.x00*b.x03) + float64(a.x01*b.x13) + float64(a.x02*b.x23) + float64(a.x03*b.x33)
+	m.x13 = float64(a.x10*b.x03) + float64(a.x11*b.x13) + float64(a.x12*b.x23) + float64(a.x13*b.x33)
+	m.x23 = float64(a.x20*b.x03) + float64(a.x21*b.x13) + float64(a.x22*b.x23) + float64(a.x23*b.x33)
+	m.x33 = float64(a.x30*b.x03) + float64(a.x31*b.x13) + float64(a.x32*b.x23) + float64(a.x33*b.x33)
 	return m
 }
 
 // Mul multiplies 3x3 matrices.
 func (a M33) Mul(b M33) M33 {
 	m := M33{}
-	m.x00 = a.x00*b.x00 + a.x01*b.x10 + a.x02*b.x20
-	m.x10 = a.x10*b.x00 + a.x11*b.x10 + a.x12*b.x20
-	m.x20 = a.x20*b.x00 + a.x21*b.x10 + a.x22*b.x20
-	m.x01 = a.x00*b.x01 + a.x01*b.x11 + a.x02*b.x21
-	m.x11 = a.x10*b.x01 + a.x11*b.x11 + a.x12*b.x21
-	m.x21 = a.x20*b.x01 + a.x21*b.x11 + a.x22*b.x21
-	m.x02 = a.x00*b.x02 + a.x01*b.x12 + a.x02*b.x22
-	m.x12 = a.x10*b.x02 + a.x11*b.x12 + a.x12*b.x22
-	m.x22 = a.x20*b.x02 + a.x21*b.x12 + a.x22*b.x22
+	m.x00 = float64(a.x00*b.x00) + float64(a.x01*b.x10) + float64(a.x02*b.x20)
+	m.x10 = float64(a.x10*b.x00) + float64(a.x11*b.x10) + float64(a.x12*b.x20)
+	m.x20 = float64(a.x20*b.x00) + float64(a.x21*b.x10) + float64(a.x22*b.x20)
+	m.x01 = float64(a.x00*b.x01) + float64(a.x01*b.x11) + float64(a.x02*b.x21)
+	m.x11 = float64(a.x10*b.x01) + float64(a.x11*b.x11) + float64(a.x12*b.x21)
+	m.x21 = float64(a.x20*b.x01) + float64(a.x21*b.x11) + float64(a.x22*b.x21)
+	m.x02 = float64(a.x00*b.x02) + float64(a.x01*b.x12) + float64(a.x02*b.x22)
+	m.x12 = float64(a.x10*b.x02) + float64(a.x11*b.x12) + float64(a.x12*b.x22)
+	m.x22 = float64(a.x20*b.x02) + float64(a.x21*b.x12) + float64(a.x22*b.x22)
 	return m
 }
 
 // Mul multiplies 2x2 matrices.
 func (a M22) Mul(b M22) M22 {
 	m := M22{}
-	m.x00 = a.x00*b.x00 + a.x01*b.x10
-	m.x01 = a.x00*b.x01 + a.x01*b.x11
-	m.x10 = a.x10*b.x00 + a.x11*b.x10
-	m.x11 = a.x10*b.x01 + a.x11*b.x11
+	m.x00 = float64(a.x00*b.x00) + float64(a.x01*b.x10)
+	m.x01 = float64(a.x00*b.x01) + float64(a.x01*b.x11)
+	m.x10 = float64(a.x10*b.x00) + float64(a.x11*b.x10)
+	m.x11 = float64(a.x10*b.x01) + float64(a.x11*b.x11)
 	return m
 }
 
@@ -406,32 +419,34 @@ func (a M33) MulBox(box Box2) Box2 {
 
 //-----------------------------------------------------------------------------
 
+// The float64() conversions stop FMA fusion (see vecf.go).
+
 // Determinant returns the determinant of a 4x4 matrix.
 func (a M44) Determinant() float64 {
-	return (a.x00*a.x11*a.x22*a.x33 - a.x00*a.x11*a.x23*a.x32 +
-		a.x00*a.x12*a.x23*a.x31 - a.x00*a.x12*a.x21*a.x33 +
-		a.x00*a.x13*a.x21*a.x32 - a.x00*a.x13*a.x22*a.x31 -
-		a.x01*a.x12*a.x23*a.x30 + a.x01*a.x12*a.x20*a.x33 -
-		a.x01*a.x13*a.x20*a.x32 + a.x01*a.x13*a.x22*a.x30 -
-		a.x01*a.x10*a.x22*a.x33 + a.x01*a.x10*a.x23*a.x32 +
-		a.x02*a.x13*a.x20*a.x31 - a.x02*a.x13*a.x21*a.x30 +
-		a.x02*a.x10*a.x21*a.x33 - a.x02*a.x10*a.x23*a.x31 +
-		a.x02*a.x11*a.x23*a.x30 - a.x02*a.x11*a.x20*a.x33 -
-		a.x03*a.x10*a.x21*a.x32 + a.x03*a.x10*a.x22*a.x31 -
-		a.x03*a.x11*a.x22*a.x30 + a.x03*a.x11*a.x20*a.x32 -
-		a.x03*a.x12*a.x20*a.x31 + a.x03*a.x12*a.x21*a.x30)
+	return (float64(a.x00*a.x11*a.x22*a.x33) - float64(a.x00*a.x11*a.x23*a.x32) +
+		float64(a.x00*a.x12*a.x23*a.x31) - float64(a.x00*a.x12*a.x21*a.x33) +
+		float64(a.x00*a.x13*a.x21*a.x32) - float64(a.x00*a.x13*a.x22*a.x31) -
+		float64(a.x01*a.x12*a.x23*a.x30) + float64(a.x01*a.x12*a.x20*a.x33) -
+		float64(a.x01*a.x13*a.x20*a.x32) + float64(a.x01*a.x13*a.x22*a.x30) -
+		float64(a.x01*a.x10*a.x22*a.x33) + float64(a.x01*a.x10*a.x23*a.x32) +
+		float64(a.x02*a.x13*a.x20*a.x31) - float64(a.x02*a.x13*a.x21*a.x30) +
+		float64(a.x02*a.x10*a.x21*a.x33) - float64(a.x02*a.x10*a.x23*a.x31) +
+		float64(a.x02*a.x11*a.x23*a.x30) - float64(a.x02*a.x11*a.x20*a.x33) -
+		float64(a.x03*a.x10*a.x21*a.x32) + float64(a.x03*a.x10*a.x22*a.x31) -
+		float64(a.x03*a.x11*a.x22*a.x30) + float64(a.x03*a.x11*a.x20*a.x32) -
+		float64(a.x03*a.x12*a.x20*a.x31) + float64(a.x03*a.x12*a.x21*a.x30))
 }
 
 // Determinant returns the determinant of a 3x3 matrix.
 func (a M33) Determinant() float64 {
-	return (a.x00*(a.x11*a.x22-a.x21*a.x12) -
-		a.x01*(a.x10*a.x22-a.x20*a.x12) +
-		a.x02*(a.x10*a.x21-a.x20*a.x11))
+	return (float64(a.x00*(float64(a.x11*a.x22)-float64(a.x21*a.x12))) -
+		float64(a.x01*(float64(a.x10*a.x22)-float64(a.x20*a.x12))) +
+		float64(a.x02*(float64(a.x10*a.x21)-float64(a.x20*a.x11))))
 }
 
 // Determinant returns the determinant of a 2x2 matrix.
 func (a M22) Determinant() float64 {
-	return a.x00*a.x11 - a.x01*a.x10
+	return float64(a.x00*a.x11) - float64(a.x01*a.x10)
 }
 
 //-----------------------------------------------------------------------------
@@ -440,22 +455,22 @@ func (a M22) Determinant() float64 {
 func (a M44) Inverse() M44 {
 	m := M44{}
 	d := 1 / a.Determinant()
-	m.x00 = (a.x12*a.x23*a.x31 - a.x13*a.x22*a.x31 + a.x13*a.x21*a.x32 - a.x11*a.x23*a.x32 - a.x12*a.x21*a.x33 + a.x11*a.x22*a.x33) * d
-	m.x01 = (a.x03*a.x22*a.x31 - a.x02*a.x23*a.x31 - a.x03*a.x21*a.x32 + a.x01*a.x23*a.x32 + a.x02*a.x21*a.x33 - a.x01*a.x22*a.x33) * d
-	m.x02 = (a.x02*a.x13*a.x31 - a.x03*a.x12*a.x31 + a.x03*a.x11*a.x32 - a.x01*a.x13*a.x32 - a.x02*a.x11*a.x33 + a.x01*a.x12*a.x33) * d
-	m.x03 = (a.x03*a.x12*a.x21 - a.x02*a.x13*a.x21 - a.x03*a.x11*a.x22 + a.x01*a.x13*a.x22 + a.x02*a.x11*a.x23 - a.x01*a.x12*a.x23) * d
-	m.x10 = (a.x13*a.x22*a.x30 - a.x12*a.x23*a.x30 - a.x13*a.x20*a.x32 + a.x10*a.x23*a.x32 + a.x12*a.x20*a.x33 - a.x10*a.x22*a.x33) * d
-	m.x11 = (a.x02*a.x23*a.x30 - a.x03*a.x22*a.x30 + a.x03*a.x20*a.x32 - a.x00*a.x23*a.x32 - a.x02*a.x20*a.x33 + a.x00*a.x22*a.x33) * d
-	m.x12 = (a.x03*a.x12*a.x30 - a.x02*a.x13*a.x30 - a.x03*a.x10*a.x32 + a.x00*a.x13*a.x32 + a.x02*a.x10*a.x33 - a.x00*a.x12*a.x33) * d
-	m.x13 = (a.x02*a.x13*a.x20 - a.x03*a.x12*a.x20 + a.x03*a.x10*a.x22 - a.x00*a.x13*a.x22 - a.x02*a.x10*a.x23 + a.x00*a.x12*a.x23) * d
-	m.x20 = (a.x11*a.x23*a.x30 - a.x13*a.x21*a.x30 + a.x13*a.x20*a.x31 - a.x10*a.x23*a.x31 - a.x11*a.x20*a.x33 + a.x10*a.x21*a.x33) * d
-	m.x21 = (a.x03*a.x21*a.x30 - a.x01*a.x23*a.x30 - a.x03*a.x20*a.x31 + a.x00*a.x23*a.x31 + a.x01*a.x20*a.x33 - a.x00*a.x21*a.x33) * d
-	m.x22 = (a.x01*a.x13*a.x30 - a.x03*a.x11*a.x30 + a.x03*a.x10*a.x31 - a.x00*a.x13*a.x31 - a.x01*a.x10*a.x33 + a.x00*a.x11*a.x33) * d
-	m.x23 = (a.x03*a.x11*a.x20 - a.x01*a.x13*a.x20 - a.x03*a.x10*a.x21 + a.x00*a.x13*a.x21 + a.x01*a.x10*a.x23 - a.x00*a.x11*a.x23) * d
-	m.x30 = (a.x12*a.x21*a.x30 - a.x11*a.x22*a.x30 - a.x12*a.x20*a.x31 + a.x10*a.x22*a.x31 + a.x11*a.x20*a.x32 - a.x10*a.x21*a.x32) * d
-	m.x31 = (a.x01*a.x22*a.x30 - a.x02*a.x21*a.x30 + a.x02*a.x20*a.x31 - a.x00*a.x22*a.x31 - a.x01*a.x20*a.x32 + a.x00*a.x21*a.x32) * d
-	m.x32 = (a.x02*a.x11*a.x30 - a.x01*a.x12*a.x30 - a.x02*a.x10*a.x31 + a.x00*a.x12*a.x31 + a.x01*a.x10*a.x32 - a.x00*a.x11*a.x32) * d
-	m.x33 = (a.x01*a.x12*a.x20 - a.x02*a.x11*a.x20 + a.x02*a.x10*a.x21 - a.x00*a.x12*a.x21 - a.x01*a.x10*a.x22 + a.x00*a.x11*a.x22) * d
+	m.x00 = (float64(a.x12*a.x23*a.x31) - float64(a.x13*a.x22*a.x31) + float64(a.x13*a.x21*a.x32) - float64(a.x11*a.x23*a.x32) - float64(a.x12*a.x21*a.x33) + float64(a.x11*a.x22*a.x33)) * d
+	m.x01 = (float64(a.x03*a.x22*a.x31) - float64(a.x02*a.x23*a.x31) - float64(a.x03*a.x21*a.x32) + float64(a.x01*a.x23*a.x32) + float64(a.x02*a.x21*a.x33) - float64(a.x01*a.x22*a.x33)) * d
+	m.x02 = (float64(a.x02*a.x13*a.x31) - float64(a.x03*a.x12*a.x31) + float64(a.x03*a.x11*a.x32) - float64(a.x01*a.x13*a.x32) - float64(a.x02*a.x11*a.x33) + float64(a.x01*a.x12*a.x33)) * d
+	m.x03 = (float64(a.x03*a.x12*a.x21) - float64(a.x02*a.x13*a.x21) - float64(a.x03*a.x11*a.x22) + float64(a.x01*a.x13*a.x22) + float64(a.x02*a.x11*a.x23) - float64(a.x01*a.x12*a.x23)) * d
+	m.x10 = (float64(a.x13*a.x22*a.x30) - float64(a.x12*a.x23*a.x30) - float64(a.x13*a.x20*a.x32) + float64(a.x10*a.x23*a.x32) + float64(a.x12*a.x20*a.x33) - float64(a.x10*a.x22*a.x33)) * d
+	m.x11 = (float64(a.x02*a.x23*a.x30) - float64(a.x03*a.x22*a.x30) + float64(a.x03*a.x20*a.x32) - float64(a.x00*a.x23*a.x32) - float64(a.x02*a.x20*a.x33) + float64(a.x00*a.x22*a.x33)) * d
+	m.x12 = (float64(a.x03*a.x12*a.x30) - float64(a.x02*a.x13*a.x30) - float64(a.x03*a.x10*a.x32) + float64(a.x00*a.x13*a.x32) + float64(a.x02*a.x10*a.x33) - float64(a.x00*a.x12*a.x33)) * d
+	m.x13 = (float64(a.x02*a.x13*a.x20) - float64(a.x03*a.x12*a.x20) + float64(a.x03*a.x10*a.x22) - float64(a.x00*a.x13*a.x22) - float64(a.x02*a.x10*a.x23) + float64(a.x00*a.x12*a.x23)) * d
+	m.x20 = (float64(a.x11*a.x23*a.x30) - float64(a.x13*a.x21*a.x30) + float64(a.x13*a.x20*a.x31) - float64(a.x10*a.x23*a.x31) - float64(a.x11*a.x20*a.x33) + float64(a.x10*a.x21*a.x33)) * d
+	m.x21 = (float64(a.x03*a.x21*a.x30) - float64(a.x01*a.x23*a.x30) - float64(a.x03*a.x20*a.x31) + float64(a.x00*a.x23*a.x31) + float64(a.x01*a.x20*a.x33) - float64(a.x00*a.x21*a.x33)) * d
+	m.x22 = (float64(a.x01*a.x13*a.x30) - float64(a.x03*a.x11*a.x30) + float64(a.x03*a.x10*a.x31) - float64(a.x00*a.x13*a.x31) - float64(a.x01*a.x10*a.x33) + float64(a.x00*a.x11*a.x33)) * d
+	m.x23 = (float64(a.x03*a.x11*a.x20) - float64(a.x01*a.x13*a.x20) - float64(a.x03*a.x10*a.x21) + float64(a.x00*a.x13*a.x21) + float64(a.x01*a.x10*a.x23) - float64(a.x00*a.x11*a.x23)) * d
+	m.x30 = (float64(a.x12*a.x21*a.x30) - float64(a.x11*a.x22*a.x30) - float64(a.x12*a.x20*a.x31) + float64(a.x10*a.x22*a.x31) + float64(a.x11*a.x20*a.x32) - float64(a.x10*a.x21*a.x32)) * d
+	m.x31 = (float64(a.x01*a.x22*a.x30) - float64(a.x02*a.x21*a.x30) + float64(a.x02*a.x20*a.x31) - float64(a.x00*a.x22*a.x31) - float64(a.x01*a.x20*a.x32) + float64(a.x00*a.x21*a.x32)) * d
+	m.x32 = (float64(a.x02*a.x11*a.x30) - float64(a.x01*a.x12*a.x30) - float64(a.x02*a.x10*a.x31) + float64(a.x00*a.x12*a.x31) + float64(a.x01*a.x10*a.x32) - float64(a.x00*a.x11*a.x32)) * d
+	m.x33 = (float64(a.x01*a.x12*a.x20) - float64(a.x02*a.x11*a.x20) + float64(a.x02*a.x10*a.x21) - float64(a.x00*a.x12*a.x21) - float64(a.x01*a.x10*a.x22) + float64(a.x00*a.x11*a.x22)) * d
 	return m
 }
 
@@ -463,15 +478,15 @@ func (a M44) Inverse() M44 {
 func (a M33) Inverse() M33 {
 	m := M33{}
 	d := 1 / a.Determinant()
-	m.x00 = (a.x11*a.x22 - a.x12*a.x21) * d
-	m.x01 = (a.x21*a.x02 - a.x01*a.x22) * d
-	m.x02 = (a.x01*a.x12 - a.x11*a.x02) * d
-	m.x10 = (a.x12*a.x20 - a.x22*a.x10) * d
-	m.x11 = (a.x22*a.x00 - a.x20*a.x02) * d
-	m.x12 = (a.x02*a.x10 - a.x12*a.x00) * d
-	m.x20 = (a.x10*a.x21 - a.x20*a.x11) * d
-	m.x21 = (a.x20*a.x01 - a.x00*a.x21) * d
-	m.x22 = (a.x00*a.x11 - a.x01*a.x10) * d
+	m.x00 = (float64(a.x11*a.x22) - float64(a.x12*a.x21)) * d
+	m.x01 = (float64(a.x21*a.x02) - float64(a.x01*a.x22)) * d
+	m.x02 = (float64(a.x01*a.x12) - float64(a.x11*a.x02)) * d
+	m.x10 = (float64(a.x12*a.x20) - float64(a.x22*a.x10)) * d
+	m.x11 = (float64(a.x22*a.x00) - float64(a.x20*a.x02)) * d
+	m.x12 = (float64(a.x02*a.x10) - float64(a.x12*a.x00)) * d
+	m.x20 = (float64(a.x10*a.x21) - float64(a.x20*a.x11)) * d
+	m.x21 = (float64(a.x20*a.x01) - float64(a.x00*a.x21)) * d
+	m.x22 = (float64(a.x00*a.x11) - float64(a.x01*a.x10)) * d
 	return m
 }
 
@@ -0,0 +1,173 @@
+//-----------------------------------------------------------------------------
+/*
+
+2D Medial Axis
+
+The medial axis (skeleton) of a shape is the set of centers of the maximal
+inscribed circles. It's the ridge line of the interior distance field and is
+useful for centerline toolpaths, rib layouts and thickness maps.
+
+The boundary of the SDF2 is sampled and the Voronoi diagram of the samples
+is found (as the dual of the Delaunay triangulation). The Voronoi edges
+within the shape that separate widely spaced boundary samples approximate
+the medial axis. Edges separating adjacent boundary samples are pruned.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// MedialPath is a polyline on the medial axis with the inscribed circle radius at each point.
+type MedialPath struct {
+	Points []V2      // points on the medial axis
+	Radius []float64 // inscribed circle radius at each point
+}
+
+// medialPrune is the minimum boundary sample separation (in cells) for a medial axis edge.
+const medialPrune = 3.0
+
+// boundarySamples returns points on the boundary of an SDF2.
+func boundarySamples(s SDF2, step float64) V2Set {
+	bb := s.BoundingBox()
+	bb = NewBox2(bb.Center(), bb.Size().AddScalar(2*step))
+	lines := marchingSquares(s, bb, step)
+	// remove duplicate line end points (keep the order deterministic)
+	var vs V2Set
+	seen := make(map[V2i]bool)
+	q := 1e-3 * step
+	for _, l := range lines {
+		for _, p := range l {
+			k := p.DivScalar(q).ToV2i()
+			if !seen[k] {
+				seen[k] = true
+				vs = append(vs, p)
+			}
+		}
+	}
+	return vs
+}
+
+// MedialAxis2D returns the medial axis of an SDF2 as a set of polylines.
+func MedialAxis2D(
+	s SDF2, // sdf2 to process
+	meshCells int, // number of cells on the longest axis. e.g 200
+) ([]MedialPath, error) {
+	step := s.BoundingBox().Size().MaxComponent() / float64(meshCells)
+	vs := boundarySamples(s, step)
+	if len(vs) < 3 {
+		return nil, errors.New("not enough boundary samples")
+	}
+	ts, err := vs.Delaunay2d()
+	if err != nil {
+		return nil, err
+	}
+
+	// The Voronoi vertices are the circumcenters of the Delaunay triangles.
+	// Keep the vertices within the shape.
+	center := make([]V2, len(ts))
+	radius := make([]float64, len(ts))
+	inside := make([]bool, len(ts))
+	for i, t := range ts {
+		c, err := t.ToTriangle2(vs).Circumcenter()
+		if err != nil {
+			continue
+		}
+		d := s.Evaluate(c)
+		if d < 0 {
+			center[i] = c
+			radius[i] = -d
+			inside[i] = true
+		}
+	}
+
+	// The Voronoi edges join the vertices of Delaunay triangles sharing an edge.
+	e2t := make(map[EdgeI][]int)
+	var edges []EdgeI
+	for i, t := range ts {
+		for j := 0; j < 3; j++ {
+			a, b := t[j], t[(j+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			e := EdgeI{a, b}
+			if e2t[e] == nil {
+				edges = append(edges, e)
+			}
+			e2t[e] = append(e2t[e], i)
+		}
+	}
+	adj := make([][]int, len(ts))
+	limit := medialPrune * step
+	for _, e := range edges {
+		t := e2t[e]
+		if len(t) != 2 || !inside[t[0]] || !inside[t[1]] {
+			continue
+		}
+		// prune edges between adjacent boundary samples
+		if vs[e[0]].Sub(vs[e[1]]).Length() < limit {
+			continue
+		}
+		adj[t[0]] = append(adj[t[0]], t[1])
+		adj[t[1]] = append(adj[t[1]], t[0])
+	}
+
+	// trace the polylines
+	var paths []MedialPath
+	used := make(map[EdgeI]bool)
+	key := func(a, b int) EdgeI {
+		if a > b {
+			a, b = b, a
+		}
+		return EdgeI{a, b}
+	}
+	trace := func(a, b int) {
+		path := MedialPath{}
+		path.Points = append(path.Points, center[a])
+		path.Radius = append(path.Radius, radius[a])
+		used[key(a, b)] = true
+		for {
+			path.Points = append(path.Points, center[b])
+			path.Radius = append(path.Radius, radius[b])
+			if len(adj[b]) != 2 {
+				// end point or junction
+				break
+			}
+			next := adj[b][0]
+			if next == a {
+				next = adj[b][1]
+			}
+			if used[key(b, next)] {
+				// closed loop
+				break
+			}
+			used[key(b, next)] = true
+			a, b = b, next
+		}
+		paths = append(paths, path)
+	}
+	// start at the end points and junctions
+	for i := range adj {
+		if len(adj[i]) != 2 {
+			for _, j := range adj[i] {
+				if !used[key(i, j)] {
+					trace(i, j)
+				}
+			}
+		}
+	}
+	// any remaining edges are closed loops
+	for i := range adj {
+		for _, j := range adj[i] {
+			if !used[key(i, j)] {
+				trace(i, j)
+			}
+		}
+	}
+	return paths, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,378 @@
+//-----------------------------------------------------------------------------
+/*
+
+Triangle Mesh SDF3
+
+Convert a triangle mesh into an SDF3 so it can take part in the usual CSG
+operations. The distance is the distance to the closest triangle and the
+sign is determined by counting ray/mesh crossings.
+
+The mesh evaluation is slow, so meshes are normally voxelized (see Voxel3D)
+before being used in a boolean operation.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// closestPoint returns the point on a triangle closest to p.
+// See: Real-Time Collision Detection, Christer Ericson, 5.1.5
+func (t *Triangle3) closestPoint(p V3) V3 {
+	a, b, c := t.V[0], t.V[1], t.V[2]
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+	ap := p.Sub(a)
+	d1 := ab.Dot(ap)
+	d2 := ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+	bp := p.Sub(b)
+	d3 := ab.Dot(bp)
+	d4 := ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.MulScalar(d1 / (d1 - d3)))
+	}
+	cp := p.Sub(c)
+	d5 := ab.Dot(cp)
+	d6 := ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.MulScalar(d2 / (d2 - d6)))
+	}
+	va := d3*d6 - d5*d4
+	if va <= 0 && (d4-d3) >= 0 && (d5-d6) >= 0 {
+		return b.Add(c.Sub(b).MulScalar((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+	denom := 1 / (va + vb + vc)
+	return a.Add(ab.MulScalar(vb * denom)).Add(ac.MulScalar(vc * denom))
+}
+
+// rayIntersect returns true if the ray from p in direction d crosses the triangle.
+// See: Moller-Trumbore intersection algorithm
+func (t *Triangle3) rayIntersect(p, d V3) bool {
+	e1 := t.V[1].Sub(t.V[0])
+	e2 := t.V[2].Sub(t.V[0])
+	h := d.Cross(e2)
+	a := e1.Dot(h)
+	if Abs(a) < epsilon {
+		// the ray is parallel to the triangle
+		return false
+	}
+	f := 1 / a
+	s := p.Sub(t.V[0])
+	u := f * s.Dot(h)
+	if u < 0 || u > 1 {
+		return false
+	}
+	q := s.Cross(e1)
+	v := f * d.Dot(q)
+	if v < 0 || u+v > 1 {
+		return false
+	}
+	return f*e2.Dot(q) > 0
+}
+
+// BoundingBox returns the bounding box of a 3D triangle.
+func (t *Triangle3) BoundingBox() Box3 {
+	return Box3{t.V[0].Min(t.V[1]).Min(t.V[2]), t.V[0].Max(t.V[1]).Max(t.V[2])}
+}
+
+//-----------------------------------------------------------------------------
+
+// MeshSDF3 is an SDF3 defined by a closed triangle mesh.
+type MeshSDF3 struct {
+	mesh []*Triangle3 // the triangle mesh
+	size float64      // side length of a bin
+	n    V3i          // number of bins on each axis
+	bins [][]int      // triangle indices for each bin
+	bb   Box3         // bounding box
+}
+
+// Mesh3D returns an SDF3 for a closed triangle mesh.
+func Mesh3D(mesh []*Triangle3) (SDF3, error) {
+	if len(mesh) == 0 {
+		return nil, errors.New("empty mesh")
+	}
+	s := MeshSDF3{
+		mesh: mesh,
+	}
+	// work out the bounding box
+	bb := mesh[0].BoundingBox()
+	for _, t := range mesh {
+		bb = bb.Extend(t.BoundingBox())
+	}
+	s.bb = bb.ScaleAboutCenter(1.01)
+	// bin the triangles on a uniform grid, roughly a few triangles per bin
+	size := s.bb.Size()
+	if size.MaxComponent() == 0 {
+		return nil, errors.New("the mesh has no size")
+	}
+	k := math.Cbrt(size.X * size.Y * size.Z / float64(len(mesh)))
+	s.size = Max(k, size.MaxComponent()/128)
+	s.n = size.DivScalar(s.size).Ceil().ToV3i()
+	// a planar mesh has no size on an axis, it still needs a bin
+	for i := range s.n {
+		if s.n[i] < 1 {
+			s.n[i] = 1
+		}
+	}
+	s.bins = make([][]int, s.n[0]*s.n[1]*s.n[2])
+	for i, t := range mesh {
+		tbb := t.BoundingBox()
+		i0 := s.bin(tbb.Min)
+		i1 := s.bin(tbb.Max)
+		for x := i0[0]; x <= i1[0]; x++ {
+			for y := i0[1]; y <= i1[1]; y++ {
+				for z := i0[2]; z <= i1[2]; z++ {
+					j := s.index(V3i{x, y, z})
+					s.bins[j] = append(s.bins[j], i)
+				}
+			}
+		}
+	}
+	return &s, nil
+}
+
+// bin returns the (clamped) bin coordinates of a point.
+func (s *MeshSDF3) bin(p V3) V3i {
+	i := p.Sub(s.bb.Min).DivScalar(s.size).ToV3i()
+	for k := range i {
+		if i[k] < 0 {
+			i[k] = 0
+		}
+		if i[k] >= s.n[k] {
+			i[k] = s.n[k] - 1
+		}
+	}
+	return i
+}
+
+// index returns the bins index of bin coordinates.
+func (s *MeshSDF3) index(i V3i) int {
+	return (i[0]*s.n[1]+i[1])*s.n[2] + i[2]
+}
+
+// distance returns the unsigned distance from p to the mesh.
+func (s *MeshSDF3) distance(p V3) float64 {
+	q := p.Clamp(s.bb.Min, s.bb.Max)
+	dq2 := p.Sub(q).Length2()
+	c := s.bin(q)
+	dd := math.MaxFloat64
+	rmax := s.n[0] + s.n[1] + s.n[2]
+	// search shells of bins around the bin containing q
+	for r := 0; r <= rmax; r++ {
+		for x := c[0] - r; x <= c[0]+r; x++ {
+			if x < 0 || x >= s.n[0] {
+				continue
+			}
+			for y := c[1] - r; y <= c[1]+r; y++ {
+				if y < 0 || y >= s.n[1] {
+					continue
+				}
+				for z := c[2] - r; z <= c[2]+r; z++ {
+					if z < 0 || z >= s.n[2] {
+						continue
+					}
+					// only the bins on the surface of the shell
+					if Abs(float64(x-c[0])) != float64(r) &&
+						Abs(float64(y-c[1])) != float64(r) &&
+						Abs(float64(z-c[2])) != float64(r) {
+						continue
+					}
+					for _, i := range s.bins[s.index(V3i{x, y, z})] {
+						dd = Min(dd, p.Sub(s.mesh[i].closestPoint(p)).Length2())
+					}
+				}
+			}
+		}
+		// unsearched bins are at least this far away
+		k := float64(r) * s.size
+		if dd <= dq2+k*k {
+			break
+		}
+	}
+	return math.Sqrt(dd)
+}
+
+// crossings returns the number of mesh crossings for a ray from p along an axis.
+func (s *MeshSDF3) crossings(p V3, axis int) int {
+	// Rays that hit triangle edges or vertices give bad crossing counts.
+	// Mesh vertices are often on regular grids, so jitter the ray origin by
+	// a small non-round amount perpendicular to the ray.
+	j0 := 1.2345e-6 * s.size
+	j1 := 2.3456e-6 * s.size
+	var d V3
+	step := V3i{}
+	switch axis {
+	case 0:
+		d = V3{1, 0, 0}
+		p = p.Add(V3{0, j0, j1})
+		step[0] = 1
+	case 1:
+		d = V3{0, 1, 0}
+		p = p.Add(V3{j1, 0, j0})
+		step[1] = 1
+	default:
+		d = V3{0, 0, 1}
+		p = p.Add(V3{j0, j1, 0})
+		step[2] = 1
+	}
+	c := s.bin(p)
+	// gather the candidate triangles along the ray
+	var candidates []int
+	for i := c; i[axis] < s.n[axis]; i = i.Add(step) {
+		candidates = append(candidates, s.bins[s.index(i)]...)
+	}
+	sort.Ints(candidates)
+	n := 0
+	for j, i := range candidates {
+		if j > 0 && candidates[j-1] == i {
+			// already tested
+			continue
+		}
+		if s.mesh[i].rayIntersect(p, d) {
+			n++
+		}
+	}
+	return n
+}
+
+// inside returns true if the point is inside the mesh.
+func (s *MeshSDF3) inside(p V3) bool {
+	if p.Clamp(s.bb.Min, s.bb.Max) != p {
+		return false
+	}
+	// Use a majority vote over 3 rays to deal with rays that
+	// graze triangle edges or the mesh has small defects.
+	votes := 0
+	for axis := 0; axis < 3; axis++ {
+		if s.crossings(p, axis)&1 == 1 {
+			votes++
+		}
+	}
+	return votes >= 2
+}
+
+// Evaluate returns the minimum distance to a triangle mesh.
+func (s *MeshSDF3) Evaluate(p V3) float64 {
+	d := s.distance(p)
+	if s.inside(p) {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a triangle mesh.
+func (s *MeshSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Mesh Booleans
+
+// BooleanOp is a CSG boolean operation.
+type BooleanOp int
+
+const (
+	// BooleanUnion is a0 + a1
+	BooleanUnion BooleanOp = iota
+	// BooleanDifference is a0 - a1
+	BooleanDifference
+	// BooleanIntersect is a0 & a1
+	BooleanIntersect
+)
+
+// ParseBooleanOp returns the boolean operation for a name.
+func ParseBooleanOp(name string) (BooleanOp, error) {
+	switch name {
+	case "union":
+		return BooleanUnion, nil
+	case "difference":
+		return BooleanDifference, nil
+	case "intersect":
+		return BooleanIntersect, nil
+	}
+	return 0, fmt.Errorf("unknown boolean operation \"%s\"", name)
+}
+
+// MeshBoolean returns the boolean of two triangle meshes.
+// Each mesh is converted to a voxelized SDF3, the boolean is applied
+// and the result is re-meshed with marching cubes.
+func MeshBoolean(
+	m0, m1 []*Triangle3, // triangle meshes
+	op BooleanOp, // boolean operation
+	meshCells int, // number of cells on the longest axis. e.g 200
+) ([]*Triangle3, error) {
+	s0, err := Mesh3D(m0)
+	if err != nil {
+		return nil, err
+	}
+	s1, err := Mesh3D(m1)
+	if err != nil {
+		return nil, err
+	}
+	// voxelize both meshes with the same resolution
+	bb := s0.BoundingBox().Extend(s1.BoundingBox())
+	k := bb.Size().MaxComponent()
+	cells0 := int(math.Ceil(float64(meshCells) * s0.BoundingBox().Size().MaxComponent() / k))
+	cells1 := int(math.Ceil(float64(meshCells) * s1.BoundingBox().Size().MaxComponent() / k))
+	s0 = Voxel3D(s0, cells0)
+	s1 = Voxel3D(s1, cells1)
+	var s SDF3
+	switch op {
+	case BooleanUnion:
+		s = Union3D(s0, s1)
+	case BooleanDifference:
+		s = Difference3D(s0, s1)
+	case BooleanIntersect:
+		s = Intersect3D(s0, s1)
+	default:
+		return nil, errors.New("bad boolean operation")
+	}
+	// re-mesh the result
+	bb0 := s.BoundingBox()
+	meshInc := bb0.Size().MaxComponent() / float64(meshCells)
+	bb1Size := bb0.Size().DivScalar(meshInc).Ceil().AddScalar(1).MulScalar(meshInc)
+	return marchingCubes(s, NewBox3(bb0.Center(), bb1Size), meshInc), nil
+}
+
+// MeshBooleanSTL reads two STL files, applies a boolean and writes the result to an STL file.
+func MeshBooleanSTL(
+	path0, path1 string, // input STL files
+	op BooleanOp, // boolean operation
+	meshCells int, // number of cells on the longest axis. e.g 200
+	path string, // output STL file
+) error {
+	m0, err := LoadSTL(path0)
+	if err != nil {
+		return err
+	}
+	m1, err := LoadSTL(path1)
+	if err != nil {
+		return err
+	}
+	m, err := MeshBoolean(m0, m1, op, meshCells)
+	if err != nil {
+		return err
+	}
+	return SaveSTL(path, m)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,179 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mirrored Parts
+
+Mirroring a part to make a left/right pair also mirrors the features that
+have a handedness. A mirrored right hand thread is a left hand thread, and
+mirrored text reads backwards. Mirror3D rebuilds the SDF tree so these
+features keep their handedness on the mirrored part:
+
+Screws (Screw3D, ThreadScrew3D) are regenerated with the opposite hand before
+the part is mirrored, so the mirrored part has the same thread hand.
+
+Other chiral features (E.g. embossed text) are marked with Chiral3D. They are
+mirrored about their own center plane before the part is mirrored, so they
+keep their orientation.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+
+// ChiralSDF3 marks an SDF3 which keeps its handedness when the part containing it is mirrored.
+type ChiralSDF3 struct {
+	sdf    SDF3
+	mirror M44 // local mirror used when the part is mirrored
+}
+
+// Chiral3D marks an SDF3 (E.g. embossed text) which keeps its handedness when the part containing
+// it is mirrored with Mirror3D. The local mirror plane passes through the center of the
+// bounding box, use a normal along the reading direction for text.
+func Chiral3D(sdf SDF3, normal V3) SDF3 {
+	return &ChiralSDF3{
+		sdf:    sdf,
+		mirror: MirrorPlane(sdf.BoundingBox().Center(), normal),
+	}
+}
+
+// Evaluate returns the minimum distance to a chiral SDF3.
+func (s *ChiralSDF3) Evaluate(p V3) float64 {
+	return s.sdf.Evaluate(p)
+}
+
+// BoundingBox returns the bounding box of a chiral SDF3.
+func (s *ChiralSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+// Children returns the child nodes of a chiral SDF3.
+func (s *ChiralSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+//-----------------------------------------------------------------------------
+
+// isChiral returns true if an SDF tree has screws or chiral nodes.
+func isChiral(node interface{}) bool {
+	found := false
+	Walk(node, func(n interface{}, depth int) bool {
+		switch n.(type) {
+		case *ScrewSDF3, *ChiralSDF3:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// unmirror returns an SDF3 with the chiral features reversed, ready to be mirrored.
+func unmirror(s SDF3) (SDF3, error) {
+	if !isChiral(s) {
+		return s, nil
+	}
+	switch x := s.(type) {
+	case *ScrewSDF3:
+		y := *x
+		y.lead = -x.lead
+		return &y, nil
+	case *ChiralSDF3:
+		return Transform3D(x.sdf, x.mirror), nil
+	}
+	errNode := fmt.Errorf("can't mirror the chiral features of a %s", NodeName(s))
+	// reverse the children
+	var c []SDF3
+	for _, n := range Children(s) {
+		x, ok := n.(SDF3)
+		if !ok {
+			return nil, errNode
+		}
+		y, err := unmirror(x)
+		if err != nil {
+			return nil, err
+		}
+		c = append(c, y)
+	}
+	// rebuild the node with the new children
+	switch x := s.(type) {
+	case *UnionSDF3:
+		y := *x
+		y.sdf = c
+		return &y, nil
+	case *DifferenceSDF3:
+		y := *x
+		y.s0, y.s1 = c[0], c[1]
+		return &y, nil
+	case *IntersectionSDF3:
+		y := *x
+		y.s0, y.s1 = c[0], c[1]
+		return &y, nil
+	case *TransformSDF3:
+		y := *x
+		y.sdf = c[0]
+		return &y, nil
+	case *ScaleUniformSDF3:
+		y := *x
+		y.sdf = c[0]
+		return &y, nil
+	case *ScaleSDF3:
+		y := *x
+		y.sdf = c[0]
+		return &y, nil
+	case *ElongateSDF3:
+		y := *x
+		y.sdf = c[0]
+		return &y, nil
+	case *CutSDF3:
+		y := *x
+		y.sdf = c[0]
+		return &y, nil
+	case *ArraySDF3:
+		y := *x
+		y.sdf = c[0]
+		return &y, nil
+	case *RotateUnionSDF3:
+		y := *x
+		y.sdf = c[0]
+		return &y, nil
+	case *RotateCopySDF3:
+		y := *x
+		y.sdf = c[0]
+		return &y, nil
+	}
+	return nil, errNode
+}
+
+// Mirror3D returns a mirrored SDF3. Screws keep their thread hand and Chiral3D nodes keep
+// their orientation, see the notes above.
+func Mirror3D(
+	s SDF3, // part to mirror
+	mirror M44, // mirror matrix, E.g. MirrorYZ(), MirrorPlane()
+) (SDF3, error) {
+	if mirror.Determinant() >= 0 {
+		return nil, errors.New("the matrix is not a mirror")
+	}
+	u, err := unmirror(s)
+	if err != nil {
+		return nil, err
+	}
+	return Transform3D(u, mirror), nil
+}
+
+// MirrorPair3D returns the part and its mirrored copy as a left/right pair.
+func MirrorPair3D(
+	s SDF3, // part to mirror
+	mirror M44, // mirror matrix, E.g. MirrorYZ(), MirrorPlane()
+) ([]SDF3, error) {
+	m, err := Mirror3D(s, mirror)
+	if err != nil {
+		return nil, err
+	}
+	return []SDF3{s, m}, nil
+}
+
+//-----------------------------------------------------------------------------
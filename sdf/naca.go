@@ -0,0 +1,193 @@
+//-----------------------------------------------------------------------------
+/*
+
+NACA Airfoils
+
+NACA 4 and 5 digit airfoil profiles for wing ribs, fan blades, hydrofoils, etc.
+
+4 digit, E.g. "2412": max camber 2% at 40% of the chord, 12% thick.
+5 digit, E.g. "23012": design lift 0.15*2, max camber at 30/2 = 15% of the
+chord, standard (0) or reflexed (1) camber line, 12% thick.
+
+The profile has the leading edge at the origin and the chord along the
+x-axis. The thickness distribution is the closed trailing edge variant with
+an added linear thickness to give a trailing edge of a specified thickness,
+since a sharp trailing edge can't be printed or cut.
+
+See: https://en.wikipedia.org/wiki/NACA_airfoil
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+//-----------------------------------------------------------------------------
+
+// NACAParms defines the parameters for a NACA airfoil.
+type NACAParms struct {
+	Code         string  // 4 or 5 digit NACA code, E.g. "2412" or "23012"
+	Chord        float64 // chord length
+	TrailingEdge float64 // trailing edge thickness (0 = sharp)
+	Points       int     // number of points on each surface (0 = 60)
+}
+
+// camberFunc returns the camber line height and slope at x (0..1).
+type camberFunc func(x float64) (yc, dyc float64)
+
+// naca4Camber returns the camber line for a 4 digit airfoil.
+func naca4Camber(m, p float64) camberFunc {
+	return func(x float64) (float64, float64) {
+		if m == 0 || p == 0 {
+			return 0, 0
+		}
+		if x < p {
+			return m / (p * p) * (2*p*x - x*x), 2 * m / (p * p) * (p - x)
+		}
+		q := (1 - p) * (1 - p)
+		return m / q * (1 - 2*p + 2*p*x - x*x), 2 * m / q * (p - x)
+	}
+}
+
+// naca5Table is the camber line data for 5 digit airfoils (design lift 0.3), keyed by
+// the camber position and reflex digits.
+var naca5Table = map[string][3]float64{
+	// r, k1, k2/k1
+	"10": {0.0580, 361.4, 0},
+	"20": {0.1260, 51.64, 0},
+	"30": {0.2025, 15.957, 0},
+	"40": {0.2900, 6.643, 0},
+	"50": {0.3910, 3.230, 0},
+	"21": {0.1300, 51.99, 0.000764},
+	"31": {0.2170, 15.793, 0.00677},
+	"41": {0.3180, 6.520, 0.0303},
+	"51": {0.4410, 3.191, 0.1355},
+}
+
+// naca5Camber returns the camber line for a 5 digit airfoil.
+func naca5Camber(lift float64, r, k1, k21 float64, reflex bool) camberFunc {
+	// the table is for a design lift of 0.3, the camber scales linearly with lift
+	k := lift / 0.3
+	r3 := r * r * r
+	return func(x float64) (float64, float64) {
+		if !reflex {
+			if x < r {
+				return k * k1 / 6 * (x*x*x - 3*r*x*x + r*r*(3-r)*x), k * k1 / 6 * (3*x*x - 6*r*x + r*r*(3-r))
+			}
+			return k * k1 * r3 / 6 * (1 - x), -k * k1 * r3 / 6
+		}
+		b := (1 - r) * (1 - r) * (1 - r)
+		if x < r {
+			y := (x-r)*(x-r)*(x-r) - k21*b*x - r3*x + r3
+			dy := 3*(x-r)*(x-r) - k21*b - r3
+			return k * k1 / 6 * y, k * k1 / 6 * dy
+		}
+		y := k21*(x-r)*(x-r)*(x-r) - k21*b*x - r3*x + r3
+		dy := 3*k21*(x-r)*(x-r) - k21*b - r3
+		return k * k1 / 6 * y, k * k1 / 6 * dy
+	}
+}
+
+// nacaThickness returns the half thickness (closed trailing edge) at x (0..1).
+func nacaThickness(t, x float64) float64 {
+	return 5 * t * (0.2969*math.Sqrt(x) - 0.1260*x - 0.3516*x*x + 0.2843*x*x*x - 0.1036*x*x*x*x)
+}
+
+// nacaDecode returns the thickness and camber line for a NACA code.
+func nacaDecode(code string) (float64, camberFunc, error) {
+	if _, err := strconv.Atoi(code); err != nil {
+		return 0, nil, fmt.Errorf("bad NACA code \"%s\"", code)
+	}
+	d := func(s string) float64 {
+		x, _ := strconv.Atoi(s)
+		return float64(x)
+	}
+	switch len(code) {
+	case 4:
+		m := d(code[0:1]) / 100
+		p := d(code[1:2]) / 10
+		t := d(code[2:4]) / 100
+		if m != 0 && p == 0 {
+			return 0, nil, fmt.Errorf("bad NACA code \"%s\", camber position is 0", code)
+		}
+		return t, naca4Camber(m, p), nil
+	case 5:
+		lift := 0.15 * d(code[0:1])
+		x, ok := naca5Table[code[1:3]]
+		if !ok {
+			return 0, nil, fmt.Errorf("unsupported NACA code \"%s\"", code)
+		}
+		t := d(code[3:5]) / 100
+		return t, naca5Camber(lift, x[0], x[1], x[2], code[2] == '1'), nil
+	}
+	return 0, nil, fmt.Errorf("bad NACA code \"%s\", need 4 or 5 digits", code)
+}
+
+// NACAProfile returns the outline of a NACA airfoil. The points run from the trailing edge
+// along the upper surface to the leading edge and back along the lower surface.
+func NACAProfile(k *NACAParms) ([]V2, error) {
+	if k.Chord <= 0 {
+		return nil, errors.New("chord <= 0")
+	}
+	if k.TrailingEdge < 0 {
+		return nil, errors.New("trailing edge < 0")
+	}
+	n := k.Points
+	if n == 0 {
+		n = 60
+	}
+	if n < 4 {
+		return nil, errors.New("points < 4")
+	}
+	t, camber, err := nacaDecode(k.Code)
+	if err != nil {
+		return nil, err
+	}
+	if t <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	te := 0.5 * k.TrailingEdge / k.Chord // trailing edge half thickness (normalized)
+	upper := make([]V2, n)
+	lower := make([]V2, n)
+	for i := 0; i < n; i++ {
+		// cosine spacing clusters points at the leading and trailing edges
+		x := 0.5 * (1 - math.Cos(Pi*float64(i)/float64(n-1)))
+		yt := nacaThickness(t, x) + te*x
+		yc, dyc := camber(x)
+		s, c := math.Sincos(math.Atan(dyc))
+		upper[i] = V2{x - yt*s, yc + yt*c}.MulScalar(k.Chord)
+		lower[i] = V2{x + yt*s, yc - yt*c}.MulScalar(k.Chord)
+	}
+	// trailing edge to leading edge along the upper surface
+	var v []V2
+	for i := n - 1; i >= 0; i-- {
+		v = append(v, upper[i])
+	}
+	// leading edge to trailing edge along the lower surface
+	last := n
+	if te == 0 {
+		// sharp trailing edge, the surfaces meet
+		last = n - 1
+	}
+	for i := 1; i < last; i++ {
+		v = append(v, lower[i])
+	}
+	return v, nil
+}
+
+// NACA2D returns a NACA airfoil.
+func NACA2D(k *NACAParms) (SDF2, error) {
+	v, err := NACAProfile(k)
+	if err != nil {
+		return nil, err
+	}
+	return Polygon2D(v), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,331 @@
+//-----------------------------------------------------------------------------
+/*
+
+2D Nesting
+
+Pack SDF2 parts onto sheets for laser/waterjet/router cutting.
+
+Each part (at each trial rotation) is rasterized onto a grid, with a halo of
+half the part spacing. The parts are placed largest first at the lowest, then
+left-most position where they fit (bottom-left fill). A new sheet is started
+when a part doesn't fit on the existing sheets.
+
+The layout is written as the contours of the placed parts and the sheet
+outlines, with the sheets side by side.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+// Raster Bitmaps
+
+// nestBitmap is a raster of occupied cells, each row is a bitset.
+type nestBitmap struct {
+	nx, ny int        // size in cells
+	words  int        // words per row
+	rows   [][]uint64 // bitset rows
+}
+
+func newNestBitmap(nx, ny int) *nestBitmap {
+	b := nestBitmap{nx: nx, ny: ny, words: (nx + 63) / 64}
+	b.rows = make([][]uint64, ny)
+	for i := range b.rows {
+		b.rows[i] = make([]uint64, b.words)
+	}
+	return &b
+}
+
+func (b *nestBitmap) set(x, y int) {
+	b.rows[y][x>>6] |= 1 << uint(x&63)
+}
+
+// count returns the number of occupied cells.
+func (b *nestBitmap) count() int {
+	n := 0
+	for _, r := range b.rows {
+		for _, w := range r {
+			n += bits.OnesCount64(w)
+		}
+	}
+	return n
+}
+
+// rowCollides returns true if a part row shifted by x overlaps a sheet row.
+func rowCollides(sheet, part []uint64, x int) bool {
+	w0 := x >> 6
+	s := uint(x & 63)
+	for i, w := range part {
+		if w == 0 {
+			continue
+		}
+		if sheet[w0+i]&(w<<s) != 0 {
+			return true
+		}
+		if s != 0 && w0+i+1 < len(sheet) && sheet[w0+i+1]&(w>>(64-s)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// fits returns true if a part bitmap placed at x,y doesn't overlap the sheet bitmap.
+func (b *nestBitmap) fits(p *nestBitmap, x, y int) bool {
+	if x+p.nx > b.nx || y+p.ny > b.ny {
+		return false
+	}
+	for j := 0; j < p.ny; j++ {
+		if rowCollides(b.rows[y+j], p.rows[j], x) {
+			return false
+		}
+	}
+	return true
+}
+
+// place marks the cells of a part bitmap placed at x,y as occupied.
+func (b *nestBitmap) place(p *nestBitmap, x, y int) {
+	w0 := x >> 6
+	s := uint(x & 63)
+	for j := 0; j < p.ny; j++ {
+		row := b.rows[y+j]
+		for i, w := range p.rows[j] {
+			row[w0+i] |= w << s
+			if s != 0 && w0+i+1 < len(row) {
+				row[w0+i+1] |= w >> (64 - s)
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// NestParms defines the parameters for nesting parts on sheets.
+type NestParms struct {
+	Sheet      V2      // sheet size
+	Margin     float64 // minimum distance from the parts to the sheet edge
+	Spacing    float64 // minimum distance between parts
+	Rotations  int     // number of rotations to try, E.g. 4 = 90 degree steps (0 = 1, no rotation)
+	Resolution float64 // raster cell size (0 = sheet size / 500)
+	MaxSheets  int     // maximum number of sheets (0 = no limit)
+}
+
+// NestPlacement is the placement of a part on a sheet.
+type NestPlacement struct {
+	Part   int     // index of the part
+	Sheet  int     // index of the sheet
+	Angle  float64 // rotation of the part about the origin (radians)
+	Offset V2      // translation of the part (after rotation)
+}
+
+// Transform returns the placement transform for the part.
+func (p *NestPlacement) Transform() M33 {
+	return Translate2d(p.Offset).Mul(Rotate2d(p.Angle))
+}
+
+// Nesting is a layout of parts on sheets.
+type Nesting struct {
+	Sheet      V2              // sheet size
+	Sheets     int             // number of sheets
+	Placements []NestPlacement // part placements, in part order
+	parts      []SDF2
+}
+
+// nestCandidate is a rasterized part at a rotation.
+type nestCandidate struct {
+	angle  float64
+	origin V2 // world position of the bitmap origin (rotated part frame)
+	bitmap *nestBitmap
+}
+
+// Nest2D packs parts onto sheets.
+func Nest2D(parts []SDF2, k *NestParms) (*Nesting, error) {
+	if k.Sheet.X <= 0 || k.Sheet.Y <= 0 {
+		return nil, errors.New("sheet size <= 0")
+	}
+	if k.Margin < 0 {
+		return nil, errors.New("margin < 0")
+	}
+	if k.Spacing < 0 {
+		return nil, errors.New("spacing < 0")
+	}
+	if k.Rotations < 0 {
+		return nil, errors.New("rotations < 0")
+	}
+	if k.Resolution < 0 {
+		return nil, errors.New("resolution < 0")
+	}
+	rotations := maxInt(k.Rotations, 1)
+	res := k.Resolution
+	if res == 0 {
+		res = k.Sheet.MaxComponent() / 500
+	}
+	halo := 0.5 * k.Spacing
+	// cells are occupied if any point in the cell could be within the halo
+	threshold := halo + res*math.Sqrt2*0.5
+
+	// the sheet grid covers the usable area plus the part halos
+	sheetOrigin := V2{k.Margin - halo, k.Margin - halo}
+	sheetSize := k.Sheet.SubScalar(2 * (k.Margin - halo))
+	snx := int(math.Floor(sheetSize.X / res))
+	sny := int(math.Floor(sheetSize.Y / res))
+	if snx <= 0 || sny <= 0 {
+		return nil, errors.New("margin is too large for the sheet")
+	}
+
+	// rasterize the parts at each rotation
+	candidates := make([][]nestCandidate, len(parts))
+	area := make([]int, len(parts))
+	for i, part := range parts {
+		if part == nil {
+			return nil, errors.New("nil part")
+		}
+		for r := 0; r < rotations; r++ {
+			a := Tau * float64(r) / float64(rotations)
+			s := Transform2D(part, Rotate2d(a))
+			bb := s.BoundingBox()
+			origin := bb.Min.SubScalar(halo)
+			size := bb.Size().AddScalar(2 * halo)
+			nx := int(math.Ceil(size.X / res))
+			ny := int(math.Ceil(size.Y / res))
+			b := newNestBitmap(nx, ny)
+			for y := 0; y < ny; y++ {
+				for x := 0; x < nx; x++ {
+					p := origin.Add(V2{(float64(x) + 0.5) * res, (float64(y) + 0.5) * res})
+					if s.Evaluate(p) < threshold {
+						b.set(x, y)
+					}
+				}
+			}
+			candidates[i] = append(candidates[i], nestCandidate{a, origin, b})
+		}
+		area[i] = candidates[i][0].bitmap.count()
+	}
+
+	// place the largest parts first
+	order := make([]int, len(parts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return area[order[i]] > area[order[j]] })
+
+	n := Nesting{
+		Sheet:      k.Sheet,
+		Placements: make([]NestPlacement, len(parts)),
+		parts:      parts,
+	}
+	var sheets []*nestBitmap
+	for _, i := range order {
+		placed := false
+		for sheet := 0; !placed; sheet++ {
+			if sheet == len(sheets) {
+				if k.MaxSheets > 0 && sheet == k.MaxSheets {
+					return nil, errors.New("parts don't fit on the maximum number of sheets")
+				}
+				sheets = append(sheets, newNestBitmap(snx, sny))
+			}
+			b := sheets[sheet]
+			// bottom-left fill: lowest top edge, then left-most
+			best := -1
+			var bx, by, btop int
+			for c, cand := range candidates[i] {
+				p := cand.bitmap
+				found := false
+				for y := 0; y+p.ny <= b.ny && !found; y++ {
+					if best >= 0 && y+p.ny >= btop {
+						break
+					}
+					for x := 0; x+p.nx <= b.nx; x++ {
+						if b.fits(p, x, y) {
+							best, bx, by, btop = c, x, y, y+p.ny
+							found = true
+							break
+						}
+					}
+				}
+			}
+			if best < 0 {
+				if b.count() == 0 {
+					return nil, errors.New("part is too large for the sheet")
+				}
+				continue
+			}
+			cand := candidates[i][best]
+			b.place(cand.bitmap, bx, by)
+			pos := sheetOrigin.Add(V2{float64(bx), float64(by)}.MulScalar(res))
+			n.Placements[i] = NestPlacement{
+				Part:   i,
+				Sheet:  sheet,
+				Angle:  cand.angle,
+				Offset: pos.Sub(cand.origin),
+			}
+			placed = true
+		}
+	}
+	n.Sheets = len(sheets)
+	return &n, nil
+}
+
+//-----------------------------------------------------------------------------
+// Layout Output
+
+// Sheet2D returns the union of the placed parts on a sheet.
+func (n *Nesting) Sheet2D(sheet int) SDF2 {
+	var s []SDF2
+	for i := range n.Placements {
+		p := &n.Placements[i]
+		if p.Sheet == sheet {
+			s = append(s, Transform2D(n.parts[p.Part], p.Transform()))
+		}
+	}
+	return Union2D(s...)
+}
+
+// Lines returns the part contours and the sheet outlines of the layout.
+// The sheets are side by side along the x-axis.
+func (n *Nesting) Lines(
+	resolution float64, // contour sampling resolution
+) []*Line {
+	var lines []*Line
+	gap := 0.1 * n.Sheet.X
+	for sheet := 0; sheet < n.Sheets; sheet++ {
+		ofs := V2{float64(sheet) * (n.Sheet.X + gap), 0}
+		// sheet outline
+		v := []V2{{0, 0}, {n.Sheet.X, 0}, {n.Sheet.X, n.Sheet.Y}, {0, n.Sheet.Y}}
+		for i := range v {
+			lines = append(lines, &Line{v[i].Add(ofs), v[(i+1)%len(v)].Add(ofs)})
+		}
+		// part contours
+		for i := range n.Placements {
+			p := &n.Placements[i]
+			if p.Sheet != sheet {
+				continue
+			}
+			m := Translate2d(ofs).Mul(p.Transform())
+			s := Transform2D(n.parts[p.Part], m)
+			bb := s.BoundingBox()
+			bb = bb.ScaleAboutCenter(1.01)
+			lines = append(lines, marchingSquares(s, bb, resolution)...)
+		}
+	}
+	return lines
+}
+
+// SaveDXF writes the layout to a DXF file.
+func (n *Nesting) SaveDXF(path string, resolution float64) error {
+	return SaveDXF(path, n.Lines(resolution))
+}
+
+// SaveSVG writes the layout to an SVG file.
+func (n *Nesting) SaveSVG(path string, resolution float64) error {
+	return SaveSVG(path, "fill:none;stroke:black;stroke-width:0.1", n.Lines(resolution))
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,200 @@
+//-----------------------------------------------------------------------------
+/*
+
+Surface Nets
+
+Convert an SDF3 to a quad mesh.
+
+Each grid cell that straddles the surface gets a single vertex (the mean of
+the surface crossings on the cell edges, projected onto the surface). Each grid
+edge that crosses the surface generates a quad joining the vertices of the 4
+cells sharing that edge. The result is an all-quad, grid aligned mesh suited to
+subdivision and simulation workflows.
+
+See: https://0fps.net/2012/07/12/smooth-voxel-terrain-part-2/
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+//-----------------------------------------------------------------------------
+
+// QuadMesh is a mesh of quadrilateral faces with shared vertices.
+type QuadMesh struct {
+	V []V3     // vertices
+	F [][4]int // quad faces (vertex indices, counter-clockwise from outside)
+}
+
+// Triangles returns the quad mesh as triangles (quads are split on the shorter diagonal).
+func (m *QuadMesh) Triangles() []*Triangle3 {
+	mesh := make([]*Triangle3, 0, 2*len(m.F))
+	for _, f := range m.F {
+		a, b, c, d := m.V[f[0]], m.V[f[1]], m.V[f[2]], m.V[f[3]]
+		if a.Sub(c).Length2() <= b.Sub(d).Length2() {
+			mesh = append(mesh, NewTriangle3(a, b, c), NewTriangle3(a, c, d))
+		} else {
+			mesh = append(mesh, NewTriangle3(a, b, d), NewTriangle3(b, c, d))
+		}
+	}
+	return mesh
+}
+
+// SaveOBJ writes a quad mesh to a Wavefront OBJ file.
+func (m *QuadMesh) SaveOBJ(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf := bufio.NewWriter(f)
+	for _, v := range m.V {
+		fmt.Fprintf(buf, "v %g %g %g\n", v.X, v.Y, v.Z)
+	}
+	for _, q := range m.F {
+		// obj indices are 1-based
+		fmt.Fprintf(buf, "f %d %d %d %d\n", q[0]+1, q[1]+1, q[2]+1, q[3]+1)
+	}
+	return buf.Flush()
+}
+
+//-----------------------------------------------------------------------------
+
+// surfaceNets generates a quad mesh for an SDF3 sampled on a uniform grid.
+func surfaceNets(s SDF3, box Box3, step float64) *QuadMesh {
+	size := box.Size()
+	steps := size.DivScalar(step).Ceil().ToV3i()
+	inc := size.Div(steps.ToV3())
+	nx, ny, nz := steps[0]+1, steps[1]+1, steps[2]+1
+
+	// sample the sdf on the grid points
+	gIndex := func(x, y, z int) int { return (x*ny+y)*nz + z }
+	gPoint := func(x, y, z int) V3 { return box.Min.Add(V3i{x, y, z}.ToV3().Mul(inc)) }
+	val := make([]float64, nx*ny*nz)
+	sampleGrid(s, box.Min, inc, V3i{nx, ny, nz}, func(i int, p V3, d float64) {
+		val[i] = d
+	})
+
+	// create a vertex for each cell straddling the surface
+	m := &QuadMesh{}
+	cIndex := func(x, y, z int) int { return (x*steps[1]+y)*steps[2] + z }
+	cell := make([]int, steps[0]*steps[1]*steps[2])
+	h := 1e-3 * inc.MinComponent()
+	for x := 0; x < steps[0]; x++ {
+		for y := 0; y < steps[1]; y++ {
+			for z := 0; z < steps[2]; z++ {
+				cell[cIndex(x, y, z)] = -1
+				var p [8]V3
+				var v [8]float64
+				for i := 0; i < 8; i++ {
+					dx, dy, dz := i&1, (i>>1)&1, (i>>2)&1
+					p[i] = gPoint(x+dx, y+dy, z+dz)
+					v[i] = val[gIndex(x+dx, y+dy, z+dz)]
+				}
+				// average the surface crossings on the cell edges
+				var sum V3
+				n := 0
+				for _, e := range snEdges {
+					a, b := e[0], e[1]
+					if (v[a] < 0) != (v[b] < 0) {
+						t := v[a] / (v[a] - v[b])
+						sum = sum.Add(p[a].Add(p[b].Sub(p[a]).MulScalar(t)))
+						n++
+					}
+				}
+				if n == 0 {
+					continue
+				}
+				q := sum.DivScalar(float64(n))
+				// project the vertex onto the surface (within the cell)
+				q = q.Sub(Gradient3(s, q, h).MulScalar(s.Evaluate(q)))
+				q = q.Clamp(p[0], p[7])
+				cell[cIndex(x, y, z)] = len(m.V)
+				m.V = append(m.V, q)
+			}
+		}
+	}
+
+	// create a quad for each grid edge crossing the surface
+	quad := func(inside bool, a, b, c, d int) {
+		if a < 0 || b < 0 || c < 0 || d < 0 {
+			return
+		}
+		if inside {
+			m.F = append(m.F, [4]int{a, b, c, d})
+		} else {
+			m.F = append(m.F, [4]int{d, c, b, a})
+		}
+	}
+	for x := 0; x < nx; x++ {
+		for y := 0; y < ny; y++ {
+			for z := 0; z < nz; z++ {
+				v0 := val[gIndex(x, y, z)]
+				inside := v0 < 0
+				// x-edge: cells in the y-z plane
+				if x < steps[0] && y > 0 && z > 0 && y < steps[1] && z < steps[2] {
+					if inside != (val[gIndex(x+1, y, z)] < 0) {
+						quad(inside, cell[cIndex(x, y-1, z-1)], cell[cIndex(x, y, z-1)], cell[cIndex(x, y, z)], cell[cIndex(x, y-1, z)])
+					}
+				}
+				// y-edge: cells in the z-x plane
+				if y < steps[1] && z > 0 && x > 0 && z < steps[2] && x < steps[0] {
+					if inside != (val[gIndex(x, y+1, z)] < 0) {
+						quad(inside, cell[cIndex(x-1, y, z-1)], cell[cIndex(x-1, y, z)], cell[cIndex(x, y, z)], cell[cIndex(x, y, z-1)])
+					}
+				}
+				// z-edge: cells in the x-y plane
+				if z < steps[2] && x > 0 && y > 0 && x < steps[0] && y < steps[1] {
+					if inside != (val[gIndex(x, y, z+1)] < 0) {
+						quad(inside, cell[cIndex(x-1, y-1, z)], cell[cIndex(x, y-1, z)], cell[cIndex(x, y, z)], cell[cIndex(x-1, y, z)])
+					}
+				}
+			}
+		}
+	}
+	return m
+}
+
+// snEdges are the cell edges as pairs of corner indices (corner i = x + 2y + 4z).
+var snEdges = [12][2]int{
+	{0, 1}, {2, 3}, {4, 5}, {6, 7}, // x-edges
+	{0, 2}, {1, 3}, {4, 6}, {5, 7}, // y-edges
+	{0, 4}, {1, 5}, {2, 6}, {3, 7}, // z-edges
+}
+
+//-----------------------------------------------------------------------------
+
+// QuadMesh3D returns a quad mesh for an SDF3 (uses surface nets on a uniform grid).
+func QuadMesh3D(
+	s SDF3, //sdf3 to mesh
+	meshCells int, //number of cells on the longest axis. e.g 200
+) *QuadMesh {
+	// work out the region we will sample
+	bb0 := s.BoundingBox()
+	bb0Size := bb0.Size()
+	meshInc := bb0Size.MaxComponent() / float64(meshCells)
+	bb1Size := bb0Size.DivScalar(meshInc)
+	bb1Size = bb1Size.Ceil().AddScalar(1)
+	bb1Size = bb1Size.MulScalar(meshInc)
+	bb := NewBox3(bb0.Center(), bb1Size)
+	return surfaceNets(s, bb, meshInc)
+}
+
+// RenderOBJQuads renders an SDF3 as a quad mesh OBJ file.
+func RenderOBJQuads(
+	s SDF3, //sdf3 to render
+	meshCells int, //number of cells on the longest axis. e.g 200
+	path string, //path to filename
+) error {
+	m := QuadMesh3D(s, meshCells)
+	fmt.Printf("rendering %s (%d vertices, %d quads)\n", path, len(m.V), len(m.F))
+	return m.SaveOBJ(path)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,253 @@
+//-----------------------------------------------------------------------------
+/*
+
+Non-Circular Gears
+
+The pitch curve is a closed polar curve r(theta) about the center of
+rotation. The teeth are spaced evenly along the pitch curve (the circular
+pitch is the length of the curve divided by the number of teeth) and are
+generated by a standard rack rolling without slip on the pitch curve. So the
+tooth flanks are locally correct: each flank point is where the rack flank
+touches it, with the common normal through the pitch point.
+
+Addendum = module, dedendum = 1.25 module, measured normal to the pitch curve.
+The root fillet is the trochoid cut by the sharp corner of the rack tip. To
+avoid undercut the radius of curvature of the convex parts of the pitch curve
+must be at least 1.25 module / sin^2(pressure angle) (about 21 teeth on a
+circular gear with a 20 degree pressure angle).
+
+Elliptical Gears
+
+An ellipse rotating about one of its foci meshes with an identical ellipse
+rotating about its focus, with a center distance of twice the semi-major
+axis. The speed ratio varies over a turn between (1-e)/(1+e) and (1+e)/(1-e)
+(e = eccentricity). The gears mesh with their pitch curves touching on the
+line of centers, E.g. the closest point of one (theta = 0) touches the
+furthest point of the other (theta = pi). The teeth are centered on arc
+length 0, so use an odd number of teeth for a pair of identical gears.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// pitchCurve is a closed polar curve parameterized by arc length.
+type pitchCurve struct {
+	r      func(theta float64) float64 // polar radius
+	theta  []float64                   // sample angles
+	s      []float64                   // arc length at each sample angle
+	length float64                     // length of the curve
+}
+
+// newPitchCurve returns a pitch curve for a polar radius function.
+func newPitchCurve(r func(theta float64) float64, n int) (*pitchCurve, error) {
+	c := pitchCurve{r: r}
+	var p0 V2
+	for i := 0; i <= n; i++ {
+		theta := Tau * float64(i) / float64(n)
+		ri := r(theta)
+		if ri <= 0 || math.IsNaN(ri) || math.IsInf(ri, 0) {
+			return nil, errors.New("bad pitch curve radius")
+		}
+		p := PolarToXY(ri, theta)
+		if i > 0 {
+			c.length += p.Sub(p0).Length()
+		}
+		c.theta = append(c.theta, theta)
+		c.s = append(c.s, c.length)
+		p0 = p
+	}
+	return &c, nil
+}
+
+// angle returns the polar angle at an arc length.
+func (c *pitchCurve) angle(s float64) float64 {
+	s = math.Mod(s, c.length)
+	if s < 0 {
+		s += c.length
+	}
+	i := sort.SearchFloat64s(c.s, s)
+	if i == 0 {
+		return 0
+	}
+	x := (s - c.s[i-1]) / (c.s[i] - c.s[i-1])
+	return Mix(c.theta[i-1], c.theta[i], x)
+}
+
+// derivatives returns the polar radius and its first and second derivatives.
+func (c *pitchCurve) derivatives(theta float64) (r, dr, ddr float64) {
+	const h = 1e-4
+	r = c.r(theta)
+	r0, r1 := c.r(theta-h), c.r(theta+h)
+	return r, (r1 - r0) / (2 * h), (r1 - 2*r + r0) / (h * h)
+}
+
+// frame returns the point, unit tangent and outward unit normal at an arc length.
+func (c *pitchCurve) frame(s float64) (p, t, n V2) {
+	theta := c.angle(s)
+	r, dr, _ := c.derivatives(theta)
+	sin, cos := math.Sincos(theta)
+	p = V2{r * cos, r * sin}
+	t = V2{dr*cos - r*sin, dr*sin + r*cos}.Normalize()
+	n = V2{t.Y, -t.X}
+	return
+}
+
+// minRadius returns the minimum radius of curvature of the convex parts of the curve
+// (+Inf if there are none).
+func (c *pitchCurve) minRadius() float64 {
+	rho := math.Inf(1)
+	for _, theta := range c.theta {
+		r, dr, ddr := c.derivatives(theta)
+		k := r*r + 2*dr*dr - r*ddr
+		if k > 0 {
+			rho = Min(rho, math.Pow(r*r+dr*dr, 1.5)/k)
+		}
+	}
+	return rho
+}
+
+//-----------------------------------------------------------------------------
+
+// NonCircularGearParms defines the parameters for a non-circular gear.
+type NonCircularGearParms struct {
+	Teeth         int     // number of teeth
+	PressureAngle float64 // pressure angle of the generating rack (radians)
+	Backlash      float64 // reduction of the tooth thickness at the pitch curve
+	Facets        int     // number of facets for each tooth flank
+}
+
+// NonCircularGear2D returns the 2D profile of a gear with a closed polar pitch curve r(theta)
+// about the origin (the center of rotation). The module is set by the length of the pitch curve
+// and the number of teeth. The teeth are centered on the arc lengths (along the pitch curve, from
+// theta = 0) i * circular pitch.
+func NonCircularGear2D(
+	pitch func(theta float64) float64, // pitch curve polar radius
+	k *NonCircularGearParms,
+) (SDF2, error) {
+	if pitch == nil {
+		return nil, errors.New("nil pitch curve")
+	}
+	if k.Teeth < 8 {
+		return nil, errors.New("teeth < 8")
+	}
+	if k.PressureAngle <= 0 || k.PressureAngle >= DtoR(35) {
+		return nil, errors.New("bad pressure angle")
+	}
+	if k.Facets <= 0 {
+		return nil, errors.New("facets <= 0")
+	}
+	c, err := newPitchCurve(pitch, 128*k.Teeth)
+	if err != nil {
+		return nil, err
+	}
+	p := c.length / float64(k.Teeth) // circular pitch
+	m := p / Pi                      // module
+	if k.Backlash < 0 || k.Backlash >= 0.25*p {
+		return nil, errors.New("bad backlash")
+	}
+	sin, cos := math.Sincos(k.PressureAngle)
+	// the rack generates an undercut where the convex radius of curvature is too small
+	if c.minRadius()*sin*sin < 1.25*m {
+		return nil, errors.New("the pitch curve is too sharply curved for the tooth size (undercut)")
+	}
+
+	// point in the pitch curve frame at arc length s
+	point := func(s float64, x, y float64) V2 {
+		p, t, n := c.frame(s)
+		return p.Add(t.MulScalar(x)).Add(n.MulScalar(y))
+	}
+	h := 1.25 * m                // dedendum
+	dt := m / (sin * cos)        // rack offset for contact at the tip
+	dr := h / (sin * cos)        // rack offset for contact at the bottom of the rack flank
+	e := m * sin / cos           // arc length offset of the rack flank at the tip line
+	f := h * sin / cos           // arc length offset of the rack flank at the root line
+	w := 0.25*p - 0.5*k.Backlash // half tooth thickness
+	// flank crossing the pitch curve at arc length x0, from the root to the tip
+	// side = 1 for the right flank (rack flank normal (cos, sin)), -1 for the left
+	flank := func(x0, side float64) []V2 {
+		v := make([]V2, k.Facets+1)
+		for i := range v {
+			d := side * Mix(-dr, dt, float64(i)/float64(k.Facets))
+			v[i] = point(x0-d, d*cos*cos, side*d*sin*cos)
+		}
+		return v
+	}
+	// fillet (trochoid) cut by the rack tip corner, from the root up to the flank
+	fillet := func(x0, side float64) []V2 {
+		xc := x0 + side*f
+		n := k.Facets/2 + 1
+		v := make([]V2, n)
+		for i := range v {
+			s := xc + side*(dr-f)*float64(i)/float64(n)
+			v[i] = point(s, xc-s, -h)
+		}
+		return v
+	}
+	// points on the pitch curve offset by y, between (not including) arc lengths s0 and s1
+	offset := func(s0, s1, y float64, n int) []V2 {
+		v := make([]V2, n-1)
+		for i := range v {
+			v[i] = point(Mix(s0, s1, float64(i+1)/float64(n)), 0, y)
+		}
+		return v
+	}
+	reverse := func(v []V2) []V2 {
+		r := make([]V2, len(v))
+		for i := range v {
+			r[len(v)-1-i] = v[i]
+		}
+		return r
+	}
+
+	n := k.Facets/2 + 2
+	var v []V2
+	for i := 0; i < k.Teeth; i++ {
+		s := p * float64(i)
+		// left fillet and flank, tip, right flank and fillet, root
+		v = append(v, fillet(s-w, -1)...)
+		v = append(v, flank(s-w, -1)...)
+		v = append(v, offset(s-w+e, s+w-e, m, n)...)
+		v = append(v, reverse(flank(s+w, 1))...)
+		v = append(v, reverse(fillet(s+w, 1))...)
+		v = append(v, offset(s+w+f, s+p-w-f, -h, n)...)
+	}
+	return Polygon2D(v), nil
+}
+
+// EllipsePitch returns the polar radius function of an ellipse about its focus (at the origin),
+// with the closest point on the +x axis.
+func EllipsePitch(
+	a float64, // semi-major axis
+	e float64, // eccentricity (0 = circle)
+) func(theta float64) float64 {
+	return func(theta float64) float64 {
+		return a * (1 - e*e) / (1 + e*math.Cos(theta))
+	}
+}
+
+// EllipticalGear2D returns the 2D profile of an elliptical gear rotating about a focus (at the
+// origin). A pair of identical gears meshes with a center distance of 2a.
+func EllipticalGear2D(
+	a float64, // semi-major axis of the pitch curve
+	e float64, // eccentricity of the pitch curve (0 = circle)
+	k *NonCircularGearParms,
+) (SDF2, error) {
+	if a <= 0 {
+		return nil, errors.New("semi-major axis <= 0")
+	}
+	if e < 0 || e >= 1 {
+		return nil, errors.New("bad eccentricity")
+	}
+	return NonCircularGear2D(EllipsePitch(a, e), k)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,102 @@
+//-----------------------------------------------------------------------------
+/*
+
+Distance Field Normalization
+
+Some operations (scaling, twisting, morphing) distort the distance field so
+the value returned by Evaluate can be larger than the true distance to the
+surface. That breaks anything relying on the value being a lower bound
+(octree rendering, offsets, sphere tracing) and shows up as surface artifacts.
+
+If the field changes by at most "factor" per unit distance (the Lipschitz
+constant) then dividing the value by factor restores a valid lower bound.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// NormalizeSDF3 is an SDF3 with a rescaled distance value.
+type NormalizeSDF3 struct {
+	sdf SDF3
+	k   float64
+}
+
+// Normalize3D returns an SDF3 with the distance divided by factor.
+// Use the maximum rate of change of the distance field as the factor.
+func Normalize3D(sdf SDF3, factor float64) SDF3 {
+	if factor <= 0 {
+		panic("factor <= 0")
+	}
+	return &NormalizeSDF3{sdf, 1 / factor}
+}
+
+// Evaluate returns the minimum distance to a normalized SDF3.
+func (s *NormalizeSDF3) Evaluate(p V3) float64 {
+	return s.sdf.Evaluate(p) * s.k
+}
+
+// BoundingBox returns the bounding box of a normalized SDF3.
+func (s *NormalizeSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+// Children returns the child nodes of a normalized SDF3.
+func (s *NormalizeSDF3) Children() []interface{} {
+	return []interface{}{s.sdf}
+}
+
+//-----------------------------------------------------------------------------
+
+// NormalizeSDF2 is an SDF2 with a rescaled distance value.
+type NormalizeSDF2 struct {
+	sdf SDF2
+	k   float64
+}
+
+// Normalize2D returns an SDF2 with the distance divided by factor.
+// Use the maximum rate of change of the distance field as the factor.
+func Normalize2D(sdf SDF2, factor float64) SDF2 {
+	if factor <= 0 {
+		panic("factor <= 0")
+	}
+	return &NormalizeSDF2{sdf, 1 / factor}
+}
+
+// Evaluate returns the minimum distance to a normalized SDF2.
+func (s *NormalizeSDF2) Evaluate(p V2) float64 {
+	return s.sdf.Evaluate(p) * s.k
+}
+
+// BoundingBox returns the bounding box of a normalized SDF2.
+func (s *NormalizeSDF2) BoundingBox() Box2 {
+	return s.sdf.BoundingBox()
+}
+
+// Children returns the child nodes of a normalized SDF2.
+func (s *NormalizeSDF2) Children() []interface{} {
+	return []interface{}{s.sdf}
+}
+
+//-----------------------------------------------------------------------------
+
+// extrudeLipschitz returns the distance correction factor for a scaled/twisted extrusion.
+// The factor is valid for points within radius r of the extrusion axis.
+func extrudeLipschitz(height, twist float64, scale V2, r float64) float64 {
+	k := twist / height
+	inv := V2{1 / scale.X, 1 / scale.Y}.Abs()
+	// maximum xy scaling over the height of the extrusion
+	smax := Max(1, Max(inv.X, inv.Y))
+	// maximum rate of change of the xy scaling with z
+	m := inv.SubScalar(1).Abs().DivScalar(height)
+	mmax := Max(m.X, m.Y)
+	// the z derivative of the projected point
+	dz := r * (Abs(k)*smax + mmax)
+	return math.Sqrt(smax*smax + dz*dz)
+}
+
+//-----------------------------------------------------------------------------
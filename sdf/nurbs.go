@@ -0,0 +1,221 @@
+//-----------------------------------------------------------------------------
+/*
+
+NURBS Curves
+
+Non-uniform rational B-splines, as used by parametric CAD (DXF SPLINE
+entities, STEP B_SPLINE_CURVE_WITH_KNOTS). A curve is defined by its degree,
+control points, weights and knot vector. With all weights equal the curve is a
+(non-rational) B-spline. Rational curves represent conics exactly, E.g. a
+circle is a degree 2 curve with 9 control points.
+
+Curves are evaluated with de Boor's algorithm (in homogeneous coordinates)
+and tessellated with a chord tolerance. Each knot span is sampled separately,
+so the corners at knots with full multiplicity are kept.
+
+A profile is made from one or more curves joined end to end into a closed
+loop. The curves are reversed as needed, so the edges of a loop exported
+from CAD can be used in any direction.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+
+// NURBS is a 2d non-uniform rational B-spline curve.
+type NURBS struct {
+	degree int       // degree of the curve
+	p      []V3      // control points in homogeneous coordinates (wx, wy, w)
+	knots  []float64 // knot vector
+}
+
+// NewNURBS returns a NURBS curve. With nil weights all the weights are 1 (a B-spline).
+// With nil knots the knot vector is uniform and clamped, so the curve starts and ends
+// on the first and last control points.
+func NewNURBS(
+	degree int, // degree of the curve (E.g. 3 for a cubic)
+	control []V2, // control points
+	weights []float64, // control point weights (nil = all 1)
+	knots []float64, // knot vector, len(control) + degree + 1 values (nil = clamped uniform)
+) (*NURBS, error) {
+	n := len(control)
+	if degree < 1 {
+		return nil, errors.New("degree < 1")
+	}
+	if n < degree+1 {
+		return nil, fmt.Errorf("a degree %d curve needs at least %d control points", degree, degree+1)
+	}
+	if weights != nil && len(weights) != n {
+		return nil, errors.New("the number of weights != the number of control points")
+	}
+	if knots == nil {
+		knots = ClampedKnots(n, degree)
+	}
+	if len(knots) != n+degree+1 {
+		return nil, fmt.Errorf("the knot vector needs %d values", n+degree+1)
+	}
+	for i := 1; i < len(knots); i++ {
+		if knots[i] < knots[i-1] {
+			return nil, errors.New("the knot vector is decreasing")
+		}
+	}
+	if knots[degree] >= knots[n] {
+		return nil, errors.New("the curve has an empty domain")
+	}
+	c := NURBS{
+		degree: degree,
+		p:      make([]V3, n),
+		knots:  append([]float64(nil), knots...),
+	}
+	for i, v := range control {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+			if w <= 0 {
+				return nil, errors.New("weight <= 0")
+			}
+		}
+		c.p[i] = V3{v.X * w, v.Y * w, w}
+	}
+	return &c, nil
+}
+
+// ClampedKnots returns a uniform knot vector on [0,1] for n control points, with the end
+// knots repeated so the curve starts and ends on the first and last control points.
+func ClampedKnots(n, degree int) []float64 {
+	k := make([]float64, n+degree+1)
+	m := n - degree // number of knot spans
+	for i := range k {
+		k[i] = Clamp(float64(i-degree)/float64(m), 0, 1)
+	}
+	return k
+}
+
+// Domain returns the parameter range of the curve.
+func (c *NURBS) Domain() (float64, float64) {
+	return c.knots[c.degree], c.knots[len(c.p)]
+}
+
+// span returns the index of the knot span containing t.
+func (c *NURBS) span(t float64) int {
+	lo, hi := c.degree, len(c.p)-1
+	if t >= c.knots[hi+1] {
+		// the last non-empty span
+		for c.knots[hi] == c.knots[hi+1] {
+			hi--
+		}
+		return hi
+	}
+	// binary search for knots[k] <= t < knots[k+1]
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if c.knots[mid] <= t {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// Point returns the point on the curve at parameter t (clamped to the domain).
+func (c *NURBS) Point(t float64) V2 {
+	t0, t1 := c.Domain()
+	t = Clamp(t, t0, t1)
+	// de Boor's algorithm
+	p := c.degree
+	k := c.span(t)
+	d := make([]V3, p+1)
+	copy(d, c.p[k-p:k+1])
+	for r := 1; r <= p; r++ {
+		for j := p; j >= r; j-- {
+			i := j + k - p
+			a := (t - c.knots[i]) / (c.knots[i+1+p-r] - c.knots[i])
+			d[j] = d[j-1].MulScalar(1 - a).Add(d[j].MulScalar(a))
+		}
+	}
+	return V2{d[p].X / d[p].Z, d[p].Y / d[p].Z}
+}
+
+// Polyline returns points on the curve, from the start to the end, with the line segments
+// between them within a chord tolerance of the curve.
+func (c *NURBS) Polyline(tol float64) []V2 {
+	t0, t1 := c.Domain()
+	points := []V2{c.Point(t0)}
+	// sample each knot span
+	a := t0
+	for _, b := range c.knots {
+		if b <= a || b > t1 {
+			continue
+		}
+		points = append(points, AdaptiveCurve(c.Point, a, b, tol)[1:]...)
+		a = b
+	}
+	return points
+}
+
+//-----------------------------------------------------------------------------
+
+// NURBS2D returns an SDF2 bounded by curves joined end to end into a closed loop.
+// The curves are reversed as needed to join them. The boundary is within the
+// tolerance of the curves, and the curve ends must meet within the tolerance.
+func NURBS2D(curves []*NURBS, tol float64) (SDF2, error) {
+	if len(curves) == 0 {
+		return nil, errors.New("no curves")
+	}
+	if tol <= 0 {
+		return nil, errors.New("tolerance <= 0")
+	}
+	lines := make([][]V2, len(curves))
+	for i, c := range curves {
+		lines[i] = c.Polyline(tol)
+	}
+	// the first curve is reversed if its start joins the second curve
+	if len(lines) > 1 {
+		v := lines[0]
+		if joinDistance(v[0], lines[1]) < joinDistance(v[len(v)-1], lines[1]) {
+			reverseV2(v)
+		}
+	}
+	loop := lines[0]
+	for i, v := range lines[1:] {
+		end := loop[len(loop)-1]
+		if v[len(v)-1].Sub(end).Length() < v[0].Sub(end).Length() {
+			reverseV2(v)
+		}
+		if v[0].Sub(end).Length() > tol {
+			return nil, fmt.Errorf("curve %d doesn't join the previous curve", i+1)
+		}
+		loop = append(loop, v[1:]...)
+	}
+	n := len(loop)
+	if loop[0].Sub(loop[n-1]).Length() > tol {
+		return nil, errors.New("the curves don't make a closed loop")
+	}
+	loop = loop[:n-1]
+	if len(loop) < 3 {
+		return nil, errors.New("the loop has no area")
+	}
+	return Polygon2D(loop), nil
+}
+
+// joinDistance returns the distance from a point to the nearest end of a polyline.
+func joinDistance(p V2, v []V2) float64 {
+	return Min(p.Sub(v[0]).Length(), p.Sub(v[len(v)-1]).Length())
+}
+
+// reverseV2 reverses a slice of points in place.
+func reverseV2(v []V2) {
+	for i, j := 0, len(v)-1; i < j; i, j = i+1, j-1 {
+		v[i], v[j] = v[j], v[i]
+	}
+}
+
+//-----------------------------------------------------------------------------
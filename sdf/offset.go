@@ -0,0 +1,145 @@
+//-----------------------------------------------------------------------------
+/*
+
+Exact Offsets
+
+Offset2D subtracts the offset from the distance, so it's only correct when
+the SDF2 is an exact distance function. Many SDF2s aren't (scaled shapes,
+smooth blends, normalized or displaced fields) and their offsets are
+distorted.
+
+ExactOffset2D re-polygonizes instead:
+
+1) The boundary of the SDF2 is polygonized (Polygonize2D).
+2) The exact distance to the polygons is offset, and the result is
+polygonized again.
+3) The result is the exact distance to the offset polygons.
+
+The boundary is within the tolerance of the true offset, and the distance
+field is exact for the polygons. Sharp features smaller than the mesh cells
+are lost.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// LoopsSDF2 is an SDF2 bounded by a set of closed polygons (E.g. a shape with holes).
+type LoopsSDF2 struct {
+	a  []V2      // start of each line segment
+	u  []V2      // normalized line segment vectors
+	l  []float64 // line segment lengths
+	bb Box2      // bounding box
+}
+
+// Loops2D returns an SDF2 bounded by a set of closed polygons. The inside is given by
+// the non-zero winding rule, so holes have the opposite winding to their outer boundary
+// (as returned by Polygonize2D).
+func Loops2D(loops [][]V2) (SDF2, error) {
+	s := LoopsSDF2{}
+	var all V2Set
+	for _, v := range loops {
+		v = openVertices(v, true)
+		if len(v) < 3 {
+			return nil, errors.New("polygon needs at least 3 vertices")
+		}
+		for i, a := range v {
+			d := v[(i+1)%len(v)].Sub(a)
+			if d.Length() == 0 {
+				continue
+			}
+			s.a = append(s.a, a)
+			s.u = append(s.u, d.Normalize())
+			s.l = append(s.l, d.Length())
+		}
+		all = append(all, v...)
+	}
+	if len(s.a) == 0 {
+		return nil, errors.New("no polygons")
+	}
+	s.bb = Box2{all.Min(), all.Max()}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a set of closed polygons.
+func (s *LoopsSDF2) Evaluate(p V2) float64 {
+	dd := math.MaxFloat64 // d^2 to the polygons
+	wn := 0               // winding number
+	for i, a := range s.a {
+		u := s.u[i]
+		pa := p.Sub(a)
+		t := Clamp(pa.Dot(u), 0, s.l[i])
+		dd = Min(dd, pa.Sub(u.MulScalar(t)).Length2())
+		// See: http://geomalgorithms.com/a03-_inclusion.html
+		b := a.Add(u.MulScalar(s.l[i]))
+		left := u.Cross(pa)
+		if a.Y <= p.Y {
+			if b.Y > p.Y && left > 0 {
+				wn++
+			}
+		} else if b.Y <= p.Y && left < 0 {
+			wn--
+		}
+	}
+	d := math.Sqrt(dd)
+	if wn != 0 {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a set of closed polygons.
+func (s *LoopsSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
+// ExactOffset2D returns an SDF2 offset outwards (offset > 0) or inwards (offset < 0) with an
+// exact distance field. The boundary is within the tolerance of the true offset boundary.
+func ExactOffset2D(
+	s SDF2, // sdf2 to offset
+	offset float64, // offset distance
+	meshCells int, // number of cells on the longest axis. e.g 200
+	tol float64, // boundary tolerance
+) (SDF2, error) {
+	if s == nil {
+		return nil, errors.New("nil sdf")
+	}
+	// half the tolerance for each polygonization
+	loops, err := Polygonize2D(s, meshCells, 0.5*tol)
+	if err != nil {
+		return nil, err
+	}
+	if len(loops) == 0 {
+		return nil, errors.New("the sdf has no boundary")
+	}
+	s0, err := Loops2D(loops)
+	if err != nil {
+		return nil, err
+	}
+	if offset == 0 {
+		return s0, nil
+	}
+	size := s0.BoundingBox().Size()
+	if offset < 0 && 2*offset <= -size.MaxComponent() {
+		return nil, errors.New("the offset removes the shape")
+	}
+	loops, err = Polygonize2D(Offset2D(s0, offset), meshCells, 0.5*tol)
+	if err != nil {
+		return nil, err
+	}
+	if len(loops) == 0 {
+		return nil, errors.New("the offset removes the shape")
+	}
+	return Loops2D(loops)
+}
+
+//-----------------------------------------------------------------------------
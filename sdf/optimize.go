@@ -0,0 +1,502 @@
+//-----------------------------------------------------------------------------
+/*
+
+SDF3 Tree Optimizer
+
+Rewrite an SDF3 tree into an equivalent tree that is faster to evaluate.
+
+* Chains of transforms are folded into a single transform.
+* Identity transforms are removed.
+* Nested unions are flattened into a single union with a bounding volume
+  hierarchy, so distant children are skipped during evaluation.
+* Union children with an empty bounding box are removed.
+* Difference operands that don't overlap the object being cut are removed.
+* Subtrees that are referenced more than once are wrapped in a cache node.
+
+The optimizer descends through transforms, booleans and the other single
+child operations in this package. Other nodes are left as they are.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+//-----------------------------------------------------------------------------
+// Bounding Volume Hierarchy Union
+
+// bvhNode is a node in a bounding volume hierarchy.
+type bvhNode struct {
+	bb          Box3     // bounding box for this node
+	sdf         SDF3     // sdf for a leaf node
+	left, right *bvhNode // child nodes
+}
+
+// newBVH returns a bounding volume hierarchy for a set of SDF3s.
+func newBVH(sdf []SDF3) *bvhNode {
+	bb := sdf[0].BoundingBox()
+	for _, s := range sdf {
+		bb = bb.Extend(s.BoundingBox())
+	}
+	if len(sdf) == 1 {
+		return &bvhNode{bb: bb, sdf: sdf[0]}
+	}
+	// split the set on the longest axis of the bounding box centers
+	c := sdf[0].BoundingBox().Center()
+	cbb := Box3{c, c}
+	for _, s := range sdf {
+		c = s.BoundingBox().Center()
+		cbb = cbb.Extend(Box3{c, c})
+	}
+	size := cbb.Size()
+	axis := func(v V3) float64 { return v.X }
+	if size.Y >= size.X && size.Y >= size.Z {
+		axis = func(v V3) float64 { return v.Y }
+	} else if size.Z >= size.X && size.Z >= size.Y {
+		axis = func(v V3) float64 { return v.Z }
+	}
+	sorted := make([]SDF3, len(sdf))
+	copy(sorted, sdf)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return axis(sorted[i].BoundingBox().Center()) < axis(sorted[j].BoundingBox().Center())
+	})
+	k := len(sorted) / 2
+	return &bvhNode{
+		bb:    bb,
+		left:  newBVH(sorted[:k]),
+		right: newBVH(sorted[k:]),
+	}
+}
+
+// boxDistance returns the distance from a point to a box (0 within the box).
+func boxDistance(bb Box3, p V3) float64 {
+	return p.Sub(p.Clamp(bb.Min, bb.Max)).Length()
+}
+
+// evaluate returns the minimum of d and the distance to the sdfs within the node.
+func (n *bvhNode) evaluate(p V3, d float64) float64 {
+	if n.sdf != nil {
+		return Min(d, n.sdf.Evaluate(p))
+	}
+	// visit the closest node first
+	a, b := n.left, n.right
+	da, db := boxDistance(a.bb, p), boxDistance(b.bb, p)
+	if db < da {
+		a, b = b, a
+		da, db = db, da
+	}
+	// an sdf can't be closer than its bounding box, but p may be inside it
+	if da == 0 || da < d {
+		d = a.evaluate(p, d)
+	}
+	if db == 0 || db < d {
+		d = b.evaluate(p, d)
+	}
+	return d
+}
+
+// BVHUnionSDF3 is a union of SDF3s using a bounding volume hierarchy.
+type BVHUnionSDF3 struct {
+	sdf  []SDF3
+	root *bvhNode
+}
+
+// BVHUnion3D returns the union of multiple SDF3 objects.
+// Bounding boxes are used to skip the evaluation of distant objects.
+// This is useful for unions of many small objects.
+func BVHUnion3D(sdf ...SDF3) SDF3 {
+	s := BVHUnionSDF3{}
+	// strip out any nils
+	for _, x := range sdf {
+		if x != nil {
+			s.sdf = append(s.sdf, x)
+		}
+	}
+	if len(s.sdf) == 0 {
+		return nil
+	}
+	if len(s.sdf) == 1 {
+		// only one sdf - not really a union
+		return s.sdf[0]
+	}
+	s.root = newBVH(s.sdf)
+	return &s
+}
+
+// Evaluate returns the minimum distance to a BVH union.
+func (s *BVHUnionSDF3) Evaluate(p V3) float64 {
+	return s.root.evaluate(p, math.MaxFloat64)
+}
+
+// BoundingBox returns the bounding box of a BVH union.
+func (s *BVHUnionSDF3) BoundingBox() Box3 {
+	return s.root.bb
+}
+
+// Children returns the child nodes of a BVH union.
+func (s *BVHUnionSDF3) Children() []interface{} {
+	c := make([]interface{}, len(s.sdf))
+	for i, x := range s.sdf {
+		c[i] = x
+	}
+	return c
+}
+
+//-----------------------------------------------------------------------------
+// Cached SDF3
+
+// cacheSlots is the number of cached evaluations. Parallel renders evaluate
+// different points, so they mostly use different slots.
+const cacheSlots = 64
+
+// cacheSlot is a cached evaluation.
+type cacheSlot struct {
+	lock  sync.Mutex
+	p     V3
+	d     float64
+	valid bool
+}
+
+// CacheSDF3 is an SDF3 that caches recent evaluations.
+type CacheSDF3 struct {
+	sdf   SDF3
+	slots [cacheSlots]cacheSlot
+}
+
+// Cache3D returns an SDF3 that caches recent evaluations.
+// This is useful for a subtree that is evaluated repeatedly at the same point.
+func Cache3D(sdf SDF3) SDF3 {
+	return &CacheSDF3{sdf: sdf}
+}
+
+// slot returns the cache slot for a point.
+func (s *CacheSDF3) slot(p V3) *cacheSlot {
+	h := math.Float64bits(p.X)
+	h = h*31 ^ math.Float64bits(p.Y)
+	h = h*31 ^ math.Float64bits(p.Z)
+	h ^= h >> 32
+	h ^= h >> 16
+	return &s.slots[h%cacheSlots]
+}
+
+// Evaluate returns the minimum distance to a cached SDF3.
+func (s *CacheSDF3) Evaluate(p V3) float64 {
+	c := s.slot(p)
+	c.lock.Lock()
+	if c.valid && c.p == p {
+		d := c.d
+		c.lock.Unlock()
+		return d
+	}
+	c.lock.Unlock()
+	d := s.sdf.Evaluate(p)
+	c.lock.Lock()
+	c.p, c.d, c.valid = p, d, true
+	c.lock.Unlock()
+	return d
+}
+
+// BoundingBox returns the bounding box of a cached SDF3.
+func (s *CacheSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+// Children returns the child nodes of a cached SDF3.
+func (s *CacheSDF3) Children() []interface{} {
+	return []interface{}{s.sdf}
+}
+
+//-----------------------------------------------------------------------------
+
+// bvhThreshold is the minimum number of union children for a BVH union.
+const bvhThreshold = 4
+
+// sameFunc returns true if two functions are the same function.
+func sameFunc(f, g interface{}) bool {
+	return reflect.ValueOf(f).Pointer() == reflect.ValueOf(g).Pointer()
+}
+
+// isComparable returns true if an SDF3 can be used as a map key.
+func isComparable(s SDF3) bool {
+	return s != nil && reflect.TypeOf(s).Comparable()
+}
+
+// sameSDF3 returns true if two SDF3s are the same node.
+func sameSDF3(a, b SDF3) bool {
+	return isComparable(a) && isComparable(b) && a == b
+}
+
+// emptyBox returns true if a bounding box has zero volume.
+func emptyBox(bb Box3) bool {
+	s := bb.Size()
+	return s.X <= 0 || s.Y <= 0 || s.Z <= 0
+}
+
+// boxOverlap returns true if two bounding boxes overlap.
+func boxOverlap(a, b Box3) bool {
+	return a.Min.X <= b.Max.X && b.Min.X <= a.Max.X &&
+		a.Min.Y <= b.Max.Y && b.Min.Y <= a.Max.Y &&
+		a.Min.Z <= b.Max.Z && b.Min.Z <= a.Max.Z
+}
+
+type optimizer struct {
+	done  map[SDF3]SDF3 // rewritten nodes
+	refs  map[SDF3]int  // reference counts
+	cache map[SDF3]SDF3 // cached nodes
+}
+
+// Optimize3D returns an optimized, equivalent SDF3 tree.
+func Optimize3D(s SDF3) SDF3 {
+	o := optimizer{
+		done:  make(map[SDF3]SDF3),
+		refs:  make(map[SDF3]int),
+		cache: make(map[SDF3]SDF3),
+	}
+	s = o.rewrite(s)
+	o.count(s)
+	return o.hoist(s)
+}
+
+// rewrite returns an optimized version of an SDF3 node.
+// Shared nodes are rewritten once so they remain shared.
+func (o *optimizer) rewrite(s SDF3) SDF3 {
+	if !isComparable(s) {
+		return s
+	}
+	if x, ok := o.done[s]; ok {
+		return x
+	}
+	x := o.rewriteNode(s)
+	o.done[s] = x
+	return x
+}
+
+func (o *optimizer) rewriteNode(s SDF3) SDF3 {
+	switch n := s.(type) {
+	case *TransformSDF3:
+		child := o.rewrite(n.sdf)
+		matrix := n.matrix
+		// fold a chain of transforms
+		if t, ok := child.(*TransformSDF3); ok {
+			child = t.sdf
+			matrix = matrix.Mul(t.matrix)
+		}
+		if matrix.Equals(Identity3d(), epsilon) {
+			return child
+		}
+		if sameSDF3(child, n.sdf) {
+			return n
+		}
+		return Transform3D(child, matrix)
+
+	case *UnionSDF3:
+		if !sameFunc(n.min, Min) {
+			// blended union: the children can't be flattened
+			children := make([]SDF3, len(n.sdf))
+			changed := false
+			for i, x := range n.sdf {
+				children[i] = o.rewrite(x)
+				changed = changed || !sameSDF3(children[i], x)
+			}
+			if !changed {
+				return n
+			}
+			u := Union3D(children...)
+			u.(*UnionSDF3).SetMin(n.min)
+			return u
+		}
+		children := o.flatten(n.sdf, nil)
+		if len(children) >= bvhThreshold {
+			return BVHUnion3D(children...)
+		}
+		if len(children) == 0 {
+			// everything was empty
+			return n
+		}
+		return Union3D(children...)
+
+	case *DifferenceSDF3:
+		s0 := o.rewrite(n.s0)
+		s1 := o.rewrite(n.s1)
+		if sameFunc(n.max, Max) && !boxOverlap(s0.BoundingBox(), s1.BoundingBox()) {
+			// s1 doesn't cut s0, the surface is unchanged
+			return s0
+		}
+		if sameSDF3(s0, n.s0) && sameSDF3(s1, n.s1) {
+			return n
+		}
+		d := Difference3D(s0, s1)
+		d.(*DifferenceSDF3).SetMax(n.max)
+		return d
+
+	case *IntersectionSDF3:
+		s0 := o.rewrite(n.s0)
+		s1 := o.rewrite(n.s1)
+		if sameSDF3(s0, n.s0) && sameSDF3(s1, n.s1) {
+			return n
+		}
+		i := Intersect3D(s0, s1)
+		i.(*IntersectionSDF3).SetMax(n.max)
+		return i
+
+	// single child nodes: the child is equivalent, so copy the node
+	case *ScaleUniformSDF3:
+		if c := o.rewrite(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	case *ScaleSDF3:
+		if c := o.rewrite(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	case *CutSDF3:
+		if c := o.rewrite(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	case *ElongateSDF3:
+		if c := o.rewrite(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	case *ArraySDF3:
+		if c := o.rewrite(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	case *RotateUnionSDF3:
+		if c := o.rewrite(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	case *RotateCopySDF3:
+		if c := o.rewrite(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	case *ConnectedSDF3:
+		if c := o.rewrite(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	}
+	return s
+}
+
+// flatten returns the rewritten, non-empty children of nested unions.
+func (o *optimizer) flatten(sdf []SDF3, out []SDF3) []SDF3 {
+	for _, x := range sdf {
+		x = o.rewrite(x)
+		switch n := x.(type) {
+		case *UnionSDF3:
+			if sameFunc(n.min, Min) {
+				out = o.flatten(n.sdf, out)
+				continue
+			}
+		case *BVHUnionSDF3:
+			out = o.flatten(n.sdf, out)
+			continue
+		}
+		if emptyBox(x.BoundingBox()) {
+			continue
+		}
+		out = append(out, x)
+	}
+	return out
+}
+
+// count counts the references to each node in the tree.
+func (o *optimizer) count(s SDF3) {
+	if !isComparable(s) {
+		return
+	}
+	o.refs[s]++
+	if o.refs[s] > 1 {
+		// already counted the children
+		return
+	}
+	for _, c := range Children(s) {
+		if x, ok := c.(SDF3); ok {
+			o.count(x)
+		}
+	}
+}
+
+// hoist wraps the shared subtrees of the tree in cache nodes.
+func (o *optimizer) hoist(s SDF3) SDF3 {
+	if !isComparable(s) {
+		return s
+	}
+	if x, ok := o.cache[s]; ok {
+		return x
+	}
+	x := o.hoistNode(s)
+	if o.refs[s] > 1 && Children(s) != nil {
+		x = Cache3D(x)
+	}
+	o.cache[s] = x
+	return x
+}
+
+func (o *optimizer) hoistNode(s SDF3) SDF3 {
+	switch n := s.(type) {
+	case *TransformSDF3:
+		if c := o.hoist(n.sdf); !sameSDF3(c, n.sdf) {
+			x := *n
+			x.sdf = c
+			return &x
+		}
+	case *UnionSDF3:
+		if children, changed := o.hoistSet(n.sdf); changed {
+			x := *n
+			x.sdf = children
+			return &x
+		}
+	case *BVHUnionSDF3:
+		if children, changed := o.hoistSet(n.sdf); changed {
+			return BVHUnion3D(children...)
+		}
+	case *DifferenceSDF3:
+		s0, s1 := o.hoist(n.s0), o.hoist(n.s1)
+		if !sameSDF3(s0, n.s0) || !sameSDF3(s1, n.s1) {
+			x := *n
+			x.s0, x.s1 = s0, s1
+			return &x
+		}
+	case *IntersectionSDF3:
+		s0, s1 := o.hoist(n.s0), o.hoist(n.s1)
+		if !sameSDF3(s0, n.s0) || !sameSDF3(s1, n.s1) {
+			x := *n
+			x.s0, x.s1 = s0, s1
+			return &x
+		}
+	}
+	return s
+}
+
+func (o *optimizer) hoistSet(sdf []SDF3) ([]SDF3, bool) {
+	out := make([]SDF3, len(sdf))
+	changed := false
+	for i, x := range sdf {
+		out[i] = o.hoist(x)
+		changed = changed || !sameSDF3(out[i], x)
+	}
+	return out, changed
+}
+
+//-----------------------------------------------------------------------------
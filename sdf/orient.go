@@ -0,0 +1,182 @@
+//-----------------------------------------------------------------------------
+/*
+
+Print Orientation Optimization
+
+Find the rotation of a part that minimizes a weighted cost of support
+volume, overhang area and build height for 3d printing.
+
+Triangles facing downwards at more than the overhang angle from the vertical
+(and not on the build plate) need support. The support volume is the volume
+between the overhanging triangles and the build plate.
+
+Candidate build directions are evenly spread over the sphere (plus the 6 axis
+directions) and the best candidate is refined with a local search.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// PrintCost sets the weights of the components of the print orientation cost.
+// Each component is normalized, so the weights are relative.
+type PrintCost struct {
+	Support  float64 // weight for the support volume (relative to the bounding box volume)
+	Overhang float64 // weight for the overhang area (relative to the total area)
+	Height   float64 // weight for the build height (relative to the bounding box diagonal)
+	Angle    float64 // overhang angle from the vertical (radians), E.g. 45 degrees
+}
+
+// DefaultPrintCost is a reasonable default print cost.
+var DefaultPrintCost = PrintCost{
+	Support:  1.0,
+	Overhang: 0.5,
+	Height:   0.1,
+	Angle:    DtoR(45),
+}
+
+// Overhang returns the overhang area and support volume for a mesh printed with the up direction.
+func Overhang(mesh []*Triangle3, up V3, angle float64) (area, volume float64) {
+	up = up.Normalize()
+	zmin := math.MaxFloat64
+	zmax := -math.MaxFloat64
+	for _, t := range mesh {
+		for _, v := range t.V {
+			z := v.Dot(up)
+			zmin = Min(zmin, z)
+			zmax = Max(zmax, z)
+		}
+	}
+	// triangles within this height of the build plate are on the plate
+	eps := 1e-3 * (zmax - zmin)
+	k := -math.Sin(angle)
+	for _, t := range mesh {
+		n := t.V[1].Sub(t.V[0]).Cross(t.V[2].Sub(t.V[0]))
+		a := 0.5 * n.Length()
+		if a == 0 {
+			continue
+		}
+		nz := n.Dot(up) * 0.5 / a
+		if nz >= k {
+			// not an overhang
+			continue
+		}
+		h := (t.V[0].Dot(up)+t.V[1].Dot(up)+t.V[2].Dot(up))/3 - zmin
+		if h < eps {
+			// on the build plate
+			continue
+		}
+		area += a
+		volume += a * -nz * h
+	}
+	return area, volume
+}
+
+// cost returns the normalized print cost for a build direction.
+func (c *PrintCost) cost(mesh []*Triangle3, up V3, totalArea, size float64) float64 {
+	area, volume := Overhang(mesh, up, c.Angle)
+	zmin := math.MaxFloat64
+	zmax := -math.MaxFloat64
+	for _, t := range mesh {
+		for _, v := range t.V {
+			z := v.Dot(up)
+			zmin = Min(zmin, z)
+			zmax = Max(zmax, z)
+		}
+	}
+	return c.Support*volume/(size*size*size) + c.Overhang*area/totalArea + c.Height*(zmax-zmin)/size
+}
+
+// rotateToZ returns the rotation taking a direction to the +z axis.
+func rotateToZ(v V3) M44 {
+	v = v.Normalize()
+	z := V3{0, 0, 1}
+	axis := v.Cross(z)
+	if axis.Length() < epsilon {
+		if v.Z > 0 {
+			return Identity3d()
+		}
+		return RotateX(Pi)
+	}
+	return Rotate3d(axis, math.Acos(Clamp(v.Dot(z), -1, 1)))
+}
+
+// PrintOrientation returns the rotation minimizing the print cost of a mesh (and the cost).
+func PrintOrientation(
+	mesh []*Triangle3, // triangle mesh
+	c PrintCost, // print cost weights
+	samples int, // number of candidate build directions. e.g 200
+) (M44, float64) {
+	if len(mesh) == 0 {
+		return Identity3d(), 0
+	}
+	// normalization values
+	totalArea := 0.0
+	bb := mesh[0].BoundingBox()
+	for _, t := range mesh {
+		totalArea += 0.5 * t.V[1].Sub(t.V[0]).Cross(t.V[2].Sub(t.V[0])).Length()
+		bb = bb.Extend(t.BoundingBox())
+	}
+	size := bb.Size().Length()
+	if totalArea == 0 || size == 0 {
+		return Identity3d(), 0
+	}
+	// candidate directions: the axes and a fibonacci sphere
+	dirs := []V3{{0, 0, 1}, {0, 0, -1}, {1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}}
+	ga := Pi * (3 - math.Sqrt(5))
+	for i := 0; i < samples; i++ {
+		z := 1 - (2*float64(i)+1)/float64(samples)
+		r := math.Sqrt(1 - z*z)
+		theta := ga * float64(i)
+		dirs = append(dirs, V3{r * math.Cos(theta), r * math.Sin(theta), z})
+	}
+	best := dirs[0]
+	bestCost := c.cost(mesh, best, totalArea, size)
+	for _, d := range dirs[1:] {
+		if x := c.cost(mesh, d, totalArea, size); x < bestCost {
+			best, bestCost = d, x
+		}
+	}
+	// refine the best direction with a local search
+	h := math.Sqrt(4*Pi/float64(len(dirs))) / 2
+	for h > 1e-3 {
+		// tangent vectors at the current direction
+		t0 := best.Cross(V3{1, 0, 0})
+		if t0.Length() < 0.1 {
+			t0 = best.Cross(V3{0, 1, 0})
+		}
+		t0 = t0.Normalize()
+		t1 := best.Cross(t0)
+		moved := false
+		for _, t := range []V3{t0, t0.Neg(), t1, t1.Neg()} {
+			d := best.Add(t.MulScalar(h)).Normalize()
+			if x := c.cost(mesh, d, totalArea, size); x < bestCost {
+				best, bestCost, moved = d, x, true
+			}
+		}
+		if !moved {
+			h *= 0.5
+		}
+	}
+	return rotateToZ(best), bestCost
+}
+
+// PrintOrientation3D returns the rotation minimizing the print cost of an SDF3.
+func PrintOrientation3D(
+	s SDF3, // sdf3 to orient
+	meshCells int, // number of cells on the longest axis. e.g 100
+	c PrintCost, // print cost weights
+	samples int, // number of candidate build directions. e.g 200
+) M44 {
+	bb := s.BoundingBox()
+	step := bb.Size().MaxComponent() / float64(meshCells)
+	mesh := marchingCubes(s, bb.ScaleAboutCenter(1.05), step)
+	m, _ := PrintOrientation(mesh, c, samples)
+	return m
+}
+
+//-----------------------------------------------------------------------------
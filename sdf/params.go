@@ -0,0 +1,196 @@
+//-----------------------------------------------------------------------------
+/*
+
+Model Parameters
+
+A registry of the named parameters of a model, so they can be overridden
+without writing bespoke flag parsing in every main().
+
+Parameters are set from (highest priority first):
+
+1) command line flags: -name=value
+2) environment variables: <PREFIX>_<NAME>=value, E.g. GEAR_TEETH=20
+3) a JSON file: -params file.json, E.g. {"teeth": 20, "module": 0.5}
+4) the default value
+
+Usage:
+
+p := NewParams("gear")
+teeth := p.Int("teeth", 20, "number of teeth")
+module := p.Float("module", 1.0, "gear module")
+p.MustParse()
+... use *teeth and *module
+
+-dump-params prints the parameter values as JSON and exits, the output can be
+edited and used with -params.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// Params is a set of named model parameters.
+type Params struct {
+	name string        // model name, used as the environment variable prefix
+	fs   *flag.FlagSet // the parameters are flags
+	file string        // JSON parameter file
+	dump bool          // dump the parameters as JSON
+}
+
+// NewParams returns an empty parameter set for a model.
+func NewParams(name string) *Params {
+	p := &Params{
+		name: name,
+		fs:   flag.NewFlagSet(name, flag.ContinueOnError),
+	}
+	p.fs.StringVar(&p.file, "params", "", "read parameter values from a JSON file")
+	p.fs.BoolVar(&p.dump, "dump-params", false, "print the parameter values as JSON and exit")
+	return p
+}
+
+// Float adds a float64 parameter.
+func (p *Params) Float(name string, value float64, usage string) *float64 {
+	return p.fs.Float64(name, value, usage)
+}
+
+// Int adds an int parameter.
+func (p *Params) Int(name string, value int, usage string) *int {
+	return p.fs.Int(name, value, usage)
+}
+
+// Bool adds a bool parameter.
+func (p *Params) Bool(name string, value bool, usage string) *bool {
+	return p.fs.Bool(name, value, usage)
+}
+
+// String adds a string parameter.
+func (p *Params) String(name string, value string, usage string) *string {
+	return p.fs.String(name, value, usage)
+}
+
+// isParam returns true if a flag is a model parameter (rather than a control flag).
+func isParam(name string) bool {
+	return name != "params" && name != "dump-params"
+}
+
+// envName returns the environment variable name for a parameter.
+func (p *Params) envName(name string) string {
+	s := strings.ToUpper(p.name + "_" + name)
+	return strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// Parse sets the parameter values from the command line arguments, environment and JSON file.
+func (p *Params) Parse(args []string) error {
+	err := p.fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	// flags have priority
+	set := make(map[string]bool)
+	p.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	// JSON file
+	values := make(map[string]string)
+	if p.file != "" {
+		buf, err := ioutil.ReadFile(p.file)
+		if err != nil {
+			return err
+		}
+		var x map[string]interface{}
+		d := json.NewDecoder(bytes.NewReader(buf))
+		d.UseNumber()
+		if err := d.Decode(&x); err != nil {
+			return fmt.Errorf("%s: %s", p.file, err)
+		}
+		for k, v := range x {
+			if f := p.fs.Lookup(k); f == nil || !isParam(k) {
+				return fmt.Errorf("%s: unknown parameter \"%s\"", p.file, k)
+			}
+			values[k] = fmt.Sprint(v)
+		}
+	}
+	var setErr error
+	p.fs.VisitAll(func(f *flag.Flag) {
+		if setErr != nil || set[f.Name] || !isParam(f.Name) {
+			return
+		}
+		// environment variables override the JSON file
+		if s, ok := os.LookupEnv(p.envName(f.Name)); ok {
+			if err := f.Value.Set(s); err != nil {
+				setErr = fmt.Errorf("%s: %s", p.envName(f.Name), err)
+			}
+			return
+		}
+		if s, ok := values[f.Name]; ok {
+			if err := f.Value.Set(s); err != nil {
+				setErr = fmt.Errorf("%s: %s: %s", p.file, f.Name, err)
+			}
+		}
+	})
+	return setErr
+}
+
+// MustParse parses the program arguments. It exits on error, or after dumping the parameters.
+func (p *Params) MustParse() {
+	err := p.Parse(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(2)
+	}
+	if p.dump {
+		p.WriteJSON(os.Stdout)
+		os.Exit(0)
+	}
+}
+
+// Values returns the current parameter values as strings.
+func (p *Params) Values() map[string]string {
+	m := make(map[string]string)
+	p.fs.VisitAll(func(f *flag.Flag) {
+		if isParam(f.Name) {
+			m[f.Name] = f.Value.String()
+		}
+	})
+	return m
+}
+
+// WriteJSON writes the current parameter values as JSON.
+func (p *Params) WriteJSON(w io.Writer) error {
+	m := make(map[string]interface{})
+	p.fs.VisitAll(func(f *flag.Flag) {
+		if !isParam(f.Name) {
+			return
+		}
+		if g, ok := f.Value.(flag.Getter); ok {
+			m[f.Name] = g.Get()
+		}
+	})
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", buf)
+	return err
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,470 @@
+//-----------------------------------------------------------------------------
+/*
+
+Paths
+
+A path is a polyline parameterized by arc length (the distance along the
+path from the start). Build a 2D path from points, or with the polygon
+builder to get arcs, smoothed corners and Bezier curves. A 3D path is built
+from points.
+
+The points along the path, the tangent and the normal are queried by arc
+length. A 2D path normal is to the left of the tangent. A 3D path carries a
+rotation minimizing frame (the normal turns with the path without spinning
+about it), starting with the component of the x-axis normal to the path (the
+y-axis if the path starts along x).
+
+Paths are used by sweeps, chains, tubes, distributing copies along a path
+and text on a path. The tangent is constant on each polyline segment, so use
+closely spaced points (or a small chord tolerance) for smooth curves.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// pathLengths returns the path length at each point of a polyline (from a list of segment lengths).
+func pathLengths(l []float64) []float64 {
+	s := make([]float64, len(l)+1)
+	for i := range l {
+		s[i+1] = s[i] + l[i]
+	}
+	return s
+}
+
+// pathSegment returns the segment index and the distance along the segment at an arc length.
+func pathSegment(s []float64, x float64) (int, float64) {
+	n := len(s) - 1
+	// binary search for the segment
+	lo, hi := 0, n-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if s[mid] <= x {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo, x - s[lo]
+}
+
+//-----------------------------------------------------------------------------
+// 2D Paths
+
+// Path2 is a 2D polyline parameterized by arc length.
+type Path2 struct {
+	p      []V2      // points (the first point is repeated at the end of a closed path)
+	t      []V2      // segment tangents
+	s      []float64 // path length at each point
+	closed bool      // is the path closed?
+}
+
+// NewPath2 returns a 2D path through a set of points.
+func NewPath2(points []V2, closed bool) (*Path2, error) {
+	path := Path2{closed: closed}
+	// remove repeated points
+	for _, p := range points {
+		if len(path.p) == 0 || !p.Equals(path.p[len(path.p)-1], tolerance) {
+			path.p = append(path.p, p)
+		}
+	}
+	if closed && len(path.p) > 1 && path.p[0].Equals(path.p[len(path.p)-1], tolerance) {
+		path.p = path.p[:len(path.p)-1]
+	}
+	if len(path.p) < 2 {
+		return nil, errors.New("the path needs at least 2 distinct points")
+	}
+	if closed {
+		path.p = append(path.p, path.p[0])
+	}
+	l := make([]float64, len(path.p)-1)
+	for i := range l {
+		d := path.p[i+1].Sub(path.p[i])
+		l[i] = d.Length()
+		path.t = append(path.t, d.DivScalar(l[i]))
+	}
+	path.s = pathLengths(l)
+	return &path, nil
+}
+
+// PolygonPath2 returns a 2D path from a polygon (with arcs, smoothed vertices and Bezier curves).
+func PolygonPath2(p *Polygon) (*Path2, error) {
+	return NewPath2(p.Vertices(), p.closed)
+}
+
+// ArcPath2 returns a 2D path on a circular arc, counter-clockwise from the start to the end angle.
+// A negative angle range gives a clockwise arc.
+func ArcPath2(
+	center V2, // center of the arc
+	radius float64, // radius of the arc
+	start, end float64, // start and end angles (radians)
+	facets int, // number of line segments
+) (*Path2, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if facets <= 0 {
+		return nil, errors.New("facets <= 0")
+	}
+	if start == end {
+		return nil, errors.New("start angle == end angle")
+	}
+	points := make([]V2, facets+1)
+	for i := range points {
+		a := Mix(start, end, float64(i)/float64(facets))
+		points[i] = PolarToXY(radius, a).Add(center)
+	}
+	return NewPath2(points, false)
+}
+
+// Length returns the length of a 2D path.
+func (path *Path2) Length() float64 {
+	return path.s[len(path.s)-1]
+}
+
+// Closed returns true if the path is closed.
+func (path *Path2) Closed() bool {
+	return path.closed
+}
+
+// Points returns the points of a 2D path (the first point is repeated at the end of a closed path).
+func (path *Path2) Points() []V2 {
+	return append([]V2(nil), path.p...)
+}
+
+// clamp returns an arc length on the path (wrapped for a closed path).
+func (path *Path2) clamp(s float64) float64 {
+	l := path.Length()
+	if path.closed {
+		s = math.Mod(s, l)
+		if s < 0 {
+			s += l
+		}
+		return s
+	}
+	return Clamp(s, 0, l)
+}
+
+// Point returns the point at an arc length along a 2D path.
+func (path *Path2) Point(s float64) V2 {
+	i, u := pathSegment(path.s, path.clamp(s))
+	return path.p[i].Add(path.t[i].MulScalar(u))
+}
+
+// Tangent returns the unit tangent at an arc length along a 2D path.
+func (path *Path2) Tangent(s float64) V2 {
+	i, _ := pathSegment(path.s, path.clamp(s))
+	return path.t[i]
+}
+
+// Normal returns the unit normal (to the left of the tangent) at an arc length along a 2D path.
+func (path *Path2) Normal(s float64) V2 {
+	t := path.Tangent(s)
+	return V2{-t.Y, t.X}
+}
+
+// Closest returns the arc length of the point on a 2D path closest to a point, and the distance to it.
+func (path *Path2) Closest(p V2) (float64, float64) {
+	k, u := 0, 0.0
+	dmin := math.MaxFloat64
+	for i, t := range path.t {
+		x := Clamp(p.Sub(path.p[i]).Dot(t), 0, path.s[i+1]-path.s[i])
+		d := p.Sub(path.p[i].Add(t.MulScalar(x))).Length2()
+		if d < dmin {
+			k, u, dmin = i, x, d
+		}
+	}
+	return path.s[k] + u, math.Sqrt(dmin)
+}
+
+// Offset returns a 2D path offset by a distance along the normal (to the left for d > 0).
+// The offset segments are joined at their intersections, so keep the offset smaller than
+// the radius of curvature of the path.
+func (path *Path2) Offset(d float64) (*Path2, error) {
+	normal := func(i int) V2 { return V2{-path.t[i].Y, path.t[i].X} }
+	n := len(path.t)
+	points := make([]V2, 0, n+1)
+	for i := 0; i <= n; i++ {
+		// the normals of the segments before and after the point
+		var n0, n1 V2
+		switch {
+		case path.closed:
+			n0, n1 = normal((i+n-1)%n), normal(i%n)
+		case i == 0:
+			n0, n1 = normal(0), normal(0)
+		case i == n:
+			n0, n1 = normal(n-1), normal(n-1)
+		default:
+			n0, n1 = normal(i-1), normal(i)
+		}
+		// miter join, limited for sharp corners
+		k := 1 + n0.Dot(n1)
+		v := n0.Add(n1).DivScalar(Max(k, 0.25))
+		points = append(points, path.p[i].Add(v.MulScalar(d)))
+	}
+	return NewPath2(points, path.closed)
+}
+
+//-----------------------------------------------------------------------------
+// 3D Paths
+
+// Path3 is a 3D polyline parameterized by arc length, with a rotation minimizing frame.
+type Path3 struct {
+	p       []V3      // points
+	t, n, b []V3      // segment frames (tangent, normal, binormal)
+	s       []float64 // path length at each point
+}
+
+// NewPath3 returns a 3D path through a set of points.
+func NewPath3(points []V3) (*Path3, error) {
+	var path Path3
+	// remove repeated points
+	for _, p := range points {
+		if len(path.p) == 0 || !p.Equals(path.p[len(path.p)-1], tolerance) {
+			path.p = append(path.p, p)
+		}
+	}
+	if len(path.p) < 2 {
+		return nil, errors.New("the path needs at least 2 distinct points")
+	}
+	l := make([]float64, len(path.p)-1)
+	for i := range l {
+		d := path.p[i+1].Sub(path.p[i])
+		l[i] = d.Length()
+		t := d.DivScalar(l[i])
+		var n V3
+		if i == 0 {
+			n = V3{1, 0, 0}
+			if Abs(t.X) > 0.9 {
+				n = V3{0, 1, 0}
+			}
+			n = n.Sub(t.MulScalar(n.Dot(t))).Normalize()
+		} else {
+			// rotate the previous frame with the path
+			t0 := path.t[i-1]
+			n = path.n[i-1]
+			axis := t0.Cross(t)
+			if sin := axis.Length(); sin > epsilon {
+				n = Rotate3d(axis, math.Atan2(sin, t0.Dot(t))).MulPosition(n)
+			}
+		}
+		path.t = append(path.t, t)
+		path.n = append(path.n, n)
+		path.b = append(path.b, t.Cross(n))
+	}
+	path.s = pathLengths(l)
+	return &path, nil
+}
+
+// Path3 returns a 2D path as a 3D path in the xy plane.
+func (path *Path2) Path3() (*Path3, error) {
+	points := make([]V3, len(path.p))
+	for i, p := range path.p {
+		points[i] = V3{p.X, p.Y, 0}
+	}
+	return NewPath3(points)
+}
+
+// Length returns the length of a 3D path.
+func (path *Path3) Length() float64 {
+	return path.s[len(path.s)-1]
+}
+
+// Points returns the points of a 3D path.
+func (path *Path3) Points() []V3 {
+	return append([]V3(nil), path.p...)
+}
+
+// Point returns the point at an arc length along a 3D path.
+func (path *Path3) Point(s float64) V3 {
+	i, u := pathSegment(path.s, Clamp(s, 0, path.Length()))
+	return path.p[i].Add(path.t[i].MulScalar(u))
+}
+
+// Tangent returns the unit tangent at an arc length along a 3D path.
+func (path *Path3) Tangent(s float64) V3 {
+	i, _ := pathSegment(path.s, Clamp(s, 0, path.Length()))
+	return path.t[i]
+}
+
+// Normal returns the unit normal (of the rotation minimizing frame) at an arc length along a 3D path.
+func (path *Path3) Normal(s float64) V3 {
+	i, _ := pathSegment(path.s, Clamp(s, 0, path.Length()))
+	return path.n[i]
+}
+
+// Binormal returns the unit binormal (tangent x normal) at an arc length along a 3D path.
+func (path *Path3) Binormal(s float64) V3 {
+	i, _ := pathSegment(path.s, Clamp(s, 0, path.Length()))
+	return path.b[i]
+}
+
+// Frame returns the transform from the path frame at an arc length to world coordinates.
+// The x, y and z axes of the frame are the normal, binormal and tangent of the path.
+func (path *Path3) Frame(s float64) M44 {
+	i, u := pathSegment(path.s, Clamp(s, 0, path.Length()))
+	p := path.p[i].Add(path.t[i].MulScalar(u))
+	n, b, t := path.n[i], path.b[i], path.t[i]
+	return M44{
+		n.X, b.X, t.X, p.X,
+		n.Y, b.Y, t.Y, p.Y,
+		n.Z, b.Z, t.Z, p.Z,
+		0, 0, 0, 1,
+	}
+}
+
+// closest returns the segment index and distance along the segment of the closest path point.
+func (path *Path3) closest(p V3) (int, float64) {
+	k, u := 0, 0.0
+	dmin := math.MaxFloat64
+	for i, t := range path.t {
+		x := Clamp(p.Sub(path.p[i]).Dot(t), 0, path.s[i+1]-path.s[i])
+		d := p.Sub(path.p[i].Add(t.MulScalar(x))).Length2()
+		if d < dmin {
+			k, u, dmin = i, x, d
+		}
+	}
+	return k, u
+}
+
+// Closest returns the arc length of the point on a 3D path closest to a point, and the distance to it.
+func (path *Path3) Closest(p V3) (float64, float64) {
+	k, u := path.closest(p)
+	return path.s[k] + u, p.Sub(path.p[k].Add(path.t[k].MulScalar(u))).Length()
+}
+
+// Offset returns a 3D path offset in the path frame (x along the normal, y along the binormal).
+func (path *Path3) Offset(d V2) (*Path3, error) {
+	n := len(path.t)
+	points := make([]V3, n+1)
+	for i := range points {
+		// average the frames of the segments before and after the point
+		j0, j1 := i-1, i
+		if j0 < 0 {
+			j0 = 0
+		}
+		if j1 == n {
+			j1 = n - 1
+		}
+		v0 := path.n[j0].MulScalar(d.X).Add(path.b[j0].MulScalar(d.Y))
+		v1 := path.n[j1].MulScalar(d.X).Add(path.b[j1].MulScalar(d.Y))
+		points[i] = path.p[i].Add(v0.Add(v1).MulScalar(0.5))
+	}
+	return NewPath3(points)
+}
+
+//-----------------------------------------------------------------------------
+// Tubes
+
+// TubeSDF3 is a tube of constant radius along a 3D path.
+type TubeSDF3 struct {
+	path   *Path3  // tube path
+	radius float64 // tube radius
+	bb     Box3    // bounding box
+}
+
+// Tube3D returns a tube of constant radius along a 3D path (with rounded ends).
+func Tube3D(path *Path3, radius float64) (SDF3, error) {
+	if path == nil {
+		return nil, errors.New("nil path")
+	}
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	bb := Box3{path.p[0], path.p[0]}
+	for _, p := range path.p {
+		bb = bb.Extend(Box3{p, p})
+	}
+	return &TubeSDF3{
+		path:   path,
+		radius: radius,
+		bb:     Box3{bb.Min.SubScalar(radius), bb.Max.AddScalar(radius)},
+	}, nil
+}
+
+// Evaluate returns the minimum distance to a tube.
+func (s *TubeSDF3) Evaluate(p V3) float64 {
+	_, d := s.path.Closest(p)
+	return d - s.radius
+}
+
+// BoundingBox returns the bounding box of a tube.
+func (s *TubeSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Distribute Along a Path
+
+// pathSpacing returns the arc lengths of n evenly spaced points on a path.
+// The points span an open path, and are one spacing apart around a closed path.
+func pathSpacing(length float64, n int, closed bool) []float64 {
+	s := make([]float64, n)
+	if n == 1 {
+		return s
+	}
+	k := length / float64(n-1)
+	if closed {
+		k = length / float64(n)
+	}
+	for i := range s {
+		s[i] = float64(i) * k
+	}
+	return s
+}
+
+// DistributePath2D returns n copies of an SDF2 evenly spaced along a 2D path.
+// With align the x-axis of each copy is rotated to the path tangent.
+func DistributePath2D(s SDF2, path *Path2, n int, align bool) (SDF2, error) {
+	if s == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if path == nil {
+		return nil, errors.New("nil path")
+	}
+	if n <= 0 {
+		return nil, errors.New("n <= 0")
+	}
+	copies := make([]SDF2, n)
+	for i, x := range pathSpacing(path.Length(), n, path.closed) {
+		m := Translate2d(path.Point(x))
+		if align {
+			t := path.Tangent(x)
+			m = m.Mul(Rotate2d(math.Atan2(t.Y, t.X)))
+		}
+		copies[i] = Transform2D(s, m)
+	}
+	return Union2D(copies...), nil
+}
+
+// DistributePath3D returns n copies of an SDF3 evenly spaced along a 3D path.
+// With align each copy is placed in the path frame (see Path3.Frame), otherwise it is translated.
+func DistributePath3D(s SDF3, path *Path3, n int, align bool) (SDF3, error) {
+	if s == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if path == nil {
+		return nil, errors.New("nil path")
+	}
+	if n <= 0 {
+		return nil, errors.New("n <= 0")
+	}
+	copies := make([]SDF3, n)
+	for i, x := range pathSpacing(path.Length(), n, false) {
+		m := Translate3d(path.Point(x))
+		if align {
+			m = path.Frame(x)
+		}
+		copies[i] = Transform3D(s, m)
+	}
+	return Union3D(copies...), nil
+}
+
+//-----------------------------------------------------------------------------
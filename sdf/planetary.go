@@ -0,0 +1,202 @@
+//-----------------------------------------------------------------------------
+/*
+
+Planetary Gear Sets
+
+A sun gear in the center, planet gears on a carrier around it and an internal
+ring gear outside them. The ring has SunTeeth + 2 * PlanetTeeth teeth and the
+planets are equally spaced, which needs (SunTeeth + RingTeeth) / Planets to be
+a whole number.
+
+The gears are positioned and rotated so they mesh. The backlash is split
+equally between the members, so each mesh (sun/planet and planet/ring) has the
+same backlash.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// PlanetaryParms defines the parameters for a planetary gear set.
+type PlanetaryParms struct {
+	Module           float64 // gear module
+	PressureAngle    float64 // gear pressure angle (radians)
+	SunTeeth         int     // number of teeth on the sun gear
+	PlanetTeeth      int     // number of teeth on each planet gear
+	Planets          int     // number of planet gears
+	Backlash         float64 // backlash for each mesh, as a per-tooth distance at the pitch circumference
+	Clearance        float64 // additional root clearance
+	FaceWidth        float64 // width of the gear faces (along the z-axis)
+	RingWidth        float64 // width of the ring gear wall (from the root circle)
+	SunBore          float64 // sun gear bore diameter (0 = none)
+	PlanetBore       float64 // planet gear bore diameter (0 = none)
+	CarrierThickness float64 // carrier plate thickness (0 = no carrier)
+	PinDiameter      float64 // diameter of the carrier pins for the planets (< PlanetBore)
+	Facets           int     // number of facets for involute flanks
+}
+
+// PlanetaryGears are the members of a planetary gear set.
+// The gears have their faces from z = 0 to z = FaceWidth, the carrier (if any) is below them.
+type PlanetaryGears struct {
+	Sun     SDF3   // sun gear
+	Planets []SDF3 // planet gears
+	Ring    SDF3   // ring gear
+	Carrier SDF3   // planet carrier (nil if none)
+}
+
+// RingTeeth returns the number of teeth on the ring gear.
+func (k *PlanetaryParms) RingTeeth() int {
+	return k.SunTeeth + 2*k.PlanetTeeth
+}
+
+// CenterDistance returns the distance from the sun gear axis to the planet axes.
+func (k *PlanetaryParms) CenterDistance() float64 {
+	return 0.5 * k.Module * float64(k.SunTeeth+k.PlanetTeeth)
+}
+
+// Ratio returns the reduction ratio from the sun (input) to the carrier (output) with the ring fixed.
+func (k *PlanetaryParms) Ratio() float64 {
+	return 1 + float64(k.RingTeeth())/float64(k.SunTeeth)
+}
+
+// PlanetaryGearSet returns the positioned members of a planetary gear set.
+// The sun is centered on the origin with a tooth on the +x axis, the first planet is on the +x axis.
+func PlanetaryGearSet(k *PlanetaryParms) (*PlanetaryGears, error) {
+	if k.Module <= 0 {
+		return nil, errors.New("module <= 0")
+	}
+	if k.PressureAngle <= 0 || k.PressureAngle >= DtoR(45) {
+		return nil, errors.New("bad pressure angle")
+	}
+	if k.SunTeeth < 4 || k.PlanetTeeth < 4 {
+		return nil, errors.New("sun and planet teeth must be >= 4")
+	}
+	if k.Planets < 1 {
+		return nil, errors.New("planets < 1")
+	}
+	if (k.SunTeeth+k.RingTeeth())%k.Planets != 0 {
+		return nil, errors.New("(sun teeth + ring teeth) / planets is not a whole number")
+	}
+	if k.Backlash < 0 || k.Clearance < 0 {
+		return nil, errors.New("backlash and clearance must be >= 0")
+	}
+	if k.FaceWidth <= 0 {
+		return nil, errors.New("face width <= 0")
+	}
+	if k.RingWidth <= 0 {
+		return nil, errors.New("ring width <= 0")
+	}
+	if k.Facets <= 0 {
+		return nil, errors.New("facets <= 0")
+	}
+	if k.CarrierThickness < 0 {
+		return nil, errors.New("carrier thickness < 0")
+	}
+	if k.CarrierThickness > 0 && (k.PinDiameter <= 0 || k.PinDiameter >= k.PlanetBore) {
+		return nil, errors.New("the pin diameter must be > 0 and < the planet bore")
+	}
+
+	m := k.Module
+	a := k.CenterDistance()
+	rp := 0.5 * m * float64(k.PlanetTeeth)
+	// adjacent planets mustn't touch
+	if k.Planets > 1 && 2*a*math.Sin(Pi/float64(k.Planets)) <= 2*(rp+m) {
+		return nil, errors.New("the planets overlap")
+	}
+	rootRadius := rp - m - k.Clearance
+	if 0.5*k.PlanetBore >= rootRadius || 0.5*k.SunBore >= 0.5*m*float64(k.SunTeeth)-m-k.Clearance {
+		return nil, errors.New("the bore is larger than the root circle")
+	}
+
+	b := 0.5 * k.Backlash
+	ns := float64(k.SunTeeth)
+	np := float64(k.PlanetTeeth)
+	nr := float64(k.RingTeeth())
+
+	// sun
+	sun, err := CheckedInvoluteGear(k.SunTeeth, m, k.PressureAngle, b, k.Clearance, 0, k.Facets)
+	if err != nil {
+		return nil, err
+	}
+	if k.SunBore > 0 {
+		sun = Difference2D(sun, Circle2D(0.5*k.SunBore))
+	}
+
+	// planet
+	planet, err := CheckedInvoluteGear(k.PlanetTeeth, m, k.PressureAngle, b, k.Clearance, 0, k.Facets)
+	if err != nil {
+		return nil, err
+	}
+	if k.PlanetBore > 0 {
+		planet = Difference2D(planet, Circle2D(0.5*k.PlanetBore))
+	}
+
+	// The planet on the +x axis has a tooth space facing the sun tooth on the +x axis.
+	// For an even number of planet teeth the planet also has a space facing out, so the ring
+	// is turned a half tooth to put a tooth there.
+	psi0 := Pi - Pi/np
+	ringAngle := 0.0
+	if k.PlanetTeeth%2 == 0 {
+		ringAngle = Pi / nr
+	}
+	ring := InternalInvoluteGear(k.RingTeeth(), m, k.PressureAngle, b, k.Clearance, k.RingWidth, k.Facets)
+	ring = Transform2D(ring, Rotate2d(ringAngle))
+
+	s := PlanetaryGears{
+		Sun:     Extrude3D(sun, k.FaceWidth),
+		Planets: make([]SDF3, k.Planets),
+		Ring:    Extrude3D(ring, k.FaceWidth),
+	}
+	up := Translate3d(V3{0, 0, 0.5 * k.FaceWidth})
+	s.Sun = Transform3D(s.Sun, up)
+	s.Ring = Transform3D(s.Ring, up)
+
+	planet3 := Extrude3D(planet, k.FaceWidth)
+	// the carrier plate is below the gears, with a clearance gap
+	gap := Max(k.Clearance, 0.1*m)
+	var pins []SDF3
+	for i := range s.Planets {
+		phi := Tau * float64(i) / float64(k.Planets)
+		// moving a planet around the fixed sun by phi turns it by phi * (ns + np) / np
+		psi := psi0 + phi*(ns+np)/np
+		c := PolarToXY(a, phi)
+		s.Planets[i] = Transform3D(planet3, Translate3d(c.ToV3(0.5*k.FaceWidth)).Mul(RotateZ(psi)))
+		if k.CarrierThickness > 0 {
+			// the pins run from the bottom of the carrier plate to the top of the planets
+			h := k.FaceWidth + gap + k.CarrierThickness
+			pin := Cylinder3D(h, 0.5*k.PinDiameter, 0)
+			pins = append(pins, Transform3D(pin, Translate3d(c.ToV3(k.FaceWidth-0.5*h))))
+		}
+	}
+
+	if k.CarrierThickness > 0 {
+		r := a + Max(k.PinDiameter, 2*m)
+		plate := Cylinder3D(k.CarrierThickness, r, 0)
+		plate = Transform3D(plate, Translate3d(V3{0, 0, -gap - 0.5*k.CarrierThickness}))
+		if k.SunBore > 0 {
+			bore := Cylinder3D(k.CarrierThickness+1, 0.5*k.SunBore, 0)
+			plate = Difference3D(plate, Transform3D(bore, Translate3d(V3{0, 0, -gap - 0.5*k.CarrierThickness})))
+		}
+		s.Carrier = Union3D(append([]SDF3{plate}, pins...)...)
+	}
+
+	return &s, nil
+}
+
+// Assembly returns all the members of a planetary gear set as a single SDF3.
+func (s *PlanetaryGears) Assembly() SDF3 {
+	parts := append([]SDF3{s.Sun, s.Ring}, s.Planets...)
+	if s.Carrier != nil {
+		parts = append(parts, s.Carrier)
+	}
+	return Union3D(parts...)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,157 @@
+//-----------------------------------------------------------------------------
+/*
+
+Sphere Packing
+
+Random, non-overlapping spheres inside a solid (Poisson disk sampling in 3D,
+with Bridson's algorithm). New spheres are placed around existing spheres
+until no more fit, so the spheres are evenly spread without a regular
+pattern (blue noise).
+
+Uses: the centers of a stochastic lattice, spherical pockets for weight
+reduction (Difference3D of the part and the spheres), or spheres unioned
+with a part for a particle-filled appearance.
+
+The spheres have random radii between the minimum and maximum radius. A
+negative margin lets the spheres cut the surface of the solid.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+//-----------------------------------------------------------------------------
+
+const (
+	poissonAttempts = 30   // default number of candidate spheres around each sphere
+	poissonSeeds    = 1000 // number of failed random points before giving up on new fronts
+)
+
+// PoissonParms defines the parameters for packing spheres inside a solid.
+type PoissonParms struct {
+	MinRadius float64 // minimum sphere radius
+	MaxRadius float64 // maximum sphere radius (<= MinRadius for equal spheres)
+	Gap       float64 // minimum gap between spheres
+	Margin    float64 // minimum distance from the spheres to the surface of the solid
+	Attempts  int     // number of candidate spheres around each sphere (0 = 30)
+	MaxCount  int     // maximum number of spheres (0 = no limit)
+	Seed      int64   // random seed (the same seed gives the same spheres)
+}
+
+// PoissonSpheres3D returns the centers and radii of random non-overlapping spheres inside an SDF3.
+func PoissonSpheres3D(s SDF3, k *PoissonParms) ([]V3, []float64, error) {
+	if s == nil {
+		return nil, nil, errors.New("nil sdf")
+	}
+	if k.MinRadius <= 0 {
+		return nil, nil, errors.New("minimum radius <= 0")
+	}
+	if k.Gap < 0 {
+		return nil, nil, errors.New("gap < 0")
+	}
+	if k.Attempts < 0 {
+		return nil, nil, errors.New("attempts < 0")
+	}
+	if k.MaxCount < 0 {
+		return nil, nil, errors.New("maximum count < 0")
+	}
+	rmin, rmax := k.MinRadius, Max(k.MinRadius, k.MaxRadius)
+	attempts := k.Attempts
+	if attempts == 0 {
+		attempts = poissonAttempts
+	}
+	rnd := rand.New(rand.NewSource(k.Seed))
+	radius := func() float64 { return rmin + (rmax-rmin)*rnd.Float64() }
+	direction := func() V3 {
+		for {
+			v := V3{2*rnd.Float64() - 1, 2*rnd.Float64() - 1, 2*rnd.Float64() - 1}
+			if l := v.Length(); l > 0.1 && l <= 1 {
+				return v.DivScalar(l)
+			}
+		}
+	}
+
+	// spheres that can overlap a sphere are in the same or adjacent grid cells
+	bb := s.BoundingBox()
+	cell := 2*rmax + k.Gap
+	key := func(p V3) V3i {
+		q := p.Sub(bb.Min).DivScalar(cell)
+		return V3i{int(math.Floor(q.X)), int(math.Floor(q.Y)), int(math.Floor(q.Z))}
+	}
+	grid := make(map[V3i][]int)
+	var centers []V3
+	var radii []float64
+
+	// fits returns true if a sphere is inside the solid and clear of the other spheres.
+	fits := func(c V3, r float64) bool {
+		if s.Evaluate(c) > -(r + k.Margin) {
+			return false
+		}
+		i := key(c)
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				for dz := -1; dz <= 1; dz++ {
+					for _, j := range grid[i.Add(V3i{dx, dy, dz})] {
+						if c.Sub(centers[j]).Length() < r+radii[j]+k.Gap {
+							return false
+						}
+					}
+				}
+			}
+		}
+		return true
+	}
+	add := func(c V3, r float64) int {
+		i := key(c)
+		grid[i] = append(grid[i], len(centers))
+		centers = append(centers, c)
+		radii = append(radii, r)
+		return len(centers) - 1
+	}
+	full := func() bool { return k.MaxCount > 0 && len(centers) >= k.MaxCount }
+
+	size := bb.Size()
+	for misses := 0; misses < poissonSeeds && !full(); {
+		// start a new front at a random point
+		c := bb.Min.Add(V3{size.X * rnd.Float64(), size.Y * rnd.Float64(), size.Z * rnd.Float64()})
+		r := radius()
+		if !fits(c, r) {
+			misses++
+			continue
+		}
+		active := []int{add(c, r)}
+		for len(active) > 0 && !full() {
+			i := rnd.Intn(len(active))
+			a := active[i]
+			found := false
+			for n := 0; n < attempts; n++ {
+				// candidates are one to two sphere spacings away
+				r := radius()
+				d := radii[a] + r + k.Gap
+				c := centers[a].Add(direction().MulScalar(d * (1 + rnd.Float64())))
+				if fits(c, r) {
+					active = append(active, add(c, r))
+					found = true
+					break
+				}
+			}
+			if !found {
+				// no room around this sphere
+				active[i] = active[len(active)-1]
+				active = active[:len(active)-1]
+			}
+		}
+	}
+	if len(centers) == 0 {
+		return nil, nil, errors.New("no spheres fit")
+	}
+	return centers, radii, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -3,6 +3,12 @@
 
 Polygon Building Code
 
+Vertices marked with Control() are Bezier control points. One control point
+between two vertices gives a quadratic curve, two give a cubic curve. The
+curve is tessellated with a number of facets, or adaptively to a chord
+tolerance (the maximum distance from the curve to a line segment) with
+options on the end vertex of the curve.
+
 */
 //-----------------------------------------------------------------------------
 
@@ -29,18 +35,24 @@ type PolygonVertex struct {
 	vertex   V2      // vertex coordinates
 	facets   int     // number of polygon facets to create when smoothing
 	radius   float64 // radius of smoothing (0 == none)
+	bFacets  int     // number of facets for a bezier curve ending at this vertex
+	bTol     float64 // chord tolerance for a bezier curve ending at this vertex (0 == use facets)
 }
 
 // pvType is the type of a polygon vertex.
 type pvType int
 
 const (
-	pvNormal pvType = iota // normal vertex
-	pvHide                 // hide the line segment in rendering
-	pvSmooth               // smooth the vertex
-	pvArc                  // replace the line segment with an arc
+	pvNormal  pvType = iota // normal vertex
+	pvHide                  // hide the line segment in rendering
+	pvSmooth                // smooth the vertex
+	pvArc                   // replace the line segment with an arc
+	pvControl               // bezier control point
 )
 
+// bezierFacets is the default number of facets for a bezier curve.
+const bezierFacets = 16
+
 //-----------------------------------------------------------------------------
 // Operations on Polygon Vertices
 
@@ -95,6 +107,25 @@ func (v *PolygonVertex) Arc(radius float64, facets int) *PolygonVertex {
 	return v
 }
 
+// Control marks the polygon vertex as a bezier control point. The curve options are set on
+// the end vertex of the curve (the first vertex for a curve closing a polygon).
+func (v *PolygonVertex) Control() *PolygonVertex {
+	v.vtype = pvControl
+	return v
+}
+
+// Bezier sets the number of facets for a bezier curve ending at this vertex.
+func (v *PolygonVertex) Bezier(facets int) *PolygonVertex {
+	v.bFacets = facets
+	return v
+}
+
+// BezierTolerance sets the chord tolerance for a bezier curve ending at this vertex.
+func (v *PolygonVertex) BezierTolerance(tol float64) *PolygonVertex {
+	v.bTol = tol
+	return v
+}
+
 //-----------------------------------------------------------------------------
 
 // nextVertex returns the next vertex in the polygon.
@@ -150,7 +181,7 @@ func (p *Polygon) arcVertex(i int) bool {
 	// distance from a to midpoint
 	dMid := mid.Sub(a).Length()
 	// distance from midpoint to center of arc
-	dCenter := math.Sqrt((radius * radius) - (dMid * dMid))
+	dCenter := math.Sqrt(float64(radius*radius) - float64(dMid*dMid))
 	// center of arc
 	c := mid.Add(n.MulScalar(dCenter))
 	// work out the angle
@@ -250,6 +281,86 @@ func (p *Polygon) smoothVertices() {
 	}
 }
 
+//-----------------------------------------------------------------------------
+// bezier curves
+
+// bezierVertex replaces the bezier control points before the i-th vertex with the curve.
+func (p *Polygon) bezierVertex(i int) bool {
+	v := &p.vlist[i]
+	if v.vtype == pvControl || i == 0 || p.vlist[i-1].vtype != pvControl {
+		return false
+	}
+	// find the start of the curve
+	j := i - 1
+	for j >= 0 && p.vlist[j].vtype == pvControl {
+		j--
+	}
+	if j < 0 {
+		panic("bezier curve needs a start vertex")
+	}
+	if i-j-1 > 2 {
+		panic("bezier curve has more than 2 control points")
+	}
+	cp := make([]V2, 0, 4)
+	for k := j; k <= i; k++ {
+		cp = append(cp, p.vlist[k].vertex)
+	}
+	s := NewBezierSpline(cp)
+	var points []V2
+	if v.bTol > 0 {
+		points = AdaptiveCurve(s.f0, 0, 1, v.bTol)
+		// the start and end vertices are kept
+		points = points[1 : len(points)-1]
+	} else {
+		n := v.bFacets
+		if n <= 0 {
+			n = bezierFacets
+		}
+		for k := 1; k < n; k++ {
+			points = append(points, s.f0(float64(k)/float64(n)))
+		}
+	}
+	vlist := make([]PolygonVertex, len(points))
+	for k := range vlist {
+		vlist[k] = PolygonVertex{vertex: points[k]}
+	}
+	// replace the control points with the curve points
+	p.vlist = append(p.vlist[:j+1], append(vlist, p.vlist[i:]...)...)
+	return true
+}
+
+// createBeziers converts bezier control points to curves.
+func (p *Polygon) createBeziers() {
+	n := len(p.vlist)
+	if n == 0 {
+		return
+	}
+	if p.vlist[0].vtype == pvControl {
+		panic("polygon can't start with a bezier control point")
+	}
+	// a closed polygon can curve back to the first vertex
+	wrap := p.closed && p.vlist[n-1].vtype == pvControl
+	if wrap {
+		v := p.vlist[0]
+		p.vlist = append(p.vlist, PolygonVertex{vertex: v.vertex, bFacets: v.bFacets, bTol: v.bTol})
+	} else if p.vlist[n-1].vtype == pvControl {
+		panic("open polygon can't end with a bezier control point")
+	}
+	done := false
+	for done == false {
+		done = true
+		for i := range p.vlist {
+			if p.bezierVertex(i) {
+				done = false
+				break
+			}
+		}
+	}
+	if wrap {
+		p.Drop()
+	}
+}
+
 //-----------------------------------------------------------------------------
 
 // relToAbs converts relative vertices to absolute vertices.
@@ -271,6 +382,7 @@ func (p *Polygon) relToAbs() {
 
 func (p *Polygon) fixups() {
 	p.relToAbs()
+	p.createBeziers()
 	p.createArcs()
 	p.smoothVertices()
 }
@@ -369,6 +481,133 @@ func (p *Polygon) Render(path string) error {
 	return nil
 }
 
+//-----------------------------------------------------------------------------
+// Simplification and Resampling
+
+// openVertices returns the vertices of a closed polygon without a closing vertex.
+func openVertices(v []V2, closed bool) []V2 {
+	n := len(v)
+	if closed && n > 1 && v[0].Equals(v[n-1], tolerance) {
+		return v[:n-1]
+	}
+	return v
+}
+
+// segmentDistance returns the distance from a point to a line segment.
+func segmentDistance(p, a, b V2) float64 {
+	if a.Equals(b, tolerance) {
+		return p.Sub(a).Length()
+	}
+	return Abs(newLinePP(a, b).Distance(p))
+}
+
+// douglasPeucker marks the vertices of v[i:j+1] to keep.
+func douglasPeucker(v []V2, i, j int, tol float64, keep []bool) {
+	for j-i > 1 {
+		dmax, k := -1.0, 0
+		for m := i + 1; m < j; m++ {
+			if d := segmentDistance(v[m], v[i], v[j]); d > dmax {
+				dmax, k = d, m
+			}
+		}
+		if dmax <= tol {
+			return
+		}
+		keep[k] = true
+		douglasPeucker(v, i, k, tol, keep)
+		i = k
+	}
+}
+
+// Simplify returns the vertices of a polyline or polygon with the Douglas-Peucker algorithm.
+// The simplified line is within the tolerance of all the original vertices.
+func Simplify(
+	v []V2, // vertices
+	tol float64, // maximum distance from the simplified line
+	closed bool, // the vertices form a closed polygon
+) []V2 {
+	v = openVertices(v, closed)
+	n := len(v)
+	if n < 3 {
+		return append([]V2(nil), v...)
+	}
+	keep := make([]bool, n+1)
+	if closed {
+		// split the polygon at the vertex farthest from the first vertex
+		k, dmax := 0, -1.0
+		for i, x := range v {
+			if d := x.Sub(v[0]).Length2(); d > dmax {
+				k, dmax = i, d
+			}
+		}
+		w := append(v[:n:n], v[0])
+		keep[0], keep[k] = true, true
+		douglasPeucker(w, 0, k, tol, keep)
+		douglasPeucker(w, k, n, tol, keep)
+		keep[n] = false
+	} else {
+		keep[0], keep[n-1] = true, true
+		douglasPeucker(v, 0, n-1, tol, keep)
+	}
+	var out []V2
+	for i, x := range v {
+		if keep[i] {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// Resample returns a polyline or polygon with vertices evenly spaced by arc length.
+// The spacing is adjusted to fit a whole number of segments. The end points of a polyline are kept.
+func Resample(
+	v []V2, // vertices
+	spacing float64, // maximum distance between vertices
+	closed bool, // the vertices form a closed polygon
+) []V2 {
+	v = openVertices(v, closed)
+	n := len(v)
+	if n < 2 || spacing <= 0 {
+		return append([]V2(nil), v...)
+	}
+	if closed {
+		v = append(v[:n:n], v[0])
+	}
+	// cumulative arc lengths
+	s := make([]float64, len(v))
+	for i := 1; i < len(v); i++ {
+		s[i] = s[i-1] + v[i].Sub(v[i-1]).Length()
+	}
+	length := s[len(s)-1]
+	if length == 0 {
+		return []V2{v[0]}
+	}
+	m := int(math.Ceil(length/spacing - epsilon))
+	if m < 1 {
+		m = 1
+	}
+	if closed && m < 3 {
+		m = 3
+	}
+	out := make([]V2, 0, m+1)
+	j := 1
+	for i := 0; i <= m; i++ {
+		if i == m && closed {
+			break
+		}
+		d := length * float64(i) / float64(m)
+		for j < len(s)-1 && s[j] < d {
+			j++
+		}
+		t := 0.0
+		if l := s[j] - s[j-1]; l > 0 {
+			t = Clamp((d-s[j-1])/l, 0, 1)
+		}
+		out = append(out, v[j-1].Add(v[j].Sub(v[j-1]).MulScalar(t)))
+	}
+	return out
+}
+
 //-----------------------------------------------------------------------------
 
 // Nagon return the vertices of a N sided regular polygon.
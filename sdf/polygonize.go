@@ -0,0 +1,188 @@
+//-----------------------------------------------------------------------------
+/*
+
+Adaptive Polygonization
+
+Convert curves and SDF2 boundaries to polygons with a chord error tolerance
+rather than a fixed number of facets or a fixed sampling grid. Points are
+dense where the curvature is high and sparse on straight lines.
+
+For SDF2s the boundary is found with marching squares, the vertices are
+projected onto the boundary (using the gradient of the SDF), edges are split
+until the chord error is within tolerance, and then the polygons are
+simplified. The mesh cells set the size of the features that are found, and
+sharp corners are resolved to about the cell size.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+//-----------------------------------------------------------------------------
+
+// adaptiveDepth is the recursion limit for adaptive sampling.
+const adaptiveDepth = 16
+
+// Gradient2 returns the normalized gradient of an SDF2 at p (central differences, step h).
+func Gradient2(s SDF2, p V2, h float64) V2 {
+	dx := s.Evaluate(p.Add(V2{h, 0})) - s.Evaluate(p.Sub(V2{h, 0}))
+	dy := s.Evaluate(p.Add(V2{0, h})) - s.Evaluate(p.Sub(V2{0, h}))
+	return V2{dx, dy}.Normalize()
+}
+
+// curveSample adds the points of a curve in (t0, t1] to within a chord tolerance.
+func curveSample(f func(float64) V2, t0, t1 float64, p0, p1 V2, tol float64, depth int, points []V2) []V2 {
+	// test points through the interval (a single midpoint can be on the chord of an s-curve)
+	flat := true
+	for _, k := range []float64{0.25, 0.5, 0.75} {
+		if segmentDistance(f(t0+k*(t1-t0)), p0, p1) > tol {
+			flat = false
+			break
+		}
+	}
+	if flat || depth > adaptiveDepth {
+		return append(points, p1)
+	}
+	tmid := 0.5 * (t0 + t1)
+	pmid := f(tmid)
+	points = curveSample(f, t0, tmid, p0, pmid, tol, depth+1, points)
+	return curveSample(f, tmid, t1, pmid, p1, tol, depth+1, points)
+}
+
+// AdaptiveCurve returns points on a parametric curve from t0 to t1 (inclusive) such that the
+// line segments between them are within a chord tolerance of the curve.
+func AdaptiveCurve(
+	f func(float64) V2, // parametric curve
+	t0, t1 float64, // parameter range
+	tol float64, // chord tolerance
+) []V2 {
+	p0 := f(t0)
+	return curveSample(f, t0, t1, p0, f(t1), tol, 0, []V2{p0})
+}
+
+//-----------------------------------------------------------------------------
+
+// projectBoundary moves a point onto the boundary of an SDF2.
+func projectBoundary(s SDF2, p V2, h float64) V2 {
+	for i := 0; i < 4; i++ {
+		d := s.Evaluate(p)
+		if Abs(d) < 1e-3*h {
+			break
+		}
+		p = p.Sub(Gradient2(s, p, h).MulScalar(d))
+	}
+	return p
+}
+
+// refineEdge adds the boundary points in (a, b] to within a chord tolerance.
+func refineEdge(s SDF2, a, b V2, tol, h float64, depth int, points []V2) []V2 {
+	mid := a.Add(b).MulScalar(0.5)
+	m := projectBoundary(s, mid, h)
+	// on the medial axis the projection can jump to another part of the boundary
+	jump := m.Sub(mid).Length() > b.Sub(a).Length()
+	if depth > adaptiveDepth || jump || segmentDistance(m, a, b) <= tol {
+		return append(points, b)
+	}
+	points = refineEdge(s, a, m, tol, h, depth+1, points)
+	return refineEdge(s, m, b, tol, h, depth+1, points)
+}
+
+// Polygonize2D returns the boundary of an SDF2 as closed polygons within a chord tolerance.
+// The polygons have the inside of the SDF2 on the left (outer boundaries are counter-clockwise,
+// holes are clockwise).
+func Polygonize2D(
+	s SDF2, // sdf2 to polygonize
+	meshCells int, // number of cells on the longest axis. e.g 200
+	tol float64, // chord tolerance
+) ([][]V2, error) {
+	if meshCells <= 0 {
+		return nil, errors.New("mesh cells <= 0")
+	}
+	if tol <= 0 {
+		return nil, errors.New("tolerance <= 0")
+	}
+	bb := s.BoundingBox()
+	step := bb.Size().MaxComponent() / float64(meshCells)
+	// offset the grid so samples aren't on the axis aligned edges of simple shapes
+	ofs := V2{1, 1}.MulScalar(step / goldenRatio)
+	bb = NewBox2(bb.Center().Add(ofs), bb.Size().AddScalar(4*step))
+	h := 1e-3 * step
+	var polygons [][]V2
+	for _, loop := range chainLines(marchingSquares(s, bb, step), 1e-3*step) {
+		for i := range loop {
+			loop[i] = projectBoundary(s, loop[i], h)
+		}
+		// split edges to half the tolerance, simplify to the other half
+		n := len(loop)
+		v := []V2{loop[0]}
+		for i := range loop {
+			v = refineEdge(s, loop[i], loop[(i+1)%n], 0.5*tol, h, 0, v)
+		}
+		v = Simplify(v, 0.5*tol, true)
+		if len(v) < 3 {
+			continue
+		}
+		// the inside is to the left of the edges
+		a, b := v[0], v[1]
+		ba := b.Sub(a)
+		left := a.Add(ba.MulScalar(0.5)).Add(V2{-ba.Y, ba.X}.Normalize().MulScalar(10 * h))
+		if s.Evaluate(left) > 0 {
+			for i, j := 0, len(v)-1; i < j; i, j = i+1, j-1 {
+				v[i], v[j] = v[j], v[i]
+			}
+		}
+		polygons = append(polygons, v)
+	}
+	return polygons, nil
+}
+
+// polygonLines returns the line segments of a set of closed polygons.
+func polygonLines(polygons [][]V2) []*Line {
+	var lines []*Line
+	for _, v := range polygons {
+		for i := range v {
+			lines = append(lines, &Line{v[i], v[(i+1)%len(v)]})
+		}
+	}
+	return lines
+}
+
+//-----------------------------------------------------------------------------
+
+// RenderDXFAdaptive renders an SDF2 as a DXF file with a chord tolerance.
+func RenderDXFAdaptive(
+	s SDF2, // sdf2 to render
+	meshCells int, // number of cells on the longest axis. e.g 200
+	tol float64, // chord tolerance
+	path string, // path to filename
+) error {
+	polygons, err := Polygonize2D(s, meshCells, tol)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("rendering %s (%d polygons, tolerance %g)\n", path, len(polygons), tol)
+	return SaveDXF(path, polygonLines(polygons))
+}
+
+// RenderSVGAdaptive renders an SDF2 as an SVG file with a chord tolerance.
+func RenderSVGAdaptive(
+	s SDF2, // sdf2 to render
+	meshCells int, // number of cells on the longest axis. e.g 200
+	tol float64, // chord tolerance
+	path string, // path to filename
+	lineStyle string, // SVG line style
+) error {
+	polygons, err := Polygonize2D(s, meshCells, tol)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("rendering %s (%d polygons, tolerance %g)\n", path, len(polygons), tol)
+	return SaveSVG(path, lineStyle, polygonLines(polygons))
+}
+
+//-----------------------------------------------------------------------------
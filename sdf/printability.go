@@ -0,0 +1,404 @@
+//-----------------------------------------------------------------------------
+/*
+
+Printability Report
+
+Check a part for features that are hard to print in a given orientation:
+
+bridge: unsupported regions of a layer with a span longer than a limit.
+thin: walls thinner than the nozzle width.
+hole: holes (in the xy-plane of the layers) smaller than a limit.
+
+The part is sliced into layers, and each layer is rasterized. Distances within
+a layer come from an exact Euclidean distance transform of the raster.
+
+A cell of a layer is supported if it is within the overhang allowance of the
+layer below. Unsupported regions are measured as bridges, I.e. anchored on
+both sides, the span is twice the largest distance to a supported cell. A
+cantilevered overhang of length L has a span of 2L. An unsupported region
+with no supported cells in the layer (an island) has an infinite span.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+// Euclidean Distance Transform
+
+// edt1 computes the 1D squared distance transform of f (Felzenszwalb/Huttenlocher).
+// Infinite values of f are cells with no feature.
+func edt1(f, d []float64, v []int, z []float64) {
+	n := len(f)
+	k := -1
+	for q := 0; q < n; q++ {
+		if math.IsInf(f[q], 1) {
+			continue
+		}
+		if k < 0 {
+			k = 0
+			v[0] = q
+			z[0] = math.Inf(-1)
+			z[1] = math.Inf(1)
+			continue
+		}
+		s := 0.0
+		for {
+			p := v[k]
+			s = ((f[q] + float64(q*q)) - (f[p] + float64(p*p))) / float64(2*(q-p))
+			if s > z[k] {
+				break
+			}
+			k--
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+	if k < 0 {
+		for q := range d {
+			d[q] = math.Inf(1)
+		}
+		return
+	}
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		p := v[k]
+		d[q] = float64((q-p)*(q-p)) + f[p]
+	}
+}
+
+// edt2 returns the squared distance (in cells) from each cell to the nearest feature cell.
+func edt2(feature []bool, nx, ny int) []float64 {
+	n := maxInt(nx, ny)
+	f := make([]float64, n)
+	d := make([]float64, n)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+	out := make([]float64, nx*ny)
+	for i, x := range feature {
+		if x {
+			out[i] = 0
+		} else {
+			out[i] = math.Inf(1)
+		}
+	}
+	// columns
+	for x := 0; x < nx; x++ {
+		for y := 0; y < ny; y++ {
+			f[y] = out[y*nx+x]
+		}
+		edt1(f[:ny], d[:ny], v, z)
+		for y := 0; y < ny; y++ {
+			out[y*nx+x] = d[y]
+		}
+	}
+	// rows
+	for y := 0; y < ny; y++ {
+		copy(f, out[y*nx:(y+1)*nx])
+		edt1(f[:nx], d[:nx], v, z)
+		copy(out[y*nx:(y+1)*nx], d[:nx])
+	}
+	return out
+}
+
+// components returns the 4-connected components of the marked cells.
+func components(mark []bool, nx, ny int) [][]int {
+	var comps [][]int
+	seen := make([]bool, len(mark))
+	for i := range mark {
+		if !mark[i] || seen[i] {
+			continue
+		}
+		var c []int
+		stack := []int{i}
+		seen[i] = true
+		for len(stack) > 0 {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			c = append(c, j)
+			x, y := j%nx, j/nx
+			for _, k := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if k[0] < 0 || k[0] >= nx || k[1] < 0 || k[1] >= ny {
+					continue
+				}
+				n := k[1]*nx + k[0]
+				if mark[n] && !seen[n] {
+					seen[n] = true
+					stack = append(stack, n)
+				}
+			}
+		}
+		comps = append(comps, c)
+	}
+	return comps
+}
+
+//-----------------------------------------------------------------------------
+
+// PrintabilityParms defines the limits for a printability report.
+type PrintabilityParms struct {
+	Orientation M44     // rotation of the part for printing (zero = as is), E.g. from PrintOrientation3D
+	LayerHeight float64 // layer height
+	Resolution  float64 // raster cell size (0 = nozzle width / 4)
+	Nozzle      float64 // nozzle width, the minimum wall thickness
+	MaxBridge   float64 // maximum unsupported bridge span
+	MinHole     float64 // minimum hole diameter
+	Angle       float64 // overhang angle from the vertical (0 = 45 degrees)
+}
+
+// PrintIssue is a region of a layer that may not print well.
+type PrintIssue struct {
+	Kind   string  // "bridge", "thin" or "hole"
+	Layer  int     // layer number
+	Z      float64 // layer height
+	Center V2      // center of the region
+	Size   float64 // bridge span, wall thickness or hole diameter
+	Area   float64 // area of the region
+}
+
+// PrintReport is the result of a printability check.
+type PrintReport struct {
+	Box    Box3         // bounding box of the oriented part
+	Layers int          // number of layers
+	Issues []PrintIssue // issues, in layer order
+	nx, ny int          // preview size
+	top    []float64    // preview height map
+	mark   []string     // preview issue marks
+}
+
+// Printability returns a printability report for an SDF3.
+func Printability(s SDF3, k *PrintabilityParms) (*PrintReport, error) {
+	if k.LayerHeight <= 0 {
+		return nil, errors.New("layer height <= 0")
+	}
+	if k.Nozzle <= 0 {
+		return nil, errors.New("nozzle <= 0")
+	}
+	if k.MaxBridge < 0 {
+		return nil, errors.New("max bridge < 0")
+	}
+	if k.MinHole < 0 {
+		return nil, errors.New("min hole < 0")
+	}
+	if k.Resolution < 0 {
+		return nil, errors.New("resolution < 0")
+	}
+	if k.Orientation != (M44{}) {
+		s = Transform3D(s, k.Orientation)
+	}
+	res := k.Resolution
+	if res == 0 {
+		res = 0.25 * k.Nozzle
+	}
+	angle := k.Angle
+	if angle == 0 {
+		angle = DtoR(45)
+	}
+	allowance := k.LayerHeight * math.Tan(angle)
+
+	bb := s.BoundingBox()
+	// one empty cell around the part, so the outside is connected to the grid edge
+	origin := V2{bb.Min.X, bb.Min.Y}.SubScalar(res)
+	nx := int(math.Ceil(bb.Size().X/res)) + 2
+	ny := int(math.Ceil(bb.Size().Y/res)) + 2
+	layers := int(math.Ceil(bb.Size().Z / k.LayerHeight))
+	if nx*ny > 1<<24 {
+		return nil, errors.New("raster is too large, increase the resolution")
+	}
+
+	r := PrintReport{
+		Box:    bb,
+		Layers: layers,
+		nx:     nx,
+		ny:     ny,
+		top:    make([]float64, nx*ny),
+		mark:   make([]string, nx*ny),
+	}
+	for i := range r.top {
+		r.top[i] = math.Inf(-1)
+	}
+	cell := func(i int) V2 {
+		return origin.Add(V2{float64(i%nx) + 0.5, float64(i/nx) + 0.5}.MulScalar(res))
+	}
+	// distance from cell centers to a boundary is about half a cell less than the center distances
+	dist := func(d2 float64) float64 {
+		return math.Sqrt(d2)*res - 0.5*res
+	}
+	// the widest point of a region falls on or between cell centers
+	width := func(d2 float64) float64 {
+		return 2*dist(d2) + 0.5*res
+	}
+	issue := func(kind string, layer int, z float64, c []int, size float64) {
+		center := V2{}
+		for _, i := range c {
+			center = center.Add(cell(i))
+			r.mark[i] = kind
+		}
+		r.Issues = append(r.Issues, PrintIssue{
+			Kind:   kind,
+			Layer:  layer,
+			Z:      z,
+			Center: center.DivScalar(float64(len(c))),
+			Size:   size,
+			Area:   float64(len(c)) * res * res,
+		})
+	}
+
+	var below []bool
+	solid := make([]bool, nx*ny)
+	empty := make([]bool, nx*ny)
+	for layer := 0; layer < layers; layer++ {
+		z := bb.Min.Z + (float64(layer)+0.5)*k.LayerHeight
+		for i := range solid {
+			solid[i] = s.Evaluate(cell(i).ToV3(z)) < 0
+			empty[i] = !solid[i]
+			if solid[i] {
+				r.top[i] = z
+			}
+		}
+
+		// thin walls: solid cells not covered by a nozzle width disk within the layer
+		rn := 0.5 * k.Nozzle
+		din := edt2(empty, nx, ny)
+		core := make([]bool, nx*ny)
+		for i := range core {
+			core[i] = solid[i] && dist(din[i]) >= rn-0.5*res
+		}
+		dcore := edt2(core, nx, ny)
+		thin := make([]bool, nx*ny)
+		for i := range thin {
+			thin[i] = solid[i] && math.Sqrt(dcore[i])*res > rn
+		}
+		for _, c := range components(thin, nx, ny) {
+			t := 0.0
+			for _, i := range c {
+				t = Max(t, width(din[i]))
+			}
+			issue("thin", layer, z, c, t)
+		}
+
+		// holes: enclosed empty regions with a small inscribed circle
+		dout := edt2(solid, nx, ny)
+		for _, c := range components(empty, nx, ny) {
+			enclosed := true
+			d := 0.0
+			for _, i := range c {
+				x, y := i%nx, i/nx
+				if x == 0 || y == 0 || x == nx-1 || y == ny-1 {
+					enclosed = false
+					break
+				}
+				d = Max(d, width(dout[i]))
+			}
+			if enclosed && d < k.MinHole {
+				issue("hole", layer, z, c, d)
+			}
+		}
+
+		// bridges: cells beyond the overhang allowance of the layer below
+		if below != nil {
+			dbelow := edt2(below, nx, ny)
+			unsupported := make([]bool, nx*ny)
+			anchor := make([]bool, nx*ny)
+			for i := range solid {
+				if solid[i] {
+					unsupported[i] = math.Sqrt(dbelow[i])*res > allowance+0.5*res
+					anchor[i] = !unsupported[i]
+				}
+			}
+			danchor := edt2(anchor, nx, ny)
+			for _, c := range components(unsupported, nx, ny) {
+				span := 0.0
+				for _, i := range c {
+					span = Max(span, 2*math.Sqrt(danchor[i])*res)
+				}
+				if span > k.MaxBridge {
+					issue("bridge", layer, z, c, span)
+				}
+			}
+		}
+		below = append(below[:0], solid...)
+	}
+	return &r, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// Count returns the number of issues of a kind.
+func (r *PrintReport) Count(kind string) int {
+	n := 0
+	for _, x := range r.Issues {
+		if x.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// String returns a summary of the report.
+func (r *PrintReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d layers, %d bridge, %d thin, %d hole issues\n",
+		r.Layers, r.Count("bridge"), r.Count("thin"), r.Count("hole"))
+	for _, x := range r.Issues {
+		fmt.Fprintf(&sb, "layer %d z %.3f: %s %.3f at (%.3f, %.3f) area %.3f\n",
+			x.Layer, x.Z, x.Kind, x.Size, x.Center.X, x.Center.Y, x.Area)
+	}
+	return sb.String()
+}
+
+// printIssueColor are the preview colors for the issue kinds.
+var printIssueColor = map[string]color.RGBA{
+	"bridge": {0xff, 0x00, 0x00, 0xff},
+	"thin":   {0x00, 0x00, 0xff, 0xff},
+	"hole":   {0x00, 0xc0, 0x00, 0xff},
+}
+
+// Image returns a top view preview of the part, shaded by height, with the issue regions colored.
+// Bridges are red, thin walls are blue and holes are green.
+func (r *PrintReport) Image() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, r.nx, r.ny))
+	h := r.Box.Size().Z
+	for i, z := range r.top {
+		x, y := i%r.nx, r.ny-1-i/r.nx
+		var c color.RGBA
+		if k, ok := printIssueColor[r.mark[i]]; ok {
+			c = k
+		} else if math.IsInf(z, -1) {
+			c = color.RGBA{0xff, 0xff, 0xff, 0xff}
+		} else {
+			v := uint8(64 + 128*(z-r.Box.Min.Z)/Max(h, 1e-9))
+			c = color.RGBA{v, v, v, 0xff}
+		}
+		img.SetRGBA(x, y, c)
+	}
+	return img
+}
+
+// SavePNG writes the preview image to a PNG file.
+func (r *PrintReport) SavePNG(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, r.Image())
+}
+
+//-----------------------------------------------------------------------------
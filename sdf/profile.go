@@ -0,0 +1,244 @@
+//-----------------------------------------------------------------------------
+/*
+
+Per-Node Evaluation Profiling
+
+Wrap the nodes of an SDF tree with a profiler to count the Evaluate calls
+and the cumulative evaluation time for each node. After a render the report
+shows which subtree is making the render slow.
+
+Profile wraps every node of a tree, naming each node by its path from the
+root (as Check does). Profile3D/Profile2D wrap single nodes by hand.
+
+The time for a node includes the time for any profiled nodes below it.
+The wrappers add timing overhead, so only use them when profiling.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+//-----------------------------------------------------------------------------
+
+// profileNode holds the profiling counters for a node.
+type profileNode struct {
+	name  string // node name
+	calls int64  // number of Evaluate calls
+	nsecs int64  // cumulative evaluation time (nanoseconds)
+}
+
+func (n *profileNode) add(start time.Time) {
+	atomic.AddInt64(&n.calls, 1)
+	atomic.AddInt64(&n.nsecs, int64(time.Since(start)))
+}
+
+// Profiler collects evaluation statistics for SDF nodes.
+type Profiler struct {
+	nodes []*profileNode
+	lock  sync.Mutex
+}
+
+// NewProfiler returns a new SDF profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{}
+}
+
+func (p *Profiler) newNode(name string) *profileNode {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	n := &profileNode{name: name}
+	p.nodes = append(p.nodes, n)
+	return n
+}
+
+// Reset zeroes the profiling counters.
+func (p *Profiler) Reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, n := range p.nodes {
+		atomic.StoreInt64(&n.calls, 0)
+		atomic.StoreInt64(&n.nsecs, 0)
+	}
+}
+
+// Report writes the profiling results, slowest node first.
+func (p *Profiler) Report(w io.Writer) {
+	p.lock.Lock()
+	nodes := make([]profileNode, len(p.nodes))
+	for i, n := range p.nodes {
+		nodes[i] = profileNode{n.name, atomic.LoadInt64(&n.calls), atomic.LoadInt64(&n.nsecs)}
+	}
+	p.lock.Unlock()
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].nsecs > nodes[j].nsecs
+	})
+	fmt.Fprintf(w, "%12s %14s %12s  %s\n", "calls", "total", "per call", "node")
+	for _, n := range nodes {
+		var per time.Duration
+		if n.calls != 0 {
+			per = time.Duration(n.nsecs / n.calls)
+		}
+		fmt.Fprintf(w, "%12d %14s %12s  %s\n", n.calls, time.Duration(n.nsecs), per, n.name)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// ProfileSDF3 is an SDF3 with evaluation profiling.
+type ProfileSDF3 struct {
+	sdf  SDF3
+	node *profileNode
+}
+
+// Profile3D returns an SDF3 that records evaluation statistics with the profiler.
+func (p *Profiler) Profile3D(sdf SDF3, name string) SDF3 {
+	return &ProfileSDF3{sdf, p.newNode(name)}
+}
+
+// Evaluate returns the minimum distance to a profiled SDF3.
+func (s *ProfileSDF3) Evaluate(p V3) float64 {
+	start := time.Now()
+	d := s.sdf.Evaluate(p)
+	s.node.add(start)
+	return d
+}
+
+// BoundingBox returns the bounding box of a profiled SDF3.
+func (s *ProfileSDF3) BoundingBox() Box3 {
+	return s.sdf.BoundingBox()
+}
+
+//-----------------------------------------------------------------------------
+
+// ProfileSDF2 is an SDF2 with evaluation profiling.
+type ProfileSDF2 struct {
+	sdf  SDF2
+	node *profileNode
+}
+
+// Profile2D returns an SDF2 that records evaluation statistics with the profiler.
+func (p *Profiler) Profile2D(sdf SDF2, name string) SDF2 {
+	return &ProfileSDF2{sdf, p.newNode(name)}
+}
+
+// Evaluate returns the minimum distance to a profiled SDF2.
+func (s *ProfileSDF2) Evaluate(p V2) float64 {
+	start := time.Now()
+	d := s.sdf.Evaluate(p)
+	s.node.add(start)
+	return d
+}
+
+// BoundingBox returns the bounding box of a profiled SDF2.
+func (s *ProfileSDF2) BoundingBox() Box2 {
+	return s.sdf.BoundingBox()
+}
+
+//-----------------------------------------------------------------------------
+// Profile Trees
+
+// profileTree wraps the nodes of a tree with profilers.
+type profileTree struct {
+	p    *Profiler
+	done map[interface{}]interface{} // wrapped nodes, so shared nodes stay shared
+}
+
+// Profile returns a copy of an SDF3 tree with every node profiled, and the profiler.
+// Each node is named by its path from the root. Nodes with children are copied with
+// their children replaced, the original tree is unchanged.
+func Profile(s SDF3) (SDF3, *Profiler) {
+	t := profileTree{NewProfiler(), make(map[interface{}]interface{})}
+	return t.wrap(s, NodeName(s)).(SDF3), t.p
+}
+
+// wrap returns a profiled copy of a node and its children.
+func (t *profileTree) wrap(node interface{}, path string) interface{} {
+	comparable := node != nil && reflect.TypeOf(node).Comparable()
+	if comparable {
+		if x, ok := t.done[node]; ok {
+			return x
+		}
+	}
+	x := t.copyNode(node, path)
+	switch n := x.(type) {
+	case SDF3:
+		x = t.p.Profile3D(n, path)
+	case SDF2:
+		x = t.p.Profile2D(n, path)
+	}
+	if comparable {
+		t.done[node] = x
+	}
+	return x
+}
+
+// copyNode returns a copy of a node with its children wrapped.
+func (t *profileTree) copyNode(node interface{}, path string) interface{} {
+	children := Children(node)
+	v := reflect.ValueOf(node)
+	if len(children) == 0 || v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return node
+	}
+	// name the children as Check does
+	names := make(map[interface{}]string)
+	for i, c := range children {
+		if c == nil || !reflect.TypeOf(c).Comparable() {
+			continue
+		}
+		name := NodeName(c)
+		if len(children) > 1 {
+			name = fmt.Sprintf("%s[%d]", name, i)
+		}
+		if _, ok := names[c]; !ok {
+			names[c] = path + "/" + name
+		}
+	}
+	// replace the child fields of a copy of the node
+	child := func(f reflect.Value) {
+		if f.IsNil() || !f.Elem().Type().Comparable() {
+			return
+		}
+		if name, ok := names[f.Interface()]; ok {
+			f.Set(reflect.ValueOf(t.wrap(f.Interface(), name)))
+		}
+	}
+	x := reflect.New(v.Elem().Type())
+	x.Elem().Set(v.Elem())
+	for i := 0; i < x.Elem().NumField(); i++ {
+		f := x.Elem().Field(i)
+		// the fields are unexported
+		f = reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+		switch f.Kind() {
+		case reflect.Interface:
+			child(f)
+		case reflect.Slice:
+			if f.Type().Elem().Kind() != reflect.Interface || f.IsNil() {
+				continue
+			}
+			c := reflect.MakeSlice(f.Type(), f.Len(), f.Len())
+			reflect.Copy(c, f)
+			for j := 0; j < c.Len(); j++ {
+				child(c.Index(j))
+			}
+			f.Set(c)
+		}
+	}
+	if b, ok := x.Interface().(*BVHUnionSDF3); ok {
+		// the hierarchy refers to the children
+		return BVHUnion3D(b.sdf...)
+	}
+	return x.Interface()
+}
+
+//-----------------------------------------------------------------------------
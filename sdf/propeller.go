@@ -0,0 +1,202 @@
+//-----------------------------------------------------------------------------
+/*
+
+Propellers and Fan Blades
+
+A blade is an airfoil section lofted along a radial stacking line. The chord,
+twist (pitch angle) and offset of the section are given at stations along the
+blade and interpolated linearly between them.
+
+The propeller axis is the z-axis and the thrust is in the +z direction when
+the propeller turns counter-clockwise (viewed from +z). The first blade is on
+the +x axis.
+
+Section coordinates: the airfoil has its leading edge at the origin, a chord
+of 1 along the x-axis and the upper (suction) surface on +y, E.g. from NACA2D.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+//-----------------------------------------------------------------------------
+
+// BladeStation defines the blade section at a radius.
+type BladeStation struct {
+	Radius float64 // radial position of the section
+	Chord  float64 // chord length
+	Twist  float64 // pitch angle of the chord line from the plane of rotation (radians)
+	Sweep  float64 // offset of the section in the direction of rotation
+	Rake   float64 // offset of the section along the axis
+}
+
+// BladeSDF3 is an airfoil section lofted along a radial line (the x-axis).
+type BladeSDF3 struct {
+	section  SDF2           // unit chord airfoil section
+	stations []BladeStation // blade stations, sorted by radius
+	pivot    float64        // chord fraction of the stacking line
+	bb       Box3           // bounding box
+}
+
+// Blade3D returns a blade on the x-axis with the section placed at each station.
+func Blade3D(
+	section SDF2, // unit chord airfoil section
+	stations []BladeStation, // blade stations
+	pivot float64, // chord fraction of the stacking line, E.g. 0.25
+) (SDF3, error) {
+	if section == nil {
+		return nil, errors.New("nil section")
+	}
+	if len(stations) < 2 {
+		return nil, errors.New("need at least 2 stations")
+	}
+	s := BladeSDF3{
+		section:  section,
+		stations: append([]BladeStation(nil), stations...),
+		pivot:    pivot,
+	}
+	sort.SliceStable(s.stations, func(i, j int) bool { return s.stations[i].Radius < s.stations[j].Radius })
+	for i, x := range s.stations {
+		if x.Chord <= 0 {
+			return nil, errors.New("chord <= 0")
+		}
+		if i > 0 && x.Radius == s.stations[i-1].Radius {
+			return nil, errors.New("duplicate station radius")
+		}
+	}
+	// the section extent about the stacking point
+	sbb := section.BoundingBox()
+	r := 0.0
+	for _, v := range sbb.Vertices() {
+		r = Max(r, v.Sub(V2{pivot, 0}).Length())
+	}
+	k := 0.0
+	for _, x := range s.stations {
+		k = Max(k, r*x.Chord+V2{x.Sweep, x.Rake}.Length())
+	}
+	r0 := s.stations[0].Radius
+	r1 := s.stations[len(s.stations)-1].Radius
+	s.bb = Box3{V3{r0, -k, -k}, V3{r1, k, k}}
+	return &s, nil
+}
+
+// station returns the interpolated blade station at a radius.
+func (s *BladeSDF3) station(r float64) BladeStation {
+	n := len(s.stations)
+	i := sort.Search(n, func(i int) bool { return s.stations[i].Radius >= r })
+	if i == 0 {
+		return s.stations[0]
+	}
+	if i == n {
+		return s.stations[n-1]
+	}
+	a := s.stations[i-1]
+	b := s.stations[i]
+	t := (r - a.Radius) / (b.Radius - a.Radius)
+	return BladeStation{
+		Radius: r,
+		Chord:  Mix(a.Chord, b.Chord, t),
+		Twist:  Mix(a.Twist, b.Twist, t),
+		Sweep:  Mix(a.Sweep, b.Sweep, t),
+		Rake:   Mix(a.Rake, b.Rake, t),
+	}
+}
+
+// Evaluate returns the minimum distance to a blade.
+func (s *BladeSDF3) Evaluate(p V3) float64 {
+	x := s.station(p.X)
+	// the chord runs from the leading edge (+y) back to the trailing edge,
+	// rising toward the leading edge by the twist angle.
+	sin, cos := math.Sincos(x.Twist)
+	d := V2{p.Y - x.Sweep, p.Z - x.Rake}
+	u := s.pivot*x.Chord - d.X*cos - d.Y*sin
+	v := -d.X*sin + d.Y*cos
+	d2 := s.section.Evaluate(V2{u, v}.DivScalar(x.Chord)) * x.Chord
+	// flat ends at the first and last stations
+	r0 := s.stations[0].Radius
+	r1 := s.stations[len(s.stations)-1].Radius
+	return Max(d2, Max(r0-p.X, p.X-r1))
+}
+
+// BoundingBox returns the bounding box of a blade.
+func (s *BladeSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a blade.
+func (s *BladeSDF3) Children() []interface{} {
+	return []interface{}{s.section}
+}
+
+//-----------------------------------------------------------------------------
+
+// PropellerParms defines the parameters for a propeller or fan.
+type PropellerParms struct {
+	Blades     int            // number of blades
+	Section    SDF2           // unit chord airfoil section (nil = NACA 4412)
+	Stations   []BladeStation // blade stations from root to tip
+	Pivot      float64        // chord fraction of the stacking line (0 = 0.25)
+	HubRadius  float64        // hub radius
+	HubHeight  float64        // hub height
+	Bore       float64        // shaft bore diameter (0 = no bore)
+	Clockwise  bool           // the propeller turns clockwise (viewed from +z) for +z thrust
+	HubRounded float64        // hub edge rounding
+}
+
+// PropellerTwist returns the twist at a radius for a geometric pitch (advance per revolution).
+func PropellerTwist(pitch, radius float64) float64 {
+	return math.Atan2(pitch, Tau*radius)
+}
+
+// Propeller3D returns a propeller with blades on a hub. The hub is centered on the origin.
+func Propeller3D(k *PropellerParms) (SDF3, error) {
+	if k.Blades <= 0 {
+		return nil, errors.New("blades <= 0")
+	}
+	if k.HubRadius <= 0 {
+		return nil, errors.New("hub radius <= 0")
+	}
+	if k.HubHeight <= 0 {
+		return nil, errors.New("hub height <= 0")
+	}
+	if k.Bore < 0 || k.Bore >= 2*k.HubRadius {
+		return nil, errors.New("invalid bore")
+	}
+	if len(k.Stations) != 0 && k.Stations[0].Radius > k.HubRadius {
+		return nil, errors.New("the blade root is outside the hub")
+	}
+	section := k.Section
+	if section == nil {
+		var err error
+		section, err = NACA2D(&NACAParms{Code: "4412", Chord: 1, TrailingEdge: 0.01})
+		if err != nil {
+			return nil, err
+		}
+	}
+	pivot := k.Pivot
+	if pivot == 0 {
+		pivot = 0.25
+	}
+	blade, err := Blade3D(section, k.Stations, pivot)
+	if err != nil {
+		return nil, err
+	}
+	if k.Clockwise {
+		blade = Transform3D(blade, MirrorXZ())
+	}
+	blades := RotateUnion3D(blade, k.Blades, RotateZ(Tau/float64(k.Blades)))
+	hub := Cylinder3D(k.HubHeight, k.HubRadius, k.HubRounded)
+	s := Union3D(hub, blades)
+	if k.Bore > 0 {
+		s = Difference3D(s, Cylinder3D(k.HubHeight+1, 0.5*k.Bore, 0))
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
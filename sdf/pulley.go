@@ -0,0 +1,165 @@
+//-----------------------------------------------------------------------------
+/*
+
+Timing Belt Pulleys
+
+The pulley grooves approximate the belt tooth profile with a circular arc
+bottom (and parallel walls for the deep HTD grooves) and rounded edges.
+The groove sizes are the usual values for printed pulleys:
+
+profile  pitch  pitch line  groove  groove
+                distance    depth   width
+GT2      2      0.254       0.764   1.494
+GT3      3      0.381       1.169   2.310
+HTD 3M   3      0.381       1.289   2.270
+HTD 5M   5      0.5715      2.199   3.781
+
+The outside diameter is the pitch diameter less twice the pitch line distance
+(the belt tensile cords run on the pitch circle).
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// BeltProfile is the tooth profile of a timing belt.
+type BeltProfile int
+
+const (
+	// BeltGT2 is a 2 mm pitch GT2 belt.
+	BeltGT2 BeltProfile = iota
+	// BeltGT3 is a 3 mm pitch GT3 belt.
+	BeltGT3
+	// BeltHTD3M is a 3 mm pitch HTD belt.
+	BeltHTD3M
+	// BeltHTD5M is a 5 mm pitch HTD belt.
+	BeltHTD5M
+)
+
+// beltGroove defines the pulley groove for a belt profile.
+type beltGroove struct {
+	pitch float64 // belt pitch
+	pld   float64 // pitch line distance
+	depth float64 // groove depth
+	width float64 // groove width
+}
+
+var beltGrooves = map[BeltProfile]beltGroove{
+	BeltGT2:   {2, 0.254, 0.764, 1.494},
+	BeltGT3:   {3, 0.381, 1.169, 2.310},
+	BeltHTD3M: {3, 0.381, 1.289, 2.270},
+	BeltHTD5M: {5, 0.5715, 2.199, 3.781},
+}
+
+// PulleyParms defines the parameters for a timing belt pulley.
+type PulleyParms struct {
+	Profile         BeltProfile // belt profile
+	Teeth           int         // number of teeth
+	Width           float64     // width of the toothed section (the belt width plus clearance)
+	Flange          float64     // flange height above the outside diameter (0 = no flanges)
+	FlangeThickness float64     // thickness of each flange
+	Bore            float64     // bore diameter (0 = no bore)
+}
+
+// groove returns the groove parameters for the pulley.
+func (k *PulleyParms) groove() (*beltGroove, error) {
+	g, ok := beltGrooves[k.Profile]
+	if !ok {
+		return nil, errors.New("bad belt profile")
+	}
+	return &g, nil
+}
+
+// PitchDiameter returns the pitch diameter of the pulley.
+func (k *PulleyParms) PitchDiameter() float64 {
+	g, err := k.groove()
+	if err != nil {
+		return 0
+	}
+	return g.pitch * float64(k.Teeth) / Pi
+}
+
+// OutsideDiameter returns the outside diameter of the pulley.
+func (k *PulleyParms) OutsideDiameter() float64 {
+	g, err := k.groove()
+	if err != nil {
+		return 0
+	}
+	return k.PitchDiameter() - 2*g.pld
+}
+
+// TimingPulley2D returns the 2D profile of a timing belt pulley centered on the origin.
+// The grooves are centered on the angles 2*pi*i/Teeth.
+func TimingPulley2D(k *PulleyParms) (SDF2, error) {
+	g, err := k.groove()
+	if err != nil {
+		return nil, err
+	}
+	if k.Teeth < 8 {
+		return nil, errors.New("teeth < 8")
+	}
+	r := 0.5 * k.OutsideDiameter()
+	w := 0.5 * g.width
+	var groove SDF2
+	if g.depth > w {
+		// semicircular bottom with parallel walls
+		c := Transform2D(Circle2D(w), Translate2d(V2{r - g.depth + w, 0}))
+		h := g.depth - w + g.pitch
+		walls := Transform2D(Box2D(V2{h, g.width}, 0), Translate2d(V2{r - g.depth + w + 0.5*h, 0}))
+		groove = Union2D(c, walls)
+	} else {
+		// circular arc through the groove edges and bottom
+		c := (w*w - g.depth*g.depth) / (2 * g.depth)
+		groove = Transform2D(Circle2D(c+g.depth), Translate2d(V2{r + c, 0}))
+	}
+	grooves := RotateCopy2D(groove, k.Teeth)
+	s := Difference2D(Circle2D(r), grooves)
+	// round the groove edges
+	s.(*DifferenceSDF2).SetMax(PolyMax(0.25 * (g.pitch - g.width)))
+	return s, nil
+}
+
+// TimingPulley3D returns a timing belt pulley centered on the origin with its axis along the z-axis.
+// The toothed section is centered on z = 0, with the flanges (if any) on each side.
+func TimingPulley3D(k *PulleyParms) (SDF3, error) {
+	s2, err := TimingPulley2D(k)
+	if err != nil {
+		return nil, err
+	}
+	if k.Width <= 0 {
+		return nil, errors.New("width <= 0")
+	}
+	if k.Flange < 0 {
+		return nil, errors.New("flange < 0")
+	}
+	if k.Flange > 0 && k.FlangeThickness <= 0 {
+		return nil, errors.New("flange thickness <= 0")
+	}
+	g, _ := k.groove()
+	if k.Bore < 0 || k.Bore >= k.OutsideDiameter()-2*g.depth {
+		return nil, errors.New("bad bore diameter")
+	}
+	s := Extrude3D(s2, k.Width)
+	if k.Flange > 0 {
+		r := 0.5*k.OutsideDiameter() + k.Flange
+		t := k.FlangeThickness
+		flange := Cylinder3D(t, r, 0)
+		z := 0.5 * (k.Width + t)
+		s = Union3D(
+			s,
+			Transform3D(flange, Translate3d(V3{0, 0, z})),
+			Transform3D(flange, Translate3d(V3{0, 0, -z})),
+		)
+	}
+	if k.Bore > 0 {
+		h := k.Width + 2*k.FlangeThickness + 1
+		s = Difference3D(s, Cylinder3D(h, 0.5*k.Bore, 0))
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,127 @@
+//-----------------------------------------------------------------------------
+/*
+
+Ratchets and Pawls
+
+A ratchet wheel has sawtooth teeth, each with a steep locking face and a
+sloping back. The locking face leans forward from a radial line by the tooth
+angle, so a loaded pawl is pulled into the tooth rather than pushed out of it.
+
+The wheel turns freely clockwise (the pawl rides up the tooth backs) and is
+locked counter-clockwise.
+
+The pawl is a lever with its pivot on the tangent to the tip circle at the
+engaged tooth. Its nose is cut from the wheel profile (plus clearance) so it
+matches the tooth space.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// RatchetParms defines the parameters for a ratchet wheel and pawl.
+type RatchetParms struct {
+	Teeth         int     // number of teeth
+	OuterRadius   float64 // tip radius of the teeth
+	RootRadius    float64 // root radius of the teeth
+	ToothAngle    float64 // lean of the locking face from radial (radians, >= 0)
+	PawlLength    float64 // distance along the tangent from the tooth tip to the pawl pivot
+	PawlWidth     float64 // width of the pawl
+	PivotDiameter float64 // diameter of the pawl pivot hole (0 = no hole)
+	Clearance     float64 // clearance between the pawl nose and the tooth space
+}
+
+// check returns an error if the ratchet parameters are invalid.
+func (k *RatchetParms) check() error {
+	if k.Teeth < 3 {
+		return errors.New("teeth < 3")
+	}
+	if k.RootRadius <= 0 {
+		return errors.New("root radius <= 0")
+	}
+	if k.OuterRadius <= k.RootRadius {
+		return errors.New("outer radius <= root radius")
+	}
+	if k.ToothAngle < 0 || k.OuterRadius*math.Sin(k.ToothAngle) >= k.RootRadius {
+		return errors.New("bad tooth angle")
+	}
+	if math.Atan2(k.root().Y, k.root().X) < -Pi/float64(k.Teeth) {
+		return errors.New("tooth angle is too large")
+	}
+	return nil
+}
+
+// root returns the root end of the locking face of the tooth with its tip on the +x axis.
+func (k *RatchetParms) root() V2 {
+	ro, rr := k.OuterRadius, k.RootRadius
+	sin, cos := math.Sincos(k.ToothAngle)
+	t := ro*cos - math.Sqrt(rr*rr-ro*ro*sin*sin)
+	return V2{ro - t*cos, -t * sin}
+}
+
+// Ratchet2D returns the 2D profile of a ratchet wheel centered on the origin.
+// The tips of the locking faces are at the angles 2*pi*i/Teeth.
+func Ratchet2D(k *RatchetParms) (SDF2, error) {
+	if err := k.check(); err != nil {
+		return nil, err
+	}
+	tip := V2{k.OuterRadius, 0}
+	root := k.root()
+	v := make([]V2, 0, 2*k.Teeth)
+	for i := 0; i < k.Teeth; i++ {
+		m := Rotate(Tau * float64(i) / float64(k.Teeth))
+		v = append(v, m.MulPosition(tip), m.MulPosition(root))
+	}
+	return Polygon2D(v), nil
+}
+
+// PawlPivot returns the position of the pawl pivot.
+func (k *RatchetParms) PawlPivot() V2 {
+	return V2{k.OuterRadius + 0.5*k.PawlWidth, k.PawlLength}
+}
+
+// Pawl2D returns the 2D profile of a pawl engaged with the tooth on the +x axis of the ratchet
+// wheel returned by Ratchet2D. The pawl lies on the counter-clockwise side of the tooth.
+func Pawl2D(k *RatchetParms) (SDF2, error) {
+	wheel, err := Ratchet2D(k)
+	if err != nil {
+		return nil, err
+	}
+	if k.PawlWidth <= 0 {
+		return nil, errors.New("pawl width <= 0")
+	}
+	if k.PawlLength <= k.PawlWidth {
+		return nil, errors.New("pawl length <= pawl width")
+	}
+	if k.PivotDiameter < 0 || k.PivotDiameter >= k.PawlWidth {
+		return nil, errors.New("bad pivot diameter")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+	w := k.PawlWidth
+	p := k.PawlPivot()
+	// the bar runs from the root of the tooth space to the pivot, the wheel trims the nose
+	bar := Polygon2D([]V2{
+		k.root(),
+		{k.OuterRadius + w, 0},
+		p.Add(V2{0.5 * w, 0}),
+		p.Sub(V2{0.5 * w, 0}),
+	})
+	boss := Transform2D(Circle2D(0.5*w), Translate2d(p))
+	s := Difference2D(Union2D(bar, boss), Offset2D(wheel, k.Clearance))
+	if k.PivotDiameter > 0 {
+		hole := Transform2D(Circle2D(0.5*k.PivotDiameter), Translate2d(p))
+		s = Difference2D(s, hole)
+	}
+	return s, nil
+}
+
+//-----------------------------------------------------------------------------
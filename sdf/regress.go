@@ -0,0 +1,158 @@
+//-----------------------------------------------------------------------------
+/*
+
+Geometry Regression Testing
+
+Compare a newly generated model with a stored reference model. The
+comparison reports the Hausdorff distance between the surfaces and the
+volume difference, and checks them against tolerances.
+
+The Hausdorff distance is estimated by sampling the vertices and triangle
+centroids of each mesh and measuring the distance to the other mesh.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// GeometryDiff stores the differences between two models.
+type GeometryDiff struct {
+	Hausdorff   float64 // Hausdorff distance between the surfaces
+	Distance0   float64 // maximum distance from model 0 to model 1
+	Distance1   float64 // maximum distance from model 1 to model 0
+	Volume0     float64 // volume of model 0
+	Volume1     float64 // volume of model 1
+	VolumeDelta float64 // volume 1 - volume 0
+}
+
+// directedDistance returns the maximum distance from the sample points of mesh a to mesh b.
+func directedDistance(a []*Triangle3, b *MeshSDF3) float64 {
+	d := 0.0
+	for _, t := range a {
+		for _, v := range t.V {
+			d = Max(d, b.distance(v))
+		}
+		c := t.V[0].Add(t.V[1]).Add(t.V[2]).DivScalar(3)
+		d = Max(d, b.distance(c))
+	}
+	return d
+}
+
+// CompareMesh returns the differences between two triangle meshes.
+func CompareMesh(m0, m1 []*Triangle3) (*GeometryDiff, error) {
+	s0, err := Mesh3D(m0)
+	if err != nil {
+		return nil, err
+	}
+	s1, err := Mesh3D(m1)
+	if err != nil {
+		return nil, err
+	}
+	d := GeometryDiff{
+		Distance0: directedDistance(m0, s1.(*MeshSDF3)),
+		Distance1: directedDistance(m1, s0.(*MeshSDF3)),
+		Volume0:   MeshVolume(m0),
+		Volume1:   MeshVolume(m1),
+	}
+	d.Hausdorff = Max(d.Distance0, d.Distance1)
+	d.VolumeDelta = d.Volume1 - d.Volume0
+	return &d, nil
+}
+
+// CompareSDF3 renders two SDF3s and returns the differences between them.
+func CompareSDF3(
+	s0, s1 SDF3, // sdf3s to compare
+	meshCells int, // number of cells on the longest axis. e.g 200
+) (*GeometryDiff, error) {
+	return CompareMesh(RenderMesh(s0, meshCells), RenderMesh(s1, meshCells))
+}
+
+// Check returns an error if the differences exceed the tolerances.
+// The volume tolerance is a fraction of the reference (model 0) volume.
+func (d *GeometryDiff) Check(
+	tolerance float64, // maximum Hausdorff distance
+	volumeTolerance float64, // maximum volume change as a fraction, E.g. 0.01 = 1%
+) error {
+	var errs []string
+	if d.Hausdorff > tolerance {
+		errs = append(errs, fmt.Sprintf("hausdorff distance %g > %g", d.Hausdorff, tolerance))
+	}
+	if Abs(d.VolumeDelta) > volumeTolerance*d.Volume0 {
+		errs = append(errs, fmt.Sprintf("volume change %g > %g%%", d.VolumeDelta, 100*volumeTolerance))
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// String returns a description of the differences.
+func (d *GeometryDiff) String() string {
+	pct := 0.0
+	if d.Volume0 != 0 {
+		pct = 100 * d.VolumeDelta / d.Volume0
+	}
+	return fmt.Sprintf("hausdorff %g (%g, %g) volume %g -> %g (%+.3f%%)",
+		d.Hausdorff, d.Distance0, d.Distance1, d.Volume0, d.Volume1, pct)
+}
+
+//-----------------------------------------------------------------------------
+
+// RegressionParms defines a regression test against a stored reference model.
+type RegressionParms struct {
+	Reference       string  // reference file, an STL mesh (*.stl) or a voxel grid (other extensions)
+	MeshCells       int     // number of cells on the longest axis. e.g 200
+	Tolerance       float64 // maximum Hausdorff distance
+	VolumeTolerance float64 // maximum volume change as a fraction, E.g. 0.01 = 1%
+	Update          bool    // write the reference file from the model
+}
+
+// Regression compares a model with a stored reference and returns the differences.
+// The reference is written if it doesn't exist (or Update is set), and the differences are nil.
+// The error is non-nil if the model is out of tolerance.
+func Regression(s SDF3, k *RegressionParms) (*GeometryDiff, error) {
+	if k.MeshCells <= 0 {
+		return nil, errors.New("mesh cells <= 0")
+	}
+	if k.Tolerance <= 0 {
+		return nil, errors.New("tolerance <= 0")
+	}
+	stl := strings.HasSuffix(strings.ToLower(k.Reference), ".stl")
+	mesh := RenderMesh(s, k.MeshCells)
+	if _, err := os.Stat(k.Reference); os.IsNotExist(err) || k.Update {
+		if stl {
+			return nil, SaveSTL(k.Reference, mesh)
+		}
+		return nil, SaveVoxel(k.Reference, Voxel3D(s, k.MeshCells))
+	}
+	var ref []*Triangle3
+	if stl {
+		var err error
+		ref, err = LoadSTL(k.Reference)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		v, err := LoadVoxel(k.Reference)
+		if err != nil {
+			return nil, err
+		}
+		ref = RenderMesh(v, k.MeshCells)
+	}
+	d, err := CompareMesh(ref, mesh)
+	if err != nil {
+		return nil, err
+	}
+	return d, d.Check(k.Tolerance, k.VolumeTolerance)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,445 @@
+//-----------------------------------------------------------------------------
+/*
+
+Isotropic Remeshing
+
+Marching cubes produces meshes with many slivers and a vertex density that
+follows the sampling grid. Remeshing converts this into a near uniform mesh
+with a target edge length by iterating:
+
+1) split edges that are too long
+2) collapse edges that are too short
+3) flip edges to equalize vertex valence (target 6)
+4) tangential relaxation of the vertices
+5) projection of the vertices onto the SDF3 surface
+
+See: Botsch & Kobbelt, "A Remeshing Approach to Multiresolution Modeling"
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "sort"
+
+//-----------------------------------------------------------------------------
+
+// Gradient3 returns the normalized gradient of an SDF3 at p (central differences, step h).
+func Gradient3(s SDF3, p V3, h float64) V3 {
+	dx := s.Evaluate(p.Add(V3{h, 0, 0})) - s.Evaluate(p.Sub(V3{h, 0, 0}))
+	dy := s.Evaluate(p.Add(V3{0, h, 0})) - s.Evaluate(p.Sub(V3{0, h, 0}))
+	dz := s.Evaluate(p.Add(V3{0, 0, h})) - s.Evaluate(p.Sub(V3{0, 0, h}))
+	return V3{dx, dy, dz}.Normalize()
+}
+
+//-----------------------------------------------------------------------------
+
+// edgeKey is an undirected mesh edge.
+type edgeKey [2]int
+
+func newEdgeKey(a, b int) edgeKey {
+	if a < b {
+		return edgeKey{a, b}
+	}
+	return edgeKey{b, a}
+}
+
+// indexedMesh is a triangle mesh with shared vertices.
+type indexedMesh struct {
+	v []V3     // vertices
+	t [][3]int // triangles (vertex indices)
+}
+
+// newIndexedMesh welds the vertices of a triangle mesh.
+func newIndexedMesh(mesh []*Triangle3, tol float64) *indexedMesh {
+	m := indexedMesh{}
+	index := make(map[V3i]int)
+	weld := func(p V3) int {
+		k := p.DivScalar(tol).Ceil().ToV3i()
+		if i, ok := index[k]; ok {
+			return i
+		}
+		index[k] = len(m.v)
+		m.v = append(m.v, p)
+		return len(m.v) - 1
+	}
+	for _, t := range mesh {
+		a := weld(t.V[0])
+		b := weld(t.V[1])
+		c := weld(t.V[2])
+		if a != b && b != c && c != a {
+			m.t = append(m.t, [3]int{a, b, c})
+		}
+	}
+	return &m
+}
+
+// triangles returns the triangle mesh.
+func (m *indexedMesh) triangles() []*Triangle3 {
+	mesh := make([]*Triangle3, len(m.t))
+	for i, t := range m.t {
+		mesh[i] = NewTriangle3(m.v[t[0]], m.v[t[1]], m.v[t[2]])
+	}
+	return mesh
+}
+
+// edges returns the mesh edges (sorted) and the triangles using each edge.
+func (m *indexedMesh) edges() ([]edgeKey, map[edgeKey][]int) {
+	e2t := make(map[edgeKey][]int)
+	for i, t := range m.t {
+		for j := 0; j < 3; j++ {
+			k := newEdgeKey(t[j], t[(j+1)%3])
+			e2t[k] = append(e2t[k], i)
+		}
+	}
+	// sort the edges so the results don't depend on map ordering
+	edges := make([]edgeKey, 0, len(e2t))
+	for k := range e2t {
+		edges = append(edges, k)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i][0] == edges[j][0] {
+			return edges[i][1] < edges[j][1]
+		}
+		return edges[i][0] < edges[j][0]
+	})
+	return edges, e2t
+}
+
+// neighbours returns the sorted neighbour vertices for each vertex.
+func (m *indexedMesh) neighbours() [][]int {
+	nb := make([][]int, len(m.v))
+	edges, _ := m.edges()
+	for _, e := range edges {
+		nb[e[0]] = append(nb[e[0]], e[1])
+		nb[e[1]] = append(nb[e[1]], e[0])
+	}
+	return nb
+}
+
+// hasVertex returns true if the triangle uses vertex i.
+func hasVertex(t [3]int, i int) bool {
+	return t[0] == i || t[1] == i || t[2] == i
+}
+
+// length returns the length of an edge.
+func (m *indexedMesh) length(e edgeKey) float64 {
+	return m.v[e[0]].Sub(m.v[e[1]]).Length()
+}
+
+// normal returns the (non-normalized) normal of a triangle.
+func (m *indexedMesh) normal(t [3]int) V3 {
+	return m.v[t[1]].Sub(m.v[t[0]]).Cross(m.v[t[2]].Sub(m.v[t[0]]))
+}
+
+//-----------------------------------------------------------------------------
+
+// splitEdges splits all edges longer than hi, returns true if any were split.
+func (m *indexedMesh) splitEdges(hi float64) bool {
+	mid := make(map[edgeKey]int)
+	edges, _ := m.edges()
+	for _, e := range edges {
+		if m.length(e) > hi {
+			mid[e] = len(m.v)
+			m.v = append(m.v, m.v[e[0]].Add(m.v[e[1]]).MulScalar(0.5))
+		}
+	}
+	if len(mid) == 0 {
+		return false
+	}
+	var tlist [][3]int
+	for _, t := range m.t {
+		// work out which edges are split
+		var ms [3]int
+		n := 0
+		for j := 0; j < 3; j++ {
+			if k, ok := mid[newEdgeKey(t[j], t[(j+1)%3])]; ok {
+				ms[j] = k
+				n++
+			} else {
+				ms[j] = -1
+			}
+		}
+		// rotate the triangle to a canonical split pattern
+		r := 0
+		switch n {
+		case 1:
+			for ms[r] < 0 {
+				r++
+			}
+		case 2:
+			for ms[(r+2)%3] >= 0 {
+				r++
+			}
+		}
+		a, b, c := t[r], t[(r+1)%3], t[(r+2)%3]
+		mab, mbc, mca := ms[r], ms[(r+1)%3], ms[(r+2)%3]
+		switch n {
+		case 0:
+			tlist = append(tlist, t)
+		case 1:
+			tlist = append(tlist, [3]int{a, mab, c}, [3]int{mab, b, c})
+		case 2:
+			tlist = append(tlist, [3]int{mab, b, mbc}, [3]int{a, mab, mbc}, [3]int{a, mbc, c})
+		case 3:
+			tlist = append(tlist, [3]int{a, mab, mca}, [3]int{mab, b, mbc}, [3]int{mca, mbc, c}, [3]int{mab, mbc, mca})
+		}
+	}
+	m.t = tlist
+	return true
+}
+
+// collapseEdges collapses edges shorter than lo, returns true if any were collapsed.
+func (m *indexedMesh) collapseEdges(lo, hi float64) bool {
+	edges, e2t := m.edges()
+	nb := m.neighbours()
+	vtris := make([][]int, len(m.v))
+	for i, t := range m.t {
+		for _, j := range t {
+			vtris[j] = append(vtris[j], i)
+		}
+	}
+	touched := make([]bool, len(m.v))
+	deleted := make([]bool, len(m.t))
+	collapsed := false
+	for _, e := range edges {
+		a, b := e[0], e[1]
+		if touched[a] || touched[b] || len(e2t[e]) != 2 || m.length(e) >= lo {
+			continue
+		}
+		// link condition: a and b share exactly the 2 opposite vertices
+		common := 0
+		for _, x := range nb[a] {
+			for _, y := range nb[b] {
+				if x == y {
+					common++
+				}
+			}
+		}
+		if common != 2 {
+			continue
+		}
+		// the collapse must not create long edges or flip triangles
+		p := m.v[a].Add(m.v[b]).MulScalar(0.5)
+		ok := true
+		for _, x := range append(nb[a], nb[b]...) {
+			if x != a && x != b && m.v[x].Sub(p).Length() > hi {
+				ok = false
+			}
+		}
+		for _, i := range append(vtris[a], vtris[b]...) {
+			t := m.t[i]
+			if hasVertex(t, a) && hasVertex(t, b) {
+				// this triangle is removed by the collapse
+				continue
+			}
+			var q [3]V3
+			for j, k := range t {
+				if k == a || k == b {
+					q[j] = p
+				} else {
+					q[j] = m.v[k]
+				}
+			}
+			n := q[1].Sub(q[0]).Cross(q[2].Sub(q[0]))
+			if n.Dot(m.normal(t)) <= 0 {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		// collapse b into a
+		m.v[a] = p
+		for _, i := range vtris[b] {
+			t := &m.t[i]
+			for j := range t {
+				if t[j] == b {
+					t[j] = a
+				}
+			}
+			if t[0] == t[1] || t[1] == t[2] || t[2] == t[0] {
+				deleted[i] = true
+			}
+		}
+		for _, x := range append(nb[a], nb[b]...) {
+			touched[x] = true
+		}
+		touched[a] = true
+		touched[b] = true
+		collapsed = true
+	}
+	// remove the deleted triangles
+	tlist := m.t[:0]
+	for i, t := range m.t {
+		if !deleted[i] {
+			tlist = append(tlist, t)
+		}
+	}
+	m.t = tlist
+	return collapsed
+}
+
+// flipEdges flips edges to bring vertex valences closer to 6.
+func (m *indexedMesh) flipEdges() {
+	edges, e2t := m.edges()
+	valence := make([]int, len(m.v))
+	for _, e := range edges {
+		valence[e[0]]++
+		valence[e[1]]++
+	}
+	touched := make([]bool, len(m.v))
+	deviation := func(v ...int) int {
+		n := 0
+		for _, x := range v {
+			d := x - 6
+			if d < 0 {
+				d = -d
+			}
+			n += d
+		}
+		return n
+	}
+	for _, e := range edges {
+		tl := e2t[e]
+		if len(tl) != 2 || touched[e[0]] || touched[e[1]] {
+			// Note: flipped triangles only have touched vertices,
+			// so the edge to triangle map is valid for this edge.
+			continue
+		}
+		t0, t1 := m.t[tl[0]], m.t[tl[1]]
+		// orient t0 as (a, b, c) and t1 as (b, a, d)
+		r := 0
+		for !(newEdgeKey(t0[r], t0[(r+1)%3]) == e) {
+			r++
+		}
+		a, b, c := t0[r], t0[(r+1)%3], t0[(r+2)%3]
+		d := t1[0] + t1[1] + t1[2] - a - b
+		if touched[c] || touched[d] || c == d {
+			continue
+		}
+		if _, ok := e2t[newEdgeKey(c, d)]; ok {
+			// the flipped edge already exists
+			continue
+		}
+		before := deviation(valence[a], valence[b], valence[c], valence[d])
+		after := deviation(valence[a]-1, valence[b]-1, valence[c]+1, valence[d]+1)
+		if after >= before {
+			continue
+		}
+		n0 := [3]int{a, d, c}
+		n1 := [3]int{b, c, d}
+		n := m.normal(t0).Add(m.normal(t1))
+		if m.normal(n0).Dot(n) <= 0 || m.normal(n1).Dot(n) <= 0 {
+			continue
+		}
+		m.t[tl[0]] = n0
+		m.t[tl[1]] = n1
+		valence[a]--
+		valence[b]--
+		valence[c]++
+		valence[d]++
+		touched[a], touched[b], touched[c], touched[d] = true, true, true, true
+	}
+}
+
+// relax moves each vertex towards the centroid of its neighbours (tangentially).
+func (m *indexedMesh) relax() {
+	nb := m.neighbours()
+	vn := make([]V3, len(m.v))
+	for _, t := range m.t {
+		n := m.normal(t)
+		for _, i := range t {
+			vn[i] = vn[i].Add(n)
+		}
+	}
+	v := make([]V3, len(m.v))
+	for i, p := range m.v {
+		v[i] = p
+		if len(nb[i]) == 0 {
+			continue
+		}
+		var q V3
+		for _, j := range nb[i] {
+			q = q.Add(m.v[j])
+		}
+		u := q.DivScalar(float64(len(nb[i]))).Sub(p)
+		n := vn[i].Normalize()
+		v[i] = p.Add(u.Sub(n.MulScalar(n.Dot(u))))
+	}
+	m.v = v
+}
+
+// project moves the vertices onto the SDF3 surface.
+func (m *indexedMesh) project(s SDF3, h float64) {
+	for i, p := range m.v {
+		for j := 0; j < 3; j++ {
+			d := s.Evaluate(p)
+			p = p.Sub(Gradient3(s, p, h).MulScalar(d))
+		}
+		m.v[i] = p
+	}
+}
+
+// compact removes unused vertices.
+func (m *indexedMesh) compact() {
+	index := make([]int, len(m.v))
+	for i := range index {
+		index[i] = -1
+	}
+	var v []V3
+	for i := range m.t {
+		for j, k := range m.t[i] {
+			if index[k] < 0 {
+				index[k] = len(v)
+				v = append(v, m.v[k])
+			}
+			m.t[i][j] = index[k]
+		}
+	}
+	m.v = v
+}
+
+//-----------------------------------------------------------------------------
+
+// Remesh converts a triangle mesh of an SDF3 into a near uniform mesh with a target edge length.
+func Remesh(
+	s SDF3, // the sdf3 the mesh was generated from
+	mesh []*Triangle3, // the triangle mesh (E.g. from marching cubes)
+	edgeLength float64, // target edge length
+	iterations int, // number of remeshing iterations, e.g. 5
+) []*Triangle3 {
+	m := newIndexedMesh(mesh, edgeLength*1e-4)
+	hi := edgeLength * 4.0 / 3.0
+	lo := edgeLength * 4.0 / 5.0
+	h := edgeLength * 1e-3
+	for i := 0; i < iterations; i++ {
+		for j := 0; j < 8 && m.splitEdges(hi); j++ {
+		}
+		for j := 0; j < 8 && m.collapseEdges(lo, hi); j++ {
+		}
+		m.compact()
+		m.flipEdges()
+		m.relax()
+		m.project(s, h)
+	}
+	return m.triangles()
+}
+
+// RenderSTLRemesh renders an SDF3 as an STL file with a near uniform triangle mesh.
+func RenderSTLRemesh(
+	s SDF3, //sdf3 to render
+	edgeLength float64, // target edge length
+	path string, //path to filename
+) error {
+	// initial marching cubes mesh at the target resolution
+	bb0 := s.BoundingBox()
+	bb1Size := bb0.Size().DivScalar(edgeLength).Ceil().AddScalar(1).MulScalar(edgeLength)
+	bb := NewBox3(bb0.Center(), bb1Size)
+	m := marchingCubes(s, bb, edgeLength)
+	m = Remesh(s, m, edgeLength, 5)
+	return SaveSTL(path, m)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,109 @@
+//-----------------------------------------------------------------------------
+/*
+
+Resizing
+
+Scale and center a shape to fit a target box, so imported art and meshes can
+be sized by their final dimensions rather than by measuring them first.
+
+FitKeepAspect scales uniformly so the shape fits inside the box (touching it
+on at least one axis), the distance field is exact. FitStretch scales each
+axis to fill the box, the distance is corrected by the smallest scale
+factor (see Scale3D) so it remains a lower bound.
+
+The padding is kept clear inside each face of the box. The fit uses the
+bounding box of the shape, so a loose bounding box leaves a gap.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// FitMode is the way a shape is scaled to fit a box.
+type FitMode int
+
+const (
+	FitKeepAspect FitMode = iota // uniform scaling to fit inside the box
+	FitStretch                   // per-axis scaling to fill the box
+)
+
+// fitScale returns the per-axis scale factors to fit a size into a target size.
+// Axes with zero size (E.g. a flat shape) get the uniform scale factor.
+func fitScale(size, target []float64, mode FitMode) ([]float64, error) {
+	k := make([]float64, len(size))
+	uniform := 0.0
+	for i := range size {
+		if target[i] <= 0 {
+			return nil, errors.New("box is smaller than the padding")
+		}
+		if size[i] > 0 {
+			k[i] = target[i] / size[i]
+			if uniform == 0 || k[i] < uniform {
+				uniform = k[i]
+			}
+		}
+	}
+	if uniform == 0 {
+		return nil, errors.New("shape has an empty bounding box")
+	}
+	for i := range k {
+		if mode == FitKeepAspect || size[i] == 0 {
+			k[i] = uniform
+		}
+	}
+	return k, nil
+}
+
+// FitTo returns an SDF3 scaled and centered to fit a box, with padding inside the box.
+func FitTo(s SDF3, box Box3, mode FitMode, padding float64) (SDF3, error) {
+	if s == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if padding < 0 {
+		return nil, errors.New("padding < 0")
+	}
+	bb := s.BoundingBox()
+	size := bb.Size()
+	target := box.Size().SubScalar(2 * padding)
+	k, err := fitScale([]float64{size.X, size.Y, size.Z}, []float64{target.X, target.Y, target.Z}, mode)
+	if err != nil {
+		return nil, err
+	}
+	s = Transform3D(s, Translate3d(bb.Center().Neg()))
+	if mode == FitKeepAspect {
+		s = ScaleUniform3D(s, k[0])
+	} else {
+		s = Scale3D(s, V3{k[0], k[1], k[2]})
+	}
+	return Transform3D(s, Translate3d(box.Center())), nil
+}
+
+// FitTo2D returns an SDF2 scaled and centered to fit a box, with padding inside the box.
+func FitTo2D(s SDF2, box Box2, mode FitMode, padding float64) (SDF2, error) {
+	if s == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if padding < 0 {
+		return nil, errors.New("padding < 0")
+	}
+	bb := s.BoundingBox()
+	size := bb.Size()
+	target := box.Size().SubScalar(2 * padding)
+	k, err := fitScale([]float64{size.X, size.Y}, []float64{target.X, target.Y}, mode)
+	if err != nil {
+		return nil, err
+	}
+	s = Transform2D(s, Translate2d(bb.Center().Neg()))
+	if mode == FitKeepAspect {
+		s = ScaleUniform2D(s, k[0])
+	} else {
+		s = Scale2D(s, V2{k[0], k[1]})
+	}
+	return Transform2D(s, Translate2d(box.Center())), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,241 @@
+//-----------------------------------------------------------------------------
+/*
+
+Screw Bosses and Ribs
+
+Molded part style features for enclosures. Everything stands on the z = 0
+plane (the inside surface of the enclosure wall) and goes up in +z.
+
+The draft angle tapers the walls of the bosses, gussets and ribs so they
+are thicker at the base, as needed to release a molded part (and it's
+good for printed parts too). The usual rule for molded parts is a rib
+thickness (at the base) of 0.5 to 0.7 of the wall thickness, so the wall
+doesn't sink over the rib.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// ribSection returns the cross section of a drafted rib, (across, z) with the base on z = 0.
+func ribSection(height, thickness, draft float64) SDF2 {
+	wt := 0.5 * thickness
+	wb := wt + height*math.Tan(draft)
+	return Polygon2D([]V2{{-wb, 0}, {wb, 0}, {wt, height}, {-wt, height}})
+}
+
+// checkRib checks the parameters of a drafted rib.
+func checkRib(height, thickness, draft float64) error {
+	if height <= 0 {
+		return errors.New("height <= 0")
+	}
+	if thickness <= 0 {
+		return errors.New("thickness <= 0")
+	}
+	if draft < 0 || draft >= DtoR(45) {
+		return errors.New("bad draft angle")
+	}
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// Ribs
+
+// RibSDF3 is a straight rib with drafted sides.
+type RibSDF3 struct {
+	section SDF2    // cross section
+	length  float64 // half length
+	bb      Box3
+}
+
+// Rib3D returns a straight rib along the x-axis (centered on the origin), standing on the z = 0 plane.
+func Rib3D(
+	length float64, // length of the rib
+	height float64, // height of the rib
+	thickness float64, // thickness at the top of the rib
+	draft float64, // draft angle of the sides (radians)
+) (SDF3, error) {
+	if length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	if err := checkRib(height, thickness, draft); err != nil {
+		return nil, err
+	}
+	s := RibSDF3{
+		section: ribSection(height, thickness, draft),
+		length:  0.5 * length,
+	}
+	bb := s.section.BoundingBox()
+	s.bb = Box3{V3{-s.length, bb.Min.X, 0}, V3{s.length, bb.Max.X, height}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a rib.
+func (s *RibSDF3) Evaluate(p V3) float64 {
+	return Max(s.section.Evaluate(V2{p.Y, p.Z}), Abs(p.X)-s.length)
+}
+
+// BoundingBox returns the bounding box of a rib.
+func (s *RibSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a rib.
+func (s *RibSDF3) Children() []interface{} { return []interface{}{s.section} }
+
+// RibParms defines the parameters for a network of stiffening ribs.
+type RibParms struct {
+	Spacing   V2      // spacing of the ribs parallel to the y and x axes (0 = none in that direction)
+	Offset    V2      // offset of the rib grid from the center of the region
+	Height    float64 // height of the ribs
+	Thickness float64 // thickness at the top of the ribs
+	Draft     float64 // draft angle of the sides (radians)
+}
+
+// RibsSDF3 is a rectangular grid of stiffening ribs across a region.
+type RibsSDF3 struct {
+	section SDF2 // cross section
+	region  SDF2 // ribs are within the region
+	origin  V2   // a rib crossing point
+	spacing V2
+	bb      Box3
+}
+
+// Ribs3D returns a rectangular grid of stiffening ribs across a flat panel region (in the
+// xy plane), standing on the z = 0 plane. The ribs end at the boundary of the region.
+func Ribs3D(
+	region SDF2, // panel region
+	k *RibParms,
+) (SDF3, error) {
+	if region == nil {
+		return nil, errors.New("nil region")
+	}
+	if err := checkRib(k.Height, k.Thickness, k.Draft); err != nil {
+		return nil, err
+	}
+	if k.Spacing.X < 0 || k.Spacing.Y < 0 || (k.Spacing.X == 0 && k.Spacing.Y == 0) {
+		return nil, errors.New("bad rib spacing")
+	}
+	s := RibsSDF3{
+		section: ribSection(k.Height, k.Thickness, k.Draft),
+		region:  region,
+		spacing: k.Spacing,
+	}
+	// the ribs must not touch at the base
+	w := s.section.BoundingBox().Size().X
+	if (k.Spacing.X != 0 && k.Spacing.X <= w) || (k.Spacing.Y != 0 && k.Spacing.Y <= w) {
+		return nil, errors.New("rib spacing is too small for the base thickness")
+	}
+	bb := region.BoundingBox()
+	s.origin = bb.Center().Add(k.Offset)
+	s.bb = Box3{V3{bb.Min.X, bb.Min.Y, 0}, V3{bb.Max.X, bb.Max.Y, k.Height}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a grid of ribs.
+func (s *RibsSDF3) Evaluate(p V3) float64 {
+	d := math.MaxFloat64
+	// offset from the closest rib in each direction
+	if s.spacing.X > 0 {
+		x := p.X - s.origin.X
+		x -= s.spacing.X * math.Round(x/s.spacing.X)
+		d = Min(d, s.section.Evaluate(V2{x, p.Z}))
+	}
+	if s.spacing.Y > 0 {
+		y := p.Y - s.origin.Y
+		y -= s.spacing.Y * math.Round(y/s.spacing.Y)
+		d = Min(d, s.section.Evaluate(V2{y, p.Z}))
+	}
+	return Max(d, s.region.Evaluate(V2{p.X, p.Y}))
+}
+
+// BoundingBox returns the bounding box of a grid of ribs.
+func (s *RibsSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a grid of ribs.
+func (s *RibsSDF3) Children() []interface{} { return []interface{}{s.region} }
+
+//-----------------------------------------------------------------------------
+// Screw Bosses
+
+// ScrewBossParms defines the parameters for a screw boss.
+type ScrewBossParms struct {
+	Height          float64 // height of the boss
+	Diameter        float64 // outer diameter at the top of the boss
+	HoleDiameter    float64 // pilot hole diameter at the bottom of the hole
+	HoleDepth       float64 // depth of the pilot hole (0 = the boss height)
+	Draft           float64 // draft angle of the boss, hole and gussets (radians)
+	Gussets         int     // number of gussets around the boss
+	GussetHeight    float64 // height of the gussets at the boss
+	GussetLength    float64 // length of the gussets out from the top of the boss
+	GussetThickness float64 // thickness at the top of the gussets
+}
+
+// ScrewBoss3D returns a screw boss with gussets, standing on the z = 0 plane.
+func ScrewBoss3D(k *ScrewBossParms) (SDF3, error) {
+	r := 0.5 * k.Diameter
+	rh := 0.5 * k.HoleDiameter
+	if k.Height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	if r <= 0 {
+		return nil, errors.New("diameter <= 0")
+	}
+	if k.Draft < 0 || k.Draft >= DtoR(45) {
+		return nil, errors.New("bad draft angle")
+	}
+	if rh <= 0 || rh >= r {
+		return nil, errors.New("bad hole diameter")
+	}
+	depth := k.HoleDepth
+	if depth == 0 {
+		depth = k.Height
+	}
+	if depth < 0 || depth > k.Height {
+		return nil, errors.New("bad hole depth")
+	}
+	if k.Gussets < 0 {
+		return nil, errors.New("gussets < 0")
+	}
+	tan := math.Tan(k.Draft)
+
+	// the boss is a cone, wider at the base
+	boss := Cone3D(k.Height, r+k.Height*tan, r, 0)
+	boss = Transform3D(boss, Translate3d(V3{0, 0, 0.5 * k.Height}))
+
+	if k.Gussets > 0 {
+		if k.GussetHeight <= 0 || k.GussetHeight > k.Height {
+			return nil, errors.New("bad gusset height")
+		}
+		if k.GussetLength <= 0 {
+			return nil, errors.New("gusset length <= 0")
+		}
+		// a rib from the axis out to the end of the gusset
+		l := r + k.GussetLength
+		rib, err := Rib3D(l, k.GussetHeight, k.GussetThickness, k.Draft)
+		if err != nil {
+			return nil, err
+		}
+		rib = Transform3D(rib, Translate3d(V3{0.5 * l, 0, 0}))
+		// with a sloping top edge
+		h := k.GussetHeight
+		gusset := Cut3D(rib, V3{l, 0, 0}, V3{-h, 0, -l})
+		boss = Union3D(boss, RotateCopy3D(gusset, k.Gussets))
+	}
+
+	// the pilot hole is a cone, wider at the top
+	hole := Cone3D(depth, rh, rh+depth*tan, 0)
+	hole = Transform3D(hole, Translate3d(V3{0, 0, k.Height - 0.5*depth}))
+	return Difference3D(boss, hole), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,121 @@
+//-----------------------------------------------------------------------------
+/*
+
+Ruled Surfaces
+
+A ruled surface is swept by a straight line (the ruling) moving along two
+curves. The curves are polylines with the same number of points, the rulings
+join corresponding points. Between the points the surface is the bilinear
+patch of the four corner points (a hyperbolic paraboloid when the rulings
+twist), it's approximated by triangles.
+
+The surface is given a thickness to make a solid, E.g. webs, vanes and
+twisted transitions between edges.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// RuledSDF3 is a thickened ruled surface between two 3D polylines.
+type RuledSDF3 struct {
+	patch [][]*Triangle3 // triangles for each patch between the polylines
+	pbb   []Box3         // bounding box of each patch
+	t     float64        // half thickness
+	bb    Box3           // bounding box
+}
+
+// Ruled3D returns a ruled surface between two 3D polylines, thickened to a solid.
+func Ruled3D(
+	a, b []V3, // polylines with the same number of points
+	thickness float64, // thickness of the surface
+	steps int, // subdivisions of each patch along the polylines and the rulings
+) (SDF3, error) {
+	if len(a) < 2 || len(a) != len(b) {
+		return nil, errors.New("the polylines need the same number of points (>= 2)")
+	}
+	if thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	if steps < 1 {
+		return nil, errors.New("steps < 1")
+	}
+	s := RuledSDF3{
+		t: 0.5 * thickness,
+	}
+	n := steps
+	for i := 0; i < len(a)-1; i++ {
+		// grid of points on the bilinear patch
+		grid := make([][]V3, n+1)
+		for j := range grid {
+			u := float64(j) / float64(n)
+			pa := a[i].Add(a[i+1].Sub(a[i]).MulScalar(u))
+			pb := b[i].Add(b[i+1].Sub(b[i]).MulScalar(u))
+			grid[j] = make([]V3, n+1)
+			for k := range grid[j] {
+				v := float64(k) / float64(n)
+				grid[j][k] = pa.Add(pb.Sub(pa).MulScalar(v))
+			}
+		}
+		var tri []*Triangle3
+		bb := Box3{grid[0][0], grid[0][0]}
+		for j := 0; j < n; j++ {
+			for k := 0; k < n; k++ {
+				p00, p10 := grid[j][k], grid[j+1][k]
+				p01, p11 := grid[j][k+1], grid[j+1][k+1]
+				tri = append(tri, NewTriangle3(p00, p10, p11), NewTriangle3(p00, p11, p01))
+			}
+		}
+		for _, t := range tri {
+			bb = bb.Extend(t.BoundingBox())
+		}
+		s.patch = append(s.patch, tri)
+		s.pbb = append(s.pbb, bb)
+	}
+	bb := s.pbb[0]
+	for _, x := range s.pbb[1:] {
+		bb = bb.Extend(x)
+	}
+	d := V3{s.t, s.t, s.t}
+	s.bb = Box3{bb.Min.Sub(d), bb.Max.Add(d)}
+	return &s, nil
+}
+
+// boxDistance2 returns the squared distance from a point to a box (0 within the box).
+func boxDistance2(b Box3, p V3) float64 {
+	q := V3{
+		Clamp(p.X, b.Min.X, b.Max.X),
+		Clamp(p.Y, b.Min.Y, b.Max.Y),
+		Clamp(p.Z, b.Min.Z, b.Max.Z),
+	}
+	return p.Sub(q).Length2()
+}
+
+// Evaluate returns the minimum distance to a ruled surface.
+func (s *RuledSDF3) Evaluate(p V3) float64 {
+	d2 := math.MaxFloat64
+	for i, tri := range s.patch {
+		// skip patches further away than the closest point so far
+		if boxDistance2(s.pbb[i], p) >= d2 {
+			continue
+		}
+		for _, t := range tri {
+			d2 = Min(d2, p.Sub(t.closestPoint(p)).Length2())
+		}
+	}
+	return math.Sqrt(d2) - s.t
+}
+
+// BoundingBox returns the bounding box of a ruled surface.
+func (s *RuledSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
@@ -11,9 +11,38 @@ the x-axis as the screw axis. Most thread profiles are symmetric about the y-axi
 but a few aren't (E.g. buttress threads) so in general we build the profile of
 an entire pitch period.
 
-This code doesn't deal with thread tolerancing. If you want threads to fit properly
+Any 2D profile in this form can be swept with HelicalSweep3D, so custom thread
+forms (E.g. PCO-1881 bottle threads, proprietary forms) don't need built-in
+profiles.
+
+The thread profiles are the basic sizes. If you want threads to fit properly
 the radius of the thread will need to be tweaked (+/-) to give internal/external thread
-clearance.
+clearance. ISOThread3D does this with ISO tolerance classes and/or a printer clearance.
+
+ISO Metric Threads
+
+ISOThread3D builds a complete thread from the nominal diameter and pitch. The
+basic profile is a 60 degree V of height H = (sqrt(3)/2) * pitch, truncated
+by H/8 at the major diameter and H/4 at the minor diameter. The external thread
+root is rounded and goes below the basic minor diameter, so the internal crest
+clears it. An internal thread is built as the tap form (the solid to subtract
+from a part). The lead-in chamfers are 45 degrees, down to the minor diameter
+of an external thread or out to the major diameter of an internal thread.
+
+Tolerance classes (ISO 965-1): the thread is offset radially to the middle of
+the pitch diameter tolerance band for the class, E.g. "6g" (external) or "6H"
+(internal). The deviations and tolerances are from the ISO 965 formulas, so
+they can differ from the rounded table values by a few microns:
+
+fundamental deviation: e = -(50 + 11P), f = -(30 + 11P), g = -(15 + 11P),
+G = +(15 + 11P), h = H = 0 (um)
+
+pitch diameter tolerance: Td2(6) = 90 P^0.4 d^0.1 (external),
+TD2(6) = 1.32 Td2(6) (internal), scaled for grades 3 to 9.
+
+For printed threads the clearance is an additional radial clearance between
+the mating threads. Printed holes come out smaller than printed pegs, so the
+internal thread takes 2/3 of the clearance and the external thread 1/3.
 
 */
 //-----------------------------------------------------------------------------
@@ -21,6 +50,7 @@ clearance.
 package sdf
 
 import (
+	"errors"
 	"fmt"
 	"math"
 )
@@ -169,11 +199,71 @@ func (t *ThreadParameters) HexHeight() float64 {
 //-----------------------------------------------------------------------------
 // Thread Profiles
 
-// AcmeThread returns the 2d profile for an acme thread.
-func AcmeThread(
-	radius float64, // radius of thread
+// Hand is the handedness of a helix.
+type Hand int
+
+const (
+	// RightHand is a right hand helix (the default).
+	RightHand Hand = iota
+	// LeftHand is a left hand helix.
+	LeftHand
+)
+
+// Sign returns 1 for a right hand helix and -1 for a left hand helix.
+func (h Hand) Sign() int {
+	if h == LeftHand {
+		return -1
+	}
+	return 1
+}
+
+func (h Hand) String() string {
+	if h == LeftHand {
+		return "left hand"
+	}
+	return "right hand"
+}
+
+// ThreadProfile generates the 2D profile of a screw thread.
+type ThreadProfile interface {
+	// Polygon returns the vertices of the thread profile for a major radius and pitch.
+	// The profile is a single thread centered on the y-axis (the x-axis is the screw axis),
+	// extending at least half a pitch either side of the y-axis. See Screw3D.
+	Polygon(radius, pitch float64) []V2
+}
+
+// ThreadFunc adapts a function to the ThreadProfile interface, for custom thread profiles.
+type ThreadFunc func(radius, pitch float64) []V2
+
+// Polygon returns the vertices of the thread profile.
+func (f ThreadFunc) Polygon(radius, pitch float64) []V2 {
+	return f(radius, pitch)
+}
+
+// ThreadProfile2D returns the 2D profile of a thread.
+func ThreadProfile2D(p ThreadProfile, radius, pitch float64) SDF2 {
+	return Polygon2D(p.Polygon(radius, pitch))
+}
+
+// ThreadScrew3D returns a screw with a thread profile.
+func ThreadScrew3D(
+	p ThreadProfile, // thread profile
+	radius float64, // major radius of the thread
+	length float64, // length of screw
 	pitch float64, // thread to thread distance
-) SDF2 {
+	starts int, // number of thread starts
+	hand Hand, // thread hand
+) SDF3 {
+	return Screw3D(ThreadProfile2D(p, radius, pitch), length, pitch, starts*hand.Sign())
+}
+
+//-----------------------------------------------------------------------------
+
+// AcmeThreadProfile is a 29 degree acme thread.
+type AcmeThreadProfile struct{}
+
+// Polygon returns the vertices of an acme thread profile.
+func (AcmeThreadProfile) Polygon(radius, pitch float64) []V2 {
 
 	h := radius - 0.5*pitch
 	theta := DtoR(29.0 / 2.0)
@@ -192,17 +282,24 @@ func AcmeThread(
 	acme.Add(-radius, 0)
 
 	//acme.Render("acme.dxf")
-	return Polygon2D(acme.Vertices())
+	return acme.Vertices()
 }
 
-// ISOThread returns the 2d profile for an ISO/UTS thread.
-// https://en.wikipedia.org/wiki/ISO_metric_screw_thread
-// https://en.wikipedia.org/wiki/Unified_Thread_Standard
-func ISOThread(
+// AcmeThread returns the 2d profile for an acme thread.
+func AcmeThread(
 	radius float64, // radius of thread
 	pitch float64, // thread to thread distance
-	mode string, // internal/external thread
 ) SDF2 {
+	return ThreadProfile2D(AcmeThreadProfile{}, radius, pitch)
+}
+
+// ISOThreadProfile is an ISO/UTS 60 degree thread.
+type ISOThreadProfile struct {
+	Internal bool // internal (nut) thread
+}
+
+// Polygon returns the vertices of an ISO/UTS thread profile.
+func (p ISOThreadProfile) Polygon(radius, pitch float64) []V2 {
 
 	theta := DtoR(30.0)
 	h := pitch / (2.0 * math.Tan(theta))
@@ -210,7 +307,7 @@ func ISOThread(
 	r0 := rMajor - (7.0/8.0)*h
 
 	iso := NewPolygon()
-	if mode == "external" {
+	if !p.Internal {
 		rRoot := (pitch / 8.0) / math.Cos(theta)
 		xOfs := (1.0 / 16.0) * pitch
 		iso.Add(pitch, 0)
@@ -221,7 +318,7 @@ func ISOThread(
 		iso.Add(-pitch/2.0, r0).Smooth(rRoot, 5)
 		iso.Add(-pitch, r0+h)
 		iso.Add(-pitch, 0)
-	} else if mode == "internal" {
+	} else {
 		rMinor := r0 + (1.0/4.0)*h
 		rCrest := (pitch / 16.0) / math.Cos(theta)
 		xOfs := (1.0 / 8.0) * pitch
@@ -232,20 +329,32 @@ func ISOThread(
 		iso.Add(-pitch/2+xOfs, rMinor)
 		iso.Add(-pitch, rMinor)
 		iso.Add(-pitch, 0)
-	} else {
-		panic("bad mode")
 	}
 	//iso.Render("iso.dxf")
-	return Polygon2D(iso.Vertices())
+	return iso.Vertices()
 }
 
-// ANSIButtressThread returns the 2d profile for an ANSI 45/7 buttress thread.
-// https://en.wikipedia.org/wiki/Buttress_thread
-// AMSE B1.9-1973
-func ANSIButtressThread(
+// ISOThread returns the 2d profile for an ISO/UTS thread.
+// https://en.wikipedia.org/wiki/ISO_metric_screw_thread
+// https://en.wikipedia.org/wiki/Unified_Thread_Standard
+func ISOThread(
 	radius float64, // radius of thread
 	pitch float64, // thread to thread distance
+	mode string, // internal/external thread
 ) SDF2 {
+	if mode != "external" && mode != "internal" {
+		panic("bad mode")
+	}
+	return ThreadProfile2D(ISOThreadProfile{Internal: mode == "internal"}, radius, pitch)
+}
+
+// ButtressThreadProfile is a 45/7 degree buttress thread.
+type ButtressThreadProfile struct {
+	Plastic bool // screw top style plastic buttress thread, with more corner rounding
+}
+
+// Polygon returns the vertices of a buttress thread profile.
+func (p ButtressThreadProfile) Polygon(radius, pitch float64) []V2 {
 	t0 := math.Tan(DtoR(45.0))
 	t1 := math.Tan(DtoR(7.0))
 	b := 0.6 // thread engagement
@@ -257,14 +366,30 @@ func ANSIButtressThread(
 	tp := NewPolygon()
 	tp.Add(pitch, 0)
 	tp.Add(pitch, radius)
-	tp.Add(hp-((h0-h1)*t1), radius)
-	tp.Add(t0*h0-hp, radius-h1).Smooth(0.0714*pitch, 5)
-	tp.Add((h0-h1)*t0-hp, radius)
+	if p.Plastic {
+		tp.Add(hp-((h0-h1)*t1), radius).Smooth(0.05*pitch, 5)
+		tp.Add(t0*h0-hp, radius-h1).Smooth(0.15*pitch, 5)
+		tp.Add((h0-h1)*t0-hp, radius).Smooth(0.15*pitch, 5)
+	} else {
+		tp.Add(hp-((h0-h1)*t1), radius)
+		tp.Add(t0*h0-hp, radius-h1).Smooth(0.0714*pitch, 5)
+		tp.Add((h0-h1)*t0-hp, radius)
+	}
 	tp.Add(-pitch, radius)
 	tp.Add(-pitch, 0)
 
 	//tp.Render("buttress.dxf")
-	return Polygon2D(tp.Vertices())
+	return tp.Vertices()
+}
+
+// ANSIButtressThread returns the 2d profile for an ANSI 45/7 buttress thread.
+// https://en.wikipedia.org/wiki/Buttress_thread
+// AMSE B1.9-1973
+func ANSIButtressThread(
+	radius float64, // radius of thread
+	pitch float64, // thread to thread distance
+) SDF2 {
+	return ThreadProfile2D(ButtressThreadProfile{}, radius, pitch)
 }
 
 // PlasticButtressThread returns the 2d profile for a screw top style plastic buttress thread.
@@ -273,25 +398,95 @@ func PlasticButtressThread(
 	radius float64, // radius of thread
 	pitch float64, // thread to thread distance
 ) SDF2 {
-	t0 := math.Tan(DtoR(45.0))
-	t1 := math.Tan(DtoR(7.0))
-	b := 0.6 // thread engagement
+	return ThreadProfile2D(ButtressThreadProfile{Plastic: true}, radius, pitch)
+}
 
-	h0 := pitch / (t0 + t1)
-	h1 := ((b / 2.0) * pitch) + (0.5 * h0)
-	hp := pitch / 2.0
+// SquareThreadProfile is a square thread, with equal tooth and space widths and a depth of
+// half the pitch.
+type SquareThreadProfile struct{}
 
-	tp := NewPolygon()
-	tp.Add(pitch, 0)
-	tp.Add(pitch, radius)
-	tp.Add(hp-((h0-h1)*t1), radius).Smooth(0.05*pitch, 5)
-	tp.Add(t0*h0-hp, radius-h1).Smooth(0.15*pitch, 5)
-	tp.Add((h0-h1)*t0-hp, radius).Smooth(0.15*pitch, 5)
-	tp.Add(-pitch, radius)
-	tp.Add(-pitch, 0)
+// Polygon returns the vertices of a square thread profile.
+func (SquareThreadProfile) Polygon(radius, pitch float64) []V2 {
+	h := radius - 0.5*pitch
+	q := 0.25 * pitch
+	sq := NewPolygon()
+	sq.Add(pitch, 0)
+	sq.Add(pitch, radius)
+	sq.Add(3*q, radius)
+	sq.Add(3*q, h)
+	sq.Add(q, h)
+	sq.Add(q, radius)
+	sq.Add(-q, radius)
+	sq.Add(-q, h)
+	sq.Add(-3*q, h)
+	sq.Add(-3*q, radius)
+	sq.Add(-pitch, radius)
+	sq.Add(-pitch, 0)
+	return sq.Vertices()
+}
 
-	//tp.Render("buttress.dxf")
-	return Polygon2D(tp.Vertices())
+// KnuckleThreadProfile is a round (knuckle/bottle) thread, with semicircular crests and roots
+// of radius pitch/4, and a depth of half the pitch.
+type KnuckleThreadProfile struct {
+	Facets int // facets on each semicircle (0 = 8)
+}
+
+// Polygon returns the vertices of a knuckle thread profile.
+func (p KnuckleThreadProfile) Polygon(radius, pitch float64) []V2 {
+	n := p.Facets
+	if n <= 0 {
+		n = 8
+	}
+	r := 0.25 * pitch
+	y := radius - r
+	v := []V2{{pitch, 0}, {pitch, radius}}
+	// add an arc centered at (x, y), crests above y and roots below
+	arc := func(x, a0, a1, dy float64, n int) {
+		if n < 1 {
+			n = 1
+		}
+		for i := 1; i <= n; i++ {
+			s, c := math.Sincos(Mix(a0, a1, float64(i)/float64(n)))
+			v = append(v, V2{x + r*c, y + dy*r*s})
+		}
+	}
+	// alternate crests and roots from +x to -x, with part crests at the ends
+	arc(pitch, 0.5*Pi, Pi, 1, n/2)
+	arc(0.5*pitch, 0, Pi, -1, n)
+	arc(0, 0, Pi, 1, n)
+	arc(-0.5*pitch, 0, Pi, -1, n)
+	arc(-pitch, 0, 0.5*Pi, 1, n/2)
+	v = append(v, V2{-pitch, 0})
+	return v
+}
+
+// WhitworthThreadProfile is a 55 degree British Standard Whitworth thread,
+// with rounded crests and roots.
+type WhitworthThreadProfile struct {
+	Facets int // facets on the crest and root roundings (0 = 5)
+}
+
+// Polygon returns the vertices of a Whitworth thread profile.
+func (p WhitworthThreadProfile) Polygon(radius, pitch float64) []V2 {
+	n := p.Facets
+	if n <= 0 {
+		n = 5
+	}
+	H := 0.960491 * pitch // sharp V height
+	h := 0.640327 * pitch // thread depth
+	r := 0.137329 * pitch // crest/root radius
+	t := 0.5 * (H - h)    // truncation of the sharp V at the crest and root
+	yc := radius + t      // sharp crest
+	yr := radius - h - t  // sharp root
+	w := NewPolygon()
+	w.Add(pitch, 0)
+	w.Add(pitch, yc)
+	w.Add(pitch/2, yr).Smooth(r, n)
+	w.Add(0, yc).Smooth(r, n)
+	w.Add(-pitch/2, yr).Smooth(r, n)
+	w.Add(-pitch, yc)
+	w.Add(-pitch, 0)
+	return w.Vertices()
 }
 
 //-----------------------------------------------------------------------------
@@ -350,4 +545,177 @@ func (s *ScrewSDF3) BoundingBox() Box3 {
 	return s.bb
 }
 
+// HelicalSweep3D sweeps a 2D tooth profile helically about the z-axis, for custom thread forms.
+// The profile has the screw axis on the x-axis and the radius on the y-axis (see ThreadProfile).
+// It's evaluated over one pitch (lead / starts) centered on the y-axis, so a profile of a single
+// tooth gives one thread per start. E.g. a bottle thread with flat topped teeth and wide gaps:
+//
+//	tooth := Polygon2D([]V2{{p, 0}, {p, r0}, {w1, r0}, {w0, r1}, {-w0, r1}, {-w1, r0}, {-p, r0}, {-p, 0}})
+//	neck, _ := HelicalSweep3D(tooth, 2*p, 2, length)
+func HelicalSweep3D(
+	profile SDF2, // 2D tooth profile
+	lead float64, // axial distance for one turn of the helix
+	starts int, // number of thread starts (< 0 for left hand threads)
+	length float64, // length of the screw
+) (SDF3, error) {
+	if profile == nil {
+		return nil, errors.New("nil profile")
+	}
+	if lead <= 0 {
+		return nil, errors.New("lead <= 0")
+	}
+	if starts == 0 {
+		return nil, errors.New("starts == 0")
+	}
+	if length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	pitch := lead / Abs(float64(starts))
+	return Screw3D(profile, length, pitch, starts), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// threadClearance returns the radial offsets of the external and internal threads for a clearance.
+func threadClearance(clearance float64) (float64, float64) {
+	return clearance / 3, 2 * clearance / 3
+}
+
+// ISOThreadParms defines the parameters for an ISO metric thread.
+type ISOThreadParms struct {
+	Diameter  float64 // nominal (major) diameter
+	Pitch     float64 // thread to thread distance
+	Internal  bool    // internal thread (the tap form to subtract from a part)
+	Length    float64 // length of the thread
+	LeadIn    bool    // add 45 degree lead-in chamfers at both ends
+	Hand      Hand    // thread hand
+	Class     string  // ISO 965 tolerance class, E.g. "6g" or "6H" ("" = basic size)
+	Clearance float64 // radial clearance between mating printed threads
+}
+
+// isoThreadRanges are the ISO 965 nominal diameter ranges.
+var isoThreadRanges = []float64{0.99, 1.4, 2.8, 5.6, 11.2, 22.4, 45, 90, 180, 355, 600}
+
+// isoThreadGrades are the tolerance multipliers for the grades relative to grade 6.
+var isoThreadGrades = map[int]float64{3: 0.5, 4: 0.63, 5: 0.8, 6: 1, 7: 1.25, 8: 1.6, 9: 2}
+
+// ISOThreadTolerance returns the lower and upper deviations (mm) of the pitch diameter for an
+// ISO 965 tolerance class. Lower case positions (e, f, g, h) are external threads, upper case
+// positions (G, H) are internal threads.
+func ISOThreadTolerance(
+	diameter float64, // nominal diameter
+	pitch float64, // thread pitch
+	class string, // tolerance class, E.g. "6g" or "6H"
+) (float64, float64, error) {
+	if pitch <= 0 {
+		return 0, 0, errors.New("pitch <= 0")
+	}
+	if len(class) != 2 || class[0] < '0' || class[0] > '9' {
+		return 0, 0, fmt.Errorf("bad tolerance class \"%s\"", class)
+	}
+	k, ok := isoThreadGrades[int(class[0]-'0')]
+	if !ok {
+		return 0, 0, fmt.Errorf("tolerance grade %c not supported", class[0])
+	}
+	// geometric mean of the diameter range
+	d := 0.0
+	for i := 1; i < len(isoThreadRanges); i++ {
+		if diameter > isoThreadRanges[i-1] && diameter <= isoThreadRanges[i] {
+			d = math.Sqrt(isoThreadRanges[i-1] * isoThreadRanges[i])
+		}
+	}
+	if d == 0 {
+		return 0, 0, errors.New("diameter out of range")
+	}
+	p := pitch
+	td2 := 90 * math.Pow(p, 0.4) * math.Pow(d, 0.1)
+	var lo, hi float64 // um
+	switch pos := class[1]; pos {
+	case 'e', 'f', 'g', 'h':
+		es := map[byte]float64{'e': -(50 + 11*p), 'f': -(30 + 11*p), 'g': -(15 + 11*p), 'h': 0}[pos]
+		lo, hi = es-k*td2, es
+	case 'G', 'H':
+		ei := map[byte]float64{'G': 15 + 11*p, 'H': 0}[pos]
+		lo, hi = ei, ei+1.32*k*td2
+	default:
+		return 0, 0, fmt.Errorf("tolerance position %c not supported", pos)
+	}
+	return 1e-3 * lo, 1e-3 * hi, nil
+}
+
+// ISOThreadDepth returns the basic thread depth (major radius - minor radius) of an ISO thread.
+func ISOThreadDepth(pitch float64) float64 {
+	return (5.0 / 8.0) * pitch * math.Sqrt(3) / 2
+}
+
+// ISOThread3D returns an ISO metric thread, centered on the origin along the z-axis.
+func ISOThread3D(k *ISOThreadParms) (SDF3, error) {
+	if k.Diameter <= 0 {
+		return nil, errors.New("diameter <= 0")
+	}
+	if k.Pitch <= 0 {
+		return nil, errors.New("pitch <= 0")
+	}
+	r := 0.5 * k.Diameter
+	depth := ISOThreadDepth(k.Pitch)
+	// the sharp root of the profile (7H/8 below the major diameter) must be above the axis
+	if r <= (7.0/5.0)*depth {
+		return nil, errors.New("pitch is too large for the diameter")
+	}
+	if k.Length <= 0 {
+		return nil, errors.New("length <= 0")
+	}
+	if k.LeadIn && k.Length <= 2*depth {
+		return nil, errors.New("length is too short for the lead-in chamfers")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+	// radial offset of the thread for the tolerance class and clearance
+	ext, in := threadClearance(k.Clearance)
+	if k.Internal {
+		r += in
+	} else {
+		r -= ext
+	}
+	if k.Class != "" {
+		lo, hi, err := ISOThreadTolerance(k.Diameter, k.Pitch, k.Class)
+		if err != nil {
+			return nil, err
+		}
+		if k.Internal != (k.Class[1] == 'G' || k.Class[1] == 'H') {
+			return nil, fmt.Errorf("tolerance class \"%s\" is for the other thread type", k.Class)
+		}
+		// the middle of the pitch diameter tolerance band
+		r += 0.25 * (lo + hi)
+	}
+	thread := ThreadScrew3D(ISOThreadProfile{Internal: k.Internal}, r, k.Length, k.Pitch, 1, k.Hand)
+	if !k.LeadIn {
+		return thread, nil
+	}
+	l := 0.5 * k.Length
+	rMinor := r - depth
+	p := NewPolygon()
+	p.Add(0, -l)
+	if k.Internal {
+		// countersinks from the major diameter down to the minor diameter
+		p.Add(r, -l)
+		p.Add(rMinor, depth-l)
+		p.Add(rMinor, l-depth)
+		p.Add(r, l)
+	} else {
+		// chamfers from the minor diameter up to the major diameter
+		p.Add(rMinor, -l)
+		p.Add(r, depth-l)
+		p.Add(r, l-depth)
+		p.Add(rMinor, l)
+	}
+	p.Add(0, l)
+	chamfer := Revolve3D(Polygon2D(p.Vertices()))
+	if k.Internal {
+		return Union3D(thread, chamfer), nil
+	}
+	return Intersect3D(thread, chamfer), nil
+}
+
 //-----------------------------------------------------------------------------
@@ -81,6 +81,14 @@ func Circle2D(radius float64) SDF2 {
 	return &s
 }
 
+// CheckedCircle2D returns the SDF2 for a 2d circle, with an error for invalid parameters.
+func CheckedCircle2D(radius float64) (SDF2, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	return Circle2D(radius), nil
+}
+
 // Evaluate returns the minimum distance to a 2d circle.
 func (s *CircleSDF2) Evaluate(p V2) float64 {
 	return p.Length() - s.radius
@@ -112,6 +120,17 @@ func MultiCircle2D(radius float64, positions V2Set) SDF2 {
 	return &s
 }
 
+// CheckedMultiCircle2D returns an SDF2 for multiple circles, with an error for invalid parameters.
+func CheckedMultiCircle2D(radius float64, positions V2Set) (SDF2, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if len(positions) == 0 {
+		return nil, errors.New("no positions")
+	}
+	return MultiCircle2D(radius, positions), nil
+}
+
 // Evaluate returns the minimum distance to multiple circles.
 func (s *MultiCircleSDF2) Evaluate(p V2) float64 {
 	d := math.MaxFloat64
@@ -146,6 +165,20 @@ func Box2D(size V2, round float64) SDF2 {
 	return &s
 }
 
+// CheckedBox2D returns a 2d box, with an error for invalid parameters.
+func CheckedBox2D(size V2, round float64) (SDF2, error) {
+	if size.X <= 0 || size.Y <= 0 {
+		return nil, errors.New("size <= 0")
+	}
+	if round < 0 {
+		return nil, errors.New("round < 0")
+	}
+	if 2*round > size.MinComponent() {
+		return nil, errors.New("round > half the box size")
+	}
+	return Box2D(size, round), nil
+}
+
 // Evaluate returns the minimum distance to a 2d box.
 func (s *BoxSDF2) Evaluate(p V2) float64 {
 	return sdfBox2d(p, s.size) - s.round
@@ -169,12 +202,23 @@ type LineSDF2 struct {
 // Line2D returns a line from (-l/2,0) to (l/2,0).
 func Line2D(l, round float64) SDF2 {
 	s := LineSDF2{}
-	s.l = l / 2
-	s.round = round
-	s.bb = Box2{V2{-s.l - round, -round}, V2{s.l + round, round}}
+	s.l = float64(l / 2)
+	s.round = float64(round)
+	s.bb = Box2{V2{-s.l - s.round, -s.round}, V2{s.l + s.round, s.round}}
 	return &s
 }
 
+// CheckedLine2D returns a line from (-l/2,0) to (l/2,0), with an error for invalid parameters.
+func CheckedLine2D(l, round float64) (SDF2, error) {
+	if l < 0 {
+		return nil, errors.New("length < 0")
+	}
+	if round <= 0 {
+		return nil, errors.New("round <= 0")
+	}
+	return Line2D(l, round), nil
+}
+
 // Evaluate returns the minimum distance to a 2d line.
 func (s *LineSDF2) Evaluate(p V2) float64 {
 	p = p.Abs()
@@ -189,6 +233,403 @@ func (s *LineSDF2) BoundingBox() Box2 {
 	return s.bb
 }
 
+//-----------------------------------------------------------------------------
+// Ellipse (exact distance field)
+
+// EllipseSDF2 is the 2d signed distance object for an ellipse.
+type EllipseSDF2 struct {
+	a, b float64 // semi-axes on the x and y axes
+	bb   Box2    // bounding box
+}
+
+// Ellipse2D returns the SDF2 for an ellipse with semi-axes a and b on the x and y axes.
+func Ellipse2D(a, b float64) (SDF2, error) {
+	if a <= 0 || b <= 0 {
+		return nil, errors.New("semi-axis <= 0")
+	}
+	s := EllipseSDF2{}
+	s.a = a
+	s.b = b
+	d := V2{a, b}
+	s.bb = Box2{d.Neg(), d}
+	return &s, nil
+}
+
+// ellipseRoot returns the root of (r0*z0/(s+r0))^2 + (z1/(s+1))^2 - 1 by bisection.
+// See: Eberly, "Distance from a Point to an Ellipse, an Ellipsoid, or a Hyperellipsoid".
+func ellipseRoot(r0, z0, z1, g float64) float64 {
+	n0 := r0 * z0
+	s0 := z1 - 1
+	s1 := 0.0
+	if g > 0 {
+		s1 = math.Hypot(n0, z1) - 1
+	}
+	s := 0.0
+	for i := 0; i < 1100; i++ {
+		s = float64(0.5 * (s0 + s1))
+		if s == s0 || s == s1 {
+			break
+		}
+		x0 := n0 / (s + r0)
+		x1 := z1 / (s + 1)
+		g = float64(x0*x0) + float64(x1*x1) - 1
+		if g > 0 {
+			s0 = s
+		} else if g < 0 {
+			s1 = s
+		} else {
+			break
+		}
+	}
+	return s
+}
+
+// ellipseDistance returns the distance from a point in the first quadrant to an ellipse
+// with semi-axes e0 >= e1 > 0.
+func ellipseDistance(e0, e1, y0, y1 float64) float64 {
+	if y1 > 0 {
+		if y0 > 0 {
+			z0 := y0 / e0
+			z1 := y1 / e1
+			g := float64(z0*z0) + float64(z1*z1) - 1
+			if g == 0 {
+				return 0
+			}
+			r0 := float64((e0 / e1) * (e0 / e1))
+			s := ellipseRoot(r0, z0, z1, g)
+			x0 := float64(r0*y0) / (s + r0)
+			x1 := y1 / (s + 1)
+			return math.Hypot(x0-y0, x1-y1)
+		}
+		return Abs(y1 - e1)
+	}
+	// on the major axis, the closest point may be off the axis
+	n0 := e0 * y0
+	d0 := float64(e0*e0) - float64(e1*e1)
+	if n0 < d0 {
+		k := n0 / d0
+		return math.Hypot(float64(e0*k)-y0, e1*math.Sqrt(1-float64(k*k)))
+	}
+	return Abs(y0 - e0)
+}
+
+// Evaluate returns the minimum distance to an ellipse.
+func (s *EllipseSDF2) Evaluate(p V2) float64 {
+	p = p.Abs()
+	var d float64
+	if s.a >= s.b {
+		d = ellipseDistance(s.a, s.b, p.X, p.Y)
+	} else {
+		d = ellipseDistance(s.b, s.a, p.Y, p.X)
+	}
+	if float64((p.X/s.a)*(p.X/s.a))+float64((p.Y/s.b)*(p.Y/s.b)) < 1 {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box for an ellipse.
+func (s *EllipseSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Regular Polygons and Stars (exact distance field)
+
+// StarSDF2 is the 2d signed distance object for a star or a regular polygon.
+type StarSDF2 struct {
+	n  int     // number of points
+	a  V2      // outer vertex (on the x-axis)
+	u  V2      // normalized vector from the outer vertex to the inner vertex
+	l  float64 // length of the edge from the outer vertex to the inner vertex
+	bb Box2    // bounding box
+}
+
+// Star2D returns the SDF2 for an n pointed star with the first point on the x-axis.
+// The outer and inner vertices alternate around the star.
+func Star2D(n int, rOuter, rInner float64) (SDF2, error) {
+	if n < 2 {
+		return nil, errors.New("n < 2")
+	}
+	if rInner <= 0 {
+		return nil, errors.New("inner radius <= 0")
+	}
+	if rOuter <= rInner {
+		return nil, errors.New("outer radius <= inner radius")
+	}
+	return newStar2D(n, rOuter, rInner), nil
+}
+
+// RegularPolygon2D returns the SDF2 for an n sided regular polygon with a vertex on the x-axis.
+// The radius is the circumradius (as for Nagon).
+func RegularPolygon2D(n int, radius float64) (SDF2, error) {
+	if n < 3 {
+		return nil, errors.New("n < 3")
+	}
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	// a star with the inner vertices on the edge midpoints
+	return newStar2D(n, radius, radius*math.Cos(Pi/float64(n))), nil
+}
+
+func newStar2D(n int, rOuter, rInner float64) SDF2 {
+	s := StarSDF2{}
+	s.n = n
+	s.a = V2{rOuter, 0}
+	u := PolarToXY(rInner, Pi/float64(n)).Sub(s.a)
+	s.u = u.Normalize()
+	s.l = u.Length()
+	v := make(V2Set, 2*n)
+	for i := range v {
+		r := rOuter
+		if i&1 != 0 {
+			r = rInner
+		}
+		v[i] = PolarToXY(r, Pi*float64(i)/float64(n))
+	}
+	s.bb = Box2{v.Min(), v.Max()}
+	return &s
+}
+
+// Evaluate returns the minimum distance to a star.
+func (s *StarSDF2) Evaluate(p V2) float64 {
+	// fold the point into the sector between an outer vertex and the next inner vertex
+	k := Pi / float64(s.n)
+	theta := math.Mod(math.Atan2(p.Y, p.X)+Tau, 2*k)
+	if theta > k {
+		theta = 2*k - theta
+	}
+	v := PolarToXY(p.Length(), theta).Sub(s.a)
+	t := Clamp(v.Dot(s.u), 0, s.l)
+	d := v.Sub(s.u.MulScalar(t)).Length()
+	if s.u.Cross(v) > 0 {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box for a star.
+func (s *StarSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Slots, D-Shapes and Keyholes (exact distance field)
+
+// Slot2D returns a slot (a stadium) centered on the origin with its length along the x-axis.
+func Slot2D(length, width float64) (SDF2, error) {
+	if width <= 0 {
+		return nil, errors.New("width <= 0")
+	}
+	if length < width {
+		return nil, errors.New("length < width")
+	}
+	return Line2D(length-width, 0.5*width), nil
+}
+
+// DShapeSDF2 is the 2d signed distance object for a circle with a flat.
+type DShapeSDF2 struct {
+	radius float64 // circle radius
+	c      V2      // corner between the flat and the circle (+ve x-axis)
+	bb     Box2    // bounding box
+}
+
+// DShape2D returns a circle centered on the origin with a flat (E.g. a D-shaft profile).
+// The flat is parallel to the x-axis at y = flat.
+func DShape2D(radius, flat float64) (SDF2, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if Abs(flat) >= radius {
+		return nil, errors.New("the flat doesn't cut the circle")
+	}
+	s := DShapeSDF2{}
+	s.radius = radius
+	s.c = V2{math.Sqrt(float64(radius*radius) - float64(flat*flat)), flat}
+	x := radius
+	if flat < 0 {
+		x = s.c.X
+	}
+	s.bb = Box2{V2{-x, -radius}, V2{x, flat}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a D-shape.
+func (s *DShapeSDF2) Evaluate(p V2) float64 {
+	// we have symmetry about the y-axis
+	p = V2{Abs(p.X), p.Y}
+	l := p.Length()
+	if l <= s.radius && p.Y <= s.c.Y {
+		// inside
+		return Max(l-s.radius, p.Y-s.c.Y)
+	}
+	if p.X <= s.c.X && p.Y >= s.c.Y {
+		// the closest point is on the flat
+		return p.Y - s.c.Y
+	}
+	if s.c.Cross(p) <= 0 {
+		// the closest point is on the circle
+		return l - s.radius
+	}
+	// the closest point is the corner
+	return p.Sub(s.c).Length()
+}
+
+// BoundingBox returns the bounding box for a D-shape.
+func (s *DShapeSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+// KeyholeSDF2 is the 2d signed distance object for a keyhole.
+type KeyholeSDF2 struct {
+	r0 float64 // radius of the head circle
+	r1 float64 // half width of the slot
+	l  float64 // distance from the head circle center to the slot end center
+	j  V2      // junction between the head circle and the slot (+ve x-axis)
+	bb Box2    // bounding box
+}
+
+// Keyhole2D returns a keyhole with the head circle centered on the origin
+// and a round ended slot along the +ve y-axis.
+func Keyhole2D(
+	r0 float64, // radius of the head circle
+	r1 float64, // half width of the slot
+	l float64, // distance from the head circle center to the slot end center
+) (SDF2, error) {
+	if r1 <= 0 {
+		return nil, errors.New("slot width <= 0")
+	}
+	if r0 <= r1 {
+		return nil, errors.New("the head radius <= the slot half width")
+	}
+	s := KeyholeSDF2{}
+	s.r0 = r0
+	s.r1 = r1
+	s.l = l
+	s.j = V2{r1, math.Sqrt(float64(r0*r0) - float64(r1*r1))}
+	if l < s.j.Y {
+		return nil, errors.New("the slot is inside the head circle")
+	}
+	s.bb = Box2{V2{-r0, -r0}, V2{r0, l + r1}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a keyhole.
+func (s *KeyholeSDF2) Evaluate(p V2) float64 {
+	// we have symmetry about the y-axis
+	p = V2{Abs(p.X), p.Y}
+	l := p.Length()
+	e := p.Sub(V2{0, s.l})
+	inside := l < s.r0 || e.Length() < s.r1 || (p.X < s.r1 && p.Y >= 0 && p.Y <= s.l)
+	// head circle
+	var d float64
+	if s.j.Cross(p) <= 0 {
+		d = Abs(l - s.r0)
+	} else {
+		d = p.Sub(s.j).Length()
+	}
+	// slot side
+	d = Min(d, math.Hypot(p.X-s.r1, p.Y-Clamp(p.Y, s.j.Y, s.l)))
+	// slot end
+	if p.Y >= s.l {
+		d = Min(d, Abs(e.Length()-s.r1))
+	}
+	if inside {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box for a keyhole.
+func (s *KeyholeSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Annular Sector (exact distance field)
+
+// AnnularSectorSDF2 is the 2d signed distance object for an annular sector.
+type AnnularSectorSDF2 struct {
+	r0, r1 float64 // inner and outer radius
+	u      V2      // unit vector along the edge of the sector (the sector is centered on the x-axis)
+	m      M22     // rotation from the sector to the x-axis
+	bb     Box2    // bounding box
+}
+
+// AnnularSector2D returns the region between two circles from a start angle counter-clockwise
+// to a stop angle (radians). An inner radius of 0 gives a pie slice.
+func AnnularSector2D(
+	r0 float64, // inner radius
+	r1 float64, // outer radius
+	a0 float64, // start angle
+	a1 float64, // stop angle
+) (SDF2, error) {
+	if r0 < 0 {
+		return nil, errors.New("inner radius < 0")
+	}
+	if r1 <= r0 {
+		return nil, errors.New("outer radius <= inner radius")
+	}
+	span := math.Mod(a1-a0, Tau)
+	if span < 0 {
+		span += Tau
+	}
+	if span == 0 {
+		return nil, errors.New("start angle == stop angle")
+	}
+	s := AnnularSectorSDF2{}
+	s.r0 = r0
+	s.r1 = r1
+	s.u = PolarToXY(1, 0.5*span)
+	s.m = Rotate(-(a0 + float64(0.5*span)))
+	// the bounding box includes the corners and the axis crossings of the outer circle
+	v := V2Set{
+		PolarToXY(r0, a0), PolarToXY(r0, a0+span),
+		PolarToXY(r1, a0), PolarToXY(r1, a0+span),
+	}
+	for i := 0; i < 4; i++ {
+		a := float64(0.5 * Pi * float64(i))
+		if math.Mod(a-a0+float64(2*Tau), Tau) < span {
+			v = append(v, PolarToXY(r1, a))
+		}
+	}
+	s.bb = Box2{v.Min(), v.Max()}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to an annular sector.
+func (s *AnnularSectorSDF2) Evaluate(p V2) float64 {
+	// we have symmetry about the x-axis
+	p = s.m.MulPosition(p)
+	p = V2{p.X, Abs(p.Y)}
+	l := p.Length()
+	// is the closest point on an arc or an arc end?
+	onArc := s.u.Cross(p) <= 0
+	arc := func(r float64) float64 {
+		if onArc {
+			return Abs(l - r)
+		}
+		return p.Sub(s.u.MulScalar(r)).Length()
+	}
+	d := arc(s.r1)
+	if s.r0 > 0 {
+		d = Min(d, arc(s.r0))
+	}
+	// edge
+	t := Clamp(p.Dot(s.u), s.r0, s.r1)
+	d = Min(d, p.Sub(s.u.MulScalar(t)).Length())
+	if onArc && l > s.r0 && l < s.r1 {
+		return -d
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box for an annular sector.
+func (s *AnnularSectorSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
 //-----------------------------------------------------------------------------
 
 // OffsetSDF2 offsets the distance function of an existing SDF2.
@@ -299,6 +740,18 @@ func Polygon2D(vertex []V2) SDF2 {
 	return &s
 }
 
+// CheckedPolygon2D returns an SDF2 made from a closed set of line segments, with an error for
+// invalid parameters.
+func CheckedPolygon2D(vertex []V2) (SDF2, error) {
+	if len(vertex) < 3 {
+		return nil, errors.New("polygon needs at least 3 vertices")
+	}
+	if Abs(loopArea(openVertices(vertex, true))) < epsilon {
+		return nil, errors.New("polygon has no area")
+	}
+	return Polygon2D(vertex), nil
+}
+
 // Evaluate returns the minimum distance for a 2d polygon.
 func (s *PolySDF2) Evaluate(p V2) float64 {
 	dd := math.MaxFloat64 // d^2 to polygon (>0)
@@ -374,7 +827,7 @@ type TransformSDF2 struct {
 }
 
 // Transform2D applies a transformation matrix to an SDF2.
-// Distance is *not* preserved with scaling.
+// Distance is *not* preserved with scaling. Use Scale2D or ScaleUniform2D for scaling.
 func Transform2D(sdf SDF2, m M33) SDF2 {
 	s := TransformSDF2{}
 	s.sdf = sdf
@@ -428,6 +881,48 @@ func (s *ScaleUniformSDF2) BoundingBox() Box2 {
 	return s.bb
 }
 
+//-----------------------------------------------------------------------------
+// Non-Uniform XY Scaling of SDF2s
+// The distance is corrected by the smallest scale factor so it remains a lower
+// bound, but it is only exact when the scaling is uniform.
+
+// ScaleSDF2 is an SDF2 scaled by different amounts on each axis.
+type ScaleSDF2 struct {
+	sdf  SDF2
+	invK V2      // 1/scale for each axis
+	k    float64 // distance correction
+	bb   Box2
+}
+
+// Scale2D scales an SDF2 by k.X, k.Y on the x and y axes.
+func Scale2D(sdf SDF2, k V2) SDF2 {
+	if k.X == 0 || k.Y == 0 {
+		panic("zero scale factor")
+	}
+	return &ScaleSDF2{
+		sdf:  sdf,
+		invK: V2{1 / k.X, 1 / k.Y},
+		k:    Min(Abs(k.X), Abs(k.Y)),
+		bb:   Scale2d(k).MulBox(sdf.BoundingBox()),
+	}
+}
+
+// Evaluate returns the minimum distance to a scaled SDF2.
+// The distance is a lower bound, it is exact only for uniform scaling.
+func (s *ScaleSDF2) Evaluate(p V2) float64 {
+	return s.sdf.Evaluate(p.Mul(s.invK)) * s.k
+}
+
+// Exact returns true if the scaled distance is exact (uniform scaling).
+func (s *ScaleSDF2) Exact() bool {
+	return Abs(s.invK.X) == Abs(s.invK.Y)
+}
+
+// BoundingBox returns the bounding box of a scaled SDF2.
+func (s *ScaleSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
 //-----------------------------------------------------------------------------
 
 // Center2D centers the origin of an SDF2 on it's bounding box.
@@ -484,7 +979,7 @@ func (s *ArraySDF2) Evaluate(p V2) float64 {
 	d := math.MaxFloat64
 	for j := 0; j < s.num[0]; j++ {
 		for k := 0; k < s.num[1]; k++ {
-			x := p.Sub(V2{float64(j) * s.step.X, float64(k) * s.step.Y})
+			x := p.Sub(V2i{j, k}.ToV2().Mul(s.step))
 			d = s.min(d, s.sdf.Evaluate(x))
 		}
 	}
@@ -661,6 +1156,69 @@ func (s *SliceSDF2) BoundingBox() Box2 {
 
 //-----------------------------------------------------------------------------
 
+// ProjectSDF2 is the silhouette of an SDF3 projected onto a plane.
+type ProjectSDF2 struct {
+	sdf        SDF3    // the sdf3 being projected
+	u, v, w    V3      // 2d x-axis, 2d y-axis and view direction
+	wmin, wmax float64 // range of the sdf3 along the view direction
+	h          float64 // minimum step along the view direction
+	bb         Box2    // bounding box
+}
+
+// Project2D returns an SDF2 for the silhouette of an SDF3 projected onto a plane.
+// u and v are the directions of the 2d x and y axes. The distance is the minimum distance
+// along the view direction, so the SDF3 should not overestimate the distance.
+func Project2D(
+	sdf SDF3, // SDF3 to be projected
+	u, v V3, // 2d x and y axes
+) SDF2 {
+	s := ProjectSDF2{}
+	s.sdf = sdf
+	s.u = u.Normalize()
+	s.w = u.Cross(v).Normalize()
+	s.v = s.w.Cross(s.u)
+	v3 := sdf.BoundingBox().Vertices()
+	v2 := make(V2Set, len(v3))
+	s.wmin, s.wmax = math.Inf(1), math.Inf(-1)
+	for i, p := range v3 {
+		v2[i] = V2{p.Dot(s.u), p.Dot(s.v)}
+		s.wmin = Min(s.wmin, p.Dot(s.w))
+		s.wmax = Max(s.wmax, p.Dot(s.w))
+	}
+	s.bb = Box2{v2.Min(), v2.Max()}
+	s.h = 1e-4 * sdf.BoundingBox().Size().Length()
+	return &s
+}
+
+// Evaluate returns the minimum distance to the projected SDF2.
+func (s *ProjectSDF2) Evaluate(p V2) float64 {
+	q := s.u.MulScalar(p.X).Add(s.v.MulScalar(p.Y))
+	// The distance changes by at most 1 per unit step, so skip the steps that can't
+	// be below the minimum (to within the minimum step, or 1% of the distance outside
+	// and 10% inside).
+	dmin := math.Inf(1)
+	for t := s.wmin; ; {
+		d := s.sdf.Evaluate(q.Add(s.w.MulScalar(t)))
+		dmin = Min(dmin, d)
+		if t == s.wmax {
+			break
+		}
+		e := 0.01 * dmin
+		if dmin < 0 {
+			e = -0.1 * dmin
+		}
+		t = Min(t+d-dmin+Max(s.h, e), s.wmax)
+	}
+	return dmin
+}
+
+// BoundingBox returns the bounding box of the projected SDF2.
+func (s *ProjectSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+
 // UnionSDF2 is a union of multiple SDF2 objects.
 type UnionSDF2 struct {
 	sdf []SDF2
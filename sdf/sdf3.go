@@ -9,6 +9,7 @@
 package sdf
 
 import (
+	"errors"
 	"math"
 )
 
@@ -107,7 +108,7 @@ func Revolve3D(sdf SDF2) SDF3 {
 
 // Evaluate returns the minimum distance to a solid of revolution.
 func (s *SorSDF3) Evaluate(p V3) float64 {
-	x := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	x := math.Sqrt(float64(p.X*p.X) + float64(p.Y*p.Y))
 	a := s.sdf.Evaluate(V2{x, p.Z})
 	b := a
 	if s.theta != 0 {
@@ -135,6 +136,7 @@ type ExtrudeSDF3 struct {
 	sdf     SDF2
 	height  float64
 	extrude ExtrudeFunc
+	k       float64 // distance correction for distorting extrusions
 	bb      Box3
 }
 
@@ -144,6 +146,7 @@ func Extrude3D(sdf SDF2, height float64) SDF3 {
 	s.sdf = sdf
 	s.height = height / 2
 	s.extrude = NormalExtrude
+	s.k = 1
 	// work out the bounding box
 	bb := sdf.BoundingBox()
 	s.bb = Box3{V3{bb.Min.X, bb.Min.Y, -s.height}, V3{bb.Max.X, bb.Max.Y, s.height}}
@@ -160,6 +163,25 @@ func TwistExtrude3D(sdf SDF2, height, twist float64) SDF3 {
 	bb := sdf.BoundingBox()
 	l := bb.Max.Length()
 	s.bb = Box3{V3{-l, -l, -s.height}, V3{l, l, s.height}}
+	s.k = 1 / extrudeLipschitz(height, twist, V2{1, 1}, bb.Min.Abs().Max(bb.Max.Abs()).Length())
+	return &s
+}
+
+// HerringboneExtrude3D extrudes an SDF2 while rotating by twist radians from the mid-plane to
+// each end of the extrusion. The two halves are mirror images, meeting at the mid-plane.
+func HerringboneExtrude3D(sdf SDF2, height, twist float64) SDF3 {
+	s := ExtrudeSDF3{}
+	s.sdf = sdf
+	s.height = height / 2
+	k := twist / s.height
+	s.extrude = func(p V3) V2 {
+		return Rotate(Abs(p.Z) * k).MulPosition(V2{p.X, p.Y})
+	}
+	// work out the bounding box
+	bb := sdf.BoundingBox()
+	l := bb.Max.Length()
+	s.bb = Box3{V3{-l, -l, -s.height}, V3{l, l, s.height}}
+	s.k = 1 / extrudeLipschitz(s.height, twist, V2{1, 1}, bb.Min.Abs().Max(bb.Max.Abs()).Length())
 	return &s
 }
 
@@ -173,6 +195,7 @@ func ScaleExtrude3D(sdf SDF2, height float64, scale V2) SDF3 {
 	bb := sdf.BoundingBox()
 	bb = bb.Extend(Box2{bb.Min.Mul(scale), bb.Max.Mul(scale)})
 	s.bb = Box3{V3{bb.Min.X, bb.Min.Y, -s.height}, V3{bb.Max.X, bb.Max.Y, s.height}}
+	s.k = 1 / extrudeLipschitz(height, 0, scale, bb.Min.Abs().Max(bb.Max.Abs()).Length())
 	return &s
 }
 
@@ -187,13 +210,14 @@ func ScaleTwistExtrude3D(sdf SDF2, height, twist float64, scale V2) SDF3 {
 	bb = bb.Extend(Box2{bb.Min.Mul(scale), bb.Max.Mul(scale)})
 	l := bb.Max.Length()
 	s.bb = Box3{V3{-l, -l, -s.height}, V3{l, l, s.height}}
+	s.k = 1 / extrudeLipschitz(height, twist, scale, bb.Min.Abs().Max(bb.Max.Abs()).Length())
 	return &s
 }
 
 // Evaluate returns the minimum distance to an extrusion.
 func (s *ExtrudeSDF3) Evaluate(p V3) float64 {
 	// sdf for the projected 2d surface
-	a := s.sdf.Evaluate(s.extrude(p))
+	a := s.sdf.Evaluate(s.extrude(p)) * s.k
 	// sdf for the extrusion region: z = [-height, height]
 	b := Abs(p.Z) - s.height
 	// return the intersection
@@ -201,6 +225,7 @@ func (s *ExtrudeSDF3) Evaluate(p V3) float64 {
 }
 
 // SetExtrude sets the extrusion control function.
+// Use Normalize3D if the extrusion function distorts distances.
 func (s *ExtrudeSDF3) SetExtrude(extrude ExtrudeFunc) {
 	s.extrude = extrude
 }
@@ -231,7 +256,7 @@ func ExtrudeRounded3D(sdf SDF2, height, round float64) SDF3 {
 	}
 	s := ExtrudeRoundedSDF3{}
 	s.sdf = sdf
-	s.height = (height / 2) - round
+	s.height = float64(height/2) - round
 	if s.height < 0 {
 		panic("height < 2 * round")
 	}
@@ -242,6 +267,24 @@ func ExtrudeRounded3D(sdf SDF2, height, round float64) SDF3 {
 	return &s
 }
 
+// CheckedExtrudeRounded3D does a linear extrude of an SDF2 with rounded edges,
+// with an error for invalid parameters.
+func CheckedExtrudeRounded3D(sdf SDF2, height, round float64) (SDF3, error) {
+	if sdf == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	if round < 0 {
+		return nil, errors.New("round < 0")
+	}
+	if height < 2*round {
+		return nil, errors.New("height < 2 * round")
+	}
+	return ExtrudeRounded3D(sdf, height, round), nil
+}
+
 // Evaluate returns the minimum distance to a rounded extrusion.
 func (s *ExtrudeRoundedSDF3) Evaluate(p V3) float64 {
 	// sdf for the projected 2d surface
@@ -255,7 +298,7 @@ func (s *ExtrudeRoundedSDF3) Evaluate(p V3) float64 {
 			d = b
 		} else {
 			// outside the boundary
-			d = math.Sqrt((a * a) + (b * b))
+			d = math.Sqrt(float64(a*a) + float64(b*b))
 		}
 	} else {
 		// within the object Z extent
@@ -292,7 +335,7 @@ func Loft3D(sdf0, sdf1 SDF2, height, round float64) SDF3 {
 	s := LoftSDF3{
 		sdf0:   sdf0,
 		sdf1:   sdf1,
-		height: (height / 2) - round,
+		height: float64(height/2) - round,
 		round:  round,
 	}
 	if s.height < 0 {
@@ -306,6 +349,24 @@ func Loft3D(sdf0, sdf1 SDF2, height, round float64) SDF3 {
 	return &s
 }
 
+// CheckedLoft3D extrudes an SDF3 that transitions between two SDF2 shapes,
+// with an error for invalid parameters.
+func CheckedLoft3D(sdf0, sdf1 SDF2, height, round float64) (SDF3, error) {
+	if sdf0 == nil || sdf1 == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	if round < 0 {
+		return nil, errors.New("round < 0")
+	}
+	if height < 2*round {
+		return nil, errors.New("height < 2 * round")
+	}
+	return Loft3D(sdf0, sdf1, height, round), nil
+}
+
 // Evaluate returns the minimum distance to a loft extrusion.
 func (s *LoftSDF3) Evaluate(p V3) float64 {
 	// work out the mix value as a function of height
@@ -324,7 +385,7 @@ func (s *LoftSDF3) Evaluate(p V3) float64 {
 			d = b
 		} else {
 			// outside the boundary
-			d = math.Sqrt((a * a) + (b * b))
+			d = math.Sqrt(float64(a*a) + float64(b*b))
 		}
 	} else {
 		// within the object Z extent
@@ -364,6 +425,20 @@ func Box3D(size V3, round float64) SDF3 {
 	return &s
 }
 
+// CheckedBox3D return an SDF3 for a 3d box, with an error for invalid parameters.
+func CheckedBox3D(size V3, round float64) (SDF3, error) {
+	if size.X <= 0 || size.Y <= 0 || size.Z <= 0 {
+		return nil, errors.New("size <= 0")
+	}
+	if round < 0 {
+		return nil, errors.New("round < 0")
+	}
+	if 2*round > size.MinComponent() {
+		return nil, errors.New("round > half the box size")
+	}
+	return Box3D(size, round), nil
+}
+
 // Evaluate returns the minimum distance to a 3d box.
 func (s *BoxSDF3) Evaluate(p V3) float64 {
 	return sdfBox3d(p, s.size) - s.round
@@ -392,6 +467,14 @@ func Sphere3D(radius float64) SDF3 {
 	return &s
 }
 
+// CheckedSphere3D return an SDF3 for a sphere, with an error for invalid parameters.
+func CheckedSphere3D(radius float64) (SDF3, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	return Sphere3D(radius), nil
+}
+
 // Evaluate returns the minimum distance to a sphere.
 func (s *SphereSDF3) Evaluate(p V3) float64 {
 	return p.Length() - s.radius
@@ -416,17 +499,39 @@ type CylinderSDF3 struct {
 // Cylinder3D return an SDF3 for a cylinder (rounded edges with round > 0).
 func Cylinder3D(height, radius, round float64) SDF3 {
 	s := CylinderSDF3{}
-	s.height = (height / 2) - round
-	s.radius = radius - round
+	// float64() stops FMA fusion with the arguments of an inlined call (see vecf.go)
+	s.height = float64(height/2) - float64(round)
+	s.radius = float64(radius) - float64(round)
 	s.round = round
 	d := V3{radius, radius, height / 2}
 	s.bb = Box3{d.Neg(), d}
 	return &s
 }
 
-// Capsule3D return an SDF3 for a capsule.
+// CheckedCylinder3D return an SDF3 for a cylinder, with an error for invalid parameters.
+func CheckedCylinder3D(height, radius, round float64) (SDF3, error) {
+	if height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if round < 0 {
+		return nil, errors.New("round < 0")
+	}
+	if round > radius {
+		return nil, errors.New("round > radius")
+	}
+	if 2*round > height {
+		return nil, errors.New("round > height/2")
+	}
+	return Cylinder3D(height, radius, round), nil
+}
+
+// Capsule3D return an SDF3 for a capsule on the z-axis.
+// The height is the overall length (including the hemispherical ends).
 func Capsule3D(radius, height float64) SDF3 {
-	return Cylinder3D(radius, height, radius)
+	return Cylinder3D(height, radius, radius)
 }
 
 // Evaluate returns the minimum distance to a cylinder.
@@ -440,6 +545,88 @@ func (s *CylinderSDF3) BoundingBox() Box3 {
 	return s.bb
 }
 
+//-----------------------------------------------------------------------------
+// Cylinders and capsules between two points (exact distance field)
+
+// AxisCylinderSDF3 is a cylinder (with rounded edges) or a capsule with its axis between two points.
+type AxisCylinderSDF3 struct {
+	c      V3      // center of the axis
+	u      V3      // normalized axis vector
+	height float64 // half height (less the rounding)
+	radius float64 // radius (less the rounding)
+	round  float64 // rounding
+	bb     Box3    // bounding box
+}
+
+// newAxisCylinder returns a rounded cylinder with the end faces centered on a and b.
+func newAxisCylinder(a, b V3, radius, round float64) *AxisCylinderSDF3 {
+	v := b.Sub(a)
+	s := AxisCylinderSDF3{}
+	s.c = a.Add(b).MulScalar(0.5)
+	s.u = v.Normalize()
+	s.height = float64(0.5*v.Length()) - round
+	s.radius = radius - round
+	s.round = round
+	// the end circles are the extent of the cylinder
+	u := s.u
+	e := V3{
+		math.Sqrt(Max(1-float64(u.X*u.X), 0)),
+		math.Sqrt(Max(1-float64(u.Y*u.Y), 0)),
+		math.Sqrt(Max(1-float64(u.Z*u.Z), 0)),
+	}.MulScalar(radius)
+	s.bb = Box3{a.Min(b).Sub(e), a.Max(b).Add(e)}
+	return &s
+}
+
+// RoundedCylinder3D returns an SDF3 for a cylinder with the end faces centered on a and b,
+// and rounded edges (round > 0).
+func RoundedCylinder3D(a, b V3, radius, round float64) (SDF3, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if round < 0 {
+		return nil, errors.New("round < 0")
+	}
+	if round > radius {
+		return nil, errors.New("round > radius")
+	}
+	if a.Equals(b, tolerance) {
+		return nil, errors.New("zero length axis")
+	}
+	if 2*round > b.Sub(a).Length() {
+		return nil, errors.New("round > height/2")
+	}
+	return newAxisCylinder(a, b, radius, round), nil
+}
+
+// LineCapsule3D returns an SDF3 for a capsule (a sphere swept along a line) from a to b.
+func LineCapsule3D(a, b V3, radius float64) (SDF3, error) {
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if a.Equals(b, tolerance) {
+		return Transform3D(Sphere3D(radius), Translate3d(a)), nil
+	}
+	// extend the axis so the sphere centers are on a and b
+	u := b.Sub(a).Normalize().MulScalar(radius)
+	s := newAxisCylinder(a.Sub(u), b.Add(u), radius, radius)
+	s.bb = Box3{a.Min(b).SubScalar(radius), a.Max(b).AddScalar(radius)}
+	return s, nil
+}
+
+// Evaluate returns the minimum distance to a cylinder or capsule.
+func (s *AxisCylinderSDF3) Evaluate(p V3) float64 {
+	v := p.Sub(s.c)
+	z := v.Dot(s.u)
+	x := v.Sub(s.u.MulScalar(z)).Length()
+	return sdfBox2d(V2{x, z}, V2{s.radius, s.height}) - s.round
+}
+
+// BoundingBox returns the bounding box for a cylinder or capsule.
+func (s *AxisCylinderSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
 //-----------------------------------------------------------------------------
 // Cylinders of the same radius and height at various x/y positions
 // (E.g. drilling patterns) are useful enough to warrant their own SDF3 function.
@@ -498,15 +685,16 @@ type ConeSDF3 struct {
 // Cone3D returns the SDF3 for a trucated cone (round > 0 gives rounded edges).
 func Cone3D(height, r0, r1, round float64) SDF3 {
 	s := ConeSDF3{}
-	s.height = (height / 2) - round
+	s.height = float64(height/2) - round
 	s.round = round
 	// cone slope vector and normal
-	s.u = V2{r1, height / 2}.Sub(V2{r0, -height / 2}).Normalize()
+	h := float64(height / 2)
+	s.u = V2{r1, h}.Sub(V2{r0, -h}).Normalize()
 	s.n = V2{s.u.Y, -s.u.X}
 	// inset the radii for the rounding
 	ofs := round / s.n.X
-	s.r0 = r0 - (1+s.n.Y)*ofs
-	s.r1 = r1 - (1-s.n.Y)*ofs
+	s.r0 = r0 - float64((1+s.n.Y)*ofs)
+	s.r1 = r1 - float64((1-s.n.Y)*ofs)
 	// cone slope length
 	s.l = V2{s.r1, s.height}.Sub(V2{s.r0, -s.height}).Length()
 	// work out the bounding box
@@ -515,6 +703,29 @@ func Cone3D(height, r0, r1, round float64) SDF3 {
 	return &s
 }
 
+// CheckedCone3D returns the SDF3 for a trucated cone, with an error for invalid parameters.
+func CheckedCone3D(height, r0, r1, round float64) (SDF3, error) {
+	if height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	if r0 < 0 || r1 < 0 {
+		return nil, errors.New("radius < 0")
+	}
+	if r0 == 0 && r1 == 0 {
+		return nil, errors.New("both radii are 0")
+	}
+	if round < 0 {
+		return nil, errors.New("round < 0")
+	}
+	if 2*round > height {
+		return nil, errors.New("round > height/2")
+	}
+	if round > Max(r0, r1) {
+		return nil, errors.New("round > radius")
+	}
+	return Cone3D(height, r0, r1, round), nil
+}
+
 // Evaluate returns the minimum distance to a trucated cone.
 func (s *ConeSDF3) Evaluate(p V3) float64 {
 	// convert to SoR 2d coordinates
@@ -564,12 +775,13 @@ type TransformSDF3 struct {
 }
 
 // Transform3D applies a transformation matrix to an SDF3.
+// Use Scale3D or ScaleUniform3D for scaling.
 func Transform3D(sdf SDF3, matrix M44) SDF3 {
 	s := TransformSDF3{}
 	s.sdf = sdf
 	s.matrix = matrix
 	s.inverse = matrix.Inverse()
-	s.bb = matrix.MulBox(sdf.BoundingBox())
+	s.bb = transformedBox(sdf, matrix)
 	return &s
 }
 
@@ -617,6 +829,49 @@ func (s *ScaleUniformSDF3) BoundingBox() Box3 {
 	return s.bb
 }
 
+//-----------------------------------------------------------------------------
+// Non-Uniform XYZ Scaling of SDF3s
+// The distance is corrected by the smallest scale factor so it remains a lower
+// bound, but it is only exact when the scaling is uniform.
+
+// ScaleSDF3 is an SDF3 scaled by different amounts on each axis.
+type ScaleSDF3 struct {
+	sdf  SDF3
+	invK V3      // 1/scale for each axis
+	k    float64 // distance correction
+	bb   Box3
+}
+
+// Scale3D scales an SDF3 by k.X, k.Y, k.Z on the x, y and z axes.
+func Scale3D(sdf SDF3, k V3) SDF3 {
+	if k.X == 0 || k.Y == 0 || k.Z == 0 {
+		panic("zero scale factor")
+	}
+	return &ScaleSDF3{
+		sdf:  sdf,
+		invK: V3{1 / k.X, 1 / k.Y, 1 / k.Z},
+		k:    k.Abs().MinComponent(),
+		bb:   Scale3d(k).MulBox(sdf.BoundingBox()),
+	}
+}
+
+// Evaluate returns the minimum distance to a scaled SDF3.
+// The distance is a lower bound, it is exact only for uniform scaling.
+func (s *ScaleSDF3) Evaluate(p V3) float64 {
+	return s.sdf.Evaluate(p.Mul(s.invK)) * s.k
+}
+
+// Exact returns true if the scaled distance is exact (uniform scaling).
+func (s *ScaleSDF3) Exact() bool {
+	k := s.invK.Abs()
+	return k.X == k.Y && k.Y == k.Z
+}
+
+// BoundingBox returns the bounding box of a scaled SDF3.
+func (s *ScaleSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
 //-----------------------------------------------------------------------------
 
 // UnionSDF3 is a union of SDF3s.
@@ -867,7 +1122,7 @@ func (s *ArraySDF3) Evaluate(p V3) float64 {
 	for j := 0; j < s.num[0]; j++ {
 		for k := 0; k < s.num[1]; k++ {
 			for l := 0; l < s.num[2]; l++ {
-				x := p.Sub(V3{float64(j) * s.step.X, float64(k) * s.step.Y, float64(l) * s.step.Z})
+				x := p.Sub(V3i{j, k, l}.ToV3().Mul(s.step))
 				d = s.min(d, s.sdf.Evaluate(x))
 			}
 		}
@@ -5,9 +5,26 @@
 package sdf
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
 	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"io/ioutil"
 	"math"
+	"os"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
 )
 
 //-----------------------------------------------------------------------------
@@ -722,3 +739,5168 @@ func Test_Box2_Distances(t *testing.T) {
 }
 
 //-----------------------------------------------------------------------------
+
+func Test_MeshSDF3(t *testing.T) {
+	// mesh a box and check the mesh distance field against the box
+	box := Box3D(V3{10, 20, 30}, 0)
+	bb := box.BoundingBox().ScaleAboutCenter(1.2)
+	mesh := marchingCubes(box, bb, 1.0)
+	s, err := Mesh3D(mesh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []V3{
+		{0, 0, 0},
+		{4, 0, 0},
+		{0, -8, 12},
+		{6, 0, 0},
+		{0, 0, -20},
+		{0, 12, 0},
+	}
+	for _, p := range tests {
+		d0 := box.Evaluate(p)
+		d1 := s.Evaluate(p)
+		if Abs(d0-d1) > 0.1 {
+			t.Logf("p %v expected %f, actual %f\n", p, d0, d1)
+			t.Error("FAIL")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// edgeLengthStats returns the mean and standard deviation of mesh edge lengths.
+func edgeLengthStats(mesh []*Triangle3) (float64, float64) {
+	m := newIndexedMesh(mesh, 1e-9)
+	edges, _ := m.edges()
+	if len(edges) == 0 {
+		return 0, 0
+	}
+	var sum, sum2 float64
+	for _, e := range edges {
+		l := m.length(e)
+		sum += l
+		sum2 += l * l
+	}
+	n := float64(len(edges))
+	mean := sum / n
+	return mean, math.Sqrt(Max(sum2/n-mean*mean, 0))
+}
+
+func Test_Remesh(t *testing.T) {
+	s := Sphere3D(10)
+	bb := s.BoundingBox().ScaleAboutCenter(1.2)
+	mesh := marchingCubes(s, bb, 1.0)
+	mesh = Remesh(s, mesh, 2.0, 5)
+	mean, sd := edgeLengthStats(mesh)
+	if Abs(mean-2.0) > 0.3 || sd > 0.5 {
+		t.Logf("mean %f sd %f\n", mean, sd)
+		t.Error("FAIL")
+	}
+	for _, tri := range mesh {
+		for _, v := range tri.V {
+			if Abs(s.Evaluate(v)) > 0.01 {
+				t.Logf("vertex %v is off the surface\n", v)
+				t.Error("FAIL")
+				return
+			}
+		}
+	}
+}
+
+func Test_QuadMesh(t *testing.T) {
+	s := Box3D(V3{10, 20, 30}, 0)
+	m := QuadMesh3D(s, 30)
+	// every edge should be shared by exactly 2 quads
+	edges := make(map[[2]int]int)
+	for _, f := range m.F {
+		for i := range f {
+			a, b := f[i], f[(i+1)%4]
+			if a > b {
+				a, b = b, a
+			}
+			edges[[2]int{a, b}]++
+		}
+	}
+	for e, n := range edges {
+		if n != 2 {
+			t.Logf("edge %v is used %d times\n", e, n)
+			t.Error("FAIL")
+			break
+		}
+	}
+	// outward facing quads give a positive volume
+	volume := 0.0
+	for _, tri := range m.Triangles() {
+		volume += tri.V[0].Dot(tri.V[1].Cross(tri.V[2])) / 6
+	}
+	if Abs(volume-6000) > 60 {
+		t.Logf("volume %f\n", volume)
+		t.Error("FAIL")
+	}
+}
+
+func Test_Float32(t *testing.T) {
+	s0 := Sphere3D(10)
+	s1 := Box3D(V3{10, 20, 30}, 1)
+	s2 := Union3D(s0, Transform3D(s1, Translate3d(V3{10, 0, 0})))
+	s3 := Difference3D(ScaleUniform3D(s2, 0.5), Cylinder3D(30, 3, 0.5))
+	s4 := Intersect3D(s3, Extrude3D(Circle2D(8), 20))
+	s5 := Union3D(s0, s1)
+	s5.(*UnionSDF3).SetMin(PolyMin(2))
+	for _, s := range []SDF3{s0, s1, s2, s3, s4, s5} {
+		sf := Float32(s)
+		bb := s.BoundingBox()
+		for i := 0; i < 1000; i++ {
+			p := bb.Random()
+			if Abs(s.Evaluate(p)-sf.Evaluate(p)) > 1e-4 {
+				t.Logf("p %v d64 %f d32 %f\n", p, s.Evaluate(p), sf.Evaluate(p))
+				t.Error("FAIL")
+				break
+			}
+		}
+	}
+	// the operations and primitives are float32 nodes (the extrusion isn't)
+	if _, ok := toSDF3f(s4).(*intersection32); !ok {
+		t.Error("FAIL")
+	}
+	sf := toSDF3f(s3).(*difference32)
+	if _, ok := sf.s0.(*scaleUniform32).sdf.(*union32).sdf[1].(*transform32).sdf.(*box32); !ok || sf.max != nil {
+		t.Error("FAIL")
+	}
+	if _, ok := toSDF3f(s4).(*intersection32).s1.(*float64SDF3); !ok {
+		t.Error("FAIL")
+	}
+	// float32 meshing should give the same mesh topology
+	bb := s2.BoundingBox().ScaleAboutCenter(1.1)
+	m64 := marchingCubes(s2, bb, 1.0)
+	m32 := marchingCubes(Float32(s2), bb, 1.0)
+	if len(m64) != len(m32) {
+		t.Logf("float64 %d triangles, float32 %d triangles\n", len(m64), len(m32))
+		t.Error("FAIL")
+	}
+}
+
+func Test_Deterministic(t *testing.T) {
+	render := func() []Triangle3 {
+		b := NewBezier()
+		b.Add(-10, 0)
+		b.Add(0, 10).Mid()
+		b.Add(10, 0)
+		b.Add(0, -5).Mid()
+		b.Close()
+		s0 := Extrude3D(Polygon2D(b.Polygon().Vertices()), 5)
+		s1 := Transform3D(Sphere3D(4), RotateZ(0.3).Mul(Translate3d(V3{3, 2, 1})))
+		s := Union3D(s0, s1)
+		s.(*UnionSDF3).SetMin(PolyMin(1))
+		output := make(chan *Triangle3)
+		go func() {
+			marchingCubesOctree(s, 0.5, output)
+			close(output)
+		}()
+		var mesh []Triangle3
+		for tri := range output {
+			mesh = append(mesh, *tri)
+		}
+		return mesh
+	}
+	m0 := render()
+	m1 := render()
+	if len(m0) != len(m1) {
+		t.Error("FAIL")
+		return
+	}
+	for i := range m0 {
+		if m0[i] != m1[i] {
+			t.Logf("triangle %d differs\n", i)
+			t.Error("FAIL")
+			return
+		}
+	}
+}
+
+// Test_GoldenMesh checks the meshes of SDFs built from the FMA safe primitives and
+// CSG operations (see vecf.go) against hashes recorded on amd64, so they are bit-identical
+// on all platforms.
+func Test_GoldenMesh(t *testing.T) {
+	s0 := Box3D(V3{8, 6, 4}, 1)
+	s1 := Transform3D(Sphere3D(4), RotateZ(0.3).Mul(Translate3d(V3{3, 2, 1})))
+	s := Union3D(s0, s1)
+	s.(*UnionSDF3).SetMin(PolyMin(1))
+	// 2d primitives
+	e, _ := Ellipse2D(3, 2)
+	a, _ := AnnularSector2D(2, 4, DtoR(20), DtoR(160))
+	k, _ := Keyhole2D(1.5, 0.7, 3)
+	d, _ := DShape2D(2, 1)
+	s2d := Union2D(e, Transform2D(a, Translate2d(V2{0, 3})), Transform2D(k, Translate2d(V2{4, 0})))
+	s2d = Difference2D(s2d, Array2D(d, V2i{2, 1}, V2{3, 0}))
+	s2d = Union2D(s2d, RotateCopy2D(Transform2D(Line2D(3, 0.4), Translate2d(V2{0, -4})), 3))
+	// 3d primitives and csg
+	t0 := ExtrudeRounded3D(s2d, 3, 0.5)
+	t1 := Transform3D(Cone3D(6, 3, 1, 0.5), Translate3d(V3{-5, 0, 2}))
+	t2 := Capsule3D(1, 10)
+	t3 := Revolve3D(Transform2D(Circle2D(1), Translate2d(V2{5, 0})))
+	t4 := Intersect3D(Cylinder3D(8, 6, 1), Box3D(V3{8, 8, 3}, 0.5))
+	t5 := RotateCopy3D(Transform3D(Sphere3D(1), Translate3d(V3{6, 0, 1})), 5)
+	t6 := Array3D(Loft3D(Circle2D(1), Polygon2D(Nagon(6, 1.5)), 2, 0.2), V3i{2, 2, 1}, V3{3, 3, 0})
+	t6 = Transform3D(t6, MirrorPlane(V3{0, 0, -2}, V3{0.2, 0.1, 1}))
+	u := Union3D(t0, t1, t2, t3, t5, t6)
+	u.(*UnionSDF3).SetMin(PolyMin(0.5))
+	primitives := Difference3D(u, Transform3D(t4, Translate3d(V3{0, 0, 4})))
+	primitives = ScaleUniform3D(primitives, 1.1)
+	hash := func(mesh []*Triangle3) uint64 {
+		h := fnv.New64a()
+		b := make([]byte, 8)
+		for _, tri := range mesh {
+			for _, v := range tri.V {
+				for _, x := range []float64{v.X, v.Y, v.Z} {
+					u := math.Float64bits(x)
+					for i := range b {
+						b[i] = byte(u >> (8 * uint(i)))
+					}
+					h.Write(b)
+				}
+			}
+		}
+		return h.Sum64()
+	}
+	output := make(chan *Triangle3)
+	go func() {
+		marchingCubesOctree(s, 0.5, output)
+		close(output)
+	}()
+	var mesh []*Triangle3
+	for tri := range output {
+		mesh = append(mesh, tri)
+	}
+	h0 := hash(mesh)
+	h1 := hash(marchingCubes(s, s.BoundingBox(), 0.5))
+	h2 := hash(marchingCubes(primitives, primitives.BoundingBox(), 0.25))
+	// float32 rendering of the converted nodes
+	f := Difference3D(Union3D(s0, s1), Cylinder3D(10, 2, 0.5))
+	h3 := hash(marchingCubes(Float32(f), f.BoundingBox(), 0.5))
+	if h0 != 0x7bae34ebbe7167e1 || h1 != 0x6fc9b4cd72889823 || h2 != 0xe450b10743af4de1 || h3 != 0x6ac246af378a9315 {
+		t.Logf("mesh hashes %#x %#x %#x %#x\n", h0, h1, h2, h3)
+		t.Error("FAIL")
+	}
+}
+
+func Test_Profiler(t *testing.T) {
+	p := NewProfiler()
+	s0 := p.Profile3D(Sphere3D(5), "sphere")
+	s1 := p.Profile3D(Box3D(V3{5, 5, 5}, 0), "box")
+	s := p.Profile3D(Union3D(s0, s1), "union")
+	for i := 0; i < 100; i++ {
+		s.Evaluate(V3{float64(i), 0, 0})
+	}
+	for _, n := range p.nodes {
+		if n.calls != 100 {
+			t.Logf("%s: %d calls\n", n.name, n.calls)
+			t.Error("FAIL")
+		}
+	}
+	p.Reset()
+	if p.nodes[0].calls != 0 || p.nodes[0].nsecs != 0 {
+		t.Error("FAIL")
+	}
+	// profile every node of a tree
+	s0 = Transform3D(Sphere3D(5), Translate3d(V3{3, 0, 0}))
+	s1 = Extrude3D(Circle2D(2), 20)
+	s = Difference3D(Union3D(s0, Box3D(V3{5, 5, 5}, 0), s1), s1)
+	ps, p := Profile(s)
+	bb := s.BoundingBox()
+	for i := 0; i < 1000; i++ {
+		q := bb.Random()
+		if ps.Evaluate(q) != s.Evaluate(q) {
+			t.Error("FAIL")
+			break
+		}
+	}
+	calls := make(map[string]int64)
+	for _, n := range p.nodes {
+		calls[n.name] = n.calls
+	}
+	for name, n := range map[string]int64{
+		"DifferenceSDF3":              1000,
+		"DifferenceSDF3/UnionSDF3[0]": 1000,
+		"DifferenceSDF3/UnionSDF3[0]/TransformSDF3[0]/SphereSDF3": 1000,
+		"DifferenceSDF3/UnionSDF3[0]/ExtrudeSDF3[2]/CircleSDF2":   2000,
+	} {
+		if calls[name] != n {
+			t.Logf("%s: %d calls\n", name, calls[name])
+			t.Error("FAIL")
+		}
+	}
+	// the shared extrusion is profiled once
+	if len(p.nodes) != 7 {
+		t.Logf("%d nodes\n", len(p.nodes))
+		t.Error("FAIL")
+	}
+}
+
+func Test_Walk(t *testing.T) {
+	s0 := Extrude3D(Circle2D(5), 10)
+	s1 := Transform3D(Box3D(V3{5, 5, 5}, 0), Translate3d(V3{10, 0, 0}))
+	s := Difference3D(Union3D(s0, s1), Sphere3D(3))
+	var names []string
+	Walk(s, func(n interface{}, depth int) bool {
+		names = append(names, fmt.Sprintf("%d:%s", depth, NodeName(n)))
+		return true
+	})
+	expected := []string{
+		"0:DifferenceSDF3",
+		"1:UnionSDF3",
+		"2:ExtrudeSDF3",
+		"3:CircleSDF2",
+		"2:TransformSDF3",
+		"3:BoxSDF3",
+		"1:SphereSDF3",
+	}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Logf("%v\n", names)
+		t.Error("FAIL")
+	}
+}
+
+func Test_Optimize3D(t *testing.T) {
+	// fold transforms
+	s := Transform3D(Transform3D(Sphere3D(5), Translate3d(V3{1, 2, 3})), RotateZ(1))
+	s = Optimize3D(s)
+	if Children(Children(s)[0]) != nil {
+		t.Error("FAIL")
+	}
+	// an equivalent tree
+	hole := Cylinder3D(20, 1, 0)
+	var parts []SDF3
+	for i := 0; i < 8; i++ {
+		b := Box3D(V3{4, 4, 4}, 0.5)
+		parts = append(parts, Transform3D(b, Translate3d(V3{float64(6 * i), 0, 0})))
+	}
+	s0 := Union3D(Union3D(parts[:4]...), Union3D(parts[4:]...))
+	s1 := Union3D(hole, Transform3D(hole, Translate3d(V3{12, 0, 0})))
+	s = Difference3D(s0, Intersect3D(s1, Transform3D(s1, Identity3d())))
+	opt := Optimize3D(s)
+	bb := s.BoundingBox()
+	for i := 0; i < 10000; i++ {
+		p := bb.Random()
+		if Abs(s.Evaluate(p)-opt.Evaluate(p)) > tolerance {
+			t.Logf("p %v d %f opt %f\n", p, s.Evaluate(p), opt.Evaluate(p))
+			t.Error("FAIL")
+			break
+		}
+	}
+	// overlapping union children, the interior distances are the same
+	parts = nil
+	for i := 0; i < 6; i++ {
+		parts = append(parts, Transform3D(Sphere3D(5), Translate3d(V3{float64(3 * i), 0, 0})))
+	}
+	s = Union3D(parts...)
+	opt = Optimize3D(s)
+	if _, ok := opt.(*BVHUnionSDF3); !ok {
+		t.Error("FAIL")
+	}
+	bb = s.BoundingBox()
+	for i := 0; i < 10000; i++ {
+		p := bb.Random()
+		if i < 2 {
+			p = []V3{{4, 0, 0}, {7.5, 0, 0}}[i]
+		}
+		if Abs(s.Evaluate(p)-opt.Evaluate(p)) > tolerance {
+			t.Logf("p %v d %f opt %f\n", p, s.Evaluate(p), opt.Evaluate(p))
+			t.Error("FAIL")
+			break
+		}
+	}
+	// cached evaluations don't allocate
+	c := Cache3D(s)
+	p := V3{1, 2, 3}
+	if c.Evaluate(p) != s.Evaluate(p) || testing.AllocsPerRun(100, func() { c.Evaluate(p) }) != 0 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_TransformedBox(t *testing.T) {
+	// a rotated sphere has the same bounding box
+	s := Transform3D(Sphere3D(5), RotateX(0.5).Mul(RotateZ(0.7)))
+	if !s.BoundingBox().Equals(Sphere3D(5).BoundingBox(), tolerance) {
+		t.Logf("%v\n", s.BoundingBox())
+		t.Error("FAIL")
+	}
+	// nested rotations of a cylinder back to the start
+	c := Cylinder3D(20, 2, 0.5)
+	s = c
+	for i := 0; i < 8; i++ {
+		s = Transform3D(s, RotateY(Pi/4))
+	}
+	if !s.BoundingBox().Equals(c.BoundingBox(), 1e-6) {
+		t.Logf("%v\n", s.BoundingBox())
+		t.Error("FAIL")
+	}
+	// a cylinder on its side
+	s = Transform3D(c, RotateX(Pi/2))
+	if !s.BoundingBox().Equals(Box3{V3{-2, -10, -2}, V3{2, 10, 2}}, 1e-6) {
+		t.Logf("%v\n", s.BoundingBox())
+		t.Error("FAIL")
+	}
+}
+
+func Test_Normalize(t *testing.T) {
+	// distorting extrusions should have a lipschitz constant <= 1
+	s2 := Box2D(V2{6, 3}, 0)
+	for _, s := range []SDF3{
+		TwistExtrude3D(s2, 10, Tau),
+		ScaleExtrude3D(s2, 10, V2{0.3, 2}),
+		ScaleTwistExtrude3D(s2, 10, Pi, V2{0.5, 0.5}),
+	} {
+		bb := s.BoundingBox()
+		h := 1e-4
+		for i := 0; i < 10000; i++ {
+			p := bb.Random()
+			q := p.Add(bb.Random().Sub(bb.Center()).Normalize().MulScalar(h))
+			k := Abs(s.Evaluate(p)-s.Evaluate(q)) / p.Sub(q).Length()
+			if k > 1+1e-3 {
+				t.Logf("lipschitz %f at %v\n", k, p)
+				t.Error("FAIL")
+				break
+			}
+		}
+	}
+	s := Normalize3D(Sphere3D(5), 2)
+	if s.Evaluate(V3{10, 0, 0}) != 2.5 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Scale3D(t *testing.T) {
+	k := V3{1, 2, 4}
+	s := Scale3D(Sphere3D(5), k)
+	if s.(*ScaleSDF3).Exact() || !Scale3D(Sphere3D(5), V3{2, 2, -2}).(*ScaleSDF3).Exact() {
+		t.Error("FAIL")
+	}
+	if !s.BoundingBox().Equals(Box3{V3{-5, -10, -20}, V3{5, 10, 20}}, tolerance) {
+		t.Error("FAIL")
+	}
+	// points on the surface of the ellipsoid
+	for _, p := range []V3{{5, 0, 0}, {0, 10, 0}, {0, 0, -20}} {
+		if Abs(s.Evaluate(p)) > tolerance {
+			t.Error("FAIL")
+		}
+	}
+	// the distance is a lower bound
+	bb := s.BoundingBox().ScaleAboutCenter(1.5)
+	for i := 0; i < 10000; i++ {
+		p := bb.Random()
+		q := bb.Random()
+		if Abs(s.Evaluate(p)-s.Evaluate(q)) > p.Sub(q).Length()+tolerance {
+			t.Logf("p %v q %v\n", p, q)
+			t.Error("FAIL")
+			break
+		}
+	}
+}
+
+func Test_MedialAxis2D(t *testing.T) {
+	s := Box2D(V2{20, 10}, 0)
+	paths, err := MedialAxis2D(s, 100)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	maxRadius := 0.0
+	n := 0
+	for _, path := range paths {
+		for i, p := range path.Points {
+			// medial axis points are equidistant from the 2 closest sides
+			d := []float64{10 - p.X, 10 + p.X, 5 - p.Y, 5 + p.Y}
+			sort.Float64s(d)
+			if d[1]-d[0] > 0.5 {
+				t.Logf("point %v is not on the medial axis\n", p)
+				t.Error("FAIL")
+				return
+			}
+			if Abs(path.Radius[i]-d[0]) > 0.01 {
+				t.Error("FAIL")
+				return
+			}
+			maxRadius = Max(maxRadius, path.Radius[i])
+			n++
+		}
+	}
+	if n == 0 || Abs(maxRadius-5) > 0.1 {
+		t.Logf("%d points, max radius %f\n", n, maxRadius)
+		t.Error("FAIL")
+	}
+}
+
+func Test_Inscribed(t *testing.T) {
+	// 3,4,5 triangle, the incircle has radius 1
+	s2 := Polygon2D([]V2{{0, 0}, {4, 0}, {0, 3}})
+	c2, r2, err := InscribedCircle2D(s2, 50)
+	if err != nil || Abs(r2-1) > 1e-6 || !c2.Equals(V2{1, 1}, 1e-6) {
+		t.Logf("center %v radius %f\n", c2, r2)
+		t.Error("FAIL")
+	}
+	s3 := Box3D(V3{10, 20, 30}, 0)
+	_, r3, err := InscribedSphere3D(s3, 50)
+	if err != nil || Abs(r3-5) > 1e-6 {
+		t.Logf("radius %f\n", r3)
+		t.Error("FAIL")
+	}
+}
+
+func Test_Components(t *testing.T) {
+	s := Union3D(
+		Sphere3D(10),
+		Transform3D(Sphere3D(5), Translate3d(V3{20, 0, 0})),
+		Transform3D(Sphere3D(2), Translate3d(V3{0, 20, 0})),
+	)
+	c := Components3D(s, 50)
+	if len(c) != 3 {
+		t.Logf("%d components\n", len(c))
+		t.Error("FAIL")
+		return
+	}
+	if c[0].Evaluate(V3{}) >= 0 || c[0].Evaluate(V3{20, 0, 0}) <= 0 ||
+		c[1].Evaluate(V3{20, 0, 0}) >= 0 || c[1].Evaluate(V3{}) <= 0 {
+		t.Error("FAIL")
+	}
+	if !c[2].BoundingBox().Equals(Box3{V3{-2, 18, -2}, V3{2, 22, 2}}, 1.5) {
+		t.Logf("%v\n", c[2].BoundingBox())
+		t.Error("FAIL")
+	}
+	mesh := marchingCubes(s, s.BoundingBox().ScaleAboutCenter(1.1), 1.0)
+	if len(MeshComponents(mesh)) != 3 {
+		t.Error("FAIL")
+	}
+
+	// nested parts: a ball inside a spherical shell
+	s = Union3D(Difference3D(Sphere3D(3), Sphere3D(2.5)), Sphere3D(1.3))
+	c = Components3D(s, 40)
+	if len(c) != 2 {
+		t.Fatalf("%d components", len(c))
+	}
+	// the shell has inner and outer surfaces
+	for i, n := range []int{2, 1} {
+		if m := len(MeshComponents(RenderMesh(c[i], 60))); m != n {
+			t.Errorf("FAIL component %d has %d mesh components, expected %d", i, m, n)
+		}
+	}
+	// the ball isn't part of the shell
+	shell, ball := c[0], c[1]
+	for _, p := range []V3{{0, 0, 0}, {1.25, 0, 0}, {0, 0.9, 0.9}} {
+		if shell.Evaluate(p) <= 0 || ball.Evaluate(p) >= 0 {
+			t.Errorf("FAIL %v", p)
+		}
+	}
+}
+
+func Test_PrintOrientation(t *testing.T) {
+	// an upside down "T": a plate on top of a post
+	plate := Transform3D(Box3D(V3{20, 20, 2}, 0), Translate3d(V3{0, 0, 11}))
+	post := Box3D(V3{4, 4, 20}, 0)
+	s := Union3D(plate, post)
+	m := PrintOrientation3D(s, 40, DefaultPrintCost, 100)
+	// the plate should be on the build plate
+	up := m.MulPosition(V3{0, 0, -1})
+	if !up.Equals(V3{0, 0, 1}, 1e-3) {
+		t.Logf("up %v\n", up)
+		t.Error("FAIL")
+	}
+}
+
+func Test_VasePath(t *testing.T) {
+	// a cup: an open topped cylindrical shell
+	outer := Cylinder3D(10, 10, 0)
+	inner := Cylinder3D(10, 9, 0)
+	s := Difference3D(outer, Transform3D(inner, Translate3d(V3{0, 0, 1})))
+	k := VaseParms{
+		LayerHeight: 0.5,
+		MeshCells:   100,
+		FeedRate:    1200,
+		Extrusion:   0.05,
+	}
+	path, err := VasePath(s, &k)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// the first contour is at the layer height above the bed
+	if path[0].Z != k.LayerHeight {
+		t.Logf("z %f\n", path[0].Z)
+		t.Error("FAIL")
+	}
+	for i, p := range path {
+		if p.Z <= 0 {
+			t.Logf("z %f\n", p.Z)
+			t.Error("FAIL")
+			break
+		}
+		// z always rises
+		if i > 0 && p.Z < path[i-1].Z {
+			t.Logf("z %f < %f\n", p.Z, path[i-1].Z)
+			t.Error("FAIL")
+			break
+		}
+		// only the outer wall is followed
+		if r := (V2{p.X, p.Y}).Length(); Abs(r-10) > 0.1 {
+			t.Logf("r %f\n", r)
+			t.Error("FAIL")
+			break
+		}
+	}
+	if path[len(path)-1].Z < 4 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Coupons(t *testing.T) {
+	k := FitCouponParms{
+		Diameter:  5,
+		Clearance: 0.1,
+		Step:      0.1,
+		Steps:     5,
+		Thickness: 3,
+		Height:    5,
+		Spacing:   10,
+	}
+	s, err := FitCoupon3D(&k)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < k.Steps; i++ {
+		x := couponX(i, k.Steps, k.Spacing)
+		c := k.Clearance + float64(i)*k.Step
+		// distance from the hole center to the hole wall
+		if d := s[0].Evaluate(V3{x, 0, 0}); Abs(d-0.5*(k.Diameter+c)) > 1e-6 {
+			t.Logf("hole %d: %f\n", i, d)
+			t.Error("FAIL")
+		}
+		// distance from the peg surface to the peg center
+		if d := s[1].Evaluate(V3{x, 0, 4}); Abs(d+0.5*(k.Diameter-c)) > 1e-6 {
+			t.Logf("peg %d: %f\n", i, d)
+			t.Error("FAIL")
+		}
+	}
+	k.Spacing = 5
+	_, err = FitCoupon3D(&k)
+	if err == nil {
+		t.Error("FAIL")
+	}
+	_, err = OverhangCoupon3D(&OverhangCouponParms{
+		Angle:     DtoR(30),
+		Step:      DtoR(10),
+		Steps:     5,
+		Length:    10,
+		Thickness: 2,
+		Width:     5,
+		Base:      2,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = BridgeCoupon3D(&BridgeCouponParms{
+		Span:      10,
+		Step:      5,
+		Steps:     5,
+		Height:    5,
+		Thickness: 1,
+		Width:     5,
+		Pillar:    3,
+		Base:      2,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Gridfinity(t *testing.T) {
+	bin, err := GridfinityBin3D(&GridfinityBinParms{
+		Size:     V3i{2, 1, 3},
+		Wall:     1.2,
+		Dividers: V2i{1, 0},
+		Label:    true,
+		Lip:      true,
+		Magnets:  true,
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	tests := []struct {
+		p      V3
+		inside bool
+	}{
+		{V3{-21, 0, 3}, true},        // base
+		{V3{-21, 0, 10}, false},      // cavity
+		{V3{0, 0, 10}, true},         // divider
+		{V3{-21, 20.5, 18}, true},    // label shelf
+		{V3{-21 + 13, 13, 1}, false}, // magnet hole
+		{V3{-41.5, 0, 23}, true},     // stacking lip
+	}
+	for _, x := range tests {
+		if (bin.Evaluate(x.p) < 0) != x.inside {
+			t.Logf("bin %v\n", x.p)
+			t.Error("FAIL")
+		}
+	}
+	plate, err := GridfinityBaseplate3D(&GridfinityBaseplateParms{
+		Size:    V2i{2, 2},
+		Magnets: true,
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if plate.Evaluate(V3{21, 21, 4}) < 0 || plate.Evaluate(V3{0, 21, 4}) > 0 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Container(t *testing.T) {
+	k := ContainerParms{
+		InnerRadius:  20,
+		InnerHeight:  30,
+		Wall:         2,
+		Pitch:        4,
+		Starts:       2,
+		ThreadLength: 10,
+		Clearance:    0.3,
+		Knurl:        true,
+	}
+	s, err := Container3D(&k)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	body, lid := s[0], s[1]
+	// the internal volume is empty
+	if body.Evaluate(V3{0, 0, 1.5 * k.Wall}) < 0 || body.Evaluate(V3{0, 0, k.Wall + k.InnerHeight - 1}) < 0 {
+		t.Error("FAIL")
+	}
+	// the floor and neck are solid
+	if body.Evaluate(V3{0, 0, 0.5 * k.Wall}) > 0 || body.Evaluate(V3{k.InnerRadius + 0.5*k.Wall, 0, 28}) > 0 {
+		t.Error("FAIL")
+	}
+	// the lid is closed at the top and open at the bottom
+	if lid.Evaluate(V3{0, 0, 0.5 * k.Wall}) > 0 || lid.Evaluate(V3{0, 0, k.Wall + 1}) < 0 {
+		t.Error("FAIL")
+	}
+	// the lid can be screwed onto the body without the threads overlapping
+	overlap := func(dz float64) bool {
+		m := Translate3d(V3{0, 0, k.Wall + k.InnerHeight + k.Wall + dz}).Mul(RotateX(Pi))
+		l := Transform3D(lid, m)
+		for i := 0; i < 100; i++ {
+			a := Tau * float64(i) / 100
+			z := k.Wall + k.InnerHeight - k.ThreadLength + 1 + 8*float64(i)/100
+			for r := k.InnerRadius; r < k.InnerRadius+10; r += 0.1 {
+				p := V3{r * math.Cos(a), r * math.Sin(a), z}
+				if body.Evaluate(p) < 0 && l.Evaluate(p) < 0 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	lead := k.Pitch * float64(k.Starts)
+	fit := false
+	for dz := 0.0; dz < lead && !fit; dz += lead / 40 {
+		fit = !overlap(dz)
+	}
+	if !fit {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Hinge(t *testing.T) {
+	k := HingeParms{
+		Length:       40,
+		Width:        15,
+		Thickness:    3,
+		Knuckles:     5,
+		Radius:       3,
+		PinRadius:    1.5,
+		Clearance:    0.4,
+		PrintInPlace: true,
+	}
+	leaf, err := Hinge3D(&k)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// the pin is part of the first leaf and passes through the second leaf
+	if leaf[0].Evaluate(V3{0, 0, 0}) > 0 || leaf[1].Evaluate(V3{0, 0, 0}) < 0 {
+		t.Error("FAIL")
+	}
+	// the leaves don't touch
+	bb := leaf[0].BoundingBox().Extend(leaf[1].BoundingBox())
+	for i := 0; i < 20000; i++ {
+		p := bb.Random()
+		if leaf[0].Evaluate(p) < 0.5*k.Clearance && leaf[1].Evaluate(p) < 0.5*k.Clearance {
+			t.Logf("leaves touch at %v\n", p)
+			t.Error("FAIL")
+			break
+		}
+	}
+}
+
+func Test_Chain(t *testing.T) {
+	for _, style := range []string{"oval", "square", "ball"} {
+		k := ChainLinkParms{
+			Style:     style,
+			Length:    20,
+			Width:     12,
+			Wire:      3,
+			Clearance: 0.4,
+		}
+		link, pitch, err := ChainLink3D(&k)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		// adjacent links
+		var links []SDF3
+		for i := 0; i < 2; i++ {
+			x := float64(i) * pitch
+			s, err := Chain3D(link, []V3{{x, 0, 0}, {x + pitch, 0, 0}}, &ChainParms{
+				Pitch: pitch,
+				Roll:  DtoR(45) + float64(i)*DtoR(90),
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			links = append(links, s)
+		}
+		// the links don't touch
+		bb := links[0].BoundingBox().Extend(links[1].BoundingBox())
+		for i := 0; i < 20000; i++ {
+			p := bb.Random()
+			if links[0].Evaluate(p) < 0.4*k.Clearance && links[1].Evaluate(p) < 0.4*k.Clearance {
+				t.Logf("%s links touch at %v\n", style, p)
+				t.Error("FAIL")
+				break
+			}
+		}
+		// the wire of the second link passes through the first link
+		p := V3{k.Length - 2.5*k.Wire - 1.5*k.Clearance, 0, 0}
+		if style != "ball" && (links[1].Evaluate(p) > 0 || p.X > 0.5*pitch+0.5*k.Length-k.Wire) {
+			t.Error("FAIL")
+		}
+	}
+}
+
+func Test_ChainBend(t *testing.T) {
+	k := ChainLinkParms{
+		Style:     "oval",
+		Length:    20,
+		Width:     12,
+		Wire:      3,
+		Clearance: 0.4,
+	}
+	link, pitch, err := ChainLink3D(&k)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// bend the path by 30 degrees part way along a link
+	l := 2.5 * pitch
+	a := DtoR(30)
+	path := []V3{{0, 0, 0}, {l, 0, 0}, {l + l*math.Cos(a), l * math.Sin(a), 0}}
+	// the joints are on the path and a pitch apart
+	p, _ := NewPath3(path)
+	j0 := p.Point(0)
+	n := 0
+	for s, ok := nextJoint(p, 0, pitch); ok; s, ok = nextJoint(p, s, pitch) {
+		j1 := p.Point(s)
+		if !EqualFloat64(j1.Sub(j0).Length(), pitch, tolerance) {
+			t.Logf("joint %d is %f from the previous joint\n", n+1, j1.Sub(j0).Length())
+			t.Error("FAIL")
+		}
+		j0 = j1
+		n++
+	}
+	s, err := Chain3D(link, path, &ChainParms{
+		Pitch: pitch,
+		Roll:  DtoR(45),
+		Twist: DtoR(90),
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	links := s.(*UnionSDF3).sdf
+	if len(links) != n {
+		t.Error("FAIL")
+	}
+	// the links don't overlap
+	for i := 1; i < len(links); i++ {
+		for j := 0; j < i; j++ {
+			bb := links[i].BoundingBox().Extend(links[j].BoundingBox())
+			for m := 0; m < 20000; m++ {
+				q := bb.Random()
+				if links[i].Evaluate(q) < 0 && links[j].Evaluate(q) < 0 {
+					t.Logf("links %d and %d overlap at %v\n", j, i, q)
+					t.Error("FAIL")
+					break
+				}
+			}
+		}
+	}
+}
+
+func Test_UniversalJoint(t *testing.T) {
+	// parts don't touch when moved through an angle
+	noContact := func(a, b SDF3, clearance float64) bool {
+		bb := a.BoundingBox().Extend(b.BoundingBox())
+		for i := 0; i < 20000; i++ {
+			p := bb.Random()
+			if a.Evaluate(p) < clearance && b.Evaluate(p) < clearance {
+				t.Logf("contact at %v\n", p)
+				return false
+			}
+		}
+		return true
+	}
+	uj, err := UniversalJoint3D(&UniversalJointParms{Shaft: 8, Clearance: 0.4})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// the cross and second yoke turn on the pins of the first yoke
+	m := RotateX(DtoR(20))
+	if !noContact(uj[0], Union3D(Transform3D(uj[1], m), Transform3D(uj[2], m)), 0.1) {
+		t.Error("FAIL")
+	}
+	// the second yoke turns on the other pins of the cross
+	m = RotateY(DtoR(20))
+	if !noContact(Union3D(uj[0], uj[2]), Transform3D(uj[1], m), 0.1) {
+		t.Error("FAIL")
+	}
+	g, err := Gimbal3D(&GimbalParms{Radius: 20, Ring: 4, Height: 10, PinRadius: 2, Clearance: 0.4})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// the inner ring turns inside the outer ring
+	for _, a := range []float64{0, 30, 90} {
+		if !noContact(g[0], Transform3D(g[1], RotateX(DtoR(a))), 0.1) {
+			t.Error("FAIL")
+		}
+	}
+}
+
+func Test_Fasteners(t *testing.T) {
+	f, err := ISOBolt("M5")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if f.HexFlat2Flat != 8 || f.SocketKey != 4 || f.ClearanceNormal != 5.5 {
+		t.Error("FAIL")
+	}
+	_, err = ISOBolt("M7")
+	if err == nil {
+		t.Error("FAIL")
+	}
+	// the fastener tables agree with the thread database
+	for _, db := range []fastenerDatabase{isoFastenerDB, ansiFastenerDB} {
+		for name, f := range db {
+			th, err := ThreadLookup(f.Thread)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if !EqualFloat64(th.Pitch, f.Pitch, tolerance) || !EqualFloat64(2*th.Radius, f.Diameter, tolerance) || th.Units != f.Units {
+				t.Logf("%s %s\n", name, f.Thread)
+				t.Error("FAIL")
+			}
+			if f.ClearanceClose <= f.Diameter || f.WasherInner <= f.Diameter || f.WasherOuter <= f.HexFlat2Flat {
+				t.Logf("%s\n", name)
+				t.Error("FAIL")
+			}
+		}
+	}
+}
+
+func Test_Mass(t *testing.T) {
+	// 10mm cube
+	v := Volume3D(Box3D(V3{10, 10, 10}, 0), 50)
+	if Abs(v-1000) > 5 {
+		t.Logf("volume %f\n", v)
+		t.Error("FAIL")
+	}
+	m, err := MaterialLookup("PLA")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r := NewMassReport(1000, m)
+	if !EqualFloat64(r.Mass, 1.24, tolerance) {
+		t.Error("FAIL")
+	}
+	// 1 cm^3 of 1.75mm filament is ~0.416m
+	if Abs(r.FilamentLength-0.4158) > 1e-3 {
+		t.Logf("filament %f\n", r.FilamentLength)
+		t.Error("FAIL")
+	}
+	var b bytes.Buffer
+	r.Report(&b)
+	t.Logf("\n%s", b.String())
+	// the lookup returns a copy
+	m.Density = 0
+	m, _ = MaterialLookup("PLA")
+	if m.Density != 1.24 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_MeshArrays(t *testing.T) {
+	mesh := RenderMesh(Box3D(V3{10, 10, 10}, 0), 20)
+	v, n := MeshArrays(mesh)
+	if len(v) != 9*len(mesh) || len(n) != len(v) {
+		t.Error("FAIL")
+	}
+	for i := 0; i < len(n); i += 3 {
+		l := V3{float64(n[i]), float64(n[i+1]), float64(n[i+2])}.Length()
+		if !EqualFloat64(l, 1, 1e-5) {
+			t.Error("FAIL")
+			break
+		}
+	}
+	for _, x := range v {
+		if Abs(float64(x)) > 5+1e-3 {
+			t.Error("FAIL")
+			break
+		}
+	}
+}
+
+func Test_Params(t *testing.T) {
+	newParams := func() (*Params, *int, *float64, *string) {
+		p := NewParams("testmodel")
+		return p, p.Int("teeth", 20, "number of teeth"), p.Float("module", 1, "gear module"), p.String("name", "gear", "part name")
+	}
+	// defaults
+	p, teeth, module, name := newParams()
+	if err := p.Parse(nil); err != nil || *teeth != 20 || *module != 1 || *name != "gear" {
+		t.Error("FAIL")
+	}
+	// JSON file < environment < flags
+	f, err := ioutil.TempFile("", "params*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	fmt.Fprint(f, `{"teeth": 30, "module": 0.5, "name": "pinion"}`)
+	f.Close()
+	os.Setenv("TESTMODEL_MODULE", "2.5")
+	defer os.Unsetenv("TESTMODEL_MODULE")
+	p, teeth, module, name = newParams()
+	if err := p.Parse([]string{"-params", f.Name(), "-name=wheel"}); err != nil {
+		t.Fatal(err)
+	}
+	if *teeth != 30 || *module != 2.5 || *name != "wheel" {
+		t.Logf("%d %f %s", *teeth, *module, *name)
+		t.Error("FAIL")
+	}
+	if p.Values()["teeth"] != "30" {
+		t.Error("FAIL")
+	}
+	var buf bytes.Buffer
+	p.WriteJSON(&buf)
+	if !strings.Contains(buf.String(), `"teeth": 30`) {
+		t.Error("FAIL")
+	}
+	// bad values
+	os.Setenv("TESTMODEL_MODULE", "abc")
+	p, _, _, _ = newParams()
+	if p.Parse(nil) == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Regression(t *testing.T) {
+	s0 := Box3D(V3{10, 10, 10}, 1)
+	s1 := Box3D(V3{10, 10, 10.4}, 1)
+	d, err := CompareSDF3(s0, s1, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the top and bottom faces move by 0.2
+	if !EqualFloat64(d.Hausdorff, 0.2, 0.05) || !EqualFloat64(d.VolumeDelta, 40, 4) {
+		t.Logf("%s", d)
+		t.Error("FAIL")
+	}
+	if d.Check(0.5, 0.05) != nil || d.Check(0.1, 0.05) == nil || d.Check(0.5, 0.01) == nil {
+		t.Error("FAIL")
+	}
+	// stored references
+	dir, err := ioutil.TempDir("", "regress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"ref.stl", "ref.vox"} {
+		k := RegressionParms{
+			Reference:       dir + "/" + name,
+			MeshCells:       50,
+			Tolerance:       0.1,
+			VolumeTolerance: 0.01,
+		}
+		// the first run writes the reference
+		if d, err := Regression(s0, &k); d != nil || err != nil {
+			t.Error("FAIL")
+		}
+		if d, err := Regression(s0, &k); d == nil || err != nil {
+			t.Logf("%s %v", d, err)
+			t.Error("FAIL")
+		}
+		if _, err := Regression(s1, &k); err == nil {
+			t.Error("FAIL")
+		}
+	}
+}
+
+func Test_Cache(t *testing.T) {
+	model := func(r float64) SDF3 {
+		return Difference3D(Box3D(V3{10, 10, 10}, 1), Sphere3D(r))
+	}
+	h0, err := HashSDF3(model(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h1, _ := HashSDF3(model(6))
+	h2, _ := HashSDF3(model(6.5))
+	if h0 != h1 || h0 == h2 {
+		t.Error("FAIL")
+	}
+	// closures can't be hashed
+	u := Union3D(model(6), Sphere3D(1))
+	u.(*UnionSDF3).SetMin(PolyMin(1))
+	if _, err := HashSDF3(u); err == nil {
+		t.Error("FAIL")
+	}
+	// cache
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c, err := NewRenderCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v0, err := c.Voxel3D(model(6), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := c.Voxel3D(model(6), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hv0, _ := HashSDF3(v0)
+	hv1, _ := HashSDF3(v1)
+	if hv0 != hv1 {
+		t.Error("FAIL")
+	}
+	m0, _ := c.Mesh(model(6), 20)
+	m1, _ := c.Mesh(model(6), 20)
+	files, _ := ioutil.ReadDir(dir)
+	if len(m0) == 0 || len(m0) != len(m1) || len(files) != 2 || c.Err() != nil {
+		t.Error("FAIL")
+	}
+	// the render is returned when the cache file can't be written
+	os.RemoveAll(dir)
+	m2, err := c.Mesh(model(6), 20)
+	if err != nil || len(m2) != len(m0) || c.Err() == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Nest(t *testing.T) {
+	var parts []SDF2
+	for i := 0; i < 6; i++ {
+		parts = append(parts, Box2D(V2{40, 20}, 2), Circle2D(10))
+	}
+	k := NestParms{
+		Sheet:     V2{100, 100},
+		Margin:    2,
+		Spacing:   3,
+		Rotations: 4,
+	}
+	n, err := Nest2D(parts, &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Sheets < 2 || n.Sheets > 3 {
+		t.Logf("sheets %d", n.Sheets)
+		t.Error("FAIL")
+	}
+	// the parts are within the margins and spaced apart
+	placed := make([]SDF2, len(parts))
+	for i, p := range n.Placements {
+		placed[i] = Transform2D(parts[p.Part], p.Transform())
+	}
+	for i := 0; i < 200*200; i++ {
+		p := V2{float64(i%200) * 0.5, float64(i/200) * 0.5}
+		for sheet := 0; sheet < n.Sheets; sheet++ {
+			near := 0
+			for j, s := range placed {
+				if n.Placements[j].Sheet != sheet {
+					continue
+				}
+				d := s.Evaluate(p)
+				if d < 1.4 {
+					near++
+				}
+				if d < 0 && (p.X < k.Margin || p.Y < k.Margin || p.X > 100-k.Margin || p.Y > 100-k.Margin) {
+					t.Error("FAIL")
+				}
+			}
+			if near > 1 {
+				t.Error("FAIL")
+			}
+		}
+	}
+	if len(n.Lines(0.5)) == 0 {
+		t.Error("FAIL")
+	}
+	// too large
+	k.MaxSheets = 1
+	if _, err := Nest2D([]SDF2{Circle2D(60)}, &k); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := Nest2D(parts, &k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_NACA(t *testing.T) {
+	// symmetric 12% section
+	s, err := NACA2D(&NACAParms{Code: "0012", Chord: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	if !EqualFloat64(bb.Max.Y, 6, 0.05) || !EqualFloat64(bb.Min.Y, -6, 0.05) ||
+		!EqualFloat64(bb.Min.X, 0, 1e-6) || !EqualFloat64(bb.Max.X, 100, 1e-6) {
+		t.Logf("%v", bb)
+		t.Error("FAIL")
+	}
+	if s.Evaluate(V2{30, 0}) >= 0 || s.Evaluate(V2{30, 6.5}) <= 0 {
+		t.Error("FAIL")
+	}
+	// trailing edge thickness
+	v, _ := NACAProfile(&NACAParms{Code: "2412", Chord: 50, TrailingEdge: 0.8})
+	if !EqualFloat64(v[0].Sub(v[len(v)-1]).Length(), 0.8, 1e-6) {
+		t.Error("FAIL")
+	}
+	// cambered sections lift the mid chord
+	for _, code := range []string{"2412", "23012", "23112"} {
+		s, err := NACA2D(&NACAParms{Code: code, Chord: 100, TrailingEdge: 0.5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bb := s.BoundingBox()
+		if bb.Max.Y+bb.Min.Y <= 0 {
+			t.Logf("%s %v", code, bb)
+			t.Error("FAIL")
+		}
+	}
+	for _, code := range []string{"", "241", "24x2", "26012", "2012"} {
+		if _, err := NACA2D(&NACAParms{Code: code, Chord: 100}); err == nil {
+			t.Logf("%s", code)
+			t.Error("FAIL")
+		}
+	}
+}
+
+func Test_Propeller(t *testing.T) {
+	k := PropellerParms{
+		Blades:    3,
+		HubRadius: 10,
+		HubHeight: 12,
+		Bore:      5,
+		Stations: []BladeStation{
+			{Radius: 8, Chord: 14, Twist: PropellerTwist(80, 8)},
+			{Radius: 50, Chord: 10, Twist: PropellerTwist(80, 50), Sweep: 2},
+		},
+	}
+	s, err := Propeller3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.BoundingBox()
+	if bb.Max.X < 50 || bb.Max.X > 60 || bb.Max.Z < 6 {
+		t.Logf("%v", bb)
+		t.Error("FAIL")
+	}
+	// the blade stacking line is solid, the bore and the gaps between blades are empty
+	p := V3{30, 2 * 22.0 / 42.0, 0}
+	if s.Evaluate(p) >= 0 || s.Evaluate(V3{0, 0, 0}) <= 0 || s.Evaluate(V3{-30, 0, 0}) <= 0 {
+		t.Error("FAIL")
+	}
+	// n-fold symmetry
+	for i := 1; i < k.Blades; i++ {
+		q := RotateZ(Tau * float64(i) / float64(k.Blades)).MulPosition(p)
+		if !EqualFloat64(s.Evaluate(p), s.Evaluate(q), 1e-9) {
+			t.Error("FAIL")
+		}
+	}
+	// the leading edge is raised for +z thrust, and lowered for a clockwise propeller
+	b, _ := Blade3D(Polygon2D([]V2{{0, -0.05}, {1, -0.05}, {1, 0.05}, {0, 0.05}}), k.Stations, 0.5)
+	r := b.(*BladeSDF3).station(30)
+	le := V3{30, r.Sweep + 0.45*r.Chord*math.Cos(r.Twist), 0.45 * r.Chord * math.Sin(r.Twist)}
+	if b.Evaluate(le) >= 0 || b.Evaluate(V3{le.X, le.Y, -le.Z}) <= 0 {
+		t.Error("FAIL")
+	}
+	k.Clockwise = true
+	s, _ = Propeller3D(&k)
+	// the clockwise propeller is the mirror image
+	s0, _ := Propeller3D(&PropellerParms{Blades: 3, HubRadius: 10, HubHeight: 12, Bore: 5, Stations: k.Stations})
+	if !EqualFloat64(s.Evaluate(V3{30, 5, 3}), s0.Evaluate(V3{30, -5, 3}), 1e-9) {
+		t.Error("FAIL")
+	}
+	// bad parameters
+	for _, x := range []PropellerParms{
+		{Blades: 0, HubRadius: 10, HubHeight: 12, Stations: k.Stations},
+		{Blades: 3, HubRadius: 10, HubHeight: 12, Stations: k.Stations[:1]},
+		{Blades: 3, HubRadius: 10, HubHeight: 12, Bore: 20, Stations: k.Stations},
+		{Blades: 3, HubRadius: 5, HubHeight: 12, Stations: k.Stations},
+	} {
+		if _, err := Propeller3D(&x); err == nil {
+			t.Error("FAIL")
+		}
+	}
+}
+
+func Test_ThreadHoles(t *testing.T) {
+	k, err := ThreadHoleSizes("M6x1", 0.75)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", *k)
+	// standard tap drills: 5.0mm (cutting), 5.5mm (forming)
+	if !EqualFloat64(k.Major, 6, 1e-3) || !EqualFloat64(k.TapDrill, 5.0, 0.03) ||
+		!EqualFloat64(k.Forming, 5.5, 0.2) || k.ClearanceNormal != 6.6 {
+		t.Error("FAIL")
+	}
+	k, err = ThreadHoleSizes("M3x0.5", 0.75)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(k.TapDrill, 2.5, 0.015) {
+		t.Logf("%+v", *k)
+		t.Error("FAIL")
+	}
+	// any thread form, E.g. acme
+	k, err = ThreadFormHoles(AcmeThread(10, 4), 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(k.Minor, 16, 1e-3) || !EqualFloat64(k.TapDrill, 16, 1e-3) ||
+		k.Forming <= k.Minor || k.Forming >= k.Major {
+		t.Logf("%+v", *k)
+		t.Error("FAIL")
+	}
+	if _, err := ThreadFormHoles(AcmeThread(10, 4), 4, 1.5); err == nil {
+		t.Error("FAIL")
+	}
+	s, err := TapCoupon3D(&TapCouponParms{Thread: "M4x0.7", Steps: 4, Thickness: 5, Tolerance: 0.1})
+	if err != nil || len(s) != 2 {
+		t.Fatal(err)
+	}
+	// the first core hole is empty, and the matching pin is solid
+	x := couponX(0, 5, 10)
+	if s[0].Evaluate(V3{x, 0, 0}) <= 0 || s[1].Evaluate(V3{x, 0, 5}) >= 0 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_Printability(t *testing.T) {
+	// a slab bridging two pillars, a thin fin and a small hole
+	pillar := Box3D(V3{4, 10, 10}, 0)
+	s := Union3D(
+		Transform3D(pillar, Translate3d(V3{-13, 0, 0})),
+		Transform3D(pillar, Translate3d(V3{13, 0, 0})),
+		Transform3D(Box3D(V3{30, 10, 2}, 0), Translate3d(V3{0, 0, 6})),
+		Transform3D(Box3D(V3{0.2, 6, 5}, 0), Translate3d(V3{0, 0, -2.5})),
+	)
+	s = Difference3D(s, Transform3D(Cylinder3D(20, 0.5, 0), Translate3d(V3{-13, 0, 0})))
+	k := PrintabilityParms{
+		LayerHeight: 0.5,
+		Resolution:  0.1,
+		Nozzle:      0.4,
+		MaxBridge:   10,
+		MinHole:     2,
+	}
+	r, err := Printability(s, &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Count("bridge") == 0 || r.Count("thin") == 0 || r.Count("hole") == 0 {
+		t.Logf("%s", r)
+		t.Error("FAIL")
+	}
+	for _, x := range r.Issues {
+		switch x.Kind {
+		case "bridge":
+			if x.Z < 5 || !EqualFloat64(x.Size, 22, 1) {
+				t.Logf("%+v", x)
+				t.Error("FAIL")
+			}
+		case "thin":
+			if Abs(x.Center.X) > 0.1 || x.Size > 0.3 {
+				t.Logf("%+v", x)
+				t.Error("FAIL")
+			}
+		case "hole":
+			if !EqualFloat64(x.Center.X, -13, 0.1) || !EqualFloat64(x.Size, 1, 0.2) {
+				t.Logf("%+v", x)
+				t.Error("FAIL")
+			}
+		}
+	}
+	if b := r.Image().Bounds(); b.Dx() != r.nx || b.Dy() != r.ny {
+		t.Error("FAIL")
+	}
+	// no issues with relaxed limits
+	k.Nozzle, k.MaxBridge, k.MinHole = 0.1, 30, 0.5
+	k.Resolution = 0.05
+	r, _ = Printability(s, &k)
+	if len(r.Issues) != 0 {
+		t.Logf("%s", r)
+		t.Error("FAIL")
+	}
+}
+
+func Test_ArcGearRack(t *testing.T) {
+	rack := GearRack2D(20, 2, DtoR(20), 0, 5)
+	for _, radius := range []float64{1e5, -1e5} {
+		s, err := ArcGearRack2D(20, 2, DtoR(20), 0, 5, radius)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a large radius is the same as the straight rack near the middle
+		for _, p := range []V2{{0, 9}, {1, 7}, {3.2, 8}, {-2, 10}, {0.5, 2}} {
+			if !EqualFloat64(s.Evaluate(p), rack.Evaluate(p), 1e-3) {
+				t.Logf("%f %v %f %f", radius, p, s.Evaluate(p), rack.Evaluate(p))
+				t.Error("FAIL")
+			}
+		}
+	}
+	// the teeth are on the arc
+	s, _ := ArcGearRack2D(20, 2, DtoR(20), 0, 5, 100)
+	theta := 2 * Pi * 5 / 100 // 5 teeth along the arc
+	p := V2{0, 7.5 - 100}.Add(V2{math.Sin(theta), math.Cos(theta)}.MulScalar(101))
+	if s.Evaluate(p) >= 0 || s.Evaluate(V2{0, 7.5 - 100}.Add(V2{math.Sin(theta), math.Cos(theta)}.MulScalar(103))) <= 0 {
+		t.Error("FAIL")
+	}
+	bb := s.BoundingBox()
+	if p.X > bb.Max.X || p.Y < bb.Min.Y || bb.Max.Y < 9.5 || bb.Min.Y > 0 {
+		t.Logf("%v", bb)
+		t.Error("FAIL")
+	}
+	if _, err := ArcGearRack2D(200, 2, DtoR(20), 0, 5, 100); err == nil {
+		t.Error("FAIL")
+	}
+	// face rack teeth scale with the radius
+	f, err := FaceRack3D(30, 2, DtoR(20), 0, 3, 20, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []V2{{0, 6}, {0.8, 5.5}, {2.5, 7}, {1.6, 4.4}} {
+		z0 := 3 + 2.5
+		a := f.Evaluate(V3{30, q.X, q.Y})
+		b := f.Evaluate(V3{36, q.X * 36 / 30, z0 + (q.Y-z0)*36/30})
+		if (a < 0) != (b < 0) {
+			t.Logf("%v %f %f", q, a, b)
+			t.Error("FAIL")
+		}
+	}
+	if f.Evaluate(V3{30, 0, 2}) >= 0 || f.Evaluate(V3{10, 0, 2}) <= 0 || f.Evaluate(V3{45, 0, 2}) <= 0 {
+		t.Error("FAIL")
+	}
+}
+
+func Test_HelicalGear(t *testing.T) {
+	k := HelicalGearParms{
+		NumberTeeth:   20,
+		Module:        2,
+		PressureAngle: DtoR(20),
+		FaceWidth:     10,
+		Clearance:     0.5,
+		RingWidth:     5,
+		Facets:        7,
+	}
+	// no helix angle is a spur gear
+	s, err := HelicalGear3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spur := Extrude3D(InvoluteGear(20, 2, DtoR(20), 0, 0.5, 5, 7), 10)
+	for _, p := range []V3{{20, 0, 0}, {21, 1, 2}, {19.5, 1.5, -3}, {17, 0, 0}} {
+		if !EqualFloat64(s.Evaluate(p), spur.Evaluate(p), 1e-9) {
+			t.Error("FAIL")
+		}
+	}
+	// right hand helix, the teeth advance counter-clockwise with z
+	k.HelixAngle = DtoR(20)
+	s, _ = HelicalGear3D(&k)
+	rp := k.PitchRadius()
+	if !EqualFloat64(rp, 20*2/math.Cos(k.HelixAngle)/2, 1e-9) {
+		t.Error("FAIL")
+	}
+	bb := s.BoundingBox()
+	if bb.Max.X < rp+2 {
+		t.Error("FAIL")
+	}
+	a := 2 * math.Tan(k.HelixAngle) / rp
+	for _, p := range []V2{{rp, 0}, {rp + 1, 1}, {rp - 0.5, 1.5}, {rp + 1.5, -0.7}} {
+		q := Rotate(a).MulPosition(p)
+		if !EqualFloat64(s.Evaluate(p.ToV3(0)), s.Evaluate(q.ToV3(2)), 1e-6) {
+			t.Error("FAIL")
+		}
+	}
+	// the tooth depth is set by the normal module
+	if !EqualFloat64(bb.Max.Z, 5, 1e-9) || s.Evaluate(V3{rp + 2 + 0.1, 0, 0}) <= 0 {
+		t.Error("FAIL")
+	}
+	k.FaceWidth = 0
+	if _, err := HelicalGear3D(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_HerringboneGear(t *testing.T) {
+	k := HelicalGearParms{
+		NumberTeeth:   16,
+		Module:        2,
+		PressureAngle: DtoR(20),
+		HelixAngle:    DtoR(30),
+		FaceWidth:     12,
+		Clearance:     0.5,
+		RingWidth:     5,
+		Facets:        7,
+	}
+	s, err := HerringboneGear3D(&k, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the lower half is the helical gear, the upper half is its mirror image
+	h, _ := HelicalGear3D(&k)
+	rp := k.PitchRadius()
+	for _, p := range []V3{{rp, 0, -3}, {rp + 1, 1, -5}, {rp - 0.5, 1.5, -1}, {rp + 1.5, -0.7, -2}} {
+		if !EqualFloat64(s.Evaluate(p), h.Evaluate(p), 1e-9) ||
+			!EqualFloat64(s.Evaluate(p), s.Evaluate(V3{p.X, p.Y, -p.Z}), 1e-9) {
+			t.Error("FAIL")
+		}
+	}
+	// the relief groove is cut down to the root circle
+	s, _ = HerringboneGear3D(&k, 2)
+	if s.Evaluate(V3{rp, 0, 0}) <= 0 || s.Evaluate(V3{rp - 3, 0, 0}) >= 0 {
+		t.Error("FAIL")
+	}
+	if _, err := HerringboneGear3D(&k, 12); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+func Test_InternalGear(t *testing.T) {
+	n, np := 40, 12
+	m := 2.0
+	pa := DtoR(20)
+	ring := InternalInvoluteGear(n, m, pa, 0.1, 0.25*m, 5, 10)
+	rp := float64(n) * m / 2
+	theta := Pi / float64(n)
+	// tooth spaces on the tooth angles, teeth between them
+	if ring.Evaluate(V2{rp, 0}) <= 0 || ring.Evaluate(V2{rp * math.Cos(theta), rp * math.Sin(theta)}) >= 0 {
+		t.Error("FAIL")
+	}
+	// the bore is the tip circle, the ring wall is outside the root circle
+	if ring.Evaluate(V2{rp - m - 0.1, 0}) <= 0 || ring.Evaluate(V2{rp + 1.25*m + 0.1, 0}) >= 0 ||
+		ring.Evaluate(V2{rp + 1.25*m + 5.1, 0}) <= 0 {
+		t.Error("FAIL")
+	}
+	// a meshing pinion doesn't overlap the ring
+	pinion := InvoluteGear(np, m, pa, 0.1, 0.25*m, 3, 10)
+	pinion = Transform2D(pinion, Translate2d(V2{rp - float64(np)*m/2, 0}))
+	for x := rp - 10.0; x < rp+5; x += 0.05 {
+		for y := -8.0; y < 8; y += 0.05 {
+			if Max(ring.Evaluate(V2{x, y}), pinion.Evaluate(V2{x, y})) < 0 {
+				t.Logf("overlap at %f %f", x, y)
+				t.Fatal("FAIL")
+			}
+		}
+	}
+}
+
+func Test_CamProgram(t *testing.T) {
+	p := CamProgram{
+		{"cycloidal", DtoR(120), 10},
+		{"dwell", DtoR(60), 0},
+		{"poly345", DtoR(120), -10},
+		{"dwell", DtoR(60), 0},
+	}
+	if err := p.CheckC2(1e-6); err != nil {
+		t.Error(err)
+	}
+	s, v, _ := p.Displacement(DtoR(60))
+	if !EqualFloat64(s, 5, 1e-9) || v <= 0 {
+		t.Error("FAIL")
+	}
+	if s, _, _ = p.Displacement(DtoR(150)); !EqualFloat64(s, 10, 1e-9) || p.MaxLift() != 10 {
+		t.Error("FAIL")
+	}
+	// the derivatives match the displacement
+	for _, theta := range []float64{0.3, 1.1, 3.7, 4.0} {
+		h := 1e-5
+		s0, _, _ := p.Displacement(theta - h)
+		s1, v, a := p.Displacement(theta)
+		s2, _, _ := p.Displacement(theta + h)
+		if !EqualFloat64(v, (s2-s0)/(2*h), 1e-4) || !EqualFloat64(a, (s2-2*s1+s0)/(h*h), 1e-2) {
+			t.Logf("%f %f %f", theta, v, a)
+			t.Error("FAIL")
+		}
+	}
+	// harmonic motion between dwells has acceleration jumps
+	p[0].Law = "harmonic"
+	if err := p.CheckC2(1e-6); err == nil || strings.Contains(err.Error(), "velocity") {
+		t.Error("FAIL")
+	}
+	p[0].Law = "linear"
+	if err := p.CheckC2(1e-6); err == nil || !strings.Contains(err.Error(), "velocity") {
+		t.Error("FAIL")
+	}
+	// bad programs
+	for _, x := range []CamProgram{
+		{{"cycloidal", DtoR(180), 10}, {"dwell", DtoR(180), 0}},
+		{{"cycloidal", DtoR(180), 10}, {"poly345", DtoR(170), -10}},
+		{{"spline", DtoR(180), 10}, {"poly345", DtoR(180), -10}},
+		{{"dwell", DtoR(180), 10}, {"poly345", DtoR(180), -10}},
+	} {
+		if x.Check() == nil {
+			t.Error("FAIL")
+		}
+	}
+	// knife edge cam, the follower is on +y at the start
+	p[0].Law = "cycloidal"
+	cam, err := ProgramCam2D(p, 20, 720)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Abs(cam.Evaluate(V2{0, 20})) > 1e-3 || Abs(cam.Evaluate(V2{15, -15 * math.Sqrt(3)})) > 1e-2 ||
+		cam.Evaluate(V2{0, 0}) >= 0 {
+		t.Logf("%f", cam.Evaluate(V2{0, 20}))
+		t.Error("FAIL")
+	}
+}
+
+func Test_EdgeFillet(t *testing.T) {
+	// a washer profile
+	profile := Polygon2D([]V2{{5, 0}, {20, 0}, {20, 4}, {5, 4}})
+	s := Revolve3D(profile)
+	i, err := ProfileVertex(s, "top outer")
+	if err != nil || i != 2 {
+		t.Fatal(err)
+	}
+	if i, _ := ProfileVertex(s, "bottom inner"); i != 0 {
+		t.Error("FAIL")
+	}
+	if _, err := ProfileVertex(s, "top"); err == nil {
+		t.Error("FAIL")
+	}
+	// fillet the top outer edge
+	f, err := FilletEdge3D(s, 2, 2, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corner := V3{20, 0, 4}
+	center := V3{18, 0, 2}
+	if f.Evaluate(corner) <= 0 || Abs(f.Evaluate(center.Add(V3{1, 0, 1}.Normalize().MulScalar(2)))) > 0.01 {
+		t.Error("FAIL")
+	}
+	if !EqualFloat64(f.Evaluate(V3{0, 12, 6}), 2, 1e-9) {
+		t.Error("FAIL")
+	}
+	// chamfer an extruded edge, the cut is at the size along each edge
+	e := Transform3D(Extrude3D(Polygon2D([]V2{{0, 0}, {10, 0}, {10, 5}, {0, 5}}), 3), Translate3d(V3{0, 0, 1}))
+	i, _ = ProfileVertex(e, "right bottom")
+	c, err := ChamferEdge3D(e, i, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Evaluate(V3{9.6, 0.3, 1}) <= 0 || c.Evaluate(V3{8.9, 0.05, 1}) >= 0 || c.Evaluate(V3{9.95, 1.1, 1}) >= 0 {
+		t.Error("FAIL")
+	}
+	// errors
+	if _, err := FilletEdge3D(s, 2, 10, 4); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := FilletEdge3D(Sphere3D(1), 0, 1, 4); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ThreadProfile(t *testing.T) {
+	r, p := 10.0, 2.0
+	// thread depths for the built-in profiles
+	tests := []struct {
+		profile ThreadProfile
+		depth   float64
+	}{
+		{ISOThreadProfile{}, (17.0 / 24.0) * p * math.Sqrt(3) / 2},
+		{SquareThreadProfile{}, 0.5 * p},
+		{KnuckleThreadProfile{}, 0.5 * p},
+		{WhitworthThreadProfile{}, 0.640327 * p},
+		{AcmeThreadProfile{}, 0.5 * p},
+	}
+	for i, x := range tests {
+		k, err := ThreadFormHoles(ThreadProfile2D(x.profile, r, p), p, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !EqualFloat64(k.Major, 2*r, 1e-3) || !EqualFloat64(0.5*(k.Major-k.Minor), x.depth, 1e-2) {
+			t.Logf("%d: major %f depth %f", i, k.Major, 0.5*(k.Major-k.Minor))
+			t.Error("FAIL")
+		}
+	}
+	// the existing thread functions use the profiles
+	iso := ISOThread(r, p, "internal")
+	isoProfile := ThreadProfile2D(ISOThreadProfile{Internal: true}, r, p)
+	for _, v := range []V2{{0, 9}, {0.3, 8.7}, {-0.8, 8.9}} {
+		if iso.Evaluate(v) != isoProfile.Evaluate(v) {
+			t.Error("FAIL")
+		}
+	}
+	// a custom sawtooth profile
+	saw := ThreadFunc(func(radius, pitch float64) []V2 {
+		return []V2{{pitch, 0}, {pitch, radius}, {0.5 * pitch, radius - 0.5*pitch}, {-0.5 * pitch, radius}, {-pitch, radius - 0.5*pitch}, {-pitch, 0}}
+	})
+	s := ThreadScrew3D(saw, r, 20, p, 1, RightHand)
+	if s.Evaluate(V3{9.3, 0, 0}) >= 0 || s.Evaluate(V3{10.1, 0, 0}) <= 0 {
+		t.Error("FAIL")
+	}
+	k, err := ThreadFormHoles(ThreadProfile2D(saw, r, p), p, 1)
+	if err != nil || !EqualFloat64(k.Minor, 2*(r-0.5*p), 1e-3) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Simplify(t *testing.T) {
+	// a densely sampled circle
+	circle := make([]V2, 1000)
+	for i := range circle {
+		s, c := math.Sincos(Tau * float64(i) / float64(len(circle)))
+		circle[i] = V2{c, s}.MulScalar(10)
+	}
+	tol := 0.01
+	v := Simplify(circle, tol, true)
+	if len(v) >= len(circle)/4 || len(v) < 8 {
+		t.Logf("%d vertices", len(v))
+		t.Error("FAIL")
+	}
+	// all the original vertices are within tolerance of the simplified polygon
+	s := Polygon2D(v)
+	for _, p := range circle {
+		if Abs(s.Evaluate(p)) > tol+1e-9 {
+			t.Error("FAIL")
+			break
+		}
+	}
+	// collinear points are removed from a polyline
+	line := []V2{{0, 0}, {1, 0}, {2, 0}, {3, 0.001}, {4, 0}, {4, 5}}
+	v = Simplify(line, 0.01, false)
+	if len(v) != 3 || !v[1].Equals(V2{4, 0}, 1e-12) {
+		t.Logf("%v", v)
+		t.Error("FAIL")
+	}
+	// resample a square to equal spacing
+	square := []V2{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+	v = Resample(square, 0.3, true)
+	if len(v) != 54 {
+		t.Logf("%d vertices", len(v))
+		t.Error("FAIL")
+	}
+	for i := range v {
+		d := v[(i+1)%len(v)].Sub(v[i]).Length()
+		if d > 16.0/54.0+1e-9 {
+			t.Error("FAIL")
+			break
+		}
+	}
+	v = Resample(line[:2], 0.25, false)
+	if len(v) != 5 || !v[4].Equals(V2{1, 0}, 1e-12) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_CycloidalGear(t *testing.T) {
+	n0, n1 := 30, 8
+	m := 1.0
+	r0 := float64(n0) * m / 2
+	r1 := float64(n1) * m / 2
+	g0 := CycloidalGear(n0, n1, m, 0.05, 0.1, 2, 8)
+	g1 := CycloidalGear(n1, n0, m, 0.05, 0.1, 1, 8)
+	// turn the gears through a tooth, the teeth mustn't overlap
+	for step := 0; step < 8; step++ {
+		a0 := (Tau / float64(n0)) * float64(step) / 8
+		a1 := -a0*float64(n0)/float64(n1) + Pi/float64(n1)
+		s0 := Transform2D(g0, Rotate2d(a0))
+		s1 := Transform2D(g1, Translate2d(V2{r0 + r1, 0}).Mul(Rotate2d(a1)))
+		dmin := math.Inf(1)
+		for x := r0 - 1.5*m; x <= r0+1.5*m; x += 0.02 {
+			for y := -2.5 * m; y <= 2.5*m; y += 0.02 {
+				p := V2{x, y}
+				d := Max(s0.Evaluate(p), s1.Evaluate(p))
+				dmin = Min(dmin, d)
+			}
+		}
+		if dmin < -0.02 || dmin > 0.1 {
+			t.Logf("step %d: %f", step, dmin)
+			t.Error("FAIL")
+		}
+	}
+	// cycloidal drive disk, all the ring pins touch the offset disk
+	e := 2.0
+	disk, err := CycloidalDisk2D(10, 40, 4, e, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		p := PolarToXY(40, Tau*float64(i)/10).Sub(V2{e, 0})
+		if Abs(disk.Evaluate(p)-4) > 0.01 {
+			t.Error("FAIL")
+		}
+	}
+	if _, err := CycloidalDisk2D(10, 40, 4, 5, 2000); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Mirror3D(t *testing.T) {
+	// a right hand screw keeps its hand, mirroring across the YZ plane
+	// is then the same as a half turn about the z-axis.
+	screw := ThreadScrew3D(ISOThreadProfile{}, 5, 20, 1.5, 1, RightHand)
+	part := Union3D(Transform3D(screw, Translate3d(V3{0, 0, 10})), Box3D(V3{12, 12, 4}, 0))
+	m, err := Mirror3D(part, MirrorYZ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := Transform3D(part, RotateZ(Pi))
+	naive := Transform3D(part, MirrorYZ())
+	differs := false
+	bb := part.BoundingBox()
+	for _, p := range bb.RandomSet(1000) {
+		if !EqualFloat64(m.Evaluate(p), ref.Evaluate(p), 1e-9) {
+			t.Error("FAIL")
+			break
+		}
+		if !EqualFloat64(naive.Evaluate(p), ref.Evaluate(p), 1e-6) {
+			differs = true
+		}
+	}
+	if !differs {
+		t.Error("FAIL")
+	}
+	// a chiral feature (an L shape) is mirrored about its own center
+	l := Extrude3D(Polygon2D([]V2{{0, 0}, {4, 0}, {4, 1}, {1, 1}, {1, 6}, {0, 6}}), 1)
+	l = Transform3D(l, Translate3d(V3{10, 0, 0}))
+	c := l.BoundingBox().Center()
+	parts, err := MirrorPair3D(Chiral3D(l, V3{1, 0, 0}), MirrorYZ())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb = l.BoundingBox()
+	for _, p := range bb.RandomSet(100) {
+		q := p.Sub(V3{2 * c.X, 0, 0})
+		if !EqualFloat64(parts[1].Evaluate(q), l.Evaluate(p), 1e-9) {
+			t.Error("FAIL")
+			break
+		}
+	}
+	// errors
+	if _, err := Mirror3D(part, RotateZ(1)); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Handedness(t *testing.T) {
+	// a left hand part is the mirror image of the right hand part
+	rh := &SpringParms{WireDiameter: 1, CoilDiameter: 10, Pitch: 3, Length: 20}
+	lh := *rh
+	lh.Hand = LeftHand
+	s0, err := Spring3D(rh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, _ := Spring3D(&lh)
+	b0, err := Bolt(&BoltParms{Thread: "M6x1", Style: "hex", TotalLength: 20, ShankLength: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b1, _ := Bolt(&BoltParms{Thread: "M6x1", Style: "hex", TotalLength: 20, ShankLength: 5, Hand: LeftHand})
+	g := &HelicalGearParms{NumberTeeth: 12, Module: 1, PressureAngle: DtoR(20), HelixAngle: DtoR(15), FaceWidth: 5, Facets: 5}
+	g0, _ := HelicalGear3D(g)
+	g.Hand = LeftHand
+	g1, _ := HelicalGear3D(g)
+	a := &AugerParms{ShaftDiameter: 4, FlightDiameter: 20, FlightThickness: 1, Pitch: 8, Starts: 2, Length: 30}
+	a0, err := Auger3D(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Hand = LeftHand
+	a1, _ := Auger3D(a)
+	for i, x := range [][2]SDF3{{s0, s1}, {b0, b1}, {g0, g1}, {a0, a1}} {
+		m := Transform3D(x[0], MirrorXZ())
+		bb := x[0].BoundingBox()
+		differs := false
+		for _, p := range bb.RandomSet(500) {
+			if !EqualFloat64(x[1].Evaluate(p), m.Evaluate(p), 1e-9) {
+				t.Logf("%d: %v", i, p)
+				t.Error("FAIL")
+				break
+			}
+			if !EqualFloat64(x[0].Evaluate(p), x[1].Evaluate(p), 1e-9) {
+				differs = true
+			}
+		}
+		if !differs {
+			t.Error("FAIL")
+		}
+	}
+	if _, err := Spring3D(&SpringParms{WireDiameter: 2, CoilDiameter: 10, Pitch: 1, Length: 10}); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_PlanetaryGearSet(t *testing.T) {
+	k := &PlanetaryParms{
+		Module:           1,
+		PressureAngle:    DtoR(20),
+		SunTeeth:         15,
+		PlanetTeeth:      12,
+		Planets:          3,
+		Backlash:         0.1,
+		Clearance:        0.25,
+		FaceWidth:        5,
+		RingWidth:        3,
+		PlanetBore:       4,
+		PinDiameter:      3.8,
+		CarrierThickness: 2,
+		Facets:           8,
+	}
+	for _, np := range []int{12, 13} {
+		k.PlanetTeeth = np
+		if np == 13 {
+			k.SunTeeth = 14
+		}
+		g, err := PlanetaryGearSet(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.RingTeeth() != k.SunTeeth+2*np || len(g.Planets) != 3 {
+			t.Error("FAIL")
+		}
+		// the planets don't overlap the sun or the ring
+		r := 0.5 * float64(k.RingTeeth()) * k.Module
+		for x := -r - 1; x <= r+1; x += 0.1 {
+			for y := -r - 1; y <= r+1; y += 0.1 {
+				p := V3{x, y, 2.5}
+				dp := math.Inf(1)
+				for _, s := range g.Planets {
+					dp = Min(dp, s.Evaluate(p))
+				}
+				if Max(dp, Min(g.Sun.Evaluate(p), g.Ring.Evaluate(p))) < -1e-3 {
+					t.Logf("%d: overlap at %v", np, p)
+					t.Fatal("FAIL")
+				}
+			}
+		}
+		// the pins are in the planet bores, the carrier is below the gears
+		c := PolarToXY(k.CenterDistance(), Tau/3)
+		if g.Carrier.Evaluate(c.ToV3(2.5)) >= 0 || g.Carrier.Evaluate(V3{0, 0, 0}) <= 0 {
+			t.Error("FAIL")
+		}
+		if g.Assembly().Evaluate(c.ToV3(2.5)) >= 0 {
+			t.Error("FAIL")
+		}
+	}
+	// the planets can't be equally spaced
+	k.SunTeeth = 15
+	if _, err := PlanetaryGearSet(k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Weld(t *testing.T) {
+	// a T joint, a vertical plate (x = -1..1) standing on a horizontal plate (top at z = 0)
+	base := Transform3D(Box3D(V3{20, 20, 2}, 0), Translate3d(V3{0, 0, -1}))
+	web := Transform3D(Box3D(V3{2, 20, 10}, 0), Translate3d(V3{0, 0, 5}))
+	w, err := Weld3D(base, web, 2, WeldTriangular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the legs are on both surfaces
+	if w.Evaluate(V3{1.3, 0, 0.3}) >= 0 || w.Evaluate(V3{2.9, 0, 0.05}) >= 0 || w.Evaluate(V3{-1.05, 0, 1.9}) >= 0 {
+		t.Error("FAIL")
+	}
+	if w.Evaluate(V3{2.6, 0, 1.5}) <= 0 || w.Evaluate(V3{3.1, 0, 0.05}) <= 0 {
+		t.Error("FAIL")
+	}
+	// the chamfer face is at 45 degrees
+	if !EqualFloat64(w.Evaluate(V3{3, 0, 2}), math.Sqrt(2), 1e-9) {
+		t.Error("FAIL")
+	}
+	c, _ := Weld3D(base, web, 2, WeldConcave)
+	if c.Evaluate(V3{1.2, 0, 0.2}) >= 0 || c.Evaluate(V3{1.8, 0, 0.8}) <= 0 {
+		t.Error("FAIL")
+	}
+	if !EqualFloat64(c.Evaluate(V3{2, 0, 1}), 2-math.Sqrt(2), 1e-9) {
+		t.Error("FAIL")
+	}
+	// the bead alone is outside both solids
+	b, _ := WeldBead3D(base, web, 2, WeldTriangular)
+	if b.Evaluate(V3{1.3, 0, 0.3}) >= 0 || b.Evaluate(V3{0, 0, -1}) <= 0 || b.Evaluate(V3{0, 0, 5}) <= 0 {
+		t.Error("FAIL")
+	}
+	if _, err := Weld3D(base, web, 0, WeldConcave); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_RackGearTooth(t *testing.T) {
+	n, m, pa := 30, 2.0, DtoR(20)
+	rp := float64(n) * m / 2
+	rb := rp * math.Cos(pa)
+	// the flank above the root fillet is the involute
+	rack := RackGearTooth(n, m, pa, rp-1.25*m, rp+m, 0, 0.25*m/(1-math.Sin(pa)), 10)
+	inv := InvoluteGearTooth(n, m, rp-1.25*m, rb, rp+m, 0, 40)
+	for _, r := range []float64{rp - 0.5*m, rp, rp + 0.5*m} {
+		for a := -0.1; a < 0.1; a += 0.002 {
+			p := V2{r * math.Cos(a), r * math.Sin(a)}
+			if Abs(rack.Evaluate(p)-inv.Evaluate(p)) > 0.01 {
+				t.Logf("r %f a %f: %f %f", r, a, rack.Evaluate(p), inv.Evaluate(p))
+				t.Fatal("FAIL")
+			}
+		}
+	}
+	// the root fillet adds material outside the radial line from the base circle
+	r := rp - 1.15*m
+	if rack.Evaluate(PolarToXY(r, 0.075)) >= 0 || inv.Evaluate(PolarToXY(r, 0.075)) <= 0 {
+		t.Error("FAIL")
+	}
+	// a pinion with few teeth is undercut below the base circle
+	rp = 8 * m / 2
+	pinion := RackGearTooth(8, m, pa, rp-1.25*m, rp+m, 0, 0.25*m/(1-math.Sin(pa)), 10)
+	if pinion.Evaluate(PolarToXY(7, 0.205)) <= 0 || pinion.Evaluate(PolarToXY(7, 0.19)) >= 0 {
+		t.Error("FAIL")
+	}
+	// an undercut pinion meshes with a gear through a tooth
+	n0, n1 := 30, 8
+	r0 := float64(n0) * m / 2
+	r1 := float64(n1) * m / 2
+	g0 := InvoluteGear(n0, m, pa, 0.1, 0.25*m, 3, 8)
+	g1 := InvoluteGear(n1, m, pa, 0.1, 0.25*m, 1, 8)
+	for step := 0; step < 6; step++ {
+		a0 := (Tau / float64(n0)) * float64(step) / 6
+		a1 := -a0*float64(n0)/float64(n1) + Pi/float64(n1)
+		s0 := Transform2D(g0, Rotate2d(a0))
+		s1 := Transform2D(g1, Translate2d(V2{r0 + r1, 0}).Mul(Rotate2d(a1)))
+		for x := r0 - 1.5*m; x <= r0+1.5*m; x += 0.04 {
+			for y := -3 * m; y <= 3*m; y += 0.04 {
+				if Max(s0.Evaluate(V2{x, y}), s1.Evaluate(V2{x, y})) < -0.01 {
+					t.Logf("step %d: overlap at %f %f", step, x, y)
+					t.Fatal("FAIL")
+				}
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Wrap(t *testing.T) {
+	r := 10.0
+	// a 4 x 2 box at the front of a cylinder
+	box := Box2D(V2{4, 2}, 0)
+	pattern := Transform2D(box, Translate2d(V2{0, 5}))
+	s, err := Wrap3D(pattern, r, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(s SDF3, u, z, h, taper float64) float64 {
+		rs := r + z*math.Tan(taper)
+		return s.Evaluate(PolarToXY(rs+h, u/rs).ToV3(z))
+	}
+	if at(s, 0, 5, 0.5, 0) >= 0 || at(s, 1.5, 5.8, 0.9, 0) >= 0 || at(s, 0, 5, -0.05, 0) >= 0 {
+		t.Error("FAIL")
+	}
+	if at(s, 2.5, 5, 0.5, 0) <= 0 || at(s, 0, 6.5, 0.5, 0) <= 0 || at(s, 0, 5, 1.2, 0) <= 0 || at(s, 0, 5, -0.2, 0) <= 0 {
+		t.Error("FAIL")
+	}
+	// the pattern wraps across the seam
+	c := Tau * r
+	seam, _ := Wrap3D(Transform2D(box, Translate2d(V2{0.5 * c, 5})), r, 0, 1)
+	if at(seam, 0.5*c-1, 5, 0.5, 0) >= 0 || at(seam, -0.5*c+1, 5, 0.5, 0) >= 0 || at(seam, -0.5*c+2.5, 5, 0.5, 0) <= 0 {
+		t.Error("FAIL")
+	}
+	// engraved on a cone, the y-axis of the pattern is along the slant
+	taper := DtoR(20)
+	cone, _ := Wrap3D(pattern, r, taper, -0.5)
+	z := 5.8 * math.Cos(taper)
+	if at(cone, 0, z, -0.3, taper) >= 0 || at(cone, 0, z, 0.3, taper) <= 0 || at(cone, 0, 6.5*math.Cos(taper), -0.3, taper) <= 0 {
+		t.Error("FAIL")
+	}
+	if _, err := Wrap3D(pattern, r, 0, 0); err == nil {
+		t.Error("FAIL")
+	}
+	// the distance is a lower bound, the chord is shorter than the arc
+	p0 := PolarToXY(r, 0.5*Pi).ToV3(5)
+	p1 := PolarToXY(r, 2/r).ToV3(5)
+	if s.Evaluate(p0) > p0.Sub(p1).Length() {
+		t.Error("FAIL")
+	}
+	for _, s := range []SDF3{s, seam, cone} {
+		bb := s.BoundingBox().ScaleAboutCenter(2)
+		h := 1e-4
+		for i := 0; i < 10000; i++ {
+			p := bb.Random()
+			q := p.Add(bb.Random().Sub(bb.Center()).Normalize().MulScalar(h))
+			k := Abs(s.Evaluate(p)-s.Evaluate(q)) / p.Sub(q).Length()
+			if k > 1+1e-3 {
+				t.Logf("lipschitz %f at %v\n", k, p)
+				t.Error("FAIL")
+				break
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_WrapSphere(t *testing.T) {
+	r := 20.0
+	box := Box2D(V2{4, 2}, 0)
+	// equirectangular, a box on the equator at 90 degrees longitude
+	s, err := WrapSphere3D(Transform2D(box, Translate2d(V2{0.5 * Pi * r, 0})), r, Equirectangular, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(s SDF3, lon, lat, h float64) float64 {
+		return s.Evaluate(V3{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}.MulScalar(r + h))
+	}
+	if at(s, 0.5*Pi, 0, 0.5) >= 0 || at(s, 0.5*Pi+1.5/r, 0.8/r, 0.5) >= 0 {
+		t.Error("FAIL")
+	}
+	if at(s, 0.5*Pi+2.5/r, 0, 0.5) <= 0 || at(s, 0.5*Pi, 1.5/r, 0.5) <= 0 || at(s, 0.5*Pi, 0, 1.5) <= 0 {
+		t.Error("FAIL")
+	}
+	// azimuthal, a ring around the pole engraved in a knob
+	ring := Difference2D(Circle2D(6), Circle2D(4))
+	a, _ := WrapSphere3D(ring, r, Azimuthal, -0.5)
+	for _, lon := range []float64{0, 1, 2, 3} {
+		if at(a, lon, 0.5*Pi-5/r, -0.3) >= 0 || at(a, lon, 0.5*Pi-3/r, -0.3) <= 0 || at(a, lon, 0.5*Pi-5/r, -0.7) <= 0 {
+			t.Error("FAIL")
+		}
+	}
+	if _, err := WrapSphere3D(ring, r, Azimuthal, 0); err == nil {
+		t.Error("FAIL")
+	}
+	// the distance is a lower bound, the chord is shorter than the arc
+	p0 := V3{r, 0, 0}
+	p1 := V3{math.Cos(0.5*Pi - 2/r), math.Sin(0.5*Pi - 2/r), 0}.MulScalar(r)
+	if s.Evaluate(p0) > p0.Sub(p1).Length() {
+		t.Error("FAIL")
+	}
+	// a pattern near the pole is stretched
+	polar, _ := WrapSphere3D(Transform2D(box, Translate2d(V2{0, 0.4 * Pi * r})), r, Equirectangular, 1)
+	for _, s := range []SDF3{s, polar, a} {
+		bb := s.BoundingBox().ScaleAboutCenter(2)
+		h := 1e-4
+		for i := 0; i < 10000; i++ {
+			p := bb.Random()
+			q := p.Add(bb.Random().Sub(bb.Center()).Normalize().MulScalar(h))
+			k := Abs(s.Evaluate(p)-s.Evaluate(q)) / p.Sub(q).Length()
+			if k > 1+1e-3 {
+				t.Logf("lipschitz %f at %v\n", k, p)
+				t.Error("FAIL")
+				break
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_FollowerCam(t *testing.T) {
+	p := CamProgram{
+		{"cycloidal", DtoR(120), 10},
+		{"dwell", DtoR(60), 0},
+		{"poly345", DtoR(120), -10},
+		{"dwell", DtoR(60), 0},
+	}
+	rb := 30.0
+	rr := 8.0
+
+	// roller: the roller is tangent to the cam at every cam angle
+	roller, err := FollowerCam2D(&FollowerCamParms{p, RollerFollower, rb, rr, 720})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flat: the face is tangent to the cam at every cam angle
+	flat, err := FollowerCam2D(&FollowerCamParms{p, FlatFollower, rb, 0, 720})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 36; i++ {
+		theta := Tau * float64(i) / 36
+		s, ds, _ := p.Displacement(theta)
+		n := V2{math.Sin(theta), math.Cos(theta)}
+		c := n.MulScalar(rb + rr + s)
+		if d := roller.Evaluate(c); Abs(d-rr) > 0.05 {
+			t.Logf("theta %f roller distance %f", RtoD(theta), d)
+			t.Error("FAIL")
+		}
+		// sample along the face
+		u := V2{n.Y, -n.X}
+		dmin := math.MaxFloat64
+		for j := -100; j <= 100; j++ {
+			q := n.MulScalar(rb + s).Add(u.MulScalar(0.2 * float64(j)))
+			dmin = Min(dmin, flat.Evaluate(q))
+		}
+		if Abs(dmin) > 0.05 {
+			t.Logf("theta %f flat distance %f", RtoD(theta), dmin)
+			t.Error("FAIL")
+		}
+		// the contact point is offset from the follower axis by ds/dtheta
+		q := n.MulScalar(rb + s).Add(u.MulScalar(ds))
+		if d := flat.Evaluate(q); Abs(d) > 0.05 {
+			t.Logf("theta %f contact distance %f", RtoD(theta), d)
+			t.Error("FAIL")
+		}
+	}
+
+	// knife edge matches ProgramCam2D
+	knife, err := FollowerCam2D(&FollowerCamParms{p, KnifeFollower, rb, 0, 720})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := knife.Evaluate(V2{0, rb}); Abs(d) > 1e-6 {
+		t.Error("FAIL")
+	}
+
+	// pressure angle
+	pa := p.PressureAngle(rb + rr)
+	if pa <= 0 || pa > DtoR(30) {
+		t.Logf("pressure angle %f", RtoD(pa))
+		t.Error("FAIL")
+	}
+
+	// undercut roller and cusped flat faced profiles
+	fast := CamProgram{
+		{"harmonic", DtoR(60), 10},
+		{"harmonic", DtoR(60), -10},
+		{"dwell", DtoR(240), 0},
+	}
+	if _, err := FollowerCam2D(&FollowerCamParms{fast, RollerFollower, 5, 20, 720}); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := FollowerCam2D(&FollowerCamParms{fast, FlatFollower, 5, 0, 720}); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := FollowerCam2D(&FollowerCamParms{fast, FlatFollower, 50, 0, 720}); err != nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_GearInspection(t *testing.T) {
+	for _, n := range []int{20, 25} {
+		g := GearInspection{n, 2, DtoR(20), 0.1}
+		gear := InvoluteGear(n, g.Module, g.PressureAngle, g.Backlash, 0.25*g.Module, 0, 20)
+
+		// standard values for zero backlash
+		g0 := g
+		g0.Backlash = 0
+		if n == 20 {
+			if g.SpanTeeth() != 3 || Abs(g0.Span(3)-15.3209) > 1e-3 {
+				t.Logf("span teeth %d span %f", g.SpanTeeth(), g0.Span(3))
+				t.Error("FAIL")
+			}
+		}
+
+		// the caliper jaws touch the teeth flanks
+		k := g.SpanTeeth()
+		w := g.Span(k)
+		beta := float64(k-1) * Pi / float64(n)
+		a := V2{math.Cos(beta), math.Sin(beta)}
+		u := V2{-a.Y, a.X}
+		rp := g.PitchRadius()
+		for _, side := range []float64{-1, 1} {
+			dmin := math.MaxFloat64
+			for i := 0; i <= 200; i++ {
+				// the jaws don't reach the root circle
+				r := rp - g.Module + 2*g.Module*float64(i)/200
+				dmin = Min(dmin, gear.Evaluate(a.MulScalar(r).Add(u.MulScalar(side*0.5*w))))
+			}
+			if Abs(dmin) > 0.01 {
+				t.Logf("teeth %d span jaw distance %f", n, dmin)
+				t.Error("FAIL")
+			}
+		}
+
+		// the pins touch both flanks of a tooth space
+		dp := g.PinDiameter()
+		c := PolarToXY(g.PinRadius(dp), Pi/float64(n))
+		if d := gear.Evaluate(c); Abs(d-0.5*dp) > 0.01 {
+			t.Logf("teeth %d pin distance %f", n, d-0.5*dp)
+			t.Error("FAIL")
+		}
+		// over pins measurement
+		m := g.OverPins(dp)
+		c1 := PolarToXY(g.PinRadius(dp), Pi/float64(n)+Tau*float64(n/2)/float64(n))
+		if Abs(m-(c.Sub(c1).Length()+dp)) > 1e-6 {
+			t.Logf("teeth %d over pins %f", n, m)
+			t.Error("FAIL")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_BarrelCam(t *testing.T) {
+	p := CamProgram{
+		{"cycloidal", DtoR(120), 20},
+		{"dwell", DtoR(60), 0},
+		{"poly345", DtoR(120), -20},
+		{"dwell", DtoR(60), 0},
+	}
+	f := func(theta float64) float64 {
+		s, _, _ := p.Displacement(theta)
+		return s - 10
+	}
+	k := BarrelCamParms{
+		Radius:       30,
+		Length:       40,
+		GrooveWidth:  6,
+		GrooveDepth:  4,
+		Displacement: f,
+		Points:       720,
+	}
+	cam, err := BarrelCam3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 36; i++ {
+		theta := Tau * float64(i) / 36
+		// groove center, half way down: 2 from the groove bottom
+		c := PolarToXY(28, theta).ToV3(f(theta))
+		if d := cam.Evaluate(c); Abs(d-2) > 0.05 {
+			t.Logf("theta %f center distance %f", RtoD(theta), d)
+			t.Error("FAIL")
+		}
+	}
+	// dwells have straight walls
+	for _, a := range []float64{150, 330} {
+		theta := DtoR(a)
+		z := f(theta)
+		if d := cam.Evaluate(PolarToXY(29, theta).ToV3(z + 3)); Abs(d) > 1e-3 {
+			t.Logf("theta %f wall distance %f", a, d)
+			t.Error("FAIL")
+		}
+		if d := cam.Evaluate(PolarToXY(29, theta).ToV3(z - 4)); Abs(d+1) > 1e-3 {
+			t.Logf("theta %f solid distance %f", a, d)
+			t.Error("FAIL")
+		}
+	}
+	// the groove is wider along the z-axis on the rise
+	theta := DtoR(60)
+	c := PolarToXY(29, theta).ToV3(f(theta) + 3)
+	if d := cam.Evaluate(c); d <= 0 {
+		t.Error("FAIL")
+	}
+
+	// errors
+	k1 := k
+	k1.Displacement = func(theta float64) float64 { return theta }
+	if _, err := BarrelCam3D(&k1); err == nil {
+		t.Error("FAIL")
+	}
+	k1 = k
+	k1.Length = 24
+	if _, err := BarrelCam3D(&k1); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_GearRelief(t *testing.T) {
+	n := 20
+	m := 2.0
+	pa := DtoR(20)
+	rb := 0.5 * m * float64(n) * math.Cos(pa)
+	relief := GearRelief{
+		Tip:  FlankRelief{ParabolicRelief, 0.1, 0.45},
+		Root: FlankRelief{LinearRelief, 0.05, 0.2},
+	}
+	plain := InvoluteGear(n, m, pa, 0, 0.25*m, 0, 20)
+	gear, err := RelievedInvoluteGear(n, m, pa, 0, 0.25*m, 0, &relief, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// half angular thickness of a tooth at a radius
+	flank := func(s SDF2, r float64) float64 {
+		lo, hi := 0.0, Pi/float64(n)
+		for i := 0; i < 50; i++ {
+			a := 0.5 * (lo + hi)
+			if s.Evaluate(PolarToXY(r, -a)) < 0 {
+				lo = a
+			} else {
+				hi = a
+			}
+		}
+		return 0.5 * (lo + hi)
+	}
+	// roll angle, expected relief
+	for _, x := range [][2]float64{{0.1, 0.025}, {0.3, 0}, {0.364, 0}, {0.55, 0.1 * 0.629 * 0.629}} {
+		r := rb * math.Sqrt(1+x[0]*x[0])
+		d := (flank(plain, r) - flank(gear, r)) * rb
+		if Abs(d-x[1]) > 0.003 {
+			t.Logf("roll angle %f relief %f expected %f", x[0], d, x[1])
+			t.Error("FAIL")
+		}
+	}
+
+	// bad reliefs
+	bad := []GearRelief{
+		{Tip: FlankRelief{LinearRelief, 0.1, 0.7}},
+		{Root: FlankRelief{LinearRelief, -0.1, 0.2}},
+		{Tip: FlankRelief{LinearRelief, 0.1, 0.3}, Root: FlankRelief{LinearRelief, 0.1, 0.4}},
+	}
+	for i := range bad {
+		if _, err := RelievedInvoluteGear(n, m, pa, 0, 0.25*m, 0, &bad[i], 20); err == nil {
+			t.Error("FAIL")
+		}
+	}
+
+	// helical gears take the relief
+	k := HelicalGearParms{NumberTeeth: n, Module: m, PressureAngle: pa, FaceWidth: 5, Facets: 10, Relief: &bad[0]}
+	if _, err := HelicalGear3D(&k); err == nil {
+		t.Error("FAIL")
+	}
+	k.Relief = &relief
+	if _, err := HelicalGear3D(&k); err != nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Drive(t *testing.T) {
+	size, err := HexalobularSize(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := HexalobularSize(21); err == nil {
+		t.Error("FAIL")
+	}
+
+	// profile points: lobe tips and flute bottoms, hex and square flats
+	checks := []struct {
+		style DriveStyle
+		size  float64
+		p     V2
+	}{
+		{HexalobularDrive, size, V2{0.5 * size, 0}},
+		{HexalobularDrive, size, PolarToXY(0.5*size, Pi/3)},
+		{HexalobularDrive, size, PolarToXY(0.5*0.7165*size, Pi/6)},
+		{HexalobularDrive, size, PolarToXY(0.5*0.7165*size, -Pi/2)},
+		{HexDrive, 5, PolarToXY(2.5, Pi/6)},
+		{HexDrive, 5, PolarToXY(2.5, Pi/2)},
+		{SquareDrive, 6, V2{3, 1}},
+	}
+	for _, c := range checks {
+		s, err := DriveProfile2D(c.style, c.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := s.Evaluate(c.p); Abs(d) > 0.01*c.size {
+			t.Logf("style %d point %v distance %f", c.style, c.p, d)
+			t.Error("FAIL")
+		}
+	}
+
+	hl, _ := DriveProfile2D(HexalobularDrive, size)
+	if hl.Evaluate(V2{}) >= 0 || hl.Evaluate(PolarToXY(0.45*size, Pi/6)) <= 0 {
+		t.Error("FAIL")
+	}
+
+	// the hexalobular profile is smooth: the vertices are closely spaced
+	v := hexalobular(size, 8)
+	for i := range v {
+		if v[i].Sub(v[(i+1)%len(v)]).Length() > 0.05*size {
+			t.Error("FAIL")
+			break
+		}
+	}
+
+	// recess and bit with clearance
+	k := DriveParms{HexDrive, 5, 4, 0.1}
+	recess, err := DriveRecess3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bit, err := DriveBit3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat := PolarToXY(2.5, Pi/6)
+	if d := recess.Evaluate(flat.ToV3(-2)); Abs(d+0.1) > 1e-6 {
+		t.Error("FAIL")
+	}
+	if d := recess.Evaluate(V3{0, 0, -4}); Abs(d) > 1e-6 {
+		t.Error("FAIL")
+	}
+	if d := bit.Evaluate(flat.ToV3(2)); Abs(d-0.1) > 1e-6 {
+		t.Error("FAIL")
+	}
+	if d := bit.Evaluate(V3{0, 0, 4}); Abs(d) > 1e-6 {
+		t.Error("FAIL")
+	}
+
+	k.Clearance = -1
+	if _, err := DriveRecess3D(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Sprocket(t *testing.T) {
+	for _, z := range []int{9, 17, 40} {
+		// 08B chain
+		k := SprocketParms{Pitch: 12.7, RollerDiameter: 8.51, Teeth: z, Width: 7.2, Facets: 8}
+		s, err := Sprocket2D(&k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := k.PitchDiameter()
+		r1 := 0.5 * k.RollerDiameter
+		ri := 0.5 * (d - k.RootDiameter())
+		// the rollers seat on the seating arc
+		c0 := V2{0.5 * d, 0}
+		if x := s.Evaluate(c0); Abs(x-ri) > 0.02 {
+			t.Logf("teeth %d seat distance %f", z, x)
+			t.Error("FAIL")
+		}
+		// tip and root circles
+		if x := s.Evaluate(PolarToXY(0.5*k.TipDiameter(), Pi/float64(z))); Abs(x) > 1e-3 {
+			t.Logf("teeth %d tip distance %f", z, x)
+			t.Error("FAIL")
+		}
+		if x := s.Evaluate(V2{0.5 * k.RootDiameter(), 0}); Abs(x) > 1e-3 {
+			t.Error("FAIL")
+		}
+		// a roller pivoting about the seated roller, from the straight chain into the next seat,
+		// clears the teeth
+		c1 := PolarToXY(0.5*d, Tau/float64(z))
+		u := c1.Sub(c0)
+		for i := 0; i <= 20; i++ {
+			beta := Tau / float64(z) * float64(i) / 20
+			p := c0.Add(Rotate(-beta).MulPosition(u))
+			// no point on the roller is inside the sprocket
+			for j := 0; j < 72; j++ {
+				q := p.Add(PolarToXY(r1, Tau*float64(j)/72))
+				if x := s.Evaluate(q); x < -0.01*k.Pitch {
+					t.Logf("teeth %d roller interference %f", z, x)
+					t.Error("FAIL")
+					break
+				}
+			}
+		}
+	}
+
+	k := SprocketParms{Pitch: 12.7, RollerDiameter: 8.51, Teeth: 20, Width: 7.2, Facets: 8}
+	s, err := Sprocket3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the tooth tips are chamfered
+	tip := PolarToXY(0.5*k.TipDiameter(), Pi/20)
+	if x := s.Evaluate(tip.ToV3(0.5*k.Width - 0.1*k.Pitch)); x > 1e-3 {
+		t.Error("FAIL")
+	}
+	if x := s.Evaluate(tip.ToV3(0.5*k.Width - 0.01)); x < 0.01 {
+		t.Error("FAIL")
+	}
+	k.RollerDiameter = 13
+	if _, err := Sprocket2D(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_TimingPulley(t *testing.T) {
+	k := PulleyParms{Profile: BeltGT2, Teeth: 20}
+	if Abs(k.OutsideDiameter()-12.224) > 1e-3 {
+		t.Logf("GT2 20T outside diameter %f", k.OutsideDiameter())
+		t.Error("FAIL")
+	}
+	depths := []float64{0.764, 1.169, 1.289, 2.199}
+	for i, profile := range []BeltProfile{BeltGT2, BeltGT3, BeltHTD3M, BeltHTD5M} {
+		k := PulleyParms{Profile: profile, Teeth: 24}
+		s, err := TimingPulley2D(&k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := 0.5 * k.OutsideDiameter()
+		// groove bottom
+		if d := s.Evaluate(V2{r - depths[i], 0}); Abs(d) > 1e-3 {
+			t.Logf("profile %d groove bottom %f", profile, d)
+			t.Error("FAIL")
+		}
+		// land between the grooves
+		if d := s.Evaluate(PolarToXY(r, Pi/24)); Abs(d) > 1e-3 {
+			t.Logf("profile %d land %f", profile, d)
+			t.Error("FAIL")
+		}
+		// the groove is open at the outside diameter
+		if d := s.Evaluate(V2{r - 0.1, 0}); d <= 0 {
+			t.Error("FAIL")
+		}
+	}
+
+	k = PulleyParms{Profile: BeltGT2, Teeth: 20, Width: 7, Flange: 1.5, FlangeThickness: 1, Bore: 5}
+	s, err := TimingPulley3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf := 0.5*k.OutsideDiameter() + k.Flange
+	if d := s.Evaluate(V3{rf, 0, 4}); Abs(d) > 1e-3 {
+		t.Error("FAIL")
+	}
+	if d := s.Evaluate(V3{2.5, 0, 0}); Abs(d) > 1e-3 {
+		t.Error("FAIL")
+	}
+	k.Bore = 12
+	if _, err := TimingPulley3D(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Ruled3D(t *testing.T) {
+	// flat strip
+	s, err := Ruled3D([]V3{{0, 0, 0}, {5, 0, 0}, {10, 0, 0}}, []V3{{0, 5, 0}, {5, 5, 0}, {10, 5, 0}}, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		p V3
+		d float64
+	}{
+		{V3{5, 2.5, 0}, -0.5},
+		{V3{5, 2.5, 2}, 1.5},
+		{V3{-1, 2.5, 0}, 0.5},
+		{V3{7, 8, 0}, 2.5},
+	}
+	for _, x := range tests {
+		if d := s.Evaluate(x.p); Abs(d-x.d) > 1e-9 {
+			t.Logf("%v distance %f expected %f", x.p, d, x.d)
+			t.Error("FAIL")
+		}
+	}
+
+	// twisted patch: z = x * y
+	s, err = Ruled3D([]V3{{0, 0, 0}, {1, 0, 0}}, []V3{{0, 1, 0}, {1, 1, 1}}, 0.1, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := s.Evaluate(V3{0.5, 0.5, 0.25}); Abs(d+0.05) > 1e-3 {
+		t.Error("FAIL")
+	}
+	// offset along the normal (-y, -x, 1)
+	n := V3{-0.5, -0.5, 1}.Normalize()
+	if d := s.Evaluate(V3{0.5, 0.5, 0.25}.Add(n.MulScalar(0.2))); Abs(d-0.15) > 2e-3 {
+		t.Logf("twisted distance %f", d)
+		t.Error("FAIL")
+	}
+	// the rulings are straight
+	for _, u := range []float64{0.25, 0.75} {
+		p := V3{u, 0, 0}.Add(V3{0, 1, u}.MulScalar(0.3))
+		if d := s.Evaluate(p); Abs(d+0.05) > 1e-9 {
+			t.Error("FAIL")
+		}
+	}
+
+	if _, err := Ruled3D([]V3{{0, 0, 0}, {1, 0, 0}}, []V3{{0, 1, 0}}, 0.1, 4); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_InvoluteSpline(t *testing.T) {
+	k := SplineParms{Teeth: 20, Module: 1, PressureAngle: DtoR(30), Fit: SplineFitSliding, Facets: 10}
+	shaft, err := SplineShaft2D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub, err := SplineHub2D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := 0.5 * k.PitchDiameter()
+	// half angle of the solid (shaft) or space (hub) at a radius
+	halfAngle := func(s SDF2, r float64, inside bool) float64 {
+		lo, hi := 0.0, Pi/float64(k.Teeth)
+		for i := 0; i < 50; i++ {
+			a := 0.5 * (lo + hi)
+			if (s.Evaluate(PolarToXY(r, a)) < 0) == inside {
+				lo = a
+			} else {
+				hi = a
+			}
+		}
+		return 0.5 * (lo + hi)
+	}
+	c := 0.03
+	if a := halfAngle(shaft, rp, true); Abs(a-(Pi/40-0.25*c/rp)) > 1e-4 {
+		t.Logf("shaft tooth half angle %f", a)
+		t.Error("FAIL")
+	}
+	if a := halfAngle(hub, rp, true); Abs(a-(Pi/40+0.25*c/rp)) > 1e-4 {
+		t.Logf("hub space half angle %f", a)
+		t.Error("FAIL")
+	}
+	// diameters
+	if d := shaft.Evaluate(V2{10.5, 0}); Abs(d) > 0.02 {
+		t.Error("FAIL")
+	}
+	if d := hub.Evaluate(PolarToXY(9.5, Pi/20)); Abs(d) > 1e-6 {
+		t.Error("FAIL")
+	}
+	// the shaft fits in the hub with clearance
+	for i := 0; i <= 40; i++ {
+		for j := 0; j <= 40; j++ {
+			p := PolarToXY(8+3*float64(i)/40, Tau/20*float64(j)/40)
+			if shaft.Evaluate(p) < 0 && hub.Evaluate(p) > -0.25*c*math.Cos(DtoR(30))+1e-4 {
+				t.Logf("interference at %v", p)
+				t.Error("FAIL")
+				return
+			}
+		}
+	}
+	k.Fit = SplineFit(9)
+	if _, err := SplineShaft2D(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Sweep3D(t *testing.T) {
+	// straight path with a blend from r = 1 to r = 3
+	s, err := Sweep3D(Circle2D(1), Circle2D(3), []V3{{0, 0, -5}, {0, 0, 0}, {0, 0, 5}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		p V3
+		d float64
+	}{
+		{V3{0, 0, 0}, -2},
+		{V3{4, 0, 0}, 2},
+		{V3{0, 4, 2.5}, 1.5},
+		{V3{0, 0, 6}, 1},
+		{V3{0, 0, -4.5}, -0.5},
+	}
+	for _, x := range tests {
+		if d := s.Evaluate(x.p); Abs(d-x.d) > 1e-9 {
+			t.Logf("%v distance %f expected %f", x.p, d, x.d)
+			t.Error("FAIL")
+		}
+	}
+
+	// quarter circle path in the xz-plane
+	var path []V3
+	for i := 0; i <= 90; i++ {
+		a := DtoR(float64(i))
+		path = append(path, V3{20 - 20*math.Cos(a), 0, 20 * math.Sin(a)})
+	}
+	s, err = Sweep3D(Circle2D(2), Circle2D(2), path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, deg := range []float64{10, 45, 80} {
+		a := DtoR(deg)
+		c := V3{20 - 20*math.Cos(a), 0, 20 * math.Sin(a)}
+		radial := V3{-math.Cos(a), 0, math.Sin(a)}
+		if d := s.Evaluate(c); Abs(d+2) > 0.01 {
+			t.Error("FAIL")
+		}
+		if d := s.Evaluate(c.Add(radial.MulScalar(3))); Abs(d-1) > 0.01 {
+			t.Error("FAIL")
+		}
+		if d := s.Evaluate(c.Add(V3{0, 3, 0})); Abs(d-1) > 0.01 {
+			t.Error("FAIL")
+		}
+	}
+	// the end of the path (along +x) is capped
+	if d := s.Evaluate(V3{21, 0, 20}); Abs(d-1) > 0.01 {
+		t.Error("FAIL")
+	}
+
+	// twisted rectangle
+	s, err = Sweep3D(Box2D(V2{4, 2}, 0), Box2D(V2{4, 2}, 0), []V3{{0, 0, -5}, {0, 0, 5}}, 0.5*Pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V3{1.8, 0, -4.9}) >= 0 || s.Evaluate(V3{0, 1.8, -4.9}) <= 0 {
+		t.Error("FAIL")
+	}
+	if s.Evaluate(V3{1.8, 0, 4.9}) <= 0 || s.Evaluate(V3{0, 1.8, 4.9}) >= 0 {
+		t.Error("FAIL")
+	}
+
+	if _, err := Sweep3D(Circle2D(1), Circle2D(1), []V3{{0, 0, 0}, {0, 0, 0}}, 0); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Ratchet(t *testing.T) {
+	k := RatchetParms{
+		Teeth:         12,
+		OuterRadius:   20,
+		RootRadius:    16,
+		ToothAngle:    DtoR(10),
+		PawlLength:    15,
+		PawlWidth:     5,
+		PivotDiameter: 3,
+		Clearance:     0.2,
+	}
+	wheel, err := Ratchet2D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pawl, err := Pawl2D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the tooth tip and the root of the locking face are on the surface
+	root := k.root()
+	if Abs(wheel.Evaluate(V2{20, 0})) > tolerance || Abs(wheel.Evaluate(root)) > tolerance {
+		t.Error("FAIL")
+	}
+	if !EqualFloat64(root.Length(), 16, tolerance) || root.Y >= 0 {
+		t.Logf("root %v", root)
+		t.Error("FAIL")
+	}
+	// the locking face is undercut by the tooth angle
+	face := V2{20, 0}.Sub(root)
+	if !EqualFloat64(math.Atan2(face.Y, face.X), k.ToothAngle, 1e-9) {
+		t.Error("FAIL")
+	}
+	// the pawl nose sits against the locking face with the clearance
+	mid := V2{20, 0}.Add(root).MulScalar(0.5)
+	n := V2{-face.Y, face.X}.Normalize()
+	if pawl.Evaluate(mid.Add(n.MulScalar(k.Clearance+0.1))) >= 0 {
+		t.Error("FAIL")
+	}
+	if pawl.Evaluate(mid.Add(n.MulScalar(k.Clearance-0.05))) <= 0 {
+		t.Error("FAIL")
+	}
+	// the pawl doesn't overlap the wheel
+	bb := pawl.BoundingBox()
+	for x := bb.Min.X; x <= bb.Max.X; x += 0.1 {
+		for y := bb.Min.Y; y <= bb.Max.Y; y += 0.1 {
+			p := V2{x, y}
+			if pawl.Evaluate(p) < 0 && wheel.Evaluate(p) < 0 {
+				t.Logf("overlap at %v", p)
+				t.Error("FAIL")
+				return
+			}
+		}
+	}
+	// the pivot hole
+	if pawl.Evaluate(k.PawlPivot()) <= 0 {
+		t.Error("FAIL")
+	}
+	// bad parameters
+	k.ToothAngle = DtoR(60)
+	if _, err := Ratchet2D(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Mask(t *testing.T) {
+	// knurl the top half of a cylinder
+	base := Cylinder3D(20, 5, 0)
+	knurl := func(s SDF3) SDF3 {
+		d, _ := Displace3D(s, func(p V3) float64 {
+			return 0.25 * math.Sin(8*math.Atan2(p.Y, p.X))
+		}, 0.25)
+		return d
+	}
+	mask := Transform3D(Box3D(V3{20, 20, 10}, 0), Translate3d(V3{0, 0, 5}))
+	s, err := Mask3D(base, knurl, mask, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// top half is displaced, bottom half is not
+	p := PolarToXY(5, Pi/16)
+	if !EqualFloat64(s.Evaluate(V3{p.X, p.Y, 5}), -0.25, 1e-9) {
+		t.Error("FAIL")
+	}
+	if Abs(s.Evaluate(V3{p.X, p.Y, -5})) > tolerance {
+		t.Error("FAIL")
+	}
+	// half way at the mask boundary
+	if !EqualFloat64(s.Evaluate(V3{p.X, p.Y, 0}), -0.125, 1e-9) {
+		t.Error("FAIL")
+	}
+	if s.BoundingBox().Max.X < 5.25 {
+		t.Error("FAIL")
+	}
+	// 2d offset with no blend
+	s2, err := Mask2D(Box2D(V2{10, 10}, 0), func(s SDF2) SDF2 { return Offset2D(s, 1) }, Circle2D(3), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(s2.Evaluate(V2{0, 0}), -6, 1e-9) || !EqualFloat64(s2.Evaluate(V2{4, 0}), -1, 1e-9) {
+		t.Error("FAIL")
+	}
+	if _, err := Mask3D(base, nil, mask, 1); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Annotations(t *testing.T) {
+	a := NewAnnotations()
+	a.Anchor("left", V2{-20, 0})
+	a.Anchor("right", V2{20, 0})
+	a.Circle("bore", V2{0, 0}, 4)
+	if err := a.Distance("left", "right", -8); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Diameter("bore"); err != nil {
+		t.Fatal(err)
+	}
+	if a.Distance("left", "top", 0) == nil || a.Diameter("hole") == nil {
+		t.Error("FAIL")
+	}
+	labels := a.Labels()
+	if len(labels) != 2 || labels[0] != "40.00" || labels[1] != "Ø8.00" {
+		t.Logf("labels %v", labels)
+		t.Error("FAIL")
+	}
+	// the labels are drawn on the image
+	d, err := NewPNG("annotate.png", Box2{V2{-25, -25}, V2{25, 25}}, V2i{200, 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.RenderSDF2(Circle2D(20))
+	d.Annotate(a)
+	n := 0
+	for x := 0; x < 200; x++ {
+		for y := 0; y < 200; y++ {
+			if d.img.RGBAAt(x, y) == a.Color {
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_NonCircularGear(t *testing.T) {
+	k := NonCircularGearParms{
+		Teeth:         30,
+		PressureAngle: DtoR(20),
+		Facets:        16,
+	}
+	// a circular pitch curve generates involute teeth
+	gear, err := NonCircularGear2D(func(theta float64) float64 { return 30 }, &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha := k.PressureAngle
+	rb := 30 * math.Cos(alpha)
+	inv := func(a float64) float64 { return math.Tan(a) - a }
+	w := 0.25 * Tau * 30 / 30
+	for r := rb + 0.1; r < 31.9; r += 0.1 {
+		psi := w/30 + inv(alpha) - inv(math.Acos(rb/r))
+		if d := gear.Evaluate(PolarToXY(r, psi)); Abs(d) > 0.01 {
+			t.Logf("r %f d %f", r, d)
+			t.Error("FAIL")
+		}
+	}
+	// elliptical gear: teeth and spaces along the pitch curve
+	k.Teeth = 31
+	gear, err = EllipticalGear2D(40, 0.2, &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := newPitchCurve(EllipsePitch(40, 0.2), 1024)
+	p := c.length / 31
+	m := p / Pi
+	for i := 0; i < 31; i++ {
+		s := p * float64(i)
+		q, _, n := c.frame(s)
+		if gear.Evaluate(q.Add(n.MulScalar(0.5*m))) >= 0 {
+			t.Error("FAIL")
+		}
+		if Abs(gear.Evaluate(q.Add(n.MulScalar(m)))) > 0.02*m {
+			t.Error("FAIL")
+		}
+		q, _, n = c.frame(s + 0.5*p)
+		if gear.Evaluate(q.Add(n.MulScalar(0.5*m))) <= 0 {
+			t.Error("FAIL")
+		}
+		if Abs(gear.Evaluate(q.Sub(n.MulScalar(1.25*m)))) > 0.02*m {
+			t.Error("FAIL")
+		}
+	}
+	// too few teeth for the curvature
+	if _, err := EllipticalGear2D(40, 0.9, &k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MirrorTile(t *testing.T) {
+	// herringbone: a diagonal bar in each cell, alternately mirrored
+	bar := Transform2D(Box2D(V2{14, 2}, 0), Rotate2d(DtoR(45)))
+	s := MirrorTile2D(bar, V2i{4, 3}, V2{10, 10})
+	// the same pattern as a union of explicit copies
+	var copies []SDF2
+	for j := 0; j < 4; j++ {
+		for k := 0; k < 3; k++ {
+			m := Translate2d(V2{float64(j) * 10, float64(k) * 10})
+			if j&1 == 1 {
+				m = m.Mul(MirrorY())
+			}
+			if k&1 == 1 {
+				m = m.Mul(MirrorX())
+			}
+			copies = append(copies, Transform2D(bar, m))
+		}
+	}
+	u := Union2D(copies...)
+	bb := s.BoundingBox()
+	if !bb.Min.Equals(u.BoundingBox().Min, tolerance) || !bb.Max.Equals(u.BoundingBox().Max, tolerance) {
+		t.Logf("%v %v", bb, u.BoundingBox())
+		t.Error("FAIL")
+	}
+	for x := bb.Min.X; x <= bb.Max.X; x += 0.7 {
+		for y := bb.Min.Y; y <= bb.Max.Y; y += 0.7 {
+			p := V2{x, y}
+			if !EqualFloat64(s.Evaluate(p), u.Evaluate(p), 1e-9) {
+				t.Logf("%v %f %f", p, s.Evaluate(p), u.Evaluate(p))
+				t.Error("FAIL")
+				return
+			}
+		}
+	}
+	// the bars meet at the shared edges
+	if s.Evaluate(V2{4.5, 4.5}) >= 0 || s.Evaluate(V2{14.5, -4.5}) >= 0 || s.Evaluate(V2{12, 2}) <= 0 {
+		t.Error("FAIL")
+	}
+	// 3d
+	s3 := MirrorTile3D(Box3D(V3{4, 2, 2}, 0), V3i{3, 1, 2}, V3{5, 0, 5})
+	if s3.Evaluate(V3{10, 0, 5}) >= 0 || s3.Evaluate(V3{7.5, 0, 0}) <= 0 {
+		t.Error("FAIL")
+	}
+	if MirrorTile3D(Box3D(V3{4, 2, 2}, 0), V3i{3, 2, 1}, V3{5, 0, 5}) != nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_GearReport(t *testing.T) {
+	r, err := InvoluteGearReport(20, 40, 2, DtoR(20), 0.1, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(r.PitchDiameter, 40, tolerance) ||
+		!EqualFloat64(r.BaseDiameter, 37.5877, 1e-5) ||
+		!EqualFloat64(r.RootDiameter, 35.5, tolerance) ||
+		!EqualFloat64(r.OuterDiameter, 44, tolerance) ||
+		!EqualFloat64(r.CenterDistance, 60, tolerance) ||
+		!EqualFloat64(r.Backlash, 0.2, tolerance) {
+		t.Logf("%+v", r)
+		t.Error("FAIL")
+	}
+	if !EqualFloat64(r.ContactRatio, 1.6352, 1e-4) {
+		t.Logf("contact ratio %f", r.ContactRatio)
+		t.Error("FAIL")
+	}
+	if r.MinTeeth != 18 || r.Undercut || r.MateUndercut || r.Check() != nil {
+		t.Error("FAIL")
+	}
+	// 14.5 degrees, a small pinion is undercut
+	r, err = InvoluteGearReport(12, 40, 2, DtoR(14.5), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MinTeeth != 32 || !r.Undercut || r.MateUndercut {
+		t.Error("FAIL")
+	}
+	if _, err := InvoluteGearReport(20, 2, 2, DtoR(20), 0, 0); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Density(t *testing.T) {
+	g, err := Density3D(Sphere3D(10), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.N != (V3i{20, 20, 20}) || !g.Origin.Equals(V3{-10, -10, -10}, tolerance) {
+		t.Error("FAIL")
+	}
+	// the center is solid, the corners are empty, the boundary is partial
+	if g.Density[g.index(10, 10, 10)] != 1 || g.Density[g.index(0, 0, 0)] != 0 {
+		t.Error("FAIL")
+	}
+	partial := 0
+	for _, x := range g.Density {
+		if x > 0 && x < 1 {
+			partial++
+		}
+	}
+	if partial == 0 {
+		t.Error("FAIL")
+	}
+	v := 4.0 / 3.0 * Pi * 1000
+	if Abs(g.Volume()-v)/v > 0.01 {
+		t.Logf("volume %f expected %f", g.Volume(), v)
+		t.Error("FAIL")
+	}
+	// npy file
+	var b bytes.Buffer
+	if err := g.WriteNPY(&b); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	hlen := int(data[8]) | int(data[9])<<8
+	if string(data[:6]) != npyMagic || (10+hlen)%64 != 0 || len(data) != 10+hlen+4*8000 {
+		t.Error("FAIL")
+	}
+	hdr := string(data[10 : 10+hlen])
+	if !strings.Contains(hdr, "'shape': (20, 20, 20)") || !strings.HasSuffix(hdr, "\n") {
+		t.Logf("header %q", hdr)
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_AnalyticInvoluteGear(t *testing.T) {
+	n, m, pa, backlash := 12, 2.0, DtoR(20), 0.1
+	rp := float64(n) * m / 2
+	rb := rp * math.Cos(pa)
+	rr := rp - 1.25*m
+	ro := rp + m
+	gear := newInvoluteGearSDF2(n, m, pa, backlash, rr, ro, 0.25*m/(1-math.Sin(pa)))
+	g := gear.(*InvoluteGearSDF2)
+	// the same shape with finely faceted flanks
+	tooth := InvoluteGearTooth(n, m, rr, rb, ro, backlash, 2000)
+	ref := Union2D(RotateCopy2D(tooth, n), Circle2D(rr))
+	// the reference has a straight tip and no root fillet, compare between them
+	for r := g.rj + 0.2; r < ro-0.1; r += 0.1 {
+		for a := 0.0; a < Tau; a += 0.02 {
+			p := PolarToXY(r, a)
+			d0 := gear.Evaluate(p)
+			d1 := ref.Evaluate(p)
+			if (d0 < -1e-4 && d1 > 1e-4) || (d0 > 1e-4 && d1 < -1e-4) || (d1 > 0 && Abs(d0-d1) > 1e-4) {
+				t.Logf("%v %f %f", p, d0, d1)
+				t.Error("FAIL")
+				return
+			}
+		}
+	}
+	if !EqualFloat64(gear.Evaluate(V2{ro + 0.5, 0}), 0.5, 1e-9) {
+		t.Error("FAIL")
+	}
+	// points on the involute are on the surface
+	for t0 := g.tStart; t0 <= g.tStop; t0 += 0.01 {
+		if Abs(gear.Evaluate(g.flank(t0))) > 1e-9 {
+			t.Error("FAIL")
+		}
+	}
+	// selected by facets = 0
+	s := InvoluteGear(n, m, pa, backlash, 0.25*m, 3, 0)
+	if _, ok := s.(*DifferenceSDF2); !ok || Abs(s.Evaluate(g.flank(0.3))) > 1e-9 {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_DensityImport(t *testing.T) {
+	g, err := Density3D(Sphere3D(10), 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// round trip through a npy file
+	var b bytes.Buffer
+	if err := g.WriteNPY(&b); err != nil {
+		t.Fatal(err)
+	}
+	g1, err := ReadNPY(&b, g.Origin, g.Voxel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g1.N != g.N || len(g1.Density) != len(g.Density) || g1.Density[g.index(10, 10, 10)] != 1 {
+		t.Error("FAIL")
+	}
+	s, err := g1.IsoSurface(0.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// close to the surface and a distance field away from it
+	for _, r := range []float64{2.5, 5, 10, 15} {
+		for _, v := range []V3{{1, 0, 0}, {0, 1, 0}, {0, 0, -1}, V3{1, 1, 1}.Normalize()} {
+			d := s.Evaluate(v.MulScalar(r))
+			if Abs(d-(r-10)) > 0.25 {
+				t.Logf("r %f d %f", r, d)
+				t.Error("FAIL")
+			}
+		}
+	}
+	// bad data
+	if _, err := ReadNPY(strings.NewReader("not a npy file"), V3{}, 1); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := g.IsoSurface(1.5, 0); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ISOThread3D(t *testing.T) {
+	k := ISOThreadParms{Diameter: 10, Pitch: 1.5, Length: 20, LeadIn: true}
+	depth := ISOThreadDepth(k.Pitch)
+	if !EqualFloat64(depth, 0.812, 1e-3) {
+		t.Error("FAIL")
+	}
+	s, err := ISOThread3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rMinor := 5 - depth
+	// solid core, nothing outside the major diameter
+	if s.Evaluate(V3{rMinor - 0.1, 0, 0}) >= 0 || s.Evaluate(V3{0, 5.05, 3}) <= 0 {
+		t.Error("FAIL")
+	}
+	// the thread reaches the major diameter in the middle, but not at the chamfered ends
+	crest, end := math.Inf(1), math.Inf(1)
+	for i := 0; i < 36; i++ {
+		x := PolarToXY(4.9, Tau*float64(i)/36)
+		crest = Min(crest, s.Evaluate(V3{x.X, x.Y, 0}))
+		end = Min(end, s.Evaluate(V3{x.X, x.Y, 9.95}))
+	}
+	if crest >= 0 || end <= 0 {
+		t.Logf("crest %f end %f", crest, end)
+		t.Error("FAIL")
+	}
+	// the internal thread (tap form) is countersunk at the ends
+	k.Internal = true
+	s, err = ISOThread3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V3{4.9, 0, 9.98}) >= 0 || s.Evaluate(V3{0, -4.9, -9.98}) >= 0 || s.Evaluate(V3{rMinor - 0.1, 0, 0}) >= 0 {
+		t.Error("FAIL")
+	}
+	// bad parameters
+	for _, x := range []ISOThreadParms{
+		{Diameter: 0, Pitch: 1, Length: 10},
+		{Diameter: 10, Pitch: 0, Length: 10},
+		{Diameter: 2, Pitch: 2, Length: 10},
+		{Diameter: 10, Pitch: 1.5, Length: 0},
+		{Diameter: 10, Pitch: 1.5, Length: 1, LeadIn: true},
+	} {
+		if _, err := ISOThread3D(&x); err == nil {
+			t.Error("FAIL")
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SheetMetal(t *testing.T) {
+	base := []V2{{-20, -15}, {20, -15}, {20, 15}, {-20, 15}}
+	s, err := NewSheetMetal(base, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// up on the -y edge, down on the +x edge
+	if err := s.Flange(&FlangeParms{Edge: 0, Angle: DtoR(90), Radius: 1, KFactor: 0.4, Length: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Flange(&FlangeParms{Edge: 1, Angle: DtoR(-90), Radius: 1, KFactor: 0.4, Length: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Flange(&FlangeParms{Edge: 0, Angle: DtoR(90), Length: 1}) == nil || s.Flange(&FlangeParms{Edge: 4, Angle: 1}) == nil {
+		t.Error("FAIL")
+	}
+	f := s.Folded3D()
+	// the up flange mid thickness is at y = -17 (bend center y = -15, z = 3, mid radius 2)
+	inside := []V3{{0, -17, 8}, {10, -17, 12.9}, {0, -15 - 1.414, 1.586}, {0, 0, 1}, {22, 0, -5.9}}
+	outside := []V3{{0, -17, 13.1}, {0, -18.1, 8}, {0, -15.9, 8}, {0, 0, 2.1}, {22, 0, -6.1}, {23.1, 0, -3}, {21, -16, 5}}
+	for _, p := range inside {
+		if f.Evaluate(p) >= 0 {
+			t.Logf("inside %v %f", p, f.Evaluate(p))
+			t.Error("FAIL")
+		}
+	}
+	for _, p := range outside {
+		if f.Evaluate(p) <= 0 {
+			t.Logf("outside %v %f", p, f.Evaluate(p))
+			t.Error("FAIL")
+		}
+	}
+	if !EqualFloat64(f.Evaluate(V3{0, -17, 8}), -1, 1e-9) {
+		t.Error("FAIL")
+	}
+	bb := f.BoundingBox()
+	if bb.Max.Z < 13 || bb.Min.Z > -6 || bb.Min.Y > -17.9 || bb.Max.X < 22.9 {
+		t.Logf("%v", bb)
+		t.Error("FAIL")
+	}
+	// flat pattern: the bend allowance plus the flat
+	ba := 0.5 * Pi * (1 + 0.4*2)
+	flat := s.Flat2D()
+	if flat.Evaluate(V2{0, -15 - ba - 9.9}) >= 0 || flat.Evaluate(V2{0, -15 - ba - 10.1}) <= 0 {
+		t.Error("FAIL")
+	}
+	if flat.Evaluate(V2{20 + ba + 4.9, 0}) >= 0 || flat.Evaluate(V2{20 + ba + 5.1, 0}) <= 0 {
+		t.Error("FAIL")
+	}
+	// corner notch
+	if flat.Evaluate(V2{21, -16}) <= 0 {
+		t.Error("FAIL")
+	}
+	lines := s.BendLines()
+	if len(lines) != 2 || !lines[0][0].Equals(V2{-20, -15 - 0.5*ba}, 1e-9) || !lines[1][1].Equals(V2{20 + 0.5*ba, 15}, 1e-9) {
+		t.Logf("%v %v", lines[0], lines[1])
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Fits(t *testing.T) {
+	tests := []struct {
+		size    float64
+		fit     string
+		hole    [2]float64 // deviations (um)
+		shaft   [2]float64
+		fitType FitType
+	}{
+		{8, "H7/g6", [2]float64{0, 15}, [2]float64{-14, -5}, ClearanceFit},
+		{20, "H7/k6", [2]float64{0, 21}, [2]float64{2, 15}, TransitionFit},
+		{20, "H7/p6", [2]float64{0, 21}, [2]float64{22, 35}, InterferenceFit},
+		{40, "H9/d9", [2]float64{0, 62}, [2]float64{-142, -80}, ClearanceFit},
+		{10, "F8/h7", [2]float64{13, 35}, [2]float64{-15, 0}, ClearanceFit},
+		{100, "JS7/js6", [2]float64{-17.5, 17.5}, [2]float64{-11, 11}, TransitionFit},
+	}
+	for _, x := range tests {
+		f, err := ISOFit(x.size, x.fit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if Abs(f.HoleMin-x.size-1e-3*x.hole[0]) > 1e-9 || Abs(f.HoleMax-x.size-1e-3*x.hole[1]) > 1e-9 ||
+			Abs(f.ShaftMin-x.size-1e-3*x.shaft[0]) > 1e-9 || Abs(f.ShaftMax-x.size-1e-3*x.shaft[1]) > 1e-9 ||
+			f.Type() != x.fitType {
+			t.Logf("%s", f)
+			t.Error("FAIL")
+		}
+	}
+	// the modeled diameters track the nominal size
+	f, _ := ISOFit(8, "H7/g6")
+	if !EqualFloat64(f.Hole(), 8.0075, 1e-9) || !EqualFloat64(f.Shaft(), 7.9905, 1e-9) {
+		t.Error("FAIL")
+	}
+	for _, x := range []struct {
+		size float64
+		fit  string
+	}{{600, "H7/g6"}, {0, "H7/g6"}, {10, "H13/g6"}, {10, "K7/h6"}, {10, "H7/c11"}, {10, "h7/H6"}, {10, "H7"}} {
+		if _, err := ISOFit(x.size, x.fit); err == nil {
+			t.Logf("%g %s", x.size, x.fit)
+			t.Error("FAIL")
+		}
+	}
+	// printed fits get looser with the class
+	c := -1.0
+	for _, class := range []PrintedFitClass{PressFit, SnugFit, SlidingFit, RunningFit} {
+		f, err := PrintedFit(5, class)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.MinClearance() <= c || !EqualFloat64(f.Hole()+f.Shaft(), 10, 1e-9) || f.Type() != ClearanceFit {
+			t.Error("FAIL")
+		}
+		c = f.MinClearance()
+	}
+	if _, err := PrintedFit(5, PrintedFitClass(10)); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_BossRibs(t *testing.T) {
+	draft := DtoR(1)
+	tan := math.Tan(draft)
+	rib, err := Rib3D(20, 10, 2, draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// thicker at the base
+	if rib.Evaluate(V3{0, 1.05, 9}) <= 0 || rib.Evaluate(V3{0, 1.05, 1}) >= 0 || rib.Evaluate(V3{10.1, 0, 5}) <= 0 {
+		t.Error("FAIL")
+	}
+	// a grid of ribs across a panel
+	panel := Box2D(V2{100, 60}, 0)
+	ribs, err := Ribs3D(panel, &RibParms{Spacing: V2{20, 15}, Height: 5, Thickness: 1.5, Draft: draft})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []V3{{0, 10, 2}, {20, -25, 2}, {-40, 15, 4.9}, {7, 0, 1}, {-49.5, 15, 1}} {
+		if ribs.Evaluate(p) >= 0 {
+			t.Logf("inside %v %f", p, ribs.Evaluate(p))
+			t.Error("FAIL")
+		}
+	}
+	for _, p := range []V3{{10, 7, 2}, {20, 10, 5.1}, {0, 10, -0.1}, {50.1, 15, 1}, {0.75 + 0.1, 7, 4.99}} {
+		if ribs.Evaluate(p) <= 0 {
+			t.Logf("outside %v %f", p, ribs.Evaluate(p))
+			t.Error("FAIL")
+		}
+	}
+	if _, err := Ribs3D(panel, &RibParms{Spacing: V2{1, 0}, Height: 5, Thickness: 1.5}); err == nil {
+		t.Error("FAIL")
+	}
+	// screw boss with gussets
+	k := ScrewBossParms{
+		Height:          12,
+		Diameter:        7,
+		HoleDiameter:    2.5,
+		HoleDepth:       10,
+		Draft:           draft,
+		Gussets:         4,
+		GussetHeight:    8,
+		GussetLength:    5,
+		GussetThickness: 1.2,
+	}
+	boss, err := ScrewBoss3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inside := []V3{{3.4, 0, 11.9}, {0, 3.4 + 6*tan, 6}, {0, 0, 1}, {5, 0, 3}, {0, -7, 1}, {0, 8.3, 0.1}}
+	outside := []V3{{0, 0, 11}, {1.24, 0, 2.1}, {3.6, 0, 11.9}, {2.6, 2.6, 11}, {0, 8.6, 0.1}, {7, 0, 5}, {3.55, 0, 12.1}}
+	for _, p := range inside {
+		if boss.Evaluate(p) >= 0 {
+			t.Logf("inside %v %f", p, boss.Evaluate(p))
+			t.Error("FAIL")
+		}
+	}
+	for _, p := range outside {
+		if boss.Evaluate(p) <= 0 {
+			t.Logf("outside %v %f", p, boss.Evaluate(p))
+			t.Error("FAIL")
+		}
+	}
+	k.HoleDiameter = 8
+	if _, err := ScrewBoss3D(&k); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Flexures(t *testing.T) {
+	const e = 3500.0
+	// leaf flexure
+	leaf := LeafFlexureParms{Length: 20, Thickness: 0.8, Depth: 5, Block: V2{5, 6}}
+	s, err := LeafFlexure2D(&leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V2{0, 0.35}) >= 0 || s.Evaluate(V2{0, 0.45}) <= 0 || s.Evaluate(V2{11, 2.9}) >= 0 || s.Evaluate(V2{15.1, 0}) <= 0 || s.Evaluate(V2{5, 1}) <= 0 {
+		t.Error("FAIL")
+	}
+	kt, kr := leaf.Stiffness(e)
+	i := 5 * 0.8 * 0.8 * 0.8 / 12
+	if !EqualFloat64(kt, 3*e*i/8000, 1e-9) || !EqualFloat64(kr, e*i/20, 1e-9) {
+		t.Error("FAIL")
+	}
+	// circular notch hinge, compare with the Paros-Weisbord formula (t << R)
+	notch := NotchHingeParms{Size: V2{20, 10}, Thickness: 0.5, Notch: V2{4, 4}, Depth: 5}
+	s, err = NotchHinge2D(&notch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V2{0, 0.2}) >= 0 || s.Evaluate(V2{0, -0.3}) <= 0 || s.Evaluate(V2{4.1, 4.5}) >= 0 || s.Evaluate(V2{-3, 4.5}) <= 0 {
+		t.Error("FAIL")
+	}
+	k := notch.Stiffness(e)
+	pw := 2 * e * 5 * math.Pow(0.5, 2.5) / (9 * Pi * math.Sqrt(4))
+	if Abs(k-pw)/pw > 0.1 {
+		t.Logf("notch %f paros-weisbord %f", k, pw)
+		t.Error("FAIL")
+	}
+	// an elliptical notch, longer is more compliant
+	notch.Notch = V2{6, 4}
+	s, err = NotchHinge2D(&notch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V2{5, 4.5}) <= 0 || notch.Stiffness(e) >= k {
+		t.Error("FAIL")
+	}
+	notch.Notch = V2{12, 4}
+	if _, err := NotchHinge2D(&notch); err == nil {
+		t.Error("FAIL")
+	}
+	// parallelogram stage
+	stage := ParallelFlexureParms{Length: 30, Thickness: 1, Separation: 20, Block: 5, Depth: 10}
+	s, err = ParallelFlexure2D(&stage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V2{10.4, 0}) >= 0 || s.Evaluate(V2{10.6, 0}) <= 0 || s.Evaluate(V2{0, 0}) <= 0 || s.Evaluate(V2{0, 17}) >= 0 || s.Evaluate(V2{0, -17}) >= 0 {
+		t.Error("FAIL")
+	}
+	// 8 times a cantilever leaf
+	kt, _ = (&LeafFlexureParms{Length: 30, Thickness: 1, Depth: 10}).Stiffness(e)
+	if !EqualFloat64(stage.Stiffness(e), 8*kt, 1e-9) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_HelicalSweep(t *testing.T) {
+	// a flat topped bottle style tooth with wide gaps
+	p, r0, r1 := 2.0, 10.0, 11.0
+	tooth := Polygon2D([]V2{{p, 0}, {p, r0}, {0.5, r0}, {0.3, r1}, {-0.3, r1}, {-0.5, r0}, {-p, r0}, {-p, 0}})
+	s, err := HelicalSweep3D(tooth, 2*p, 2, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := Screw3D(tooth, 20, p, 2)
+	for i := 0; i < 100; i++ {
+		v := V3{randomRange(-12, 12), randomRange(-12, 12), randomRange(-11, 11)}
+		if s.Evaluate(v) != ref.Evaluate(v) {
+			t.Error("FAIL")
+			break
+		}
+	}
+	// one tooth per start: the crest repeats every lead/starts along the z axis
+	if s.Evaluate(V3{10.5, 0, 0}) >= 0 || s.Evaluate(V3{10.5, 0, 0.5 * p}) <= 0 || s.Evaluate(V3{10.5, 0, p}) >= 0 {
+		t.Error("FAIL")
+	}
+	// a left hand single start has the opposite twist
+	l, _ := HelicalSweep3D(tooth, p, -1, 20)
+	r, _ := HelicalSweep3D(tooth, p, 1, 20)
+	v := PolarToXY(10.5, DtoR(90))
+	if !EqualFloat64(l.Evaluate(V3{v.X, v.Y, 0}), r.Evaluate(V3{v.X, -v.Y, 0}), 1e-9) {
+		t.Error("FAIL")
+	}
+	if _, err := HelicalSweep3D(tooth, 0, 1, 20); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := HelicalSweep3D(tooth, p, 0, 20); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_HexFasteners(t *testing.T) {
+	check := func(s SDF3, inside, outside []V3) {
+		for _, p := range inside {
+			if s.Evaluate(p) >= 0 {
+				t.Logf("inside %v %f", p, s.Evaluate(p))
+				t.Error("FAIL")
+			}
+		}
+		for _, p := range outside {
+			if s.Evaluate(p) <= 0 {
+				t.Logf("outside %v %f", p, s.Evaluate(p))
+				t.Error("FAIL")
+			}
+		}
+	}
+	// M5 bolt: head height 3.5, 20 long with a 5 long shank
+	bolt, err := HexBolt3D(&HexBoltParms{Size: "M5", Length: 20, ShankLength: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(bolt,
+		[]V3{{0, 0, 1}, {3.8, 0, 1}, {2.45, 0, 6}, {0.5, 0, 23.4}, {0, 1.9, 15}},
+		[]V3{{0, 0, -0.1}, {0, 4.7, 1}, {2.55, 0, 6}, {0, 0, 23.6}, {0, 2.55, 15}},
+	)
+	// the external thread takes 1/3 of the clearance
+	bolt, _ = HexBolt3D(&HexBoltParms{Size: "M5", Length: 20, ShankLength: 5, Clearance: 0.3})
+	check(bolt, []V3{{2.35, 0, 6}}, []V3{{2.45, 0, 6}})
+	// M5 nut: height 4.7, minor diameter 4.13
+	nut, err := HexNut3D(&HexNutParms{Size: "M5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(nut,
+		[]V3{{3.8, 0, 2.35}, {0, -2.6, 2.35}},
+		[]V3{{0.5, 0, 2.35}, {1.9, 0, 2.35}, {3.8, 0, 4.8}, {2.4, 0, 4.65}},
+	)
+	nut, _ = HexNut3D(&HexNutParms{Size: "M5", Clearance: 0.3})
+	check(nut, nil, []V3{{2.2, 0, 2.35}})
+	// M5 washer: 5.3 x 10 x 1
+	washer, err := FlatWasher3D(&FlatWasherParms{Size: "M5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(washer, []V3{{4, 0, 0.5}, {0, -2.7, 0.5}}, []V3{{2.6, 0, 0.5}, {4, 0, 1.1}, {5.1, 0, 0.5}})
+	if _, err := FlatWasher3D(&FlatWasherParms{Size: "M7"}); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := HexBolt3D(&HexBoltParms{Size: "M5", Length: 10, ShankLength: 10}); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Inlay3MF(t *testing.T) {
+	part := Box3D(V3{40, 20, 5}, 0)
+	label := Box2D(V2{10, 5}, 0)
+	base, insert, err := Inlay3D(part, label, &InlayParms{Surface: 2.5, Depth: 1, Raise: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.Evaluate(V3{0, 0, 2}) <= 0 || base.Evaluate(V3{0, 0, 1.4}) >= 0 || base.Evaluate(V3{8, 0, 2}) >= 0 {
+		t.Error("FAIL")
+	}
+	if insert.Evaluate(V3{0, 0, 2}) >= 0 || insert.Evaluate(V3{0, 0, 2.9}) >= 0 || insert.Evaluate(V3{0, 0, 3.1}) <= 0 ||
+		insert.Evaluate(V3{0, 0, 1.4}) <= 0 || insert.Evaluate(V3{5.1, 0, 2}) <= 0 {
+		t.Error("FAIL")
+	}
+	// with clearance the pocket is bigger than the insert
+	base, _, _ = Inlay3D(part, label, &InlayParms{Surface: 2.5, Depth: 1, Clearance: 0.2})
+	if base.Evaluate(V3{5.1, 0, 2}) <= 0 {
+		t.Error("FAIL")
+	}
+
+	// save both as a 3MF file
+	var buf bytes.Buffer
+	objects := []*Object3MF{
+		{Name: "base", Color: color.White, Mesh: RenderMesh(base, 40)},
+		{Name: "label <1>", Color: color.RGBA{0xff, 0, 0, 0xff}, Mesh: RenderMesh(insert, 40)},
+	}
+	if err := Write3MF(&buf, objects); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := make(map[string]int)
+	files := 0
+	for _, f := range z.File {
+		files++
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// all parts are well formed xml
+		d := xml.NewDecoder(r)
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				if err != io.EOF {
+					t.Error(err)
+				}
+				break
+			}
+			if se, ok := tok.(xml.StartElement); ok {
+				count[se.Name.Local]++
+				if se.Name.Local == "base" && se.Attr[0].Value == "label <1>" && se.Attr[1].Value != "#FF0000FF" {
+					t.Error("FAIL")
+				}
+			}
+		}
+		r.Close()
+	}
+	if files != 3 || count["object"] != 2 || count["item"] != 2 || count["base"] != 2 {
+		t.Logf("%d %v", files, count)
+		t.Error("FAIL")
+	}
+	// vertices are shared: a closed mesh has about half as many vertices as triangles
+	n := len(objects[0].Mesh) + len(objects[1].Mesh)
+	if count["triangle"] == 0 || count["triangle"] > n || count["vertex"] > count["triangle"] {
+		t.Logf("%d %v", n, count)
+		t.Error("FAIL")
+	}
+	if Write3MF(&buf, nil) == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Turntable(t *testing.T) {
+	// a sphere with a bump on the +x side
+	s := Union3D(Sphere3D(5), Transform3D(Box3D(V3{4, 2, 2}, 0), Translate3d(V3{6, 0, 0})))
+	frames, err := Turntable(s, &TurntableParms{Frames: 4, Width: 40, Height: 30, Elevation: DtoR(20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 4 {
+		t.Fatal("FAIL")
+	}
+	for _, f := range frames {
+		// the model is in the middle, the background is in the corners
+		if f.ColorIndexAt(20, 15) == 0 || f.ColorIndexAt(0, 0) != 0 || f.ColorIndexAt(39, 29) != 0 {
+			t.Error("FAIL")
+		}
+	}
+	// looking from -y the bump (+x) is on the right
+	count := func(f *image.Paletted, x0, x1 int) int {
+		n := 0
+		for x := x0; x < x1; x++ {
+			for y := 0; y < f.Rect.Dy(); y++ {
+				if f.ColorIndexAt(x, y) != 0 {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	c := Camera{Eye: V3{0, -40, 0}, Center: V3{0, 0, 0}, Up: V3{0, 0, 1}, FOV: DtoR(30)}
+	img, err := c.Render(s, 40, 30, color.White)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count(img, 20, 40) <= count(img, 0, 20) {
+		t.Error("FAIL")
+	}
+	// save as a GIF
+	dir, err := ioutil.TempDir("", "turntable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := dir + "/turntable.gif"
+	if err := SaveGIF(path, frames, 10); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	g, err := gif.DecodeAll(f)
+	if err != nil || len(g.Image) != 4 || g.Delay[0] != 10 {
+		t.Error("FAIL")
+	}
+	c.Eye = V3{0, 0, 40}
+	if _, err := c.Render(s, 10, 10, color.White); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ISOThreadTolerance(t *testing.T) {
+	// M10x1.5 6g: es = -0.032, Td2 = 0.132 (table values)
+	lo, hi, err := ISOThreadTolerance(10, 1.5, "6g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("6g %f %f", lo, hi)
+	if Abs(hi+0.0315) > 0.001 || Abs(hi-lo-0.132) > 0.01 {
+		t.Error("FAIL")
+	}
+	// M10x1.5 6H: EI = 0, TD2 = 0.180
+	lo, hi, err = ISOThreadTolerance(10, 1.5, "6H")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("6H %f %f", lo, hi)
+	if lo != 0 || Abs(hi-0.180) > 0.01 {
+		t.Error("FAIL")
+	}
+	// M5x0.8 4h: es = 0
+	lo, hi, _ = ISOThreadTolerance(5, 0.8, "4h")
+	if hi != 0 || lo >= 0 {
+		t.Error("FAIL")
+	}
+	for _, c := range []string{"", "6", "6x", "2g", "6gH"} {
+		if _, _, err := ISOThreadTolerance(10, 1.5, c); err == nil {
+			t.Errorf("FAIL %s", c)
+		}
+	}
+	// the class must match the thread type
+	k := ISOThreadParms{Diameter: 10, Pitch: 1.5, Length: 10, Class: "6H"}
+	if _, err := ISOThread3D(&k); err == nil {
+		t.Error("FAIL")
+	}
+	k.Class = "6g"
+	ext, err := ISOThread3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basic, _ := ISOThread3D(&ISOThreadParms{Diameter: 10, Pitch: 1.5, Length: 10})
+	// the toleranced external thread is smaller than the basic thread
+	p := V3{4.9, 0, 0.3}
+	if ext.Evaluate(p) <= basic.Evaluate(p) {
+		t.Error("FAIL")
+	}
+	// clearance shrinks the external thread and grows the internal thread
+	k = ISOThreadParms{Diameter: 10, Pitch: 1.5, Length: 10, Internal: true, Class: "6H", Clearance: 0.3}
+	in, err := ISOThread3D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tap, _ := ISOThread3D(&ISOThreadParms{Diameter: 10, Pitch: 1.5, Length: 10, Internal: true})
+	if in.Evaluate(p) >= tap.Evaluate(p) {
+		t.Error("FAIL")
+	}
+	if _, err := ISOThread3D(&ISOThreadParms{Diameter: 10, Pitch: 1.5, Length: 10, Clearance: -1}); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_PolygonBezier(t *testing.T) {
+	// quadratic curve with a fixed number of facets
+	p := NewPolygon()
+	p.Add(0, 0)
+	p.Add(1, 2).Control()
+	p.Add(2, 0).Bezier(4)
+	v := p.Vertices()
+	if len(v) != 5 {
+		t.Errorf("FAIL %d vertices", len(v))
+	}
+	if !v[2].Equals(V2{1, 1}, tolerance) || !v[4].Equals(V2{2, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+	// cubic quarter circles with a chord tolerance
+	const k = 0.5522847498
+	const tol = 1e-3
+	p = NewPolygon()
+	p.Add(1, 0)
+	p.Add(1, k).Control()
+	p.Add(k, 1).Control()
+	p.Add(0, 1).BezierTolerance(tol)
+	p.Add(-k, 1).Control()
+	p.Add(-1, k).Control()
+	p.Add(-1, 0).BezierTolerance(tol)
+	p.Add(-1, -1)
+	p.Add(1, -1)
+	p.Close()
+	v = p.Vertices()
+	t.Logf("%d vertices", len(v))
+	if len(v) < 10 || len(v) > 100 {
+		t.Error("FAIL")
+	}
+	for i := range v {
+		if v[i].Y < 0 {
+			continue
+		}
+		// vertices on the curve, segment midpoints within the tolerance
+		m := v[i].Add(v[(i+1)%len(v)]).MulScalar(0.5)
+		if Abs(v[i].Length()-1) > 3e-4 || (m.Y > 0 && Abs(m.Length()-1) > tol+3e-4) {
+			t.Errorf("FAIL %v", v[i])
+		}
+	}
+	// a curve closing a polygon
+	p = NewPolygon()
+	p.Add(0, 0).Bezier(8)
+	p.Add(2, 0)
+	p.Add(1, 2).Control()
+	p.Close()
+	v = p.Vertices()
+	t.Logf("%v", v)
+	if len(v) != 9 || !v[5].Equals(V2{1, 1}, tolerance) {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_LatheToolpath(t *testing.T) {
+	// stepped shaft, radius 10 for z = 0..20, radius 5 for z = 20..40
+	profile := Polygon2D([]V2{{0, 0}, {10, 0}, {10, 20}, {5, 20}, {5, 40}, {0, 40}})
+	if _, err := LatheProfile(Box3D(V3{1, 1, 1}, 0)); err == nil {
+		t.Error("FAIL")
+	}
+	p, err := LatheProfile(Revolve3D(profile))
+	if err != nil || p != profile {
+		t.Error("FAIL")
+	}
+	k := LatheParms{
+		StockRadius: 12,
+		DepthOfCut:  1,
+		Allowance:   0.2,
+		Step:        0.5,
+		Clearance:   2,
+		RoughFeed:   100,
+		FinishFeed:  50,
+		Speed:       1000,
+	}
+	moves, err := LatheToolpath(profile, &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%d moves", len(moves))
+	finish := false
+	for _, m := range moves {
+		d := profile.Evaluate(m.P)
+		switch m.Feed {
+		case k.RoughFeed:
+			// roughing stays clear of the finishing allowance
+			if d < k.Allowance-1e-6 {
+				t.Errorf("FAIL rough %v", m.P)
+			}
+		case k.FinishFeed:
+			// finishing is on the part surface (or clear of it)
+			if d < -1e-6 {
+				t.Errorf("FAIL finish %v", m.P)
+			}
+			if m.P.Equals(V2{5, 40}, 1e-3) || m.P.Equals(V2{10, 20}, 1e-3) {
+				finish = true
+			}
+		}
+	}
+	if !finish {
+		t.Error("FAIL")
+	}
+	k.StockRadius = 9
+	if _, err := LatheToolpath(profile, &k); err == nil {
+		t.Error("FAIL")
+	}
+	k.StockRadius = 12
+	dir, err := ioutil.TempDir("", "lathe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := dir + "/shaft.ngc"
+	if err := SaveLatheGCode(path, moves, &k); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadFile(path)
+	if !strings.Contains(string(b), "G7") || !strings.Contains(string(b), "G0 X28.000") {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Polygonize2D(t *testing.T) {
+	// square plate with a round hole
+	const tol = 0.01
+	s := Difference2D(Box2D(V2{40, 40}, 0), Circle2D(10))
+	polygons, err := Polygonize2D(s, 100, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(polygons) != 2 {
+		t.Fatalf("FAIL %d polygons", len(polygons))
+	}
+	var outer, hole []V2
+	for _, v := range polygons {
+		if loopArea(v) > 0 {
+			outer = v
+		} else {
+			hole = v
+		}
+	}
+	if outer == nil || hole == nil {
+		t.Fatal("FAIL orientation")
+	}
+	t.Logf("outer %d vertices, hole %d vertices", len(outer), len(hole))
+	// sparse on the straight edges, dense on the curve
+	if len(outer) > 24 || len(hole) < 40 {
+		t.Error("FAIL")
+	}
+	for i := range hole {
+		m := hole[i].Add(hole[(i+1)%len(hole)]).MulScalar(0.5)
+		if Abs(hole[i].Length()-10) > 0.5*tol || Abs(m.Length()-10) > tol {
+			t.Errorf("FAIL %v", hole[i])
+			break
+		}
+	}
+	if !EqualFloat64(Abs(loopArea(hole)), Pi*100, 2e-3) {
+		t.Error("FAIL")
+	}
+	// adaptive curve sampling
+	v := AdaptiveCurve(func(t float64) V2 { return PolarToXY(1, t) }, 0, Pi, 1e-3)
+	if !v[0].Equals(V2{1, 0}, tolerance) || !v[len(v)-1].Equals(V2{-1, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+	// adaptive involute flanks
+	n, m := 20, 2.0
+	rp := 0.5 * float64(n) * m
+	rb := rp * math.Cos(DtoR(20))
+	fine := InvoluteGearTooth(n, m, rp-1.25*m, rb, rp+m, 0, 2000)
+	adaptive := InvoluteGearTooth(n, m, rp-1.25*m, rb, rp+m, 0, 0)
+	for i := 0; i < 100; i++ {
+		r := rb + (rp+m-rb)*float64(i)/100
+		// find the flank at this radius
+		lo, hi := 0.0, 0.5
+		for j := 0; j < 50; j++ {
+			a := 0.5 * (lo + hi)
+			if fine.Evaluate(PolarToXY(r, a)) < 0 {
+				lo = a
+			} else {
+				hi = a
+			}
+		}
+		if d := adaptive.Evaluate(PolarToXY(r, lo)); Abs(d) > 1e-3*m {
+			t.Errorf("FAIL r %f d %f", r, d)
+			break
+		}
+	}
+	dir, err := ioutil.TempDir("", "polygonize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := RenderSVGAdaptive(s, 100, tol, dir+"/plate.svg", "fill:none;stroke:black"); err != nil {
+		t.Error(err)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_GearRackRounded(t *testing.T) {
+	sharp := GearRack2D(10, 2, DtoR(20), 0, 5)
+	k := GearRackParms{
+		NumberTeeth:   10,
+		Module:        2,
+		PressureAngle: DtoR(20),
+		BaseHeight:    5,
+		TipRadius:     0.3,
+		RootRadius:    0.5,
+		Facets:        8,
+	}
+	s, err := GearRackRounded2D(&k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same away from the corners
+	for _, p := range []V2{{0, 8}, {1.5, 7}, {3.1, 6}, {-20, 2}, {0, 12}} {
+		if Abs(s.Evaluate(p)-sharp.Evaluate(p)) > 1e-9 {
+			t.Errorf("FAIL %v", p)
+		}
+	}
+	// the tip corner (0.752, 9.5) is rounded off, the root corner (2.390, 5) is filled
+	tip, root := V2{0.74, 9.49}, V2{2.395, 5.01}
+	if sharp.Evaluate(tip) >= 0 || s.Evaluate(tip) <= 0 {
+		t.Error("FAIL")
+	}
+	if sharp.Evaluate(root) <= 0 || s.Evaluate(root) >= 0 {
+		t.Error("FAIL")
+	}
+	// sharp corners with zero radii
+	k.TipRadius, k.RootRadius = 0, 0
+	s, _ = GearRackRounded2D(&k)
+	if Abs(s.Evaluate(tip)-sharp.Evaluate(tip)) > 1e-9 {
+		t.Error("FAIL")
+	}
+	for _, r := range []V2{{2, 0}, {0, 2}, {-1, 0}, {1.5, 1.5}} {
+		k.TipRadius, k.RootRadius = r.X, r.Y
+		if _, err := GearRackRounded2D(&k); err == nil {
+			t.Errorf("FAIL %v", r)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Check(t *testing.T) {
+	kinds := func(w []CheckWarning) string {
+		var k []string
+		for _, x := range w {
+			t.Logf("%s", x.String())
+			k = append(k, x.Kind)
+		}
+		return strings.Join(k, ",")
+	}
+	// no problems
+	if k := kinds(Check(Union3D(Sphere3D(5), Box3D(V3{4, 4, 20}, 1)))); k != "" {
+		t.Error("FAIL")
+	}
+	// non-uniform scaling, reported at the scaled node only
+	s := Union3D(Sphere3D(5), Transform3D(Sphere3D(5), Scale3d(V3{0.25, 1, 1})))
+	w := Check(s)
+	if kinds(w) != "lipschitz" || w[0].Path != "UnionSDF3/TransformSDF3[1]" {
+		t.Error("FAIL")
+	}
+	if !strings.Contains(w[0].Message, "3.9") {
+		t.Error("FAIL")
+	}
+	// normalizing fixes it
+	if k := kinds(Check(Normalize3D(s, 4))); k != "" {
+		t.Error("FAIL")
+	}
+	// far apart shapes
+	s = Union3D(Sphere3D(1), Transform3D(Sphere3D(1), Translate3d(V3{500, 500, 0})))
+	if kinds(Check(s)) != "sparse" {
+		t.Error("FAIL")
+	}
+	// inverted and bad bounding boxes
+	if kinds(Check(Extrude3D(Circle2D(1), -1))) != "box" {
+		t.Error("FAIL")
+	}
+	if kinds(Check(Sphere3D(math.NaN()))) != "nan" {
+		t.Error("FAIL")
+	}
+	// 2D
+	if kinds(Check2D(Union2D(Circle2D(1), Transform2D(Circle2D(1), Translate2d(V2{1000, 0}))))) != "sparse" {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_TextSFNT(t *testing.T) {
+	dir, err := ioutil.TempDir("", "font")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := dir + "/goregular.ttf"
+	if err := ioutil.WriteFile(path, goregular.TTF, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadFontSFNT(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the hole in the o
+	o, err := TextSFNT2D(f, NewText("o"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := o.BoundingBox()
+	c := bb.Center()
+	if o.Evaluate(c) <= 0 || o.Evaluate(V2{bb.Min.X + 0.05*bb.Size().X, c.Y}) >= 0 {
+		t.Error("FAIL")
+	}
+	// the same as the freetype text
+	ft, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := NewText("Hi, Wily Fitz! 17 $%")
+	s0, err := TextSDF2(ft, text, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := TextSFNT2D(f, text, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the sizes are for different line heights
+	bb0, bb1 := s0.BoundingBox(), s1.BoundingBox()
+	s1 = ScaleUniform2D(s1, bb0.Size().X/bb1.Size().X)
+	bb1 = s1.BoundingBox()
+	t.Logf("%v %v", bb0, bb1)
+	if !bb0.Min.Equals(bb1.Min, 0.05) || !bb0.Max.Equals(bb1.Max, 0.05) {
+		t.Error("FAIL")
+	}
+	n, bad := 0, 0
+	for i := 0; i < 200; i++ {
+		for j := 0; j < 40; j++ {
+			p := V2{Mix(bb0.Min.X, bb0.Max.X, float64(i)/200), Mix(bb0.Min.Y, bb0.Max.Y, float64(j)/40)}
+			d0, d1 := s0.Evaluate(p), s1.Evaluate(p)
+			if Abs(d0) < 0.05 {
+				continue
+			}
+			n++
+			if (d0 < 0) != (d1 < 0) {
+				bad++
+			}
+		}
+	}
+	t.Logf("%d/%d differ", bad, n)
+	if bad > n/200 {
+		t.Error("FAIL")
+	}
+	if _, err := TextSFNT2D(f, NewText(" "), 10); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Path(t *testing.T) {
+	// open 2D path: a 10x10 L shape
+	p, err := NewPath2([]V2{{0, 0}, {10, 0}, {10, 0}, {10, 10}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(p.Length(), 20, tolerance) {
+		t.Error("FAIL")
+	}
+	if !p.Point(15).Equals(V2{10, 5}, tolerance) || !p.Point(-1).Equals(V2{0, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+	if !p.Tangent(5).Equals(V2{1, 0}, tolerance) || !p.Normal(15).Equals(V2{-1, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+	if x, d := p.Closest(V2{12, 4}); !EqualFloat64(x, 14, tolerance) || !EqualFloat64(d, 2, tolerance) {
+		t.Error("FAIL")
+	}
+	// offset to the left (inside the corner)
+	o, err := p.Offset(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := o.Points()
+	if len(v) != 3 || !v[0].Equals(V2{0, 1}, tolerance) || !v[1].Equals(V2{9, 1}, tolerance) || !v[2].Equals(V2{9, 10}, tolerance) {
+		t.Logf("offset %v", v)
+		t.Error("FAIL")
+	}
+
+	// closed 2D path: a square, arc lengths wrap
+	p, err = NewPath2([]V2{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(p.Length(), 16, tolerance) || !p.Point(18).Equals(V2{2, 0}, tolerance) || !p.Point(-2).Equals(V2{0, 2}, tolerance) {
+		t.Error("FAIL")
+	}
+	o, _ = p.Offset(-1)
+	if !EqualFloat64(o.Length(), 24, tolerance) {
+		t.Error("FAIL")
+	}
+
+	// paths from the polygon builder and arcs
+	poly := NewPolygon()
+	poly.Add(0, 0)
+	poly.Add(10, 0).Smooth(2, 8)
+	poly.Add(10, 10)
+	p, err = PolygonPath2(poly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Length() >= 20 || p.Length() < 20-4+Pi-0.1 {
+		t.Error("FAIL")
+	}
+	p, err = ArcPath2(V2{1, 1}, 5, 0, Pi, 180)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Abs(p.Length()-5*Pi) > 1e-3 || !p.Point(0.5*p.Length()).Equals(V2{1, 6}, 1e-6) || !p.Normal(0).Equals(V2{-1, 0}, 1e-2) {
+		t.Error("FAIL")
+	}
+
+	// 3D path frames
+	p3, err := NewPath3([]V3{{0, 0, 0}, {0, 0, 10}, {10, 0, 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p3.Normal(5).Equals(V3{1, 0, 0}, tolerance) || !p3.Binormal(5).Equals(V3{0, 1, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+	// the frame turns with the path
+	if !p3.Tangent(15).Equals(V3{1, 0, 0}, tolerance) || !p3.Normal(15).Equals(V3{0, 0, -1}, tolerance) {
+		t.Error("FAIL")
+	}
+	if !p3.Frame(15).MulPosition(V3{1, 2, 3}).Equals(V3{8, 2, 9}, tolerance) {
+		t.Error("FAIL")
+	}
+	o3, _ := p3.Offset(V2{0, 1})
+	if !EqualFloat64(o3.Length(), 20, tolerance) || !o3.Point(0).Equals(V3{0, 1, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+
+	// tube
+	tube, err := Tube3D(p3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(tube.Evaluate(V3{0, 0, 5}), -1, tolerance) || !EqualFloat64(tube.Evaluate(V3{5, 3, 10}), 2, tolerance) {
+		t.Error("FAIL")
+	}
+
+	// distribute along a path
+	s, err := DistributePath2D(Box2D(V2{2, 1}, 0), p, 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the middle copy is rotated to the path tangent at the top of the arc
+	if s.Evaluate(V2{1, 6}) >= 0 || s.Evaluate(V2{1.8, 6}) >= 0 || s.Evaluate(V2{1, 6.8}) <= 0 {
+		t.Error("FAIL")
+	}
+	s3, err := DistributePath3D(Sphere3D(1), p3, 5, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(s3.Evaluate(V3{0, 0, 5}), -1, tolerance) || !EqualFloat64(s3.Evaluate(V3{10, 0, 10}), -1, tolerance) {
+		t.Error("FAIL")
+	}
+
+	if _, err := NewPath2([]V2{{1, 1}, {1, 1}}, false); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := Tube3D(p3, 0); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_TextPath(t *testing.T) {
+	f, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// glyph spacing of the test string on the path
+	h := 5.0
+	gs, l, err := lineGlyphs(f, "III")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale := fixed.Int26_6(f.FUnitsPerEm())
+	ah := float64(f.VMetric(scale, f.Index('\n')).AdvanceHeight)
+	dx := (gs[1].x - gs[0].x) * h / ah
+	if l <= 0 {
+		t.Fatal("FAIL")
+	}
+
+	// clockwise arc over the top of a circle, the text is on the outside
+	r := 20.0
+	arc, err := ArcPath2(V2{}, r, 0.75*Pi, 0.25*Pi, 360)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s0, err := TextPath2D(f, NewText("III"), h, arc, 0.5*arc.Length())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := TextSFNTPath2D(sf, NewText("III"), h, arc, 0.5*arc.Length())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the stems are radial
+	for _, a := range []float64{-dx / r, 0, dx / r} {
+		a += 0.5 * Pi
+		if s0.Evaluate(PolarToXY(r+1, a)) >= 0 || s0.Evaluate(PolarToXY(r+2, a)) >= 0 {
+			t.Logf("no stem at %f degrees", RtoD(a))
+			t.Error("FAIL")
+		}
+		if s0.Evaluate(PolarToXY(r-0.5, a)) <= 0 || s0.Evaluate(PolarToXY(r+h, a)) <= 0 {
+			t.Error("FAIL")
+		}
+	}
+	// between the stems
+	if s0.Evaluate(PolarToXY(r+1, 0.5*Pi+0.5*dx/r)) <= 0 {
+		t.Error("FAIL")
+	}
+	// the sfnt line height differs, but the middle glyph is at the same place
+	if s1.Evaluate(V2{0, r + 1}) >= 0 || s1.Evaluate(V2{0, r - 0.5}) <= 0 {
+		t.Error("FAIL")
+	}
+
+	// the text doesn't fit on a short open path
+	line, _ := NewPath2([]V2{{0, 0}, {dx, 0}}, false)
+	if _, err := TextPath2D(f, NewText("III"), h, line, 0.5*dx); err == nil {
+		t.Error("FAIL")
+	}
+	// but wraps around a closed path
+	circle, _ := ArcPath2(V2{}, r, 0, Tau, 360)
+	circle, _ = NewPath2(circle.Points(), true)
+	if _, err := TextPath2D(f, NewText("III"), h, circle, 0); err != nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Drawing(t *testing.T) {
+	// projections
+	p := Project2D(Transform3D(Sphere3D(5), Translate3d(V3{0, 0, 20})), V3{1, 0, 0}, V3{0, 1, 0})
+	if d := p.Evaluate(V2{8, 0}); d < 3 || d > 3.03 {
+		t.Error("FAIL")
+	}
+	if d := p.Evaluate(V2{0, 0}); d < -5 || d > -4.5 {
+		t.Error("FAIL")
+	}
+	p = Project2D(Box3D(V3{40, 20, 10}, 0), V3{0, 1, 0}, V3{0, 0, 1})
+	bb := p.BoundingBox()
+	if !bb.Size().Equals(V2{20, 10}, tolerance) || Abs(p.Evaluate(V2{12, 0})-2) > 0.02 {
+		t.Error("FAIL")
+	}
+
+	// a plate with a hole
+	part := Difference3D(Box3D(V3{40, 20, 10}, 0), Cylinder3D(20, 5, 0))
+	d, err := NewDrawing(part, &DrawingParms{
+		Title:     "plate",
+		MeshCells: 100,
+		Sections:  []DrawingSection{{"A", V3{}, V3{0, 0, 1}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Pages() != 2 {
+		t.Error("FAIL")
+	}
+	// the top view and the section have a hole
+	for i, n := range []int{4, 2} {
+		count := 0
+		for _, x := range d.pages[i].paths {
+			if x.layer == layerOutline {
+				count++
+			}
+		}
+		if count != n {
+			t.Logf("page %d has %d outlines", i+1, count)
+			t.Error("FAIL")
+		}
+	}
+	dir, err := ioutil.TempDir("", "drawing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := d.SaveSVG(dir + "/plate.svg"); err != nil {
+		t.Error(err)
+	}
+	if err := d.SavePDF(dir + "/plate.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(dir + "/plate.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf := string(b)
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") || !strings.Contains(pdf, "/Count 2") {
+		t.Error("FAIL")
+	}
+	// overall and section dimensions
+	for _, s := range []string{"(40.00) Tj", "(20.00) Tj", "(10.00) Tj", "(SECTION A-A) Tj", "(plate  40.00 x 20.00 x 10.00  page 2/2) Tj"} {
+		if !strings.Contains(pdf, s) {
+			t.Logf("missing %s", s)
+			t.Error("FAIL")
+		}
+	}
+	// the cross reference table points at the objects
+	i := strings.LastIndex(pdf, "startxref\n")
+	var xref int
+	fmt.Sscanf(pdf[i+len("startxref\n"):], "%d", &xref)
+	if !strings.HasPrefix(pdf[xref:], "xref") {
+		t.Error("FAIL")
+	}
+	var ofs int
+	fmt.Sscanf(pdf[xref+len("xref\n0 8\n0000000000 65535 f \n"):], "%d", &ofs)
+	if !strings.HasPrefix(pdf[ofs:], "1 0 obj") {
+		t.Error("FAIL")
+	}
+
+	if _, err := NewDrawing(part, &DrawingParms{Sections: []DrawingSection{{"B", V3{0, 0, 20}, V3{0, 0, 1}}}}); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_PoissonSpheres(t *testing.T) {
+	// equal spheres in a sphere
+	k := PoissonParms{
+		MinRadius: 1,
+		Gap:       0.2,
+		Margin:    0.5,
+		Seed:      1,
+	}
+	s := Sphere3D(10)
+	c, r, err := PoissonSpheres3D(s, &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volume := 0.0
+	for i := range c {
+		if r[i] != 1 || c[i].Length()+r[i] > 9.5+1e-9 {
+			t.Error("FAIL")
+		}
+		for j := 0; j < i; j++ {
+			if c[i].Sub(c[j]).Length() < r[i]+r[j]+0.2 {
+				t.Error("FAIL")
+			}
+		}
+		volume += 4.0 / 3.0 * Pi * r[i] * r[i] * r[i]
+	}
+	// the spheres are dense (relative to the volume for the centers)
+	fill := volume / (4.0 / 3.0 * Pi * 8.5 * 8.5 * 8.5)
+	t.Logf("%d spheres, fill %.2f", len(c), fill)
+	if fill < 0.2 {
+		t.Error("FAIL")
+	}
+	// the same seed gives the same spheres
+	c1, _, _ := PoissonSpheres3D(s, &k)
+	if len(c1) != len(c) || !c1[len(c)-1].Equals(c[len(c)-1], tolerance) {
+		t.Error("FAIL")
+	}
+
+	// random radii, a maximum count and two separate parts
+	s = Union3D(Box3D(V3{10, 10, 10}, 0), Transform3D(Box3D(V3{10, 10, 10}, 0), Translate3d(V3{30, 0, 0})))
+	k = PoissonParms{MinRadius: 0.5, MaxRadius: 1.5, Seed: 2}
+	c, r, err = PoissonSpheres3D(s, &k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := [2]int{}
+	for i := range c {
+		if r[i] < 0.5 || r[i] > 1.5 || s.Evaluate(c[i]) > -r[i] {
+			t.Error("FAIL")
+		}
+		n[int(c[i].X/30+0.5)]++
+	}
+	if n[0] == 0 || n[1] == 0 {
+		t.Error("FAIL")
+	}
+	k.MaxCount = 10
+	if c, _, _ = PoissonSpheres3D(s, &k); len(c) != 10 {
+		t.Error("FAIL")
+	}
+
+	if _, _, err := PoissonSpheres3D(Sphere3D(1), &PoissonParms{MinRadius: 2}); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_FitTo(t *testing.T) {
+	// a 10x20x5 box into a 40x40x40 box centered at (100,0,0) with 5 of padding
+	s := Transform3D(Box3D(V3{10, 20, 5}, 0), Translate3d(V3{-3, 7, 1}))
+	box := NewBox3(V3{100, 0, 0}, V3{40, 40, 40})
+	f, err := FitTo(s, box, FitKeepAspect, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := f.BoundingBox()
+	if !bb.Center().Equals(V3{100, 0, 0}, tolerance) || !bb.Size().Equals(V3{15, 30, 7.5}, tolerance) {
+		t.Logf("%v", bb)
+		t.Error("FAIL")
+	}
+	// the distance is scaled
+	if !EqualFloat64(f.Evaluate(V3{100, 0, 10}), 6.25, tolerance) {
+		t.Error("FAIL")
+	}
+
+	f, err = FitTo(s, box, FitStretch, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb = f.BoundingBox()
+	if !bb.Center().Equals(V3{100, 0, 0}, tolerance) || !bb.Size().Equals(V3{30, 30, 30}, tolerance) {
+		t.Error("FAIL")
+	}
+	// the distance is a lower bound (scaled by the smallest factor)
+	if d := f.Evaluate(V3{100, 0, 20}); d > 5+tolerance || d < 5*1.5/6-tolerance {
+		t.Error("FAIL")
+	}
+
+	// flat 2D art keeps its aspect ratio
+	g, err := FitTo2D(Box2D(V2{4, 2}, 0), NewBox2(V2{}, V2{100, 100}), FitKeepAspect, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.BoundingBox().Size().Equals(V2{100, 50}, tolerance) || !EqualFloat64(g.Evaluate(V2{0, 0}), -25, tolerance) {
+		t.Error("FAIL")
+	}
+
+	if _, err := FitTo(s, box, FitKeepAspect, 20); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_CheckedConstructors(t *testing.T) {
+	// bad parameters give errors
+	bad := []error{}
+	add := func(err error) { bad = append(bad, err) }
+	_, err := CheckedCircle2D(-1)
+	add(err)
+	_, err = CheckedBox2D(V2{1, 2}, 0.6)
+	add(err)
+	_, err = CheckedPolygon2D([]V2{{0, 0}, {1, 0}, {2, 0}})
+	add(err)
+	_, err = CheckedCylinder3D(1, 2, 0.6)
+	add(err)
+	_, err = CheckedExtrudeRounded3D(Circle2D(1), 1, 0.6)
+	add(err)
+	_, err = CheckedCone3D(1, 0, 0, 0)
+	add(err)
+	_, err = CheckedFlatFlankCam2D(10, 5, 20)
+	add(err)
+	_, err = CheckedThreeArcCam2D(10, 5, 2, 5)
+	add(err)
+	_, err = CheckedInvoluteGear(20, 1, DtoR(20), 2, 0, 0, 0)
+	add(err)
+	_, err = CheckedWasher3D(&WasherParms{Thickness: 1, InnerRadius: 2, OuterRadius: 1})
+	add(err)
+	_, _, err = MakeGenevaCam(6, 100, 50, 50, 5, 60)
+	add(err)
+	for i, err := range bad {
+		if err == nil {
+			t.Errorf("FAIL %d: no error", i)
+		} else {
+			t.Logf("%d: %s", i, err)
+		}
+	}
+
+	// good parameters give the same shape as the unchecked constructors
+	s0, err := CheckedCylinder3D(2, 1, 0.1)
+	if err != nil {
+		t.Error("FAIL")
+	}
+	s1 := Cylinder3D(2, 1, 0.1)
+	for _, p := range []V3{{0, 0, 0}, {1, 0, 1}, {0.5, 0.5, 2}} {
+		if s0.Evaluate(p) != s1.Evaluate(p) {
+			t.Error("FAIL")
+		}
+	}
+	if _, err := CheckedThreeArcCam2D(30, 20, 5, 60); err != nil {
+		t.Error("FAIL")
+	}
+	if _, err := MakeThreeArcCam(0.1, DtoR(160), 0.7, 1.1); err != nil {
+		t.Error("FAIL")
+	}
+	if _, err := MakeFlatFlankCam(0.094, DtoR(115), 0.625); err != nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Ellipse2D(t *testing.T) {
+	for _, ab := range []V2{{3, 1}, {1, 2}, {2, 2}, {10, 0.1}} {
+		s, err := Ellipse2D(ab.X, ab.Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// brute force distance to points on the ellipse
+		n := 20000
+		pts := make([]V2, n)
+		for i := range pts {
+			theta := Tau * float64(i) / float64(n)
+			pts[i] = V2{ab.X * math.Cos(theta), ab.Y * math.Sin(theta)}
+		}
+		bb := s.BoundingBox().ScaleAboutCenter(1.5)
+		for _, p := range bb.RandomSet(500) {
+			d := math.MaxFloat64
+			for _, q := range pts {
+				d = Min(d, p.Sub(q).Length())
+			}
+			if (p.X/ab.X)*(p.X/ab.X)+(p.Y/ab.Y)*(p.Y/ab.Y) < 1 {
+				d = -d
+			}
+			if Abs(s.Evaluate(p)-d) > 1e-3*ab.MaxComponent() {
+				t.Errorf("FAIL %v %v: %f != %f", ab, p, s.Evaluate(p), d)
+				break
+			}
+		}
+	}
+	if _, err := Ellipse2D(1, 0); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Star2D(t *testing.T) {
+	// compare with polygons made from the vertices
+	star := func(n int, r0, r1 float64) []V2 {
+		v := make([]V2, 2*n)
+		for i := range v {
+			r := r0
+			if i&1 != 0 {
+				r = r1
+			}
+			v[i] = PolarToXY(r, Pi*float64(i)/float64(n))
+		}
+		return v
+	}
+	test := func(s0, s1 SDF2) {
+		if !s0.BoundingBox().Equals(s1.BoundingBox(), tolerance) {
+			t.Errorf("FAIL %v %v", s0.BoundingBox(), s1.BoundingBox())
+		}
+		bb := s1.BoundingBox().ScaleAboutCenter(1.5)
+		for _, p := range bb.RandomSet(500) {
+			if Abs(s0.Evaluate(p)-s1.Evaluate(p)) > tolerance {
+				t.Errorf("FAIL %v: %f != %f", p, s0.Evaluate(p), s1.Evaluate(p))
+				return
+			}
+		}
+	}
+	for _, n := range []int{3, 4, 5, 6, 12} {
+		s, err := RegularPolygon2D(n, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test(s, Polygon2D(Nagon(n, 2)))
+	}
+	for _, n := range []int{2, 5, 7} {
+		s, err := Star2D(n, 3, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test(s, Polygon2D(star(n, 3, 1)))
+	}
+	if _, err := Star2D(5, 1, 1); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := RegularPolygon2D(2, 1); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_SlotShapes(t *testing.T) {
+	// brute force distance to points on the boundary
+	test := func(s SDF2, boundary []V2, inside func(p V2) bool) {
+		bb := s.BoundingBox().ScaleAboutCenter(1.5)
+		for _, p := range bb.RandomSet(500) {
+			d := math.MaxFloat64
+			for _, q := range boundary {
+				d = Min(d, p.Sub(q).Length())
+			}
+			if inside(p) {
+				d = -d
+			}
+			if Abs(s.Evaluate(p)-d) > 1e-3 {
+				t.Errorf("FAIL %v: %f != %f", p, s.Evaluate(p), d)
+				return
+			}
+		}
+	}
+	arc := func(c V2, r, a0, a1 float64, n int) []V2 {
+		v := make([]V2, n+1)
+		for i := range v {
+			v[i] = c.Add(PolarToXY(r, Mix(a0, a1, float64(i)/float64(n))))
+		}
+		return v
+	}
+	line := func(a, b V2, n int) []V2 {
+		v := make([]V2, n+1)
+		for i := range v {
+			v[i] = a.Add(b.Sub(a).MulScalar(float64(i) / float64(n)))
+		}
+		return v
+	}
+
+	// slot
+	s, err := Slot2D(10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.BoundingBox().Equals(Box2{V2{-5, -2}, V2{5, 2}}, tolerance) {
+		t.Error("FAIL")
+	}
+
+	// D-shapes
+	for _, h := range []float64{1.5, -0.5} {
+		s, err := DShape2D(2, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a := math.Asin(h / 2)
+		w := 2 * math.Cos(a)
+		b := append(arc(V2{}, 2, Pi-a, Tau+a, 20000), line(V2{-w, h}, V2{w, h}, 4000)...)
+		test(s, b, func(p V2) bool { return p.Length() < 2 && p.Y < h })
+	}
+
+	// keyhole
+	s, err = Keyhole2D(3, 1, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := math.Asin(1.0 / 3)
+	y := 3 * math.Cos(a)
+	b := arc(V2{}, 3, Pi/2+a, Tau+Pi/2-a, 20000)
+	b = append(b, arc(V2{0, 6}, 1, 0, Pi, 4000)...)
+	b = append(b, line(V2{1, y}, V2{1, 6}, 4000)...)
+	b = append(b, line(V2{-1, y}, V2{-1, 6}, 4000)...)
+	test(s, b, func(p V2) bool {
+		return p.Length() < 3 || p.Sub(V2{0, 6}).Length() < 1 || (Abs(p.X) < 1 && p.Y > 0 && p.Y < 6)
+	})
+	if _, err := Keyhole2D(3, 1, 2); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := DShape2D(2, 2); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_AnnularSector2D(t *testing.T) {
+	for _, k := range [][4]float64{
+		{1, 3, DtoR(30), DtoR(120)},
+		{0, 2, DtoR(-45), DtoR(200)},
+		{1, 2, DtoR(300), DtoR(60)},
+		{0.5, 2, DtoR(10), DtoR(350)},
+	} {
+		r0, r1, a0, a1 := k[0], k[1], k[2], k[3]
+		s, err := AnnularSector2D(r0, r1, a0, a1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		span := math.Mod(a1-a0+Tau, Tau)
+		// brute force distance to points on the boundary
+		var b []V2
+		n := 10000
+		for i := 0; i <= n; i++ {
+			a := a0 + span*float64(i)/float64(n)
+			x := float64(i) / float64(n)
+			b = append(b, PolarToXY(r0, a), PolarToXY(r1, a))
+			b = append(b, PolarToXY(Mix(r0, r1, x), a0), PolarToXY(Mix(r0, r1, x), a1))
+		}
+		v := V2Set(b)
+		if !s.BoundingBox().Equals(Box2{v.Min(), v.Max()}, 1e-3) {
+			t.Errorf("FAIL %v %v", s.BoundingBox(), Box2{v.Min(), v.Max()})
+		}
+		bb := s.BoundingBox().ScaleAboutCenter(1.5)
+		for _, p := range bb.RandomSet(500) {
+			d := math.MaxFloat64
+			for _, q := range b {
+				d = Min(d, p.Sub(q).Length())
+			}
+			a := math.Mod(math.Atan2(p.Y, p.X)-a0+2*Tau, Tau)
+			if l := p.Length(); l > r0 && l < r1 && a < span {
+				d = -d
+			}
+			if Abs(s.Evaluate(p)-d) > 1e-3 {
+				t.Errorf("FAIL %v %v: %f != %f", k, p, s.Evaluate(p), d)
+				break
+			}
+		}
+	}
+	if _, err := AnnularSector2D(2, 1, 0, 1); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_NURBS(t *testing.T) {
+	// a circle as a rational quadratic curve
+	w := math.Sqrt2 / 2
+	control := []V2{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}, {1, 0}}
+	weights := []float64{1, w, 1, w, 1, w, 1, w, 1}
+	knots := []float64{0, 0, 0, 0.25, 0.25, 0.5, 0.5, 0.75, 0.75, 1, 1, 1}
+	for i := range control {
+		control[i] = control[i].MulScalar(2)
+	}
+	c, err := NewNURBS(2, control, weights, knots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= 100; i++ {
+		if Abs(c.Point(float64(i)/100).Length()-2) > 1e-12 {
+			t.Error("FAIL")
+		}
+	}
+	tol := 1e-3
+	for _, p := range c.Polyline(tol) {
+		if Abs(p.Length()-2) > 1e-12 {
+			t.Error("FAIL")
+		}
+	}
+	s, err := NURBS2D([]*NURBS{c}, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	circle := Circle2D(2)
+	bb := s.BoundingBox().ScaleAboutCenter(1.5)
+	for _, p := range bb.RandomSet(200) {
+		if Abs(s.Evaluate(p)-circle.Evaluate(p)) > tol {
+			t.Errorf("FAIL %v: %f != %f", p, s.Evaluate(p), circle.Evaluate(p))
+			break
+		}
+	}
+
+	// clamped B-splines start and end on the control points
+	b, err := NewNURBS(3, []V2{{0, 0}, {1, 2}, {2, -1}, {3, 1}, {4, 0}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Point(0).Equals(V2{0, 0}, tolerance) || !b.Point(1).Equals(V2{4, 0}, tolerance) {
+		t.Error("FAIL")
+	}
+
+	// a half disk from a line and a reversed arc
+	line, _ := NewNURBS(1, []V2{{-1, 0}, {1, 0}}, nil, nil)
+	arc, _ := NewNURBS(2, []V2{{-1, 0}, {-1, 1}, {0, 1}, {1, 1}, {1, 0}}, []float64{1, w, 1, w, 1}, []float64{0, 0, 0, 0.5, 0.5, 1, 1, 1})
+	s, err = NURBS2D([]*NURBS{line, arc}, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Evaluate(V2{0, 0.5}) >= 0 || s.Evaluate(V2{0, -0.5}) <= 0 || Abs(s.Evaluate(V2{0, 2})-1) > tol {
+		t.Error("FAIL")
+	}
+
+	// bad parameters
+	if _, err := NewNURBS(3, []V2{{0, 0}, {1, 1}, {2, 0}}, nil, nil); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := NURBS2D([]*NURBS{b}, tol); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_ExactOffset2D(t *testing.T) {
+	tol := 1e-3
+	// a box with a distance field that is too large
+	s := Normalize2D(Box2D(V2{2, 2}, 0), 0.5)
+	for _, k := range []struct {
+		offset float64
+		exact  SDF2
+	}{
+		{0.5, Box2D(V2{3, 3}, 0.5)},
+		{-0.5, Box2D(V2{1, 1}, 0)},
+	} {
+		s1, err := ExactOffset2D(s, k.offset, 100, tol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bb := k.exact.BoundingBox().ScaleAboutCenter(1.5)
+		for _, p := range bb.RandomSet(500) {
+			// the polygon corners are within the mesh cell size of the exact corners
+			if Abs(s1.Evaluate(p)-k.exact.Evaluate(p)) > 0.03 {
+				t.Errorf("FAIL %v %v: %f != %f", k.offset, p, s1.Evaluate(p), k.exact.Evaluate(p))
+				break
+			}
+		}
+		// away from the corners the boundary is within the tolerance
+		for _, p := range []V2{{0, 1 + k.offset}, {1 + k.offset, 0.2}, {-0.3, -1 - k.offset}} {
+			if Abs(s1.Evaluate(p)) > tol {
+				t.Errorf("FAIL %v %v: %f", k.offset, p, s1.Evaluate(p))
+			}
+		}
+	}
+
+	// a shape with a hole
+	ring := Difference2D(Circle2D(2), Circle2D(1))
+	s2, err := ExactOffset2D(ring, 0.25, 100, tol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []float64{0, 0.5, 1, 1.5, 2, 3} {
+		d := Max(x-2.25, 0.75-x)
+		if Abs(s2.Evaluate(V2{x, 0})-d) > tol {
+			t.Errorf("FAIL %f: %f != %f", x, s2.Evaluate(V2{x, 0}), d)
+		}
+	}
+
+	if _, err := ExactOffset2D(Circle2D(1), -1.5, 100, tol); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_Capsule(t *testing.T) {
+	// capsules on the z-axis
+	s0 := Capsule3D(0.3, 1.4)
+	s1, err := LineCapsule3D(V3{0, 0, -0.4}, V3{0, 0, 0.4}, 0.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s0.BoundingBox().Equals(Box3{V3{-0.3, -0.3, -0.7}, V3{0.3, 0.3, 0.7}}, tolerance) {
+		t.Error("FAIL")
+	}
+	bb := s0.BoundingBox().ScaleAboutCenter(1.5)
+	for _, p := range bb.RandomSet(500) {
+		// distance to the line segment less the radius
+		d := p.Sub(V3{0, 0, Clamp(p.Z, -0.4, 0.4)}).Length() - 0.3
+		if Abs(s0.Evaluate(p)-d) > tolerance || Abs(s1.Evaluate(p)-d) > tolerance {
+			t.Errorf("FAIL %v: %f %f != %f", p, s0.Evaluate(p), s1.Evaluate(p), d)
+			break
+		}
+	}
+
+	// a capsule between two points
+	a, b := V3{1, 2, 3}, V3{-2, 0, 4}
+	s2, err := LineCapsule3D(a, b, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb = s2.BoundingBox()
+	if !bb.Equals(Box3{V3{-2.5, -0.5, 2.5}, V3{1.5, 2.5, 4.5}}, tolerance) {
+		t.Errorf("FAIL %v", bb)
+	}
+	bb = bb.ScaleAboutCenter(1.5)
+	for _, p := range bb.RandomSet(500) {
+		v := b.Sub(a)
+		k := Clamp(p.Sub(a).Dot(v)/v.Dot(v), 0, 1)
+		d := p.Sub(a.Add(v.MulScalar(k))).Length() - 0.5
+		if Abs(s2.Evaluate(p)-d) > tolerance {
+			t.Errorf("FAIL %v: %f != %f", p, s2.Evaluate(p), d)
+			break
+		}
+	}
+
+	// a rounded cylinder on the x-axis
+	s3, err := RoundedCylinder3D(V3{-1, 0, 0}, V3{1, 0, 0}, 0.5, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s4 := Transform3D(Cylinder3D(2, 0.5, 0.1), RotateY(DtoR(90)))
+	if !s3.BoundingBox().Equals(Box3{V3{-1, -0.5, -0.5}, V3{1, 0.5, 0.5}}, tolerance) {
+		t.Errorf("FAIL %v", s3.BoundingBox())
+	}
+	bb = s3.BoundingBox().ScaleAboutCenter(1.5)
+	for _, p := range bb.RandomSet(500) {
+		if Abs(s3.Evaluate(p)-s4.Evaluate(p)) > tolerance {
+			t.Errorf("FAIL %v: %f != %f", p, s3.Evaluate(p), s4.Evaluate(p))
+			break
+		}
+	}
+	if _, err := RoundedCylinder3D(V3{}, V3{0, 0, 1}, 0.5, 0.6); err == nil {
+		t.Error("FAIL")
+	}
+	if _, err := RoundedCylinder3D(V3{1, 2, 3}, V3{1, 2, 3}, 0.5, 0); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_InvoluteGearFillet(t *testing.T) {
+	m := 2.0
+	for _, n := range []int{8, 30} {
+		// the exact flanks and the faceted rack generated tooth have the same root fillet
+		// and undercut, they agree to within the facet error
+		s0 := InvoluteGear(n, m, DtoR(20), 0.1, 0.25*m, m, 0)
+		s1 := InvoluteGear(n, m, DtoR(20), 0.1, 0.25*m, m, 40)
+		rp := float64(n) * m / 2
+		for r := rp - 1.5*m; r < rp+1.2*m; r += 0.02 * m {
+			for a := 0.0; a < 2*Pi/float64(n); a += 0.02 / float64(n) {
+				p := PolarToXY(r, a)
+				d0, d1 := s0.Evaluate(p), s1.Evaluate(p)
+				// the inside distance of the faceted gear is a bound
+				if d0 < 0 && d1 < 0 {
+					continue
+				}
+				if Abs(d0-d1) > 0.01*m {
+					t.Logf("n %d %v %f %f", n, p, d0, d1)
+					t.Error("FAIL")
+					return
+				}
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_MeshBoolean(t *testing.T) {
+	m0 := RenderMesh(Box3D(V3{10, 10, 10}, 0), 20)
+	m1 := RenderMesh(Transform3D(Sphere3D(6), Translate3d(V3{5, 0, 0})), 20)
+	tests := []struct {
+		op      BooleanOp
+		in, out []V3
+	}{
+		{BooleanUnion, []V3{{0, 0, 0}, {9, 0, 0}}, []V3{{0, 9, 0}, {12, 0, 0}}},
+		{BooleanDifference, []V3{{-3, 0, 0}, {-4, 4, 4}}, []V3{{4, 0, 0}, {9, 0, 0}}},
+		{BooleanIntersect, []V3{{3, 0, 0}}, []V3{{-3, 0, 0}, {9, 0, 0}}},
+	}
+	for _, test := range tests {
+		m, err := MeshBoolean(m0, m1, test.op, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := Mesh3D(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range test.in {
+			if s.Evaluate(p) >= 0 {
+				t.Logf("op %d %v should be inside", test.op, p)
+				t.Error("FAIL")
+			}
+		}
+		for _, p := range test.out {
+			if s.Evaluate(p) <= 0 {
+				t.Logf("op %d %v should be outside", test.op, p)
+				t.Error("FAIL")
+			}
+		}
+	}
+	// a planar mesh has a zero size bounding box on one axis
+	plane := []*Triangle3{
+		{V: [3]V3{{0, 0, 0}, {10, 0, 0}, {10, 10, 0}}},
+		{V: [3]V3{{0, 0, 0}, {10, 10, 0}, {0, 10, 0}}},
+	}
+	s, err := Mesh3D(plane)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !EqualFloat64(s.Evaluate(V3{5, 5, 3}), 3, 1e-9) {
+		t.Error("FAIL")
+	}
+	if _, err := MeshBoolean(m0, plane, BooleanUnion, 20); err != nil {
+		t.Error("FAIL")
+	}
+	if _, err := Mesh3D([]*Triangle3{{V: [3]V3{{1, 1, 1}, {1, 1, 1}, {1, 1, 1}}}}); err == nil {
+		t.Error("FAIL")
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -29,10 +29,11 @@ func Panel2D(k *PanelParms) SDF2 {
 	}
 
 	// corners
-	tl := V2{-0.5*k.Size.X + k.HoleMargin[3], 0.5*k.Size.Y - k.HoleMargin[0]}
-	tr := V2{0.5*k.Size.X - k.HoleMargin[1], 0.5*k.Size.Y - k.HoleMargin[0]}
-	br := V2{0.5*k.Size.X - k.HoleMargin[1], -0.5*k.Size.Y + k.HoleMargin[2]}
-	bl := V2{-0.5*k.Size.X + k.HoleMargin[3], -0.5*k.Size.Y + k.HoleMargin[2]}
+	x, y := float64(0.5*k.Size.X), float64(0.5*k.Size.Y)
+	tl := V2{-x + k.HoleMargin[3], y - k.HoleMargin[0]}
+	tr := V2{x - k.HoleMargin[1], y - k.HoleMargin[0]}
+	br := V2{x - k.HoleMargin[1], -y + k.HoleMargin[2]}
+	bl := V2{-x + k.HoleMargin[3], -y + k.HoleMargin[2]}
 
 	// holes
 	hole := Circle2D(0.5 * k.HoleDiameter)
@@ -58,7 +59,7 @@ type FingerButtonParms struct {
 
 // FingerButton2D returns a 2D cutout for a finger button.
 func FingerButton2D(k *FingerButtonParms) SDF2 {
-	r0 := 0.5 * k.Width
+	r0 := float64(0.5 * k.Width)
 	r1 := r0 - k.Gap
 	l := 2.0 * k.Length
 	s := Difference2D(Line2D(l, r0), Line2D(l, r1))
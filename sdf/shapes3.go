@@ -58,7 +58,7 @@ func HexHead3D(
 	round string, // (t)top, (b)bottom, (tb)top/bottom
 ) SDF3 {
 	// basic hex body
-	cornerRound := r * 0.08
+	cornerRound := float64(r * 0.08)
 	hex2d := Polygon2D(Nagon(6, r-cornerRound))
 	hex2d = Offset2D(hex2d, cornerRound)
 	hex3d := Extrude3D(hex2d, h)
@@ -67,7 +67,7 @@ func HexHead3D(
 		topRound := r * 1.6
 		d := r * math.Cos(DtoR(30))
 		sphere3d := Sphere3D(topRound)
-		zOfs := math.Sqrt(topRound*topRound-d*d) - h/2
+		zOfs := math.Sqrt(float64(topRound*topRound)-float64(d*d)) - float64(h/2)
 		if round == "t" || round == "tb" {
 			hex3d = Intersect3D(hex3d, Transform3D(sphere3d, Translate3d(V3{0, 0, -zOfs})))
 		}
@@ -85,7 +85,7 @@ func KnurledHead3D(
 	pitch float64, // knurl pitch
 ) SDF3 {
 	theta := DtoR(45)
-	cylinderRound := r * 0.05
+	cylinderRound := float64(r * 0.05)
 	knurlH := pitch * math.Floor((h-cylinderRound)/pitch)
 	knurl3d := Knurl3D(knurlH, r, pitch, pitch*0.3, theta)
 	return Union3D(Cylinder3D(h, r, cylinderRound), knurl3d)
@@ -166,15 +166,32 @@ func Washer3D(k *WasherParms) SDF3 {
 		b := Box2D(V2{dx, dy}, 0)
 		b = Transform2D(b, Translate2d(V2{xofs, 0}))
 		// rotate about the z-axis
-		theta := Tau * (1.0 - k.Remove)
+		theta := float64(Tau * (1.0 - k.Remove))
 		s = RevolveTheta3D(b, theta)
 		// center the removed portion on the x-axis
-		dtheta := 0.5 * (Tau - theta)
+		dtheta := float64(0.5 * (Tau - theta))
 		s = Transform3D(s, RotateZ(dtheta))
 	}
 	return s
 }
 
+// CheckedWasher3D returns a washer, with an error for invalid parameters.
+func CheckedWasher3D(k *WasherParms) (SDF3, error) {
+	if k.Thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	if k.InnerRadius < 0 {
+		return nil, errors.New("inner radius < 0")
+	}
+	if k.InnerRadius >= k.OuterRadius {
+		return nil, errors.New("inner radius >= outer radius")
+	}
+	if k.Remove < 0 || k.Remove >= 1.0 {
+		return nil, errors.New("remove must be [0..1)")
+	}
+	return Washer3D(k), nil
+}
+
 //-----------------------------------------------------------------------------
 // Board standoffs
 
@@ -327,6 +344,7 @@ type BoltParms struct {
 	Tolerance   float64 // subtract from external thread radius
 	TotalLength float64 // threaded length + shank length
 	ShankLength float64 // non threaded length
+	Hand        Hand    // thread hand
 }
 
 // Bolt returns a simple bolt suitable for 3d printing.
@@ -360,7 +378,7 @@ func Bolt(k *BoltParms) (SDF3, error) {
 	}
 
 	// shank
-	shankLength := k.ShankLength + hh/2
+	shankLength := k.ShankLength + float64(hh/2)
 	shankOffset := shankLength / 2
 	shank := Cylinder3D(shankLength, t.Radius, hh*0.08)
 	shank = Transform3D(shank, Translate3d(V3{0, 0, shankOffset}))
@@ -373,8 +391,8 @@ func Bolt(k *BoltParms) (SDF3, error) {
 	var thread SDF3
 	if threadLength != 0 {
 		r := t.Radius - k.Tolerance
-		threadOffset := threadLength/2 + shankLength
-		thread = Screw3D(ISOThread(r, t.Pitch, "external"), threadLength, t.Pitch, 1)
+		threadOffset := float64(threadLength/2) + shankLength
+		thread = Screw3D(ISOThread(r, t.Pitch, "external"), threadLength, t.Pitch, k.Hand.Sign())
 		// chamfer the thread
 		thread = ChamferedCylinder(thread, 0, 0.5)
 		thread = Transform3D(thread, Translate3d(V3{0, 0, threadOffset}))
@@ -391,6 +409,7 @@ type NutParms struct {
 	Thread    string  // name of thread
 	Style     string  // head style "hex" or "knurl"
 	Tolerance float64 // add to internal thread radius
+	Hand      Hand    // thread hand
 }
 
 // Nut returns a simple nut suitable for 3d printing.
@@ -418,7 +437,7 @@ func Nut(k *NutParms) (SDF3, error) {
 	}
 
 	// internal thread
-	thread := Screw3D(ISOThread(t.Radius+k.Tolerance, t.Pitch, "internal"), nh, t.Pitch, 1)
+	thread := Screw3D(ISOThread(t.Radius+k.Tolerance, t.Pitch, "internal"), nh, t.Pitch, k.Hand.Sign())
 
 	return Difference3D(nut, thread), nil
 }
@@ -0,0 +1,261 @@
+//-----------------------------------------------------------------------------
+/*
+
+Sheet Metal
+
+A flat base face (a polygon in the xy plane, extruded upwards by the sheet
+thickness) with flanges bent from its edges. The same part gives the folded
+3D model and the flat pattern (with bend lines) for laser cutting.
+
+Each flange is a bend (angle, inside radius, K-factor) followed by a flat
+of a given length. The bend starts at the base edge and spans the length of
+the edge. Positive bend angles fold the flange up (+z), negative angles fold
+it down.
+
+The flat pattern length of a bend is the bend allowance:
+
+BA = angle * (radius + K * thickness)
+
+The K-factor is the position of the neutral axis (0 = inside surface,
+0.5 = mid thickness), typically 0.3 to 0.5 depending on the material and the
+bend radius. The bend line is the center of the bend allowance.
+
+Flanges on the two edges of a corner are separate (there's a gap or a
+notch at the corner). Flanges on the edges of an acute corner overlap.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// FlangeParms defines the parameters for a sheet metal flange.
+type FlangeParms struct {
+	Edge    int     // base edge index (vertex i to vertex i+1)
+	Angle   float64 // bend angle (radians, > 0 is up, < 0 is down)
+	Radius  float64 // inside bend radius
+	KFactor float64 // position of the neutral axis (0..1 of the thickness)
+	Length  float64 // length of the flat flange after the bend
+}
+
+// sheetFlange is a flange on a base edge.
+type sheetFlange struct {
+	FlangeParms
+	o     V2      // edge midpoint
+	u     V2      // unit vector along the edge
+	n     V2      // outward unit normal
+	h     float64 // half length of the edge
+	c     V2      // bend center (in the u = outward, v = z cross section)
+	rm    float64 // mid thickness bend radius
+	e, t  V2      // end of the bend on the mid thickness, and flange direction
+	fEnd  V2      // end of the flange on the mid thickness
+	allow float64 // bend allowance
+}
+
+// SheetMetal is a sheet metal part.
+type SheetMetal struct {
+	thickness float64
+	base      []V2
+	flanges   []*sheetFlange
+}
+
+// NewSheetMetal returns a sheet metal part with a polygonal base face.
+func NewSheetMetal(
+	base []V2, // base face polygon (in the xy plane)
+	thickness float64, // sheet thickness
+) (*SheetMetal, error) {
+	if len(base) < 3 {
+		return nil, errors.New("base has < 3 vertices")
+	}
+	if thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	if loopArea(base) == 0 {
+		return nil, errors.New("base has zero area")
+	}
+	return &SheetMetal{
+		thickness: thickness,
+		base:      base,
+	}, nil
+}
+
+// Flange adds a flange to an edge of the base face.
+func (s *SheetMetal) Flange(k *FlangeParms) error {
+	if k.Edge < 0 || k.Edge >= len(s.base) {
+		return errors.New("bad edge index")
+	}
+	for _, f := range s.flanges {
+		if f.Edge == k.Edge {
+			return errors.New("edge already has a flange")
+		}
+	}
+	if k.Angle == 0 || Abs(k.Angle) > Pi {
+		return errors.New("bad bend angle")
+	}
+	if k.Radius < 0 {
+		return errors.New("radius < 0")
+	}
+	if k.KFactor < 0 || k.KFactor > 1 {
+		return errors.New("bad k-factor")
+	}
+	if k.Length < 0 {
+		return errors.New("length < 0")
+	}
+	p0 := s.base[k.Edge]
+	p1 := s.base[(k.Edge+1)%len(s.base)]
+	if p0.Equals(p1, tolerance) {
+		return errors.New("zero length edge")
+	}
+	f := sheetFlange{FlangeParms: *k}
+	f.o = p0.Add(p1).MulScalar(0.5)
+	f.u = p1.Sub(p0).Normalize()
+	f.h = 0.5 * p1.Sub(p0).Length()
+	// outward normal (the base may be either winding)
+	f.n = V2{f.u.Y, -f.u.X}
+	if loopArea(s.base) < 0 {
+		f.n = f.n.Neg()
+	}
+	// cross section: outward from the edge, z up
+	sign := 1.0
+	if k.Angle < 0 {
+		sign = -1
+	}
+	t := s.thickness
+	a := Abs(k.Angle)
+	f.rm = k.Radius + 0.5*t
+	f.c = V2{0, 0.5*t + sign*f.rm}
+	sin, cos := math.Sincos(a)
+	f.e = f.c.Add(V2{sin, -sign * cos}.MulScalar(f.rm))
+	f.t = V2{cos, sign * sin}
+	f.fEnd = f.e.Add(f.t.MulScalar(k.Length))
+	f.allow = k.BendAllowance(t)
+	s.flanges = append(s.flanges, &f)
+	return nil
+}
+
+// BendAllowance returns the flat pattern length of a flange bend.
+func (k *FlangeParms) BendAllowance(thickness float64) float64 {
+	return Abs(k.Angle) * (k.Radius + k.KFactor*thickness)
+}
+
+//-----------------------------------------------------------------------------
+// Folded Part
+
+// section returns the distance to the flange cross section (bend and flat).
+func (f *sheetFlange) section(p V2, t float64) float64 {
+	// distance to the bend mid thickness arc
+	q := p.Sub(f.c)
+	sign := 1.0
+	if f.Angle < 0 {
+		sign = -1
+	}
+	phi := math.Atan2(q.X, -sign*q.Y)
+	var d float64
+	if phi >= 0 && phi <= Abs(f.Angle) {
+		d = Abs(q.Length() - f.rm)
+	} else {
+		d = Min(p.Sub(f.e).Length(), p.Sub(V2{0, 0.5 * t}).Length())
+	}
+	// distance to the flat mid thickness
+	d = Min(d, segmentDistance(p, f.e, f.fEnd))
+	// square end
+	return Max(d-0.5*t, p.Sub(f.fEnd).Dot(f.t))
+}
+
+// SheetMetalSDF3 is a folded sheet metal part.
+type SheetMetalSDF3 struct {
+	s    *SheetMetal
+	base SDF3
+	bb   Box3
+}
+
+// Folded3D returns the folded sheet metal part. The base face is on the xy plane (z = 0 to thickness).
+func (s *SheetMetal) Folded3D() SDF3 {
+	t := s.thickness
+	base := Extrude3D(Polygon2D(s.base), t)
+	base = Transform3D(base, Translate3d(V3{0, 0, 0.5 * t}))
+	bb := base.BoundingBox()
+	for _, f := range s.flanges {
+		// bounding box of the cross section (bend and flat)
+		b := Box2{V2{0, 0}, V2{0, t}}
+		r := f.rm + 0.5*t
+		b = b.Extend(Box2{f.c.SubScalar(r), f.c.AddScalar(r)})
+		b = b.Extend(Box2{f.fEnd.SubScalar(0.5 * t), f.fEnd.AddScalar(0.5 * t)})
+		// corners of the swept cross section
+		for _, x := range []float64{b.Min.X, b.Max.X} {
+			for _, h := range []float64{-f.h, f.h} {
+				p := f.o.Add(f.n.MulScalar(x)).Add(f.u.MulScalar(h))
+				bb = bb.Extend(Box3{V3{p.X, p.Y, b.Min.Y}, V3{p.X, p.Y, b.Max.Y}})
+			}
+		}
+	}
+	return &SheetMetalSDF3{
+		s:    s,
+		base: base,
+		bb:   bb,
+	}
+}
+
+// Evaluate returns the minimum distance to a folded sheet metal part.
+func (s *SheetMetalSDF3) Evaluate(p V3) float64 {
+	d := s.base.Evaluate(p)
+	xy := V2{p.X, p.Y}
+	for _, f := range s.s.flanges {
+		q := xy.Sub(f.o)
+		a := f.section(V2{q.Dot(f.n), p.Z}, s.s.thickness)
+		d = Min(d, Max(a, Abs(q.Dot(f.u))-f.h))
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a folded sheet metal part.
+func (s *SheetMetalSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a folded sheet metal part.
+func (s *SheetMetalSDF3) Children() []interface{} { return []interface{}{s.base} }
+
+//-----------------------------------------------------------------------------
+// Flat Pattern
+
+// flat returns the flat pattern rectangle of a flange.
+func (f *sheetFlange) flat() []V2 {
+	l := f.allow + f.Length
+	p0 := f.o.Sub(f.u.MulScalar(f.h))
+	p1 := f.o.Add(f.u.MulScalar(f.h))
+	dl := f.n.MulScalar(l)
+	return []V2{p0, p0.Add(dl), p1.Add(dl), p1}
+}
+
+// Flat2D returns the flat pattern of the sheet metal part.
+func (s *SheetMetal) Flat2D() SDF2 {
+	parts := []SDF2{Polygon2D(s.base)}
+	for _, f := range s.flanges {
+		if f.allow+f.Length > 0 {
+			parts = append(parts, Polygon2D(f.flat()))
+		}
+	}
+	return Union2D(parts...)
+}
+
+// BendLines returns the bend lines of the flat pattern (the center of each bend allowance).
+func (s *SheetMetal) BendLines() []*Line {
+	lines := make([]*Line, len(s.flanges))
+	for i, f := range s.flanges {
+		d := f.n.MulScalar(0.5 * f.allow)
+		lines[i] = &Line{
+			f.o.Sub(f.u.MulScalar(f.h)).Add(d),
+			f.o.Add(f.u.MulScalar(f.h)).Add(d),
+		}
+	}
+	return lines
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,177 @@
+//-----------------------------------------------------------------------------
+/*
+
+Roller Chain Sprockets
+
+The tooth gaps have the ISO 606 form: a roller seating arc around the roller
+position on the pitch circle, joined to convex tooth flank arcs. Where ISO 606
+gives a range the middle value is used:
+
+pitch diameter: d = p / sin(180/z)
+roller seating radius: ri = 0.505 d1 + 0.0345 d1^(1/3)
+roller seating angle: alpha = 130 - 90/z degrees
+tooth flank radius: re = 0.5 (0.008 d1 (z^2 + 180) + 0.12 d1 (z + 2))
+tip diameter: da = d + p (1.125 - 0.8/z) - d1
+
+(p = chain pitch, d1 = roller diameter, z = number of teeth)
+
+The flank arcs are tangent to the ends of the seating arc, with their centers
+on the tooth side, so the teeth have convex flanks.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// SprocketParms defines the parameters for a roller chain sprocket.
+type SprocketParms struct {
+	Pitch          float64 // chain pitch
+	RollerDiameter float64 // chain roller diameter
+	Teeth          int     // number of teeth
+	Width          float64 // tooth width (E.g. 0.93 to 0.95 of the chain inner width)
+	Facets         int     // number of facets for each arc of the tooth gap
+}
+
+// PitchDiameter returns the pitch diameter of the sprocket.
+func (k *SprocketParms) PitchDiameter() float64 {
+	return k.Pitch / math.Sin(Pi/float64(k.Teeth))
+}
+
+// TipDiameter returns the tip (outside) diameter of the sprocket.
+func (k *SprocketParms) TipDiameter() float64 {
+	z := float64(k.Teeth)
+	return k.PitchDiameter() + k.Pitch*(1.125-0.8/z) - k.RollerDiameter
+}
+
+// RootDiameter returns the root diameter of the sprocket.
+func (k *SprocketParms) RootDiameter() float64 {
+	return k.PitchDiameter() - 2*k.seatingRadius()
+}
+
+// seatingRadius returns the roller seating radius.
+func (k *SprocketParms) seatingRadius() float64 {
+	d1 := k.RollerDiameter
+	return 0.505*d1 + 0.0345*math.Cbrt(d1)
+}
+
+// check returns an error if the sprocket parameters are invalid.
+func (k *SprocketParms) check() error {
+	if k.Pitch <= 0 {
+		return errors.New("pitch <= 0")
+	}
+	if k.RollerDiameter <= 0 || k.RollerDiameter >= k.Pitch {
+		return errors.New("roller diameter must be > 0 and < pitch")
+	}
+	if k.Teeth < 6 {
+		return errors.New("teeth < 6")
+	}
+	if k.Facets <= 0 {
+		return errors.New("facets <= 0")
+	}
+	return nil
+}
+
+// toothGap returns the vertices of a tooth gap, centered on the +x axis.
+// The gap runs past the tip circle so it cuts cleanly.
+func (k *SprocketParms) toothGap() []V2 {
+	z := float64(k.Teeth)
+	d1 := k.RollerDiameter
+	ri := k.seatingRadius()
+	alpha := DtoR(130 - 90/z)
+	re := 0.5 * (0.008*d1*(z*z+180) + 0.12*d1*(z+2))
+	rt := 0.5 * k.TipDiameter()
+
+	c := V2{0.5 * k.PitchDiameter(), 0}
+	// upper end of the seating arc, and the flank center on the tooth side
+	a0 := Pi - 0.5*alpha
+	f := c.Add(PolarToXY(ri+re, a0))
+
+	// seating arc, from the lower end to the upper end
+	var v []V2
+	for i := 0; i <= 2*k.Facets; i++ {
+		a := Pi + 0.5*alpha - alpha*float64(i)/float64(2*k.Facets)
+		v = append(v, c.Add(PolarToXY(ri, a)))
+	}
+
+	// upper flank, from the end of the seating arc out to the tip circle
+	flank := func(phi float64) V2 {
+		return f.Add(PolarToXY(re, phi))
+	}
+	phi0 := a0 + Pi
+	phi1 := phi0
+	for flank(phi1).Length() < rt && phi1 < phi0+Pi {
+		phi1 += 0.01
+	}
+	lo, hi := phi0, phi1
+	for i := 0; i < 50; i++ {
+		m := 0.5 * (lo + hi)
+		if flank(m).Length() < rt {
+			lo = m
+		} else {
+			hi = m
+		}
+	}
+	phi1 = hi
+	var upper []V2
+	for i := 1; i <= k.Facets; i++ {
+		upper = append(upper, flank(phi0+(phi1-phi0)*float64(i)/float64(k.Facets)))
+	}
+	v = append(v, upper...)
+
+	// outside the tip circle
+	p := upper[len(upper)-1]
+	v = append(v, p.Add(p.Normalize().MulScalar(0.5*k.Pitch)))
+	v = append(v, V2{p.X, -p.Y}.Add(V2{p.X, -p.Y}.Normalize().MulScalar(0.5*k.Pitch)))
+
+	// lower flank (mirror of the upper flank)
+	for i := len(upper) - 1; i >= 0; i-- {
+		v = append(v, V2{upper[i].X, -upper[i].Y})
+	}
+	return v
+}
+
+// Sprocket2D returns the 2D profile of a roller chain sprocket centered on the origin.
+// The tooth gaps (roller positions) are centered on the angles 2*pi*i/Teeth.
+func Sprocket2D(k *SprocketParms) (SDF2, error) {
+	if err := k.check(); err != nil {
+		return nil, err
+	}
+	gap := Polygon2D(k.toothGap())
+	gaps := RotateCopy2D(gap, k.Teeth)
+	return Difference2D(Circle2D(0.5*k.TipDiameter()), gaps), nil
+}
+
+// Sprocket3D returns a roller chain sprocket centered on the origin with its axis along the
+// z-axis. The sides of the teeth are chamfered (by 0.1 of the pitch at the tip) to guide the chain.
+func Sprocket3D(k *SprocketParms) (SDF3, error) {
+	s, err := Sprocket2D(k)
+	if err != nil {
+		return nil, err
+	}
+	if k.Width <= 0 {
+		return nil, errors.New("width <= 0")
+	}
+	w := 0.5 * k.Width
+	ba := Min(0.1*k.Pitch, 0.5*w)
+	rt := 0.5 * k.TipDiameter()
+	rc := rt - 0.5*k.Pitch
+	// radial section of the chamfered tooth sides
+	side := Polygon2D([]V2{
+		{0, -w},
+		{rc, -w},
+		{rt, -w + ba},
+		{rt, w - ba},
+		{rc, w},
+		{0, w},
+	})
+	return Intersect3D(Extrude3D(s, k.Width), Revolve3D(side)), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -10,9 +10,14 @@ package sdf
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -142,3 +147,78 @@ func WriteSTL(wg *sync.WaitGroup, path string) (chan<- *Triangle3, error) {
 }
 
 //-----------------------------------------------------------------------------
+
+// LoadSTL reads a triangle mesh from an STL file (binary or ASCII).
+func LoadSTL(path string) ([]*Triangle3, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// An ASCII file starts with "solid", but so do some binary files.
+	// Use the triangle count in the binary header to disambiguate.
+	if len(buf) >= 84 {
+		count := binary.LittleEndian.Uint32(buf[80:84])
+		if len(buf) == 84+int(count)*50 {
+			return readBinarySTL(buf)
+		}
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte("solid")) {
+		return readASCIISTL(buf)
+	}
+	return nil, fmt.Errorf("%s: not an STL file", path)
+}
+
+// readBinarySTL reads the triangles from a binary STL file.
+func readBinarySTL(buf []byte) ([]*Triangle3, error) {
+	r := bytes.NewReader(buf)
+	hdr := STLHeader{}
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, err
+	}
+	mesh := make([]*Triangle3, hdr.Count)
+	var d STLTriangle
+	for i := range mesh {
+		if err := binary.Read(r, binary.LittleEndian, &d); err != nil {
+			return nil, err
+		}
+		a := V3{float64(d.Vertex1[0]), float64(d.Vertex1[1]), float64(d.Vertex1[2])}
+		b := V3{float64(d.Vertex2[0]), float64(d.Vertex2[1]), float64(d.Vertex2[2])}
+		c := V3{float64(d.Vertex3[0]), float64(d.Vertex3[1]), float64(d.Vertex3[2])}
+		mesh[i] = NewTriangle3(a, b, c)
+	}
+	return mesh, nil
+}
+
+// readASCIISTL reads the triangles from an ASCII STL file.
+func readASCIISTL(buf []byte) ([]*Triangle3, error) {
+	var mesh []*Triangle3
+	var v []V3
+	for _, line := range strings.Split(string(buf), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 || f[0] != "vertex" {
+			continue
+		}
+		if len(f) != 4 {
+			return nil, errors.New("bad vertex line in STL file")
+		}
+		var x [3]float64
+		for i := range x {
+			k, err := strconv.ParseFloat(f[i+1], 64)
+			if err != nil {
+				return nil, err
+			}
+			x[i] = k
+		}
+		v = append(v, V3{x[0], x[1], x[2]})
+		if len(v) == 3 {
+			mesh = append(mesh, NewTriangle3(v[0], v[1], v[2]))
+			v = v[:0]
+		}
+	}
+	if len(v) != 0 {
+		return nil, errors.New("incomplete triangle in STL file")
+	}
+	return mesh, nil
+}
+
+//-----------------------------------------------------------------------------
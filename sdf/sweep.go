@@ -0,0 +1,126 @@
+//-----------------------------------------------------------------------------
+/*
+
+Swept Lofts
+
+A loft (a blend between two profiles) swept along a guide path. The path is
+a 3D polyline (see Path3), use closely spaced points for a smooth result. The profiles
+are carried along the path on a rotation minimizing frame (the frame turns
+with the path without spinning about it), and can be twisted about the path.
+
+At the start of the path the profile x-axis is the component of the world
+x-axis normal to the path (the world y-axis if the path starts along x), and
+the profile y-axis completes a right handed frame with the path tangent. So
+a straight path along +z matches Loft3D.
+
+The distance is measured to the closest point on the path, so the profile
+must be smaller than the radius of curvature of the path.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// SweepSDF3 is a loft swept along a guide path.
+type SweepSDF3 struct {
+	sdf0, sdf1 SDF2    // start and end profiles
+	path       *Path3  // guide path
+	twist      float64 // profile rotation along the path
+	bb         Box3    // bounding box
+}
+
+// Sweep3D returns a loft between two profiles swept along a guide path. The profile blends from
+// sdf0 at the start of the path to sdf1 at the end, and rotates by the twist angle (radians,
+// counter-clockwise looking back along the path) about the path.
+func Sweep3D(
+	sdf0, sdf1 SDF2, // start and end profiles
+	path []V3, // guide path
+	twist float64, // profile rotation along the path (radians)
+) (SDF3, error) {
+	p, err := NewPath3(path)
+	if err != nil {
+		return nil, err
+	}
+	return SweepPath3D(sdf0, sdf1, p, twist)
+}
+
+// SweepPath3D returns a loft between two profiles swept along a guide path (see Sweep3D).
+func SweepPath3D(
+	sdf0, sdf1 SDF2, // start and end profiles
+	path *Path3, // guide path
+	twist float64, // profile rotation along the path (radians)
+) (SDF3, error) {
+	if sdf0 == nil || sdf1 == nil {
+		return nil, errors.New("nil profile")
+	}
+	if path == nil {
+		return nil, errors.New("nil path")
+	}
+	s := SweepSDF3{
+		sdf0:  sdf0,
+		sdf1:  sdf1,
+		path:  path,
+		twist: twist,
+	}
+	// the bounding box is the path extended by the profile size
+	bb0 := sdf0.BoundingBox()
+	bb1 := sdf1.BoundingBox()
+	r := 0.0
+	for _, v := range append(bb0.Vertices(), bb1.Vertices()...) {
+		r = Max(r, v.Length())
+	}
+	bb := Box3{path.p[0], path.p[0]}
+	for _, p := range path.p {
+		bb = bb.Extend(Box3{p, p})
+	}
+	s.bb = Box3{bb.Min.SubScalar(r), bb.Max.AddScalar(r)}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a swept loft.
+func (s *SweepSDF3) Evaluate(p V3) float64 {
+	path := s.path
+	// closest point on the path
+	k, u := path.closest(p)
+	q := p.Sub(path.p[k].Add(path.t[k].MulScalar(u)))
+	// position along the path
+	f := (path.s[k] + u) / path.Length()
+	// profile coordinates
+	sin, cos := math.Sincos(-s.twist * f)
+	x := q.Dot(path.n[k])
+	y := q.Dot(path.b[k])
+	v := V2{x*cos - y*sin, x*sin + y*cos}
+	a := Mix(s.sdf0.Evaluate(v), s.sdf1.Evaluate(v), f)
+	// distance beyond the ends of the path
+	e := math.Inf(-1)
+	if k == 0 {
+		e = -p.Sub(path.p[0]).Dot(path.t[0])
+	}
+	if n := len(path.t) - 1; k == n {
+		e = Max(e, p.Sub(path.p[n+1]).Dot(path.t[n]))
+	}
+	if e > 0 {
+		if a < 0 {
+			return e
+		}
+		return math.Sqrt(a*a + e*e)
+	}
+	return Max(a, e)
+}
+
+// BoundingBox returns the bounding box of a swept loft.
+func (s *SweepSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a swept loft.
+func (s *SweepSDF3) Children() []interface{} { return []interface{}{s.sdf0, s.sdf1} }
+
+//-----------------------------------------------------------------------------
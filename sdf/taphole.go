@@ -0,0 +1,207 @@
+//-----------------------------------------------------------------------------
+/*
+
+Tap Drill and Clearance Holes
+
+Work out the hole sizes for a thread form by measuring the 2D thread profile
+(see screw.go) over one pitch.
+
+Cutting taps: the core hole is the major diameter less a fraction (the thread
+engagement) of twice the thread height. As in tap drill charts the height is
+that of the basic 60 degree form (3H/4 = 0.6495P), not the rounded root of the
+external thread, so 75% engagement gives the usual major - pitch (E.g. M6x1
+= 5.0mm). 75% is the usual choice, higher values give little extra strength
+and break taps. The core hole is never smaller than the minor diameter.
+
+Forming taps: the material displaced from the thread grooves fills the thread
+crests, so the core hole is the diameter with equal volumes of thread material
+inside and outside of it. The same diameter is the blank size for a thread
+rolling die.
+
+Clearance holes: from the fastener tables (ISO 273, ASME) if the thread is a
+standard fastener thread, or scaled from the major diameter.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// ThreadHoles stores the hole sizes for a thread.
+type ThreadHoles struct {
+	Major           float64 // major diameter of the thread form
+	Minor           float64 // minor diameter of the thread form
+	Pitch           float64 // thread pitch
+	TapDrill        float64 // core hole diameter for a cutting tap
+	Forming         float64 // core hole diameter for a forming tap (or the blank for a rolling die)
+	ClearanceClose  float64 // close fit clearance hole diameter
+	ClearanceNormal float64 // normal fit clearance hole diameter
+	ClearanceLoose  float64 // loose fit clearance hole diameter
+}
+
+// threadFormSamples is the number of samples over a pitch of the thread profile.
+const threadFormSamples = 256
+
+// threadHeights returns the surface radius of a thread profile at points over one pitch.
+func threadHeights(thread SDF2, pitch float64) []float64 {
+	rmax := thread.BoundingBox().Max.Y
+	tol := 1e-6 * rmax
+	h := make([]float64, threadFormSamples)
+	for i := range h {
+		x := pitch * ((float64(i)+0.5)/float64(threadFormSamples) - 0.5)
+		// the profile is solid from the axis up to the thread surface
+		lo, hi := 0.0, rmax
+		for hi-lo > tol {
+			y := 0.5 * (lo + hi)
+			if thread.Evaluate(V2{x, y}) < 0 {
+				lo = y
+			} else {
+				hi = y
+			}
+		}
+		h[i] = 0.5 * (lo + hi)
+	}
+	return h
+}
+
+// ThreadFormHoles returns the hole sizes for an external thread profile.
+func ThreadFormHoles(
+	thread SDF2, // external thread profile (see screw.go)
+	pitch float64, // thread to thread distance
+	engagement float64, // cutting tap thread engagement, E.g. 0.75
+) (*ThreadHoles, error) {
+	if thread == nil {
+		return nil, errors.New("nil thread")
+	}
+	if pitch <= 0 {
+		return nil, errors.New("pitch <= 0")
+	}
+	if engagement <= 0 || engagement > 1 {
+		return nil, errors.New("engagement must be > 0 and <= 1")
+	}
+	h := threadHeights(thread, pitch)
+	rmin, rmax := math.Inf(1), 0.0
+	sum := 0.0
+	for _, y := range h {
+		rmin = Min(rmin, y)
+		rmax = Max(rmax, y)
+		sum += y * y
+	}
+	if rmin <= 0 || rmax <= rmin {
+		return nil, errors.New("the profile is not an external thread form")
+	}
+	k := ThreadHoles{
+		Major: 2 * rmax,
+		Minor: 2 * rmin,
+		Pitch: pitch,
+	}
+	// thread height (3H/4) of the basic 60 degree form
+	height := 0.75 * pitch * math.Sqrt(3) / 2
+	k.TapDrill = Max(k.Major-2*engagement*height, k.Minor)
+	// the displaced volume balances when r^2 is the mean of the squared surface radii
+	k.Forming = 2 * math.Sqrt(sum/float64(len(h)))
+	k.ClearanceClose = 1.06 * k.Major
+	k.ClearanceNormal = 1.1 * k.Major
+	k.ClearanceLoose = 1.2 * k.Major
+	return &k, nil
+}
+
+// ThreadHoleSizes returns the hole sizes for a named thread in the thread database.
+func ThreadHoleSizes(
+	name string, // name of thread
+	engagement float64, // cutting tap thread engagement, E.g. 0.75
+) (*ThreadHoles, error) {
+	t, err := ThreadLookup(name)
+	if err != nil {
+		return nil, err
+	}
+	k, err := ThreadFormHoles(ISOThread(t.Radius, t.Pitch, "external"), t.Pitch, engagement)
+	if err != nil {
+		return nil, err
+	}
+	// use the standard clearance holes for fastener threads
+	for _, db := range []fastenerDatabase{isoFastenerDB, ansiFastenerDB} {
+		for _, f := range db {
+			if f.Thread == name {
+				k.ClearanceClose = f.ClearanceClose
+				k.ClearanceNormal = f.ClearanceNormal
+				k.ClearanceLoose = f.ClearanceLoose
+			}
+		}
+	}
+	return k, nil
+}
+
+//-----------------------------------------------------------------------------
+// Tap Test Coupons
+
+// TapCouponParms defines the parameters for a tap drill test plate.
+type TapCouponParms struct {
+	Thread     string  // name of thread
+	Engagement float64 // cutting tap thread engagement (0 = 0.75)
+	Steps      int     // number of core holes
+	Thickness  float64 // plate thickness
+	Tolerance  float64 // radial tolerance of the printed threads
+}
+
+// TapCoupon3D returns a test plate and a plug gauge for a thread.
+// The plate has core holes stepping from the cutting tap drill size to the forming tap size,
+// to be tapped with a metal tap, and a printed internal thread to test with a metal bolt.
+// The plug gauge has pins matching the core holes, to check the printed hole sizes before
+// tapping, and a printed external thread to test with a metal nut.
+func TapCoupon3D(k *TapCouponParms) ([]SDF3, error) {
+	t, err := ThreadLookup(k.Thread)
+	if err != nil {
+		return nil, err
+	}
+	if k.Steps < 2 {
+		return nil, errors.New("steps < 2")
+	}
+	if k.Thickness <= 0 {
+		return nil, errors.New("thickness <= 0")
+	}
+	if k.Tolerance < 0 {
+		return nil, errors.New("tolerance < 0")
+	}
+	engagement := k.Engagement
+	if engagement == 0 {
+		engagement = 0.75
+	}
+	holes, err := ThreadHoleSizes(k.Thread, engagement)
+	if err != nil {
+		return nil, err
+	}
+
+	n := k.Steps + 1
+	spacing := 2.5 * holes.Major
+	plate := couponPlate(V3{float64(n) * spacing, spacing, k.Thickness})
+	cores := make([]SDF3, n)
+	pins := make([]SDF3, n)
+	for i := 0; i < k.Steps; i++ {
+		d := Mix(holes.TapDrill, holes.Forming, float64(i)/float64(k.Steps-1))
+		x := couponX(i, n, spacing)
+		cores[i] = Transform3D(Cylinder3D(2*k.Thickness, 0.5*d, 0), Translate3d(V3{x, 0, 0}))
+		pin := Cylinder3D(k.Thickness, 0.5*d, 0)
+		pins[i] = Transform3D(pin, Translate3d(V3{x, 0, k.Thickness}))
+	}
+	// printed threads
+	x := couponX(k.Steps, n, spacing)
+	internal := Screw3D(ISOThread(t.Radius+k.Tolerance, t.Pitch, "internal"), 2*k.Thickness, t.Pitch, 1)
+	cores[k.Steps] = Transform3D(internal, Translate3d(V3{x, 0, 0}))
+	length := Max(k.Thickness, 3*t.Pitch)
+	external := Screw3D(ISOThread(t.Radius-k.Tolerance, t.Pitch, "external"), length, t.Pitch, 1)
+	pins[k.Steps] = Transform3D(external, Translate3d(V3{x, 0, 0.5 * (k.Thickness + length)}))
+
+	return []SDF3{
+		Difference3D(plate, Union3D(cores...)),
+		Union3D(plate, Union3D(pins...)),
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -5,17 +5,30 @@ Text Operations
 
 Convert a string and font specification into an SDF2
 
+TextSDF2 uses the freetype package and supports truetype (*.ttf) fonts.
+TextSFNT2D uses the sfnt package and supports truetype and opentype (*.otf,
+with CFF outlines) fonts. The glyph curves are converted to polygons within
+a chord tolerance (0.1% of the font em size), and the kerning comes from the
+kern table of the font.
+
+TextPath2D and TextSFNTPath2D place text along a 2D path (see Path2), with
+each glyph positioned and rotated along the path.
+
 */
 //-----------------------------------------------------------------------------
 
 package sdf
 
 import (
+	"errors"
 	"io/ioutil"
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
 	"golang.org/x/image/math/fixed"
 )
 
@@ -102,13 +115,20 @@ func glyphConvert(g *truetype.GlyphBuf) SDF2 {
 
 //-----------------------------------------------------------------------------
 
-// lineSDF2 returns an SDF2 slice for a line of text
-func lineSDF2(f *truetype.Font, l string) ([]SDF2, float64, error) {
+// glyph is a glyph on a line of text.
+type glyph struct {
+	s       SDF2    // glyph outline at the origin (nil if there is no outline)
+	x       float64 // x offset of the glyph on the line
+	advance float64 // advance width of the glyph
+}
+
+// lineGlyphs returns the glyphs for a line of text, and the line length.
+func lineGlyphs(f *truetype.Font, l string) ([]glyph, float64, error) {
 	iPrev := truetype.Index(0)
 	scale := fixed.Int26_6(f.FUnitsPerEm())
 	xOfs := 0.0
 
-	var ss []SDF2
+	var gs []glyph
 
 	for _, r := range l {
 		i := f.Index(r)
@@ -128,15 +148,25 @@ func lineSDF2(f *truetype.Font, l string) ([]SDF2, float64, error) {
 			return nil, 0, err
 		}
 
-		s := glyphConvert(g)
-		if s != nil {
-			s = Transform2D(s, Translate2d(V2{xOfs, 0}))
-			ss = append(ss, s)
-		}
-
+		gs = append(gs, glyph{glyphConvert(g), xOfs, float64(hm.AdvanceWidth)})
 		xOfs += float64(hm.AdvanceWidth)
 	}
 
+	return gs, xOfs, nil
+}
+
+// lineSDF2 returns an SDF2 slice for a line of text
+func lineSDF2(f *truetype.Font, l string) ([]SDF2, float64, error) {
+	gs, xOfs, err := lineGlyphs(f, l)
+	if err != nil {
+		return nil, 0, err
+	}
+	var ss []SDF2
+	for _, g := range gs {
+		if g.s != nil {
+			ss = append(ss, Transform2D(g.s, Translate2d(V2{g.x, 0})))
+		}
+	}
 	return ss, xOfs, nil
 }
 
@@ -193,3 +223,293 @@ func TextSDF2(f *truetype.Font, t *Text, h float64) (SDF2, error) {
 }
 
 //-----------------------------------------------------------------------------
+// SFNT Fonts
+
+// sfntTolerance is the chord tolerance for glyph curves (fraction of the em size).
+const sfntTolerance = 1e-3
+
+// sfntPoint converts an sfnt point (y down, 26.6 fixed point) to a V2.
+func sfntPoint(p fixed.Point26_6) V2 {
+	return V2{float64(p.X) / 64, -float64(p.Y) / 64}
+}
+
+// sfntGlyph returns the SDF2 for the segments of a glyph (nil for an empty glyph).
+func sfntGlyph(segments []sfnt.Segment, tol float64) SDF2 {
+	// build the contours
+	var contours [][]V2
+	var p *Polygon
+	closeContour := func() {
+		if p != nil {
+			p.Close()
+			if v := openVertices(p.Vertices(), true); len(v) >= 3 {
+				contours = append(contours, v)
+			}
+		}
+	}
+	for _, seg := range segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			closeContour()
+			p = NewPolygon()
+			p.AddV2(sfntPoint(seg.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			p.AddV2(sfntPoint(seg.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			p.AddV2(sfntPoint(seg.Args[0])).Control()
+			p.AddV2(sfntPoint(seg.Args[1])).BezierTolerance(tol)
+		case sfnt.SegmentOpCubeTo:
+			p.AddV2(sfntPoint(seg.Args[0])).Control()
+			p.AddV2(sfntPoint(seg.Args[1])).Control()
+			p.AddV2(sfntPoint(seg.Args[2])).BezierTolerance(tol)
+		}
+	}
+	closeContour()
+	if len(contours) == 0 {
+		return nil
+	}
+	// Truetype and CFF outlines have opposite contour directions, so use the nesting
+	// depth of the contours to find the holes.
+	sort.Slice(contours, func(i, j int) bool {
+		return Abs(loopArea(contours[i])) > Abs(loopArea(contours[j]))
+	})
+	polygons := make([]SDF2, len(contours))
+	depth := make([]int, len(contours))
+	maxDepth := 0
+	for i, c := range contours {
+		polygons[i] = Polygon2D(c)
+		for j := 0; j < i; j++ {
+			if polygons[j].Evaluate(c[0]) < 0 {
+				depth[i]++
+			}
+		}
+		if depth[i] > maxDepth {
+			maxDepth = depth[i]
+		}
+	}
+	var s SDF2
+	for d := 0; d <= maxDepth; d++ {
+		var level []SDF2
+		for i := range polygons {
+			if depth[i] == d {
+				level = append(level, polygons[i])
+			}
+		}
+		if d&1 == 0 {
+			s = Union2D(append([]SDF2{s}, level...)...)
+		} else {
+			s = Difference2D(s, Union2D(level...))
+		}
+	}
+	return s
+}
+
+// LoadFontSFNT loads a truetype (*.ttf) or opentype (*.otf) font file.
+func LoadFontSFNT(fname string) (*sfnt.Font, error) {
+	b, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return sfnt.Parse(b)
+}
+
+// sfntLineGlyphs returns the glyphs for a line of text (in font units), and the line length.
+func sfntLineGlyphs(f *sfnt.Font, buf *sfnt.Buffer, l string, ppem fixed.Int26_6, tol float64) ([]glyph, float64, error) {
+	var gs []glyph
+	xOfs := 0.0
+	iPrev := sfnt.GlyphIndex(0)
+	for _, r := range l {
+		i, err := f.GlyphIndex(buf, r)
+		if err != nil {
+			return nil, 0, err
+		}
+		// apply kerning (fonts without a kern table have no kerning)
+		if iPrev != 0 {
+			k, err := f.Kern(buf, iPrev, i, ppem, font.HintingNone)
+			if err == nil {
+				xOfs += float64(k) / 64
+			} else if err != sfnt.ErrNotFound {
+				return nil, 0, err
+			}
+		}
+		iPrev = i
+		segments, err := f.LoadGlyph(buf, i, ppem, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		adv, err := f.GlyphAdvance(buf, i, ppem, font.HintingNone)
+		if err != nil {
+			return nil, 0, err
+		}
+		gs = append(gs, glyph{sfntGlyph(segments, tol), xOfs, float64(adv) / 64})
+		xOfs += float64(adv) / 64
+	}
+	return gs, xOfs, nil
+}
+
+// TextSFNT2D returns a sized SDF2 for a text object. The size is the line height (ascent +
+// descent + line gap), so it can differ a little from the size of TextSDF2 for the same font.
+func TextSFNT2D(f *sfnt.Font, t *Text, h float64) (SDF2, error) {
+	var buf sfnt.Buffer
+	// work in font units
+	upem := int(f.UnitsPerEm())
+	ppem := fixed.I(upem)
+	m, err := f.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	ah := float64(m.Height) / 64
+	tol := sfntTolerance * float64(upem)
+
+	var ss []SDF2
+	yOfs := 0.0
+	for _, l := range strings.Split(t.s, "\n") {
+		gs, xOfs, err := sfntLineGlyphs(f, &buf, l, ppem, tol)
+		if err != nil {
+			return nil, err
+		}
+		var line []SDF2
+		for _, g := range gs {
+			if g.s != nil {
+				line = append(line, Transform2D(g.s, Translate2d(V2{g.x, yOfs})))
+			}
+		}
+		// alignment
+		dx := 0.0
+		if t.halign == rAlign {
+			dx = -xOfs
+		} else if t.halign == cAlign {
+			dx = -xOfs / 2.0
+		}
+		for _, s := range line {
+			ss = append(ss, Transform2D(s, Translate2d(V2{dx, 0})))
+		}
+		yOfs -= ah
+	}
+	if len(ss) == 0 {
+		return nil, errors.New("no glyphs")
+	}
+	return CenterAndScale2D(Union2D(ss...), h/ah), nil
+}
+
+//-----------------------------------------------------------------------------
+// Text on a Path
+
+// pathText returns lines of glyphs (in font units) placed along a 2D path.
+func pathText(
+	lines [][]glyph, // glyphs for each line
+	lengths []float64, // length of each line
+	ah float64, // line height
+	t *Text, // text alignment
+	h float64, // line height on the path
+	path *Path2, // text path
+	x float64, // arc length for the text alignment
+) (SDF2, error) {
+	if path == nil {
+		return nil, errors.New("nil path")
+	}
+	if h <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	k := h / ah
+	var ss []SDF2
+	for i, gs := range lines {
+		// lines after the first are below the baseline
+		p := path
+		if i > 0 {
+			var err error
+			p, err = path.Offset(-float64(i) * h)
+			if err != nil {
+				return nil, err
+			}
+		}
+		l := lengths[i] * k
+		x0 := x
+		if t.halign == rAlign {
+			x0 -= l
+		} else if t.halign == cAlign {
+			x0 -= l / 2.0
+		}
+		if p.Closed() {
+			if l > p.Length() {
+				return nil, errors.New("text is longer than the path")
+			}
+		} else if x0 < -tolerance || x0+l > p.Length()+tolerance {
+			return nil, errors.New("text runs off the end of the path")
+		}
+		for _, g := range gs {
+			if g.s == nil {
+				continue
+			}
+			// place the center of the glyph on the path, rotated to the path tangent
+			c := x0 + (g.x+0.5*g.advance)*k
+			tangent := p.Tangent(c)
+			m := Translate2d(p.Point(c)).Mul(Rotate2d(math.Atan2(tangent.Y, tangent.X)))
+			s := Transform2D(g.s, Translate2d(V2{-0.5 * g.advance, 0}))
+			ss = append(ss, Transform2D(ScaleUniform2D(s, k), m))
+		}
+	}
+	if len(ss) == 0 {
+		return nil, errors.New("no glyphs")
+	}
+	return Union2D(ss...), nil
+}
+
+// TextPath2D returns the SDF2 for text along a 2D path (E.g. an arc for labels on a knob or
+// dial). The baseline of the text follows the path with the text on the left (normal) side,
+// and the glyphs are rotated to the path tangent at their centers. The text is aligned at an
+// arc length along the path. h is the line height, and any further lines are below the first.
+// For text reading around the outside of a circle use a clockwise arc.
+func TextPath2D(
+	f *truetype.Font, // truetype font
+	t *Text, // text
+	h float64, // line height
+	path *Path2, // text path
+	x float64, // arc length for the text alignment
+) (SDF2, error) {
+	scale := fixed.Int26_6(f.FUnitsPerEm())
+	vm := f.VMetric(scale, f.Index('\n'))
+	ah := float64(vm.AdvanceHeight)
+	var lines [][]glyph
+	var lengths []float64
+	for _, l := range strings.Split(t.s, "\n") {
+		gs, xOfs, err := lineGlyphs(f, l)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, gs)
+		lengths = append(lengths, xOfs)
+	}
+	return pathText(lines, lengths, ah, t, h, path, x)
+}
+
+// TextSFNTPath2D returns the SDF2 for text along a 2D path using an sfnt font (see TextPath2D).
+func TextSFNTPath2D(
+	f *sfnt.Font, // truetype or opentype font
+	t *Text, // text
+	h float64, // line height
+	path *Path2, // text path
+	x float64, // arc length for the text alignment
+) (SDF2, error) {
+	var buf sfnt.Buffer
+	upem := int(f.UnitsPerEm())
+	ppem := fixed.I(upem)
+	m, err := f.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	ah := float64(m.Height) / 64
+	tol := sfntTolerance * float64(upem)
+	var lines [][]glyph
+	var lengths []float64
+	for _, l := range strings.Split(t.s, "\n") {
+		gs, xOfs, err := sfntLineGlyphs(f, &buf, l, ppem, tol)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, gs)
+		lengths = append(lengths, xOfs)
+	}
+	return pathText(lines, lengths, ah, t, h, path, x)
+}
+
+//-----------------------------------------------------------------------------
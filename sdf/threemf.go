@@ -0,0 +1,160 @@
+//-----------------------------------------------------------------------------
+/*
+
+3MF Files
+
+Save multiple meshes as the objects of a 3MF file. Each object has a name
+and a display color, so multi-part prints (E.g. a two color inlay) load into
+a slicer as separate objects, in place, ready to assign to extruders.
+
+3MF is a zip archive of XML files, the mesh is an indexed triangle set so the
+vertices are shared between triangles.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+)
+
+//-----------------------------------------------------------------------------
+
+// Object3MF is an object in a 3MF file.
+type Object3MF struct {
+	Name  string       // name of the object
+	Color color.Color  // display color (nil = gray)
+	Mesh  []*Triangle3 // triangle mesh
+}
+
+const threeMFContentTypes = `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+ <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+ <Default Extension="model" ContentType="application/vnd.ms-package.3dmanufacturing-3dmodel+xml"/>
+</Types>
+`
+
+const threeMFRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+ <Relationship Target="/3D/3dmodel.model" Id="rel0" Type="http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"/>
+</Relationships>
+`
+
+// threeMFColor returns a 3MF display color (#RRGGBBAA).
+func threeMFColor(c color.Color) string {
+	if c == nil {
+		c = color.Gray{0x80}
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02X%02X%02X%02X", n.R, n.G, n.B, n.A)
+}
+
+// xmlEscape returns a string escaped for an XML attribute.
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// write3MFModel writes the 3D model part of a 3MF file.
+func write3MFModel(w io.Writer, objects []*Object3MF) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintf(buf, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(buf, "<model unit=\"millimeter\" xml:lang=\"en-US\" xmlns=\"http://schemas.microsoft.com/3dmanufacturing/core/2015/02\">\n")
+	fmt.Fprintf(buf, " <resources>\n")
+	// one base material per object for the display colors
+	fmt.Fprintf(buf, "  <basematerials id=\"1\">\n")
+	for _, obj := range objects {
+		fmt.Fprintf(buf, "   <base name=\"%s\" displaycolor=\"%s\"/>\n", xmlEscape(obj.Name), threeMFColor(obj.Color))
+	}
+	fmt.Fprintf(buf, "  </basematerials>\n")
+	for i, obj := range objects {
+		fmt.Fprintf(buf, "  <object id=\"%d\" type=\"model\" name=\"%s\" pid=\"1\" pindex=\"%d\">\n", i+2, xmlEscape(obj.Name), i)
+		fmt.Fprintf(buf, "   <mesh>\n    <vertices>\n")
+		// shared vertices
+		index := make(map[V3]int)
+		var tri [][3]int
+		for _, t := range obj.Mesh {
+			var v [3]int
+			for j, p := range t.V {
+				k, ok := index[p]
+				if !ok {
+					k = len(index)
+					index[p] = k
+					fmt.Fprintf(buf, "     <vertex x=\"%g\" y=\"%g\" z=\"%g\"/>\n", p.X, p.Y, p.Z)
+				}
+				v[j] = k
+			}
+			// skip degenerate triangles
+			if v[0] != v[1] && v[1] != v[2] && v[2] != v[0] {
+				tri = append(tri, v)
+			}
+		}
+		fmt.Fprintf(buf, "    </vertices>\n    <triangles>\n")
+		for _, v := range tri {
+			fmt.Fprintf(buf, "     <triangle v1=\"%d\" v2=\"%d\" v3=\"%d\"/>\n", v[0], v[1], v[2])
+		}
+		fmt.Fprintf(buf, "    </triangles>\n   </mesh>\n  </object>\n")
+	}
+	fmt.Fprintf(buf, " </resources>\n <build>\n")
+	for i := range objects {
+		fmt.Fprintf(buf, "  <item objectid=\"%d\"/>\n", i+2)
+	}
+	fmt.Fprintf(buf, " </build>\n</model>\n")
+	return buf.Flush()
+}
+
+// Write3MF writes a set of objects as a 3MF file.
+func Write3MF(w io.Writer, objects []*Object3MF) error {
+	if len(objects) == 0 {
+		return errors.New("no objects")
+	}
+	z := zip.NewWriter(w)
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"[Content_Types].xml", func(w io.Writer) error {
+			_, err := io.WriteString(w, threeMFContentTypes)
+			return err
+		}},
+		{"_rels/.rels", func(w io.Writer) error {
+			_, err := io.WriteString(w, threeMFRels)
+			return err
+		}},
+		{"3D/3dmodel.model", func(w io.Writer) error {
+			return write3MFModel(w, objects)
+		}},
+	}
+	for _, f := range files {
+		fw, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if err := f.write(fw); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// Save3MF writes a set of objects to a 3MF file.
+func Save3MF(path string, objects []*Object3MF) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return Write3MF(file, objects)
+}
+
+//-----------------------------------------------------------------------------
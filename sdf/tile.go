@@ -0,0 +1,206 @@
+//-----------------------------------------------------------------------------
+/*
+
+Mirror Tiling
+
+A finite array of copies of an SDF where alternate copies along each axis are
+mirrored. Copy (j, k) is centered on (j * step.X, k * step.Y), and is mirrored
+in x if j is odd and in y if k is odd. Each copy is the reflection of its
+neighbours about their shared edge, so a pattern which is continuous at the
+edges of its cell (E.g. half of a herringbone tile, an angled tread lug)
+joins without phase errors.
+
+The tile cell is centered on the origin of the SDF. The SDF can extend past
+its cell into the neighbouring cells, but not further.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "math"
+
+//-----------------------------------------------------------------------------
+
+// tileIndex returns the index of the closest copy along an axis.
+func tileIndex(x, step float64, num int) int {
+	if num == 1 {
+		return 0
+	}
+	j := int(math.Round(x / step))
+	if j < 0 {
+		return 0
+	}
+	if j >= num {
+		return num - 1
+	}
+	return j
+}
+
+// tileCoord returns the coordinate along an axis in the frame of copy j.
+func tileCoord(x, step float64, j int) float64 {
+	x -= float64(j) * step
+	if j&1 == 1 {
+		return -x
+	}
+	return x
+}
+
+// tileRange returns the range along an axis covered by the copies of [min, max].
+func tileRange(min, max, step float64, num int) (float64, float64) {
+	lo, hi := min, max
+	for j := 1; j < num; j++ {
+		x := float64(j) * step
+		if j&1 == 1 {
+			lo, hi = Min(lo, x-max), Max(hi, x-min)
+		} else {
+			lo, hi = Min(lo, x+min), Max(hi, x+max)
+		}
+	}
+	return lo, hi
+}
+
+//-----------------------------------------------------------------------------
+
+// MirrorTileSDF2 is a finite array of SDF2s, alternately mirrored along each axis.
+type MirrorTileSDF2 struct {
+	sdf  SDF2
+	num  V2i // number of copies on each axis
+	step V2  // tile step size
+	min  MinFunc
+	bb   Box2
+}
+
+// MirrorTile2D returns an XY grid array of an SDF2, with alternate copies mirrored on each axis.
+func MirrorTile2D(sdf SDF2, num V2i, step V2) SDF2 {
+	// check the number of steps
+	if num[0] <= 0 || num[1] <= 0 {
+		return nil
+	}
+	if (num[0] > 1 && step.X == 0) || (num[1] > 1 && step.Y == 0) {
+		return nil
+	}
+	s := MirrorTileSDF2{}
+	s.sdf = sdf
+	s.num = num
+	s.step = step
+	s.min = Min
+	// work out the bounding box
+	bb := sdf.BoundingBox()
+	s.bb.Min.X, s.bb.Max.X = tileRange(bb.Min.X, bb.Max.X, step.X, num[0])
+	s.bb.Min.Y, s.bb.Max.Y = tileRange(bb.Min.Y, bb.Max.Y, step.Y, num[1])
+	return &s
+}
+
+// SetMin sets the minimum function to control blending.
+func (s *MirrorTileSDF2) SetMin(min MinFunc) {
+	s.min = min
+}
+
+// Evaluate returns the minimum distance to a mirror tiled SDF2.
+func (s *MirrorTileSDF2) Evaluate(p V2) float64 {
+	j0 := tileIndex(p.X, s.step.X, s.num[0])
+	k0 := tileIndex(p.Y, s.step.Y, s.num[1])
+	d := math.MaxFloat64
+	// the closest copy and its neighbours
+	for j := j0 - 1; j <= j0+1; j++ {
+		if j < 0 || j >= s.num[0] {
+			continue
+		}
+		for k := k0 - 1; k <= k0+1; k++ {
+			if k < 0 || k >= s.num[1] {
+				continue
+			}
+			x := V2{tileCoord(p.X, s.step.X, j), tileCoord(p.Y, s.step.Y, k)}
+			d = s.min(d, s.sdf.Evaluate(x))
+		}
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a mirror tiled SDF2.
+func (s *MirrorTileSDF2) BoundingBox() Box2 {
+	return s.bb
+}
+
+// Children returns the child nodes of a mirror tiled SDF2.
+func (s *MirrorTileSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+//-----------------------------------------------------------------------------
+
+// MirrorTileSDF3 is a finite array of SDF3s, alternately mirrored along each axis.
+type MirrorTileSDF3 struct {
+	sdf  SDF3
+	num  V3i // number of copies on each axis
+	step V3  // tile step size
+	min  MinFunc
+	bb   Box3
+}
+
+// MirrorTile3D returns an XYZ grid array of an SDF3, with alternate copies mirrored on each axis.
+func MirrorTile3D(sdf SDF3, num V3i, step V3) SDF3 {
+	// check the number of steps
+	if num[0] <= 0 || num[1] <= 0 || num[2] <= 0 {
+		return nil
+	}
+	if (num[0] > 1 && step.X == 0) || (num[1] > 1 && step.Y == 0) || (num[2] > 1 && step.Z == 0) {
+		return nil
+	}
+	s := MirrorTileSDF3{}
+	s.sdf = sdf
+	s.num = num
+	s.step = step
+	s.min = Min
+	// work out the bounding box
+	bb := sdf.BoundingBox()
+	s.bb.Min.X, s.bb.Max.X = tileRange(bb.Min.X, bb.Max.X, step.X, num[0])
+	s.bb.Min.Y, s.bb.Max.Y = tileRange(bb.Min.Y, bb.Max.Y, step.Y, num[1])
+	s.bb.Min.Z, s.bb.Max.Z = tileRange(bb.Min.Z, bb.Max.Z, step.Z, num[2])
+	return &s
+}
+
+// SetMin sets the minimum function to control blending.
+func (s *MirrorTileSDF3) SetMin(min MinFunc) {
+	s.min = min
+}
+
+// Evaluate returns the minimum distance to a mirror tiled SDF3.
+func (s *MirrorTileSDF3) Evaluate(p V3) float64 {
+	j0 := tileIndex(p.X, s.step.X, s.num[0])
+	k0 := tileIndex(p.Y, s.step.Y, s.num[1])
+	l0 := tileIndex(p.Z, s.step.Z, s.num[2])
+	d := math.MaxFloat64
+	// the closest copy and its neighbours
+	for j := j0 - 1; j <= j0+1; j++ {
+		if j < 0 || j >= s.num[0] {
+			continue
+		}
+		for k := k0 - 1; k <= k0+1; k++ {
+			if k < 0 || k >= s.num[1] {
+				continue
+			}
+			for l := l0 - 1; l <= l0+1; l++ {
+				if l < 0 || l >= s.num[2] {
+					continue
+				}
+				x := V3{
+					tileCoord(p.X, s.step.X, j),
+					tileCoord(p.Y, s.step.Y, k),
+					tileCoord(p.Z, s.step.Z, l),
+				}
+				d = s.min(d, s.sdf.Evaluate(x))
+			}
+		}
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a mirror tiled SDF3.
+func (s *MirrorTileSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a mirror tiled SDF3.
+func (s *MirrorTileSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,259 @@
+//-----------------------------------------------------------------------------
+/*
+
+Turntable Rendering
+
+Render preview images of an SDF3 directly (no mesh) by ray marching (sphere
+tracing) the distance field, and make a turntable animation of frames with
+the camera orbiting the model.
+
+The shading is a single color with a light at the camera, so the images are
+paletted (a ramp of the model color plus the background) and save directly
+as an animated GIF. Save single frames with png.Encode (E.g. to make a
+webm/mp4 with ffmpeg).
+
+The ray march takes steps of the evaluated distance, so it relies on the
+SDF not over estimating the distance. SDFs with approximate distances
+(E.g. the smooth min functions) are stepped conservatively.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+)
+
+//-----------------------------------------------------------------------------
+
+const (
+	rayMaxSteps   = 512  // maximum number of ray march steps
+	rayStepFactor = 0.9  // fraction of the distance for each step
+	rayHitFactor  = 1e-4 // hit distance as a fraction of the bounding box size
+	rayShades     = 255  // number of shades of the model color
+)
+
+// Camera defines a view of an SDF3.
+type Camera struct {
+	Eye    V3      // camera position
+	Center V3      // point the camera is looking at
+	Up     V3      // up direction
+	FOV    float64 // vertical field of view (radians)
+}
+
+// rayBox returns the ray parameter range within a box (tmin > tmax if the ray misses).
+func rayBox(p, d V3, bb Box3) (float64, float64) {
+	tmin, tmax := math.Inf(-1), math.Inf(1)
+	for i := 0; i < 3; i++ {
+		var o, v, lo, hi float64
+		switch i {
+		case 0:
+			o, v, lo, hi = p.X, d.X, bb.Min.X, bb.Max.X
+		case 1:
+			o, v, lo, hi = p.Y, d.Y, bb.Min.Y, bb.Max.Y
+		case 2:
+			o, v, lo, hi = p.Z, d.Z, bb.Min.Z, bb.Max.Z
+		}
+		if v == 0 {
+			if o < lo || o > hi {
+				return 1, 0
+			}
+			continue
+		}
+		t0, t1 := (lo-o)/v, (hi-o)/v
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		tmin, tmax = Max(tmin, t0), Min(tmax, t1)
+	}
+	return Max(tmin, 0), tmax
+}
+
+// shade returns the brightness (0..1) of the model on a ray, or -1 if the ray misses.
+func shade(s SDF3, p, d, light V3, bb Box3, eps float64) float64 {
+	t, tmax := rayBox(p, d, bb)
+	if t > tmax {
+		return -1
+	}
+	for i := 0; i < rayMaxSteps && t <= tmax; i++ {
+		q := p.Add(d.MulScalar(t))
+		dist := s.Evaluate(q)
+		if dist < eps {
+			// normal from the gradient
+			h := eps
+			n := V3{
+				s.Evaluate(q.Add(V3{h, 0, 0})) - s.Evaluate(q.Sub(V3{h, 0, 0})),
+				s.Evaluate(q.Add(V3{0, h, 0})) - s.Evaluate(q.Sub(V3{0, h, 0})),
+				s.Evaluate(q.Add(V3{0, 0, h})) - s.Evaluate(q.Sub(V3{0, 0, h})),
+			}.Normalize()
+			return 0.2 + 0.8*Max(0, n.Dot(light))
+		}
+		t += Max(rayStepFactor*dist, 0.1*eps)
+	}
+	return -1
+}
+
+// shadePalette returns a palette of the background and shades of the model color.
+func shadePalette(c color.Color) color.Palette {
+	r, g, b, _ := c.RGBA()
+	p := color.Palette{color.White}
+	for i := 0; i < rayShades; i++ {
+		k := float64(i) / float64(rayShades-1)
+		p = append(p, color.RGBA{
+			uint8(k * float64(r>>8)),
+			uint8(k * float64(g>>8)),
+			uint8(k * float64(b>>8)),
+			0xff,
+		})
+	}
+	return p
+}
+
+// Render ray marches an SDF3 to a paletted image, shaded with a color.
+func (c *Camera) Render(s SDF3, width, height int, col color.Color) (*image.Paletted, error) {
+	if s == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("bad image size")
+	}
+	if c.FOV <= 0 || c.FOV >= Pi {
+		return nil, errors.New("bad field of view")
+	}
+	f := c.Center.Sub(c.Eye).Normalize()
+	r := f.Cross(c.Up)
+	if r.Length() < epsilon {
+		return nil, errors.New("up is parallel to the view direction")
+	}
+	r = r.Normalize()
+	u := r.Cross(f)
+	// light from over the left shoulder of the camera
+	light := f.Neg().Add(u.MulScalar(0.5)).Sub(r.MulScalar(0.3)).Normalize()
+	bb := s.BoundingBox()
+	eps := rayHitFactor * bb.Size().Length()
+	k := math.Tan(0.5*c.FOV) / float64(height)
+	img := image.NewPaletted(image.Rect(0, 0, width, height), shadePalette(col))
+	// render one row per work item
+	var wg sync.WaitGroup
+	rows := make(chan int)
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				for x := 0; x < width; x++ {
+					dx := (2*float64(x) + 1 - float64(width)) * k
+					dy := (float64(height) - 2*float64(y) - 1) * k
+					d := f.Add(r.MulScalar(dx)).Add(u.MulScalar(dy)).Normalize()
+					i := 0
+					if v := shade(s, c.Eye, d, light, bb, eps); v >= 0 {
+						i = 1 + int(math.Round(v*float64(rayShades-1)))
+					}
+					img.SetColorIndex(x, y, uint8(i))
+				}
+			}
+		}()
+	}
+	for y := 0; y < height; y++ {
+		rows <- y
+	}
+	close(rows)
+	wg.Wait()
+	return img, nil
+}
+
+//-----------------------------------------------------------------------------
+
+// TurntableParms defines the parameters for a turntable animation.
+type TurntableParms struct {
+	Frames    int         // number of frames for one turn
+	Width     int         // image width (pixels)
+	Height    int         // image height (pixels)
+	Elevation float64     // camera elevation above the xy plane (radians)
+	Distance  float64     // camera distance from the center of the bounding box (0 = fit the model)
+	FOV       float64     // vertical field of view (radians, 0 = 30 degrees)
+	Color     color.Color // model color (nil = steel blue)
+}
+
+// Turntable returns frames of an SDF3 with the camera orbiting about the z-axis
+// through the center of its bounding box.
+func Turntable(s SDF3, k *TurntableParms) ([]*image.Paletted, error) {
+	if s == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if k.Frames <= 0 {
+		return nil, errors.New("frames <= 0")
+	}
+	if Abs(k.Elevation) >= 0.5*Pi {
+		return nil, errors.New("bad elevation")
+	}
+	fov := k.FOV
+	if fov == 0 {
+		fov = DtoR(30)
+	}
+	col := k.Color
+	if col == nil {
+		col = color.RGBA{0x46, 0x82, 0xb4, 0xff}
+	}
+	bb := s.BoundingBox()
+	center := bb.Center()
+	dist := k.Distance
+	if dist == 0 {
+		// the bounding sphere fills the view
+		dist = 0.5 * bb.Size().Length() / math.Sin(0.5*fov)
+	}
+	if dist < 0 {
+		return nil, errors.New("distance < 0")
+	}
+	frames := make([]*image.Paletted, k.Frames)
+	sinEl, cosEl := math.Sincos(k.Elevation)
+	for i := range frames {
+		sin, cos := math.Sincos(Tau * float64(i) / float64(k.Frames))
+		c := Camera{
+			Eye:    center.Add(V3{cosEl * cos, cosEl * sin, sinEl}.MulScalar(dist)),
+			Center: center,
+			Up:     V3{0, 0, 1},
+			FOV:    fov,
+		}
+		img, err := c.Render(s, k.Width, k.Height, col)
+		if err != nil {
+			return nil, err
+		}
+		frames[i] = img
+	}
+	return frames, nil
+}
+
+// SaveGIF saves frames as an animated (looping) GIF.
+func SaveGIF(
+	path string, // path to file
+	frames []*image.Paletted, // animation frames
+	delay int, // delay between frames (100ths of a second)
+) error {
+	if len(frames) == 0 {
+		return errors.New("no frames")
+	}
+	g := gif.GIF{Image: frames}
+	for range frames {
+		g.Delay = append(g.Delay, delay)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, &g); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//-----------------------------------------------------------------------------
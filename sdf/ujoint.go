@@ -0,0 +1,157 @@
+//-----------------------------------------------------------------------------
+/*
+
+Universal Joints and Gimbals
+
+Universal Joint: Two yokes joined by a cross. The cross pins turn in holes in
+the yoke arms. The parts are sized in proportion to the shaft diameter.
+
+Gimbal: An inner ring turning in an outer ring on pins along the x-axis. The
+outer ring has pins on the y-axis for mounting in a fork. The ring surfaces
+are spherical so the inner ring can turn through a full rotation.
+
+The parts are returned in their assembled positions, so they can be printed
+in place.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// alongY returns an SDF3 with its z-axis rotated onto the y-axis and centered at y.
+func alongY(s SDF3, y float64) SDF3 {
+	return Transform3D(s, Translate3d(V3{0, y, 0}).Mul(RotateX(DtoR(90))))
+}
+
+//-----------------------------------------------------------------------------
+// Universal Joint
+
+// UniversalJointParms defines the parameters for a universal joint.
+type UniversalJointParms struct {
+	Shaft     float64 // shaft diameter
+	Length    float64 // length of the shaft bore (0 = 1.5 * shaft diameter)
+	Clearance float64 // clearance between the moving parts
+}
+
+// UniversalJoint3D returns the yokes and cross of a universal joint.
+// The cross is centered on the origin. The first yoke is below it with arms on the x-axis,
+// the second yoke is above it with arms on the y-axis. The shafts are on the z-axis.
+func UniversalJoint3D(k *UniversalJointParms) ([]SDF3, error) {
+	if k.Shaft <= 0 {
+		return nil, errors.New("shaft <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+	if k.Length < 0 {
+		return nil, errors.New("length < 0")
+	}
+	d := k.Shaft
+	c := k.Clearance
+	l := k.Length
+	if l == 0 {
+		l = 1.5 * d
+	}
+
+	// proportions
+	rp := 0.25 * d            // pin radius
+	ho := rp + c + 0.25*d     // half width of the arms
+	xi := math.Sqrt2*ho + 2*c // inside radius of the arms
+	xo := xi + 0.5*d          // outside radius of the arms
+	// The arms of the two yokes can't collide at any angle if xi > sqrt(2) * ho.
+	// The arms of the other yoke swing within a radius of gap.
+	gap := math.Sqrt(xo*xo+ho*ho) + c
+
+	// cross
+	pins := Union3D(
+		alongX(Cylinder3D(2*xo, rp, 0), 0),
+		alongY(Cylinder3D(2*xo, rp, 0), 0),
+	)
+	cross := Union3D(Sphere3D(xi-c), pins)
+
+	// yoke arms are slabs within an annulus, with a rounded top
+	ring := Difference2D(Circle2D(xo), Circle2D(xi))
+	arms := Intersect3D(
+		Transform3D(Extrude3D(ring, 2*(gap+ho)), Translate3d(V3{0, 0, ho - gap})),
+		Union3D(
+			Transform3D(Box3D(V3{2 * xo, 2 * ho, gap}, 0), Translate3d(V3{0, 0, -0.5 * gap})),
+			alongX(Cylinder3D(2*xo, ho, 0), 0),
+		),
+	)
+	w := 2 * d // hub diameter
+	plate := Box3D(V3{2 * xo, Max(w, 2*ho), 0.5 * d}, 0)
+	plate = Transform3D(plate, Translate3d(V3{0, 0, -gap - 0.25*d}))
+	hub := Cylinder3D(l, 0.5*w, 0)
+	hub = Transform3D(hub, Translate3d(V3{0, 0, -gap - 0.5*d - 0.5*l}))
+	yoke := Union3D(arms, plate, hub)
+	bore := Cylinder3D(l, 0.5*d, 0)
+	bore = Transform3D(bore, Translate3d(V3{0, 0, -gap - 0.5*d - 0.5*l}))
+	holes := alongX(Cylinder3D(2*xo+1, rp+c, 0), 0)
+	yoke = Difference3D(yoke, Union3D(bore, holes))
+
+	// the second yoke is above the cross with arms on the y-axis
+	yoke1 := Transform3D(yoke, RotateX(Pi).Mul(RotateZ(0.5*Pi)))
+
+	return []SDF3{yoke, yoke1, cross}, nil
+}
+
+//-----------------------------------------------------------------------------
+// Gimbal
+
+// GimbalParms defines the parameters for a 2 axis gimbal.
+type GimbalParms struct {
+	Radius    float64 // inside radius of the inner ring
+	Ring      float64 // radial thickness of each ring
+	Height    float64 // height of the rings
+	PinRadius float64 // pin radius
+	Clearance float64 // clearance between the moving parts
+}
+
+// Gimbal3D returns the outer and inner rings of a 2 axis gimbal.
+// The inner ring turns on the x-axis, the outer ring has mounting pins on the y-axis.
+func Gimbal3D(k *GimbalParms) ([]SDF3, error) {
+	if k.Radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if k.Ring <= 0 {
+		return nil, errors.New("ring <= 0")
+	}
+	if k.Height <= 0 {
+		return nil, errors.New("height <= 0")
+	}
+	if k.Clearance < 0 {
+		return nil, errors.New("clearance < 0")
+	}
+	if k.PinRadius <= 0 || k.PinRadius+k.Clearance >= 0.5*k.Height {
+		return nil, errors.New("invalid pin radius")
+	}
+	h := 0.5 * k.Height
+	r1 := k.Radius + k.Ring // outside of the inner ring
+	r2 := r1 + k.Clearance  // inside of the outer ring
+	r3 := r2 + k.Ring       // outside of the outer ring
+	if r1*r1-h*h <= k.Radius*k.Radius {
+		return nil, errors.New("ring height is too large")
+	}
+
+	// inner ring with pins on the x-axis
+	inner := Intersect3D(Cylinder3D(k.Height, r1, 0), Sphere3D(r1))
+	inner = Union3D(inner, alongX(Cylinder3D(2*r3, k.PinRadius, 0), 0))
+	inner = Difference3D(inner, Cylinder3D(k.Height+1, k.Radius, 0))
+
+	// outer ring with holes on the x-axis and pins on the y-axis
+	outer := Intersect3D(Cylinder3D(k.Height, r3, 0), Sphere3D(r3))
+	outer = Union3D(outer, alongY(Cylinder3D(2*(r3+k.Ring), k.PinRadius, 0), 0))
+	holes := alongX(Cylinder3D(2*r3+1, k.PinRadius+k.Clearance, 0), 0)
+	outer = Difference3D(outer, Union3D(Sphere3D(r2), holes))
+
+	return []SDF3{outer, inner}, nil
+}
+
+//-----------------------------------------------------------------------------
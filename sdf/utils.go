@@ -55,7 +55,7 @@ func Clamp(x, a, b float64) float64 {
 
 // Mix does a linear interpolation from x to y, a = [0,1]
 func Mix(x, y, a float64) float64 {
-	return x + (a * (y - x))
+	return x + float64(a*(y-x))
 }
 
 //-----------------------------------------------------------------------------
@@ -106,9 +106,9 @@ func Sign(x float64) float64 {
 
 // SawTooth generates a sawtooth function. Returns [-period/2, period/2)
 func SawTooth(x, period float64) float64 {
-	x += period / 2
+	x += float64(period / 2)
 	t := x / period
-	return period*(t-math.Floor(t)) - period/2
+	return float64(period*(t-math.Floor(t))) - float64(period/2)
 }
 
 //-----------------------------------------------------------------------------
@@ -151,7 +151,7 @@ func PowMin(k float64) MinFunc {
 
 func poly(a, b, k float64) float64 {
 	h := Clamp(0.5+0.5*(b-a)/k, 0.0, 1.0)
-	return Mix(b, a, h) - k*h*(1.0-h)
+	return Mix(b, a, h) - float64(k*h*(1.0-h))
 }
 
 // PolyMin returns a minimum function (Try k = 0.1, a bigger k gives a bigger fillet).
@@ -0,0 +1,192 @@
+//-----------------------------------------------------------------------------
+/*
+
+Vase Mode (Single Wall) Toolpaths
+
+Slice an SDF3 into layers and keep only the outer contour of each layer.
+The contours are joined into a single continuous spiral, with z increasing
+steadily along each contour, so a shell can be printed as a single wall
+without any layer change seams.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+)
+
+//-----------------------------------------------------------------------------
+
+// VaseParms defines the parameters for a vase mode toolpath.
+type VaseParms struct {
+	LayerHeight float64 // height of each layer
+	MeshCells   int     // number of cells on the longest axis of a slice. e.g 200
+	FeedRate    float64 // feed rate (mm/min)
+	Extrusion   float64 // filament length per unit of toolpath length
+}
+
+//-----------------------------------------------------------------------------
+
+// chainLines joins line segments into closed loops.
+func chainLines(lines []*Line, tol float64) [][]V2 {
+	key := func(p V2) V2i { return p.DivScalar(tol).Add(V2{0.5, 0.5}).ToV2i() }
+	// map end points to lines
+	ends := make(map[V2i][]int)
+	for i, l := range lines {
+		ends[key(l[0])] = append(ends[key(l[0])], i)
+		ends[key(l[1])] = append(ends[key(l[1])], i)
+	}
+	used := make([]bool, len(lines))
+	var loops [][]V2
+	for i := range lines {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		loop := []V2{lines[i][0]}
+		p := lines[i][1]
+		for key(p) != key(loop[0]) {
+			loop = append(loop, p)
+			next := -1
+			for _, j := range ends[key(p)] {
+				if !used[j] {
+					next = j
+					break
+				}
+			}
+			if next < 0 {
+				// open chain
+				break
+			}
+			used[next] = true
+			if key(lines[next][0]) == key(p) {
+				p = lines[next][1]
+			} else {
+				p = lines[next][0]
+			}
+		}
+		if len(loop) >= 3 {
+			loops = append(loops, loop)
+		}
+	}
+	return loops
+}
+
+// loopArea returns the signed area of a closed loop (positive for counter-clockwise).
+func loopArea(loop []V2) float64 {
+	a := 0.0
+	for i, p := range loop {
+		a += p.Cross(loop[(i+1)%len(loop)])
+	}
+	return 0.5 * a
+}
+
+// outerContour returns the outer contour of a z-slice of an SDF3 (counter-clockwise).
+func outerContour(s SDF3, z float64, meshCells int) []V2 {
+	s2 := Slice2D(s, V3{0, 0, z}, V3{0, 0, 1})
+	bb := s2.BoundingBox()
+	step := bb.Size().MaxComponent() / float64(meshCells)
+	bb = NewBox2(bb.Center(), bb.Size().AddScalar(2*step))
+	loops := chainLines(marchingSquares(s2, bb, step), 1e-3*step)
+	var outer []V2
+	maxArea := 0.0
+	for _, l := range loops {
+		if a := Abs(loopArea(l)); a > maxArea {
+			outer, maxArea = l, a
+		}
+	}
+	if outer != nil && loopArea(outer) < 0 {
+		// make it counter-clockwise
+		for i, j := 0, len(outer)-1; i < j; i, j = i+1, j-1 {
+			outer[i], outer[j] = outer[j], outer[i]
+		}
+	}
+	return outer
+}
+
+// VasePath returns a continuous spiral toolpath following the outer contours of an SDF3.
+// The bottom of the SDF3 is on the bed (z = 0). The first contour is printed flat at
+// the layer height, and the toolpath rises by a layer height on each following contour.
+func VasePath(s SDF3, k *VaseParms) ([]V3, error) {
+	if k.LayerHeight <= 0 {
+		return nil, errors.New("LayerHeight <= 0")
+	}
+	if k.MeshCells <= 0 {
+		return nil, errors.New("MeshCells <= 0")
+	}
+	bb := s.BoundingBox()
+	layers := int(math.Floor(bb.Size().Z / k.LayerHeight))
+	var path []V3
+	for i := 0; i < layers; i++ {
+		z0 := float64(i) * k.LayerHeight
+		loop := outerContour(s, bb.Min.Z+z0+0.5*k.LayerHeight, k.MeshCells)
+		if loop == nil {
+			continue
+		}
+		// start the loop at the point closest to the end of the previous loop
+		if len(path) != 0 {
+			end := V2{path[len(path)-1].X, path[len(path)-1].Y}
+			j := 0
+			for i, p := range loop {
+				if p.Sub(end).Length2() < loop[j].Sub(end).Length2() {
+					j = i
+				}
+			}
+			loop = append(loop[j:], loop[:j]...)
+		}
+		// z rises by a layer height over the length of the loop
+		length := 0.0
+		for i, p := range loop {
+			length += p.Sub(loop[(i+1)%len(loop)]).Length()
+		}
+		rise := k.LayerHeight
+		if len(path) == 0 {
+			// the first contour is flat
+			z0, rise = k.LayerHeight, 0
+		}
+		d := 0.0
+		for i, p := range loop {
+			path = append(path, V3{p.X, p.Y, z0 + rise*d/length})
+			d += p.Sub(loop[(i+1)%len(loop)]).Length()
+		}
+	}
+	if len(path) == 0 {
+		return nil, errors.New("no contours found")
+	}
+	return path, nil
+}
+
+// SaveVaseGCode writes a vase mode toolpath as G-code.
+func SaveVaseGCode(path string, toolpath []V3, k *VaseParms) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf := bufio.NewWriter(f)
+	fmt.Fprintf(buf, "; vase mode toolpath, layer height %.3f\n", k.LayerHeight)
+	fmt.Fprintf(buf, "G21 ; millimeters\n")
+	fmt.Fprintf(buf, "G90 ; absolute positioning\n")
+	fmt.Fprintf(buf, "M82 ; absolute extrusion\n")
+	fmt.Fprintf(buf, "G92 E0\n")
+	if len(toolpath) != 0 {
+		p := toolpath[0]
+		fmt.Fprintf(buf, "G0 X%.3f Y%.3f Z%.3f\n", p.X, p.Y, p.Z)
+		fmt.Fprintf(buf, "G1 F%.0f\n", k.FeedRate)
+	}
+	e := 0.0
+	for i := 1; i < len(toolpath); i++ {
+		p := toolpath[i]
+		e += p.Sub(toolpath[i-1]).Length() * k.Extrusion
+		fmt.Fprintf(buf, "G1 X%.3f Y%.3f Z%.3f E%.5f\n", p.X, p.Y, p.Z, e)
+	}
+	return buf.Flush()
+}
+
+//-----------------------------------------------------------------------------
@@ -94,28 +94,37 @@ func (b *Box3) RandomSet(n int) V3Set {
 }
 
 //-----------------------------------------------------------------------------
+// Some architectures (E.g. arm64) fuse a*b + c into a single FMA instruction
+// which rounds differently to amd64. The explicit float64() conversions in
+// the vector/matrix functions, Mix, PolyMin/PolyMax, the marching cubes/squares
+// grids and interpolation, the primitives, CSG operations and transforms of
+// sdf2.go/sdf3.go, the shapes2.go/shapes3.go shapes and the Float32 nodes prevent
+// the fusion. Fusion can also happen across an inlined call, so constructors
+// convert parameters that are used in a sum. A mesh built from these is
+// bit-identical across platforms. Other SDFs (E.g. gears, threads, splines)
+// may still fuse, so their meshes are only repeatable on the same platform.
 
 // Dot returns the dot product of a and b.
 func (a V3) Dot(b V3) float64 {
-	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+	return float64(a.X*b.X) + float64(a.Y*b.Y) + float64(a.Z*b.Z)
 }
 
 // Dot returns the dot product of a and b.
 func (a V2) Dot(b V2) float64 {
-	return a.X*b.X + a.Y*b.Y
+	return float64(a.X*b.X) + float64(a.Y*b.Y)
 }
 
 // Cross returns the cross product of a and b.
 func (a V3) Cross(b V3) V3 {
-	x := a.Y*b.Z - a.Z*b.Y
-	y := a.Z*b.X - a.X*b.Z
-	z := a.X*b.Y - a.Y*b.X
+	x := float64(a.Y*b.Z) - float64(a.Z*b.Y)
+	y := float64(a.Z*b.X) - float64(a.X*b.Z)
+	z := float64(a.X*b.Y) - float64(a.Y*b.X)
 	return V3{x, y, z}
 }
 
 // Cross returns the cross product of a and b.
 func (a V2) Cross(b V2) float64 {
-	return (a.X * b.Y) - (a.Y * b.X)
+	return float64(a.X*b.Y) - float64(a.Y*b.X)
 }
 
 // colinearSlow return true if 3 points are colinear (slow test).
@@ -158,12 +167,12 @@ func (a V2) SubScalar(b float64) V2 {
 
 // MulScalar multiplies each vector component by a scalar.
 func (a V3) MulScalar(b float64) V3 {
-	return V3{a.X * b, a.Y * b, a.Z * b}
+	return V3{float64(a.X * b), float64(a.Y * b), float64(a.Z * b)}
 }
 
 // MulScalar multiplies each vector component by a scalar.
 func (a V2) MulScalar(b float64) V2 {
-	return V2{a.X * b, a.Y * b}
+	return V2{float64(a.X * b), float64(a.Y * b)}
 }
 
 // DivScalar divides each vector component by a scalar.
@@ -254,12 +263,12 @@ func (a V2) Sub(b V2) V2 {
 
 // Mul multiplies two vectors by component.
 func (a V3) Mul(b V3) V3 {
-	return V3{a.X * b.X, a.Y * b.Y, a.Z * b.Z}
+	return V3{float64(a.X * b.X), float64(a.Y * b.Y), float64(a.Z * b.Z)}
 }
 
 // Mul multiplies two vectors by component.
 func (a V2) Mul(b V2) V2 {
-	return V2{a.X * b.X, a.Y * b.Y}
+	return V2{float64(a.X * b.X), float64(a.Y * b.Y)}
 }
 
 // Div divides two vectors by component.
@@ -324,22 +333,22 @@ func (a V2Set) Max() V2 {
 
 // Length returns the vector length.
 func (a V3) Length() float64 {
-	return math.Sqrt(a.X*a.X + a.Y*a.Y + a.Z*a.Z)
+	return math.Sqrt(a.Length2())
 }
 
 // Length2 returns the vector length * length.
 func (a V3) Length2() float64 {
-	return a.X*a.X + a.Y*a.Y + a.Z*a.Z
+	return float64(a.X*a.X) + float64(a.Y*a.Y) + float64(a.Z*a.Z)
 }
 
 // Length returns the vector length.
 func (a V2) Length() float64 {
-	return math.Sqrt(a.X*a.X + a.Y*a.Y)
+	return math.Sqrt(a.Length2())
 }
 
 // Length2 returns the vector length * length.
 func (a V2) Length2() float64 {
-	return a.X*a.X + a.Y*a.Y
+	return float64(a.X*a.X) + float64(a.Y*a.Y)
 }
 
 // MinComponent returns the minimum component of the vector.
@@ -0,0 +1,184 @@
+//-----------------------------------------------------------------------------
+/*
+
+SDF Tree Traversal
+
+An SDF model is a tree of SDF2/SDF3 nodes. Nodes with child nodes implement
+the Parent interface so external tools can walk and inspect the tree.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// Parent is implemented by SDF2/SDF3 nodes with child nodes.
+// Each child is an SDF2 or an SDF3.
+type Parent interface {
+	Children() []interface{}
+}
+
+// Children returns the child nodes of an SDF2/SDF3 (nil for a leaf node).
+func Children(node interface{}) []interface{} {
+	if p, ok := node.(Parent); ok {
+		return p.Children()
+	}
+	return nil
+}
+
+// Walk traverses an SDF tree depth first, calling fn for each node.
+// The children of a node are skipped if fn returns false.
+func Walk(node interface{}, fn func(node interface{}, depth int) bool) {
+	walk(node, fn, 0)
+}
+
+func walk(node interface{}, fn func(node interface{}, depth int) bool, depth int) {
+	if !fn(node, depth) {
+		return
+	}
+	for _, c := range Children(node) {
+		walk(c, fn, depth+1)
+	}
+}
+
+// NodeName returns the type name of an SDF2/SDF3 node.
+func NodeName(node interface{}) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", node), "*sdf.")
+}
+
+// PrintTree writes an indented listing of an SDF tree.
+func PrintTree(w io.Writer, node interface{}) {
+	Walk(node, func(n interface{}, depth int) bool {
+		fmt.Fprintf(w, "%s%s\n", strings.Repeat("  ", depth), NodeName(n))
+		return true
+	})
+}
+
+//-----------------------------------------------------------------------------
+// SDF2 nodes
+
+// Children returns the child nodes of an offset SDF2.
+func (s *OffsetSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a cut SDF2.
+func (s *CutSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a transformed SDF2.
+func (s *TransformSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a uniformly scaled SDF2.
+func (s *ScaleUniformSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a scaled SDF2.
+func (s *ScaleSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of an array SDF2.
+func (s *ArraySDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a rotate/union SDF2.
+func (s *RotateUnionSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a rotate/copy SDF2.
+func (s *RotateCopySDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a sliced SDF3.
+func (s *SliceSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a projection.
+func (s *ProjectSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of an SDF2 union.
+func (s *UnionSDF2) Children() []interface{} {
+	c := make([]interface{}, len(s.sdf))
+	for i, x := range s.sdf {
+		c[i] = x
+	}
+	return c
+}
+
+// Children returns the child nodes of an SDF2 difference.
+func (s *DifferenceSDF2) Children() []interface{} { return []interface{}{s.s0, s.s1} }
+
+// Children returns the child nodes of an elongated SDF2.
+func (s *ElongateSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a gear rack.
+func (s *GearRackSDF2) Children() []interface{} { return []interface{}{s.tooth} }
+
+// Children returns the child nodes of a profiled SDF2.
+func (s *ProfileSDF2) Children() []interface{} { return []interface{}{s.sdf} }
+
+//-----------------------------------------------------------------------------
+// SDF3 nodes
+
+// Children returns the child nodes of a solid of revolution.
+func (s *SorSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of an extrusion.
+func (s *ExtrudeSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a rounded extrusion.
+func (s *ExtrudeRoundedSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a loft.
+func (s *LoftSDF3) Children() []interface{} { return []interface{}{s.sdf0, s.sdf1} }
+
+// Children returns the child nodes of a screw.
+func (s *ScrewSDF3) Children() []interface{} { return []interface{}{s.thread} }
+
+// Children returns the child nodes of a transformed SDF3.
+func (s *TransformSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a uniformly scaled SDF3.
+func (s *ScaleUniformSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a scaled SDF3.
+func (s *ScaleSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of an SDF3 union.
+func (s *UnionSDF3) Children() []interface{} {
+	c := make([]interface{}, len(s.sdf))
+	for i, x := range s.sdf {
+		c[i] = x
+	}
+	return c
+}
+
+// Children returns the child nodes of an SDF3 difference.
+func (s *DifferenceSDF3) Children() []interface{} { return []interface{}{s.s0, s.s1} }
+
+// Children returns the child nodes of an elongated SDF3.
+func (s *ElongateSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of an SDF3 intersection.
+func (s *IntersectionSDF3) Children() []interface{} { return []interface{}{s.s0, s.s1} }
+
+// Children returns the child nodes of a cut SDF3.
+func (s *CutSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of an array SDF3.
+func (s *ArraySDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a rotate/union SDF3.
+func (s *RotateUnionSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a rotate/copy SDF3.
+func (s *RotateCopySDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a connected SDF3.
+func (s *ConnectedSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a profiled SDF3.
+func (s *ProfileSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+// Children returns the child nodes of a float32 SDF3.
+func (s *Float32SDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,212 @@
+//-----------------------------------------------------------------------------
+/*
+
+Voxel Cached SDF3
+
+Sample an SDF3 on a uniform grid and evaluate it with trilinear interpolation.
+This turns an expensive SDF3 (E.g. a triangle mesh) into a cheap one.
+
+The voxel grid can be saved to a file, E.g. as a reference model for
+regression tests.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+)
+
+//-----------------------------------------------------------------------------
+
+// VoxelSDF3 is an SDF3 sampled on a uniform grid.
+type VoxelSDF3 struct {
+	origin V3        // position of the grid point at index 0,0,0
+	step   V3        // grid point spacing
+	n      V3i       // number of grid points on each axis
+	value  []float64 // sampled distance values
+	bb     Box3      // bounding box
+}
+
+// Voxel3D returns an SDF3 sampled on a uniform grid.
+func Voxel3D(
+	sdf SDF3, // sdf3 to sample
+	meshCells int, // number of cells on the longest axis. e.g 200
+) SDF3 {
+	// work out the grid
+	bb := sdf.BoundingBox().ScaleAboutCenter(1.05)
+	size := bb.Size()
+	resolution := size.MaxComponent() / float64(meshCells)
+	// at least one cell on each axis (E.g. a planar mesh)
+	size = size.Max(V3{resolution, resolution, resolution})
+	bb = NewBox3(bb.Center(), size)
+	cells := size.DivScalar(resolution).Ceil().ToV3i()
+	s := VoxelSDF3{
+		origin: bb.Min,
+		step:   size.Div(cells.ToV3()),
+		n:      cells.AddScalar(1),
+		bb:     bb,
+	}
+	s.value = make([]float64, s.n[0]*s.n[1]*s.n[2])
+	sampleGrid(sdf, s.origin, s.step, s.n, func(i int, p V3, d float64) {
+		s.value[i] = d
+	})
+	return &s
+}
+
+// sampleGrid evaluates an SDF3 at the points of a grid, one x-layer per work item.
+// fn is called concurrently with the index (x*n[1]+y)*n[2]+z, position and value of each grid point.
+func sampleGrid(s SDF3, origin, step V3, n V3i, fn func(i int, p V3, d float64)) {
+	var wg sync.WaitGroup
+	layers := make(chan int)
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for x := range layers {
+				for y := 0; y < n[1]; y++ {
+					for z := 0; z < n[2]; z++ {
+						p := origin.Add(V3i{x, y, z}.ToV3().Mul(step))
+						fn((x*n[1]+y)*n[2]+z, p, s.Evaluate(p))
+					}
+				}
+			}
+		}()
+	}
+	for x := 0; x < n[0]; x++ {
+		layers <- x
+	}
+	close(layers)
+	wg.Wait()
+}
+
+// index returns the value index for grid point x,y,z.
+func (s *VoxelSDF3) index(x, y, z int) int {
+	return (x*s.n[1]+y)*s.n[2] + z
+}
+
+// Evaluate returns the minimum distance to a voxel sampled SDF3.
+func (s *VoxelSDF3) Evaluate(p V3) float64 {
+	// points outside the grid use the nearest grid boundary value
+	q := p.Clamp(s.bb.Min, s.bb.Max)
+	d := p.Sub(q).Length()
+	// grid coordinates
+	g := q.Sub(s.origin).Div(s.step)
+	i := g.ToV3i()
+	for k := range i {
+		if i[k] >= s.n[k]-1 {
+			i[k] = s.n[k] - 2
+		}
+	}
+	t := g.Sub(i.ToV3())
+	// trilinear interpolation
+	x, y, z := i[0], i[1], i[2]
+	c00 := Mix(s.value[s.index(x, y, z)], s.value[s.index(x+1, y, z)], t.X)
+	c01 := Mix(s.value[s.index(x, y, z+1)], s.value[s.index(x+1, y, z+1)], t.X)
+	c10 := Mix(s.value[s.index(x, y+1, z)], s.value[s.index(x+1, y+1, z)], t.X)
+	c11 := Mix(s.value[s.index(x, y+1, z+1)], s.value[s.index(x+1, y+1, z+1)], t.X)
+	c0 := Mix(c00, c10, t.Y)
+	c1 := Mix(c01, c11, t.Y)
+	return Mix(c0, c1, t.Z) + d
+}
+
+// BoundingBox returns the bounding box of a voxel sampled SDF3.
+func (s *VoxelSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+//-----------------------------------------------------------------------------
+// Voxel File
+
+// voxelMagic identifies a voxel file.
+var voxelMagic = [8]byte{'S', 'D', 'F', 'X', 'V', 'O', 'X', '1'}
+
+// voxelHeader is the voxel file header.
+type voxelHeader struct {
+	Magic  [8]byte
+	Origin [3]float64
+	Step   [3]float64
+	Max    [3]float64
+	N      [3]int32
+}
+
+// SaveVoxel saves a voxel sampled SDF3 to a file. The values are stored as float32.
+func SaveVoxel(path string, s SDF3) error {
+	v, ok := s.(*VoxelSDF3)
+	if !ok {
+		return errors.New("not a voxel sdf3")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	hdr := voxelHeader{
+		Magic:  voxelMagic,
+		Origin: [3]float64{v.origin.X, v.origin.Y, v.origin.Z},
+		Step:   [3]float64{v.step.X, v.step.Y, v.step.Z},
+		Max:    [3]float64{v.bb.Max.X, v.bb.Max.Y, v.bb.Max.Z},
+		N:      [3]int32{int32(v.n[0]), int32(v.n[1]), int32(v.n[2])},
+	}
+	if err := binary.Write(w, binary.LittleEndian, &hdr); err != nil {
+		f.Close()
+		return err
+	}
+	value := make([]float32, len(v.value))
+	for i, x := range v.value {
+		value[i] = float32(x)
+	}
+	if err := binary.Write(w, binary.LittleEndian, value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadVoxel loads a voxel sampled SDF3 from a file.
+func LoadVoxel(path string) (SDF3, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	var hdr voxelHeader
+	if err := binary.Read(r, binary.LittleEndian, &hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Magic != voxelMagic {
+		return nil, errors.New("not a voxel file")
+	}
+	n := V3i{int(hdr.N[0]), int(hdr.N[1]), int(hdr.N[2])}
+	if n[0] < 2 || n[1] < 2 || n[2] < 2 {
+		return nil, errors.New("bad voxel grid size")
+	}
+	value := make([]float32, n[0]*n[1]*n[2])
+	if err := binary.Read(r, binary.LittleEndian, value); err != nil {
+		return nil, err
+	}
+	s := VoxelSDF3{
+		origin: V3{hdr.Origin[0], hdr.Origin[1], hdr.Origin[2]},
+		step:   V3{hdr.Step[0], hdr.Step[1], hdr.Step[2]},
+		n:      n,
+		value:  make([]float64, len(value)),
+	}
+	for i, x := range value {
+		s.value[i] = float64(x)
+	}
+	s.bb = Box3{s.origin, V3{hdr.Max[0], hdr.Max[1], hdr.Max[2]}}
+	return &s, nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,106 @@
+//-----------------------------------------------------------------------------
+/*
+
+Fillet Weld Beads
+
+A fillet weld fills the corner where two solids meet. The bead is found from
+the two distance fields: it is the region close to both surfaces (and outside
+both solids) near their intersection curve. When the surfaces are
+perpendicular the bead has legs of the given length on each surface.
+
+Bead styles:
+
+triangular: a flat face across the corner (a 45 degree chamfer)
+concave: a circular fillet with a radius of the leg length
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import "errors"
+
+//-----------------------------------------------------------------------------
+
+// WeldStyle is the cross section of a weld bead.
+type WeldStyle int
+
+const (
+	// WeldTriangular is a flat faced bead.
+	WeldTriangular WeldStyle = iota
+	// WeldConcave is a concave (circular fillet) bead.
+	WeldConcave
+)
+
+// WeldSDF3 is two solids joined with a fillet weld bead.
+type WeldSDF3 struct {
+	s0, s1 SDF3      // welded solids
+	leg    float64   // leg length of the bead
+	style  WeldStyle // bead style
+	bead   bool      // the bead only (without the solids)
+	bb     Box3      // bounding box
+}
+
+// newWeld returns a weld SDF3.
+func newWeld(s0, s1 SDF3, leg float64, style WeldStyle, bead bool) (SDF3, error) {
+	if s0 == nil || s1 == nil {
+		return nil, errors.New("nil sdf")
+	}
+	if leg <= 0 {
+		return nil, errors.New("leg <= 0")
+	}
+	if style != WeldTriangular && style != WeldConcave {
+		return nil, errors.New("bad weld style")
+	}
+	// the bead is within the leg length of both solids
+	bb := s0.BoundingBox().Extend(s1.BoundingBox())
+	d := V3{leg, leg, leg}
+	bb = Box3{bb.Min.Sub(d), bb.Max.Add(d)}
+	return &WeldSDF3{s0, s1, leg, style, bead, bb}, nil
+}
+
+// Weld3D returns the union of two solids with a fillet weld bead along their intersection.
+func Weld3D(
+	s0, s1 SDF3, // welded solids
+	leg float64, // leg length of the bead
+	style WeldStyle, // bead style
+) (SDF3, error) {
+	return newWeld(s0, s1, leg, style, false)
+}
+
+// WeldBead3D returns the fillet weld bead along the intersection of two solids, without the solids.
+func WeldBead3D(
+	s0, s1 SDF3, // welded solids
+	leg float64, // leg length of the bead
+	style WeldStyle, // bead style
+) (SDF3, error) {
+	return newWeld(s0, s1, leg, style, true)
+}
+
+// Evaluate returns the minimum distance to a weld.
+func (s *WeldSDF3) Evaluate(p V3) float64 {
+	a := s.s0.Evaluate(p)
+	b := s.s1.Evaluate(p)
+	var d float64
+	switch s.style {
+	case WeldTriangular:
+		d = ChamferMin(s.leg)(a, b)
+	case WeldConcave:
+		d = RoundMin(s.leg)(a, b)
+	}
+	if s.bead {
+		// remove the solids
+		return Max(d, -Min(a, b))
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of a weld.
+func (s *WeldSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a weld.
+func (s *WeldSDF3) Children() []interface{} { return []interface{}{s.s0, s.s1} }
+
+//-----------------------------------------------------------------------------
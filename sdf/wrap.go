@@ -0,0 +1,252 @@
+//-----------------------------------------------------------------------------
+/*
+
+Wrapped Patterns
+
+Map an SDF2 pattern (text, logos, knurls) onto the surface of a cylinder,
+cone or sphere to emboss or engrave it.
+
+Cylinder/cone pattern coordinates: x is the distance around the surface (the
+arc length at the surface radius, counter-clockwise from the +x axis) and y is
+the distance along the surface from z = 0 (the slant height for a cone).
+Patterns that run past the +/- x seam (at the -x axis) wrap around.
+
+Sphere pattern coordinates (distances are on the surface of the sphere):
+
+equirectangular: x is the longitude (counter-clockwise from the +x axis) and y
+is the latitude (from the equator), both as distances at the sphere radius.
+The x seam wraps around as for a cylinder. Shapes are stretched around the
+circumference away from the equator.
+
+azimuthal: an azimuthal equidistant projection about the +z pole, the distance
+from the origin is the distance from the pole, with +x toward the +x axis.
+Shapes are stretched around the circumference away from the pole.
+
+*/
+//-----------------------------------------------------------------------------
+
+package sdf
+
+import (
+	"errors"
+	"math"
+)
+
+//-----------------------------------------------------------------------------
+
+// WrapSDF3 is an SDF2 pattern wrapped onto a cylinder or cone as a relief.
+type WrapSDF3 struct {
+	sdf      SDF2    // pattern
+	radius   float64 // surface radius at z = 0
+	tan, cos float64 // cone taper
+	d0, d1   float64 // relief offsets from the surface (along the normal)
+	bb       Box3    // bounding box
+}
+
+// Wrap3D returns an SDF2 pattern wrapped onto the surface of a cylinder or cone (about the
+// z-axis) as a relief. A positive depth is raised from the surface (union it with the part),
+// a negative depth is recessed into the surface (subtract it from the part). The relief runs
+// through the surface by 10% of the depth so the union/difference is clean.
+func Wrap3D(
+	pattern SDF2, // pattern in surface coordinates
+	radius float64, // surface radius at z = 0
+	taper float64, // cone half angle (radians, 0 = cylinder, > 0 the radius increases with z)
+	depth float64, // relief depth (> 0 emboss, < 0 engrave)
+) (SDF3, error) {
+	if pattern == nil {
+		return nil, errors.New("nil pattern")
+	}
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if Abs(taper) >= DtoR(80) {
+		return nil, errors.New("taper >= 80 degrees")
+	}
+	if depth == 0 {
+		return nil, errors.New("depth == 0")
+	}
+	s := WrapSDF3{
+		sdf:    pattern,
+		radius: radius,
+		tan:    math.Tan(taper),
+		cos:    math.Cos(taper),
+	}
+	s.d0, s.d1 = reliefOffsets(depth)
+	// the pattern must stay on the cone
+	pbb := pattern.BoundingBox()
+	z0 := pbb.Min.Y * s.cos
+	z1 := pbb.Max.Y * s.cos
+	if s.surfaceRadius(z0)+s.d0 <= 0 || s.surfaceRadius(z1)+s.d0 <= 0 {
+		return nil, errors.New("the pattern runs past the tip of the cone")
+	}
+	r := Max(s.surfaceRadius(z0), s.surfaceRadius(z1)) + s.d1/s.cos
+	dz := (Abs(s.d0) + Abs(s.d1)) * Abs(math.Sin(taper))
+	s.bb = Box3{V3{-r, -r, z0 - dz}, V3{r, r, z1 + dz}}
+	return &s, nil
+}
+
+// reliefOffsets returns the offsets of a relief from the surface, running through the surface by
+// 10% of the depth.
+func reliefOffsets(depth float64) (float64, float64) {
+	e := 0.1 * Abs(depth)
+	if depth > 0 {
+		return -e, depth
+	}
+	return depth, e
+}
+
+// surfaceRadius returns the radius of the surface at a height.
+func (s *WrapSDF3) surfaceRadius(z float64) float64 {
+	return s.radius + z*s.tan
+}
+
+// Evaluate returns the minimum distance to a wrapped pattern.
+func (s *WrapSDF3) Evaluate(p V3) float64 {
+	r := math.Sqrt(p.X*p.X + p.Y*p.Y)
+	rs := s.surfaceRadius(p.Z)
+	// offset from the surface along its normal
+	h := (r - rs) * s.cos
+	// position on the surface
+	theta := math.Atan2(p.Y, p.X)
+	u := theta * rs
+	v := p.Z/s.cos + h*s.tan
+	d := wrapSeam(s.sdf, u, v, Tau*rs)
+	// on a cone u also changes with z
+	d /= math.Sqrt(1 + theta*theta*s.tan*s.tan)
+	d = wrapDistance(d, r, rs)
+	return Max(d, Max(s.d0-h, h-s.d1))
+}
+
+// wrapSeam returns the distance to a pattern wrapped around a seam. The pattern copies
+// within half a turn of the point are used.
+func wrapSeam(s SDF2, u, v, c float64) float64 {
+	d := s.Evaluate(V2{u, v})
+	bb := s.BoundingBox()
+	if bb.Max.X > u+0.5*c {
+		d = Min(d, s.Evaluate(V2{u + c, v}))
+	}
+	if bb.Min.X < u-0.5*c {
+		d = Min(d, s.Evaluate(V2{u - c, v}))
+	}
+	return d
+}
+
+// wrapDistance returns a lower bound for the distance to a wrapped pattern, given the pattern
+// distance (an arc length on a surface of radius rs) and the distance r from the center.
+// The distance to the radial line through a pattern point at angle a is r * sin(a) (for
+// a < pi/2). It's limited to the surface radius so the distance changes by at most 1 per unit
+// distance outside the surface.
+func wrapDistance(d, r, rs float64) float64 {
+	return math.Copysign(Clamp(r, 0, rs)*math.Sin(Min(Abs(d)/rs, 0.5*Pi)), d)
+}
+
+// BoundingBox returns the bounding box of a wrapped pattern.
+func (s *WrapSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a wrapped pattern.
+func (s *WrapSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+//-----------------------------------------------------------------------------
+// Spherical Wrapping
+
+// SphereProjection is the projection of a pattern onto a sphere.
+type SphereProjection int
+
+const (
+	// Equirectangular maps longitude and latitude to x and y.
+	Equirectangular SphereProjection = iota
+	// Azimuthal is an azimuthal equidistant projection about the +z pole.
+	Azimuthal
+)
+
+// WrapSphereSDF3 is an SDF2 pattern wrapped onto a sphere as a relief.
+type WrapSphereSDF3 struct {
+	sdf        SDF2             // pattern
+	radius     float64          // sphere radius
+	projection SphereProjection // pattern projection
+	d0, d1     float64          // relief offsets from the surface
+	bb         Box3             // bounding box
+}
+
+// WrapSphere3D returns an SDF2 pattern wrapped onto the surface of a sphere (centered on the
+// origin) as a relief. A positive depth is raised from the surface (union it with the part),
+// a negative depth is recessed into the surface (subtract it from the part).
+func WrapSphere3D(
+	pattern SDF2, // pattern in surface coordinates
+	radius float64, // sphere radius
+	projection SphereProjection, // pattern projection
+	depth float64, // relief depth (> 0 emboss, < 0 engrave)
+) (SDF3, error) {
+	if pattern == nil {
+		return nil, errors.New("nil pattern")
+	}
+	if radius <= 0 {
+		return nil, errors.New("radius <= 0")
+	}
+	if projection != Equirectangular && projection != Azimuthal {
+		return nil, errors.New("bad projection")
+	}
+	if depth == 0 || depth <= -radius {
+		return nil, errors.New("bad depth")
+	}
+	s := WrapSphereSDF3{
+		sdf:        pattern,
+		radius:     radius,
+		projection: projection,
+	}
+	s.d0, s.d1 = reliefOffsets(depth)
+	r := radius + s.d1
+	s.bb = Box3{V3{-r, -r, -r}, V3{r, r, r}}
+	return &s, nil
+}
+
+// Evaluate returns the minimum distance to a pattern wrapped on a sphere.
+func (s *WrapSphereSDF3) Evaluate(p V3) float64 {
+	r := p.Length()
+	h := r - s.radius
+	lon := math.Atan2(p.Y, p.X)
+	var d float64
+	// The pattern distance d is a lower bound for the distance on the sphere away from the
+	// stretching. A point moving x on the sphere moves at most x/R in latitude (or colatitude),
+	// so the stretch along the way is bounded, and integrating it gives the great circle
+	// distance.
+	switch s.projection {
+	case Equirectangular:
+		lat := math.Asin(Clamp(p.Z/Max(r, epsilon), -1, 1))
+		d = wrapSeam(s.sdf, lon*s.radius, lat*s.radius, Tau*s.radius)
+		// circumferential distances are stretched by 1/cos(lat)
+		a := Abs(lat)
+		x := s.radius * (gd(gdInverse(a)+Abs(d)/s.radius) - a)
+		d = math.Copysign(x, d)
+	case Azimuthal:
+		colat := math.Acos(Clamp(p.Z/Max(r, epsilon), -1, 1))
+		d = s.sdf.Evaluate(PolarToXY(colat*s.radius, lon))
+		// circumferential distances are stretched by colat/sin(colat) <= 1/cos^2(colat/2)
+		x := s.radius * (2*math.Atan(math.Tan(0.5*colat)+0.5*Abs(d)/s.radius) - colat)
+		d = math.Copysign(x, d)
+	}
+	d = wrapDistance(d, r, s.radius)
+	return Max(d, Max(s.d0-h, h-s.d1))
+}
+
+// gd returns the Gudermannian function.
+func gd(x float64) float64 {
+	return math.Atan(math.Sinh(x))
+}
+
+// gdInverse returns the inverse of the Gudermannian function.
+func gdInverse(x float64) float64 {
+	return math.Atanh(math.Sin(x))
+}
+
+// BoundingBox returns the bounding box of a pattern wrapped on a sphere.
+func (s *WrapSphereSDF3) BoundingBox() Box3 {
+	return s.bb
+}
+
+// Children returns the child nodes of a pattern wrapped on a sphere.
+func (s *WrapSphereSDF3) Children() []interface{} { return []interface{}{s.sdf} }
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,443 @@
+//-----------------------------------------------------------------------------
+/*
+
+C Shared Library API
+
+A C API for constructing SDFs, evaluating them and meshing them to buffers,
+so the kernel can be used from C, C++, Python, Rust, etc.
+
+Build:
+
+go build -buildmode=c-shared -o libsdfx.so
+
+This generates libsdfx.so and the libsdfx.h header.
+
+Shapes and meshes are referred to by integer handles. A handle of 0 indicates
+an error, sdfx_error() returns the last error message. Call sdfx_free(h) to
+release shapes and meshes that are no longer needed.
+
+C:
+
+int s = sdfx_difference(sdfx_box(20, 20, 20, 2), sdfx_sphere(12));
+int m = sdfx_mesh(s, 100);
+int n = sdfx_mesh_triangles(m);
+float *v = malloc(9 * n * sizeof(float));
+sdfx_mesh_vertices(m, v);
+
+Python:
+
+lib = ctypes.CDLL("./libsdfx.so")
+lib.sdfx_sphere.argtypes = [ctypes.c_double]
+s = lib.sdfx_sphere(10)
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+// handles
+
+// meshBuffer is a rendered mesh.
+type meshBuffer struct {
+	vertices []float32
+	normals  []float32
+}
+
+var lock sync.Mutex
+var objects = map[C.int]interface{}{}
+var nextHandle C.int = 1
+var lastError *C.char
+
+// put stores an object and returns its handle.
+func put(x interface{}) C.int {
+	lock.Lock()
+	defer lock.Unlock()
+	h := nextHandle
+	nextHandle++
+	objects[h] = x
+	return h
+}
+
+// get returns the object for a handle.
+func get(h C.int) interface{} {
+	lock.Lock()
+	defer lock.Unlock()
+	return objects[h]
+}
+
+// get3 returns the SDF3 for a handle.
+func get3(h C.int) (sdf.SDF3, error) {
+	if s, ok := get(h).(sdf.SDF3); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("handle %d is not a 3d shape", h)
+}
+
+// get2 returns the SDF2 for a handle.
+func get2(h C.int) (sdf.SDF2, error) {
+	if s, ok := get(h).(sdf.SDF2); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("handle %d is not a 2d shape", h)
+}
+
+// getMesh returns the mesh for a handle.
+func getMesh(h C.int) (*meshBuffer, error) {
+	if m, ok := get(h).(*meshBuffer); ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("handle %d is not a mesh", h)
+}
+
+// setError records the last error message.
+func setError(err error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if lastError != nil {
+		C.free(unsafe.Pointer(lastError))
+	}
+	lastError = C.CString(err.Error())
+}
+
+// guard recovers from a panic in an exported function, so it doesn't crash the host
+// process. The panic is recorded as the error and the function returns fail.
+func guard(rv *C.int, fail C.int) {
+	if r := recover(); r != nil {
+		setError(fmt.Errorf("panic: %v", r))
+		if rv != nil {
+			*rv = fail
+		}
+	}
+}
+
+// result returns the handle for an object, or 0 and records the error.
+func result(x interface{}, err error) C.int {
+	if err != nil {
+		setError(err)
+		return 0
+	}
+	return put(x)
+}
+
+// floatSlice returns a Go slice backed by a C float array.
+func floatSlice(p *C.float, n int) []float32 {
+	return (*[1 << 28]float32)(unsafe.Pointer(p))[:n:n]
+}
+
+//-----------------------------------------------------------------------------
+// errors
+
+//export sdfx_error
+func sdfx_error() *C.char {
+	lock.Lock()
+	defer lock.Unlock()
+	return lastError
+}
+
+//export sdfx_free
+func sdfx_free(h C.int) {
+	defer guard(nil, 0)
+	lock.Lock()
+	defer lock.Unlock()
+	delete(objects, h)
+}
+
+//-----------------------------------------------------------------------------
+// primitives
+
+//export sdfx_sphere
+func sdfx_sphere(r C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	return put(sdf.Sphere3D(float64(r)))
+}
+
+//export sdfx_box
+func sdfx_box(x, y, z, round C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	return put(sdf.Box3D(sdf.V3{X: float64(x), Y: float64(y), Z: float64(z)}, float64(round)))
+}
+
+//export sdfx_cylinder
+func sdfx_cylinder(height, radius, round C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	return put(sdf.Cylinder3D(float64(height), float64(radius), float64(round)))
+}
+
+//export sdfx_cone
+func sdfx_cone(height, r0, r1, round C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	return put(sdf.Cone3D(float64(height), float64(r0), float64(r1), float64(round)))
+}
+
+//export sdfx_circle
+func sdfx_circle(r C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	return put(sdf.Circle2D(float64(r)))
+}
+
+//export sdfx_rect
+func sdfx_rect(x, y, round C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	return put(sdf.Box2D(sdf.V2{X: float64(x), Y: float64(y)}, float64(round)))
+}
+
+// sdfx_polygon: xy is an array of n x,y pairs.
+//
+//export sdfx_polygon
+func sdfx_polygon(xy *C.double, n C.int) (rv C.int) {
+	defer guard(&rv, 0)
+	if n < 3 {
+		return result(nil, errors.New("polygon needs at least 3 points"))
+	}
+	a := (*[1 << 28]float64)(unsafe.Pointer(xy))[: 2*n : 2*n]
+	v := make([]sdf.V2, n)
+	for i := range v {
+		v[i] = sdf.V2{X: a[2*i], Y: a[2*i+1]}
+	}
+	return put(sdf.Polygon2D(v))
+}
+
+//-----------------------------------------------------------------------------
+// 2d to 3d
+
+//export sdfx_extrude
+func sdfx_extrude(h C.int, height C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get2(h)
+	if err != nil {
+		return result(nil, err)
+	}
+	return put(sdf.Extrude3D(s, float64(height)))
+}
+
+//export sdfx_revolve
+func sdfx_revolve(h C.int) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get2(h)
+	if err != nil {
+		return result(nil, err)
+	}
+	return put(sdf.Revolve3D(s))
+}
+
+//-----------------------------------------------------------------------------
+// booleans
+
+//export sdfx_union
+func sdfx_union(h0, h1 C.int) (rv C.int) {
+	defer guard(&rv, 0)
+	s0, err := get3(h0)
+	if err != nil {
+		return result(nil, err)
+	}
+	s1, err := get3(h1)
+	if err != nil {
+		return result(nil, err)
+	}
+	return put(sdf.Union3D(s0, s1))
+}
+
+//export sdfx_difference
+func sdfx_difference(h0, h1 C.int) (rv C.int) {
+	defer guard(&rv, 0)
+	s0, err := get3(h0)
+	if err != nil {
+		return result(nil, err)
+	}
+	s1, err := get3(h1)
+	if err != nil {
+		return result(nil, err)
+	}
+	return put(sdf.Difference3D(s0, s1))
+}
+
+//export sdfx_intersect
+func sdfx_intersect(h0, h1 C.int) (rv C.int) {
+	defer guard(&rv, 0)
+	s0, err := get3(h0)
+	if err != nil {
+		return result(nil, err)
+	}
+	s1, err := get3(h1)
+	if err != nil {
+		return result(nil, err)
+	}
+	return put(sdf.Intersect3D(s0, s1))
+}
+
+//-----------------------------------------------------------------------------
+// transforms
+
+//export sdfx_translate
+func sdfx_translate(h C.int, x, y, z C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get3(h)
+	if err != nil {
+		return result(nil, err)
+	}
+	v := sdf.V3{X: float64(x), Y: float64(y), Z: float64(z)}
+	return put(sdf.Transform3D(s, sdf.Translate3d(v)))
+}
+
+// sdfx_rotate: rotate by an angle (degrees) about an axis.
+//
+//export sdfx_rotate
+func sdfx_rotate(h C.int, x, y, z, angle C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get3(h)
+	if err != nil {
+		return result(nil, err)
+	}
+	v := sdf.V3{X: float64(x), Y: float64(y), Z: float64(z)}
+	return put(sdf.Transform3D(s, sdf.Rotate3d(v, sdf.DtoR(float64(angle)))))
+}
+
+//export sdfx_scale
+func sdfx_scale(h C.int, k C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get3(h)
+	if err != nil {
+		return result(nil, err)
+	}
+	return put(sdf.ScaleUniform3D(s, float64(k)))
+}
+
+//-----------------------------------------------------------------------------
+// evaluation
+
+// sdfx_evaluate: evaluate n points. xyz is an array of n x,y,z triples, d is an array of n distances.
+// Returns 0 on error.
+//
+//export sdfx_evaluate
+func sdfx_evaluate(h C.int, xyz *C.double, d *C.double, n C.int) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get3(h)
+	if err != nil {
+		setError(err)
+		return 0
+	}
+	p := (*[1 << 28]float64)(unsafe.Pointer(xyz))[: 3*n : 3*n]
+	out := (*[1 << 28]float64)(unsafe.Pointer(d))[:n:n]
+	for i := range out {
+		out[i] = s.Evaluate(sdf.V3{X: p[3*i], Y: p[3*i+1], Z: p[3*i+2]})
+	}
+	return 1
+}
+
+// sdfx_bounding_box: bb is an array of 6 doubles, min x,y,z then max x,y,z.
+// Returns 0 on error.
+//
+//export sdfx_bounding_box
+func sdfx_bounding_box(h C.int, bb *C.double) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get3(h)
+	if err != nil {
+		setError(err)
+		return 0
+	}
+	b := s.BoundingBox()
+	out := (*[6]float64)(unsafe.Pointer(bb))
+	*out = [6]float64{b.Min.X, b.Min.Y, b.Min.Z, b.Max.X, b.Max.Y, b.Max.Z}
+	return 1
+}
+
+//-----------------------------------------------------------------------------
+// meshing
+
+// sdfx_mesh: render a shape with cells on the longest axis. Returns a mesh handle.
+//
+//export sdfx_mesh
+func sdfx_mesh(h C.int, cells C.int) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get3(h)
+	if err != nil {
+		return result(nil, err)
+	}
+	if cells <= 0 {
+		return result(nil, errors.New("cells <= 0"))
+	}
+	v, n := sdf.MeshArrays(sdf.RenderMesh(s, int(cells)))
+	return put(&meshBuffer{vertices: v, normals: n})
+}
+
+// sdfx_mesh_triangles: returns the number of triangles in a mesh, or -1 on error.
+//
+//export sdfx_mesh_triangles
+func sdfx_mesh_triangles(h C.int) (rv C.int) {
+	defer guard(&rv, -1)
+	m, err := getMesh(h)
+	if err != nil {
+		setError(err)
+		return -1
+	}
+	return C.int(len(m.vertices) / 9)
+}
+
+// sdfx_mesh_vertices: copy the vertices (9 floats per triangle) to a buffer.
+// Returns the number of triangles, or -1 on error.
+//
+//export sdfx_mesh_vertices
+func sdfx_mesh_vertices(h C.int, out *C.float) (rv C.int) {
+	defer guard(&rv, -1)
+	m, err := getMesh(h)
+	if err != nil {
+		setError(err)
+		return -1
+	}
+	copy(floatSlice(out, len(m.vertices)), m.vertices)
+	return C.int(len(m.vertices) / 9)
+}
+
+// sdfx_mesh_normals: copy the normals (9 floats per triangle) to a buffer.
+// Returns the number of triangles, or -1 on error.
+//
+//export sdfx_mesh_normals
+func sdfx_mesh_normals(h C.int, out *C.float) (rv C.int) {
+	defer guard(&rv, -1)
+	m, err := getMesh(h)
+	if err != nil {
+		setError(err)
+		return -1
+	}
+	copy(floatSlice(out, len(m.normals)), m.normals)
+	return C.int(len(m.normals) / 9)
+}
+
+// sdfx_save_stl: render a shape to an STL file. Returns 0 on error.
+//
+//export sdfx_save_stl
+func sdfx_save_stl(h C.int, path *C.char, cells C.int) (rv C.int) {
+	defer guard(&rv, 0)
+	s, err := get3(h)
+	if err == nil && cells <= 0 {
+		err = errors.New("cells <= 0")
+	}
+	if err == nil {
+		err = sdf.SaveSTL(C.GoString(path), sdf.RenderMesh(s, int(cells)))
+	}
+	if err != nil {
+		setError(err)
+		return 0
+	}
+	return 1
+}
+
+//-----------------------------------------------------------------------------
+
+// main is required for a c-shared build.
+func main() {}
+
+//-----------------------------------------------------------------------------
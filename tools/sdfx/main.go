@@ -0,0 +1,182 @@
+//-----------------------------------------------------------------------------
+/*
+
+sdfx command line tool
+
+Usage: sdfx <command> [arguments]
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deadsy/sdfx/preview"
+	"github.com/deadsy/sdfx/script"
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"boolean", "apply a boolean operation to two STL meshes", cmdBoolean},
+	{"diff", "compare two STL meshes (hausdorff distance and volume)", cmdDiff},
+	{"script", "render or preview a Starlark model script", cmdScript},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: sdfx <command> [arguments]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.usage)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// cmdBoolean: sdfx boolean [-op union|difference|intersect] [-cells n] a.stl b.stl out.stl
+func cmdBoolean(args []string) error {
+	fs := flag.NewFlagSet("boolean", flag.ExitOnError)
+	opName := fs.String("op", "union", "boolean operation: union, difference or intersect")
+	cells := fs.Int("cells", 200, "number of cells on the longest axis")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: sdfx boolean [flags] a.stl b.stl out.stl\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 3 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	op, err := sdf.ParseBooleanOp(*opName)
+	if err != nil {
+		return err
+	}
+	return sdf.MeshBooleanSTL(fs.Arg(0), fs.Arg(1), op, *cells, fs.Arg(2))
+}
+
+//-----------------------------------------------------------------------------
+
+// cmdDiff: sdfx diff [-tol d] [-vol f] reference.stl model.stl
+func cmdDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	tol := fs.Float64("tol", 0.1, "maximum hausdorff distance")
+	vol := fs.Float64("vol", 0.01, "maximum volume change as a fraction")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: sdfx diff [flags] reference.stl model.stl\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	m0, err := sdf.LoadSTL(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	m1, err := sdf.LoadSTL(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	d, err := sdf.CompareMesh(m0, m1)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", d)
+	return d.Check(*tol, *vol)
+}
+
+//-----------------------------------------------------------------------------
+
+// paramFlags collects -D name=value parameter overrides.
+type paramFlags map[string]float64
+
+func (p paramFlags) String() string {
+	return fmt.Sprint(map[string]float64(p))
+}
+
+func (p paramFlags) Set(s string) error {
+	x := strings.SplitN(s, "=", 2)
+	if len(x) != 2 {
+		return fmt.Errorf("bad parameter \"%s\", want name=value", s)
+	}
+	v, err := strconv.ParseFloat(x[1], 64)
+	if err != nil {
+		return err
+	}
+	p[x[0]] = v
+	return nil
+}
+
+// cmdScript: sdfx script [-cells n] [-serve addr] [-D name=value] model.star [out.stl]
+func cmdScript(args []string) error {
+	fs := flag.NewFlagSet("script", flag.ExitOnError)
+	cells := fs.Int("cells", 200, "number of cells on the longest axis")
+	addr := fs.String("serve", "", "serve a live preview at this address, E.g. localhost:8000")
+	params := make(paramFlags)
+	fs.Var(params, "D", "set a model parameter, E.g. -D radius=5 (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: sdfx script [flags] model.star [out.stl]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 || fs.NArg() > 2 || (*addr == "" && fs.NArg() != 2) {
+		fs.Usage()
+		os.Exit(2)
+	}
+	s, err := script.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defaults, err := s.Params()
+	if err != nil {
+		return err
+	}
+	for k, v := range params {
+		defaults[k] = v
+	}
+	if *addr != "" {
+		// rebuild the model when the script is edited
+		return preview.ServeReload(*addr, defaults, s.Build, s.Changed)
+	}
+	model, err := s.Build(defaults)
+	if err != nil {
+		return err
+	}
+	return sdf.SaveSTL(fs.Arg(1), sdf.RenderMesh(model, *cells))
+}
+
+//-----------------------------------------------------------------------------
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			err := c.run(os.Args[2:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "sdfx %s: %s\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+//-----------------------------------------------------------------------------
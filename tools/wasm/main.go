@@ -0,0 +1,349 @@
+//go:build js && wasm
+// +build js,wasm
+
+//-----------------------------------------------------------------------------
+/*
+
+WebAssembly Bindings
+
+Exposes SDF construction and meshing to Javascript as the global "sdfx"
+object, for browser based configurators.
+
+Build:
+
+GOOS=js GOARCH=wasm go build -o sdfx.wasm
+cp $(go env GOROOT)/misc/wasm/wasm_exec.js .
+
+Use:
+
+const go = new Go();
+WebAssembly.instantiateStreaming(fetch("sdfx.wasm"), go.importObject).then(r => {
+  go.run(r.instance);
+  const s = sdfx.difference(sdfx.box(20, 20, 20, 2), sdfx.sphere(12));
+  const m = sdfx.mesh(s, 100);
+  // m.vertices and m.normals are Float32Arrays, 9 values per triangle
+});
+
+Shapes are referred to by integer handles. Functions return an Error object
+on failure. Call sdfx.free(h) to release shapes that are no longer needed.
+
+*/
+//-----------------------------------------------------------------------------
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/deadsy/sdfx/sdf"
+)
+
+//-----------------------------------------------------------------------------
+// handles
+
+var shapes = map[int]interface{}{}
+var nextHandle = 1
+
+// put stores a shape and returns its handle.
+func put(s interface{}) int {
+	h := nextHandle
+	nextHandle++
+	shapes[h] = s
+	return h
+}
+
+// get3 returns the SDF3 for a handle.
+func get3(v js.Value) (sdf.SDF3, error) {
+	if s, ok := shapes[v.Int()].(sdf.SDF3); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("handle %d is not a 3d shape", v.Int())
+}
+
+// get2 returns the SDF2 for a handle.
+func get2(v js.Value) (sdf.SDF2, error) {
+	if s, ok := shapes[v.Int()].(sdf.SDF2); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("handle %d is not a 2d shape", v.Int())
+}
+
+//-----------------------------------------------------------------------------
+// argument helpers
+
+// floats returns the float arguments.
+func floats(args []js.Value) []float64 {
+	x := make([]float64, len(args))
+	for i, a := range args {
+		x[i] = a.Float()
+	}
+	return x
+}
+
+// float32Array returns a Javascript Float32Array with a copy of a float32 slice.
+func float32Array(x []float32) js.Value {
+	buf := make([]byte, 4*len(x))
+	for i, v := range x {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	b := js.Global().Get("Uint8Array").New(len(buf))
+	js.CopyBytesToJS(b, buf)
+	return js.Global().Get("Float32Array").New(b.Get("buffer"))
+}
+
+// wrap converts a Go function into a Javascript function.
+// Errors and panics are returned as Javascript Error objects.
+func wrap(n int, fn func(args []js.Value) (interface{}, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) (rv interface{}) {
+		defer func() {
+			if r := recover(); r != nil {
+				rv = js.Global().Get("Error").New(fmt.Sprint(r))
+			}
+		}()
+		if len(args) < n {
+			return js.Global().Get("Error").New(fmt.Sprintf("expected %d arguments, got %d", n, len(args)))
+		}
+		x, err := fn(args)
+		if err != nil {
+			return js.Global().Get("Error").New(err.Error())
+		}
+		return x
+	})
+}
+
+//-----------------------------------------------------------------------------
+// primitives
+
+func sphere(args []js.Value) (interface{}, error) {
+	return put(sdf.Sphere3D(args[0].Float())), nil
+}
+
+func box(args []js.Value) (interface{}, error) {
+	x := floats(args)
+	round := 0.0
+	if len(x) > 3 {
+		round = x[3]
+	}
+	return put(sdf.Box3D(sdf.V3{x[0], x[1], x[2]}, round)), nil
+}
+
+func cylinder(args []js.Value) (interface{}, error) {
+	x := floats(args)
+	round := 0.0
+	if len(x) > 2 {
+		round = x[2]
+	}
+	return put(sdf.Cylinder3D(x[0], x[1], round)), nil
+}
+
+func cone(args []js.Value) (interface{}, error) {
+	x := floats(args)
+	round := 0.0
+	if len(x) > 3 {
+		round = x[3]
+	}
+	return put(sdf.Cone3D(x[0], x[1], x[2], round)), nil
+}
+
+func circle(args []js.Value) (interface{}, error) {
+	return put(sdf.Circle2D(args[0].Float())), nil
+}
+
+func rect(args []js.Value) (interface{}, error) {
+	x := floats(args)
+	round := 0.0
+	if len(x) > 2 {
+		round = x[2]
+	}
+	return put(sdf.Box2D(sdf.V2{x[0], x[1]}, round)), nil
+}
+
+func polygon(args []js.Value) (interface{}, error) {
+	// flat array of x,y pairs
+	a := args[0]
+	n := a.Length()
+	if n < 6 || n%2 != 0 {
+		return nil, errors.New("polygon needs at least 3 x,y pairs")
+	}
+	v := make([]sdf.V2, n/2)
+	for i := range v {
+		v[i] = sdf.V2{a.Index(2 * i).Float(), a.Index(2*i + 1).Float()}
+	}
+	return put(sdf.Polygon2D(v)), nil
+}
+
+//-----------------------------------------------------------------------------
+// 2d to 3d
+
+func extrude(args []js.Value) (interface{}, error) {
+	s, err := get2(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return put(sdf.Extrude3D(s, args[1].Float())), nil
+}
+
+func revolve(args []js.Value) (interface{}, error) {
+	s, err := get2(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return put(sdf.Revolve3D(s)), nil
+}
+
+//-----------------------------------------------------------------------------
+// booleans
+
+func union(args []js.Value) (interface{}, error) {
+	var s []sdf.SDF3
+	for _, a := range args {
+		x, err := get3(a)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, x)
+	}
+	return put(sdf.Union3D(s...)), nil
+}
+
+func difference(args []js.Value) (interface{}, error) {
+	s0, err := get3(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s1, err := get3(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return put(sdf.Difference3D(s0, s1)), nil
+}
+
+func intersect(args []js.Value) (interface{}, error) {
+	s0, err := get3(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s1, err := get3(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return put(sdf.Intersect3D(s0, s1)), nil
+}
+
+//-----------------------------------------------------------------------------
+// transforms
+
+func translate(args []js.Value) (interface{}, error) {
+	s, err := get3(args[0])
+	if err != nil {
+		return nil, err
+	}
+	x := floats(args[1:4])
+	return put(sdf.Transform3D(s, sdf.Translate3d(sdf.V3{x[0], x[1], x[2]}))), nil
+}
+
+func rotate(args []js.Value) (interface{}, error) {
+	// rotate(h, ax, ay, az, degrees)
+	s, err := get3(args[0])
+	if err != nil {
+		return nil, err
+	}
+	x := floats(args[1:5])
+	return put(sdf.Transform3D(s, sdf.Rotate3d(sdf.V3{x[0], x[1], x[2]}, sdf.DtoR(x[3])))), nil
+}
+
+func scale(args []js.Value) (interface{}, error) {
+	s, err := get3(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return put(sdf.ScaleUniform3D(s, args[1].Float())), nil
+}
+
+//-----------------------------------------------------------------------------
+// evaluation and meshing
+
+func evaluate(args []js.Value) (interface{}, error) {
+	s, err := get3(args[0])
+	if err != nil {
+		return nil, err
+	}
+	x := floats(args[1:4])
+	return s.Evaluate(sdf.V3{x[0], x[1], x[2]}), nil
+}
+
+func bbox(args []js.Value) (interface{}, error) {
+	s, err := get3(args[0])
+	if err != nil {
+		return nil, err
+	}
+	bb := s.BoundingBox()
+	return []interface{}{bb.Min.X, bb.Min.Y, bb.Min.Z, bb.Max.X, bb.Max.Y, bb.Max.Z}, nil
+}
+
+func mesh(args []js.Value) (interface{}, error) {
+	s, err := get3(args[0])
+	if err != nil {
+		return nil, err
+	}
+	cells := 100
+	if len(args) > 1 {
+		cells = args[1].Int()
+	}
+	if cells <= 0 {
+		return nil, errors.New("cells <= 0")
+	}
+	m := sdf.RenderMesh(s, cells)
+	v, n := sdf.MeshArrays(m)
+	return map[string]interface{}{
+		"triangles": len(m),
+		"vertices":  float32Array(v),
+		"normals":   float32Array(n),
+	}, nil
+}
+
+func free(args []js.Value) (interface{}, error) {
+	for _, a := range args {
+		delete(shapes, a.Int())
+	}
+	return nil, nil
+}
+
+//-----------------------------------------------------------------------------
+
+func main() {
+	fns := map[string]js.Func{
+		"sphere":     wrap(1, sphere),
+		"box":        wrap(3, box),
+		"cylinder":   wrap(2, cylinder),
+		"cone":       wrap(3, cone),
+		"circle":     wrap(1, circle),
+		"rect":       wrap(2, rect),
+		"polygon":    wrap(1, polygon),
+		"extrude":    wrap(2, extrude),
+		"revolve":    wrap(1, revolve),
+		"union":      wrap(1, union),
+		"difference": wrap(2, difference),
+		"intersect":  wrap(2, intersect),
+		"translate":  wrap(4, translate),
+		"rotate":     wrap(5, rotate),
+		"scale":      wrap(2, scale),
+		"evaluate":   wrap(4, evaluate),
+		"bbox":       wrap(1, bbox),
+		"mesh":       wrap(1, mesh),
+		"free":       wrap(0, free),
+	}
+	obj := js.Global().Get("Object").New()
+	for k, f := range fns {
+		obj.Set(k, f)
+	}
+	js.Global().Set("sdfx", obj)
+	// keep the bindings alive
+	select {}
+}
+
+//-----------------------------------------------------------------------------